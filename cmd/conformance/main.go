@@ -0,0 +1,219 @@
+/*
+Command conformance runs the checks of the conformance package against the
+storage backends of the server and reports those that fail.
+
+	conformance [memory] [sqlite] [postgres] [flags]
+
+The memory and sqlite backends are checked by default, each check getting a
+fresh store or database file of its own. The postgres backend is only checked
+when asked for: it's the database configured by the flags, which are the same
+as the server's, and everything in it is dropped before each check.
+sqlite needs the sqlite_fts5 build tag.
+*/
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/config"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/conformance"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/inmemory"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres/migrations"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite"
+	sqlitemigrations "github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite/migrations"
+
+	_ "github.com/lib/pq"
+)
+
+const usage = `usage: %s [memory] [sqlite] [postgres] [flags]
+  memory     checks the in memory repositories
+  sqlite     checks the sqlite repositories, against temporary database files
+  postgres   checks the postgres repositories against the configured database,
+             DROPPING EVERYTHING IN IT before each check
+memory and sqlite are checked if no backend is given
+flags are the same as the server's, see -help
+`
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+	backends := []string{"memory", "sqlite"}
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		backends = nil
+		for len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+			backends, args = append(backends, args[0]), args[1:]
+		}
+	}
+	conf, err := config.Load(args)
+	if err == flag.ErrHelp {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		return
+	}
+	if err != nil {
+		logger.Fatalf("loading config failed because: %v", err)
+	}
+
+	failed := false
+	for _, backend := range backends {
+		var newRepos conformance.Factory
+		switch backend {
+		case "memory":
+			newRepos = newMemoryRepositories
+		case "sqlite":
+			newRepos = newSQLiteRepositories
+		case "postgres":
+			var release func()
+			newRepos, release, err = newPostgresFactory(conf)
+			if err != nil {
+				logger.Fatalf("connecting to postgres failed because: %v", err)
+			}
+			defer release()
+		default:
+			fmt.Fprintf(os.Stderr, usage, os.Args[0])
+			logger.Fatalf("unknown backend %q", backend)
+		}
+		if !run(backend, newRepos, logger) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run runs all the checks against the repositories returned by newRepos and
+// reports how they went. It returns false if any of them failed.
+func run(backend string, newRepos conformance.Factory, logger *log.Logger) bool {
+	start := time.Now()
+	checks := conformance.Checks()
+	failures := 0
+	for _, check := range checks {
+		reported := check.Exec(newRepos)
+		if len(reported) == 0 {
+			continue
+		}
+		failures++
+		logger.Printf("FAIL %s %s", backend, check.Name)
+		for _, failure := range reported {
+			logger.Printf("    %s", failure)
+		}
+	}
+	if failures > 0 {
+		logger.Printf("FAIL %s: %d of %d checks failed in %v", backend, failures, len(checks), time.Since(start).Round(time.Millisecond))
+		return false
+	}
+	logger.Printf("ok   %s: %d checks passed in %v", backend, len(checks), time.Since(start).Round(time.Millisecond))
+	return true
+}
+
+// newMemoryRepositories returns in memory repositories over a new store.
+func newMemoryRepositories() (*conformance.Repositories, func(), error) {
+	store := inmemory.NewStore()
+	return &conformance.Repositories{
+		User:    inmemory.NewUserRepository(store),
+		Channel: inmemory.NewChannelRepository(store),
+		Feed:    inmemory.NewFeedRepository(store),
+		Release: inmemory.NewReleaseRepository(store),
+		Post:    inmemory.NewPostRepository(store),
+		Comment: inmemory.NewCommentRepository(store),
+		Search:  inmemory.NewSearchRepository(store),
+		Auth:    inmemory.NewAuthRepository(store),
+
+		UnitOfWork: inmemory.NewUnitOfWork(store),
+	}, func() {}, nil
+}
+
+// newSQLiteRepositories returns sqlite repositories over a new, migrated
+// database file in a temporary directory that's removed on release.
+func newSQLiteRepositories() (*conformance.Repositories, func(), error) {
+	dir, err := os.MkdirTemp("", "conformance")
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := sqlite.Open(filepath.Join(dir, "issue1.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	release := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+	migrator, err := sqlitemigrations.NewMigrator(db, log.New(io.Discard, "", 0))
+	if err == nil {
+		_, err = migrator.Up()
+	}
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("migrating database failed because: %w", err)
+	}
+
+	dbRepos := make(map[string]interface{})
+	repos := &conformance.Repositories{
+		User:    sqlite.NewUserRepository(db, &dbRepos),
+		Channel: sqlite.NewChannelRepository(db, &dbRepos),
+		Feed:    sqlite.NewFeedRepository(db, &dbRepos),
+		Release: sqlite.NewReleaseRepository(db, &dbRepos),
+		Post:    sqlite.NewPostRepository(db, &dbRepos),
+		Comment: sqlite.NewCommentRepository(db, &dbRepos),
+		Search:  sqlite.NewSearchRepository(db, &dbRepos),
+		Auth:    sqlite.NewAuthRepository(db, &dbRepos),
+
+		UnitOfWork: sqlite.NewUnitOfWork(db),
+	}
+	repos.Register(dbRepos)
+	return repos, release, nil
+}
+
+// newPostgresFactory connects to the configured database and returns a factory
+// of postgres repositories over it. The schema is rolled back and applied anew
+// every time repositories are asked for, so that checks start out empty.
+// The connection is closed on release.
+func newPostgresFactory(conf *config.Config) (conformance.Factory, func(), error) {
+	db, err := sql.Open("postgres", conf.Database.DataSourceName())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	migrator, err := migrations.NewMigrator(db, log.New(io.Discard, "", 0))
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	newRepos := func() (*conformance.Repositories, func(), error) {
+		if _, err := migrator.Down(math.MaxInt32); err != nil {
+			return nil, nil, fmt.Errorf("rolling back database failed because: %w", err)
+		}
+		if _, err := migrator.Up(); err != nil {
+			return nil, nil, fmt.Errorf("migrating database failed because: %w", err)
+		}
+		dbRepos := make(map[string]interface{})
+		repos := &conformance.Repositories{
+			User:    postgres.NewUserRepository(db, &dbRepos),
+			Channel: postgres.NewChannelRepository(db, &dbRepos),
+			Feed:    postgres.NewFeedRepository(db, &dbRepos),
+			Release: postgres.NewReleaseRepository(db, &dbRepos),
+			Post:    postgres.NewPostRepository(db, &dbRepos),
+			Comment: postgres.NewCommentRepository(db, &dbRepos),
+			Search:  postgres.NewSearchRepository(db, &dbRepos),
+			Auth:    postgres.NewAuthRepository(db, &dbRepos),
+
+			UnitOfWork: postgres.NewUnitOfWork(db),
+		}
+		repos.Register(dbRepos)
+		return repos, func() {}, nil
+	}
+	return newRepos, func() { db.Close() }, nil
+}
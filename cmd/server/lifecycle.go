@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// lifecycle keeps the cleanup functions registered during startup so that
+// they're run on shutdown, in reverse order of registration like defers.
+// The database pool is registered first so that it's closed last, after
+// the caches and background jobs that use it.
+type lifecycle struct {
+	logger *log.Logger
+	hooks  []shutdownHook
+	once   sync.Once
+}
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// OnShutdown registers the function to be called on shutdown under the given name.
+func (l *lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.hooks = append(l.hooks, shutdownHook{name, fn})
+}
+
+// Shutdown calls the registered functions, in reverse order, once.
+// Failures are logged and don't keep the rest from running.
+func (l *lifecycle) Shutdown(ctx context.Context) {
+	l.once.Do(func() {
+		for i := len(l.hooks) - 1; i >= 0; i-- {
+			hook := l.hooks[i]
+			if err := hook.fn(ctx); err != nil {
+				l.logger.Printf("shutting down %s failed because: %v", hook.name, err)
+				continue
+			}
+			l.logger.Printf("%s shut down", hook.name)
+		}
+	})
+}
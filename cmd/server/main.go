@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
-	"database/sql"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	"github.com/slim-crown/issue-1-REST/pkg/config"
 	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest"
 	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
@@ -17,11 +22,13 @@ import (
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+	"github.com/slim-crown/issue-1-REST/pkg/services/mail"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 	"github.com/slim-crown/issue-1-REST/pkg/services/search"
 
-	"github.com/slim-crown/issue-1-REST/pkg/repositories/memory"
-	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres"
-
 	_ "github.com/lib/pq"
 )
 
@@ -64,122 +71,154 @@ func (logger *logger) Log(format string, a ...interface{}) {
 func main() {
 	setup := rest.Setup{}
 	setup.Logger = log.New(os.Stdout, "", log.Lmicroseconds|log.Lshortfile)
-	var db *sql.DB
-	{
-		var err error
-		const (
-			host     = "localhost"
-			port     = "5432"
-			dbname   = "issue#1_db"
-			role     = "issue#1_dev"
-			password = "password1234!@#$"
-		)
-		dataSourceName := fmt.Sprintf(
-			`host=%s port=%s dbname='%s' user='%s' password='%s' sslmode=disable`,
-			host, port, dbname, role, password)
-
-		db, err = sql.Open("postgres", dataSourceName)
-		if err != nil {
-			setup.Logger.Fatalf("database connection failed because: %s", err.Error())
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], setup.Logger); err != nil {
+			setup.Logger.Fatalf("migrating failed because: %v", err)
 		}
-		defer db.Close()
+		return
+	}
+	conf, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		setup.Logger.Fatalf("loading config failed because: %v", err)
+	}
 
-		if err = db.Ping(); err != nil {
-			setup.Logger.Fatalf("database ping failed because: %s", err.Error())
-		}
+	lc := &lifecycle{logger: setup.Logger}
+
+	var repos *repositories
+	switch conf.Storage {
+	case config.StorageMemory:
+		setup.Logger.Printf("storage is %s, nothing will be persisted", conf.Storage)
+		repos = newMemoryRepositories(setup.Logger)
+	case config.StorageSQLite:
+		repos = newSQLiteRepositories(conf, setup.Logger, lc)
+	default:
+		repos = newPostgresRepositories(conf, setup.Logger, lc)
 	}
 
 	services := make(map[string]interface{})
-	cacheRepos := make(map[string]interface{})
-	dbRepos := make(map[string]interface{})
 
 	{
-		{
-			var channelDBRepo = postgres.NewChannelRepository(db, &dbRepos)
-			dbRepos["Channel"] = &channelDBRepo
-			var channelCacheRepo = memory.NewChannelRepository(&channelDBRepo, &cacheRepos)
-			cacheRepos["Channel"] = &channelCacheRepo
-			setup.ChannelService = channel.NewService(&channelCacheRepo, &services)
-			services["Channel"] = &setup.ChannelService
-		}
-		{
-			var usrDBRepo = postgres.NewUserRepository(db, &dbRepos)
-			dbRepos["User"] = &usrDBRepo
-			var usrCacheRepo = memory.NewUserRepository(&usrDBRepo, &cacheRepos)
-			cacheRepos["User"] = &usrCacheRepo
-			setup.UserService = user.NewService(&usrCacheRepo, &services)
-			services["User"] = &setup.UserService
-		}
-		{
-			var feedDBRepo = postgres.NewFeedRepository(db, &dbRepos)
-			dbRepos["Feed"] = &feedDBRepo
-			var feedCacheRepo = memory.NewFeedRepository(&feedDBRepo, &cacheRepos)
-			cacheRepos["Feed"] = &feedCacheRepo
-			setup.FeedService = feed.NewService(&feedCacheRepo, &services)
-			services["Feed"] = &setup.FeedService
-		}
-		{
-			var releaseDBRepo = postgres.NewReleaseRepository(db, &dbRepos)
-			dbRepos["Release"] = &releaseDBRepo
-			var releaseCacheRepo = memory.NewReleaseRepository(&releaseDBRepo)
-			cacheRepos["Release"] = &releaseCacheRepo
-			setup.ReleaseService = release.NewService(&releaseCacheRepo)
-			services["Release"] = &setup.ReleaseService
-		}
-		{
-			var postDBRepo = postgres.NewPostRepository(db, &dbRepos)
-			dbRepos["Post"] = &postDBRepo
-			var postCacheRepo = memory.NewPostRepository(&postDBRepo)
-			cacheRepos["Post"] = &postCacheRepo
-			setup.PostService = post.NewService(&postCacheRepo)
-			services["Post"] = &setup.PostService
-		}
-		{
-			var commentDBRepo = postgres.NewCommentRepository(db, &dbRepos)
-			dbRepos["Comment"] = &commentDBRepo
-			var commentCacheRepo = memory.NewCommentRepository(&commentDBRepo)
-			cacheRepos["Comment"] = &commentCacheRepo
-			setup.CommentService = comment.NewService(&commentCacheRepo)
-			services["Comment"] = &setup.CommentService
-		}
-		{
-			var searchDBRepo = postgres.NewSearchRepository(db, &dbRepos)
-			dbRepos["Search"] = &searchDBRepo
-			setup.SearchService = search.NewService(&searchDBRepo)
-			services["Search"] = &setup.SearchService
-		}
+		setup.ChannelService = channel.NewService(&repos.channel, &services)
+		services["Channel"] = &setup.ChannelService
+		setup.UserService = user.NewService(&repos.user, &services)
+		services["User"] = &setup.UserService
+		setup.FeedService = feed.NewService(&repos.feed, &services)
+		services["Feed"] = &setup.FeedService
+		setup.ReleaseService = release.NewService(&repos.release, &services)
+		services["Release"] = &setup.ReleaseService
+		setup.PostService = post.NewService(&repos.post, &services)
+		services["Post"] = &setup.PostService
+		setup.CommentService = comment.NewService(&repos.comment, &services)
+		services["Comment"] = &setup.CommentService
+		setup.SearchService = search.NewService(&repos.search)
+		services["Search"] = &setup.SearchService
+		setup.UnitOfWork = repos.unitOfWork
 	}
 
-	setup.ImageServingRoute = "/images/"
-	setup.ImageStoragePath = "data/images"
-	setup.HostAddress = "localhost"
-	setup.Port = "8080"
+	setup.ImageServingRoute = conf.Images.ServingRoute
+	setup.ImageURLLifetime = conf.Images.URLLifetime
+	setup.ImageStorage, err = newImageStorage(conf)
+	if err != nil {
+		setup.Logger.Fatalf("setting up image storage failed because: %v", err)
+	}
+	setup.Images.JPEGQuality = conf.Images.JPEGQuality
+	setup.Images.MaxPixels = conf.Images.MaxPixels
+	setup.Images.MaxBytes = conf.Images.MaxBytes
+	setup.Images.MaxConcurrent = conf.Images.MaxConcurrent
+	setup.ImageProcessor = imaging.NewProcessor(setup.Images)
+	setup.HostAddress = conf.Server.Host
+	setup.Port = strconv.Itoa(conf.Server.Port)
 
 	setup.HostAddress += ":" + setup.Port
+	setup.HTTPS = conf.Server.HTTPS
+	setup.QueryTimeout = conf.Server.QueryTimeout
 
 	// setup.StrictSanitizer = bluemonday.StrictPolicy()
 	// setup.MarkupSanitizer = bluemonday.UGCPolicy()
 	// setup.MarkupSanitizer.AllowAttrs("class").Matching(regexp.MustCompile("^language-[a-zA-Z0-9]+$")).OnElements("code")
 
-	setup.TokenSigningSecret = []byte("secret")
-	setup.TokenAccessLifetime = 15 * time.Minute
-	setup.TokenRefreshLifetime = 7 * 24 * time.Hour
-
 	{
-		var authDBRepo = postgres.NewAuthRepository(db, &dbRepos)
-		dbRepos["Auth"] = &authDBRepo
-		var authCacheRepo = memory.NewAuthRepository(&authDBRepo)
-		cacheRepos["Auth"] = &authCacheRepo
-		setup.AuthService = auth.NewAuthService(&authCacheRepo,
-			setup.TokenAccessLifetime,
-			setup.TokenRefreshLifetime,
-			setup.TokenSigningSecret)
-		services["Auth"] = &setup.AuthService
+		// keys are listed in a manifest, see auth.LoadKeySet, add the next key to it ahead of
+		// time so that it's published on /.well-known/jwks.json before it starts signing
+		ephemeral := conf.Auth.EphemeralSigningKey || conf.Storage == config.StorageMemory
+		if ephemeral {
+			setup.Logger.Printf("signing tokens with an ephemeral key, they won't be accepted after a restart")
+			setup.TokenSigningKeys, err = auth.NewEphemeralKeySet()
+		} else {
+			setup.TokenSigningKeys, err = auth.LoadKeySet(conf.Auth.SigningKeyManifest)
+		}
+		if err != nil {
+			setup.Logger.Fatalf("loading signing keys failed because: %v", err)
+		}
+		if !ephemeral {
+			reloadKeySet(setup.TokenSigningKeys, conf.Auth.SigningKeyReloadInterval, setup.Logger, lc)
+		}
+	}
+	setup.TokenAccessLifetime = conf.Auth.AccessTokenLifetime
+	setup.TokenRefreshLifetime = conf.Auth.RefreshTokenLifetime
+	setup.PasswordResetLifetime = conf.Auth.PasswordResetLifetime
+	setup.EmailVerificationLifetime = conf.Auth.EmailVerificationLifetime
+	setup.EmailVerificationResendInterval = conf.Auth.EmailVerificationResendInterval
+	setup.TOTPIssuer = conf.Auth.TOTPIssuer
+	setup.TOTPChallengeLifetime = conf.Auth.TOTPChallengeLifetime
+
+	var mailer mail.Mailer
+	switch {
+	case conf.Mail.LogOnly || (conf.Mail.SMTPHost == "" && conf.Storage == config.StorageMemory):
+		setup.Logger.Printf("mails are only logged, none will be sent")
+		mailer = mail.NewLogMailer(setup.Logger)
+	case conf.Mail.SMTPHost == "":
+		// checked here rather than in config.Validate since migrate sends no mails
+		setup.Logger.Fatalf("mail.smtpHost is required unless mail.logOnly is set")
+	default:
+		mailer = mail.NewSMTPMailer(conf.Mail.SMTPHost, conf.Mail.SMTPPort, conf.Mail.Username, conf.Mail.Password, conf.Mail.From)
 	}
 
+	setup.AuthService = auth.NewAuthService(&repos.auth, mailer, setup.Config.Config)
+	services["Auth"] = &setup.AuthService
+
+	setup.Lockout.MaxUsernameFailures = conf.Lockout.MaxUsernameFailures
+	setup.Lockout.MaxIPFailures = conf.Lockout.MaxIPFailures
+	setup.Lockout.FailureWindow = conf.Lockout.FailureWindow
+	setup.Lockout.BaseLockout = conf.Lockout.BaseLockout
+	setup.Lockout.MaxLockout = conf.Lockout.MaxLockout
+
+	setup.LockoutService = lockout.NewService(&repos.lockout, setup.Lockout)
+	services["Lockout"] = &setup.LockoutService
+
+	setup.OAuth.Issuer = conf.Server.Address()
+	setup.OAuth.AuthorizationCodeLifetime = conf.OAuth.AuthorizationCodeLifetime
+
+	setup.OAuthService = oauth.NewService(&repos.oauth, setup.AuthService, setup.UserService, setup.TokenSigningKeys, setup.OAuth)
+	services["OAuth"] = &setup.OAuthService
+
+	setup.ModeratorUsernames = conf.ModeratorUsernames
+	setup.PolicyService = policy.NewService(policy.DefaultRules, policy.DefaultVerifiedOnly, policy.DefaultScopes, setup.ModeratorUsernames)
+	services["Policy"] = &setup.PolicyService
+
 	mux := rest.NewMux(&setup)
 
-	setup.Logger.Printf("server running...")
+	if setup.HTTPS {
+		setup.HostAddress = "https://" + setup.HostAddress
+	} else {
+		setup.HostAddress = "http://" + setup.HostAddress
+	}
+
+	server := &http.Server{
+		Addr:              ":" + setup.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+		ReadTimeout:       conf.Server.ReadTimeout,
+		WriteTimeout:      conf.Server.WriteTimeout,
+		IdleTimeout:       conf.Server.IdleTimeout,
+		ErrorLog:          setup.Logger,
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	// command line ui
 	go func() {
@@ -187,21 +226,74 @@ func main() {
 		for scanner.Scan() {
 			switch scanner.Text() {
 			case "k":
-				log.Fatalln("shutting server down...")
+				stop <- os.Interrupt
 			default:
 				fmt.Println("unknown command")
 			}
 		}
 	}()
 
-	setup.HTTPS = false
+	serverErr := make(chan error, 1)
+	go func() {
+		setup.Logger.Printf("server running on %s...", setup.HostAddress)
+		if setup.HTTPS {
+			serverErr <- server.ListenAndServeTLS(conf.Server.CertFile, conf.Server.KeyFile)
+		} else {
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case sig := <-stop:
+		setup.Logger.Printf("received %v, shutting server down...", sig)
+	case err := <-serverErr:
+		setup.Logger.Printf("server stopped because: %v", err)
+	}
 
-	if setup.HTTPS {
-		setup.HostAddress = "https://" + setup.HostAddress
-		log.Fatal(http.ListenAndServeTLS(":"+setup.Port, "cmd/server/cert.pem", "cmd/server/key.pem", mux))
-	} else {
-		setup.HostAddress = "http://" + setup.HostAddress
-		log.Fatal(http.ListenAndServe(":"+setup.Port, mux))
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	defer cancel()
+	// stop accepting connections and wait for in-flight requests before
+	// pulling the caches and the database out from under them
+	if err := server.Shutdown(ctx); err != nil {
+		setup.Logger.Printf("draining connections failed because: %v, closing them", err)
+		server.Close()
 	}
+	lc.Shutdown(ctx)
+	setup.Logger.Printf("server shut down")
+}
 
+// reloadKeySet reloads the keys from their manifest every interval, unless it's
+// zero, and whenever the process receives SIGHUP until shutdown. A manifest
+// that fails to load is logged and the keys are left as they were.
+func reloadKeySet(keys *auth.KeySet, interval time.Duration, logger *log.Logger, lc *lifecycle) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	ticker := &time.Ticker{}
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-hangup:
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			if err := keys.Reload(); err != nil {
+				logger.Printf("reloading signing keys failed because: %v", err)
+				continue
+			}
+			logger.Printf("signing keys reloaded")
+		}
+	}()
+	lc.OnShutdown("signing key reloading", func(context.Context) error {
+		signal.Stop(hangup)
+		if interval > 0 {
+			ticker.Stop()
+		}
+		close(done)
+		return nil
+	})
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/slim-crown/issue-1-REST/pkg/config"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres/migrations"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite"
+	sqlitemigrations "github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite/migrations"
+)
+
+const migrateUsage = `usage: %s migrate up|down [steps]|status [flags]
+  up       applies all pending migrations
+  down     rolls back the last applied migration, or the given number of them
+  status   lists the migrations and when they were applied
+flags are the same as the server's, see -help
+`
+
+// schemaMigrator is what the migrate subcommand needs of the migrators of the
+// postgres and sqlite storages.
+type schemaMigrator interface {
+	Up() (int, error)
+	Down(steps int) (int, error)
+	Status() ([]*migrations.Status, error)
+}
+
+// runMigrate runs the migrate subcommand with the given arguments, the ones
+// following "migrate" on the command line.
+func runMigrate(args []string, logger *log.Logger) error {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return fmt.Errorf("no migrate command given")
+	}
+	command, args := args[0], args[1:]
+	steps := 1
+	if command == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n < 1 {
+				return fmt.Errorf("steps must be positive, got %d", n)
+			}
+			steps, args = n, args[1:]
+		}
+	}
+
+	conf, err := config.Load(args)
+	if err == flag.ErrHelp {
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("loading config failed because: %w", err)
+	}
+	var db *sql.DB
+	var migrator schemaMigrator
+	switch conf.Storage {
+	case config.StoragePostgres:
+		db, err = sql.Open("postgres", conf.Database.DataSourceName())
+		if err != nil {
+			return fmt.Errorf("database connection failed because: %w", err)
+		}
+		defer db.Close()
+		migrator, err = migrations.NewMigrator(db, logger)
+	case config.StorageSQLite:
+		db, err = sqlite.Open(conf.SQLite.Path)
+		if err != nil {
+			return fmt.Errorf("opening database failed because: %w", err)
+		}
+		defer db.Close()
+		migrator, err = sqlitemigrations.NewMigrator(db, logger)
+	default:
+		return fmt.Errorf("storage is %s, there's no database to migrate", conf.Storage)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		n, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		logger.Printf("%d migrations applied", n)
+	case "down":
+		n, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		logger.Printf("%d migrations rolled back", n)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+	return nil
+}
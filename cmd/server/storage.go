@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sort"
+
+	"github.com/slim-crown/issue-1-REST/pkg/config"
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage"
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/inmemory"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/memory"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres/migrations"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite"
+	sqlitemigrations "github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite/migrations"
+)
+
+// repositories holds the implementations of the Repository interfaces the
+// services are built on, those of the configured storage.
+type repositories struct {
+	channel channel.Repository
+	user    user.Repository
+	feed    feed.Repository
+	release release.Repository
+	post    post.Repository
+	comment comment.Repository
+	search  search.Repository
+	auth    auth.Repository
+	lockout lockout.Repository
+	oauth   oauth.Repository
+
+	unitOfWork unitofwork.UnitOfWork
+}
+
+// newMemoryRepositories returns repositories that keep everything in memory.
+// Nothing survives a restart and nothing's shared with other instances.
+func newMemoryRepositories(logger *log.Logger) *repositories {
+	store := inmemory.NewStore()
+	return &repositories{
+		channel: inmemory.NewChannelRepository(store),
+		user:    inmemory.NewUserRepository(store),
+		feed:    inmemory.NewFeedRepository(store),
+		release: inmemory.NewReleaseRepository(store),
+		post:    inmemory.NewPostRepository(store),
+		comment: inmemory.NewCommentRepository(store),
+		search:  inmemory.NewSearchRepository(store),
+		auth:    inmemory.NewAuthRepository(store),
+		lockout: memory.NewLockoutRepository(logger),
+		oauth:   inmemory.NewOAuthRepository(store),
+
+		unitOfWork: inmemory.NewUnitOfWork(store),
+	}
+}
+
+// newPostgresRepositories connects to the database and returns repositories
+// that keep everything in it, cached in memory. The connection, the caches and
+// the invalidation listener are registered to be closed on shutdown.
+func newPostgresRepositories(conf *config.Config, logger *log.Logger, lc *lifecycle) *repositories {
+	var db *sql.DB
+	{
+		var err error
+		db, err = sql.Open("postgres", conf.Database.DataSourceName())
+		if err != nil {
+			logger.Fatalf("database connection failed because: %s", err.Error())
+		}
+		lc.OnShutdown("database pool", func(context.Context) error {
+			return db.Close()
+		})
+
+		if err = db.Ping(); err != nil {
+			logger.Fatalf("database ping failed because: %s", err.Error())
+		}
+
+		if conf.Database.AutoMigrate {
+			migrator, err := migrations.NewMigrator(db, logger)
+			if err == nil {
+				_, err = migrator.Up()
+			}
+			if err != nil {
+				logger.Fatalf("migrating database failed because: %v", err)
+			}
+		}
+	}
+
+	repos := new(repositories)
+	cacheRepos := make(map[string]interface{})
+	dbRepos := make(map[string]interface{})
+	// cache repos that keep entries report their hits and misses on shutdown
+	cacheStats := make(map[string]memory.StatsReporter)
+	cacheOptions := memory.CacheOptions{Capacity: conf.Cache.Capacity, TTL: conf.Cache.TTL}
+
+	{
+		var channelDBRepo = postgres.NewChannelRepository(db, &dbRepos)
+		dbRepos["Channel"] = &channelDBRepo
+		repos.channel = memory.NewChannelRepository(&channelDBRepo, &cacheRepos, cacheOptions)
+		cacheRepos["Channel"] = &repos.channel
+		cacheStats["Channel"] = repos.channel.(memory.StatsReporter)
+	}
+	{
+		var usrDBRepo = postgres.NewUserRepository(db, &dbRepos)
+		dbRepos["User"] = &usrDBRepo
+		repos.user = memory.NewUserRepository(&usrDBRepo, &cacheRepos, cacheOptions)
+		cacheRepos["User"] = &repos.user
+		cacheStats["User"] = repos.user.(memory.StatsReporter)
+	}
+	{
+		var feedDBRepo = postgres.NewFeedRepository(db, &dbRepos)
+		dbRepos["Feed"] = &feedDBRepo
+		repos.feed = memory.NewFeedRepository(&feedDBRepo, &cacheRepos, cacheOptions)
+		cacheRepos["Feed"] = &repos.feed
+		cacheStats["Feed"] = repos.feed.(memory.StatsReporter)
+	}
+	{
+		var releaseDBRepo = postgres.NewReleaseRepository(db, &dbRepos)
+		dbRepos["Release"] = &releaseDBRepo
+		repos.release = memory.NewReleaseRepository(&releaseDBRepo, cacheOptions)
+		cacheRepos["Release"] = &repos.release
+		cacheStats["Release"] = repos.release.(memory.StatsReporter)
+	}
+	{
+		var postDBRepo = postgres.NewPostRepository(db, &dbRepos)
+		dbRepos["Post"] = &postDBRepo
+		repos.post = memory.NewPostRepository(&postDBRepo, cacheOptions)
+		cacheRepos["Post"] = &repos.post
+		cacheStats["Post"] = repos.post.(memory.StatsReporter)
+	}
+	{
+		var commentDBRepo = postgres.NewCommentRepository(db, &dbRepos)
+		dbRepos["Comment"] = &commentDBRepo
+		repos.comment = memory.NewCommentRepository(&commentDBRepo, cacheOptions)
+		cacheRepos["Comment"] = &repos.comment
+		cacheStats["Comment"] = repos.comment.(memory.StatsReporter)
+	}
+	{
+		repos.search = postgres.NewSearchRepository(db, &dbRepos)
+		dbRepos["Search"] = &repos.search
+	}
+	{
+		var authDBRepo = postgres.NewAuthRepository(db, &dbRepos)
+		dbRepos["Auth"] = &authDBRepo
+		repos.auth = memory.NewAuthRepository(&authDBRepo, cacheOptions)
+		cacheRepos["Auth"] = &repos.auth
+		cacheStats["Auth"] = repos.auth.(memory.StatsReporter)
+	}
+	{
+		// failed attempts are kept in the database so that all instances share them,
+		// the memory storage keeps them in memory.NewLockoutRepository instead
+		repos.lockout = postgres.NewLockoutRepository(db, &dbRepos)
+		dbRepos["Lockout"] = &repos.lockout
+	}
+	{
+		var oauthDBRepo = postgres.NewOAuthRepository(db, &dbRepos)
+		dbRepos["OAuth"] = &oauthDBRepo
+		repos.oauth = memory.NewOAuthRepository(&oauthDBRepo)
+		cacheRepos["OAuth"] = &repos.oauth
+	}
+
+	lc.OnShutdown("cache stats", func(context.Context) error {
+		names := make([]string, 0, len(cacheStats))
+		for name := range cacheStats {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			stats := cacheStats[name].CacheStats()
+			logger.Printf("%s cache: %d hits, %d misses, %d evictions, %d expired, %d/%d entries",
+				name, stats.Hits, stats.Misses, stats.Evictions, stats.Expired, stats.Size, stats.Capacity)
+		}
+		return nil
+	})
+
+	invalidators := make([]memory.Invalidator, 0, len(cacheStats))
+	for _, repo := range cacheStats {
+		if invalidator, ok := repo.(memory.Invalidator); ok {
+			invalidators = append(invalidators, invalidator)
+		}
+	}
+	// the caches only take what's written in a unit of work once it's committed
+	repos.unitOfWork = memory.NewUnitOfWork(postgres.NewUnitOfWork(db))
+	{
+		// other instances write to the same database, the triggers added by the
+		// 0004_cache_invalidation migration notify us of what they change
+		listener := memory.NewListener(conf.Database.DataSourceName(), logger, invalidators...)
+		if err := listener.Listen(); err != nil {
+			logger.Fatalf("listening for cache invalidations failed because: %v", err)
+		}
+		lc.OnShutdown("cache invalidation listener", func(context.Context) error {
+			return listener.Close()
+		})
+	}
+	return repos
+}
+
+// newSQLiteRepositories opens the database file and returns repositories that
+// keep everything in it. They aren't cached, reads don't leave the process.
+// The database is registered to be closed on shutdown.
+func newSQLiteRepositories(conf *config.Config, logger *log.Logger, lc *lifecycle) *repositories {
+	db, err := sqlite.Open(conf.SQLite.Path)
+	if err != nil {
+		logger.Fatalf("opening database failed because: %v", err)
+	}
+	lc.OnShutdown("database", func(context.Context) error {
+		return db.Close()
+	})
+
+	if conf.SQLite.AutoMigrate {
+		migrator, err := sqlitemigrations.NewMigrator(db, logger)
+		if err == nil {
+			_, err = migrator.Up()
+		}
+		if err != nil {
+			logger.Fatalf("migrating database failed because: %v", err)
+		}
+	}
+
+	dbRepos := make(map[string]interface{})
+	repos := &repositories{
+		channel: sqlite.NewChannelRepository(db, &dbRepos),
+		user:    sqlite.NewUserRepository(db, &dbRepos),
+		feed:    sqlite.NewFeedRepository(db, &dbRepos),
+		release: sqlite.NewReleaseRepository(db, &dbRepos),
+		post:    sqlite.NewPostRepository(db, &dbRepos),
+		comment: sqlite.NewCommentRepository(db, &dbRepos),
+		search:  sqlite.NewSearchRepository(db, &dbRepos),
+		auth:    sqlite.NewAuthRepository(db, &dbRepos),
+		lockout: sqlite.NewLockoutRepository(db, &dbRepos),
+		oauth:   sqlite.NewOAuthRepository(db, &dbRepos),
+
+		unitOfWork: sqlite.NewUnitOfWork(db),
+	}
+	dbRepos["Channel"] = &repos.channel
+	dbRepos["User"] = &repos.user
+	dbRepos["Feed"] = &repos.feed
+	dbRepos["Release"] = &repos.release
+	dbRepos["Post"] = &repos.post
+	dbRepos["Comment"] = &repos.comment
+	dbRepos["Search"] = &repos.search
+	dbRepos["Auth"] = &repos.auth
+	dbRepos["Lockout"] = &repos.lockout
+	dbRepos["OAuth"] = &repos.oauth
+	return repos
+}
+
+// newImageStorage returns the storage images are kept in, that configured.
+// Images kept on the filesystem are served by the server under the serving
+// route, those kept in an S3 bucket are fetched from it through signed links.
+func newImageStorage(conf *config.Config) (storage.Storage, error) {
+	if conf.Images.Storage == config.ImageStorageS3 {
+		s3 := conf.Images.S3
+		return storage.NewS3Storage(s3.Endpoint, s3.Region, s3.Bucket, s3.AccessKeyID, s3.SecretAccessKey, nil)
+	}
+	return storage.NewFileSystemStorage(conf.Images.StoragePath, conf.Server.Address()+conf.Images.ServingRoute)
+}
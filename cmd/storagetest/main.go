@@ -0,0 +1,114 @@
+/*
+Command storagetest runs the checks of the storagetest package against the
+implementations of the storage package and reports those that fail.
+
+	storagetest [filesystem] [s3]
+
+Both are checked by default, each check getting a storage of its own. The
+filesystem storage keeps its blobs in a temporary directory served over HTTP
+and the s3 storage is checked against a storagetest.S3StandIn, no account or
+network access is needed.
+*/
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage"
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage/storagetest"
+)
+
+const usage = `usage: %s [filesystem] [s3]
+  filesystem  checks the filesystem storage, against temporary directories
+  s3          checks the s3 storage, against an in memory stand-in
+both are checked if no backend is given
+`
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+	backends := os.Args[1:]
+	if len(backends) == 0 {
+		backends = []string{"filesystem", "s3"}
+	}
+
+	failed := false
+	for _, backend := range backends {
+		var newStorage storagetest.Factory
+		switch backend {
+		case "filesystem":
+			newStorage = newFileSystemStorage
+		case "s3":
+			newStorage = newS3Storage
+		default:
+			fmt.Fprintf(os.Stderr, usage, os.Args[0])
+			logger.Fatalf("unknown backend %q", backend)
+		}
+		if !run(backend, newStorage, logger) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run runs all the checks against the storages returned by newStorage and
+// reports how they went. It returns false if any of them failed.
+func run(backend string, newStorage storagetest.Factory, logger *log.Logger) bool {
+	start := time.Now()
+	checks := storagetest.Checks()
+	failures := 0
+	for _, check := range checks {
+		reported := check.Exec(newStorage)
+		if len(reported) == 0 {
+			continue
+		}
+		failures++
+		logger.Printf("FAIL %s %s", backend, check.Name)
+		for _, failure := range reported {
+			logger.Printf("    %s", failure)
+		}
+	}
+	if failures > 0 {
+		logger.Printf("FAIL %s: %d of %d checks failed in %v", backend, failures, len(checks), time.Since(start).Round(time.Millisecond))
+		return false
+	}
+	logger.Printf("ok   %s: %d checks passed in %v", backend, len(checks), time.Since(start).Round(time.Millisecond))
+	return true
+}
+
+// newFileSystemStorage returns a filesystem storage over a new temporary
+// directory, served the way the server serves images, that's removed on release.
+func newFileSystemStorage() (storage.Storage, func(), error) {
+	dir, err := os.MkdirTemp("", "storagetest")
+	if err != nil {
+		return nil, nil, err
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	release := func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+	s, err := storage.NewFileSystemStorage(dir, server.URL+"/")
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return s, release, nil
+}
+
+// newS3Storage returns an s3 storage over a new stand-in that's closed on release.
+func newS3Storage() (storage.Storage, func(), error) {
+	standIn := storagetest.NewS3StandIn("us-east-1", "AKIDSTORAGETEST", "storagetest-secret", "images")
+	s, err := storage.NewS3Storage(standIn.URL, standIn.Region, "images", standIn.AccessKeyID, standIn.SecretAccessKey, standIn.Client())
+	if err != nil {
+		standIn.Close()
+		return nil, nil, err
+	}
+	return s, standIn.Close, nil
+}
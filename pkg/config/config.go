@@ -0,0 +1,600 @@
+/*
+Package config contains the settings of the server and the logic to load them.
+Settings are read, in increasing order of precedence, from their defaults, a YAML
+config file, environment variables and command line flags.
+*/
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix is prepended to the environment variable of each flag. The variable of
+// a flag is its name in upper case with dashes replaced by underscores, db-host
+// is read from ISSUE1_DB_HOST for example.
+const EnvPrefix = "ISSUE1_"
+
+// Storage backends the server can keep its data in.
+const (
+	// StoragePostgres keeps data in the PostgreSQL database, cached in memory.
+	StoragePostgres = "postgres"
+	// StorageMemory keeps data in memory only, it's lost once the server exits.
+	// It's meant for development and tests, the database settings are ignored.
+	StorageMemory = "memory"
+	// StorageSQLite keeps data in a single SQLite database file. It needs no
+	// database server, only a server built with the sqlite_fts5 tag.
+	StorageSQLite = "sqlite"
+)
+
+// Image storage backends images can be kept in.
+const (
+	// ImageStorageFileSystem keeps images as files in a directory.
+	ImageStorageFileSystem = "filesystem"
+	// ImageStorageS3 keeps images in the bucket of an S3 compatible service.
+	ImageStorageS3 = "s3"
+)
+
+// Config holds all the settings of the server.
+type Config struct {
+	Storage            string   `yaml:"storage"`
+	Server             Server   `yaml:"server"`
+	Database           Database `yaml:"database"`
+	SQLite             SQLite   `yaml:"sqlite"`
+	Cache              Cache    `yaml:"cache"`
+	Images             Images   `yaml:"images"`
+	Auth               Auth     `yaml:"auth"`
+	Lockout            Lockout  `yaml:"lockout"`
+	OAuth              OAuth    `yaml:"oauth"`
+	Mail               Mail     `yaml:"mail"`
+	ModeratorUsernames []string `yaml:"moderatorUsernames"`
+}
+
+// Server holds the settings of the HTTP server.
+// Host is the address clients reach the server at, it's used to build links.
+// ShutdownTimeout is how long in-flight requests are given to finish on shutdown.
+// QueryTimeout is how long the storage is given to serve each request.
+type Server struct {
+	Host              string        `yaml:"host"`
+	Port              int           `yaml:"port"`
+	HTTPS             bool          `yaml:"https"`
+	CertFile          string        `yaml:"certFile"`
+	KeyFile           string        `yaml:"keyFile"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"`
+	QueryTimeout      time.Duration `yaml:"queryTimeout"`
+}
+
+// Database holds the settings used to connect to the PostgreSQL database.
+// Password can instead be read from PasswordFile. Pending migrations are
+// applied on start if AutoMigrate is set.
+type Database struct {
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"`
+	Name         string `yaml:"name"`
+	User         string `yaml:"user"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"passwordFile"`
+	SSLMode      string `yaml:"sslMode"`
+	AutoMigrate  bool   `yaml:"autoMigrate"`
+}
+
+// SQLite holds the settings of the SQLite database file, used when the storage
+// is sqlite. The file is created if it doesn't exist. Pending migrations are
+// applied on start if AutoMigrate is set.
+type SQLite struct {
+	Path        string `yaml:"path"`
+	AutoMigrate bool   `yaml:"autoMigrate"`
+}
+
+// Cache holds the limits of the in memory caches in front of the database.
+// Capacity is the number of entries each cache holds and TTL is how long an
+// entry is served before it's read from the database again.
+type Cache struct {
+	Capacity int           `yaml:"capacity"`
+	TTL      time.Duration `yaml:"ttl"`
+}
+
+// Images holds the settings of where images are stored and served from.
+// Storage is the backend they're kept in, StoragePath is the directory of the
+// filesystem one and S3 holds the settings of the s3 one. Links to images
+// handed out by the s3 backend expire after URLLifetime. Uploaded images are
+// re-encoded, as JPEG at JPEGQuality when that's smaller, and those with more
+// than MaxPixels pixels or of more than MaxBytes bytes are rejected. No more
+// than MaxConcurrent of them are processed at once.
+type Images struct {
+	ServingRoute  string        `yaml:"servingRoute"`
+	Storage       string        `yaml:"storage"`
+	StoragePath   string        `yaml:"storagePath"`
+	S3            S3            `yaml:"s3"`
+	URLLifetime   time.Duration `yaml:"urlLifetime"`
+	JPEGQuality   int           `yaml:"jpegQuality"`
+	MaxPixels     int           `yaml:"maxPixels"`
+	MaxBytes      int64         `yaml:"maxBytes"`
+	MaxConcurrent int           `yaml:"maxConcurrent"`
+}
+
+// S3 holds the settings of the bucket images are kept in when their storage is s3.
+// Endpoint is the base URL of the service, https://s3.<region>.amazonaws.com for
+// AWS, and the bucket is addressed as the first segment of its path.
+// SecretAccessKey can instead be read from SecretAccessKeyFile.
+type S3 struct {
+	Endpoint            string `yaml:"endpoint"`
+	Region              string `yaml:"region"`
+	Bucket              string `yaml:"bucket"`
+	AccessKeyID         string `yaml:"accessKeyID"`
+	SecretAccessKey     string `yaml:"secretAccessKey"`
+	SecretAccessKeyFile string `yaml:"secretAccessKeyFile"`
+}
+
+// Auth holds the settings of the auth service.
+// Tokens are signed with the keys listed in SigningKeyManifest, which is read
+// again every SigningKeyReloadInterval, never if it's zero, and on SIGHUP.
+// With EphemeralSigningKey set, or storage memory, a key generated on start is
+// used instead, tokens signed with it are lost along with it on exit.
+type Auth struct {
+	SigningKeyManifest              string        `yaml:"signingKeyManifest"`
+	SigningKeyReloadInterval        time.Duration `yaml:"signingKeyReloadInterval"`
+	EphemeralSigningKey             bool          `yaml:"ephemeralSigningKey"`
+	AccessTokenLifetime             time.Duration `yaml:"accessTokenLifetime"`
+	RefreshTokenLifetime            time.Duration `yaml:"refreshTokenLifetime"`
+	PasswordResetLifetime           time.Duration `yaml:"passwordResetLifetime"`
+	EmailVerificationLifetime       time.Duration `yaml:"emailVerificationLifetime"`
+	EmailVerificationResendInterval time.Duration `yaml:"emailVerificationResendInterval"`
+	TOTPIssuer                      string        `yaml:"totpIssuer"`
+	TOTPChallengeLifetime           time.Duration `yaml:"totpChallengeLifetime"`
+}
+
+// Lockout holds the settings of the lockout service.
+type Lockout struct {
+	MaxUsernameFailures int           `yaml:"maxUsernameFailures"`
+	MaxIPFailures       int           `yaml:"maxIPFailures"`
+	FailureWindow       time.Duration `yaml:"failureWindow"`
+	BaseLockout         time.Duration `yaml:"baseLockout"`
+	MaxLockout          time.Duration `yaml:"maxLockout"`
+}
+
+// OAuth holds the settings of the oauth service.
+type OAuth struct {
+	AuthorizationCodeLifetime time.Duration `yaml:"authorizationCodeLifetime"`
+}
+
+// Mail holds the settings of the SMTP server mails are sent through.
+// Password can instead be read from PasswordFile. With LogOnly set, or storage
+// memory and no SMTPHost, mails are only logged instead.
+type Mail struct {
+	LogOnly      bool   `yaml:"logOnly"`
+	SMTPHost     string `yaml:"smtpHost"`
+	SMTPPort     int    `yaml:"smtpPort"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"passwordFile"`
+	From         string `yaml:"from"`
+}
+
+// ValidationError lists everything that's wrong with a Config, one problem per entry.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "config invalid: " + strings.Join(e, "; ")
+}
+
+// Default returns the settings used for development.
+func Default() *Config {
+	return &Config{
+		Storage: StoragePostgres,
+		Server: Server{
+			Host:              "localhost",
+			Port:              8080,
+			CertFile:          "cmd/server/cert.pem",
+			KeyFile:           "cmd/server/key.pem",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+			QueryTimeout:      10 * time.Second,
+		},
+		Database: Database{
+			Host:    "localhost",
+			Port:    5432,
+			Name:    "issue#1_db",
+			User:    "issue#1_dev",
+			SSLMode: "disable",
+		},
+		SQLite: SQLite{
+			Path:        "data/issue1.db",
+			AutoMigrate: true,
+		},
+		Cache: Cache{
+			Capacity: 10000,
+			TTL:      5 * time.Minute,
+		},
+		Images: Images{
+			ServingRoute: "/images/",
+			Storage:      ImageStorageFileSystem,
+			StoragePath:  "data/images",
+			S3: S3{
+				Region: "us-east-1",
+			},
+			URLLifetime:   time.Hour,
+			JPEGQuality:   85,
+			MaxPixels:     24000000,
+			MaxBytes:      20 << 20,
+			MaxConcurrent: 2,
+		},
+		Auth: Auth{
+			SigningKeyManifest:              "secrets/jwt-keys.json",
+			SigningKeyReloadInterval:        5 * time.Minute,
+			AccessTokenLifetime:             15 * time.Minute,
+			RefreshTokenLifetime:            7 * 24 * time.Hour,
+			PasswordResetLifetime:           30 * time.Minute,
+			EmailVerificationLifetime:       48 * time.Hour,
+			EmailVerificationResendInterval: 5 * time.Minute,
+			TOTPIssuer:                      "issue#1",
+			TOTPChallengeLifetime:           5 * time.Minute,
+		},
+		Lockout: Lockout{
+			MaxUsernameFailures: 5,
+			MaxIPFailures:       20,
+			FailureWindow:       15 * time.Minute,
+			BaseLockout:         30 * time.Second,
+			MaxLockout:          time.Hour,
+		},
+		OAuth: OAuth{
+			AuthorizationCodeLifetime: time.Minute,
+		},
+		Mail: Mail{
+			SMTPPort: 587,
+		},
+		ModeratorUsernames: []string{},
+	}
+}
+
+// Load builds the Config out of, in increasing order of precedence, the defaults,
+// the config file, environment variables and the given command line arguments.
+// The config file is named by the -config flag or the ISSUE1_CONFIG variable,
+// no file is read if neither is set. Secrets are read from their files once all
+// the sources are merged and the result is validated before it's returned.
+func Load(args []string) (*Config, error) {
+	c := Default()
+	path := configPath(args)
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file failed because: %w", err)
+		}
+		// strict so that misspelt keys are reported instead of silently ignored
+		if err = yaml.UnmarshalStrict(b, c); err != nil {
+			return nil, fmt.Errorf("parsing config file %s failed because: %w", path, err)
+		}
+	}
+
+	fs := c.flagSet()
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("environment variable %s=%q invalid: %w", envName(f.Name), value, setErr)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err = c.readSecretFiles(); err != nil {
+		return nil, err
+	}
+	if err = c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the settings for values the server can't run with.
+// It returns a ValidationError listing all the problems found.
+func (c *Config) Validate() error {
+	var problems ValidationError
+	check := func(ok bool, format string, a ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, a...))
+		}
+	}
+
+	check(c.Server.Host != "", "server.host is required")
+	check(validPort(c.Server.Port), "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	if c.Server.HTTPS {
+		check(fileExists(c.Server.CertFile), "server.certFile %q must exist when server.https is set", c.Server.CertFile)
+		check(fileExists(c.Server.KeyFile), "server.keyFile %q must exist when server.https is set", c.Server.KeyFile)
+	}
+
+	switch c.Storage {
+	case StoragePostgres:
+		check(c.Database.Host != "", "database.host is required")
+		check(validPort(c.Database.Port), "database.port must be between 1 and 65535, got %d", c.Database.Port)
+		check(c.Database.Name != "", "database.name is required")
+		check(c.Database.User != "", "database.user is required")
+		switch c.Database.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			check(false, "database.sslMode %q isn't one of disable, allow, prefer, require, verify-ca or verify-full", c.Database.SSLMode)
+		}
+	case StorageSQLite:
+		check(c.SQLite.Path != "", "sqlite.path is required")
+	case StorageMemory:
+	default:
+		check(false, "storage %q isn't one of %s, %s or %s", c.Storage, StoragePostgres, StorageSQLite, StorageMemory)
+	}
+
+	check(c.Cache.Capacity > 0, "cache.capacity must be positive, got %d", c.Cache.Capacity)
+
+	check(strings.HasPrefix(c.Images.ServingRoute, "/") && strings.HasSuffix(c.Images.ServingRoute, "/"),
+		"images.servingRoute must start and end with a slash, got %q", c.Images.ServingRoute)
+	switch c.Images.Storage {
+	case ImageStorageFileSystem:
+		check(c.Images.StoragePath != "", "images.storagePath is required")
+	case ImageStorageS3:
+		endpoint, err := url.Parse(c.Images.S3.Endpoint)
+		check(err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"images.s3.endpoint must be an http or https URL, got %q", c.Images.S3.Endpoint)
+		check(c.Images.S3.Region != "", "images.s3.region is required")
+		check(c.Images.S3.Bucket != "" && !strings.Contains(c.Images.S3.Bucket, "/"),
+			"images.s3.bucket is required and can't contain slashes, got %q", c.Images.S3.Bucket)
+		check(c.Images.S3.AccessKeyID != "", "images.s3.accessKeyID is required")
+		check(c.Images.S3.SecretAccessKey != "", "images.s3.secretAccessKey is required")
+		check(c.Images.URLLifetime <= 7*24*time.Hour, "images.urlLifetime can't be longer than 168h when images.storage is s3")
+	default:
+		check(false, "images.storage %q isn't one of %s or %s", c.Images.Storage, ImageStorageFileSystem, ImageStorageS3)
+	}
+	check(c.Images.JPEGQuality >= 1 && c.Images.JPEGQuality <= 100, "images.jpegQuality must be between 1 and 100, got %d", c.Images.JPEGQuality)
+	check(c.Images.MaxPixels > 0, "images.maxPixels must be positive, got %d", c.Images.MaxPixels)
+	check(c.Images.MaxBytes > 0, "images.maxBytes must be positive, got %d", c.Images.MaxBytes)
+	check(c.Images.MaxConcurrent > 0, "images.maxConcurrent must be positive, got %d", c.Images.MaxConcurrent)
+
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"server.readHeaderTimeout", c.Server.ReadHeaderTimeout},
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"server.queryTimeout", c.Server.QueryTimeout},
+		{"cache.ttl", c.Cache.TTL},
+		{"images.urlLifetime", c.Images.URLLifetime},
+		{"auth.accessTokenLifetime", c.Auth.AccessTokenLifetime},
+		{"auth.refreshTokenLifetime", c.Auth.RefreshTokenLifetime},
+		{"auth.passwordResetLifetime", c.Auth.PasswordResetLifetime},
+		{"auth.emailVerificationLifetime", c.Auth.EmailVerificationLifetime},
+		{"auth.emailVerificationResendInterval", c.Auth.EmailVerificationResendInterval},
+		{"auth.totpChallengeLifetime", c.Auth.TOTPChallengeLifetime},
+		{"lockout.failureWindow", c.Lockout.FailureWindow},
+		{"lockout.baseLockout", c.Lockout.BaseLockout},
+		{"lockout.maxLockout", c.Lockout.MaxLockout},
+		{"oauth.authorizationCodeLifetime", c.OAuth.AuthorizationCodeLifetime},
+	}
+	for _, duration := range durations {
+		check(duration.d > 0, "%s must be positive, got %s", duration.name, duration.d)
+	}
+	check(c.Auth.AccessTokenLifetime < c.Auth.RefreshTokenLifetime,
+		"auth.accessTokenLifetime must be shorter than auth.refreshTokenLifetime")
+	check(c.Auth.SigningKeyReloadInterval >= 0, "auth.signingKeyReloadInterval can't be negative, got %s", c.Auth.SigningKeyReloadInterval)
+	check(c.Auth.TOTPIssuer != "", "auth.totpIssuer is required")
+	check(c.Lockout.MaxUsernameFailures > 0, "lockout.maxUsernameFailures must be positive, got %d", c.Lockout.MaxUsernameFailures)
+	check(c.Lockout.MaxIPFailures > 0, "lockout.maxIPFailures must be positive, got %d", c.Lockout.MaxIPFailures)
+	check(c.Lockout.BaseLockout <= c.Lockout.MaxLockout, "lockout.baseLockout must not be longer than lockout.maxLockout")
+
+	if c.Mail.SMTPHost != "" {
+		check(validPort(c.Mail.SMTPPort), "mail.smtpPort must be between 1 and 65535, got %d", c.Mail.SMTPPort)
+		check(c.Mail.From != "", "mail.from is required when mail.smtpHost is set")
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// DataSourceName returns the connection string lib/pq expects for the database.
+func (d *Database) DataSourceName() string {
+	return fmt.Sprintf(`host=%s port=%d dbname=%s user=%s password=%s sslmode=%s`,
+		quoteDSNValue(d.Host), d.Port, quoteDSNValue(d.Name), quoteDSNValue(d.User),
+		quoteDSNValue(d.Password), quoteDSNValue(d.SSLMode))
+}
+
+// Address returns the address the server is reachable at, scheme included.
+func (s *Server) Address() string {
+	scheme := "http://"
+	if s.HTTPS {
+		scheme = "https://"
+	}
+	return scheme + s.Host + ":" + strconv.Itoa(s.Port)
+}
+
+// flagSet is a helper function that returns a flag set whose flags write to the config.
+// The current values of the config are used as the defaults of the flags.
+func (c *Config) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("issue1", flag.ContinueOnError)
+	// only registered so that it's accepted, it's looked up by configPath
+	fs.String("config", "", "path of the YAML config file")
+
+	fs.StringVar(&c.Storage, "storage", c.Storage, "where data is kept, postgres, sqlite or memory")
+
+	fs.StringVar(&c.Server.Host, "host", c.Server.Host, "host name clients reach the server at")
+	fs.IntVar(&c.Server.Port, "port", c.Server.Port, "port to listen on")
+	fs.BoolVar(&c.Server.HTTPS, "https", c.Server.HTTPS, "serve over TLS")
+	fs.StringVar(&c.Server.CertFile, "cert-file", c.Server.CertFile, "path of the TLS certificate")
+	fs.StringVar(&c.Server.KeyFile, "key-file", c.Server.KeyFile, "path of the TLS private key")
+	fs.DurationVar(&c.Server.ReadHeaderTimeout, "read-header-timeout", c.Server.ReadHeaderTimeout, "time allowed to read request headers")
+	fs.DurationVar(&c.Server.ReadTimeout, "read-timeout", c.Server.ReadTimeout, "time allowed to read whole requests")
+	fs.DurationVar(&c.Server.WriteTimeout, "write-timeout", c.Server.WriteTimeout, "time allowed to write responses")
+	fs.DurationVar(&c.Server.IdleTimeout, "idle-timeout", c.Server.IdleTimeout, "time idle keep-alive connections are kept open")
+	fs.DurationVar(&c.Server.ShutdownTimeout, "shutdown-timeout", c.Server.ShutdownTimeout, "time in-flight requests are given to finish on shutdown")
+	fs.DurationVar(&c.Server.QueryTimeout, "query-timeout", c.Server.QueryTimeout, "time the storage is given to serve each request")
+
+	fs.StringVar(&c.Database.Host, "db-host", c.Database.Host, "database host")
+	fs.IntVar(&c.Database.Port, "db-port", c.Database.Port, "database port")
+	fs.StringVar(&c.Database.Name, "db-name", c.Database.Name, "database name")
+	fs.StringVar(&c.Database.User, "db-user", c.Database.User, "database role")
+	fs.StringVar(&c.Database.Password, "db-password", c.Database.Password, "database password, prefer db-password-file")
+	fs.StringVar(&c.Database.PasswordFile, "db-password-file", c.Database.PasswordFile, "path of a file holding the database password")
+	fs.StringVar(&c.Database.SSLMode, "db-sslmode", c.Database.SSLMode, "lib/pq sslmode of the database connection")
+	fs.BoolVar(&c.Database.AutoMigrate, "db-auto-migrate", c.Database.AutoMigrate, "apply pending schema migrations on start")
+
+	fs.StringVar(&c.SQLite.Path, "sqlite-path", c.SQLite.Path, "path of the SQLite database file")
+	fs.BoolVar(&c.SQLite.AutoMigrate, "sqlite-auto-migrate", c.SQLite.AutoMigrate, "apply pending schema migrations of the SQLite database on start")
+
+	fs.IntVar(&c.Cache.Capacity, "cache-capacity", c.Cache.Capacity, "entries each in memory cache holds before evicting the least recently used")
+	fs.DurationVar(&c.Cache.TTL, "cache-ttl", c.Cache.TTL, "time cached entries are served before they're read from the database again")
+
+	fs.StringVar(&c.Images.ServingRoute, "image-serving-route", c.Images.ServingRoute, "route images are served under")
+	fs.StringVar(&c.Images.Storage, "image-storage", c.Images.Storage, "where images are kept: filesystem or s3")
+	fs.StringVar(&c.Images.StoragePath, "image-storage-path", c.Images.StoragePath, "directory images are stored in when image-storage is filesystem")
+	fs.DurationVar(&c.Images.URLLifetime, "image-url-lifetime", c.Images.URLLifetime, "lifetime of the signed image links handed out when image-storage is s3")
+	fs.IntVar(&c.Images.JPEGQuality, "image-jpeg-quality", c.Images.JPEGQuality, "quality uploaded images are re-encoded as JPEG at, 1 to 100")
+	fs.IntVar(&c.Images.MaxPixels, "image-max-pixels", c.Images.MaxPixels, "most pixels an uploaded image may have")
+	fs.Int64Var(&c.Images.MaxBytes, "image-max-bytes", c.Images.MaxBytes, "most bytes an uploaded image may take")
+	fs.IntVar(&c.Images.MaxConcurrent, "image-max-concurrent", c.Images.MaxConcurrent, "most uploaded images processed at once")
+	fs.StringVar(&c.Images.S3.Endpoint, "s3-endpoint", c.Images.S3.Endpoint, "base URL of the S3 compatible service images are kept in")
+	fs.StringVar(&c.Images.S3.Region, "s3-region", c.Images.S3.Region, "region of the S3 bucket")
+	fs.StringVar(&c.Images.S3.Bucket, "s3-bucket", c.Images.S3.Bucket, "S3 bucket images are kept in")
+	fs.StringVar(&c.Images.S3.AccessKeyID, "s3-access-key-id", c.Images.S3.AccessKeyID, "S3 access key id")
+	fs.StringVar(&c.Images.S3.SecretAccessKey, "s3-secret-access-key", c.Images.S3.SecretAccessKey, "S3 secret access key, prefer s3-secret-access-key-file")
+	fs.StringVar(&c.Images.S3.SecretAccessKeyFile, "s3-secret-access-key-file", c.Images.S3.SecretAccessKeyFile, "path of a file holding the S3 secret access key")
+
+	fs.StringVar(&c.Auth.SigningKeyManifest, "signing-key-manifest", c.Auth.SigningKeyManifest, "path of the token signing key manifest")
+	fs.DurationVar(&c.Auth.SigningKeyReloadInterval, "signing-key-reload-interval", c.Auth.SigningKeyReloadInterval, "time between reads of the signing key manifest, 0 to only read it on SIGHUP")
+	fs.BoolVar(&c.Auth.EphemeralSigningKey, "ephemeral-signing-key", c.Auth.EphemeralSigningKey, "sign tokens with a key generated on start instead, for development only")
+	fs.DurationVar(&c.Auth.AccessTokenLifetime, "access-token-lifetime", c.Auth.AccessTokenLifetime, "lifetime of access tokens")
+	fs.DurationVar(&c.Auth.RefreshTokenLifetime, "refresh-token-lifetime", c.Auth.RefreshTokenLifetime, "lifetime of refresh tokens")
+	fs.DurationVar(&c.Auth.PasswordResetLifetime, "password-reset-lifetime", c.Auth.PasswordResetLifetime, "lifetime of password reset tokens")
+	fs.DurationVar(&c.Auth.EmailVerificationLifetime, "email-verification-lifetime", c.Auth.EmailVerificationLifetime, "lifetime of email verification tokens")
+	fs.DurationVar(&c.Auth.EmailVerificationResendInterval, "email-verification-resend-interval", c.Auth.EmailVerificationResendInterval, "minimum time between email verification mails")
+	fs.StringVar(&c.Auth.TOTPIssuer, "totp-issuer", c.Auth.TOTPIssuer, "issuer shown by authenticator apps")
+	fs.DurationVar(&c.Auth.TOTPChallengeLifetime, "totp-challenge-lifetime", c.Auth.TOTPChallengeLifetime, "lifetime of TOTP login challenges")
+
+	fs.IntVar(&c.Lockout.MaxUsernameFailures, "lockout-max-username-failures", c.Lockout.MaxUsernameFailures, "failed logins a username is allowed before it's locked")
+	fs.IntVar(&c.Lockout.MaxIPFailures, "lockout-max-ip-failures", c.Lockout.MaxIPFailures, "failed logins an address is allowed before it's locked")
+	fs.DurationVar(&c.Lockout.FailureWindow, "lockout-failure-window", c.Lockout.FailureWindow, "time failed logins are counted over")
+	fs.DurationVar(&c.Lockout.BaseLockout, "lockout-base", c.Lockout.BaseLockout, "length of the first lockout")
+	fs.DurationVar(&c.Lockout.MaxLockout, "lockout-max", c.Lockout.MaxLockout, "longest a lockout can grow to")
+
+	fs.DurationVar(&c.OAuth.AuthorizationCodeLifetime, "oauth-code-lifetime", c.OAuth.AuthorizationCodeLifetime, "lifetime of OAuth2 authorization codes")
+
+	fs.BoolVar(&c.Mail.LogOnly, "mail-log-only", c.Mail.LogOnly, "log mails instead of sending them, for development only")
+	fs.StringVar(&c.Mail.SMTPHost, "smtp-host", c.Mail.SMTPHost, "SMTP server mails are sent through")
+	fs.IntVar(&c.Mail.SMTPPort, "smtp-port", c.Mail.SMTPPort, "port of the SMTP server")
+	fs.StringVar(&c.Mail.Username, "smtp-username", c.Mail.Username, "SMTP username")
+	fs.StringVar(&c.Mail.Password, "smtp-password", c.Mail.Password, "SMTP password, prefer smtp-password-file")
+	fs.StringVar(&c.Mail.PasswordFile, "smtp-password-file", c.Mail.PasswordFile, "path of a file holding the SMTP password")
+	fs.StringVar(&c.Mail.From, "mail-from", c.Mail.From, "sender address of mails")
+
+	fs.Var((*stringList)(&c.ModeratorUsernames), "moderators", "comma separated usernames of moderators")
+	return fs
+}
+
+// readSecretFiles is a helper function that reads the secrets that are given as files.
+// Giving a secret both directly and as a file is an error since it's unclear which is meant.
+func (c *Config) readSecretFiles() error {
+	secrets := []struct {
+		name  string
+		value *string
+		path  string
+	}{
+		{"database.password", &c.Database.Password, c.Database.PasswordFile},
+		{"mail.password", &c.Mail.Password, c.Mail.PasswordFile},
+		{"images.s3.secretAccessKey", &c.Images.S3.SecretAccessKey, c.Images.S3.SecretAccessKeyFile},
+	}
+	for _, secret := range secrets {
+		if secret.path == "" {
+			continue
+		}
+		if *secret.value != "" {
+			return ValidationError{fmt.Sprintf("only one of %s and %sFile can be set", secret.name, secret.name)}
+		}
+		b, err := ioutil.ReadFile(secret.path)
+		if err != nil {
+			return fmt.Errorf("reading %sFile failed because: %w", secret.name, err)
+		}
+		*secret.value = strings.TrimRight(string(b), "\r\n")
+	}
+	return nil
+}
+
+// configPath is a helper function that looks up the path of the config file
+// among the arguments, falling back to the environment.
+func configPath(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		switch {
+		case name == "config" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(name, "config="):
+			return strings.TrimPrefix(name, "config=")
+		}
+	}
+	return os.Getenv(envName("config"))
+}
+
+// envName returns the environment variable the flag of the given name is read from.
+func envName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// quoteDSNValue quotes the value as lib/pq expects values holding spaces or quotes to be.
+func quoteDSNValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+func validPort(port int) bool {
+	return port > 0 && port < 65536
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// stringList is a flag.Value of comma separated strings.
+type stringList []string
+
+func (l *stringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = make([]string, 0)
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
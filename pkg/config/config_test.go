@@ -0,0 +1,267 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes the content to a file named name in a temporary directory
+// removed after the test and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Setenv(envName("config"), "")
+	path := writeFile(t, "config.yaml", `
+server:
+  host: yaml.example.com
+  port: 1001
+cache:
+  capacity: 1001
+`)
+
+	c, err := Load(nil)
+	if err != nil {
+		t.Fatalf("loading the defaults failed because of: %v", err)
+	}
+	if c.Server.Port != Default().Server.Port {
+		t.Errorf("expected the default port %d without a config file, got %d", Default().Server.Port, c.Server.Port)
+	}
+
+	c, err = Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("loading the config file failed because of: %v", err)
+	}
+	if c.Server.Port != 1001 || c.Server.Host != "yaml.example.com" {
+		t.Errorf("expected the config file to override the defaults, got %s:%d", c.Server.Host, c.Server.Port)
+	}
+	if c.Server.ReadTimeout != Default().Server.ReadTimeout {
+		t.Errorf("expected the settings missing from the config file to keep their defaults, got %s", c.Server.ReadTimeout)
+	}
+
+	// the config file can be named by the environment too
+	t.Setenv(envName("config"), path)
+	t.Setenv(envName("port"), "1002")
+	t.Setenv(envName("cache-capacity"), "1002")
+	c, err = Load(nil)
+	if err != nil {
+		t.Fatalf("loading the environment failed because of: %v", err)
+	}
+	if c.Server.Port != 1002 || c.Cache.Capacity != 1002 {
+		t.Errorf("expected the environment to override the config file, got port %d and capacity %d", c.Server.Port, c.Cache.Capacity)
+	}
+	if c.Server.Host != "yaml.example.com" {
+		t.Errorf("expected the settings missing from the environment to be kept from the config file, got %s", c.Server.Host)
+	}
+
+	c, err = Load([]string{"-port", "1003"})
+	if err != nil {
+		t.Fatalf("loading the flags failed because of: %v", err)
+	}
+	if c.Server.Port != 1003 {
+		t.Errorf("expected the flags to override the environment, got %d", c.Server.Port)
+	}
+	if c.Cache.Capacity != 1002 {
+		t.Errorf("expected the settings missing from the flags to be kept from the environment, got %d", c.Cache.Capacity)
+	}
+
+	t.Setenv(envName("port"), "not a port")
+	if _, err = Load(nil); err == nil || !strings.Contains(err.Error(), envName("port")) {
+		t.Errorf("expected an invalid environment variable to be reported by name, got %v", err)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	t.Setenv(envName("config"), "")
+	path := writeFile(t, "config.yaml", `
+server:
+  prot: 8080
+`)
+	_, err := Load([]string{"-config", path})
+	if err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Errorf("expected the misspelt key to be reported, got %v", err)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	t.Setenv(envName("config"), "")
+	secrets := []struct {
+		name, flag string
+		value      func(c *Config) string
+	}{
+		{"database.password", "db-password", func(c *Config) string { return c.Database.Password }},
+		{"mail.password", "smtp-password", func(c *Config) string { return c.Mail.Password }},
+		{"images.s3.secretAccessKey", "s3-secret-access-key", func(c *Config) string { return c.Images.S3.SecretAccessKey }},
+	}
+	for _, secret := range secrets {
+		t.Run(secret.name, func(t *testing.T) {
+			path := writeFile(t, "secret", "s3cret\r\n")
+			t.Setenv(envName(secret.flag+"-file"), path)
+			c, err := Load(nil)
+			if err != nil {
+				t.Fatalf("loading failed because of: %v", err)
+			}
+			if got := secret.value(c); got != "s3cret" {
+				t.Errorf("expected the secret read from the file without its trailing line break, got %q", got)
+			}
+
+			_, err = Load([]string{"-" + secret.flag, "s3cret"})
+			var problems ValidationError
+			if !errors.As(err, &problems) || !strings.Contains(err.Error(), "only one of "+secret.name+" and "+secret.name+"File") {
+				t.Errorf("expected setting the secret both directly and as a file to be refused, got %v", err)
+			}
+
+			t.Setenv(envName(secret.flag+"-file"), filepath.Join(t.TempDir(), "missing"))
+			if _, err = Load(nil); err == nil || !strings.Contains(err.Error(), "reading "+secret.name+"File failed") {
+				t.Errorf("expected a missing secret file to be reported, got %v", err)
+			}
+		})
+	}
+}
+
+// withS3 is a helper function that sets up valid settings of the s3 image storage.
+func withS3(c *Config) {
+	c.Images.Storage = ImageStorageS3
+	c.Images.S3 = S3{
+		Endpoint:        "https://s3.us-east-1.amazonaws.com",
+		Region:          "us-east-1",
+		Bucket:          "images",
+		AccessKeyID:     "id",
+		SecretAccessKey: "secret",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+	c := Default()
+	withS3(c)
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected the s3 settings the tests start from to be valid, got %v", err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	cases := []struct {
+		problem string
+		modify  func(c *Config)
+	}{
+		{"server.host is required", func(c *Config) { c.Server.Host = "" }},
+		{"server.port must be between 1 and 65535, got 0", func(c *Config) { c.Server.Port = 0 }},
+		{`server.certFile "` + missing + `" must exist when server.https is set`, func(c *Config) {
+			c.Server.HTTPS, c.Server.CertFile = true, missing
+		}},
+		{`server.keyFile "` + missing + `" must exist when server.https is set`, func(c *Config) {
+			c.Server.HTTPS, c.Server.KeyFile = true, missing
+		}},
+
+		{"database.host is required", func(c *Config) { c.Database.Host = "" }},
+		{"database.port must be between 1 and 65535, got 65536", func(c *Config) { c.Database.Port = 65536 }},
+		{"database.name is required", func(c *Config) { c.Database.Name = "" }},
+		{"database.user is required", func(c *Config) { c.Database.User = "" }},
+		{`database.sslMode "sometimes" isn't one of disable, allow, prefer, require, verify-ca or verify-full`, func(c *Config) {
+			c.Database.SSLMode = "sometimes"
+		}},
+		{"sqlite.path is required", func(c *Config) { c.Storage, c.SQLite.Path = StorageSQLite, "" }},
+		{`storage "mongo" isn't one of postgres, sqlite or memory`, func(c *Config) { c.Storage = "mongo" }},
+
+		{"cache.capacity must be positive, got 0", func(c *Config) { c.Cache.Capacity = 0 }},
+
+		{`images.servingRoute must start and end with a slash, got "/images"`, func(c *Config) { c.Images.ServingRoute = "/images" }},
+		{"images.storagePath is required", func(c *Config) { c.Images.StoragePath = "" }},
+		{`images.s3.endpoint must be an http or https URL, got "s3.amazonaws.com"`, func(c *Config) {
+			withS3(c)
+			c.Images.S3.Endpoint = "s3.amazonaws.com"
+		}},
+		{"images.s3.region is required", func(c *Config) { withS3(c); c.Images.S3.Region = "" }},
+		{`images.s3.bucket is required and can't contain slashes, got "images/user"`, func(c *Config) {
+			withS3(c)
+			c.Images.S3.Bucket = "images/user"
+		}},
+		{"images.s3.accessKeyID is required", func(c *Config) { withS3(c); c.Images.S3.AccessKeyID = "" }},
+		{"images.s3.secretAccessKey is required", func(c *Config) { withS3(c); c.Images.S3.SecretAccessKey = "" }},
+		{"images.urlLifetime can't be longer than 168h when images.storage is s3", func(c *Config) {
+			withS3(c)
+			c.Images.URLLifetime = 8 * 24 * time.Hour
+		}},
+		{`images.storage "ftp" isn't one of filesystem or s3`, func(c *Config) { c.Images.Storage = "ftp" }},
+		{"images.jpegQuality must be between 1 and 100, got 101", func(c *Config) { c.Images.JPEGQuality = 101 }},
+		{"images.maxPixels must be positive, got 0", func(c *Config) { c.Images.MaxPixels = 0 }},
+		{"images.maxBytes must be positive, got -1", func(c *Config) { c.Images.MaxBytes = -1 }},
+		{"images.maxConcurrent must be positive, got 0", func(c *Config) { c.Images.MaxConcurrent = 0 }},
+
+		{"server.readHeaderTimeout must be positive, got 0s", func(c *Config) { c.Server.ReadHeaderTimeout = 0 }},
+		{"server.readTimeout must be positive, got 0s", func(c *Config) { c.Server.ReadTimeout = 0 }},
+		{"server.writeTimeout must be positive, got 0s", func(c *Config) { c.Server.WriteTimeout = 0 }},
+		{"server.idleTimeout must be positive, got 0s", func(c *Config) { c.Server.IdleTimeout = 0 }},
+		{"server.shutdownTimeout must be positive, got 0s", func(c *Config) { c.Server.ShutdownTimeout = 0 }},
+		{"server.queryTimeout must be positive, got -1s", func(c *Config) { c.Server.QueryTimeout = -time.Second }},
+		{"cache.ttl must be positive, got 0s", func(c *Config) { c.Cache.TTL = 0 }},
+		{"images.urlLifetime must be positive, got 0s", func(c *Config) { c.Images.URLLifetime = 0 }},
+		{"auth.accessTokenLifetime must be positive, got 0s", func(c *Config) { c.Auth.AccessTokenLifetime = 0 }},
+		{"auth.refreshTokenLifetime must be positive, got 0s", func(c *Config) { c.Auth.RefreshTokenLifetime = 0 }},
+		{"auth.passwordResetLifetime must be positive, got 0s", func(c *Config) { c.Auth.PasswordResetLifetime = 0 }},
+		{"auth.emailVerificationLifetime must be positive, got 0s", func(c *Config) { c.Auth.EmailVerificationLifetime = 0 }},
+		{"auth.emailVerificationResendInterval must be positive, got 0s", func(c *Config) { c.Auth.EmailVerificationResendInterval = 0 }},
+		{"auth.totpChallengeLifetime must be positive, got 0s", func(c *Config) { c.Auth.TOTPChallengeLifetime = 0 }},
+		{"lockout.failureWindow must be positive, got 0s", func(c *Config) { c.Lockout.FailureWindow = 0 }},
+		{"lockout.baseLockout must be positive, got 0s", func(c *Config) { c.Lockout.BaseLockout = 0 }},
+		{"lockout.maxLockout must be positive, got 0s", func(c *Config) { c.Lockout.MaxLockout = 0 }},
+		{"oauth.authorizationCodeLifetime must be positive, got 0s", func(c *Config) { c.OAuth.AuthorizationCodeLifetime = 0 }},
+
+		{"auth.accessTokenLifetime must be shorter than auth.refreshTokenLifetime", func(c *Config) {
+			c.Auth.AccessTokenLifetime = c.Auth.RefreshTokenLifetime
+		}},
+		{"auth.signingKeyReloadInterval can't be negative, got -1m0s", func(c *Config) { c.Auth.SigningKeyReloadInterval = -time.Minute }},
+		{"auth.totpIssuer is required", func(c *Config) { c.Auth.TOTPIssuer = "" }},
+		{"lockout.maxUsernameFailures must be positive, got 0", func(c *Config) { c.Lockout.MaxUsernameFailures = 0 }},
+		{"lockout.maxIPFailures must be positive, got 0", func(c *Config) { c.Lockout.MaxIPFailures = 0 }},
+		{"lockout.baseLockout must not be longer than lockout.maxLockout", func(c *Config) { c.Lockout.BaseLockout = 2 * c.Lockout.MaxLockout }},
+
+		{"mail.smtpPort must be between 1 and 65535, got 0", func(c *Config) {
+			c.Mail.SMTPHost, c.Mail.SMTPPort, c.Mail.From = "smtp.example.com", 0, "issue1@example.com"
+		}},
+		{"mail.from is required when mail.smtpHost is set", func(c *Config) { c.Mail.SMTPHost = "smtp.example.com" }},
+	}
+	for _, tc := range cases {
+		c := Default()
+		tc.modify(c)
+		err := c.Validate()
+		var problems ValidationError
+		if !errors.As(err, &problems) {
+			t.Errorf("expected a ValidationError reporting %q, got %v", tc.problem, err)
+			continue
+		}
+		found := false
+		for _, problem := range problems {
+			found = found || problem == tc.problem
+		}
+		if !found {
+			t.Errorf("expected %q among the problems reported, got %q", tc.problem, problems)
+		}
+	}
+}
+
+func TestValidateListsAllProblems(t *testing.T) {
+	c := Default()
+	c.Server.Host = ""
+	c.Cache.Capacity = 0
+	err := c.Validate()
+	var problems ValidationError
+	if !errors.As(err, &problems) || len(problems) != 2 {
+		t.Fatalf("expected both problems to be reported, got %v", err)
+	}
+	if want := "config invalid: server.host is required; cache.capacity must be positive, got 0"; err.Error() != want {
+		t.Errorf("expected the error %q, got %q", want, err.Error())
+	}
+}
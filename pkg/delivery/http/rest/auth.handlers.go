@@ -1,67 +1,132 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/dgrijalva/jwt-go/request"
 )
 
 // ParseAuthTokenMiddleware checks  if the attached request has a valid
-// authentication token. If valid JWT token found, it'll extract the
-// sub, the username in this case and attaches it to the passed request.
-// If no token is found, it'll attach an invalid username.
+// authentication token. If valid JWT token found, it'll build an auth.Principal
+// out of its claims and attach it to the context of the passed request.
+// If no valid token is found, the request is passed along without one.
+// Expired tokens aren't accepted, refresh tokens are to be used to get new ones.
+// Personal access tokens are accepted in place of JWTs and are recognized by
+// their auth.PersonalAccessTokenPrefix.
 func ParseAuthTokenMiddleware(s *Setup) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token, _ := request.ParseFromRequest(r, request.AuthorizationHeaderExtractor,
-				func(token *jwt.Token) (interface{}, error) {
-					if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-						return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			if bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); strings.HasPrefix(bearer, auth.PersonalAccessTokenPrefix) {
+				principal, err := s.AuthService.AuthenticatePersonalAccessToken(r.Context(), bearer)
+				switch err {
+				case nil:
+					if u, err := s.UserService.GetUser(r.Context(), principal.Username); err == nil {
+						principal.Verified = u.Verified
 					}
-					return s.TokenSigningSecret, nil
-				})
+					next.ServeHTTP(w, r.WithContext(auth.NewContextWithPrincipal(r.Context(), principal)))
+					return
+				case auth.ErrInvalidPersonalAccessToken:
+					s.Logger.Printf("access with invalid personal access token")
+				default:
+					s.Logger.Printf("personal access token authentication failed because: %v", err)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			token, _ := request.ParseFromRequest(r, request.AuthorizationHeaderExtractor, s.TokenSigningKeys.Keyfunc)
 			// error from ParseFromRequest is ignored because returns errors for expired
 			// requests and other cases that have nothing to do with parsing.
 			if token != nil {
 				claimMap, _ := token.Claims.(jwt.MapClaims)
-				isInBlacklist, _ := s.AuthService.IsInBlacklist(r.Header.Get("Authorization"))
+				isInBlacklist, err := s.AuthService.IsInBlacklist(r.Context(), r.Header.Get("Authorization"))
 				switch {
+				case err != nil:
+					// the token isn't accepted if it can't be checked
+					s.Logger.Printf("checking blacklist for token failed because: %v", err)
 				case isInBlacklist:
 					// has logged out token
 					break
 				case token.Valid:
 					// if valid and not expired
-					username := claimMap["sub"]
-					r.Header.Set("authorized_username", username.(string))
-					r.Header.Del("authorized_username_expired")
-					next.ServeHTTP(w, r)
-					return
-				case claimMap.VerifyExpiresAt(time.Now().Add(-s.TokenRefreshLifetime).Unix(), true):
-					// if expired but still refreshable
-					username := claimMap["sub"]
-					r.Header.Set("authorized_username_expired", username.(string))
-					r.Header.Del("authorized_username")
-					next.ServeHTTP(w, r)
-					return
+					if principal := principalFromClaims(claimMap); principal != nil {
+						if principal.SessionID != "" {
+							revoked, err := s.AuthService.IsSessionRevoked(r.Context(), principal.SessionID)
+							if err != nil {
+								// the token isn't accepted if its session can't be checked
+								s.Logger.Printf("checking session of token failed because: %v", err)
+								break
+							}
+							if revoked {
+								s.Logger.Printf("access with token of revoked session")
+								break
+							}
+						}
+						if u, err := s.UserService.GetUser(r.Context(), principal.Username); err == nil {
+							principal.Verified = u.Verified
+						}
+						next.ServeHTTP(w, r.WithContext(auth.NewContextWithPrincipal(r.Context(), principal)))
+						return
+					}
+					s.Logger.Printf("access with a token that isn't an access token")
 				default:
-					// if too expired
+					// if expired
 					s.Logger.Printf("access with expired token")
 				}
 			}
 			// if not accepted
-			r.Header.Set("authorized_username", "---HerUsername25Letters--")
-			r.Header.Del("authorized_username_expired")
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// principalFromClaims is a helper function that builds an auth.Principal out
+// of the claims of a validated token.
+// Tokens that don't specify their scope are given all of them while tokens
+// that specify a type, like TOTP challenge tokens, aren't access tokens at all.
+func principalFromClaims(claimMap jwt.MapClaims) *auth.Principal {
+	if _, ok := claimMap["typ"]; ok {
+		return nil
+	}
+	username, ok := claimMap["sub"].(string)
+	if !ok || username == "" {
+		return nil
+	}
+	principal := &auth.Principal{Username: username, Scopes: []string{auth.ScopeAll}}
+	if jti, ok := claimMap["jti"].(string); ok {
+		principal.TokenID = jti
+	}
+	if fam, ok := claimMap["fam"].(string); ok {
+		principal.SessionID = fam
+	}
+	if clientID, ok := claimMap["client_id"].(string); ok {
+		principal.ClientID = clientID
+	}
+	if exp, ok := claimMap["exp"].(float64); ok {
+		principal.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	if scope, ok := claimMap["scope"].(string); ok && scope != "" {
+		principal.Scopes = strings.Fields(scope)
+	}
+	return principal
+}
+
 // CheckForAuthMiddleware blocks access if there's no valid credential's attached
 // on the request from the ParseAuthTokenMiddleware.
 func CheckForAuthMiddleware(s *Setup) func(next http.Handler) http.Handler {
@@ -77,12 +142,76 @@ func CheckForAuthMiddleware(s *Setup) func(next http.Handler) http.Handler {
 	}
 }
 
+// isAuthenticated is a helper function that checks whether ParseAuthTokenMiddleware
+// attached a principal to the request.
 func isAuthenticated(r *http.Request) bool {
-	authUsername := r.Header.Get("authorized_username")
-	if authUsername != "" && len(authUsername) < 25 {
-		return true
+	_, ok := auth.PrincipalFromContext(r.Context())
+	return ok
+}
+
+// isVerified is a helper function that checks whether the principal attached
+// to the request has verified their email.
+func isVerified(r *http.Request) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	return ok && principal.Verified
+}
+
+// writeVerificationRequiredResponse is a helper function that responds to
+// requests made by users that need to verify their email first.
+func writeVerificationRequiredResponse(s *Setup, w http.ResponseWriter) {
+	s.Logger.Printf("unverified user attempted a restricted action")
+	writeResponseToWriter(jSendResponse{
+		Status: "fail",
+		Data: jSendFailData{
+			ErrorReason:  "verification",
+			ErrorMessage: "email needs to be verified first",
+		},
+	}, w, http.StatusForbidden)
+}
+
+// clientIP is a helper function that returns the IP address the request came from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
-	return false
+	return host
+}
+
+// authorizedUsername is a helper function that returns the username of the principal
+// attached to the request or an empty string if the request isn't authenticated.
+func authorizedUsername(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.Username
+	}
+	return ""
+}
+
+// authorize is a helper function that consults the PolicyService on whether
+// the principal attached to the request may perform the action on the resource.
+func authorize(s *Setup, r *http.Request, action policy.Action, resource *policy.Resource) bool {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	return s.PolicyService.Can(principal, action, resource)
+}
+
+// channelResource describes the given channel to the policy.
+func channelResource(c *channel.Channel) *policy.Resource {
+	return &policy.Resource{ChannelOwner: c.OwnerUsername, ChannelAdmins: c.AdminUsernames}
+}
+
+// postResource describes the given post to the policy.
+func postResource(p *post.Post) *policy.Resource {
+	return &policy.Resource{PostAuthor: p.PostedByUsername}
+}
+
+// commentResource describes the given comment to the policy.
+func commentResource(c *comment.Comment) *policy.Resource {
+	return &policy.Resource{Commenter: c.Commenter}
+}
+
+// userResource describes the given user account to the policy.
+func userResource(username string) *policy.Resource {
+	return &policy.Resource{Username: username}
 }
 
 // postTokenAuth returns a handler for POST /token-auth requests
@@ -97,38 +226,87 @@ func postTokenAuth(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			response.Data = jSendFailData{
 				ErrorReason:  "request format",
-				ErrorMessage: `bad request, use format {"username":"username","password":"password"}`,
+				ErrorMessage: `bad request, use format {"username":"username or email","password":"password"}`,
 			}
 			s.Logger.Printf("bad auth request")
 			statusCode = http.StatusBadRequest
 		} else {
-			requestUser.Email = "" // remove after email auth is fully implemented
-			success, err := s.AuthService.Authenticate(requestUser)
+			identifier := requestUser.Username
+			if identifier == "" {
+				identifier = requestUser.Email
+			}
+			ip := clientIP(r)
+			// failures are counted against the user however it's identified, by
+			// username, email or in any case
+			username, err := s.AuthService.GetUsername(r.Context(), identifier)
+			switch err {
+			case nil:
+			case auth.ErrUserNotFound:
+				username = lockout.UnknownUsername
+			default:
+				s.Logger.Printf("resolving of username failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when authenticating"
+				writeResponseToWriter(response, w, http.StatusInternalServerError)
+				return
+			}
+			{ // this block blocks locked out usernames and addresses before any password is checked
+				wait, err := s.LockoutService.Check(r.Context(), username, ip)
+				if err != nil {
+					s.Logger.Printf("lockout check failed because: %v", err)
+					response.Status = "error"
+					response.Message = "server error when authenticating"
+					writeResponseToWriter(response, w, http.StatusInternalServerError)
+					return
+				}
+				if wait > 0 {
+					s.Logger.Printf("authentication attempt while locked out")
+					writeLockedOutResponse(w, response, wait)
+					return
+				}
+			}
+			// failures are only forgotten once tokens are issued, not after the
+			// first of two factors
+			issued := false
+			success, err := s.AuthService.Authenticate(r.Context(), requestUser)
 			switch err {
 			case nil:
 				if success {
-					{
-						if requestUser.Email != "" {
-							// todo email auth
-						}
-					}
-					tokenString, err := s.AuthService.GenerateToken(requestUser.Username)
+					totpEnabled, err := s.AuthService.IsTOTPEnabled(r.Context(), requestUser.Username)
 					if err != nil {
-						s.Logger.Printf("token generation failed because: %v", err)
+						s.Logger.Printf("checking for totp failed because: %v", err)
 						response.Status = "error"
 						response.Message = "server error when authenticating"
 						statusCode = http.StatusInternalServerError
+					} else if totpEnabled {
+						// a second step is needed, see postTokenAuthTOTP
+						challenge, err := s.AuthService.IssueTOTPChallenge(r.Context(), requestUser.Username)
+						if err != nil {
+							s.Logger.Printf("totp challenge generation failed because: %v", err)
+							response.Status = "error"
+							response.Message = "server error when authenticating"
+							statusCode = http.StatusInternalServerError
+						} else {
+							response.Status = "success"
+							response.Data = *challenge
+							s.Logger.Printf("user %s got totp challenge", requestUser.Username)
+						}
 					} else {
-						response.Status = "success"
-						var responseData struct {
-							Data string `json:"token"`
+						tokens, err := s.AuthService.IssueTokens(r.Context(), requestUser.Username, r.UserAgent(), clientIP(r))
+						if err != nil {
+							s.Logger.Printf("token generation failed because: %v", err)
+							response.Status = "error"
+							response.Message = "server error when authenticating"
+							statusCode = http.StatusInternalServerError
+						} else {
+							response.Status = "success"
+							response.Data = *tokens
+							issued = true
+							s.Logger.Printf("user %s got token", requestUser.Username)
 						}
-						responseData.Data = tokenString
-						response.Data = responseData
-						s.Logger.Printf("user %s got token", requestUser.Username)
 					}
 				} else {
-					s.Logger.Printf("unsuccessful authentication attempt on nonexisting user")
+					s.Logger.Printf("unsuccessful authentication attempt")
 					response.Data = jSendFailData{
 						ErrorReason:  "credentials",
 						ErrorMessage: "incorrect username or password",
@@ -136,7 +314,7 @@ func postTokenAuth(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					statusCode = http.StatusUnauthorized
 				}
 			case auth.ErrUserNotFound:
-				s.Logger.Printf("unsuccessful authentication attempt")
+				s.Logger.Printf("unsuccessful authentication attempt on nonexisting user")
 				response.Data = jSendFailData{
 					ErrorReason:  "credentials",
 					ErrorMessage: "incorrect username or password",
@@ -148,43 +326,828 @@ func postTokenAuth(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				response.Message = "server error when generating token"
 				statusCode = http.StatusInternalServerError
 			}
+			switch {
+			case issued:
+				if err := s.LockoutService.RecordSuccess(r.Context(), username); err != nil {
+					s.Logger.Printf("recording successful authentication failed because: %v", err)
+				}
+			case statusCode == http.StatusUnauthorized:
+				// failures are counted even if the client gives up on the response
+				wait, err := s.LockoutService.RecordFailure(context.WithoutCancel(r.Context()), username, ip)
+				if err != nil {
+					s.Logger.Printf("recording failed authentication failed because: %v", err)
+				} else if wait > 0 {
+					s.Logger.Printf("lockout after failed authentication attempt from %s", ip)
+					writeLockedOutResponse(w, response, wait)
+					return
+				}
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// writeLockedOutResponse is a helper function that responds to an authentication
+// attempt made while locked out, telling the client when to try again.
+func writeLockedOutResponse(w http.ResponseWriter, response jSendResponse, wait time.Duration) {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	response.Status = "fail"
+	response.Data = jSendFailData{
+		ErrorReason:  "lockout",
+		ErrorMessage: fmt.Sprintf("too many failed attempts, try again in %d seconds", retryAfter),
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeResponseToWriter(response, w, http.StatusTooManyRequests)
+}
+
+// postTokenAuthRefresh returns a handler for POST /token-auth-refresh requests
+func postTokenAuthRefresh(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		var requestData struct {
+			RefreshToken string `json:"refreshToken"`
+		}
+		{ // this block extracts the refresh token from the request
+			requestData.RefreshToken = r.FormValue("refreshToken")
+			if requestData.RefreshToken == "" {
+				err := json.NewDecoder(r.Body).Decode(&requestData)
+				if err != nil || requestData.RefreshToken == "" {
+					response.Data = jSendFailData{
+						ErrorReason:  "request format",
+						ErrorMessage: `bad request, use format {"refreshToken":"refreshToken"}`,
+					}
+					s.Logger.Printf("bad refresh request")
+					statusCode = http.StatusBadRequest
+				}
+			}
+		}
+		if response.Data == nil {
+			tokens, err := s.AuthService.RefreshTokens(r.Context(), requestData.RefreshToken, "")
+			switch err {
+			case nil:
+				response.Status = "success"
+				response.Data = *tokens
+				s.Logger.Printf("token refreshed")
+			case auth.ErrRefreshTokenReused:
+				s.Logger.Printf("reuse of refresh token detected, token family revoked")
+				fallthrough
+			case auth.ErrInvalidRefreshToken:
+				response.Data = jSendFailData{
+					ErrorReason:  "refreshToken",
+					ErrorMessage: "refresh token is invalid, expired or revoked",
+				}
+				statusCode = http.StatusUnauthorized
+			default:
+				s.Logger.Printf("token refresh failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when refreshing token"
+				statusCode = http.StatusInternalServerError
+			}
 		}
 		writeResponseToWriter(response, w, statusCode)
 	}
 }
 
-// getTokenAuthRefresh returns a handler for GET /token-auth-refresh requests
-func getTokenAuthRefresh(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+// postTokenAuthTOTP returns a handler for POST /token-auth-totp requests
+func postTokenAuthTOTP(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var response jSendResponse
 		statusCode := http.StatusOK
 		response.Status = "fail"
 
+		var requestData struct {
+			ChallengeToken string `json:"challengeToken"`
+			Code           string `json:"code"`
+		}
+		{ // this block extracts the challenge token and code from the request
+			requestData.ChallengeToken = r.FormValue("challengeToken")
+			requestData.Code = r.FormValue("code")
+			if requestData.ChallengeToken == "" {
+				err := json.NewDecoder(r.Body).Decode(&requestData)
+				if err != nil || requestData.ChallengeToken == "" || requestData.Code == "" {
+					response.Data = jSendFailData{
+						ErrorReason:  "request format",
+						ErrorMessage: `bad request, use format {"challengeToken":"challengeToken","code":"totp or recovery code"}`,
+					}
+					s.Logger.Printf("bad totp auth request")
+					statusCode = http.StatusBadRequest
+				}
+			}
+		}
+		ip := clientIP(r)
+		var username string
+		if response.Data == nil {
+			var err error
+			username, err = s.AuthService.GetTOTPChallengeUsername(r.Context(), requestData.ChallengeToken)
+			switch err {
+			case nil:
+			case auth.ErrInvalidTOTPChallenge:
+				response.Data = jSendFailData{
+					ErrorReason:  "challengeToken",
+					ErrorMessage: "challenge token is invalid, expired or used, authenticate again",
+				}
+				statusCode = http.StatusUnauthorized
+			default:
+				s.Logger.Printf("totp challenge check failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when authenticating"
+				writeResponseToWriter(response, w, http.StatusInternalServerError)
+				return
+			}
+		}
+		if response.Data == nil {
+			// codes are only six digits long, guessing them is limited by both
+			// the user the challenge was issued to and the address
+			wait, err := s.LockoutService.Check(r.Context(), username, ip)
+			if err != nil {
+				s.Logger.Printf("lockout check failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when authenticating"
+				writeResponseToWriter(response, w, http.StatusInternalServerError)
+				return
+			}
+			if wait > 0 {
+				s.Logger.Printf("totp challenge attempt while locked out")
+				writeLockedOutResponse(w, response, wait)
+				return
+			}
+		}
+		if response.Data == nil {
+			tokens, err := s.AuthService.CompleteTOTPChallenge(r.Context(), requestData.ChallengeToken, requestData.Code, r.UserAgent(), ip)
+			switch err {
+			case nil:
+				response.Status = "success"
+				response.Data = *tokens
+				s.Logger.Printf("totp challenge completed")
+				if err := s.LockoutService.RecordSuccess(r.Context(), username); err != nil {
+					s.Logger.Printf("recording successful authentication failed because: %v", err)
+				}
+			case auth.ErrInvalidTOTPChallenge:
+				response.Data = jSendFailData{
+					ErrorReason:  "challengeToken",
+					ErrorMessage: "challenge token is invalid, expired or used, authenticate again",
+				}
+				statusCode = http.StatusUnauthorized
+			case auth.ErrInvalidTOTPCode:
+				s.Logger.Printf("totp challenge attempted with invalid code")
+				response.Data = jSendFailData{
+					ErrorReason:  "code",
+					ErrorMessage: "code is invalid, authenticate again",
+				}
+				statusCode = http.StatusUnauthorized
+				// failures are counted even if the client gives up on the response
+				if wait, err := s.LockoutService.RecordFailure(context.WithoutCancel(r.Context()), username, ip); err != nil {
+					s.Logger.Printf("recording failed totp attempt failed because: %v", err)
+				} else if wait > 0 {
+					s.Logger.Printf("lockout after failed totp attempt from %s", ip)
+					writeLockedOutResponse(w, response, wait)
+					return
+				}
+			default:
+				s.Logger.Printf("totp auth failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when authenticating"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postUserTOTP returns a handler for POST /users/{username}/totp requests
+func postUserTOTP(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
 		{ // this block secures the route
-			if isAuthenticated(r) {
-				// pass, all is good
-			} else if r.Header.Get("authorized_username_expired") != "" {
-				r.Header.Set("authorized_username", r.Header.Get("authorized_username_expired"))
+			if !authorize(s, r, policy.UserManageTOTP, userResource(username)) {
+				s.Logger.Printf("unauthorized totp enrolment request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		enrollment, err := s.AuthService.EnrollTOTP(r.Context(), username)
+		switch err {
+		case nil:
+			response.Status = "success"
+			response.Data = *enrollment
+			s.Logger.Printf("user %s started totp enrolment", username)
+		case auth.ErrTOTPAlreadyEnabled:
+			response.Data = jSendFailData{
+				ErrorReason:  "totp",
+				ErrorMessage: "totp is already enabled, disable it first to enrol again",
+			}
+			statusCode = http.StatusConflict
+		default:
+			s.Logger.Printf("totp enrolment failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when enrolling totp"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postUserTOTPConfirm returns a handler for POST /users/{username}/totp/confirm requests
+func postUserTOTPConfirm(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageTOTP, userResource(username)) {
+				s.Logger.Printf("unauthorized totp confirm request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		code, ok := totpCodeFromRequest(r)
+		if !ok {
+			response.Data = jSendFailData{
+				ErrorReason:  "request format",
+				ErrorMessage: `bad request, use format {"code":"totp code"}`,
+			}
+			statusCode = http.StatusBadRequest
+		} else {
+			err := s.AuthService.ConfirmTOTP(r.Context(), username, code)
+			switch err {
+			case nil:
+				response.Status = "success"
+				s.Logger.Printf("user %s enabled totp", username)
+			case auth.ErrTOTPNotEnrolled:
+				response.Data = jSendFailData{
+					ErrorReason:  "totp",
+					ErrorMessage: "no totp enrolment to confirm",
+				}
+				statusCode = http.StatusNotFound
+			case auth.ErrTOTPAlreadyEnabled:
+				response.Data = jSendFailData{
+					ErrorReason:  "totp",
+					ErrorMessage: "totp is already enabled",
+				}
+				statusCode = http.StatusConflict
+			case auth.ErrInvalidTOTPCode:
+				response.Data = jSendFailData{
+					ErrorReason:  "code",
+					ErrorMessage: "code is invalid",
+				}
+				statusCode = http.StatusBadRequest
+			default:
+				s.Logger.Printf("totp confirm failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when confirming totp"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// deleteUserTOTP returns a handler for DELETE /users/{username}/totp requests
+func deleteUserTOTP(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageTOTP, userResource(username)) {
+				s.Logger.Printf("unauthorized totp disable request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		code, _ := totpCodeFromRequest(r)
+		err := s.AuthService.DisableTOTP(r.Context(), username, code)
+		switch err {
+		case nil:
+			response.Status = "success"
+			s.Logger.Printf("user %s disabled totp", username)
+		case auth.ErrTOTPNotEnrolled:
+			response.Data = jSendFailData{
+				ErrorReason:  "totp",
+				ErrorMessage: "totp is not enabled",
+			}
+			statusCode = http.StatusNotFound
+		case auth.ErrInvalidTOTPCode:
+			response.Data = jSendFailData{
+				ErrorReason:  "code",
+				ErrorMessage: "a valid totp or recovery code is required to disable totp",
+			}
+			statusCode = http.StatusBadRequest
+		default:
+			s.Logger.Printf("totp disable failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when disabling totp"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// totpCodeFromRequest is a helper function that extracts a TOTP or recovery
+// code from either a form value or a JSON body.
+func totpCodeFromRequest(r *http.Request) (string, bool) {
+	var requestData struct {
+		Code string `json:"code"`
+	}
+	requestData.Code = r.FormValue("code")
+	if requestData.Code == "" {
+		err := json.NewDecoder(r.Body).Decode(&requestData)
+		if err != nil || requestData.Code == "" {
+			return "", false
+		}
+	}
+	return requestData.Code, true
+}
+
+// getUserTokens returns a handler for GET /users/{username}/tokens requests
+func getUserTokens(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageTokens, userResource(username)) {
+				s.Logger.Printf("unauthorized personal access token list request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		pats, err := s.AuthService.GetPersonalAccessTokens(r.Context(), username)
+		if err != nil {
+			s.Logger.Printf("fetching personal access tokens failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when fetching personal access tokens"
+			statusCode = http.StatusInternalServerError
+		} else {
+			response.Status = "success"
+			response.Data = pats
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postUserToken returns a handler for POST /users/{username}/tokens requests
+func postUserToken(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageTokens, userResource(username)) {
+				s.Logger.Printf("unauthorized personal access token creation request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		var requestData struct {
+			Name          string   `json:"name"`
+			Scopes        []string `json:"scopes"`
+			ExpiresInDays int      `json:"expiresInDays"`
+		}
+		{ // this block extracts the token details from the request
+			requestData.Name = r.FormValue("name")
+			if requestData.Name != "" {
+				requestData.Scopes = strings.Fields(r.FormValue("scopes"))
+				if days := r.FormValue("expiresInDays"); days != "" {
+					var err error
+					if requestData.ExpiresInDays, err = strconv.Atoi(days); err != nil {
+						requestData.ExpiresInDays = -1
+					}
+				}
 			} else {
-				s.Logger.Printf("unauthorized refresh request")
-				w.WriteHeader(http.StatusUnauthorized)
+				err := json.NewDecoder(r.Body).Decode(&requestData)
+				if err != nil {
+					requestData.Name = ""
+				}
+			}
+			if requestData.Name == "" || len(requestData.Scopes) == 0 || requestData.ExpiresInDays < 0 {
+				response.Data = jSendFailData{
+					ErrorReason:  "request format",
+					ErrorMessage: `bad request, use format {"name":"name","scopes":["scope"],"expiresInDays":0}`,
+				}
+				s.Logger.Printf("bad personal access token creation request")
+				statusCode = http.StatusBadRequest
+			}
+		}
+		if response.Data == nil {
+			lifetime := time.Duration(requestData.ExpiresInDays) * 24 * time.Hour
+			pat, err := s.AuthService.CreatePersonalAccessToken(r.Context(), username, requestData.Name, requestData.Scopes, lifetime)
+			switch err {
+			case nil:
+				response.Status = "success"
+				response.Data = *pat
+				statusCode = http.StatusCreated
+				s.Logger.Printf("user %s created personal access token %s", username, pat.ID)
+			case auth.ErrInvalidScope:
+				response.Data = jSendFailData{
+					ErrorReason:  "scopes",
+					ErrorMessage: fmt.Sprintf("scopes must be some of: %s", strings.Join(auth.GrantableScopes, ", ")),
+				}
+				statusCode = http.StatusBadRequest
+			default:
+				s.Logger.Printf("personal access token creation failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when creating personal access token"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// deleteUserToken returns a handler for DELETE /users/{username}/tokens/{tokenID} requests
+func deleteUserToken(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		tokenID := vars["tokenID"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageTokens, userResource(username)) {
+				s.Logger.Printf("unauthorized personal access token revocation request")
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
-		tokenString, err := s.AuthService.GenerateToken(r.Header.Get("authorized_username"))
+		err := s.AuthService.RevokePersonalAccessToken(r.Context(), username, tokenID)
+		switch err {
+		case nil:
+			response.Status = "success"
+			s.Logger.Printf("user %s revoked personal access token %s", username, tokenID)
+		case auth.ErrPersonalAccessTokenNotFound:
+			response.Data = jSendFailData{
+				ErrorReason:  "tokenID",
+				ErrorMessage: fmt.Sprintf("personal access token of id %s not found", tokenID),
+			}
+			statusCode = http.StatusNotFound
+		default:
+			s.Logger.Printf("personal access token revocation failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when revoking personal access token"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// getUserSessions returns a handler for GET /users/{username}/sessions requests
+func getUserSessions(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageSessions, userResource(username)) {
+				s.Logger.Printf("unauthorized session list request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		sessions, err := s.AuthService.GetSessions(r.Context(), username)
+		if err != nil {
+			s.Logger.Printf("fetching sessions failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when fetching sessions"
+			statusCode = http.StatusInternalServerError
+		} else {
+			principal, _ := auth.PrincipalFromContext(r.Context())
+			for _, session := range sessions {
+				session.Current = session.ID == principal.SessionID
+			}
+			response.Status = "success"
+			response.Data = sessions
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// deleteUserSessions returns a handler for DELETE /users/{username}/sessions requests
+// The session the request was made with is kept if the keepCurrent query is true.
+func deleteUserSessions(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageSessions, userResource(username)) {
+				s.Logger.Printf("unauthorized session revocation request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		exceptSessionID := ""
+		if keepCurrent, _ := strconv.ParseBool(r.URL.Query().Get("keepCurrent")); keepCurrent {
+			principal, _ := auth.PrincipalFromContext(r.Context())
+			exceptSessionID = principal.SessionID
+		}
+		err := s.AuthService.RevokeAllSessions(r.Context(), username, exceptSessionID)
 		if err != nil {
-			s.Logger.Printf("token generation failed because: %v", err)
+			s.Logger.Printf("session revocation failed because: %v", err)
 			response.Status = "error"
-			response.Message = "server error when authenticating"
+			response.Message = "server error when revoking sessions"
 			statusCode = http.StatusInternalServerError
 		} else {
 			response.Status = "success"
-			var responseData struct {
-				Data string `json:"token"`
+			s.Logger.Printf("user %s revoked all sessions", username)
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// deleteUserSession returns a handler for DELETE /users/{username}/sessions/{sessionID} requests
+func deleteUserSession(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		sessionID := vars["sessionID"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageSessions, userResource(username)) {
+				s.Logger.Printf("unauthorized session revocation request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		err := s.AuthService.RevokeSession(r.Context(), username, sessionID)
+		switch err {
+		case nil:
+			response.Status = "success"
+			s.Logger.Printf("user %s revoked session %s", username, sessionID)
+		case auth.ErrSessionNotFound:
+			response.Data = jSendFailData{
+				ErrorReason:  "sessionID",
+				ErrorMessage: fmt.Sprintf("session of id %s not found", sessionID),
+			}
+			statusCode = http.StatusNotFound
+		default:
+			s.Logger.Printf("session revocation failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when revoking session"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// getJWKS returns a handler for GET /.well-known/jwks.json requests
+func getJWKS(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// served as a bare RFC 7517 document rather than in a JSend envelope
+		// since that's what verifiers expect to find here
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(s.AuthService.GetJSONWebKeySet()); err != nil {
+			s.Logger.Printf("writing jwks failed because: %v", err)
+		}
+	}
+}
+
+// postPasswordReset returns a handler for POST /password-reset requests
+func postPasswordReset(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		var requestData struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		}
+		{ // this block extracts the identifier of the user from the request
+			requestData.Username = r.FormValue("username")
+			requestData.Email = r.FormValue("email")
+			if requestData.Username == "" && requestData.Email == "" {
+				err := json.NewDecoder(r.Body).Decode(&requestData)
+				if err != nil || (requestData.Username == "" && requestData.Email == "") {
+					response.Data = jSendFailData{
+						ErrorReason:  "request format",
+						ErrorMessage: `bad request, use format {"username":"username"} or {"email":"email"}`,
+					}
+					s.Logger.Printf("bad password reset request")
+					statusCode = http.StatusBadRequest
+				}
+			}
+		}
+		if response.Data == nil {
+			identifier := requestData.Username
+			if identifier == "" {
+				identifier = requestData.Email
+			}
+			err := s.AuthService.RequestPasswordReset(r.Context(), identifier)
+			switch err {
+			case nil:
+				s.Logger.Printf("password reset token issued")
+				fallthrough
+			case auth.ErrUserNotFound:
+				// success is reported either way so as not to disclose which accounts exist
+				response.Status = "success"
+				response.Message = "if the account exists, a password reset token has been mailed to it"
+			default:
+				s.Logger.Printf("password reset request failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when requesting password reset"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postPasswordResetConfirm returns a handler for POST /password-reset/confirm requests
+func postPasswordResetConfirm(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		var requestData struct {
+			Token    string `json:"token"`
+			Password string `json:"password"`
+		}
+		{ // this block extracts the reset token and new password from the request
+			requestData.Token = r.FormValue("token")
+			requestData.Password = r.FormValue("password")
+			if requestData.Token == "" {
+				err := json.NewDecoder(r.Body).Decode(&requestData)
+				if err != nil || requestData.Token == "" {
+					response.Data = jSendFailData{
+						ErrorReason:  "request format",
+						ErrorMessage: `bad request, use format {"token":"token","password":"password"}`,
+					}
+					s.Logger.Printf("bad password reset confirm request")
+					statusCode = http.StatusBadRequest
+				}
+			}
+		}
+		if response.Data == nil {
+			// the password is only reset if the sessions of the user are revoked too
+			err := s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+				return s.AuthService.ResetPassword(ctx, requestData.Token, requestData.Password)
+			})
+			switch err {
+			case nil:
+				response.Status = "success"
+				s.Logger.Printf("password was reset")
+			case auth.ErrInvalidPassword:
+				response.Data = jSendFailData{
+					ErrorReason:  "password",
+					ErrorMessage: "password length should be at least 8 chars",
+				}
+				statusCode = http.StatusBadRequest
+			case auth.ErrInvalidPasswordResetToken, auth.ErrUserNotFound:
+				response.Data = jSendFailData{
+					ErrorReason:  "token",
+					ErrorMessage: "password reset token is invalid, expired or used",
+				}
+				statusCode = http.StatusUnauthorized
+			default:
+				s.Logger.Printf("password reset failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when resetting password"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postEmailVerification returns a handler for POST /email-verification requests
+func postEmailVerification(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		var requestData struct {
+			Token string `json:"token"`
+		}
+		{ // this block extracts the verification token from the request
+			requestData.Token = r.FormValue("token")
+			if requestData.Token == "" {
+				err := json.NewDecoder(r.Body).Decode(&requestData)
+				if err != nil || requestData.Token == "" {
+					response.Data = jSendFailData{
+						ErrorReason:  "request format",
+						ErrorMessage: `bad request, use format {"token":"token"}`,
+					}
+					s.Logger.Printf("bad email verification request")
+					statusCode = http.StatusBadRequest
+				}
+			}
+		}
+		if response.Data == nil {
+			username, err := s.AuthService.VerifyEmail(r.Context(), requestData.Token)
+			if err == nil {
+				err = s.UserService.MarkVerified(r.Context(), username)
+			}
+			switch err {
+			case nil:
+				response.Status = "success"
+				s.Logger.Printf("user %s verified their email", username)
+			case auth.ErrInvalidEmailVerificationToken, user.ErrUserNotFound:
+				response.Data = jSendFailData{
+					ErrorReason:  "token",
+					ErrorMessage: "email verification token is invalid, expired or used",
+				}
+				statusCode = http.StatusUnauthorized
+			default:
+				s.Logger.Printf("email verification failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when verifying email"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postUserEmailVerification returns a handler for POST /users/{username}/email-verification requests
+func postUserEmailVerification(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserUpdate, userResource(username)) {
+				s.Logger.Printf("unauthorized email verification request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		// it's the user of the route that's checked, not the one making the request
+		u, err := s.UserService.GetUser(r.Context(), username)
+		switch {
+		case err == user.ErrUserNotFound:
+			response.Data = jSendFailData{
+				ErrorReason:  "username",
+				ErrorMessage: fmt.Sprintf("user of username %s not found", username),
+			}
+			statusCode = http.StatusNotFound
+		case err != nil:
+			s.Logger.Printf("email verification request failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when requesting email verification"
+			statusCode = http.StatusInternalServerError
+		case u.Verified:
+			response.Data = jSendFailData{
+				ErrorReason:  "verified",
+				ErrorMessage: "email is already verified",
+			}
+			statusCode = http.StatusConflict
+		default:
+			err = s.AuthService.RequestEmailVerification(r.Context(), username)
+			switch err {
+			case nil:
+				response.Status = "success"
+				s.Logger.Printf("email verification mailed to user %s", username)
+			case auth.ErrEmailVerificationThrottled:
+				response.Data = jSendFailData{
+					ErrorReason:  "throttled",
+					ErrorMessage: "an email verification has been requested recently, try again later",
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(int(s.EmailVerificationResendInterval.Seconds())))
+				statusCode = http.StatusTooManyRequests
+			default:
+				s.Logger.Printf("email verification request failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when requesting email verification"
+				statusCode = http.StatusInternalServerError
 			}
-			responseData.Data = tokenString
-			response.Data = responseData
-			s.Logger.Printf("user %s refreshed token", r.Header.Get("authorized_username"))
 		}
 		writeResponseToWriter(response, w, statusCode)
 	}
@@ -197,6 +1160,14 @@ func getLogout(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		statusCode := http.StatusOK
 		response.Status = "fail"
 
+		if principal, _ := auth.PrincipalFromContext(r.Context()); principal.TokenType == auth.TokenTypePersonalAccessToken {
+			response.Data = jSendFailData{
+				ErrorReason:  "token",
+				ErrorMessage: "personal access tokens are revoked through /users/{username}/tokens",
+			}
+			writeResponseToWriter(response, w, http.StatusBadRequest)
+			return
+		}
 		err := invalidateAttachedToken(r, s)
 		if err != nil {
 			s.Logger.Printf("logout failed because: %v", err)
@@ -212,7 +1183,11 @@ func getLogout(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 }
 
 // invalidateAttachedToken is a helper function.
+// It also revokes the refresh tokens issued along side the attached token.
 func invalidateAttachedToken(req *http.Request, s *Setup) error {
 	tokenString := req.Header.Get("Authorization")
-	return s.AuthService.AddToBlacklist(tokenString)
+	if err := s.AuthService.RevokeTokenFamily(req.Context(), tokenString); err != nil {
+		return err
+	}
+	return s.AuthService.AddToBlacklist(req.Context(), tokenString)
 }
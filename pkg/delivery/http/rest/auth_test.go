@@ -0,0 +1,251 @@
+package rest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/inmemory"
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+)
+
+var errLookup = fmt.Errorf("lookup failed")
+
+// failingAuthRepository is an auth.Repository whose blacklist or session
+// lookups fail.
+type failingAuthRepository struct {
+	auth.Repository
+	blacklist, sessions bool
+}
+
+func (r failingAuthRepository) IsInBlacklist(ctx context.Context, tokenID string) (bool, error) {
+	if r.blacklist {
+		return false, errLookup
+	}
+	return r.Repository.IsInBlacklist(ctx, tokenID)
+}
+
+func (r failingAuthRepository) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	if r.sessions {
+		return false, errLookup
+	}
+	return r.Repository.IsSessionRevoked(ctx, id)
+}
+
+// withFailingAuthRepository has the server use an auth service backed by the
+// failingAuthRepository until the returned function is called.
+func withFailingAuthRepository(s *resttest.Server, failing failingAuthRepository) (restore func()) {
+	failing.Repository = inmemory.NewAuthRepository(inmemory.NewStore())
+	var repo auth.Repository = failing
+	service := s.Setup.AuthService
+	s.Setup.AuthService = auth.NewAuthService(&repo, s.Mailbox, s.Setup.Config.Config)
+	return func() { s.Setup.AuthService = service }
+}
+
+// secureRoutes lists requests to routes that need a token, by user alice.
+var secureRoutes = []struct{ method, path string }{
+	{http.MethodPut, "/users/alice"},
+	{http.MethodDelete, "/users/alice"},
+	{http.MethodGet, "/users/alice/bookmarks"},
+	{http.MethodPut, "/users/alice/bookmarks/1"},
+	{http.MethodPut, "/users/alice/picture"},
+	{http.MethodGet, "/users/alice/feed"},
+	{http.MethodPost, "/users/alice/feed/channels"},
+	{http.MethodGet, "/users/alice/sessions"},
+	{http.MethodPost, "/users/alice/tokens"},
+	{http.MethodPost, "/users/alice/email-verification"},
+	{http.MethodPost, "/users/alice/totp"},
+	{http.MethodPut, "/channels/alice"},
+	{http.MethodDelete, "/channels/alice"},
+	{http.MethodPut, "/channels/alice/admins/bobby"},
+	{http.MethodPost, "/posts"},
+	{http.MethodPut, "/posts/1"},
+	{http.MethodDelete, "/posts/1"},
+	{http.MethodPut, "/posts/1/stars"},
+	{http.MethodPost, "/posts/1/comments"},
+	{http.MethodPost, "/releases"},
+	{http.MethodDelete, "/releases/1"},
+	{http.MethodGet, "/logout"},
+}
+
+var authScenarios = []scenario{
+	{"auth/token", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+
+		r := s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": resttest.Password})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "auth/token", r)
+
+		r = s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": "not the password"})
+		r.ExpectFail(t, http.StatusUnauthorized, "credentials")
+		g.Check(t, "auth/token-wrong-password", r)
+
+		r = s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "nobody", "password": resttest.Password})
+		r.ExpectFail(t, http.StatusUnauthorized, "credentials")
+
+		r = s.Do(t, http.MethodPost, "/token-auth", "", []byte("username=alice"))
+		r.ExpectFail(t, http.StatusBadRequest, "request format")
+		g.Check(t, "auth/token-bad-request", r)
+	}},
+	{"auth/secure routes need a token", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.NewUser(t, "alice")
+		for _, route := range secureRoutes {
+			s.Do(t, route.method, route.path, "", nil).ExpectStatus(t, http.StatusUnauthorized)
+			s.Do(t, route.method, route.path, "not a token", nil).ExpectStatus(t, http.StatusUnauthorized)
+		}
+	}},
+	{"auth/lockout", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+		// failures count against alice whether she's identified by username or email
+		identifiers := []string{"alice", resttest.Email("alice")}
+		for i := 1; i < s.Setup.Lockout.MaxUsernameFailures; i++ {
+			s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": identifiers[i%2], "password": "guess"}).
+				ExpectFail(t, http.StatusUnauthorized, "credentials")
+		}
+		r := s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": "guess"})
+		r.ExpectFail(t, http.StatusTooManyRequests, "lockout")
+		if r.Header.Get("Retry-After") == "" {
+			t.Errorf("%v locked out without a Retry-After header", r)
+		}
+		s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": resttest.Password}).
+			ExpectFail(t, http.StatusTooManyRequests, "lockout")
+	}},
+	{"auth/lockout of unknown users", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+		// making up a new username for every attempt doesn't dodge the lockout
+		for i := 1; i < s.Setup.Lockout.MaxUsernameFailures; i++ {
+			s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": fmt.Sprint("nobody", i), "password": "guess"}).
+				ExpectFail(t, http.StatusUnauthorized, "credentials")
+		}
+		s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "nobody", "password": "guess"}).
+			ExpectFail(t, http.StatusTooManyRequests, "lockout")
+		s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "nobody@example.com", "password": "guess"}).
+			ExpectFail(t, http.StatusTooManyRequests, "lockout")
+		s.Login(t, "alice", resttest.Password)
+	}},
+	{"auth/totp", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		s.NewUser(t, "bobby")
+		var enrollment struct {
+			Secret        string   `json:"secret"`
+			RecoveryCodes []string `json:"recoveryCodes"`
+		}
+		s.Do(t, http.MethodPost, "/users/alice/totp", token, nil).ExpectSuccess(t, http.StatusOK, &enrollment)
+		s.Do(t, http.MethodPost, "/users/alice/totp/confirm", token, map[string]string{"code": resttest.TOTPCode(t, enrollment.Secret)}).
+			ExpectSuccess(t, http.StatusOK, nil)
+
+		challenge := func() string {
+			var c struct {
+				ChallengeToken string `json:"challengeToken"`
+			}
+			s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": resttest.Password}).
+				ExpectSuccess(t, http.StatusOK, &c)
+			return c.ChallengeToken
+		}
+		// a challenge is good for a single attempt
+		c := challenge()
+		s.Do(t, http.MethodPost, "/token-auth-totp", "", map[string]string{"challengeToken": c, "code": "guess"}).
+			ExpectFail(t, http.StatusUnauthorized, "code")
+		s.Do(t, http.MethodPost, "/token-auth-totp", "", map[string]string{"challengeToken": c, "code": enrollment.RecoveryCodes[0]}).
+			ExpectFail(t, http.StatusUnauthorized, "challengeToken")
+		s.Do(t, http.MethodPost, "/token-auth-totp", "", map[string]string{"challengeToken": challenge(), "code": enrollment.RecoveryCodes[0]}).
+			ExpectSuccess(t, http.StatusOK, nil)
+
+		// wrong codes count against alice, even with the password logins in between
+		for i := 1; i < s.Setup.Lockout.MaxUsernameFailures; i++ {
+			s.Do(t, http.MethodPost, "/token-auth-totp", "", map[string]string{"challengeToken": challenge(), "code": "guess"}).
+				ExpectFail(t, http.StatusUnauthorized, "code")
+		}
+		s.Do(t, http.MethodPost, "/token-auth-totp", "", map[string]string{"challengeToken": challenge(), "code": "guess"}).
+			ExpectFail(t, http.StatusTooManyRequests, "lockout")
+		s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": resttest.Password}).
+			ExpectFail(t, http.StatusTooManyRequests, "lockout")
+		s.Login(t, "bobby", resttest.Password)
+	}},
+	{"auth/refresh", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+		var tokens struct {
+			RefreshToken string `json:"refreshToken"`
+		}
+		s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": resttest.Password}).
+			ExpectSuccess(t, http.StatusOK, &tokens)
+
+		r := s.Do(t, http.MethodPost, "/token-auth-refresh", "", map[string]string{"refreshToken": tokens.RefreshToken})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "auth/refresh", r)
+
+		// refresh tokens are rotated, reusing one is refused
+		s.Do(t, http.MethodPost, "/token-auth-refresh", "", map[string]string{"refreshToken": tokens.RefreshToken}).
+			ExpectStatus(t, http.StatusUnauthorized)
+	}},
+	{"auth/logout", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		s.Do(t, http.MethodGet, "/users/alice/bookmarks", token, nil).ExpectSuccess(t, http.StatusOK, nil)
+
+		r := s.Do(t, http.MethodGet, "/logout", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "auth/logout", r)
+
+		s.Do(t, http.MethodGet, "/users/alice/bookmarks", token, nil).ExpectStatus(t, http.StatusUnauthorized)
+	}},
+	{"auth/failing token lookups", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+
+		// tokens that can't be checked against the blacklist or their session aren't accepted
+		for _, failing := range []failingAuthRepository{{blacklist: true}, {sessions: true}} {
+			restore := withFailingAuthRepository(s, failing)
+			s.Do(t, http.MethodGet, "/users/alice/bookmarks", token, nil).ExpectStatus(t, http.StatusUnauthorized)
+			restore()
+		}
+		s.Do(t, http.MethodGet, "/users/alice/bookmarks", token, nil).ExpectSuccess(t, http.StatusOK, nil)
+	}},
+	{"auth/password reset", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+		var tokens struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refreshToken"`
+		}
+		s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": "alice", "password": resttest.Password}).
+			ExpectSuccess(t, http.StatusOK, &tokens)
+
+		s.Do(t, http.MethodPost, "/password-reset", "", map[string]string{"username": "alice"}).ExpectSuccess(t, http.StatusOK, nil)
+		resetToken, err := s.Mailbox.Token(resttest.Email("alice"), "password reset")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Do(t, http.MethodPost, "/password-reset/confirm", "", map[string]string{"token": resetToken, "password": "new password"}).
+			ExpectSuccess(t, http.StatusOK, nil)
+
+		// whoever held the old password is logged out
+		s.Do(t, http.MethodGet, "/users/alice/sessions", tokens.Token, nil).ExpectStatus(t, http.StatusUnauthorized)
+		s.Do(t, http.MethodPost, "/token-auth-refresh", "", map[string]string{"refreshToken": tokens.RefreshToken}).
+			ExpectStatus(t, http.StatusUnauthorized)
+		s.Do(t, http.MethodPost, "/password-reset/confirm", "", map[string]string{"token": resetToken, "password": "newer password"}).
+			ExpectFail(t, http.StatusUnauthorized, "token")
+		s.Login(t, "alice", "new password")
+	}},
+	{"auth/email verification", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+		r := s.Do(t, http.MethodPost, "/email-verification", "", map[string]string{"token": "not a token"})
+		r.ExpectFail(t, http.StatusUnauthorized, "token")
+		g.Check(t, "auth/email-verification-bad-token", r)
+
+		token := s.Login(t, "alice", resttest.Password)
+		s.VerifyEmail(t, "alice")
+		// no more verification mails are sent to verified users
+		s.Do(t, http.MethodPost, "/users/alice/email-verification", token, nil).ExpectFail(t, http.StatusConflict, "verified")
+		var u struct {
+			Verified bool `json:"verified"`
+		}
+		s.Do(t, http.MethodGet, "/users/alice", token, nil).ExpectSuccess(t, http.StatusOK, &u)
+		if !u.Verified {
+			t.Errorf("alice isn't verified after verifying the email")
+		}
+	}},
+}
+
+func TestAuth(t *testing.T) {
+	run(t, authScenarios)
+}
@@ -1,14 +1,15 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html"
-	"net/url"
-	"os"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 
 	"strconv"
 	"strings"
@@ -36,15 +37,15 @@ func getChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 
 		s.Logger.Printf("trying to fetch channel %s", channelUsername)
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 
 		switch err {
 		case nil:
 			response.Status = "success"
 			{
 				// this block sanitizes the returned User if it's not the user herself accessing the route
-				if channelUsername != r.Header.Get("authorized_username") {
-					s.Logger.Printf("user %s fetched channel %s", r.Header.Get("authorized_username"), c.ChannelUsername)
+				if !authorize(s, r, policy.ChannelViewPrivate, channelResource(c)) {
+					s.Logger.Printf("user %s fetched channel %s", authorizedUsername(r), c.ChannelUsername)
 					c.AdminUsernames = nil
 					c.ReleaseIDs = nil
 					c.OwnerUsername = ""
@@ -53,7 +54,7 @@ func getChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			if c.PictureURL != "" {
-				c.PictureURL = s.HostAddress + s.ImageServingRoute + url.PathEscape(c.PictureURL)
+				c.PictureURL, c.PictureURLs = imageURLs(r.Context(), s, c.PictureURL)
 			}
 			response.Data = *c
 			s.Logger.Printf("success fetching channel %s", channelUsername)
@@ -82,7 +83,16 @@ func getChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 // postChannel returns a handler for POST /channels requests
 func postChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		//TODO authorization
+		owner := authorizedUsername(r)
+		if !authorize(s, r, policy.ChannelCreate, userResource(owner)) {
+			if !isVerified(r) {
+				writeVerificationRequiredResponse(s, w)
+				return
+			}
+			s.Logger.Printf("unauthorized add channel attempt")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
 		var response jSendResponse
 		response.Status = "fail"
 		statusCode := http.StatusOK
@@ -133,10 +143,9 @@ func postChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			if response.Data == nil {
 				s.Logger.Printf("trying to add channel %s %s %s ", c.ChannelUsername, c.Name, c.Description)
 				if &c != nil {
-					owner := r.Header.Get("authorized_username")
 					c.OwnerUsername = owner
 					c.AdminUsernames = append(c.AdminUsernames, owner)
-					a, err := s.ChannelService.AddChannel(c)
+					a, err := s.ChannelService.AddChannel(r.Context(), c)
 					switch err {
 					case nil:
 						response.Status = "success"
@@ -159,9 +168,11 @@ func postChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 						}
 
 						statusCode = http.StatusConflict
-
+					case policy.ErrForbidden:
+						s.Logger.Printf("unauthorized add channel attempt")
+						w.WriteHeader(http.StatusForbidden)
+						return
 					default:
-						_ = s.ChannelService.DeleteChannel(c.ChannelUsername)
 						s.Logger.Printf("adding of channel failed because: %s", err.Error())
 						response.Data = jSendFailData{
 							ErrorReason:  "Server Error",
@@ -193,27 +204,16 @@ func putChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users updating of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized update channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelUpdate, channelResource(c)) {
+				s.Logger.Printf("unauthorized update channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		var c channel.Channel
@@ -235,7 +235,7 @@ func putChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 
 				statusCode = http.StatusBadRequest
 			} else {
-				a, err := s.ChannelService.UpdateChannel(channelUsername, &c)
+				a, err := s.ChannelService.UpdateChannel(r.Context(), channelUsername, &c)
 				switch err {
 				case nil:
 					s.Logger.Printf("success put channel %s", channelUsername)
@@ -243,7 +243,7 @@ func putChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					if c.ChannelUsername != "" {
 						channelUsername = c.ChannelUsername
 					}
-					a, _ = s.ChannelService.GetChannel(channelUsername)
+					a, _ = s.ChannelService.GetChannel(r.Context(), channelUsername)
 					response.Data = a
 				case channel.ErrUserNameOccupied:
 					s.Logger.Printf("adding of channel failed because: %s", err.Error())
@@ -335,7 +335,7 @@ func getChannels(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 		if response.Data == nil {
-			channels, err := s.ChannelService.SearchChannels(pattern, sortBy, sortOrder, limit, offset)
+			channels, err := s.ChannelService.SearchChannels(r.Context(), pattern, sortBy, sortOrder, limit, offset)
 			if err != nil {
 				s.Logger.Printf("fetching of channels failed because: %s", err.Error())
 				response.Data = jSendFailData{
@@ -350,7 +350,7 @@ func getChannels(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					c.ReleaseIDs = nil
 					c.OwnerUsername = ""
 					if c.PictureURL != "" {
-						c.PictureURL = s.HostAddress + s.ImageServingRoute + url.PathEscape(c.PictureURL)
+						c.PictureURL, c.PictureURLs = imageURLs(r.Context(), s, c.PictureURL)
 					}
 				}
 				response.Data = channels
@@ -372,31 +372,20 @@ func deleteChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users deleting of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if !one {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized delete channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelDelete, channelResource(c)) {
+				s.Logger.Printf("unauthorized delete channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		s.Logger.Printf("trying to delete channel %s", channelUsername)
-		err = s.ChannelService.DeleteChannel(channelUsername)
+		err = s.ChannelService.DeleteChannel(r.Context(), channelUsername)
 		if err != nil {
 			s.Logger.Printf("deletion of channel failed because: %s", err.Error())
 			response.Data = jSendFailData{
@@ -424,29 +413,19 @@ func getAdmins(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users getting admins of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized get admins of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelViewPrivate, channelResource(c)) {
+				s.Logger.Printf("unauthorized get admins of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		switch err {
 		case nil:
 
@@ -487,34 +466,20 @@ func putAdmin(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 
 		{
 			// this block blocks users updating of admins of  channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			s.Logger.Printf(r.Header.Get("authorized_username"))
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-
-					one = true
-
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized update of channel admins attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelAddAdmin, channelResource(c)) {
+				s.Logger.Printf("unauthorized update of channel admins attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		adminUsername := vars["adminUsername"]
-		err := s.ChannelService.AddAdmin(channelUsername, adminUsername)
+		err := s.ChannelService.AddAdmin(r.Context(), channelUsername, adminUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -563,25 +528,20 @@ func deleteAdmin(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		{
 
 			//// this block blocks users deleting of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.OwnerUsername
-
-			if adminUsername != r.Header.Get("authorized_username") {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized delete admins of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-				}
-
+			if !authorize(s, r, policy.ChannelRemoveAdmin, channelResource(c)) {
+				s.Logger.Printf("unauthorized delete admins of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		adminUsername := vars["adminUsername"]
-		err := s.ChannelService.DeleteAdmin(channelUsername, adminUsername)
+		err := s.ChannelService.DeleteAdmin(r.Context(), channelUsername, adminUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -624,31 +584,20 @@ func getOwner(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users getting owners of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("c %s", channelUsername)
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized get owner of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelViewPrivate, channelResource(c)) {
+				s.Logger.Printf("unauthorized get owner of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -686,24 +635,20 @@ func putOwner(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users updating owner of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			ownerUsername := c.OwnerUsername
-			if ownerUsername != r.Header.Get("authorized_username") {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-
-					s.Logger.Printf("unauthorized update owner of channel attempt %s")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-				}
+			if !authorize(s, r, policy.ChannelChangeOwner, channelResource(c)) {
+				s.Logger.Printf("unauthorized update owner of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		ownerUsername := vars["ownerUsername"]
-		err := s.ChannelService.ChangeOwner(channelUsername, ownerUsername)
+		err := s.ChannelService.ChangeOwner(r.Context(), channelUsername, ownerUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -753,30 +698,19 @@ func getCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users getting Catalog of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized get catalog of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelViewPrivate, channelResource(c)) {
+				s.Logger.Printf("unauthorized get catalog of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -784,7 +718,7 @@ func getCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			releases := make([]interface{}, 0)
 
 			for _, uID := range catalog {
-				if temp, err := s.ReleaseService.GetRelease(int(uID)); err == nil {
+				if temp, err := s.ReleaseService.GetRelease(r.Context(), int(uID)); err == nil {
 					fmt.Printf("here")
 					releases = append(releases, temp)
 				} else {
@@ -823,7 +757,7 @@ func getOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		statusCode := http.StatusOK
 		vars := getParametersFromRequestAsMap(r)
 		channelUsername := vars["channelUsername"]
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -831,7 +765,7 @@ func getOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			releases := make([]interface{}, 0)
 
 			for _, uID := range officialCatalog {
-				if temp, err := s.ReleaseService.GetRelease(int(uID)); err == nil {
+				if temp, err := s.ReleaseService.GetRelease(r.Context(), int(uID)); err == nil {
 					releases = append(releases, temp)
 				} else {
 					releases = append(releases, int(uID))
@@ -869,27 +803,16 @@ func deleteReleaseFromCatalog(s *Setup) func(w http.ResponseWriter, r *http.Requ
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users deleting release from catalog of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized delete release of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelManageCatalog, channelResource(c)) {
+				s.Logger.Printf("unauthorized delete release of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		ReleaseID, err := strconv.Atoi(vars["catalogID"])
@@ -901,7 +824,7 @@ func deleteReleaseFromCatalog(s *Setup) func(w http.ResponseWriter, r *http.Requ
 			statusCode = http.StatusBadRequest
 
 		} else {
-			errC := s.ChannelService.DeleteReleaseFromCatalog(channelUsername, uint(ReleaseID))
+			errC := s.ChannelService.DeleteReleaseFromCatalog(r.Context(), channelUsername, uint(ReleaseID))
 			switch errC {
 			case nil:
 				response.Status = "success"
@@ -947,26 +870,16 @@ func deleteReleaseFromOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *h
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users deleting release from catalog of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized delete release of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelManageCatalog, channelResource(c)) {
+				s.Logger.Printf("unauthorized delete release of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		ReleaseID, err := strconv.Atoi(vars["catalogID"])
@@ -978,7 +891,7 @@ func deleteReleaseFromOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *h
 			statusCode = http.StatusBadRequest
 
 		} else {
-			errC := s.ChannelService.DeleteReleaseFromOfficialCatalog(channelUsername, uint(ReleaseID))
+			errC := s.ChannelService.DeleteReleaseFromOfficialCatalog(r.Context(), channelUsername, uint(ReleaseID))
 			switch errC {
 			case nil:
 				response.Status = "success"
@@ -1023,27 +936,16 @@ func getReleaseFromCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users get release of catalog of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized get release of catalog of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelViewPrivate, channelResource(c)) {
+				s.Logger.Printf("unauthorized get release of catalog of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 
@@ -1056,7 +958,7 @@ func getReleaseFromCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request
 			statusCode = http.StatusBadRequest
 
 		} else {
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 
 			switch err {
 			case nil:
@@ -1065,7 +967,7 @@ func getReleaseFromCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request
 						response.Status = "success"
 						catalog := ReleaseID
 						releases := make([]interface{}, 0)
-						temp, err := s.ReleaseService.GetRelease(catalog)
+						temp, err := s.ReleaseService.GetRelease(r.Context(), catalog)
 						if err == nil {
 							releases = append(releases, temp)
 
@@ -1126,7 +1028,7 @@ func getReleaseFromOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http
 			statusCode = http.StatusBadRequest
 
 		} else {
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 
 			switch err {
 			case nil:
@@ -1135,7 +1037,7 @@ func getReleaseFromOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http
 						response.Status = "success"
 						catalog := ReleaseID
 						releases := make([]interface{}, 0)
-						temp, err := s.ReleaseService.GetRelease(catalog)
+						temp, err := s.ReleaseService.GetRelease(r.Context(), catalog)
 						if err == nil {
 							releases = append(releases, temp)
 						} else {
@@ -1175,15 +1077,16 @@ func getReleaseFromOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http
 	}
 }
 
-// putReleaseInCatalog returns a handler for PUT /releases/{id} requests
+// putReleaseInCatalog returns a handler for PUT /channels/{channelUsername}/catalogs/{catalogID} requests
 func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		limitImageUpload(w, r, d)
 		var response jSendResponse
 		response.Status = "fail"
 		statusCode := http.StatusOK
 
 		vars := getParametersFromRequestAsMap(r)
-		idRaw := vars["id"]
+		idRaw := vars["catalogID"]
 		id, err := strconv.Atoi(idRaw)
 		if err != nil {
 			d.Logger.Printf("put attempt of non invalid release id %s", idRaw)
@@ -1195,7 +1098,7 @@ func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request)
 		}
 
 		rel := new(release.Release)
-		var tmpFile *os.File
+		var images []*imaging.Image
 		{ // this block parses the JSON part of the request
 			err := json.Unmarshal([]byte(r.PostFormValue("JSON")), rel)
 			if err != nil {
@@ -1215,16 +1118,12 @@ func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request)
 					case release.Image:
 						fallthrough
 					default:
-						var fileName string
 						var err error
-						tmpFile, fileName, err = saveImageFromRequest(r, "image")
+						images, err = processImageFromRequest(r, d, "image")
 						switch err {
 						case nil:
 							d.Logger.Printf("image found on put request")
-							defer os.Remove(tmpFile.Name())
-							defer tmpFile.Close()
-							d.Logger.Printf(fmt.Sprintf("temp file saved: %s", tmpFile.Name()))
-							rel.Content = generateFileNameForStorage(fileName, "release")
+							rel.Content = generateFileNameForStorage("release")
 							rel.Type = release.Image
 						case errUnacceptedType:
 							response.Data = jSendFailData{
@@ -1232,6 +1131,12 @@ func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request)
 								ErrorReason:  "only types image/jpeg & image/png are accepted",
 							}
 							statusCode = http.StatusBadRequest
+						case errImageTooLarge:
+							response.Data = jSendFailData{
+								ErrorReason:  "image",
+								ErrorMessage: "image has too many pixels or takes too many bytes",
+							}
+							statusCode = http.StatusBadRequest
 						case errReadingFromImage:
 							d.Logger.Printf("image not found on put request")
 							if rel.Type == release.Image {
@@ -1253,14 +1158,8 @@ func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request)
 		if response.Data == nil {
 			rel.OwnerChannel = vars["channelUsername"]
 			{
-				if c, err := d.ChannelService.GetChannel(rel.OwnerChannel); err == nil {
-					found := false
-					for _, username := range c.AdminUsernames {
-						if username == r.Header.Get("authorized_username") {
-							found = true
-						}
-					}
-					if !found {
+				if c, err := d.ChannelService.GetChannel(r.Context(), rel.OwnerChannel); err == nil {
+					if !authorize(d, r, policy.ChannelManageCatalog, channelResource(c)) {
 						d.Logger.Printf("Channel %s not found", rel.OwnerChannel)
 						w.WriteHeader(http.StatusForbidden)
 						return
@@ -1285,26 +1184,37 @@ func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request)
 				if response.Data == nil {
 					if response.Data == nil {
 						rel.ID = id
-						rel, err = d.ReleaseService.UpdateRelease(rel)
+						// the release is only updated if its new image is saved too
+						imageName := rel.Content
+						var oldImage string
+						err = storeImage(r.Context(), d, images, imageName)
+						if err == nil {
+							err = d.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+								deleteImageOnRollback(ctx, d, images, imageName)
+								old, err := d.ReleaseService.GetRelease(ctx, rel.ID)
+								if err != nil {
+									return err
+								}
+								if len(images) > 0 && old.Type == release.Image {
+									oldImage = old.Content
+								}
+								rel, err = d.ReleaseService.UpdateRelease(ctx, rel)
+								return err
+							})
+						}
 						switch err {
 						case nil:
+							deleteStoredImage(d, oldImage)
+							d.Logger.Printf("success updating release %d", id)
+							response.Status = "success"
 							if rel.Type == release.Image {
-								err := saveTempFilePermanentlyToPath(tmpFile, d.ImageStoragePath+rel.Content)
-								if err != nil {
-									d.Logger.Printf("updating of release failed because: %v", err)
-									response.Status = "error"
-									response.Message = "server error when updating release"
-									statusCode = http.StatusInternalServerError
-									_ = d.ReleaseService.DeleteRelease(rel.ID)
-								}
-							}
-							if response.Message == "" {
-								d.Logger.Printf("success updating release %d", id)
-								response.Status = "success"
-								rel.Content = d.HostAddress + d.ImageServingRoute + url.PathEscape(rel.Content)
-								response.Data = *rel
-								// TODO delete old image if image updated
+								rel.Content, rel.Images = imageURLs(r.Context(), d, rel.Content)
 							}
+							response.Data = *rel
+						case policy.ErrForbidden:
+							d.Logger.Printf("unauthorized update of release %d", id)
+							w.WriteHeader(http.StatusForbidden)
+							return
 						case release.ErrAttemptToChangeReleaseType:
 							d.Logger.Printf("update attempt of release type for release %d", id)
 							response.Data = jSendFailData{
@@ -1338,12 +1248,13 @@ func putReleaseInCatalog(d *Setup) func(w http.ResponseWriter, r *http.Request)
 // postReleaseInOfficialCatalog returns a handler for POST /channels/{channelUsername}/official
 func postReleaseInCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		limitImageUpload(w, r, s)
 		var response jSendResponse
 		response.Status = "fail"
 		statusCode := http.StatusCreated
 
 		newRelease := new(release.Release)
-		var tmpFile *os.File
+		var images []*imaging.Image
 		{ // this block parses the JSON part of the request
 			err := json.Unmarshal([]byte(r.PostFormValue("JSON")), newRelease)
 			if err != nil {
@@ -1362,16 +1273,10 @@ func postReleaseInCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request)
 			vars := getParametersFromRequestAsMap(r)
 			newRelease.OwnerChannel = vars["channelUsername"]
 			{
-				if c, err := s.ChannelService.GetChannel(newRelease.OwnerChannel); err == nil {
-					found := false
-					for _, username := range c.AdminUsernames {
-						if username == r.Header.Get("authorized_username") {
-							found = true
-						}
-					}
-					if !found {
+				if c, err := s.ChannelService.GetChannel(r.Context(), newRelease.OwnerChannel); err == nil {
+					if !authorize(s, r, policy.ChannelManageCatalog, channelResource(c)) {
 						s.Logger.Printf("unauthorized post release of channel attempt")
-						w.WriteHeader(http.StatusUnauthorized)
+						w.WriteHeader(http.StatusForbidden)
 						return
 					}
 				} else {
@@ -1386,21 +1291,23 @@ func postReleaseInCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request)
 				{ // this block extracts the image file if necessary
 					switch newRelease.Type {
 					case release.Image:
-						var fileName string
 						var err error
-						tmpFile, fileName, err = saveImageFromRequest(r, "image")
+						images, err = processImageFromRequest(r, s, "image")
 						switch err {
 						case nil:
-							defer tmpFile.Close()
-							defer os.Remove(tmpFile.Name())
-							s.Logger.Printf(fmt.Sprintf("temp file saved: %s", tmpFile.Name()))
-							newRelease.Content = generateFileNameForStorage(fileName, "release")
+							newRelease.Content = generateFileNameForStorage("release")
 						case errUnacceptedType:
 							response.Data = jSendFailData{
 								ErrorMessage: "image",
 								ErrorReason:  "only types image/jpeg & image/png are accepted",
 							}
 							statusCode = http.StatusBadRequest
+						case errImageTooLarge:
+							response.Data = jSendFailData{
+								ErrorReason:  "image",
+								ErrorMessage: "image has too many pixels or takes too many bytes",
+							}
+							statusCode = http.StatusBadRequest
 						case errReadingFromImage:
 							response.Data = jSendFailData{
 								ErrorReason:  "image",
@@ -1424,31 +1331,30 @@ func postReleaseInCatalog(s *Setup) func(w http.ResponseWriter, r *http.Request)
 				if response.Data == nil {
 					s.Logger.Printf("trying to add release")
 
-					newRelease, err := s.ReleaseService.AddRelease(newRelease)
+					// the release is only added if its image is saved too
+					imageName := newRelease.Content
+					err := storeImage(r.Context(), s, images, imageName)
+					if err == nil {
+						err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+							deleteImageOnRollback(ctx, s, images, imageName)
+							var err error
+							newRelease, err = s.ReleaseService.AddRelease(ctx, newRelease)
+							return err
+						})
+					}
 					switch err {
 					case nil:
-						if newRelease.Type == release.Image {
-							err := saveTempFilePermanentlyToPath(tmpFile, s.ImageStoragePath+newRelease.Content)
-							if err != nil {
-								s.Logger.Printf("adding of release failed because: %v", err)
-								response.Status = "error"
-								response.Message = "server error when adding release"
-								statusCode = http.StatusInternalServerError
-								_ = s.ReleaseService.DeleteRelease(newRelease.ID)
-							}
-						}
-						if response.Message == "" {
-							response.Status = "success"
-							newRelease.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(newRelease.Content)
-							response.Data = *newRelease
-							s.Logger.Printf("success adding release %d to channel %s", newRelease.ID, newRelease.OwnerChannel)
-						}
+						response.Status = "success"
+						newRelease.Content, newRelease.Images = imageURLs(r.Context(), s, newRelease.Content)
+						response.Data = *newRelease
+						s.Logger.Printf("success adding release %d to channel %s", newRelease.ID, newRelease.OwnerChannel)
+					case policy.ErrForbidden:
+						s.Logger.Printf("unauthorized add release request")
+						w.WriteHeader(http.StatusForbidden)
+						return
 					case release.ErrSomeReleaseDataNotPersisted:
 						fallthrough
 					default:
-						if newRelease != nil && newRelease.ID != 0 {
-							_ = s.ReleaseService.DeleteRelease(newRelease.ID)
-						}
 						s.Logger.Printf("adding of release failed because: %v", err)
 						response.Status = "error"
 						response.Message = "server error when adding release"
@@ -1471,16 +1377,10 @@ func putReleaseInOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http.R
 
 		channelUsername := vars["channelUsername"]
 		{ // this block secures the route
-			if c, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-				found := false
-				for _, username := range c.AdminUsernames {
-					if username == r.Header.Get("authorized_username") {
-						found = true
-					}
-				}
-				if !found {
+			if c, err := s.ChannelService.GetChannel(r.Context(), channelUsername); err == nil {
+				if !authorize(s, r, policy.ChannelManageCatalog, channelResource(c)) {
 					s.Logger.Printf("unauthorized delete release of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
+					w.WriteHeader(http.StatusForbidden)
 					return
 				}
 			} else {
@@ -1520,7 +1420,7 @@ func putReleaseInOfficialCatalog(s *Setup) func(w http.ResponseWriter, r *http.R
 				// if queries are clean
 				if response.Data == nil {
 
-					err := s.ChannelService.AddReleaseToOfficialCatalog(channelUsername, uint(releaseID), requestData.PostID)
+					err := s.ChannelService.AddReleaseToOfficialCatalog(r.Context(), channelUsername, uint(releaseID), requestData.PostID)
 					switch err {
 					case nil:
 						s.Logger.Printf("success adding release %d from post %d to official catalog channel %s", releaseID, requestData.PostID, channelUsername)
@@ -1569,7 +1469,7 @@ func getChannelPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		statusCode := http.StatusOK
 		vars := getParametersFromRequestAsMap(r)
 		channelUsername := vars["channelUsername"]
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		postID, errC := strconv.Atoi(vars["postID"])
 		if errC != nil {
 			response.Data = jSendFailData{
@@ -1587,7 +1487,7 @@ func getChannelPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 						response.Status = "success"
 						postid := postID
 
-						if temp, err := s.PostService.GetPost(uint(postID)); err == nil {
+						if temp, err := s.PostService.GetPost(r.Context(), uint(postID)); err == nil {
 							response.Data = temp
 						} else {
 
@@ -1635,7 +1535,7 @@ func getChannelPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		vars := getParametersFromRequestAsMap(r)
 		channelUsername := vars["channelUsername"]
 
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -1643,7 +1543,7 @@ func getChannelPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			posts := make([]interface{}, 0)
 
 			for _, pID := range postid {
-				if temp, err := s.PostService.GetPost(pID); err == nil {
+				if temp, err := s.PostService.GetPost(r.Context(), pID); err == nil {
 
 					posts = append(posts, *temp)
 				} else {
@@ -1682,7 +1582,7 @@ func getStickiedPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		statusCode := http.StatusOK
 		vars := getParametersFromRequestAsMap(r)
 		channelUsername := vars["channelUsername"]
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 
 		switch err {
 		case nil:
@@ -1693,7 +1593,7 @@ func getStickiedPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 
 			for _, pID := range postID {
 
-				if temp, err := s.PostService.GetPost(pID); err == nil {
+				if temp, err := s.PostService.GetPost(r.Context(), pID); err == nil {
 					fmt.Printf("here12")
 					posts = append(posts, temp)
 				} else {
@@ -1735,27 +1635,16 @@ func deleteStickiedPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users deleting stickied post of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized delete stickied post of channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelManageStickies, channelResource(c)) {
+				s.Logger.Printf("unauthorized delete stickied post of channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		stickiedPostID, err := strconv.Atoi(vars["stickiedPostID"])
@@ -1767,7 +1656,7 @@ func deleteStickiedPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			statusCode = http.StatusBadRequest
 
 		} else {
-			errC := s.ChannelService.DeleteStickiedPost(channelUsername, uint(stickiedPostID))
+			errC := s.ChannelService.DeleteStickiedPost(r.Context(), channelUsername, uint(stickiedPostID))
 			switch errC {
 			case nil:
 				response.Status = "success"
@@ -1809,27 +1698,16 @@ func stickyPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users sticking a post of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized sticky a post in channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelManageStickies, channelResource(c)) {
+				s.Logger.Printf("unauthorized sticky a post in channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 
@@ -1842,7 +1720,7 @@ func stickyPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			statusCode = http.StatusBadRequest
 
 		} else {
-			err := s.ChannelService.StickyPost(channelUsername, uint(stickyPost))
+			err := s.ChannelService.StickyPost(r.Context(), channelUsername, uint(stickyPost))
 			switch err {
 			case nil:
 				response.Status = "success"
@@ -1901,11 +1779,11 @@ func getChannelPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		vars := getParametersFromRequestAsMap(r)
 		channelUsername := vars["channelUsername"]
 
-		c, err := s.ChannelService.GetChannel(channelUsername)
+		c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 		switch err {
 		case nil:
 			response.Status = "success"
-			response.Data = s.HostAddress + s.ImageServingRoute + url.PathEscape(c.PictureURL)
+			response.Data = imageURL(r.Context(), s, c.PictureURL)
 			s.Logger.Printf("success fetching channel %s picture URL", channelUsername)
 		case channel.ErrChannelNotFound:
 			s.Logger.Printf("fetch picture URL attempt of non existing channel %s", channelUsername)
@@ -1927,6 +1805,7 @@ func getChannelPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 // putChannelPicture returns a handler for PUT /channels/{channelUsername}/picture requests
 func putChannelPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		limitImageUpload(w, r, s)
 		var err error
 		var response jSendResponse
 		statusCode := http.StatusOK
@@ -1935,46 +1814,38 @@ func putChannelPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users sticking a post of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized sticky a post in channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelManagePicture, channelResource(c)) {
+				s.Logger.Printf("unauthorized sticky a post in channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
-		var tmpFile *os.File
+		var images []*imaging.Image
 		var fileName string
 		{ // this block extracts the image
-			tmpFile, fileName, err = saveImageFromRequest(r, "image")
+			images, err = processImageFromRequest(r, s, "image")
 			switch err {
 			case nil:
 				s.Logger.Printf("image found on put channel picture request")
-				defer os.Remove(tmpFile.Name())
-				defer tmpFile.Close()
-				s.Logger.Printf("temp file saved: %s", tmpFile.Name())
-				fileName = generateFileNameForStorage(fileName, "user")
+				fileName = generateFileNameForStorage("user")
 			case errUnacceptedType:
 				response.Data = jSendFailData{
 					ErrorMessage: "image",
 					ErrorReason:  "only types image/jpeg & image/png are accepted",
 				}
 				statusCode = http.StatusBadRequest
+			case errImageTooLarge:
+				response.Data = jSendFailData{
+					ErrorReason:  "image",
+					ErrorMessage: "image has too many pixels or takes too many bytes",
+				}
+				statusCode = http.StatusBadRequest
 			case errReadingFromImage:
 				s.Logger.Printf("image not found on put request")
 				response.Data = jSendFailData{
@@ -1990,22 +1861,28 @@ func putChannelPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			a, err := s.ChannelService.AddPicture(channelUsername, fileName)
+			var a, oldPicture string
+			// the picture is only set if it's saved too
+			err := storeImage(r.Context(), s, images, fileName)
+			if err == nil {
+				err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+					deleteImageOnRollback(ctx, s, images, fileName)
+					c, err := s.ChannelService.GetChannel(ctx, channelUsername)
+					if err != nil {
+						return err
+					}
+					oldPicture = c.PictureURL
+					a, err = s.ChannelService.AddPicture(ctx, channelUsername, fileName)
+					return err
+				})
+			}
 			s.Logger.Printf(channelUsername)
 			switch err {
 			case nil:
-				err := saveTempFilePermanentlyToPath(tmpFile, s.ImageStoragePath+fileName)
-				if err != nil {
-					s.Logger.Printf("adding of picture failed  case nil because: %v", err)
-					response.Status = "error"
-					response.Message = "server error when setting channel picture"
-					statusCode = http.StatusInternalServerError
-					_ = s.ChannelService.RemovePicture(channelUsername)
-				} else {
-					s.Logger.Printf("success adding picture %s to channel %s", fileName, channelUsername)
-					response.Status = "success"
-					response.Data = s.HostAddress + s.ImageServingRoute + url.PathEscape(a)
-				}
+				deleteStoredImage(s, oldPicture)
+				s.Logger.Printf("success adding picture %s to channel %s", fileName, channelUsername)
+				response.Status = "success"
+				response.Data = imageURL(r.Context(), s, a)
 			case channel.ErrChannelNotFound:
 				s.Logger.Printf("adding of channel picture failed because: %v", err)
 				response.Data = jSendFailData{
@@ -2035,35 +1912,32 @@ func deleteChannelPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		channelUsername := vars["channelUsername"]
 		{
 			// this block blocks users sticking a post of channel if is not the admin of the channel herself accessing the route
-			c, err := s.ChannelService.GetChannel(channelUsername)
+			c, err := s.ChannelService.GetChannel(r.Context(), channelUsername)
 			if err != nil {
 				s.Logger.Printf("Channel %s not found", channelUsername)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
-			adminUsername := c.AdminUsernames
-
-			one := false
-			for i := 0; i < len(adminUsername); i++ {
-				if adminUsername[i] == r.Header.Get("authorized_username") {
-					one = true
-				}
-			}
-			if one == false {
-				if _, err := s.ChannelService.GetChannel(channelUsername); err == nil {
-					s.Logger.Printf("unauthorized sticky a post in channel attempt")
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-
-				}
+			if !authorize(s, r, policy.ChannelManagePicture, channelResource(c)) {
+				s.Logger.Printf("unauthorized sticky a post in channel attempt")
+				w.WriteHeader(http.StatusForbidden)
+				return
 			}
 		}
 		// if queries are clean
 		if response.Data == nil {
-			err = s.ChannelService.RemovePicture(channelUsername)
+			var picture string
+			err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+				c, err := s.ChannelService.GetChannel(ctx, channelUsername)
+				if err != nil {
+					return err
+				}
+				picture = c.PictureURL
+				return s.ChannelService.RemovePicture(ctx, channelUsername)
+			})
 			switch err {
 			case nil:
-				// TODO delete picture from fs
+				deleteStoredImage(s, picture)
 				s.Logger.Printf("success removing piture from channel %s", channelUsername)
 				response.Status = "success"
 			case channel.ErrChannelNotFound:
@@ -0,0 +1,186 @@
+package rest_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+var channelScenarios = []scenario{
+	{"channels/create and get", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+
+		r := s.Do(t, http.MethodPost, "/channels", token, map[string]string{
+			"channelUsername": "wonderland",
+			"name":            "Wonderland",
+			"description":     "curiouser and curiouser",
+		})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/create", r)
+
+		s.Do(t, http.MethodPost, "/channels", token, map[string]string{"channelUsername": "wonderland", "name": "Again"}).
+			ExpectFail(t, http.StatusConflict, "channelUsername")
+		s.Do(t, http.MethodPost, "/channels", token, map[string]string{"channelUsername": "looking-glass"}).
+			ExpectFail(t, http.StatusBadRequest, "name")
+
+		r = s.Do(t, http.MethodGet, "/channels/wonderland", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/get-admin", r)
+
+		r = s.Do(t, http.MethodGet, "/channels/wonderland", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/get-anonymous", r)
+
+		r = s.Do(t, http.MethodGet, "/channels/nowhere", "", nil)
+		r.ExpectFail(t, http.StatusNotFound, "channelUsername")
+		g.Check(t, "channels/get-missing", r)
+	}},
+	{"channels/only verified users create", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		c := map[string]string{"channelUsername": "wonderland", "name": "Wonderland"}
+		s.Do(t, http.MethodPost, "/channels", "", c).ExpectStatus(t, http.StatusUnauthorized)
+
+		s.SignUp(t, "alice")
+		token := s.Login(t, "alice", resttest.Password)
+		s.Do(t, http.MethodPost, "/channels", token, c).ExpectFail(t, http.StatusForbidden, "verification")
+
+		s.VerifyEmail(t, "alice")
+		s.Do(t, http.MethodPost, "/channels", token, c).ExpectSuccess(t, http.StatusOK, nil)
+	}},
+	{"channels/admins", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		update := map[string]string{"description": "we're all mad here"}
+
+		s.Do(t, http.MethodPut, "/channels/alice", bobby, update).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodGet, "/channels/alice/admins", bobby, nil).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodPut, "/channels/alice/admins/bobby", bobby, nil).ExpectStatus(t, http.StatusForbidden)
+
+		s.Do(t, http.MethodPut, "/channels/alice/admins/bobby", token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodPut, "/channels/alice/admins/nobody", token, nil).
+			ExpectFail(t, http.StatusNotFound, "adminUsername")
+		r := s.Do(t, http.MethodGet, "/channels/alice/admins", bobby, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/admins", r)
+
+		r = s.Do(t, http.MethodPut, "/channels/alice", bobby, update)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/update-by-admin", r)
+
+		// admins can't hand the channel over, only its owner can
+		s.Do(t, http.MethodPut, "/channels/alice/owners/bobby", bobby, nil).ExpectStatus(t, http.StatusForbidden)
+
+		s.Do(t, http.MethodDelete, "/channels/alice/admins/bobby", token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodPut, "/channels/alice", bobby, update).ExpectStatus(t, http.StatusForbidden)
+	}},
+	{"channels/delete", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		s.Do(t, http.MethodPost, "/channels", token, map[string]string{"channelUsername": "wonderland", "name": "Wonderland"}).
+			ExpectSuccess(t, http.StatusOK, nil)
+
+		r := s.Do(t, http.MethodDelete, "/channels/wonderland", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/delete", r)
+		s.Do(t, http.MethodGet, "/channels/wonderland", "", nil).ExpectFail(t, http.StatusNotFound, "channelUsername")
+		s.Do(t, http.MethodDelete, "/channels/wonderland", token, nil).ExpectStatus(t, http.StatusForbidden)
+	}},
+	{"channels/stickied posts", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+
+		s.Do(t, http.MethodPut, "/channels/alice/Posts/"+p, bobby, nil).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodPut, "/channels/alice/Posts/"+p, token, nil).ExpectSuccess(t, http.StatusOK, nil)
+
+		r := s.Do(t, http.MethodGet, "/channels/alice/stickiedPosts", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/stickied-posts", r)
+
+		r = s.Do(t, http.MethodGet, "/channels/alice/Posts", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "channels/posts", r)
+	}},
+	{"channels/picture", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		picture := resttest.Multipart{Files: map[string]resttest.File{"image": {Name: "wonderland.png", Content: resttest.PNG(t, 64, 64)}}}
+
+		s.Do(t, http.MethodPut, "/channels/alice/picture", bobby, picture).ExpectStatus(t, http.StatusForbidden)
+
+		var pictureURL string
+		s.Do(t, http.MethodPut, "/channels/alice/picture", token, picture).ExpectSuccess(t, http.StatusOK, &pictureURL)
+		s.FetchImage(t, pictureURL, 64, 64)
+
+		var anonymous string
+		s.Do(t, http.MethodGet, "/channels/alice/picture", "", nil).ExpectSuccess(t, http.StatusOK, &anonymous)
+		if anonymous != pictureURL {
+			t.Errorf("the channel is expected to link to the picture set, got %q instead of %q", anonymous, pictureURL)
+		}
+
+		replaced := pictureURL
+		s.Do(t, http.MethodPut, "/channels/alice/picture", token, picture).ExpectSuccess(t, http.StatusOK, &pictureURL)
+		s.ExpectImageDeleted(t, replaced)
+		s.Do(t, http.MethodDelete, "/channels/alice/picture", token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.ExpectImageDeleted(t, pictureURL)
+	}},
+	{"channels/image release", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		image := resttest.PNG(t, 600, 300)
+
+		var rel struct {
+			ID      int               `json:"id"`
+			Content string            `json:"content"`
+			Images  map[string]string `json:"images"`
+		}
+		s.Do(t, http.MethodPost, "/releases", token, resttest.Multipart{
+			Fields: map[string]string{"JSON": `{"ownerChannel": "alice", "type": "image", "metadata": {"title": "Tea Party"}}`},
+			Files:  map[string]resttest.File{"image": {Name: "tea party.png", Content: image}},
+		}).ExpectSuccess(t, http.StatusCreated, &rel)
+		// images are never scaled up, only the thumb is smaller than what's uploaded
+		s.FetchImage(t, rel.Content, 600, 300)
+		s.FetchImage(t, rel.Images["medium"], 600, 300)
+		s.FetchImage(t, rel.Images["thumb"], 256, 128)
+
+		var got struct {
+			Content string            `json:"content"`
+			Images  map[string]string `json:"images"`
+		}
+		s.Do(t, http.MethodGet, "/releases/"+strconv.Itoa(rel.ID), token, nil).ExpectSuccess(t, http.StatusOK, &got)
+		s.FetchImage(t, got.Content, 600, 300)
+		for _, size := range []string{"thumb", "medium", "full"} {
+			if got.Images[size] == "" {
+				t.Errorf("the release is expected to link to its %s image, got %v", size, got.Images)
+			}
+		}
+
+		// the image replaced is deleted, and so is the one of a deleted release
+		id := strconv.Itoa(rel.ID)
+		var replaced []string
+		for _, imageURL := range got.Images {
+			replaced = append(replaced, imageURL)
+		}
+		s.Do(t, http.MethodPatch, "/releases/"+id, token, resttest.Multipart{
+			Fields: map[string]string{"JSON": `{}`},
+			Files:  map[string]resttest.File{"image": {Name: "mad hatter.png", Content: image}},
+		}).ExpectSuccess(t, http.StatusOK, &got)
+		for _, imageURL := range replaced {
+			s.ExpectImageDeleted(t, imageURL)
+		}
+		s.FetchImage(t, got.Content, 600, 300)
+		s.Do(t, http.MethodPut, "/channels/alice/catalogs/"+id, token, resttest.Multipart{
+			Fields: map[string]string{"JSON": `{}`},
+			Files:  map[string]resttest.File{"image": {Name: "march hare.png", Content: image}},
+		}).ExpectSuccess(t, http.StatusOK, nil)
+		s.ExpectImageDeleted(t, got.Content)
+		s.Do(t, http.MethodGet, "/releases/"+id, token, nil).ExpectSuccess(t, http.StatusOK, &got)
+		s.Do(t, http.MethodDelete, "/releases/"+id, token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		for _, imageURL := range got.Images {
+			s.ExpectImageDeleted(t, imageURL)
+		}
+	}},
+}
+
+func TestChannels(t *testing.T) {
+	run(t, channelScenarios)
+}
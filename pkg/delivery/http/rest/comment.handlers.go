@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 func sanitizeComment(c *comment.Comment, s *Setup) {
@@ -85,14 +86,18 @@ func postComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 			if response.Data == nil {
 				{ // this block secures the route
-					if c.Commenter != r.Header.Get("authorized_username") {
+					if !authorize(s, r, policy.CommentCreate, userResource(c.Commenter)) {
+						if !isVerified(r) {
+							writeVerificationRequiredResponse(s, w)
+							return
+						}
 						s.Logger.Printf("unauthorized post Comment request")
-						w.WriteHeader(http.StatusUnauthorized)
+						w.WriteHeader(http.StatusForbidden)
 						return
 					}
 				}
 				sanitizeComment(c, s)
-				c.Commenter = r.Header.Get("authorized_username")
+				c.Commenter = authorizedUsername(r)
 				// this block checks for required fields
 				if c.Content == "" {
 					response.Data = jSendFailData{
@@ -102,7 +107,7 @@ func postComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 				if response.Data == nil {
 					s.Logger.Printf("trying to add comment %v", c)
-					c, err = s.CommentService.AddComment(c)
+					c, err = s.CommentService.AddComment(r.Context(), c)
 					switch err {
 					case nil:
 						response.Status = "success"
@@ -172,7 +177,7 @@ func getComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if response.Data == nil {
-			c, err := s.CommentService.GetComment(id)
+			c, err := s.CommentService.GetComment(r.Context(), id)
 			switch err {
 			case nil:
 				response.Status = "success"
@@ -245,7 +250,7 @@ func getComments(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 
 			if response.Data == nil {
-				c, err := s.CommentService.GetComments(postID, comment.SortByCreationTime, comment.SortDescending, limit, offset)
+				c, err := s.CommentService.GetComments(r.Context(), postID, comment.SortByCreationTime, comment.SortDescending, limit, offset)
 				switch err {
 				case nil:
 					response.Status = "success"
@@ -318,7 +323,7 @@ func getCommentReplies(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			if response.Data == nil {
-				c, err := s.CommentService.GetReplies(commentID, comment.SortByCreationTime, comment.SortDescending, limit, offset)
+				c, err := s.CommentService.GetReplies(r.Context(), commentID, comment.SortByCreationTime, comment.SortDescending, limit, offset)
 				switch err {
 				case nil:
 					response.Status = "success"
@@ -371,10 +376,10 @@ func patchComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 
 		c.ID = id
 		{ // this block secures the route
-			if temp, err := s.CommentService.GetComment(id); err == nil {
-				if temp.Commenter != r.Header.Get("authorized_username") {
+			if temp, err := s.CommentService.GetComment(r.Context(), id); err == nil {
+				if !authorize(s, r, policy.CommentUpdate, commentResource(temp)) {
 					s.Logger.Printf("unauthorized patch Comment request")
-					w.WriteHeader(http.StatusUnauthorized)
+					w.WriteHeader(http.StatusForbidden)
 					return
 				}
 			} else {
@@ -407,7 +412,7 @@ func patchComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				if c.Content == "" {
 					// no update able data
 					statusCode = http.StatusOK
-					c, err = s.CommentService.GetComment(id)
+					c, err = s.CommentService.GetComment(r.Context(), id)
 					switch err {
 					case nil:
 						s.Logger.Printf("success patch comment at id %d", id)
@@ -421,7 +426,7 @@ func patchComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 				if response.Data == nil {
-					c, err = s.CommentService.UpdateComment(c)
+					c, err = s.CommentService.UpdateComment(r.Context(), c)
 					switch err {
 					case nil:
 						response.Status = "success"
@@ -473,10 +478,10 @@ func deleteComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 
 		{ // this block secures the route
-			if temp, err := s.CommentService.GetComment(id); err == nil {
-				if temp.Commenter != r.Header.Get("authorized_username") {
-					s.Logger.Printf("unauthorized patch Comment request")
-					w.WriteHeader(http.StatusUnauthorized)
+			if temp, err := s.CommentService.GetComment(r.Context(), id); err == nil {
+				if !authorize(s, r, policy.CommentDelete, commentResource(temp)) {
+					s.Logger.Printf("unauthorized delete Comment request")
+					w.WriteHeader(http.StatusForbidden)
 					return
 				}
 			} else {
@@ -485,7 +490,7 @@ func deleteComment(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
-		err = s.CommentService.DeleteComment(id)
+		err = s.CommentService.DeleteComment(r.Context(), id)
 		if err != nil {
 			s.Logger.Printf("deletion of comment failed because: %v", err)
 			response.Status = "error"
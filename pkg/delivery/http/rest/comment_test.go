@@ -0,0 +1,70 @@
+package rest_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+var commentScenarios = []scenario{
+	{"comments/create and reply", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+
+		var c struct {
+			ID int `json:"id"`
+		}
+		r := s.Do(t, http.MethodPost, "/posts/"+p+"/comments", bobby, map[string]string{"commenter": "bobby", "content": "where is the rabbit"})
+		r.ExpectSuccess(t, http.StatusOK, &c)
+		g.Check(t, "comments/create", r)
+		comment := strconv.Itoa(c.ID)
+
+		r = s.Do(t, http.MethodPost, "/posts/"+p+"/comments/"+comment+"/replies", token, map[string]string{"commenter": "alice", "content": "down the hole"})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "comments/reply", r)
+
+		s.Do(t, http.MethodPost, "/posts/404/comments", bobby, map[string]string{"commenter": "bobby", "content": "lost"}).
+			ExpectFail(t, http.StatusNotFound, "postID")
+
+		r = s.Do(t, http.MethodGet, "/posts/"+p+"/comments", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "comments/list", r)
+
+		r = s.Do(t, http.MethodGet, "/posts/"+p+"/comments/"+comment+"/replies", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "comments/replies", r)
+	}},
+	{"comments/others' comments", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+
+		s.Do(t, http.MethodPost, "/posts/"+p+"/comments", token, map[string]string{"commenter": "bobby", "content": "impostor"}).
+			ExpectStatus(t, http.StatusForbidden)
+
+		var c struct {
+			ID int `json:"id"`
+		}
+		s.Do(t, http.MethodPost, "/posts/"+p+"/comments", bobby, map[string]string{"commenter": "bobby", "content": "first"}).
+			ExpectSuccess(t, http.StatusOK, &c)
+		path := "/posts/" + p + "/comments/" + strconv.Itoa(c.ID)
+		update := map[string]string{"content": "edited"}
+
+		s.Do(t, http.MethodPatch, path, token, update).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodDelete, path, token, nil).ExpectStatus(t, http.StatusForbidden)
+
+		r := s.Do(t, http.MethodPatch, path, bobby, update)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "comments/update", r)
+
+		s.Do(t, http.MethodDelete, path, bobby, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodGet, path, "", nil).ExpectFail(t, http.StatusNotFound, "commentID")
+	}},
+}
+
+func TestComments(t *testing.T) {
+	run(t, commentScenarios)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 	"net/http"
 	"strconv"
 	"strings"
@@ -23,15 +24,15 @@ func getFeed(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageFeed, userResource(username)) {
 				s.Logger.Printf("unauthorized user feed request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
 
 		s.Logger.Printf("trying to fetch feed %s", username)
-		f, err := s.FeedService.GetFeed(username)
+		f, err := s.FeedService.GetFeed(r.Context(), username)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -67,9 +68,9 @@ func getFeedPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageFeed, userResource(username)) {
 				s.Logger.Printf("unauthorized user feed request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -117,13 +118,13 @@ func getFeedPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			posts, err := s.FeedService.GetPosts(&f, sort, limit, offset)
+			posts, err := s.FeedService.GetPosts(r.Context(), &f, sort, limit, offset)
 			switch err {
 			case nil:
 				response.Status = "success"
 				truePosts := make([]interface{}, 0)
 				for _, pID := range posts {
-					if temp, err := s.PostService.GetPost(uint(pID.ID)); err == nil {
+					if temp, err := s.PostService.GetPost(r.Context(), uint(pID.ID)); err == nil {
 						truePosts = append(truePosts, temp)
 					} else {
 						truePosts = append(truePosts, pID)
@@ -163,9 +164,9 @@ func getFeedChannels(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageFeed, userResource(username)) {
 				s.Logger.Printf("unauthorized user feed request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -201,13 +202,13 @@ func getFeedChannels(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		channels, err := s.FeedService.GetChannels(&feed.Feed{OwnerUsername: username}, sortBy, sortOrder)
+		channels, err := s.FeedService.GetChannels(r.Context(), &feed.Feed{OwnerUsername: username}, sortBy, sortOrder)
 		switch err {
 		case nil:
 			response.Status = "success"
 			trueChannels := make(map[time.Time]interface{}, 0)
 			for _, c := range channels {
-				if temp, err := s.ChannelService.GetChannel(c.Channelname); err == nil {
+				if temp, err := s.ChannelService.GetChannel(r.Context(), c.Channelname); err == nil {
 					tempChannel := channel.Channel{
 						ChannelUsername: temp.ChannelUsername,
 						Name:            temp.Name,
@@ -250,9 +251,9 @@ func postFeedChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageFeed, userResource(username)) {
 				s.Logger.Printf("unauthorized user feed request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -280,7 +281,7 @@ func postFeedChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			if response.Data == nil {
-				err := s.FeedService.Subscribe(&feed.Feed{OwnerUsername: username}, c.Channelname)
+				err := s.FeedService.Subscribe(r.Context(), &feed.Feed{OwnerUsername: username}, c.Channelname)
 				switch err {
 				case nil:
 					response.Status = "success"
@@ -322,9 +323,9 @@ func putFeed(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageFeed, userResource(username)) {
 				s.Logger.Printf("unauthorized user feed request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -353,7 +354,7 @@ func putFeed(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					newFeed.Sorting = feed.NotSet
 				}
 			}
-			switch err := s.FeedService.UpdateFeed(username, &newFeed); err {
+			switch err := s.FeedService.UpdateFeed(r.Context(), username, &newFeed); err {
 			case nil:
 				s.Logger.Printf("success updating of feed %s", username)
 				response.Status = "success"
@@ -387,14 +388,14 @@ func deleteFeedChannel(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageFeed, userResource(username)) {
 				s.Logger.Printf("unauthorized user feed request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
 		channelname := vars["channelname"]
-		err := s.FeedService.Unsubscribe(&feed.Feed{OwnerUsername: username}, channelname)
+		err := s.FeedService.Unsubscribe(r.Context(), &feed.Feed{OwnerUsername: username}, channelname)
 		switch err {
 		case nil:
 			response.Status = "success"
@@ -0,0 +1,44 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+var feedScenarios = []scenario{
+	{"feed/subscriptions", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		s.AddPost(t, token, "alice", "first")
+		s.AddPost(t, token, "alice", "second")
+		subscription := map[string]string{"channelname": "alice"}
+
+		s.Do(t, http.MethodPost, "/users/bobby/feed/channels", token, subscription).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodGet, "/users/bobby/feed", token, nil).ExpectStatus(t, http.StatusForbidden)
+
+		r := s.Do(t, http.MethodPost, "/users/bobby/feed/channels", bobby, subscription)
+		r.ExpectSuccess(t, http.StatusCreated, nil)
+		g.Check(t, "feed/subscribe", r)
+		s.Do(t, http.MethodPost, "/users/bobby/feed/channels", bobby, map[string]string{"channelname": "nowhere"}).
+			ExpectFail(t, http.StatusNotFound, "channelname")
+
+		r = s.Do(t, http.MethodGet, "/users/bobby/feed/channels", bobby, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "feed/channels", r)
+
+		r = s.Do(t, http.MethodGet, "/users/bobby/feed/posts?sort=new", bobby, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "feed/posts", r)
+
+		s.Do(t, http.MethodDelete, "/users/bobby/feed/channels/alice", bobby, nil).ExpectSuccess(t, http.StatusOK, nil)
+		r = s.Do(t, http.MethodGet, "/users/bobby/feed/posts?sort=new", bobby, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "feed/posts-unsubscribed", r)
+	}},
+}
+
+func TestFeed(t *testing.T) {
+	run(t, feedScenarios)
+}
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage"
+)
+
+// getImage returns a handler for GET /images/{name} requests
+// it serves the images kept in the image storage, whichever it is
+func getImage(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(getParametersFromRequestAsMap(r)["name"], "/")
+
+		blob, err := s.ImageStorage.Get(r.Context(), name)
+		switch err {
+		case nil:
+		case storage.ErrBlobNotFound, storage.ErrInvalidKey:
+			http.NotFound(w, r)
+			return
+		default:
+			s.Logger.Printf("fetching of image %s failed because: %v", name, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer blob.Close()
+
+		if !blob.LastModified.IsZero() {
+			lastModified := blob.LastModified.UTC().Truncate(time.Second)
+			if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.After(since) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		}
+		w.Header().Set("Content-Type", blob.ContentType)
+		if blob.Size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if _, err = io.Copy(w, blob); err != nil {
+			s.Logger.Printf("serving of image %s failed because: %v", name, err)
+		}
+	}
+}
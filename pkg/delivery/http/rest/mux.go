@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"time"
@@ -8,7 +9,13 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage"
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
@@ -44,15 +51,29 @@ type Dependencies struct {
 	CommentService comment.Service
 	SearchService  search.Service
 	AuthService    auth.Service
+	PolicyService  policy.Service
+	LockoutService lockout.Service
+	OAuthService   oauth.Service
+	UnitOfWork     unitofwork.UnitOfWork
+	ImageStorage   storage.Storage
+	ImageProcessor imaging.Processor
 	Logger         *log.Logger
 }
 
-// Config contains the different settings used to set up the handlers
+// Config contains the different settings used to set up the handlers.
+// QueryTimeout bounds the time the services are given to serve a request and
+// ImageURLLifetime that image links handed out are valid for, if they expire.
+// Requests uploading images are cut off once they're much larger than the
+// MaxBytes of Images.
 type Config struct {
-	ImageServingRoute, ImageStoragePath, HostAddress, Port string
-	TokenAccessLifetime, TokenRefreshLifetime              time.Duration
-	TokenSigningSecret                                     []byte
-	HTTPS                                                  bool
+	ImageServingRoute, HostAddress, Port string
+	QueryTimeout, ImageURLLifetime       time.Duration
+	auth.Config
+	Images             imaging.Config
+	Lockout            lockout.Config
+	OAuth              oauth.Config
+	ModeratorUsernames []string
+	HTTPS              bool
 }
 
 // NewMux returns a new multiplexer with all the used setup.
@@ -92,11 +113,10 @@ func NewMux(s *Setup) *httprouter.Router {
 	secureRouter := httprouter.New()
 	secureRouter.HandleMethodNotAllowed = false
 
-	rootRouter.NotFound = ParseAuthTokenMiddleware(s)(mainRouter)
+	rootRouter.NotFound = QueryTimeoutMiddleware(s)(ParseAuthTokenMiddleware(s)(mainRouter))
 	mainRouter.NotFound = CheckForAuthMiddleware(s)(secureRouter)
 
-	fs := http.FileServer(http.Dir(s.ImageStoragePath))
-	rootRouter.Handler("GET", s.ImageServingRoute+"*filepath", http.StripPrefix(s.ImageServingRoute, fs))
+	rootRouter.HandlerFunc("GET", s.ImageServingRoute+"*name", getImage(s))
 
 	// attach routes
 	attachAuthRoutesToRouters(mainRouter, secureRouter, s)
@@ -106,6 +126,7 @@ func NewMux(s *Setup) *httprouter.Router {
 	attachCommentRoutesToRouters(mainRouter, secureRouter, s)
 	attachChannelRoutesToRouters(mainRouter, secureRouter, s)
 	attachPostRoutesToRouters(mainRouter, secureRouter, s)
+	attachOAuthRoutesToRouters(mainRouter, secureRouter, s)
 
 	mainRouter.HandlerFunc("GET", "/search", getSearch(s))
 
@@ -114,10 +135,36 @@ func NewMux(s *Setup) *httprouter.Router {
 
 func attachAuthRoutesToRouters(mainRouter, secureRouter *httprouter.Router, setup *Setup) {
 	mainRouter.HandlerFunc("POST", "/token-auth", postTokenAuth(setup))
-	mainRouter.HandlerFunc("GET", "/token-auth-refresh", getTokenAuthRefresh(setup))
+	mainRouter.HandlerFunc("POST", "/token-auth-refresh", postTokenAuthRefresh(setup))
+	mainRouter.HandlerFunc("POST", "/token-auth-totp", postTokenAuthTOTP(setup))
+	mainRouter.HandlerFunc("GET", "/.well-known/jwks.json", getJWKS(setup))
+	mainRouter.HandlerFunc("POST", "/password-reset", postPasswordReset(setup))
+	mainRouter.HandlerFunc("POST", "/password-reset/confirm", postPasswordResetConfirm(setup))
+	mainRouter.HandlerFunc("POST", "/email-verification", postEmailVerification(setup))
+	secureRouter.HandlerFunc("POST", "/users/:username/email-verification", postUserEmailVerification(setup))
+	secureRouter.HandlerFunc("POST", "/users/:username/totp", postUserTOTP(setup))
+	secureRouter.HandlerFunc("POST", "/users/:username/totp/confirm", postUserTOTPConfirm(setup))
+	secureRouter.HandlerFunc("DELETE", "/users/:username/totp", deleteUserTOTP(setup))
+	secureRouter.HandlerFunc("GET", "/users/:username/tokens", getUserTokens(setup))
+	secureRouter.HandlerFunc("POST", "/users/:username/tokens", postUserToken(setup))
+	secureRouter.HandlerFunc("DELETE", "/users/:username/tokens/:tokenID", deleteUserToken(setup))
+	secureRouter.HandlerFunc("GET", "/users/:username/sessions", getUserSessions(setup))
+	secureRouter.HandlerFunc("DELETE", "/users/:username/sessions", deleteUserSessions(setup))
+	secureRouter.HandlerFunc("DELETE", "/users/:username/sessions/:sessionID", deleteUserSession(setup))
 	secureRouter.HandlerFunc("GET", "/logout", getLogout(setup))
 }
 
+func attachOAuthRoutesToRouters(mainRouter, secureRouter *httprouter.Router, setup *Setup) {
+	mainRouter.HandlerFunc("GET", "/.well-known/openid-configuration", getOpenIDConfiguration(setup))
+	mainRouter.HandlerFunc("POST", "/oauth/token", postOAuthToken(setup))
+	secureRouter.HandlerFunc("GET", "/oauth/authorize", getOAuthAuthorize(setup))
+	secureRouter.HandlerFunc("POST", "/oauth/authorize", postOAuthAuthorize(setup))
+	secureRouter.HandlerFunc("GET", "/oauth/userinfo", getOAuthUserInfo(setup))
+	secureRouter.HandlerFunc("GET", "/users/:username/clients", getUserClients(setup))
+	secureRouter.HandlerFunc("POST", "/users/:username/clients", postUserClient(setup))
+	secureRouter.HandlerFunc("DELETE", "/users/:username/clients/:clientID", deleteUserClient(setup))
+}
+
 func attachUserRoutesToRouters(mainRouter, secureRouter *httprouter.Router, setup *Setup) {
 	mainRouter.HandlerFunc("GET", "/users", getUsers(setup))
 	mainRouter.HandlerFunc("POST", "/users", postUser(setup))
@@ -165,7 +212,7 @@ func attachCommentRoutesToRouters(mainRouter, secureRouter *httprouter.Router, s
 	secureRouter.HandlerFunc(http.MethodGet, "/posts/:postID/comments/:commentID/replies/:replyID", deleteComment(setup))
 }
 func attachChannelRoutesToRouters(mainRouter, secureRouter *httprouter.Router, setup *Setup) {
-	mainRouter.HandlerFunc("POST", "/channels", postChannel(setup))
+	secureRouter.HandlerFunc("POST", "/channels", postChannel(setup))
 	mainRouter.HandlerFunc("GET", "/channels", getChannels(setup))
 	mainRouter.HandlerFunc("GET", "/channels/:channelUsername", getChannel(setup))
 	secureRouter.HandlerFunc("PUT", "/channels/:channelUsername", putChannel(setup))
@@ -197,7 +244,6 @@ func attachChannelRoutesToRouters(mainRouter, secureRouter *httprouter.Router, s
 func attachPostRoutesToRouters(mainRouter, secureRouter *httprouter.Router, setup *Setup) {
 	mainRouter.HandlerFunc("GET", "/posts", getPosts(setup))
 	secureRouter.HandlerFunc("POST", "/posts", postPost(setup))
-	//TODO secure these routes
 	mainRouter.HandlerFunc("GET", "/posts/:postID", getPost(setup))
 	secureRouter.HandlerFunc("PUT", "/posts/:postID", putPost(setup))
 	secureRouter.HandlerFunc("DELETE", "/posts/:postID", deletePost(setup))
@@ -313,3 +359,15 @@ func attachChannelRoutesToRouters(mainRouter, secureRouter *mux.Router, setup *S
 }
 
 */
+
+// QueryTimeoutMiddleware cancels the context of requests once QueryTimeout has
+// passed, which abandons the queries they still have running.
+func QueryTimeoutMiddleware(s *Setup) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), s.QueryTimeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
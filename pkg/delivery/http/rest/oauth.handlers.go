@@ -0,0 +1,359 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
+)
+
+// oAuthErrorResponse is the RFC 6749 error response of the token endpoint.
+type oAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// writeOAuthResponseToWriter is a helper function that writes responses of the
+// endpoints clients talk to directly. These aren't in JSend envelopes since
+// OAuth2 client libraries expect RFC 6749 responses.
+func writeOAuthResponseToWriter(response interface{}, w http.ResponseWriter, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// authorizationRequestFromRequest is a helper function that reads the parameters
+// of an authorization request out of the query or form of the request.
+func authorizationRequestFromRequest(r *http.Request) *oauth.AuthorizationRequest {
+	return &oauth.AuthorizationRequest{
+		ClientID:            r.FormValue("client_id"),
+		RedirectURI:         r.FormValue("redirect_uri"),
+		ResponseType:        r.FormValue("response_type"),
+		Scopes:              strings.Fields(r.FormValue("scope")),
+		State:               r.FormValue("state"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+		Nonce:               r.FormValue("nonce"),
+	}
+}
+
+// authorizationRequestFailData is a helper function that describes why an
+// authorization request was rejected. It returns nil for errors that aren't
+// the request's fault.
+func authorizationRequestFailData(err error) *jSendFailData {
+	switch err {
+	case oauth.ErrClientNotFound:
+		return &jSendFailData{
+			ErrorReason:  "client_id",
+			ErrorMessage: "client not found",
+		}
+	case oauth.ErrInvalidRedirectURI:
+		return &jSendFailData{
+			ErrorReason:  "redirect_uri",
+			ErrorMessage: "redirect_uri isn't one registered for the client",
+		}
+	case oauth.ErrInvalidScope:
+		return &jSendFailData{
+			ErrorReason:  "scope",
+			ErrorMessage: "scope must be a space separated list of supported scopes",
+		}
+	case oauth.ErrInvalidRequest:
+		return &jSendFailData{
+			ErrorReason:  "request format",
+			ErrorMessage: "response_type must be code and an S256 code_challenge is required",
+		}
+	}
+	return nil
+}
+
+// getOAuthAuthorize returns a handler for GET /oauth/authorize requests.
+// It validates the request and describes it so that a consent screen can be shown.
+func getOAuthAuthorize(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		username := authorizedUsername(r)
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserAuthorizeClient, userResource(username)) {
+				s.Logger.Printf("unauthorized authorization request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		prompt, err := s.OAuthService.GetConsentPrompt(r.Context(), username, authorizationRequestFromRequest(r))
+		if err == nil {
+			response.Status = "success"
+			response.Data = *prompt
+		} else if failData := authorizationRequestFailData(err); failData != nil {
+			response.Data = *failData
+			statusCode = http.StatusBadRequest
+			if err == oauth.ErrClientNotFound {
+				statusCode = http.StatusNotFound
+			}
+		} else {
+			s.Logger.Printf("authorization request validation failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when validating authorization request"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postOAuthAuthorize returns a handler for POST /oauth/authorize requests.
+// It records the user's decision on the request and responds with the uri the
+// user is to be redirected to.
+func postOAuthAuthorize(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		username := authorizedUsername(r)
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserAuthorizeClient, userResource(username)) {
+				s.Logger.Printf("unauthorized authorization request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		req := authorizationRequestFromRequest(r)
+		var redirectURI string
+		var err error
+		if r.FormValue("approve") == "true" {
+			redirectURI, err = s.OAuthService.Authorize(r.Context(), username, req)
+		} else {
+			redirectURI, err = s.OAuthService.Deny(r.Context(), req)
+		}
+		if err == nil {
+			response.Status = "success"
+			response.Data = struct {
+				RedirectURI string `json:"redirectURI"`
+			}{redirectURI}
+			s.Logger.Printf("user %s decided on authorization request of client %s", username, req.ClientID)
+		} else if failData := authorizationRequestFailData(err); failData != nil {
+			response.Data = *failData
+			statusCode = http.StatusBadRequest
+			if err == oauth.ErrClientNotFound {
+				statusCode = http.StatusNotFound
+			}
+		} else {
+			s.Logger.Printf("authorization failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when authorizing client"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postOAuthToken returns a handler for POST /oauth/token requests.
+// Clients authenticate using HTTP Basic or client_id and client_secret form values.
+func postOAuthToken(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &oauth.TokenRequest{
+			GrantType:    r.PostFormValue("grant_type"),
+			Code:         r.PostFormValue("code"),
+			RedirectURI:  r.PostFormValue("redirect_uri"),
+			CodeVerifier: r.PostFormValue("code_verifier"),
+			RefreshToken: r.PostFormValue("refresh_token"),
+			ClientID:     r.PostFormValue("client_id"),
+			ClientSecret: r.PostFormValue("client_secret"),
+			UserAgent:    r.UserAgent(),
+			IPAddress:    clientIP(r),
+		}
+		clientID, clientSecret, basicAuth := r.BasicAuth()
+		if basicAuth {
+			req.ClientID, req.ClientSecret = clientID, clientSecret
+		}
+		tokens, err := s.OAuthService.Exchange(r.Context(), req)
+		switch err {
+		case nil:
+			writeOAuthResponseToWriter(tokens, w, http.StatusOK)
+			s.Logger.Printf("client %s was issued tokens through %s grant", req.ClientID, req.GrantType)
+		case oauth.ErrInvalidClient:
+			if basicAuth {
+				w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			writeOAuthResponseToWriter(oAuthErrorResponse{"invalid_client", "client authentication failed"}, w, http.StatusUnauthorized)
+		case oauth.ErrInvalidGrant:
+			writeOAuthResponseToWriter(oAuthErrorResponse{"invalid_grant", "grant is invalid, expired or was issued to another client"}, w, http.StatusBadRequest)
+		case oauth.ErrUnsupportedGrantType:
+			writeOAuthResponseToWriter(oAuthErrorResponse{"unsupported_grant_type", fmt.Sprintf("grant_type must be %s or %s", oauth.GrantTypeAuthorizationCode, oauth.GrantTypeRefreshToken)}, w, http.StatusBadRequest)
+		default:
+			s.Logger.Printf("token exchange failed because: %v", err)
+			writeOAuthResponseToWriter(oAuthErrorResponse{"server_error", ""}, w, http.StatusInternalServerError)
+		}
+	}
+}
+
+// getOAuthUserInfo returns a handler for GET /oauth/userinfo requests
+func getOAuthUserInfo(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		if principal == nil || !principal.HasScope(oauth.ScopeOpenID) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			writeOAuthResponseToWriter(oAuthErrorResponse{"insufficient_scope", "the openid scope is required"}, w, http.StatusForbidden)
+			return
+		}
+		info, err := s.OAuthService.GetUserInfo(r.Context(), principal)
+		if err != nil {
+			s.Logger.Printf("fetching user info failed because: %v", err)
+			writeOAuthResponseToWriter(oAuthErrorResponse{"server_error", ""}, w, http.StatusInternalServerError)
+			return
+		}
+		writeOAuthResponseToWriter(info, w, http.StatusOK)
+	}
+}
+
+// getOpenIDConfiguration returns a handler for GET /.well-known/openid-configuration requests
+func getOpenIDConfiguration(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(s.OAuthService.GetDiscoveryDocument()); err != nil {
+			s.Logger.Printf("writing openid configuration failed because: %v", err)
+		}
+	}
+}
+
+// getUserClients returns a handler for GET /users/{username}/clients requests
+func getUserClients(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageClients, userResource(username)) {
+				s.Logger.Printf("unauthorized client list request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		clients, err := s.OAuthService.GetClients(r.Context(), username)
+		if err != nil {
+			s.Logger.Printf("fetching clients failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when fetching clients"
+			statusCode = http.StatusInternalServerError
+		} else {
+			response.Status = "success"
+			response.Data = clients
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// postUserClient returns a handler for POST /users/{username}/clients requests
+func postUserClient(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageClients, userResource(username)) {
+				s.Logger.Printf("unauthorized client registration request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		c := new(oauth.Client)
+		{ // this block extracts the client details from the request
+			c.Name = r.FormValue("name")
+			if c.Name != "" {
+				c.RedirectURIs = strings.Fields(r.FormValue("redirectURIs"))
+				c.Confidential = r.FormValue("confidential") == "true"
+			} else {
+				err := json.NewDecoder(r.Body).Decode(c)
+				if err != nil {
+					c.Name = ""
+				}
+			}
+			if c.Name == "" || len(c.RedirectURIs) == 0 {
+				response.Data = jSendFailData{
+					ErrorReason:  "request format",
+					ErrorMessage: `bad request, use format {"name":"name","redirectURIs":["uri"],"confidential":false}`,
+				}
+				s.Logger.Printf("bad client registration request")
+				statusCode = http.StatusBadRequest
+			}
+		}
+		if response.Data == nil {
+			c.OwnerUsername = username
+			c, err := s.OAuthService.RegisterClient(r.Context(), c)
+			switch err {
+			case nil:
+				response.Status = "success"
+				response.Data = *c
+				statusCode = http.StatusCreated
+				s.Logger.Printf("user %s registered client %s", username, c.ID)
+			case oauth.ErrInvalidRedirectURI:
+				response.Data = jSendFailData{
+					ErrorReason:  "redirectURIs",
+					ErrorMessage: "redirect uris must be absolute and have no fragment",
+				}
+				statusCode = http.StatusBadRequest
+			default:
+				s.Logger.Printf("client registration failed because: %v", err)
+				response.Status = "error"
+				response.Message = "server error when registering client"
+				statusCode = http.StatusInternalServerError
+			}
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
+
+// deleteUserClient returns a handler for DELETE /users/{username}/clients/{clientID} requests
+func deleteUserClient(s *Setup) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response jSendResponse
+		statusCode := http.StatusOK
+		response.Status = "fail"
+
+		vars := getParametersFromRequestAsMap(r)
+		username := vars["username"]
+		clientID := vars["clientID"]
+		{ // this block secures the route
+			if !authorize(s, r, policy.UserManageClients, userResource(username)) {
+				s.Logger.Printf("unauthorized client deletion request")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		err := s.OAuthService.DeleteClient(r.Context(), username, clientID)
+		switch err {
+		case nil:
+			response.Status = "success"
+			s.Logger.Printf("user %s deleted client %s", username, clientID)
+		case oauth.ErrClientNotFound:
+			response.Data = jSendFailData{
+				ErrorReason:  "clientID",
+				ErrorMessage: fmt.Sprintf("client of id %s not found", clientID),
+			}
+			statusCode = http.StatusNotFound
+		default:
+			s.Logger.Printf("client deletion failed because: %v", err)
+			response.Status = "error"
+			response.Message = "server error when deleting client"
+			statusCode = http.StatusInternalServerError
+		}
+		writeResponseToWriter(response, w, statusCode)
+	}
+}
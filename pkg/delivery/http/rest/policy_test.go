@@ -0,0 +1,131 @@
+package rest_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+// route is a request made as part of a table of them.
+type route struct {
+	method, path string
+	body         interface{}
+}
+
+// addTextRelease adds a text release to the channel of the user, as the user
+// the token was issued to, and returns the id of the release.
+func addTextRelease(t *testing.T, s *resttest.Server, token, username string) string {
+	t.Helper()
+	var rel struct {
+		ID int `json:"id"`
+	}
+	s.Do(t, http.MethodPost, "/releases", token, resttest.Multipart{
+		Fields: map[string]string{"JSON": `{"ownerChannel": "` + username + `", "type": "text", "content": "Jabberwocky"}`},
+	}).ExpectSuccess(t, http.StatusCreated, &rel)
+	return strconv.Itoa(rel.ID)
+}
+
+var policyScenarios = []scenario{
+	{"policy/forbidden routes", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+		rel := addTextRelease(t, s, token, "alice")
+		var c struct {
+			ID int `json:"id"`
+		}
+		s.Do(t, http.MethodPost, "/posts/"+p+"/comments", token, map[string]string{"commenter": "alice", "content": "first"}).
+			ExpectSuccess(t, http.StatusOK, &c)
+		comment := "/posts/" + p + "/comments/" + strconv.Itoa(c.ID)
+
+		// everything alice owns, bobby is turned away from
+		for _, route := range []route{
+			{http.MethodPut, "/users/alice", map[string]string{"bio": "impostor"}},
+			{http.MethodDelete, "/users/alice", nil},
+			{http.MethodGet, "/users/alice/bookmarks", nil},
+			{http.MethodPut, "/users/alice/bookmarks/" + p, nil},
+			{http.MethodDelete, "/users/alice/picture", nil},
+			{http.MethodGet, "/users/alice/feed", nil},
+			{http.MethodPut, "/users/alice/feed", map[string]string{"sorting": "new"}},
+			{http.MethodGet, "/users/alice/tokens", nil},
+			{http.MethodGet, "/users/alice/sessions", nil},
+			{http.MethodGet, "/users/alice/clients", nil},
+			{http.MethodPost, "/users/alice/totp", nil},
+
+			{http.MethodPut, "/channels/alice", map[string]string{"description": "impostor"}},
+			{http.MethodDelete, "/channels/alice", nil},
+			{http.MethodGet, "/channels/alice/admins", nil},
+			{http.MethodPut, "/channels/alice/admins/bobby", nil},
+			{http.MethodDelete, "/channels/alice/admins/alice", nil},
+			{http.MethodPut, "/channels/alice/owners/bobby", nil},
+			{http.MethodPut, "/channels/alice/Posts/" + p, nil},
+			{http.MethodDelete, "/channels/alice/catalogs/" + rel, nil},
+			{http.MethodPut, "/channels/alice/catalogs/" + rel, map[string]string{"content": "impostor"}},
+			{http.MethodDelete, "/channels/alice/picture", nil},
+
+			{http.MethodPost, "/releases", resttest.Multipart{
+				Fields: map[string]string{"JSON": `{"ownerChannel": "alice", "type": "text", "content": "impostor"}`},
+			}},
+			{http.MethodPatch, "/releases/" + rel, map[string]string{"content": "impostor"}},
+			{http.MethodDelete, "/releases/" + rel, nil},
+
+			{http.MethodPost, "/posts", map[string]string{"PostedByUsername": "alice", "originChannel": "alice", "title": "impostor"}},
+			{http.MethodPut, "/posts/" + p, map[string]string{"title": "impostor"}},
+			{http.MethodDelete, "/posts/" + p, nil},
+			{http.MethodPut, "/posts/" + p + "/stars", map[string]interface{}{"username": "alice", "stars": 5}},
+
+			{http.MethodPost, "/posts/" + p + "/comments", map[string]string{"commenter": "alice", "content": "impostor"}},
+			{http.MethodPatch, comment, map[string]string{"content": "impostor"}},
+			{http.MethodDelete, comment, nil},
+		} {
+			s.Do(t, route.method, route.path, bobby, route.body).ExpectStatus(t, http.StatusForbidden)
+		}
+	}},
+	{"policy/scoped tokens", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		p := s.AddPost(t, token, "alice", "first")
+		rel := addTextRelease(t, s, token, "alice")
+		var pat struct {
+			Token string `json:"token"`
+		}
+		s.Do(t, http.MethodPost, "/users/alice/tokens", token, map[string]interface{}{"name": "channels", "scopes": []string{"channels:write"}}).
+			ExpectSuccess(t, http.StatusCreated, &pat)
+
+		s.Do(t, http.MethodPut, "/channels/alice", pat.Token, map[string]string{"description": "by token"}).
+			ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodPut, "/channels/alice/Posts/"+p, pat.Token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodPost, "/channels", pat.Token, map[string]string{"channelUsername": "wonderland", "name": "Wonderland"}).
+			ExpectSuccess(t, http.StatusOK, nil)
+
+		// creating channels takes channels:write too
+		var comments struct {
+			Token string `json:"token"`
+		}
+		s.Do(t, http.MethodPost, "/users/alice/tokens", token, map[string]interface{}{"name": "comments", "scopes": []string{"comments:write"}}).
+			ExpectSuccess(t, http.StatusCreated, &comments)
+		s.Do(t, http.MethodPost, "/channels", comments.Token, map[string]string{"channelUsername": "looking-glass", "name": "Looking-Glass"}).
+			ExpectStatus(t, http.StatusForbidden)
+
+		// outside of its scopes, the token can do nothing alice could
+		for _, route := range []route{
+			{http.MethodDelete, "/channels/alice", nil},
+			{http.MethodPut, "/channels/alice/owners/alice", nil},
+			// managing the catalog takes channels:write but changing the release in it releases:write
+			{http.MethodPut, "/channels/alice/catalogs/" + rel, map[string]string{"content": "by token"}},
+			{http.MethodPatch, "/releases/" + rel, map[string]string{"content": "by token"}},
+			{http.MethodPost, "/posts", map[string]string{"PostedByUsername": "alice", "originChannel": "alice", "title": "by token"}},
+			{http.MethodPost, "/posts/" + p + "/comments", map[string]string{"commenter": "alice", "content": "by token"}},
+			{http.MethodGet, "/users/alice/bookmarks", nil},
+			{http.MethodPut, "/users/alice", map[string]string{"bio": "by token"}},
+			{http.MethodGet, "/users/alice/tokens", nil},
+		} {
+			s.Do(t, route.method, route.path, pat.Token, route.body).ExpectStatus(t, http.StatusForbidden)
+		}
+	}},
+}
+
+func TestPolicy(t *testing.T) {
+	run(t, policyScenarios)
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 func sanitizePost(p *post.Post, s *Setup) {
@@ -54,7 +55,7 @@ func getPost(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 		if response.Data == nil {
 			id := uint(id)
 			d.Logger.Printf("trying to fetch Post %d", id)
-			rel, err := d.PostService.GetPost(id)
+			rel, err := d.PostService.GetPost(r.Context(), id)
 			switch err {
 			case nil:
 				response.Status = "success"
@@ -142,9 +143,13 @@ func postPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 
 			{ // this block secures the route
-				if newPost.PostedByUsername != r.Header.Get("authorized_username") {
+				if !authorize(s, r, policy.PostCreate, userResource(newPost.PostedByUsername)) {
+					if !isVerified(r) {
+						writeVerificationRequiredResponse(s, w)
+						return
+					}
 					s.Logger.Printf("unauthorized update post attempt")
-					w.WriteHeader(http.StatusUnauthorized)
+					w.WriteHeader(http.StatusForbidden)
 					return
 				}
 				/*
@@ -173,7 +178,7 @@ func postPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			if response.Data == nil {
 				sanitizePost(newPost, s)
 				s.Logger.Printf("trying to add post %s %s %s %s", newPost.PostedByUsername, newPost.Title, newPost.OriginChannel, newPost.Description)
-				pos, err := s.PostService.AddPost(newPost)
+				pos, err := s.PostService.AddPost(r.Context(), newPost)
 				switch err {
 				case nil:
 					response.Status = "success"
@@ -220,11 +225,11 @@ func putPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			id := uint(id)
 			{ // this block blocks user updating of post if the poster didn't accessing the route
 
-				x, err := s.PostService.GetPost(id)
+				x, err := s.PostService.GetPost(r.Context(), id)
 				if err == nil {
-					if x.PostedByUsername != r.Header.Get("authorized_username") {
+					if !authorize(s, r, policy.PostUpdate, postResource(x)) {
 						s.Logger.Printf("unauthorized update post attempt")
-						w.WriteHeader(http.StatusUnauthorized)
+						w.WriteHeader(http.StatusForbidden)
 						return
 					}
 					/*
@@ -282,7 +287,7 @@ func putPost(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					statusCode = http.StatusBadRequest
 				} else {
 					sanitizePost(newPost, s)
-					pos, erron := s.PostService.UpdatePost(newPost, id)
+					pos, erron := s.PostService.UpdatePost(r.Context(), newPost, id)
 					switch erron {
 					case nil:
 						s.Logger.Printf("success put post %s %s %s %s %s", idRaw, pos.PostedByUsername, pos.OriginChannel, pos.Title, pos.Description)
@@ -334,11 +339,11 @@ func deletePost(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 		} else {
 			id := uint(id)
 			{ // this block blocks user deleting of post if the poster didn't accessing the route
-				x, err := d.PostService.GetPost(id)
+				x, err := d.PostService.GetPost(r.Context(), id)
 				if err == nil {
-					if x.PostedByUsername != r.Header.Get("authorized_username") {
-						d.Logger.Printf("unauthorized update post attempt")
-						w.WriteHeader(http.StatusUnauthorized)
+					if !authorize(d, r, policy.PostDelete, postResource(x)) {
+						d.Logger.Printf("unauthorized delete post attempt")
+						w.WriteHeader(http.StatusForbidden)
 						return
 					}
 					/*
@@ -352,7 +357,7 @@ func deletePost(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 								}
 							}
 							if !posterFound{
-								d.Logger.Printf("unauthorized update post attempt")
+								d.Logger.Printf("unauthorized delete post attempt")
 								w.WriteHeader(http.StatusUnauthorized)
 								return
 							}
@@ -370,7 +375,7 @@ func deletePost(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 
 			}
 			d.Logger.Printf("trying to delete Post %d", id)
-			err := d.PostService.DeletePost(id)
+			err := d.PostService.DeletePost(r.Context(), id)
 			switch err {
 			case nil:
 				response.Status = "success"
@@ -419,13 +424,13 @@ func getPostReleases(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 		if response.Data == nil {
 			id := uint(id)
 			d.Logger.Printf("trying to fetch Post %d", id)
-			pos, err := d.PostService.GetPost(id)
+			pos, err := d.PostService.GetPost(r.Context(), id)
 			switch err {
 			case nil:
 				response.Status = "success"
 				pReleases := make([]interface{}, 0)
 				for _, rID := range pos.ContentsID {
-					if temp, err := d.ReleaseService.GetRelease(int(rID)); err == nil {
+					if temp, err := d.ReleaseService.GetRelease(r.Context(), int(rID)); err == nil {
 						pReleases = append(pReleases, temp)
 					} else {
 						pReleases = append(pReleases, rID)
@@ -474,13 +479,13 @@ func getPostComments(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 		if response.Data == nil {
 			id := uint(id)
 			d.Logger.Printf("trying to fetch Post %d", id)
-			pos, err := d.PostService.GetPost(id)
+			pos, err := d.PostService.GetPost(r.Context(), id)
 			switch err {
 			case nil:
 				response.Status = "success"
 				pComments := make([]interface{}, 0)
 				for _, cID := range pos.CommentsID {
-					if temp, err := d.CommentService.GetComment(cID); err == nil {
+					if temp, err := d.CommentService.GetComment(r.Context(), cID); err == nil {
 						pComments = append(pComments, temp)
 					} else {
 						pComments = append(pComments, cID)
@@ -574,7 +579,7 @@ func getPosts(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			posts, err := s.PostService.SearchPost(pattern, sortBy, sortOrder, limit, offset)
+			posts, err := s.PostService.SearchPost(r.Context(), pattern, sortBy, sortOrder, limit, offset)
 			if err != nil {
 				s.Logger.Printf("fetching of post failed because: %v", err)
 				response.Status = "error"
@@ -613,14 +618,14 @@ func getPostStars(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 		if response.Data == nil {
 			id := uint(id)
 			d.Logger.Printf("trying to fetch Post %d", id)
-			pos, err := d.PostService.GetPost(id)
+			pos, err := d.PostService.GetPost(r.Context(), id)
 			switch err {
 			case nil:
 
 				response.Status = "success"
 				pStars := make([]interface{}, 0)
 				for username := range pos.Stars {
-					if temp, err := d.PostService.GetPostStar(id, username); err == nil {
+					if temp, err := d.PostService.GetPostStar(r.Context(), id, username); err == nil {
 						pStars = append(pStars, temp)
 					} else {
 						pStars = append(pStars, username)
@@ -673,7 +678,7 @@ func getPostStar(d *Setup) func(w http.ResponseWriter, r *http.Request) {
 		if response.Data == nil {
 			id := uint(id)
 			d.Logger.Printf("trying to fetch Star of Post %d and username %s", id, username)
-			st, err := d.PostService.GetPostStar(id, username)
+			st, err := d.PostService.GetPostStar(r.Context(), id, username)
 			switch err {
 			case nil:
 				response.Status = "success"
@@ -743,14 +748,18 @@ func putPostStar(s *Setup) func(http.ResponseWriter, *http.Request) {
 			} else {
 				username := st.Username
 				{ // this block secures the route
-					if username != r.Header.Get("authorized_username") {
+					if !authorize(s, r, policy.PostStar, userResource(username)) {
+						if !isVerified(r) {
+							writeVerificationRequiredResponse(s, w)
+							return
+						}
 						s.Logger.Printf("unauthorized post Star request")
-						w.WriteHeader(http.StatusUnauthorized)
+						w.WriteHeader(http.StatusForbidden)
 						return
 					}
 				}
 				if st.NumOfStars == 0 {
-					errs := s.PostService.DeletePostStar(id, username)
+					errs := s.PostService.DeletePostStar(r.Context(), id, username)
 					switch errs {
 					case nil:
 						response.Status = "success"
@@ -787,10 +796,10 @@ func putPostStar(s *Setup) func(http.ResponseWriter, *http.Request) {
 					statusCode = http.StatusBadRequest
 				}
 				if response.Data == nil {
-					_, errr := s.PostService.GetPostStar(id, username)
+					_, errr := s.PostService.GetPostStar(r.Context(), id, username)
 					switch errr {
 					case nil:
-						newStar, w := s.PostService.UpdatePostStar(id, st)
+						newStar, w := s.PostService.UpdatePostStar(r.Context(), id, st)
 						switch w {
 						case nil:
 							response.Status = "success"
@@ -823,7 +832,7 @@ func putPostStar(s *Setup) func(http.ResponseWriter, *http.Request) {
 						statusCode = http.StatusNotFound
 
 					case post.ErrStarNotFound:
-						newStar, e := s.PostService.AddPostStar(id, st)
+						newStar, e := s.PostService.AddPostStar(r.Context(), id, st)
 						switch e {
 						case nil:
 							response.Status = "success"
@@ -0,0 +1,92 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+var postScenarios = []scenario{
+	{"posts/create and get", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+
+		r := s.Do(t, http.MethodPost, "/posts", token, map[string]string{
+			"PostedByUsername": "alice",
+			"originChannel":    "alice",
+			"title":            "Down the Rabbit-Hole",
+			"description":      "chapter one",
+		})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/create", r)
+		s.AddPost(t, token, "alice", "The Pool of Tears")
+
+		r = s.Do(t, http.MethodGet, "/posts/1", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/get", r)
+
+		r = s.Do(t, http.MethodGet, "/posts/404", "", nil)
+		r.ExpectFail(t, http.StatusNotFound, "postID")
+		g.Check(t, "posts/get-missing", r)
+
+		r = s.Do(t, http.MethodGet, "/posts?sort=title_asc", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/list", r)
+	}},
+	{"posts/only verified users post", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		s.SignUp(t, "alice")
+		token := s.Login(t, "alice", resttest.Password)
+		post := map[string]string{"PostedByUsername": "alice", "originChannel": "alice", "title": "Unverified"}
+
+		r := s.Do(t, http.MethodPost, "/posts", token, post)
+		r.ExpectFail(t, http.StatusForbidden, "verification")
+		g.Check(t, "posts/create-unverified", r)
+
+		s.VerifyEmail(t, "alice")
+		s.Do(t, http.MethodPost, "/posts", token, post).ExpectSuccess(t, http.StatusOK, nil)
+	}},
+	{"posts/others' posts", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+		update := map[string]string{"title": "edited"}
+
+		s.Do(t, http.MethodPost, "/posts", bobby, map[string]string{"PostedByUsername": "alice", "originChannel": "alice", "title": "impostor"}).
+			ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodPut, "/posts/"+p, bobby, update).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodDelete, "/posts/"+p, bobby, nil).ExpectStatus(t, http.StatusForbidden)
+
+		r := s.Do(t, http.MethodPut, "/posts/"+p, token, update)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/update", r)
+
+		s.Do(t, http.MethodDelete, "/posts/"+p, token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodGet, "/posts/"+p, "", nil).ExpectFail(t, http.StatusNotFound, "postID")
+	}},
+	{"posts/stars", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+
+		s.Do(t, http.MethodPut, "/posts/"+p+"/stars", token, map[string]interface{}{"username": "bobby", "stars": 1}).
+			ExpectStatus(t, http.StatusForbidden)
+
+		r := s.Do(t, http.MethodPut, "/posts/"+p+"/stars", bobby, map[string]interface{}{"username": "bobby", "stars": 4})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/star", r)
+		s.Do(t, http.MethodPut, "/posts/"+p+"/stars", token, map[string]interface{}{"username": "alice", "stars": 6}).
+			ExpectFail(t, http.StatusBadRequest, "request format")
+
+		r = s.Do(t, http.MethodGet, "/posts/"+p+"/stars", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/stars", r)
+
+		r = s.Do(t, http.MethodGet, "/posts/"+p+"/stars/bobby", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "posts/stars-of-user", r)
+	}},
+}
+
+func TestPosts(t *testing.T) {
+	run(t, postScenarios)
+}
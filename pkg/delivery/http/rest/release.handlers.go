@@ -1,13 +1,14 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 	"net/http"
-	"net/url"
-	"os"
 	"strconv"
 	"strings"
 )
@@ -15,12 +16,13 @@ import (
 // postRelease returns a handler for POST /releases requests
 func postRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		limitImageUpload(w, r, s)
 		var response jSendResponse
 		response.Status = "fail"
 		statusCode := http.StatusCreated
 
 		newRelease := new(release.Release)
-		var tmpFile *os.File
+		var images []*imaging.Image
 		{ // this block parses the JSON part of the request
 			err := json.Unmarshal([]byte(r.PostFormValue("JSON")), newRelease)
 			if err != nil {
@@ -47,19 +49,12 @@ func postRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					statusCode = http.StatusBadRequest
 				} else {
 					{ // this block secure the route
-						c, err := s.ChannelService.GetChannel(newRelease.OwnerChannel)
+						c, err := s.ChannelService.GetChannel(r.Context(), newRelease.OwnerChannel)
 						switch err {
 						case nil:
-							isAdmin := false
-							for _, admin := range c.AdminUsernames {
-								if r.Header.Get("authorized_username") == admin {
-									isAdmin = true
-									break
-								}
-							}
-							if !isAdmin {
+							if !authorize(s, r, policy.ReleaseCreate, channelResource(c)) {
 								s.Logger.Printf("unauthorized add release request")
-								w.WriteHeader(http.StatusUnauthorized)
+								w.WriteHeader(http.StatusForbidden)
 								return
 							}
 						case channel.ErrChannelNotFound:
@@ -82,21 +77,23 @@ func postRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				{ // this block extracts the image file if necessary
 					switch newRelease.Type {
 					case release.Image:
-						var fileName string
 						var err error
-						tmpFile, fileName, err = saveImageFromRequest(r, "image")
+						images, err = processImageFromRequest(r, s, "image")
 						switch err {
 						case nil:
-							defer tmpFile.Close()
-							defer os.Remove(tmpFile.Name())
-							s.Logger.Printf(fmt.Sprintf("temp file saved: %s", tmpFile.Name()))
-							newRelease.Content = generateFileNameForStorage(fileName, "release")
+							newRelease.Content = generateFileNameForStorage("release")
 						case errUnacceptedType:
 							response.Data = jSendFailData{
 								ErrorMessage: "image-type",
 								ErrorReason:  "only types image/jpeg & image/png are accepted",
 							}
 							statusCode = http.StatusBadRequest
+						case errImageTooLarge:
+							response.Data = jSendFailData{
+								ErrorReason:  "image",
+								ErrorMessage: "image has too many pixels or takes too many bytes",
+							}
+							statusCode = http.StatusBadRequest
 						case errReadingFromImage:
 							response.Data = jSendFailData{
 								ErrorReason:  "image",
@@ -126,33 +123,28 @@ func postRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 				if response.Data == nil {
-					newRelease, err := s.ReleaseService.AddRelease(newRelease)
+					// the release is only added if its image is saved too
+					imageName := newRelease.Content
+					err := storeImage(r.Context(), s, images, imageName)
+					if err == nil {
+						err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+							deleteImageOnRollback(ctx, s, images, imageName)
+							var err error
+							newRelease, err = s.ReleaseService.AddRelease(ctx, newRelease)
+							return err
+						})
+					}
 					switch err {
 					case nil:
+						response.Status = "success"
 						if newRelease.Type == release.Image {
-							err := saveTempFilePermanentlyToPath(tmpFile, s.ImageStoragePath+newRelease.Content)
-							if err != nil {
-								s.Logger.Printf("adding of release failed because: %v", err)
-								response.Status = "error"
-								response.Message = "server error when adding release"
-								statusCode = http.StatusInternalServerError
-								_ = s.ReleaseService.DeleteRelease(newRelease.ID)
-							}
-						}
-						if response.Message == "" {
-							response.Status = "success"
-							if newRelease.Type == release.Image {
-								newRelease.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(newRelease.Content)
-							}
-							response.Data = *newRelease
-							s.Logger.Printf("success adding release %d to channel %s", newRelease.ID, newRelease.OwnerChannel)
+							newRelease.Content, newRelease.Images = imageURLs(r.Context(), s, newRelease.Content)
 						}
+						response.Data = *newRelease
+						s.Logger.Printf("success adding release %d to channel %s", newRelease.ID, newRelease.OwnerChannel)
 					case release.ErrSomeReleaseDataNotPersisted:
 						fallthrough
 					default:
-						if newRelease != nil && newRelease.ID != 0 {
-							_ = s.ReleaseService.DeleteRelease(newRelease.ID)
-						}
 						s.Logger.Printf("adding of release failed because: %v", err)
 						response.Status = "error"
 						response.Message = "server error when adding release"
@@ -186,15 +178,14 @@ func getRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if response.Data == nil {
-			rel, err := s.ReleaseService.GetRelease(id)
+			rel, err := s.ReleaseService.GetRelease(r.Context(), id)
 			switch err {
 			case nil:
 				if rel.Type == release.Image {
-					rel.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(rel.Content)
+					rel.Content, rel.Images = imageURLs(r.Context(), s, rel.Content)
 				}
-				// TODO secure route
 				{ // this block sanitizes the returned User if it's not the user herself accessing the route
-					c, err := s.ChannelService.GetChannel(rel.OwnerChannel)
+					c, err := s.ChannelService.GetChannel(r.Context(), rel.OwnerChannel)
 					switch err {
 					case nil:
 						isOfficial := false
@@ -210,19 +201,9 @@ func getRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 							s.Logger.Printf("success fetching release %d from an offical catalog", id)
 							break
 						}
-						// if not official, send release back only for an admin
-						isAdmin := false
-						for _, admin := range c.AdminUsernames {
-							if r.Header.Get("authorized_username") == admin {
-								isAdmin = true
-								break
-							}
-						}
-						if isAdmin {
+						// if not official, send release back only to those allowed by the policy
+						if authorize(s, r, policy.ReleaseViewUnofficial, channelResource(c)) {
 							response.Status = "success"
-							if rel.Type == release.Image {
-								rel.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(rel.Content)
-							}
 							response.Data = *rel
 							s.Logger.Printf("success fetching release %d from an offical catalog", id)
 							break
@@ -329,7 +310,7 @@ func getReleases(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			releases, err := s.ReleaseService.SearchRelease(pattern, sortBy, sortOrder, limit, offset)
+			releases, err := s.ReleaseService.SearchRelease(r.Context(), pattern, sortBy, sortOrder, limit, offset)
 			if err != nil {
 				s.Logger.Printf("fetching of releases failed because: %v", err)
 				response.Status = "error"
@@ -339,7 +320,7 @@ func getReleases(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				response.Status = "success"
 				for _, rel := range releases {
 					if rel.Type == release.Image {
-						rel.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(rel.Content)
+						rel.Content, rel.Images = imageURLs(r.Context(), s, rel.Content)
 					}
 				}
 				response.Data = releases
@@ -353,6 +334,7 @@ func getReleases(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 // patchRelease returns a handler for PUT /releases/{id} requests
 func patchRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		limitImageUpload(w, r, s)
 		var response jSendResponse
 		response.Status = "fail"
 		statusCode := http.StatusOK
@@ -370,28 +352,21 @@ func patchRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			statusCode = http.StatusBadRequest
 		}
 		if response.Data == nil {
-			temp, err := s.ReleaseService.GetRelease(id)
+			temp, err := s.ReleaseService.GetRelease(r.Context(), id)
 			switch err {
 			case nil:
 				{ // this block secure the route
-					c, err := s.ChannelService.GetChannel(temp.OwnerChannel)
+					c, err := s.ChannelService.GetChannel(r.Context(), temp.OwnerChannel)
 					switch err {
 					case nil:
-						isAdmin := false
-						for _, admin := range c.AdminUsernames {
-							if r.Header.Get("authorized_username") == admin {
-								isAdmin = true
-								break
-							}
-						}
-						if !isAdmin {
+						if !authorize(s, r, policy.ReleaseUpdate, channelResource(c)) {
 							s.Logger.Printf("unauthorized add release request")
-							w.WriteHeader(http.StatusUnauthorized)
+							w.WriteHeader(http.StatusForbidden)
 							return
 						}
 
 						rel := new(release.Release)
-						var tmpFile *os.File
+						var images []*imaging.Image
 						{ // this block parses the JSON part of the request
 							err := json.Unmarshal([]byte(r.PostFormValue("JSON")), rel)
 							if err != nil {
@@ -411,16 +386,12 @@ func patchRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 									case release.Image:
 										fallthrough
 									default:
-										var fileName string
 										var err error
-										tmpFile, fileName, err = saveImageFromRequest(r, "image")
+										images, err = processImageFromRequest(r, s, "image")
 										switch err {
 										case nil:
 											s.Logger.Printf("image found on put request")
-											defer os.Remove(tmpFile.Name())
-											defer tmpFile.Close()
-											s.Logger.Printf(fmt.Sprintf("temp file saved: %s", tmpFile.Name()))
-											rel.Content = generateFileNameForStorage(fileName, "release")
+											rel.Content = generateFileNameForStorage("release")
 											rel.Type = release.Image
 										case errUnacceptedType:
 											response.Data = jSendFailData{
@@ -428,6 +399,12 @@ func patchRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 												ErrorReason:  "only types image/jpeg & image/png are accepted",
 											}
 											statusCode = http.StatusBadRequest
+										case errImageTooLarge:
+											response.Data = jSendFailData{
+												ErrorReason:  "image",
+												ErrorMessage: "image has too many pixels or takes too many bytes",
+											}
+											statusCode = http.StatusBadRequest
 										case errReadingFromImage:
 											s.Logger.Printf("image not found on put request")
 											if rel.Type == release.Image {
@@ -452,13 +429,13 @@ func patchRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 								rel.Description == "" && len(rel.Genres) == 0 && len(rel.Authors) == 0 &&
 								rel.OwnerChannel == "" {
 								//no patchable data found
-								rel, err = s.ReleaseService.GetRelease(id)
+								rel, err = s.ReleaseService.GetRelease(r.Context(), id)
 								switch err {
 								case nil:
 									s.Logger.Printf("success put release at id %d", id)
 									response.Status = "success"
 									if rel.Type == release.Image {
-										rel.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(rel.Content)
+										rel.Content, rel.Images = imageURLs(r.Context(), s, rel.Content)
 									}
 									response.Data = *rel
 								default:
@@ -470,28 +447,37 @@ func patchRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 							}
 							if response.Data == nil {
 								rel.ID = id
-								rel, err = s.ReleaseService.UpdateRelease(rel)
-								switch err {
-								case nil:
-									if rel.Type == release.Image {
-										err := saveTempFilePermanentlyToPath(tmpFile, s.ImageStoragePath+rel.Content)
+								// the release is only updated if its new image is saved too
+								imageName := rel.Content
+								var oldImage string
+								err = storeImage(r.Context(), s, images, imageName)
+								if err == nil {
+									err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+										deleteImageOnRollback(ctx, s, images, imageName)
+										old, err := s.ReleaseService.GetRelease(ctx, rel.ID)
 										if err != nil {
-											s.Logger.Printf("updating of release failed because: %v", err)
-											response.Status = "error"
-											response.Message = "server error when updating release"
-											statusCode = http.StatusInternalServerError
-											_ = s.ReleaseService.DeleteRelease(rel.ID)
+											return err
 										}
-									}
-									if response.Message == "" {
-										s.Logger.Printf("success updating release %d", id)
-										response.Status = "success"
-										if rel.Type == release.Image {
-											rel.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(rel.Content)
+										if len(images) > 0 && old.Type == release.Image {
+											oldImage = old.Content
 										}
-										response.Data = *rel
-										// TODO delete old image if image updated
+										rel, err = s.ReleaseService.UpdateRelease(ctx, rel)
+										return err
+									})
+								}
+								switch err {
+								case nil:
+									deleteStoredImage(s, oldImage)
+									s.Logger.Printf("success updating release %d", id)
+									response.Status = "success"
+									if rel.Type == release.Image {
+										rel.Content, rel.Images = imageURLs(r.Context(), s, rel.Content)
 									}
+									response.Data = *rel
+								case policy.ErrForbidden:
+									s.Logger.Printf("unauthorized move of release %d to another channel", id)
+									w.WriteHeader(http.StatusForbidden)
+									return
 								case release.ErrAttemptToChangeReleaseType:
 									s.Logger.Printf("update attempt of release type for release %d", id)
 									response.Data = jSendFailData{
@@ -558,29 +544,24 @@ func deleteRelease(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 			statusCode = http.StatusBadRequest
 		} else {
-			temp, err := s.ReleaseService.GetRelease(id)
+			temp, err := s.ReleaseService.GetRelease(r.Context(), id)
 			switch err {
 			case nil:
 				{ // this block secure the route
-					c, err := s.ChannelService.GetChannel(temp.OwnerChannel)
+					c, err := s.ChannelService.GetChannel(r.Context(), temp.OwnerChannel)
 					switch err {
 					case nil:
-						isAdmin := false
-						for _, admin := range c.AdminUsernames {
-							if r.Header.Get("authorized_username") == admin {
-								isAdmin = true
-								break
-							}
-						}
-						if !isAdmin {
+						if !authorize(s, r, policy.ReleaseDelete, channelResource(c)) {
 							s.Logger.Printf("unauthorized add release request")
-							w.WriteHeader(http.StatusUnauthorized)
+							w.WriteHeader(http.StatusForbidden)
 							return
 						}
-						// TODO delete image if image type
-						err = s.ReleaseService.DeleteRelease(id)
+						err = s.ReleaseService.DeleteRelease(r.Context(), id)
 						switch err {
 						case nil:
+							if temp.Type == release.Image {
+								deleteStoredImage(s, temp.Content)
+							}
 							fallthrough
 						case release.ErrReleaseNotFound:
 							response.Status = "success"
@@ -0,0 +1,46 @@
+package rest_test
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+// update rewrites the golden files with the responses received instead of
+// comparing them, review the changes before committing them:
+//
+//	go test ./pkg/delivery/http/rest -update
+var update = flag.Bool("update", false, "rewrite the golden files with the responses received")
+
+// scenario is a sequence of requests made against a fresh resttest.Server.
+// Names are prefixed with the routes they exercise, "users/sign up" for
+// example.
+type scenario struct {
+	name string
+	run  func(t *testing.T, s *resttest.Server, g *resttest.Golden)
+}
+
+// run runs each of the scenarios as a subtest against a server of its own.
+// The logs of the servers are only printed with -v.
+func run(t *testing.T, scenarios []scenario) {
+	g := &resttest.Golden{Dir: "testdata", Update: *update}
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			logger := log.New(io.Discard, "", 0)
+			if testing.Verbose() {
+				logger = log.New(os.Stderr, "    ", log.Lmicroseconds|log.Lshortfile)
+			}
+			s, err := resttest.NewServer(nil, logger)
+			if err != nil {
+				t.Fatalf("starting server failed because of: %v", err)
+			}
+			defer s.Close()
+			sc.run(t, s, g)
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package resttest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Password is the password of the users signed up by SignUp.
+const Password = "password1234"
+
+// Response is a response received from the server, read in full.
+type Response struct {
+	Method, Path string
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	serverURL    string
+}
+
+// Envelope is a JSend response as written by the handlers, with the data
+// left undecoded.
+type Envelope struct {
+	Status  string          `json:"status"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// FailData is the data of the JSend responses of failed requests.
+type FailData struct {
+	ErrorReason  string `json:"errorReason"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// Multipart is a body sent as a multipart form holding the fields and the
+// files, both keyed by the name of their part.
+type Multipart struct {
+	Fields map[string]string
+	Files  map[string]File
+}
+
+// File is a file sent in a Multipart body.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Do sends a request to the server and returns the response. The token, if
+// not empty, is sent as a bearer token. Bodies of type url.Values are sent as
+// forms, Multipart as multipart forms, []byte as they are and anything else
+// non nil as JSON.
+func (s *Server) Do(t T, method, path, token string, body interface{}) *Response {
+	t.Helper()
+	var (
+		reader      io.Reader
+		contentType string
+	)
+	switch body := body.(type) {
+	case nil:
+	case url.Values:
+		reader, contentType = strings.NewReader(body.Encode()), "application/x-www-form-urlencoded"
+	case Multipart:
+		var b bytes.Buffer
+		form := multipart.NewWriter(&b)
+		for name, value := range body.Fields {
+			if err := form.WriteField(name, value); err != nil {
+				t.Fatalf("encoding body of %s %s failed because of: %v", method, path, err)
+			}
+		}
+		for name, file := range body.Files {
+			part, err := form.CreateFormFile(name, file.Name)
+			if err == nil {
+				_, err = part.Write(file.Content)
+			}
+			if err != nil {
+				t.Fatalf("encoding body of %s %s failed because of: %v", method, path, err)
+			}
+		}
+		if err := form.Close(); err != nil {
+			t.Fatalf("encoding body of %s %s failed because of: %v", method, path, err)
+		}
+		reader, contentType = &b, form.FormDataContentType()
+	case []byte:
+		reader = bytes.NewReader(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encoding body of %s %s failed because of: %v", method, path, err)
+		}
+		reader, contentType = bytes.NewReader(encoded), "application/json"
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatalf("building %s %s failed because of: %v", method, path, err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed because of: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response to %s %s failed because of: %v", method, path, err)
+	}
+	return &Response{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+		serverURL:  s.URL,
+	}
+}
+
+// SignUp is a helper function that signs up a user with the given username,
+// an email made out of it and Password. Their email isn't verified.
+func (s *Server) SignUp(t T, username string) {
+	t.Helper()
+	s.Do(t, http.MethodPost, "/users", "", map[string]string{
+		"username":  username,
+		"password":  Password,
+		"email":     Email(username),
+		"firstName": username,
+	}).ExpectSuccess(t, http.StatusOK, nil)
+}
+
+// VerifyEmail is a helper function that verifies the email of the user with
+// the token mailed to them when they signed up.
+func (s *Server) VerifyEmail(t T, username string) {
+	t.Helper()
+	token, err := s.Mailbox.Token(Email(username), "email verification")
+	if err != nil {
+		t.Fatalf("verifying email of %s failed because of: %v", username, err)
+	}
+	s.Do(t, http.MethodPost, "/email-verification", "", map[string]string{"token": token}).
+		ExpectSuccess(t, http.StatusOK, nil)
+}
+
+// Login is a helper function that authenticates as the user and returns the
+// access token issued.
+func (s *Server) Login(t T, username, password string) string {
+	t.Helper()
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	s.Do(t, http.MethodPost, "/token-auth", "", map[string]string{"username": username, "password": password}).
+		ExpectSuccess(t, http.StatusOK, &tokens)
+	if tokens.Token == "" {
+		t.Fatalf("logging in as %s issued no token", username)
+	}
+	return tokens.Token
+}
+
+// NewUser is a helper function that signs up a user, verifies their email
+// and logs them in, returning their access token.
+func (s *Server) NewUser(t T, username string) string {
+	t.Helper()
+	s.SignUp(t, username)
+	s.VerifyEmail(t, username)
+	return s.Login(t, username, Password)
+}
+
+// AddPost posts to the channel of the user, as the user the token was issued
+// to, and returns the id of the post.
+func (s *Server) AddPost(t T, token, username, title string) string {
+	t.Helper()
+	var p struct {
+		ID uint `json:"id"`
+	}
+	s.Do(t, http.MethodPost, "/posts", token, map[string]string{
+		"PostedByUsername": username,
+		"originChannel":    username,
+		"title":            title,
+		"description":      "about " + title,
+	}).ExpectSuccess(t, http.StatusOK, &p)
+	return strconv.FormatUint(uint64(p.ID), 10)
+}
+
+// Email returns the email SignUp gives the user.
+func Email(username string) string {
+	return username + "@example.com"
+}
+
+// PNG returns a PNG image of the given size, noisy enough not to compress
+// below the 512 bytes the handlers sniff the type of images from.
+func PNG(t T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint8(seed >> 24)
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatalf("encoding image failed because of: %v", err)
+	}
+	return b.Bytes()
+}
+
+// JPEG returns a JPEG image of the given size, as noisy as those of PNG,
+// carrying EXIF data that tells it's to be turned by the given orientation
+// along with the name of the camera it was taken with.
+func JPEG(t T, width, height, orientation int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint8(seed >> 24)
+		if i%4 == 3 {
+			img.Pix[i] = 0xFF
+		}
+	}
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("encoding image failed because of: %v", err)
+	}
+
+	// a big endian TIFF header followed by an IFD of the orientation and the
+	// camera make, the value of which comes right after the IFD
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x02")
+	entry := func(tag, typ uint16, count, value uint32) {
+		tiff = append(tiff, make([]byte, 12)...)
+		e := tiff[len(tiff)-12:]
+		binary.BigEndian.PutUint16(e, tag)
+		binary.BigEndian.PutUint16(e[2:], typ)
+		binary.BigEndian.PutUint32(e[4:], count)
+		binary.BigEndian.PutUint32(e[8:], value)
+	}
+	entry(0x0112, 3, 1, uint32(orientation)<<16)
+	entry(0x010F, 2, uint32(len(CameraMake)+1), 8+2+2*12+4)
+	tiff = append(append(tiff, 0, 0, 0, 0), CameraMake+"\x00"...)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	exif := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(exif[2:], uint16(2+len(segment)))
+	exif = append(exif, segment...)
+	encoded := b.Bytes()
+	return append(append(append([]byte{}, encoded[:2]...), exif...), encoded[2:]...)
+}
+
+// CameraMake is the camera the images returned by JPEG claim to be taken with.
+const CameraMake = "Looking Glass"
+
+// TOTPCode returns the current TOTP code of the base32 encoded secret handed
+// out on enrolling, as an authenticator app would.
+func TOTPCode(t T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decoding totp secret failed because of: %v", err)
+	}
+	var step [8]byte
+	binary.BigEndian.PutUint64(step[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(step[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(sum[offset:])&0x7fffffff%1000000)
+}
+
+// FetchImage is a helper function that gets the image at the URL handed out
+// by the server and reports unless it's of the given size or it carries
+// metadata, EXIF data or text chunks. It returns the image decoded.
+func (s *Server) FetchImage(t T, imageURL string, width, height int) image.Image {
+	t.Helper()
+	if !strings.HasPrefix(imageURL, s.URL+s.Setup.ImageServingRoute) {
+		t.Fatalf("image url %q isn't served by the server", imageURL)
+	}
+	r := s.Do(t, http.MethodGet, strings.TrimPrefix(imageURL, s.URL), "", nil)
+	r.ExpectStatus(t, http.StatusOK)
+	img, format, err := image.Decode(bytes.NewReader(r.Body))
+	if err != nil {
+		t.Fatalf("%v served something other than an image: %v", r, err)
+	}
+	if got := r.Header.Get("Content-Type"); got != "image/"+format {
+		t.Errorf("%v served a %s image as %q", r, format, got)
+	}
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Errorf("%v served an image of %dx%d instead of %dx%d", r, img.Bounds().Dx(), img.Bounds().Dy(), width, height)
+	}
+	for _, metadata := range []string{"Exif\x00", CameraMake, "tEXt", "iTXt", "zTXt", "eXIf"} {
+		if bytes.Contains(r.Body, []byte(metadata)) {
+			t.Errorf("%v served an image carrying %q", r, metadata)
+		}
+	}
+	return img
+}
+
+// ExpectImageDeleted fails the test if the image at the URL is still served.
+func (s *Server) ExpectImageDeleted(t T, imageURL string) {
+	t.Helper()
+	if !strings.HasPrefix(imageURL, s.URL+s.Setup.ImageServingRoute) {
+		t.Fatalf("image url %q isn't served by the server", imageURL)
+	}
+	s.Do(t, http.MethodGet, strings.TrimPrefix(imageURL, s.URL), "", nil).ExpectStatus(t, http.StatusNotFound)
+}
+
+// String returns the request the response is to.
+func (r *Response) String() string {
+	return r.Method + " " + r.Path
+}
+
+// ExpectStatus reports the response if its status code isn't code.
+func (r *Response) ExpectStatus(t T, code int) {
+	t.Helper()
+	if r.StatusCode != code {
+		t.Errorf("%v responded %d instead of %d: %s", r, r.StatusCode, code, r.Body)
+	}
+}
+
+// JSend decodes the JSend envelope of the response. It stops the test if
+// the response isn't a JSend response.
+func (r *Response) JSend(t T) *Envelope {
+	t.Helper()
+	envelope := new(Envelope)
+	if err := json.Unmarshal(r.Body, envelope); err != nil {
+		t.Fatalf("%v responded with something other than JSend: %q", r, r.Body)
+	}
+	switch envelope.Status {
+	case "success", "fail", "error":
+	default:
+		t.Fatalf("%v responded with JSend of status %q", r, envelope.Status)
+	}
+	return envelope
+}
+
+// ExpectSuccess reports the response unless it's a JSend success of the given
+// status code. The data of successful responses is decoded into data, if not nil.
+func (r *Response) ExpectSuccess(t T, code int, data interface{}) {
+	t.Helper()
+	envelope := r.expectJSend(t, code, "success")
+	if envelope == nil || data == nil {
+		return
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		t.Fatalf("decoding data of %v failed because of: %v", r, err)
+	}
+}
+
+// ExpectFail reports the response unless it's a JSend fail of the given
+// status code and error reason.
+func (r *Response) ExpectFail(t T, code int, errorReason string) {
+	t.Helper()
+	envelope := r.expectJSend(t, code, "fail")
+	if envelope == nil {
+		return
+	}
+	var data FailData
+	if err := json.Unmarshal(envelope.Data, &data); err != nil {
+		t.Errorf("%v failed with data other than an error reason: %s", r, envelope.Data)
+		return
+	}
+	if data.ErrorReason != errorReason {
+		t.Errorf("%v failed for %q instead of %q: %s", r, data.ErrorReason, errorReason, data.ErrorMessage)
+	}
+}
+
+// ExpectError reports the response unless it's a JSend error of the given
+// status code.
+func (r *Response) ExpectError(t T, code int) {
+	t.Helper()
+	r.expectJSend(t, code, "error")
+}
+
+// expectJSend is a helper function that reports the response unless it's a
+// JSend response of the given status code and JSend status. The envelope is
+// only returned if it's as expected.
+func (r *Response) expectJSend(t T, code int, status string) *Envelope {
+	t.Helper()
+	if r.StatusCode != code {
+		t.Errorf("%v responded %d instead of %d: %s", r, r.StatusCode, code, r.Body)
+		return nil
+	}
+	envelope := r.JSend(t)
+	if envelope.Status != status {
+		t.Errorf("%v responded with JSend %s instead of %s: %s", r, envelope.Status, status, r.Body)
+		return nil
+	}
+	return envelope
+}
@@ -0,0 +1,145 @@
+package resttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Golden compares responses against the golden files in Dir. When Update is
+// set the files are written with the responses instead.
+//
+// Bodies are stored as indented JSON with their keys sorted. Values that
+// change from one run to the next are replaced by placeholders first: times
+// by "{{time}}", the address of the server by "{{server}}" and the values of
+// the token fields listed in volatileFields by "{{token}}". Times used as keys,
+// like those of bookmarks, are numbered when there's more than one.
+type Golden struct {
+	Dir    string
+	Update bool
+}
+
+// volatileFields lists the fields holding tokens or other random values.
+var volatileFields = map[string]bool{
+	"token":        true,
+	"refreshToken": true,
+	"challenge":    true,
+}
+
+// Check reports the response if it's different from the one in the golden
+// file of the given name, a slash separated path without its extension.
+func (g *Golden) Check(t T, name string, r *Response) {
+	t.Helper()
+	got := r.golden()
+	path := filepath.Join(g.Dir, filepath.FromSlash(name)+".golden")
+	if g.Update {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, got, 0644)
+		}
+		if err != nil {
+			t.Errorf("updating golden file %s failed because of: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading golden file %s failed because of: %v, golden files are created when updating", path, err)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%v differs from golden file %s\n%s", r, path, diff(string(want), string(got)))
+	}
+}
+
+// golden returns the response the way it's stored in golden files, its status
+// line followed by its normalized body.
+func (r *Response) golden() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d %s\n", r.StatusCode, http.StatusText(r.StatusCode))
+	if len(bytes.TrimSpace(r.Body)) == 0 {
+		return b.Bytes()
+	}
+	decoder := json.NewDecoder(bytes.NewReader(r.Body))
+	decoder.UseNumber()
+	var body interface{}
+	if err := decoder.Decode(&body); err != nil {
+		// not JSON, kept as it is
+		b.Write(r.Body)
+		return b.Bytes()
+	}
+	encoded, _ := json.MarshalIndent(normalize(body, "", r.serverURL), "", "\t")
+	b.Write(encoded)
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// normalize is a helper function that replaces the values of the decoded JSON
+// that change from one run to the next by placeholders.
+func normalize(v interface{}, field, serverURL string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var times []string
+		for key, value := range v {
+			v[key] = normalize(value, key, serverURL)
+			if isTime(key) {
+				times = append(times, key)
+			}
+		}
+		sort.Strings(times)
+		for i, key := range times {
+			placeholder := "{{time}}"
+			if len(times) > 1 {
+				placeholder = fmt.Sprintf("{{time %d}}", i+1)
+			}
+			v[placeholder] = v[key]
+			delete(v, key)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalize(value, field, serverURL)
+		}
+	case string:
+		switch {
+		case v == "":
+		case volatileFields[field]:
+			return "{{token}}"
+		case isTime(v):
+			return "{{time}}"
+		default:
+			return strings.ReplaceAll(v, serverURL, "{{server}}")
+		}
+	}
+	return v
+}
+
+// isTime is a helper function that tells whether s is a time as encoded by
+// encoding/json.
+func isTime(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+// diff is a helper function that describes where got first differs from want,
+// line by line.
+func diff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; ; i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return fmt.Sprintf("    line %d\n    want: %s\n    got:  %s", i+1, w, g)
+		}
+	}
+}
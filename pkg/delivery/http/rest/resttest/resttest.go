@@ -0,0 +1,239 @@
+/*
+Package resttest drives the handlers of the rest package end to end. It serves
+the mux returned by rest.NewMux, wired to the services the server uses on top
+of in memory repositories, over an httptest.Server and sends it real requests.
+
+The tests of the rest package use it to exercise the routes the way clients
+do, asserting the status codes and JSend envelopes of the responses and
+comparing their bodies against golden files:
+
+	s, err := resttest.NewServer(nil, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	token := s.NewUser(t, "alice")
+	r := s.Do(t, http.MethodGet, "/users/alice", token, nil)
+	r.ExpectSuccess(t, http.StatusOK, nil)
+	golden.Check(t, "users/alice", r)
+
+The helpers report through T, which *testing.T satisfies.
+*/
+package resttest
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/slim-crown/issue-1-REST/pkg/config"
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/inmemory"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/memory"
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+	"github.com/slim-crown/issue-1-REST/pkg/services/mail"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
+	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage"
+)
+
+// T is what the helpers report failures to.
+// Fatalf is expected to stop the test.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Server is a test server serving the rest API. Everything it stores is kept
+// in memory and images are written to a temporary directory removed on Close.
+type Server struct {
+	*httptest.Server
+	Setup   *rest.Setup
+	Mailbox *Mailbox
+
+	imageDir string
+}
+
+// NewServer starts a Server configured by conf, which is config.Default() if
+// nil, that logs to logger. Only the settings the server itself reads from
+// its config are taken into account, storage is always in memory.
+func NewServer(conf *config.Config, logger *log.Logger) (*Server, error) {
+	if conf == nil {
+		conf = config.Default()
+	}
+	imageDir, err := os.MkdirTemp("", "resttest")
+	if err != nil {
+		return nil, err
+	}
+	keys, err := auth.NewEphemeralKeySet()
+	if err != nil {
+		os.RemoveAll(imageDir)
+		return nil, err
+	}
+
+	s := &Server{Setup: &rest.Setup{}, Mailbox: new(Mailbox), imageDir: imageDir}
+	setup := s.Setup
+	setup.Logger = logger
+
+	store := inmemory.NewStore()
+	var (
+		channelRepo = inmemory.NewChannelRepository(store)
+		userRepo    = inmemory.NewUserRepository(store)
+		feedRepo    = inmemory.NewFeedRepository(store)
+		releaseRepo = inmemory.NewReleaseRepository(store)
+		postRepo    = inmemory.NewPostRepository(store)
+		commentRepo = inmemory.NewCommentRepository(store)
+		searchRepo  = inmemory.NewSearchRepository(store)
+		authRepo    = inmemory.NewAuthRepository(store)
+		lockoutRepo = memory.NewLockoutRepository(logger)
+		oauthRepo   = inmemory.NewOAuthRepository(store)
+	)
+
+	services := make(map[string]interface{})
+
+	setup.ChannelService = channel.NewService(&channelRepo, &services)
+	services["Channel"] = &setup.ChannelService
+	setup.UserService = user.NewService(&userRepo, &services)
+	services["User"] = &setup.UserService
+	setup.FeedService = feed.NewService(&feedRepo, &services)
+	services["Feed"] = &setup.FeedService
+	setup.ReleaseService = release.NewService(&releaseRepo, &services)
+	services["Release"] = &setup.ReleaseService
+	setup.PostService = post.NewService(&postRepo, &services)
+	services["Post"] = &setup.PostService
+	setup.CommentService = comment.NewService(&commentRepo, &services)
+	services["Comment"] = &setup.CommentService
+	setup.SearchService = search.NewService(&searchRepo)
+	services["Search"] = &setup.SearchService
+	setup.UnitOfWork = inmemory.NewUnitOfWork(store)
+
+	setup.ImageServingRoute = conf.Images.ServingRoute
+	setup.ImageURLLifetime = conf.Images.URLLifetime
+
+	setup.TokenSigningKeys = keys
+	setup.TokenAccessLifetime = conf.Auth.AccessTokenLifetime
+	setup.TokenRefreshLifetime = conf.Auth.RefreshTokenLifetime
+	setup.PasswordResetLifetime = conf.Auth.PasswordResetLifetime
+	setup.EmailVerificationLifetime = conf.Auth.EmailVerificationLifetime
+	setup.EmailVerificationResendInterval = conf.Auth.EmailVerificationResendInterval
+	setup.TOTPIssuer = conf.Auth.TOTPIssuer
+	setup.TOTPChallengeLifetime = conf.Auth.TOTPChallengeLifetime
+
+	setup.AuthService = auth.NewAuthService(&authRepo, s.Mailbox, setup.Config.Config)
+	services["Auth"] = &setup.AuthService
+
+	setup.Lockout.MaxUsernameFailures = conf.Lockout.MaxUsernameFailures
+	setup.Lockout.MaxIPFailures = conf.Lockout.MaxIPFailures
+	setup.Lockout.FailureWindow = conf.Lockout.FailureWindow
+	setup.Lockout.BaseLockout = conf.Lockout.BaseLockout
+	setup.Lockout.MaxLockout = conf.Lockout.MaxLockout
+
+	setup.LockoutService = lockout.NewService(&lockoutRepo, setup.Lockout)
+	services["Lockout"] = &setup.LockoutService
+
+	setup.ModeratorUsernames = conf.ModeratorUsernames
+	setup.PolicyService = policy.NewService(policy.DefaultRules, policy.DefaultVerifiedOnly, policy.DefaultScopes, setup.ModeratorUsernames)
+	services["Policy"] = &setup.PolicyService
+
+	// the server's already listening once unstarted, the address the handlers
+	// and the oauth issuer refer to is known before the mux is built
+	s.Server = httptest.NewUnstartedServer(nil)
+	host, port, _ := net.SplitHostPort(s.Server.Listener.Addr().String())
+	setup.HostAddress = "http://" + net.JoinHostPort(host, port)
+	setup.Port = port
+	setup.QueryTimeout = conf.Server.QueryTimeout
+
+	setup.ImageStorage, err = storage.NewFileSystemStorage(imageDir, setup.HostAddress+setup.ImageServingRoute)
+	if err != nil {
+		s.Server.Close()
+		os.RemoveAll(imageDir)
+		return nil, err
+	}
+	setup.Images.JPEGQuality = conf.Images.JPEGQuality
+	setup.Images.MaxPixels = conf.Images.MaxPixels
+	setup.Images.MaxBytes = conf.Images.MaxBytes
+	setup.Images.MaxConcurrent = conf.Images.MaxConcurrent
+	setup.ImageProcessor = imaging.NewProcessor(setup.Images)
+
+	setup.OAuth.Issuer = setup.HostAddress
+	setup.OAuth.AuthorizationCodeLifetime = conf.OAuth.AuthorizationCodeLifetime
+
+	setup.OAuthService = oauth.NewService(&oauthRepo, setup.AuthService, setup.UserService, setup.TokenSigningKeys, setup.OAuth)
+	services["OAuth"] = &setup.OAuthService
+
+	s.Server.Config.Handler = rest.NewMux(setup)
+	s.Server.Start()
+	return s, nil
+}
+
+// Close shuts the server down and removes the images stored by it.
+func (s *Server) Close() {
+	s.Server.Close()
+	os.RemoveAll(s.imageDir)
+}
+
+// Mailbox is a mail.Mailer that keeps the messages sent through it so that
+// the tokens they carry can be used by scenarios.
+type Mailbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+// Send keeps the message.
+func (m *Mailbox) Send(msg *mail.Message) error {
+	if len(msg.To) == 0 {
+		return mail.ErrNoRecipients
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns the messages sent to the address, oldest first.
+func (m *Mailbox) Messages(to string) []mail.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var messages []mail.Message
+	for _, msg := range m.messages {
+		for _, address := range msg.To {
+			if address == to {
+				messages = append(messages, msg)
+				break
+			}
+		}
+	}
+	return messages
+}
+
+// Token returns the token carried by the latest message sent to the address
+// whose subject ends with the given suffix. Tokens are on a line of their own
+// in the third paragraph of the messages sent by the auth service.
+func (m *Mailbox) Token(to, subjectSuffix string) (string, error) {
+	messages := m.Messages(to)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(messages[i].Subject, subjectSuffix) {
+			continue
+		}
+		paragraphs := strings.Split(messages[i].Body, "\n\n")
+		if len(paragraphs) < 3 {
+			return "", fmt.Errorf("message %q has no token paragraph", messages[i].Subject)
+		}
+		return strings.TrimSpace(paragraphs[2]), nil
+	}
+	return "", fmt.Errorf("no message ending in %q was sent to %s", subjectSuffix, to)
+}
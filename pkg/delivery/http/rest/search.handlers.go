@@ -7,7 +7,6 @@ import (
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
 	"github.com/slim-crown/issue-1-REST/pkg/services/search"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 )
@@ -94,7 +93,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			order := string(sortOrder)
 			successCounter := 0
 			{
-				posts, err := s.PostService.SearchPost(pattern, "", post.SortOrder(order), limit, offset)
+				posts, err := s.PostService.SearchPost(r.Context(), pattern, "", post.SortOrder(order), limit, offset)
 				if err != nil {
 					s.Logger.Printf("searching of posts failed because: %v", err)
 					responseData.Posts = jSendResponse{
@@ -109,7 +108,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			{
-				users, err := s.UserService.SearchUser(pattern, user.SortByUsername, user.SortOrder(order), limit, offset)
+				users, err := s.UserService.SearchUser(r.Context(), pattern, user.SortByUsername, user.SortOrder(order), limit, offset)
 				if err != nil {
 					s.Logger.Printf("searching of users failed because: %v", err)
 					responseData.Users = jSendResponse{
@@ -122,7 +121,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 						u.Email = ""
 						u.BookmarkedPosts = nil
 						if u.PictureURL != "" {
-							u.PictureURL = s.HostAddress + s.ImageServingRoute + url.PathEscape(u.PictureURL)
+							u.PictureURL, u.PictureURLs = imageURLs(r.Context(), s, u.PictureURL)
 						}
 					}
 					responseData.Users = users
@@ -131,7 +130,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			{
-				releases, err := s.ReleaseService.SearchRelease(pattern, "", release.SortOrder(order), limit, offset)
+				releases, err := s.ReleaseService.SearchRelease(r.Context(), pattern, "", release.SortOrder(order), limit, offset)
 				if err != nil {
 					s.Logger.Printf("searching of releases failed because: %v", err)
 					responseData.Releases = jSendResponse{
@@ -142,7 +141,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				} else {
 					for _, rel := range releases {
 						if rel.Type == release.Image {
-							rel.Content = s.HostAddress + s.ImageServingRoute + url.PathEscape(rel.Content)
+							rel.Content, rel.Images = imageURLs(r.Context(), s, rel.Content)
 						}
 					}
 					responseData.Releases = releases
@@ -151,7 +150,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			{
-				channels, err := s.ChannelService.SearchChannels(pattern, "", channel.SortOrder(order), limit, offset)
+				channels, err := s.ChannelService.SearchChannels(r.Context(), pattern, "", channel.SortOrder(order), limit, offset)
 				if err != nil {
 					s.Logger.Printf("searching of channels failed because: %v", err)
 					responseData.Channels = jSendResponse{
@@ -165,7 +164,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 						c.ReleaseIDs = nil
 						c.OwnerUsername = ""
 						if c.PictureURL != "" {
-							c.PictureURL = s.HostAddress + s.ImageServingRoute + url.PathEscape(c.PictureURL)
+							c.PictureURL, c.PictureURLs = imageURLs(r.Context(), s, c.PictureURL)
 						}
 					}
 					responseData.Channels = channels
@@ -174,7 +173,7 @@ func getSearch(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			{
-				comments, err := s.SearchService.SearchComments(pattern, sortBy, sortOrder, limit, offset)
+				comments, err := s.SearchService.SearchComments(r.Context(), pattern, sortBy, sortOrder, limit, offset)
 				if err != nil {
 					s.Logger.Printf("searching of comments failed because: %v", err)
 					responseData.Comments = jSendResponse{
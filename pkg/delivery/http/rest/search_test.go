@@ -0,0 +1,30 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+var searchScenarios = []scenario{
+	{"search/everything", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		s.AddPost(t, token, "alice", "the white rabbit")
+		s.AddPost(t, token, "alice", "the mad hatter")
+
+		r := s.Do(t, http.MethodGet, "/search?pattern=rabbit", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "search/pattern", r)
+
+		r = s.Do(t, http.MethodGet, "/search", "", nil)
+		r.ExpectFail(t, http.StatusBadRequest, "pattern")
+		g.Check(t, "search/no-pattern", r)
+
+		s.Do(t, http.MethodGet, "/search?pattern=rabbit&limit=-1", "", nil).ExpectFail(t, http.StatusBadRequest, "limit")
+	}},
+}
+
+func TestSearch(t *testing.T) {
+	run(t, searchScenarios)
+}
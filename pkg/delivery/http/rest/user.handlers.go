@@ -1,18 +1,19 @@
 package rest
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"html"
 	"net/http"
-	"net/url"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 func sanitizeUser(u *user.User, s *Setup) {
@@ -118,13 +119,16 @@ func postUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			}
 			if response.Data == nil {
 				s.Logger.Printf("trying to add user %+v", u)
-				username := u.Username
-				u, err := s.UserService.AddUser(u)
+				u, err := s.UserService.AddUser(r.Context(), u)
 				switch err {
 				case nil:
 					response.Status = "success"
 					response.Data = *u
 					s.Logger.Printf("success adding user %+v", u)
+					if err := s.AuthService.RequestEmailVerification(r.Context(), u.Username); err != nil {
+						// the user can request it again later
+						s.Logger.Printf("sending email verification failed because: %v", err)
+					}
 				case user.ErrUserNameOccupied:
 					s.Logger.Printf("adding of user failed because: %v", err)
 					response.Data = jSendFailData{
@@ -142,7 +146,6 @@ func postUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				case user.ErrSomeUserDataNotPersisted:
 					fallthrough
 				default:
-					_ = s.UserService.DeleteUser(username)
 					s.Logger.Printf("adding of user failed because: %v", err)
 					response.Status = "error"
 					response.Message = "server error when adding user"
@@ -170,19 +173,19 @@ func getUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 
 		s.Logger.Printf("trying to fetch user %s", username)
 
-		u, err := s.UserService.GetUser(username)
+		u, err := s.UserService.GetUser(r.Context(), username)
 		switch err {
 		case nil:
 			response.Status = "success"
 			{ // this block sanitizes the returned User if it's not the user herself accessing the route
-				if username != r.Header.Get("authorized_username") {
-					s.Logger.Printf("user %s fetched user %s", r.Header.Get("authorized_username"), u.Username)
+				if !authorize(s, r, policy.UserViewPrivate, userResource(username)) {
+					s.Logger.Printf("user %s fetched user %s", authorizedUsername(r), u.Username)
 					u.Email = ""
 					u.BookmarkedPosts = nil
 				}
 			}
 			if u.PictureURL != "" {
-				u.PictureURL = s.HostAddress + s.ImageServingRoute + url.PathEscape(u.PictureURL)
+				u.PictureURL, u.PictureURLs = imageURLs(r.Context(), s, u.PictureURL)
 			}
 			response.Data = *u
 			s.Logger.Printf("success fetching user %s", username)
@@ -270,7 +273,7 @@ func getUsers(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			users, err := s.UserService.SearchUser(pattern, sortBy, sortOrder, limit, offset)
+			users, err := s.UserService.SearchUser(r.Context(), pattern, sortBy, sortOrder, limit, offset)
 			if err != nil {
 				s.Logger.Printf("fetching of users failed because: %v", err)
 				response.Status = "error"
@@ -282,7 +285,7 @@ func getUsers(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 					u.Email = ""
 					u.BookmarkedPosts = nil
 					if u.PictureURL != "" {
-						u.PictureURL = s.HostAddress + s.ImageServingRoute + url.PathEscape(u.PictureURL)
+						u.PictureURL, u.PictureURLs = imageURLs(r.Context(), s, u.PictureURL)
 					}
 				}
 				response.Data = users
@@ -304,10 +307,10 @@ func putUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block blocks user updating of user if is not the user herself accessing the route
-			if username != r.Header.Get("authorized_username") {
-				if _, err := s.UserService.GetUser(username); err == nil {
+			if !authorize(s, r, policy.UserUpdate, userResource(username)) {
+				if _, err := s.UserService.GetUser(r.Context(), username); err == nil {
 					s.Logger.Printf("unauthorized update user attempt")
-					w.WriteHeader(http.StatusUnauthorized)
+					w.WriteHeader(http.StatusForbidden)
 					return
 				}
 			}
@@ -337,7 +340,7 @@ func putUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 			case u.FirstName == "" && u.Username == "" && u.Bio == "" && u.Email == "" &&
 				u.LastName == "" && u.MiddleName == "" && u.Password == "":
 				// no update able data
-				u, err = s.UserService.GetUser(username)
+				u, err = s.UserService.GetUser(r.Context(), username)
 				switch err {
 				case nil:
 					s.Logger.Printf("success put user at user %s data %v", username, u)
@@ -386,12 +389,18 @@ func putUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 				}
 				fallthrough
 			default:
-				u, err = s.UserService.UpdateUser(u, username)
+				emailUpdated := u.Email != ""
+				u, err = s.UserService.UpdateUser(r.Context(), u, username)
 				switch err {
 				case nil:
 					s.Logger.Printf("success put user at user %s data %v", username, u)
 					response.Status = "success"
 					response.Data = *u
+					if emailUpdated && !u.Verified {
+						if err := s.AuthService.RequestEmailVerification(r.Context(), u.Username); err != nil {
+							s.Logger.Printf("sending email verification failed because: %v", err)
+						}
+					}
 				case user.ErrUserNotFound:
 					s.Logger.Printf("adding of user failed because: %v", err)
 					response.Data = jSendFailData{
@@ -445,14 +454,14 @@ func deleteUser(s *Setup) func(w http.ResponseWriter, r *http.Request) {
 		username := vars["username"]
 
 		{ // this block blocks user deletion of a user if is not the user herself accessing the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserDelete, userResource(username)) {
 				s.Logger.Printf("unauthorized update user attempt")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
 		s.Logger.Printf("trying to delete user %s", username)
-		err := s.UserService.DeleteUser(username)
+		err := s.UserService.DeleteUser(r.Context(), username)
 		if err != nil {
 			s.Logger.Printf("deletion of user failed because: %v", err)
 			response.Status = "error"
@@ -478,19 +487,19 @@ func getUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		username := vars["username"]
 
 		{ // this block blocks user deletion of a user if is not the user herself accessing the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageBookmarks, userResource(username)) {
 				s.Logger.Printf("unauthorized get user bookmarks request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
-		u, err := s.UserService.GetUser(username)
+		u, err := s.UserService.GetUser(r.Context(), username)
 		switch err {
 		case nil:
 			response.Status = "success"
 			bookmarks := make(map[time.Time]interface{})
 			for t, id := range u.BookmarkedPosts {
-				if temp, err := s.PostService.GetPost((uint(id))); err == nil {
+				if temp, err := s.PostService.GetPost(r.Context(), (uint(id))); err == nil {
 					//tempPost := post.Post{
 					//	ID:               temp.ID,
 					//	PostedByUsername: temp.PostedByUsername,
@@ -535,9 +544,9 @@ func postUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		username := vars["username"]
 
 		{ // this block blocks user deletion of a user if is not the user herself accessing the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageBookmarks, userResource(username)) {
 				s.Logger.Printf("unauthorized post user bookmarks request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -572,7 +581,7 @@ func postUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		// if queries are clean
 		s.Logger.Printf("bookmarking post: %v", post)
 		if response.Data == nil {
-			err := s.UserService.BookmarkPost(username, post.PostID)
+			err := s.UserService.BookmarkPost(r.Context(), username, post.PostID)
 			switch err {
 			case nil:
 				s.Logger.Printf("success adding bookmark %d to user %s", post.PostID, username)
@@ -614,9 +623,9 @@ func putUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageBookmarks, userResource(username)) {
 				s.Logger.Printf("unauthorized put user bookmarks request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -631,7 +640,7 @@ func putUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			err := s.UserService.BookmarkPost(username, postID)
+			err := s.UserService.BookmarkPost(r.Context(), username, postID)
 			switch err {
 			case nil:
 				s.Logger.Printf("success adding bookmark %d to user %s", postID, username)
@@ -673,9 +682,9 @@ func deleteUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManageBookmarks, userResource(username)) {
 				s.Logger.Printf("unauthorized delete bookmarks attempt")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
@@ -690,7 +699,7 @@ func deleteUserBookmarks(s *Setup) func(http.ResponseWriter, *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			err = s.UserService.DeleteBookmark(username, postID)
+			err = s.UserService.DeleteBookmark(r.Context(), username, postID)
 			switch err {
 			case nil:
 				s.Logger.Printf("success removing bookmark %d from user %s", postID, username)
@@ -724,11 +733,11 @@ func getUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		vars := getParametersFromRequestAsMap(r)
 		username := vars["username"]
 
-		u, err := s.UserService.GetUser(username)
+		u, err := s.UserService.GetUser(r.Context(), username)
 		switch err {
 		case nil:
 			response.Status = "success"
-			response.Data = s.HostAddress + s.ImageServingRoute + url.PathEscape(u.PictureURL)
+			response.Data = imageURL(r.Context(), s, u.PictureURL)
 			s.Logger.Printf("success fetching user %s picture URL", username)
 		case user.ErrUserNotFound:
 			s.Logger.Printf("fetch picture URL attempt of non existing user %s", username)
@@ -750,6 +759,7 @@ func getUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 // putUserPicture returns a handler for PUT /users/{username}/picture requests
 func putUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		limitImageUpload(w, r, s)
 		var err error
 		var response jSendResponse
 		statusCode := http.StatusOK
@@ -759,29 +769,32 @@ func putUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		username := vars["username"]
 
 		{ // this block secures the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManagePicture, userResource(username)) {
 				s.Logger.Printf("unauthorized user picture setting request")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
-		var tmpFile *os.File
+		var images []*imaging.Image
 		var fileName string
 		{ // this block extracts the image
-			tmpFile, fileName, err = saveImageFromRequest(r, "image")
+			images, err = processImageFromRequest(r, s, "image")
 			switch err {
 			case nil:
 				s.Logger.Printf("image found on put user picture request")
-				defer os.Remove(tmpFile.Name())
-				defer tmpFile.Close()
-				s.Logger.Printf("temp file saved: %s", tmpFile.Name())
-				fileName = generateFileNameForStorage(fileName, "user")
+				fileName = generateFileNameForStorage("user")
 			case errUnacceptedType:
 				response.Data = jSendFailData{
 					ErrorMessage: "image",
 					ErrorReason:  "only types image/jpeg & image/png are accepted",
 				}
 				statusCode = http.StatusBadRequest
+			case errImageTooLarge:
+				response.Data = jSendFailData{
+					ErrorReason:  "image",
+					ErrorMessage: "image has too many pixels or takes too many bytes",
+				}
+				statusCode = http.StatusBadRequest
 			case errReadingFromImage:
 				s.Logger.Printf("image not found on put request")
 				response.Data = jSendFailData{
@@ -797,21 +810,26 @@ func putUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		}
 		// if queries are clean
 		if response.Data == nil {
-			err := s.UserService.AddPicture(username, fileName)
+			// the picture is only set if it's saved too
+			var oldPicture string
+			err := storeImage(r.Context(), s, images, fileName)
+			if err == nil {
+				err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+					deleteImageOnRollback(ctx, s, images, fileName)
+					u, err := s.UserService.GetUser(ctx, username)
+					if err != nil {
+						return err
+					}
+					oldPicture = u.PictureURL
+					return s.UserService.AddPicture(ctx, username, fileName)
+				})
+			}
 			switch err {
 			case nil:
-				err := saveTempFilePermanentlyToPath(tmpFile, s.ImageStoragePath+fileName)
-				if err != nil {
-					s.Logger.Printf("adding of release failed because: %v", err)
-					response.Status = "error"
-					response.Message = "server error when setting user picture"
-					statusCode = http.StatusInternalServerError
-					_ = s.UserService.RemovePicture(username)
-				} else {
-					s.Logger.Printf("success adding picture %s to user %s", fileName, username)
-					response.Status = "success"
-					response.Data = s.HostAddress + s.ImageServingRoute + url.PathEscape(fileName)
-				}
+				deleteStoredImage(s, oldPicture)
+				s.Logger.Printf("success adding picture %s to user %s", fileName, username)
+				response.Status = "success"
+				response.Data = imageURL(r.Context(), s, fileName)
 			case user.ErrUserNotFound:
 				s.Logger.Printf("adding of user picture failed because: %v", err)
 				response.Data = jSendFailData{
@@ -820,7 +838,7 @@ func putUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 				}
 				statusCode = http.StatusNotFound
 			default:
-				s.Logger.Printf("bookmarking of post failed because: %v", err)
+				s.Logger.Printf("adding of user picture failed because: %v", err)
 				response.Status = "error"
 				response.Message = "server error when setting user picture"
 				statusCode = http.StatusInternalServerError
@@ -842,18 +860,26 @@ func deleteUserPicture(s *Setup) func(http.ResponseWriter, *http.Request) {
 		username := vars["username"]
 
 		{ // this block blocks user deletion of a user if is not the user herself accessing the route
-			if username != r.Header.Get("authorized_username") {
+			if !authorize(s, r, policy.UserManagePicture, userResource(username)) {
 				s.Logger.Printf("unauthorized delete user picture attempt")
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusForbidden)
 				return
 			}
 		}
 		// if queries are clean
 		if response.Data == nil {
-			err = s.UserService.RemovePicture(username)
+			var picture string
+			err = s.UnitOfWork.Do(r.Context(), func(ctx context.Context) error {
+				u, err := s.UserService.GetUser(ctx, username)
+				if err != nil {
+					return err
+				}
+				picture = u.PictureURL
+				return s.UserService.RemovePicture(ctx, username)
+			})
 			switch err {
 			case nil:
-				// TODO delete picture from fs
+				deleteStoredImage(s, picture)
 				s.Logger.Printf("success removing piture from user %s", username)
 				response.Status = "success"
 			case user.ErrUserNotFound:
@@ -0,0 +1,166 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/delivery/http/rest/resttest"
+)
+
+var userScenarios = []scenario{
+	{"users/sign up", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		r := s.Do(t, http.MethodPost, "/users", "", map[string]string{
+			"username":  "alice",
+			"password":  resttest.Password,
+			"email":     resttest.Email("alice"),
+			"firstName": "Alice",
+			"lastName":  "Liddell",
+		})
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/sign-up", r)
+		if len(s.Mailbox.Messages(resttest.Email("alice"))) != 1 {
+			t.Errorf("signing up didn't mail an email verification token")
+		}
+
+		r = s.Do(t, http.MethodPost, "/users", "", url.Values{
+			"username":  {"alice"},
+			"password":  {resttest.Password},
+			"email":     {resttest.Email("alice2")},
+			"firstName": {"Alice"},
+		})
+		r.ExpectFail(t, http.StatusConflict, "username")
+		g.Check(t, "users/sign-up-username-occupied", r)
+
+		s.Do(t, http.MethodPost, "/users", "", map[string]string{
+			"username":  "alice2",
+			"password":  resttest.Password,
+			"email":     resttest.Email("alice"),
+			"firstName": "Alice",
+		}).ExpectFail(t, http.StatusConflict, "email")
+
+		for reason, u := range map[string]map[string]string{
+			"username":  {"username": "al", "password": resttest.Password, "email": resttest.Email("al"), "firstName": "Al"},
+			"password":  {"username": "carol", "password": "short", "email": resttest.Email("carol"), "firstName": "Carol"},
+			"email":     {"username": "carol", "password": resttest.Password, "email": "carol", "firstName": "Carol"},
+			"firstName": {"username": "carol", "password": resttest.Password, "email": resttest.Email("carol")},
+		} {
+			s.Do(t, http.MethodPost, "/users", "", u).ExpectFail(t, http.StatusBadRequest, reason)
+		}
+	}},
+	{"users/get", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+
+		r := s.Do(t, http.MethodGet, "/users/alice", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/get-self", r)
+
+		// only the user sees their email
+		for _, token := range []string{"", bobby} {
+			r = s.Do(t, http.MethodGet, "/users/alice", token, nil)
+			r.ExpectSuccess(t, http.StatusOK, nil)
+			g.Check(t, "users/get-other", r)
+		}
+
+		r = s.Do(t, http.MethodGet, "/users/nobody", "", nil)
+		r.ExpectFail(t, http.StatusNotFound, "username")
+		g.Check(t, "users/get-missing", r)
+
+		r = s.Do(t, http.MethodGet, "/users?sort=username_asc", "", nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/list", r)
+	}},
+	{"users/update", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		update := map[string]string{"bio": "down the rabbit hole", "lastName": "Liddell"}
+
+		s.Do(t, http.MethodPut, "/users/alice", bobby, update).ExpectStatus(t, http.StatusForbidden)
+
+		r := s.Do(t, http.MethodPut, "/users/alice", token, update)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/update", r)
+
+		s.Do(t, http.MethodPut, "/users/alice", token, map[string]string{"username": "bobby"}).
+			ExpectFail(t, http.StatusConflict, "username")
+	}},
+	{"users/delete", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+
+		s.Do(t, http.MethodDelete, "/users/alice", bobby, nil).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodGet, "/users/alice", "", nil).ExpectSuccess(t, http.StatusOK, nil)
+
+		r := s.Do(t, http.MethodDelete, "/users/alice", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/delete", r)
+		s.Do(t, http.MethodGet, "/users/alice", "", nil).ExpectFail(t, http.StatusNotFound, "username")
+	}},
+	{"users/bookmarks", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		p := s.AddPost(t, token, "alice", "first")
+
+		s.Do(t, http.MethodPut, "/users/alice/bookmarks/"+p, bobby, nil).ExpectStatus(t, http.StatusForbidden)
+		s.Do(t, http.MethodGet, "/users/alice/bookmarks", bobby, nil).ExpectStatus(t, http.StatusForbidden)
+
+		s.Do(t, http.MethodPut, "/users/alice/bookmarks/"+p, token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		r := s.Do(t, http.MethodGet, "/users/alice/bookmarks", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/bookmarks", r)
+
+		s.Do(t, http.MethodDelete, "/users/alice/bookmarks/"+p, token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		r = s.Do(t, http.MethodGet, "/users/alice/bookmarks", token, nil)
+		r.ExpectSuccess(t, http.StatusOK, nil)
+		g.Check(t, "users/bookmarks-removed", r)
+	}},
+	{"users/picture", func(t *testing.T, s *resttest.Server, g *resttest.Golden) {
+		token := s.NewUser(t, "alice")
+		bobby := s.NewUser(t, "bobby")
+		// taken sideways, it's turned upright and scaled down for the medium and thumb sizes
+		picture := resttest.Multipart{Files: map[string]resttest.File{"image": {Name: "alice.jpg", Content: resttest.JPEG(t, 1100, 1030, 6)}}}
+
+		s.Do(t, http.MethodPut, "/users/alice/picture", bobby, picture).ExpectStatus(t, http.StatusForbidden)
+
+		var pictureURL string
+		s.Do(t, http.MethodPut, "/users/alice/picture", token, picture).ExpectSuccess(t, http.StatusOK, &pictureURL)
+		s.FetchImage(t, pictureURL, 1030, 1100)
+
+		var u struct {
+			PictureURL  string            `json:"pictureURL"`
+			PictureURLs map[string]string `json:"pictureURLs"`
+		}
+		s.Do(t, http.MethodGet, "/users/alice", token, nil).ExpectSuccess(t, http.StatusOK, &u)
+		if u.PictureURL != pictureURL {
+			t.Errorf("the user is expected to link to the picture set, got %q instead of %q", u.PictureURL, pictureURL)
+		}
+		if u.PictureURLs["full"] != pictureURL {
+			t.Errorf("the full size of the picture is expected to be the picture set, got %q instead of %q", u.PictureURLs["full"], pictureURL)
+		}
+		s.FetchImage(t, u.PictureURLs["medium"], 1024, 1094)
+		s.FetchImage(t, u.PictureURLs["thumb"], 240, 256)
+
+		// a replaced picture is deleted along with its sizes
+		replaced := u.PictureURLs
+		s.Do(t, http.MethodPut, "/users/alice/picture", token, picture).ExpectSuccess(t, http.StatusOK, nil)
+		for _, imageURL := range replaced {
+			s.ExpectImageDeleted(t, imageURL)
+		}
+		s.Do(t, http.MethodGet, "/users/alice", token, nil).ExpectSuccess(t, http.StatusOK, &u)
+
+		s.Do(t, http.MethodDelete, "/users/alice/picture", token, nil).ExpectSuccess(t, http.StatusOK, nil)
+		s.Do(t, http.MethodGet, "/users/alice/picture", token, nil).ExpectSuccess(t, http.StatusOK, &pictureURL)
+		if pictureURL != "" {
+			t.Errorf("a removed picture is expected to have no url, got %q", pictureURL)
+		}
+		for _, imageURL := range u.PictureURLs {
+			s.ExpectImageDeleted(t, imageURL)
+		}
+		s.Do(t, http.MethodGet, s.Setup.ImageServingRoute+"missing.png", "", nil).ExpectStatus(t, http.StatusNotFound)
+	}},
+}
+
+func TestUsers(t *testing.T) {
+	run(t, userScenarios)
+}
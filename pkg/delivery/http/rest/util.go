@@ -1,19 +1,23 @@
 package rest
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
-	"io"
-	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/slim-crown/issue-1-REST/pkg/services/imaging"
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+
 	mrand "math/rand"
-	"os"
 )
 
 type jSendResponse struct {
@@ -51,33 +55,67 @@ func writeResponseToWriter(response jSendResponse, w http.ResponseWriter, status
 
 var errUnacceptedType = fmt.Errorf("file mime type not accepted")
 var errReadingFromImage = fmt.Errorf("err reading image file from request")
+var errImageTooLarge = fmt.Errorf("image has too many pixels or takes too many bytes")
+
+// imageFormOverhead is how much larger than the image itself requests uploading
+// one may be, for the rest of the form.
+const imageFormOverhead = 1 << 20
+
+// limitImageUpload is a helper function that cuts the body of the request off
+// once it's larger than any image allowed along with the rest of the form.
+func limitImageUpload(w http.ResponseWriter, r *http.Request, s *Setup) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.Images.MaxBytes+imageFormOverhead)
+}
 
-func saveImageFromRequest(r *http.Request, fileName string) (*os.File, string, error) {
-	file, header, err := r.FormFile(fileName)
+// processImageFromRequest is a helper function that processes the image
+// uploaded under the given key of the multipart form into the sizes it's
+// served in, see imaging.Processor.
+func processImageFromRequest(r *http.Request, s *Setup, key string) ([]*imaging.Image, error) {
+	file, _, err := r.FormFile(key)
 	if err != nil {
-		return nil, "", errReadingFromImage
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errImageTooLarge
+		}
+		return nil, errReadingFromImage
 	}
 	defer file.Close()
 	err = checkIfFileIsAcceptedType(file)
 	if err != nil {
-		return nil, "", err
-	}
-	newFile, err := ioutil.TempFile("", "tempIMG*.jpg")
-	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	_, err = io.Copy(newFile, file)
-	if err != nil {
-		return nil, "", err
+	images, err := s.ImageProcessor.Process(r.Context(), file)
+	switch err {
+	case nil:
+		return images, nil
+	case imaging.ErrUnsupportedFormat:
+		return nil, errUnacceptedType
+	case imaging.ErrImageTooLarge:
+		return nil, errImageTooLarge
+	default:
+		return nil, err
 	}
-	return newFile, header.Filename, nil
 }
 
-func generateFileNameForStorage(fileName, prefix string) string {
+// generateFileNameForStorage returns a new name to store an image under. The
+// name is that of its full variant, the others are stored next to it, see
+// imageVariantName.
+func generateFileNameForStorage(prefix string) string {
 	// v4uuid, _ := uuid.NewV4()
 	// return prefix + "." + v4uuid.String() + "." + fileName
 	entropy, _ := generateRandomString(20)
-	return prefix + "." + entropy + "." + fileName
+	return prefix + "." + entropy + "/" + imaging.Full.Name
+}
+
+// imageVariantName is a helper function that returns the name the variant of
+// the image stored under the given name is stored under. Images stored before
+// uploads were processed into variants have the one name for all of them.
+func imageVariantName(name string, v imaging.Variant) string {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return name
+	}
+	return name[:i+1] + v.Name
 }
 
 func checkIfFileIsAcceptedType(file multipart.File) error { // this block checks if image is of accepted types
@@ -97,24 +135,87 @@ func checkIfFileIsAcceptedType(file multipart.File) error { // this block checks
 	return err
 }
 
-func saveTempFilePermanentlyToPath(tmpFile *os.File, path string) error {
-	newFile, err := os.Create(path)
-	if err != nil {
-		return err
+// storeImage is a helper function that puts the variants of the image into
+// the image storage next to each other under the given name. It's called
+// before the unit of work the image belongs to, so that the store isn't held
+// up by the uploads, and the variants put are deleted again if one fails.
+func storeImage(ctx context.Context, s *Setup, images []*imaging.Image, name string) error {
+	for i, image := range images {
+		err := s.ImageStorage.Put(ctx, imageVariantName(name, image.Variant), bytes.NewReader(image.Content), image.ContentType)
+		if err != nil {
+			deleteImage(s, images[:i], name)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteImageOnRollback is a helper function that has the variants of the
+// image stored with storeImage deleted if the unit of work of ctx is rolled
+// back. It's called first thing in the unit of work.
+func deleteImageOnRollback(ctx context.Context, s *Setup, images []*imaging.Image, name string) {
+	unitofwork.OnRollback(ctx, func() { deleteImage(s, images, name) })
+}
+
+// deleteImage is a helper function that deletes the variants of the image.
+func deleteImage(s *Setup, images []*imaging.Image, name string) {
+	for _, image := range images {
+		// the context of the request may be done by the time they're deleted
+		key := imageVariantName(name, image.Variant)
+		if err := s.ImageStorage.Delete(context.Background(), key); err != nil {
+			s.Logger.Printf("deleting image %s failed because: %v", key, err)
+		}
 	}
-	defer newFile.Close()
+}
 
-	_, err = tmpFile.Seek(0, 0)
-	if err != nil {
-		return err
+// deleteStoredImage is a helper function that deletes the variants of the
+// image stored under the given name, once it's been removed or replaced.
+// Images stored before there were variants are kept under the name alone.
+func deleteStoredImage(s *Setup, name string) {
+	if name == "" {
+		return
 	}
+	deleted := make(map[string]bool)
+	for _, v := range imaging.Variants {
+		key := imageVariantName(name, v)
+		if deleted[key] {
+			continue
+		}
+		deleted[key] = true
+		if err := s.ImageStorage.Delete(context.Background(), key); err != nil {
+			s.Logger.Printf("deleting image %s failed because: %v", key, err)
+		}
+	}
+}
 
-	_, err = io.Copy(newFile, tmpFile)
+// imageURL is a helper function that returns the URL the image stored under
+// the given name can be fetched from, or an empty string if there's no image.
+func imageURL(ctx context.Context, s *Setup, name string) string {
+	if name == "" {
+		return ""
+	}
+	u, err := s.ImageStorage.SignedURL(ctx, name, s.ImageURLLifetime)
 	if err != nil {
-		return err
+		s.Logger.Printf("signing url of image %s failed because: %v", name, err)
+		return ""
 	}
+	return u
+}
 
-	return nil
+// imageURLs is a helper function that returns the URL of the full variant of
+// the image stored under the given name along with those of all its variants,
+// keyed by their names, see imageURL.
+func imageURLs(ctx context.Context, s *Setup, name string) (string, map[string]string) {
+	if name == "" {
+		return "", nil
+	}
+	urls := make(map[string]string, len(imaging.Variants))
+	for _, v := range imaging.Variants {
+		if u := imageURL(ctx, s, imageVariantName(name, v)); u != "" {
+			urls[v.Name] = u
+		}
+	}
+	return urls[imaging.Full.Name], urls
 }
 
 // GenerateRandomBytes returns securely generated random bytes.
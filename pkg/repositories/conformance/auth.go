@@ -0,0 +1,383 @@
+package conformance
+
+import (
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+var authChecks = []Check{
+	{"auth/authenticate", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		u := &auth.User{Email: "alice@example.com", Password: password}
+		ok, err := repos.Auth.Authenticate(ctx, u)
+		expectNoErr(t, "Authenticate", err)
+		if !ok || u.Username != "alice" {
+			t.Errorf("Authenticate by email is expected to succeed and set the username, got %v and %+v", ok, u)
+		}
+		ok, err = repos.Auth.Authenticate(ctx, &auth.User{Username: "alice", Password: "wrong"})
+		expectNoErr(t, "Authenticate", err)
+		if ok {
+			t.Errorf("Authenticate with the wrong password succeeded")
+		}
+		_, err = repos.Auth.Authenticate(ctx, &auth.User{Username: "nobody", Password: password})
+		expectErr(t, "Authenticate of a missing user", err, auth.ErrUserNotFound)
+	}},
+	{"auth/get user", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		for _, identifier := range []string{"alice", "alice@example.com"} {
+			u, err := repos.Auth.GetUser(ctx, identifier)
+			expectNoErr(t, "GetUser", err)
+			if u.Username != "alice" || u.Email != "alice@example.com" || u.Password != "" {
+				t.Errorf("GetUser of %s returned %+v", identifier, u)
+			}
+		}
+		_, err := repos.Auth.GetUser(ctx, "nobody")
+		expectErr(t, "GetUser of a missing user", err, auth.ErrUserNotFound)
+	}},
+	{"auth/blacklist", func(t T, repos *Repositories) {
+		expectNoErr(t, "AddToBlacklist", repos.Auth.AddToBlacklist(ctx, "live", time.Now().Add(time.Hour)))
+		expectNoErr(t, "AddToBlacklist", repos.Auth.AddToBlacklist(ctx, "expired", time.Now().Add(-time.Hour)))
+		for tokenID, expected := range map[string]bool{"live": true, "expired": false, "unknown": false} {
+			blacklisted, err := repos.Auth.IsInBlacklist(ctx, tokenID)
+			expectNoErr(t, "IsInBlacklist", err)
+			if blacklisted != expected {
+				t.Errorf("IsInBlacklist of %s: expected %v, got %v", tokenID, expected, blacklisted)
+			}
+		}
+	}},
+	{"auth/refresh tokens", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		now := time.Now().Truncate(time.Second)
+		for _, hash := range []string{"first", "second"} {
+			expectNoErr(t, "AddRefreshToken", repos.Auth.AddRefreshToken(ctx, &auth.RefreshToken{
+				TokenHash:     hash,
+				FamilyID:      "family",
+				Username:      "alice",
+				AccessTokenID: "jti-" + hash,
+				CreationTime:  now,
+				ExpiresAt:     now.Add(time.Hour),
+			}))
+		}
+		err := repos.Auth.AddRefreshToken(ctx, &auth.RefreshToken{TokenHash: "third", FamilyID: "family", Username: "nobody", CreationTime: now, ExpiresAt: now.Add(time.Hour)})
+		if err == nil {
+			t.Errorf("AddRefreshToken of a missing user succeeded")
+		}
+
+		rt, err := repos.Auth.GetRefreshToken(ctx, "first")
+		expectNoErr(t, "GetRefreshToken", err)
+		if rt.FamilyID != "family" || rt.Username != "alice" || rt.AccessTokenID != "jti-first" || rt.Used || rt.Revoked {
+			t.Errorf("GetRefreshToken returned %+v", rt)
+		}
+		expectSameTime(t, "expiry", rt.ExpiresAt, now.Add(time.Hour))
+		_, err = repos.Auth.GetRefreshToken(ctx, "unknown")
+		expectErr(t, "GetRefreshToken of an unknown token", err, auth.ErrRefreshTokenNotFound)
+
+		marked, err := repos.Auth.MarkRefreshTokenUsed(ctx, "first")
+		expectNoErr(t, "MarkRefreshTokenUsed", err)
+		if !marked {
+			t.Errorf("MarkRefreshTokenUsed of an unused token returned false")
+		}
+		marked, err = repos.Auth.MarkRefreshTokenUsed(ctx, "first")
+		expectNoErr(t, "MarkRefreshTokenUsed", err)
+		if marked {
+			t.Errorf("MarkRefreshTokenUsed of a used token returned true")
+		}
+
+		expectNoErr(t, "RevokeRefreshTokenFamily", repos.Auth.RevokeRefreshTokenFamily(ctx, "family"))
+		rt, err = repos.Auth.GetRefreshToken(ctx, "second")
+		expectNoErr(t, "GetRefreshToken", err)
+		if !rt.Revoked {
+			t.Errorf("tokens of a revoked family are expected to be revoked, got %+v", rt)
+		}
+	}},
+	{"auth/password reset", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		now := time.Now()
+		addToken := func(hash string, expiresAt time.Time) {
+			t.Helper()
+			expectNoErr(t, "AddPasswordResetToken", repos.Auth.AddPasswordResetToken(ctx, &auth.PasswordResetToken{
+				TokenHash: hash, Username: "alice", CreationTime: now, ExpiresAt: expiresAt,
+			}))
+		}
+		addToken("expired", now.Add(-time.Minute))
+		_, err := repos.Auth.ConsumePasswordResetToken(ctx, "expired")
+		expectErr(t, "ConsumePasswordResetToken of an expired token", err, auth.ErrInvalidPasswordResetToken)
+
+		addToken("old", now.Add(time.Hour))
+		addToken("new", now.Add(time.Hour))
+		_, err = repos.Auth.ConsumePasswordResetToken(ctx, "old")
+		expectErr(t, "ConsumePasswordResetToken of a superseded token", err, auth.ErrInvalidPasswordResetToken)
+
+		prt, err := repos.Auth.ConsumePasswordResetToken(ctx, "new")
+		expectNoErr(t, "ConsumePasswordResetToken", err)
+		if prt.Username != "alice" || !prt.Used {
+			t.Errorf("ConsumePasswordResetToken returned %+v", prt)
+		}
+		_, err = repos.Auth.ConsumePasswordResetToken(ctx, "new")
+		expectErr(t, "ConsumePasswordResetToken of a used token", err, auth.ErrInvalidPasswordResetToken)
+		_, err = repos.Auth.ConsumePasswordResetToken(ctx, "unknown")
+		expectErr(t, "ConsumePasswordResetToken of an unknown token", err, auth.ErrInvalidPasswordResetToken)
+
+		err = repos.Auth.AddPasswordResetToken(ctx, &auth.PasswordResetToken{TokenHash: "lost", Username: "nobody", CreationTime: now, ExpiresAt: now.Add(time.Hour)})
+		if err == nil {
+			t.Errorf("AddPasswordResetToken of a missing user succeeded")
+		}
+	}},
+	{"auth/set password", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		expectNoErr(t, "SetPassword", repos.Auth.SetPassword(ctx, "alice", "new password"))
+		ok, err := repos.Auth.Authenticate(ctx, &auth.User{Username: "alice", Password: "new password"})
+		expectNoErr(t, "Authenticate", err)
+		if !ok {
+			t.Errorf("Authenticate with the new password failed")
+		}
+		ok, err = repos.Auth.Authenticate(ctx, &auth.User{Username: "alice", Password: password})
+		expectNoErr(t, "Authenticate", err)
+		if ok {
+			t.Errorf("Authenticate with the old password succeeded")
+		}
+		expectErr(t, "SetPassword of a missing user", repos.Auth.SetPassword(ctx, "nobody", "new password"), auth.ErrUserNotFound)
+	}},
+	{"auth/email verification", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		last, err := repos.Auth.GetLastEmailVerificationTime(ctx, "alice")
+		expectNoErr(t, "GetLastEmailVerificationTime", err)
+		if !last.IsZero() {
+			t.Errorf("expected no last email verification time, got %v", last)
+		}
+
+		issued := time.Now().Add(-time.Minute).Truncate(time.Second)
+		addToken := func(hash, email string, creationTime time.Time) {
+			t.Helper()
+			expectNoErr(t, "AddEmailVerificationToken", repos.Auth.AddEmailVerificationToken(ctx, &auth.EmailVerificationToken{
+				TokenHash: hash, Username: "alice", Email: email, CreationTime: creationTime, ExpiresAt: time.Now().Add(time.Hour),
+			}))
+		}
+		addToken("old", "alice@example.com", issued.Add(-time.Minute))
+		addToken("new", "alice@example.com", issued)
+		last, err = repos.Auth.GetLastEmailVerificationTime(ctx, "alice")
+		expectNoErr(t, "GetLastEmailVerificationTime", err)
+		expectSameTime(t, "last email verification time", last, issued)
+
+		_, err = repos.Auth.ConsumeEmailVerificationToken(ctx, "old")
+		expectErr(t, "ConsumeEmailVerificationToken of a superseded token", err, auth.ErrInvalidEmailVerificationToken)
+		evt, err := repos.Auth.ConsumeEmailVerificationToken(ctx, "new")
+		expectNoErr(t, "ConsumeEmailVerificationToken", err)
+		if evt.Username != "alice" || evt.Email != "alice@example.com" {
+			t.Errorf("ConsumeEmailVerificationToken returned %+v", evt)
+		}
+		_, err = repos.Auth.ConsumeEmailVerificationToken(ctx, "new")
+		expectErr(t, "ConsumeEmailVerificationToken of a used token", err, auth.ErrInvalidEmailVerificationToken)
+
+		last, err = repos.Auth.GetLastEmailVerificationTime(ctx, "alice")
+		expectNoErr(t, "GetLastEmailVerificationTime", err)
+		expectSameTime(t, "last email verification time after consuming", last, issued)
+	}},
+	{"auth/email verification of changed email", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		expectNoErr(t, "AddEmailVerificationToken", repos.Auth.AddEmailVerificationToken(ctx, &auth.EmailVerificationToken{
+			TokenHash: "stale", Username: "alice", Email: "alice@example.com", CreationTime: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+		}))
+		_, err := repos.User.UpdateUser(ctx, "alice", &user.User{Email: "liddell@example.com"})
+		expectNoErr(t, "UpdateUser", err)
+		_, err = repos.Auth.ConsumeEmailVerificationToken(ctx, "stale")
+		expectErr(t, "ConsumeEmailVerificationToken of a token mailed to the old email", err, auth.ErrInvalidEmailVerificationToken)
+	}},
+	{"auth/totp", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		_, err := repos.Auth.GetTOTPSecret(ctx, "alice")
+		expectErr(t, "GetTOTPSecret of a user that isn't enrolled", err, auth.ErrTOTPNotEnrolled)
+		expectErr(t, "ConfirmTOTPSecret of a user that isn't enrolled", repos.Auth.ConfirmTOTPSecret(ctx, "alice"), auth.ErrTOTPNotEnrolled)
+
+		expectNoErr(t, "SetTOTPSecret", repos.Auth.SetTOTPSecret(ctx, &auth.TOTPSecret{
+			Username:           "alice",
+			Secret:             "SECRET",
+			RecoveryCodeHashes: []string{"code-a", "code-b"},
+			CreationTime:       time.Now(),
+		}))
+		expectNoErr(t, "ConfirmTOTPSecret", repos.Auth.ConfirmTOTPSecret(ctx, "alice"))
+		ts, err := repos.Auth.GetTOTPSecret(ctx, "alice")
+		expectNoErr(t, "GetTOTPSecret", err)
+		if ts.Secret != "SECRET" || !ts.Confirmed || ts.LastUsedStep != 0 {
+			t.Errorf("GetTOTPSecret returned %+v", ts)
+		}
+		expectOrder(t, "recovery codes", ts.RecoveryCodeHashes, "code-a", "code-b")
+
+		for _, step := range []struct {
+			step     int64
+			expected bool
+		}{{10, true}, {10, false}, {9, false}, {11, true}} {
+			updated, err := repos.Auth.UpdateTOTPLastUsedStep(ctx, "alice", step.step)
+			expectNoErr(t, "UpdateTOTPLastUsedStep", err)
+			if updated != step.expected {
+				t.Errorf("UpdateTOTPLastUsedStep to %d: expected %v, got %v", step.step, step.expected, updated)
+			}
+		}
+
+		consumed, err := repos.Auth.ConsumeTOTPRecoveryCode(ctx, "alice", "code-a")
+		expectNoErr(t, "ConsumeTOTPRecoveryCode", err)
+		if !consumed {
+			t.Errorf("ConsumeTOTPRecoveryCode of an unused code returned false")
+		}
+		consumed, err = repos.Auth.ConsumeTOTPRecoveryCode(ctx, "alice", "code-a")
+		expectNoErr(t, "ConsumeTOTPRecoveryCode", err)
+		if consumed {
+			t.Errorf("ConsumeTOTPRecoveryCode of a used code returned true")
+		}
+		ts, err = repos.Auth.GetTOTPSecret(ctx, "alice")
+		expectNoErr(t, "GetTOTPSecret", err)
+		expectOrder(t, "unused recovery codes", ts.RecoveryCodeHashes, "code-b")
+		if ts.LastUsedStep != 11 {
+			t.Errorf("expected last used step 11, got %d", ts.LastUsedStep)
+		}
+
+		expectNoErr(t, "SetTOTPSecret", repos.Auth.SetTOTPSecret(ctx, &auth.TOTPSecret{
+			Username:           "alice",
+			Secret:             "OTHER",
+			RecoveryCodeHashes: []string{"code-c"},
+			CreationTime:       time.Now(),
+		}))
+		ts, err = repos.Auth.GetTOTPSecret(ctx, "alice")
+		expectNoErr(t, "GetTOTPSecret", err)
+		if ts.Secret != "OTHER" || ts.Confirmed {
+			t.Errorf("a new enrolment is expected to replace the old one, got %+v", ts)
+		}
+		expectOrder(t, "recovery codes of the new enrolment", ts.RecoveryCodeHashes, "code-c")
+
+		expectNoErr(t, "DeleteTOTPSecret", repos.Auth.DeleteTOTPSecret(ctx, "alice"))
+		_, err = repos.Auth.GetTOTPSecret(ctx, "alice")
+		expectErr(t, "GetTOTPSecret of a deleted enrolment", err, auth.ErrTOTPNotEnrolled)
+	}},
+	{"auth/personal access tokens", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		now := time.Now().Truncate(time.Second)
+		expiresAt := now.Add(24 * time.Hour)
+		expectNoErr(t, "AddPersonalAccessToken", repos.Auth.AddPersonalAccessToken(ctx, &auth.PersonalAccessToken{
+			ID: "older", Token: "i1pat_older", TokenHash: "older-hash", Username: "alice", Name: "ci",
+			Scopes: []string{auth.ScopePostsWrite}, CreationTime: now.Add(-time.Minute), ExpiresAt: &expiresAt,
+		}))
+		expectNoErr(t, "AddPersonalAccessToken", repos.Auth.AddPersonalAccessToken(ctx, &auth.PersonalAccessToken{
+			ID: "newer", TokenHash: "newer-hash", Username: "alice", Name: "backup",
+			Scopes: []string{auth.ScopePrivateRead, auth.ScopeUsersWrite}, CreationTime: now,
+		}))
+		err := repos.Auth.AddPersonalAccessToken(ctx, &auth.PersonalAccessToken{ID: "lost", TokenHash: "lost-hash", Username: "nobody", CreationTime: now})
+		if err == nil {
+			t.Errorf("AddPersonalAccessToken of a missing user succeeded")
+		}
+
+		pat, err := repos.Auth.GetPersonalAccessToken(ctx, "older-hash")
+		expectNoErr(t, "GetPersonalAccessToken", err)
+		if pat.ID != "older" || pat.Username != "alice" || pat.Name != "ci" || pat.Token != "" || pat.LastUsedTime != nil {
+			t.Errorf("GetPersonalAccessToken returned %+v", pat)
+		}
+		expectOrder(t, "scopes", pat.Scopes, auth.ScopePostsWrite)
+		if pat.ExpiresAt == nil {
+			t.Errorf("GetPersonalAccessToken returned no expiry")
+		} else {
+			expectSameTime(t, "expiry", *pat.ExpiresAt, expiresAt)
+		}
+		_, err = repos.Auth.GetPersonalAccessToken(ctx, "unknown")
+		expectErr(t, "GetPersonalAccessToken of an unknown token", err, auth.ErrPersonalAccessTokenNotFound)
+
+		lastUsed := now.Add(time.Minute)
+		expectNoErr(t, "UpdatePersonalAccessTokenLastUsed", repos.Auth.UpdatePersonalAccessTokenLastUsed(ctx, "newer", lastUsed))
+		pat, err = repos.Auth.GetPersonalAccessToken(ctx, "newer-hash")
+		expectNoErr(t, "GetPersonalAccessToken", err)
+		if pat.ExpiresAt != nil {
+			t.Errorf("expected a token that never expires, got %v", *pat.ExpiresAt)
+		}
+		if pat.LastUsedTime == nil {
+			t.Errorf("GetPersonalAccessToken returned no last used time")
+		} else {
+			expectSameTime(t, "last used time", *pat.LastUsedTime, lastUsed)
+		}
+
+		id := func(pat *auth.PersonalAccessToken) string { return pat.ID }
+		pats, err := repos.Auth.GetPersonalAccessTokens(ctx, "alice")
+		expectNoErr(t, "GetPersonalAccessTokens", err)
+		expectOrder(t, "tokens newest first", keys(pats, id), "newer", "older")
+
+		expectNoErr(t, "RevokePersonalAccessToken", repos.Auth.RevokePersonalAccessToken(ctx, "alice", "newer"))
+		expectErr(t, "RevokePersonalAccessToken of a revoked token",
+			repos.Auth.RevokePersonalAccessToken(ctx, "alice", "newer"), auth.ErrPersonalAccessTokenNotFound)
+		expectErr(t, "RevokePersonalAccessToken of another user's token",
+			repos.Auth.RevokePersonalAccessToken(ctx, "bob", "older"), auth.ErrPersonalAccessTokenNotFound)
+		pats, err = repos.Auth.GetPersonalAccessTokens(ctx, "alice")
+		expectNoErr(t, "GetPersonalAccessTokens", err)
+		expectOrder(t, "tokens that aren't revoked", keys(pats, id), "older")
+		pat, err = repos.Auth.GetPersonalAccessToken(ctx, "newer-hash")
+		expectNoErr(t, "GetPersonalAccessToken of a revoked token", err)
+		if !pat.Revoked {
+			t.Errorf("expected a revoked token, got %+v", pat)
+		}
+	}},
+	{"auth/sessions", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		now := time.Now().Truncate(time.Second)
+		for i, id := range []string{"laptop", "phone", "tablet"} {
+			expectNoErr(t, "AddSession", repos.Auth.AddSession(ctx, &auth.Session{
+				ID:           id,
+				Username:     "alice",
+				Scopes:       []string{auth.ScopeAll},
+				UserAgent:    "agent/" + id,
+				IPAddress:    "127.0.0.1",
+				CreationTime: now.Add(-time.Hour),
+				LastUsedTime: now.Add(time.Duration(i) * time.Minute),
+				ExpiresAt:    now.Add(time.Hour),
+			}))
+			expectNoErr(t, "AddRefreshToken", repos.Auth.AddRefreshToken(ctx, &auth.RefreshToken{
+				TokenHash: id + "-token", FamilyID: id, Username: "alice", CreationTime: now, ExpiresAt: now.Add(time.Hour),
+			}))
+		}
+		err := repos.Auth.AddSession(ctx, &auth.Session{ID: "lost", Username: "nobody", CreationTime: now, LastUsedTime: now, ExpiresAt: now.Add(time.Hour)})
+		if err == nil {
+			t.Errorf("AddSession of a missing user succeeded")
+		}
+
+		session, err := repos.Auth.GetSession(ctx, "phone")
+		expectNoErr(t, "GetSession", err)
+		if session.Username != "alice" || session.UserAgent != "agent/phone" || session.IPAddress != "127.0.0.1" || session.Revoked || session.Current {
+			t.Errorf("GetSession returned %+v", session)
+		}
+		expectOrder(t, "scopes", session.Scopes, auth.ScopeAll)
+		_, err = repos.Auth.GetSession(ctx, "unknown")
+		expectErr(t, "GetSession of an unknown session", err, auth.ErrSessionNotFound)
+
+		id := func(session *auth.Session) string { return session.ID }
+		sessions, err := repos.Auth.GetSessions(ctx, "alice")
+		expectNoErr(t, "GetSessions", err)
+		expectOrder(t, "sessions most recently used first", keys(sessions, id), "tablet", "phone", "laptop")
+
+		expectNoErr(t, "UpdateSession", repos.Auth.UpdateSession(ctx, "laptop", now.Add(time.Hour), now.Add(2*time.Hour)))
+		sessions, err = repos.Auth.GetSessions(ctx, "alice")
+		expectNoErr(t, "GetSessions", err)
+		expectOrder(t, "sessions after one is used", keys(sessions, id), "laptop", "tablet", "phone")
+
+		expectNoErr(t, "RevokeSession", repos.Auth.RevokeSession(ctx, "alice", "tablet"))
+		expectErr(t, "RevokeSession of another user's session", repos.Auth.RevokeSession(ctx, "bob", "phone"), auth.ErrSessionNotFound)
+		revoked, err := repos.Auth.IsSessionRevoked(ctx, "tablet")
+		expectNoErr(t, "IsSessionRevoked", err)
+		if !revoked {
+			t.Errorf("IsSessionRevoked of a revoked session returned false")
+		}
+		revoked, err = repos.Auth.IsSessionRevoked(ctx, "unknown")
+		expectNoErr(t, "IsSessionRevoked", err)
+		if revoked {
+			t.Errorf("IsSessionRevoked of an unknown session returned true")
+		}
+
+		expectNoErr(t, "RevokeSessions", repos.Auth.RevokeSessions(ctx, "alice", "phone"))
+		sessions, err = repos.Auth.GetSessions(ctx, "alice")
+		expectNoErr(t, "GetSessions", err)
+		expectOrder(t, "sessions after revoking the others", keys(sessions, id), "phone")
+		for hash, expected := range map[string]bool{"laptop-token": true, "phone-token": false} {
+			rt, err := repos.Auth.GetRefreshToken(ctx, hash)
+			expectNoErr(t, "GetRefreshToken", err)
+			if rt.Revoked != expected {
+				t.Errorf("refresh token %s: expected revoked %v, got %v", hash, expected, rt.Revoked)
+			}
+		}
+	}},
+}
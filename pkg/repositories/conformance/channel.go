@@ -0,0 +1,258 @@
+package conformance
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+)
+
+// addChannel is a helper function that adds a channel owned by the user.
+func addChannel(t T, repos *Repositories, channelUsername, name, owner string) {
+	t.Helper()
+	_, err := repos.Channel.AddChannel(ctx, &channel.Channel{
+		ChannelUsername: channelUsername,
+		Name:            name,
+		OwnerUsername:   owner,
+	})
+	if err != nil {
+		t.Fatalf("adding channel %s failed because of: %v", channelUsername, err)
+	}
+}
+
+var channelChecks = []Check{
+	{"channel/add and get", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		_, err := repos.Channel.AddChannel(ctx, &channel.Channel{
+			ChannelUsername: "news",
+			Name:            "The News",
+			Description:     "all the news",
+			OwnerUsername:   "alice",
+		})
+		expectNoErr(t, "AddChannel", err)
+		c, err := repos.Channel.GetChannel(ctx, "news")
+		expectNoErr(t, "GetChannel", err)
+		if c.ChannelUsername != "news" || c.Name != "The News" || c.Description != "all the news" || c.OwnerUsername != "alice" {
+			t.Errorf("GetChannel returned %+v", c)
+		}
+		expectOrder(t, "admins", c.AdminUsernames, "alice")
+		if c.CreationTime.IsZero() {
+			t.Errorf("GetChannel returned no creation time")
+		}
+		if len(c.PostIDs) != 0 || len(c.ReleaseIDs) != 0 || len(c.StickiedPostIDs) != 0 || len(c.OfficialReleaseIDs) != 0 {
+			t.Errorf("a new channel is expected to be empty, got %+v", c)
+		}
+	}},
+	{"channel/user channel", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		c, err := repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel of the user's channel", err)
+		if c.OwnerUsername != "alice" {
+			t.Errorf("users are expected to own their channel, got %+v", c)
+		}
+		occupied, err := repos.User.UsernameOccupied(ctx, "news")
+		expectNoErr(t, "UsernameOccupied", err)
+		if occupied {
+			t.Errorf("UsernameOccupied of a free username is true")
+		}
+		addChannel(t, repos, "news", "The News", "alice")
+		occupied, err = repos.User.UsernameOccupied(ctx, "news")
+		expectNoErr(t, "UsernameOccupied", err)
+		if !occupied {
+			t.Errorf("channels are expected to occupy their username")
+		}
+	}},
+	{"channel/get missing", func(t T, repos *Repositories) {
+		_, err := repos.Channel.GetChannel(ctx, "nowhere")
+		expectErr(t, "GetChannel", err, channel.ErrChannelNotFound)
+	}},
+	{"channel/duplicate", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addChannel(t, repos, "news", "The News", "alice")
+		_, err := repos.Channel.AddChannel(ctx, &channel.Channel{ChannelUsername: "news", Name: "Other News", OwnerUsername: "alice"})
+		if err == nil {
+			t.Errorf("AddChannel of a taken username succeeded")
+		}
+		_, err = repos.Channel.AddChannel(ctx, &channel.Channel{ChannelUsername: "alice", Name: "Alice", OwnerUsername: "alice"})
+		if err == nil {
+			t.Errorf("AddChannel of the username of a user's channel succeeded")
+		}
+	}},
+	{"channel/add with missing owner", func(t T, repos *Repositories) {
+		_, err := repos.Channel.AddChannel(ctx, &channel.Channel{ChannelUsername: "news", Name: "The News", OwnerUsername: "nobody"})
+		expectErr(t, "AddChannel", err, channel.ErrAdminNotFound)
+		_, err = repos.Channel.GetChannel(ctx, "news")
+		expectErr(t, "GetChannel of a channel that failed to be added", err, channel.ErrChannelNotFound)
+	}},
+	{"channel/update", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addChannel(t, repos, "news", "The News", "alice")
+		_, err := repos.Channel.UpdateChannel(ctx, "news", &channel.Channel{Description: "all the news"})
+		expectNoErr(t, "UpdateChannel", err)
+		c, err := repos.Channel.GetChannel(ctx, "news")
+		expectNoErr(t, "GetChannel", err)
+		if c.Name != "The News" || c.Description != "all the news" {
+			t.Errorf("UpdateChannel of the description left %+v", c)
+		}
+
+		_, err = repos.Channel.UpdateChannel(ctx, "nowhere", &channel.Channel{Name: "Nowhere"})
+		expectErr(t, "UpdateChannel of a missing channel", err, channel.ErrChannelNotFound)
+	}},
+	{"channel/rename", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addChannel(t, repos, "news", "The News", "alice")
+		p := addPost(t, repos, "alice", "news", "first", "")
+		_, err := repos.Channel.UpdateChannel(ctx, "news", &channel.Channel{ChannelUsername: "headlines"})
+		expectNoErr(t, "UpdateChannel", err)
+		_, err = repos.Channel.GetChannel(ctx, "news")
+		expectErr(t, "GetChannel of the old username", err, channel.ErrChannelNotFound)
+		c, err := repos.Channel.GetChannel(ctx, "headlines")
+		expectNoErr(t, "GetChannel of the new username", err)
+		if c.Name != "The News" || c.OwnerUsername != "alice" {
+			t.Errorf("GetChannel returned %+v", c)
+		}
+		expectOrder(t, "posts of the renamed channel", c.PostIDs, p.ID)
+	}},
+	{"channel/delete", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addChannel(t, repos, "news", "The News", "alice")
+		expectNoErr(t, "DeleteChannel", repos.Channel.DeleteChannel(ctx, "news"))
+		_, err := repos.Channel.GetChannel(ctx, "news")
+		expectErr(t, "GetChannel of a deleted channel", err, channel.ErrChannelNotFound)
+		expectNoErr(t, "DeleteChannel of a missing channel", repos.Channel.DeleteChannel(ctx, "news"))
+	}},
+	{"channel/delete with catalog", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addChannel(t, repos, "news", "The News", "alice")
+		addChannel(t, repos, "mirror", "The Mirror", "alice")
+		r := addRelease(t, repos, "mirror", "story", "once upon a time")
+		p := addPost(t, repos, "alice", "news", "story", "", uint(r.ID))
+		expectNoErr(t, "AddReleaseToOfficialCatalog", repos.Channel.AddReleaseToOfficialCatalog(ctx, "mirror", uint(r.ID), p.ID))
+		if err := repos.Channel.DeleteChannel(ctx, "news"); err == nil {
+			t.Errorf("DeleteChannel of a channel with a post in a catalog succeeded")
+		}
+		_, err := repos.Channel.GetChannel(ctx, "news")
+		expectNoErr(t, "GetChannel of a channel that failed to be deleted", err)
+	}},
+	{"channel/admins", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		addChannel(t, repos, "news", "The News", "alice")
+		expectNoErr(t, "AddAdmin", repos.Channel.AddAdmin(ctx, "news", "bob"))
+		expectErr(t, "AddAdmin of an admin", repos.Channel.AddAdmin(ctx, "news", "bob"), channel.ErrAdminAlreadyExists)
+		expectErr(t, "AddAdmin of a missing user", repos.Channel.AddAdmin(ctx, "news", "nobody"), channel.ErrAdminNotFound)
+		expectErr(t, "AddAdmin to a missing channel", repos.Channel.AddAdmin(ctx, "nowhere", "bob"), channel.ErrAdminNotFound)
+		c, err := repos.Channel.GetChannel(ctx, "news")
+		expectNoErr(t, "GetChannel", err)
+		expectOrder(t, "admins", c.AdminUsernames, "alice", "bob")
+
+		expectNoErr(t, "ChangeOwner", repos.Channel.ChangeOwner(ctx, "news", "bob"))
+		c, err = repos.Channel.GetChannel(ctx, "news")
+		expectNoErr(t, "GetChannel", err)
+		if c.OwnerUsername != "bob" {
+			t.Errorf("expected owner bob, got %q", c.OwnerUsername)
+		}
+
+		expectNoErr(t, "DeleteAdmin", repos.Channel.DeleteAdmin(ctx, "news", "alice"))
+		expectNoErr(t, "DeleteAdmin of a non admin", repos.Channel.DeleteAdmin(ctx, "news", "alice"))
+		c, err = repos.Channel.GetChannel(ctx, "news")
+		expectNoErr(t, "GetChannel", err)
+		expectOrder(t, "admins", c.AdminUsernames, "bob")
+	}},
+	{"channel/stickies", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		first := addPost(t, repos, "alice", "alice", "first", "")
+		second := addPost(t, repos, "alice", "alice", "second", "")
+		third := addPost(t, repos, "alice", "alice", "third", "")
+		expectErr(t, "StickyPost of a missing post", repos.Channel.StickyPost(ctx, "alice", missingID), channel.ErrPostNotFound)
+		expectNoErr(t, "StickyPost", repos.Channel.StickyPost(ctx, "alice", third.ID))
+		expectNoErr(t, "StickyPost", repos.Channel.StickyPost(ctx, "alice", first.ID))
+		expectErr(t, "StickyPost past the limit", repos.Channel.StickyPost(ctx, "alice", second.ID), channel.ErrStickiedPostFull)
+		c, err := repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel", err)
+		expectOrder(t, "stickied posts", c.StickiedPostIDs, first.ID, third.ID)
+		expectOrder(t, "posts", c.PostIDs, first.ID, second.ID, third.ID)
+
+		expectNoErr(t, "DeleteStickiedPost", repos.Channel.DeleteStickiedPost(ctx, "alice", third.ID))
+		expectNoErr(t, "StickyPost", repos.Channel.StickyPost(ctx, "alice", second.ID))
+		c, err = repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel", err)
+		expectOrder(t, "stickied posts", c.StickiedPostIDs, first.ID, second.ID)
+	}},
+	{"channel/catalog", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		first := addRelease(t, repos, "alice", "first", "one")
+		second := addRelease(t, repos, "alice", "second", "two")
+		p := addPost(t, repos, "alice", "alice", "releases", "", uint(first.ID), uint(second.ID))
+		expectErr(t, "AddReleaseToOfficialCatalog of a missing channel",
+			repos.Channel.AddReleaseToOfficialCatalog(ctx, "nowhere", uint(first.ID), p.ID), channel.ErrChannelNotFound)
+		expectErr(t, "AddReleaseToOfficialCatalog of a missing release",
+			repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", missingID, p.ID), channel.ErrReleaseNotFound)
+		expectErr(t, "AddReleaseToOfficialCatalog of a missing post",
+			repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", uint(first.ID), missingID), channel.ErrPostNotFound)
+		expectNoErr(t, "AddReleaseToOfficialCatalog", repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", uint(second.ID), p.ID))
+		expectNoErr(t, "AddReleaseToOfficialCatalog", repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", uint(first.ID), p.ID))
+		expectErr(t, "AddReleaseToOfficialCatalog of a catalogued release",
+			repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", uint(first.ID), p.ID), channel.ErrReleaseAlreadyExists)
+		c, err := repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel", err)
+		expectOrder(t, "releases", c.ReleaseIDs, uint(first.ID), uint(second.ID))
+		expectOrder(t, "official releases", c.OfficialReleaseIDs, uint(second.ID), uint(first.ID))
+
+		expectNoErr(t, "DeleteReleaseFromOfficialCatalog", repos.Channel.DeleteReleaseFromOfficialCatalog(ctx, "alice", uint(second.ID)))
+		expectNoErr(t, "DeleteReleaseFromCatalog", repos.Channel.DeleteReleaseFromCatalog(ctx, "alice", uint(second.ID)))
+		c, err = repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel", err)
+		expectOrder(t, "releases", c.ReleaseIDs, uint(first.ID))
+		expectOrder(t, "official releases", c.OfficialReleaseIDs, uint(first.ID))
+	}},
+	{"channel/search", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addChannel(t, repos, "news_b", "Morning", "alice")
+		tick()
+		addChannel(t, repos, "news_c", "evening", "alice")
+		tick()
+		addChannel(t, repos, "News_a", "Noon", "alice")
+		tick()
+		addChannel(t, repos, "sports", "Scores", "alice")
+		username := func(c *channel.Channel) string { return c.ChannelUsername }
+
+		channels, err := repos.Channel.SearchChannels(ctx, "news", channel.SortByUsername, channel.SortAscending, -1, 0)
+		expectNoErr(t, "SearchChannels", err)
+		expectOrder(t, "by username ascending", keys(channels, username), "News_a", "news_b", "news_c")
+
+		channels, err = repos.Channel.SearchChannels(ctx, "news", channel.SortByName, channel.SortDescending, -1, 0)
+		expectNoErr(t, "SearchChannels", err)
+		expectOrder(t, "by name descending", keys(channels, username), "News_a", "news_b", "news_c")
+
+		channels, err = repos.Channel.SearchChannels(ctx, "news", channel.SortCreationTime, channel.SortDescending, -1, 0)
+		expectNoErr(t, "SearchChannels", err)
+		expectOrder(t, "by creation time descending", keys(channels, username), "News_a", "news_c", "news_b")
+
+		channels, err = repos.Channel.SearchChannels(ctx, "news", channel.SortByUsername, channel.SortAscending, 1, 1)
+		expectNoErr(t, "SearchChannels", err)
+		expectOrder(t, "second page of one", keys(channels, username), "news_b")
+
+		channels, err = repos.Channel.SearchChannels(ctx, "SCORE", channel.SortByUsername, channel.SortAscending, -1, 0)
+		expectNoErr(t, "SearchChannels", err)
+		expectOrder(t, "matching the name", keys(channels, username), "sports")
+		if len(channels) == 1 {
+			expectOrder(t, "admins of the found channel", channels[0].AdminUsernames, "alice")
+		}
+	}},
+	{"channel/picture", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		_, err := repos.Channel.AddPicture(ctx, "alice", "alice.png")
+		expectNoErr(t, "AddPicture", err)
+		c, err := repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel", err)
+		if c.PictureURL != "alice.png" {
+			t.Errorf("expected picture alice.png, got %q", c.PictureURL)
+		}
+		expectNoErr(t, "RemovePicture", repos.Channel.RemovePicture(ctx, "alice"))
+		c, err = repos.Channel.GetChannel(ctx, "alice")
+		expectNoErr(t, "GetChannel", err)
+		if c.PictureURL != "" {
+			t.Errorf("expected no picture, got %q", c.PictureURL)
+		}
+		_, err = repos.Channel.AddPicture(ctx, "nowhere", "x.png")
+		expectErr(t, "AddPicture of a missing channel", err, channel.ErrChannelNotFound)
+	}},
+}
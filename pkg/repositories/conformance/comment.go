@@ -0,0 +1,106 @@
+package conformance
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+)
+
+var commentChecks = []Check{
+	{"comment/add and get", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		added := addComment(t, repos, "alice", p.ID, -1, "first!")
+		if added.ID == 0 || added.CreationTime.IsZero() {
+			t.Errorf("AddComment is expected to set the id and creation time, got %+v", added)
+		}
+		c, err := repos.Comment.GetComment(ctx, added.ID)
+		expectNoErr(t, "GetComment", err)
+		if c.ID != added.ID || c.OriginPost != int(p.ID) || c.Commenter != "alice" || c.Content != "first!" || c.ReplyTo != -1 {
+			t.Errorf("GetComment returned %+v", c)
+		}
+		expectSameTime(t, "creation time", c.CreationTime, added.CreationTime)
+
+		got, err := repos.Post.GetPost(ctx, p.ID)
+		expectNoErr(t, "GetPost", err)
+		expectOrder(t, "comments of the post", got.CommentsID, added.ID)
+	}},
+	{"comment/add with missing references", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		_, err := repos.Comment.AddComment(ctx, &comment.Comment{OriginPost: missingID, Commenter: "alice", Content: "lost", ReplyTo: -1})
+		expectErr(t, "AddComment on a missing post", err, comment.ErrPostNotFound)
+		_, err = repos.Comment.AddComment(ctx, &comment.Comment{OriginPost: int(p.ID), Commenter: "nobody", Content: "lost", ReplyTo: -1})
+		expectErr(t, "AddComment by a missing user", err, comment.ErrUserNotFound)
+		_, err = repos.Comment.GetComment(ctx, missingID)
+		expectErr(t, "GetComment of a missing comment", err, comment.ErrCommentNotFound)
+		_, err = repos.Comment.GetComments(ctx, missingID, string(comment.SortByCreationTime), string(comment.SortAscending), -1, 0)
+		expectErr(t, "GetComments of a missing post", err, comment.ErrPostNotFound)
+		_, err = repos.Comment.GetReplies(ctx, missingID, string(comment.SortByCreationTime), string(comment.SortAscending), -1, 0)
+		expectErr(t, "GetReplies of a missing comment", err, comment.ErrCommentNotFound)
+	}},
+	{"comment/list", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		other := addPost(t, repos, "alice", "alice", "second", "")
+		first := addComment(t, repos, "alice", p.ID, -1, "first")
+		tick()
+		second := addComment(t, repos, "bob", p.ID, first.ID, "second")
+		tick()
+		third := addComment(t, repos, "alice", p.ID, first.ID, "third")
+		tick()
+		addComment(t, repos, "bob", other.ID, -1, "elsewhere")
+		id := func(c *comment.Comment) int { return c.ID }
+
+		comments, err := repos.Comment.GetComments(ctx, int(p.ID), string(comment.SortByCreationTime), string(comment.SortAscending), -1, 0)
+		expectNoErr(t, "GetComments", err)
+		expectOrder(t, "comments by creation time", keys(comments, id), first.ID, second.ID, third.ID)
+
+		comments, err = repos.Comment.GetComments(ctx, int(p.ID), string(comment.SortByCreationTime), string(comment.SortDescending), 2, 0)
+		expectNoErr(t, "GetComments", err)
+		expectOrder(t, "first page of two comments by creation time descending", keys(comments, id), third.ID, second.ID)
+
+		comments, err = repos.Comment.GetComments(ctx, int(p.ID), string(comment.SortByCreationTime), string(comment.SortDescending), 2, 2)
+		expectNoErr(t, "GetComments", err)
+		expectOrder(t, "second page of two comments", keys(comments, id), first.ID)
+
+		comments, err = repos.Comment.GetReplies(ctx, first.ID, string(comment.SortByCreationTime), string(comment.SortAscending), -1, 0)
+		expectNoErr(t, "GetReplies", err)
+		expectOrder(t, "replies", keys(comments, id), second.ID, third.ID)
+		if len(comments) == 2 && (comments[0].ReplyTo != first.ID || comments[0].Commenter != "bob") {
+			t.Errorf("GetReplies returned %+v", comments[0])
+		}
+
+		comments, err = repos.Comment.GetReplies(ctx, third.ID, string(comment.SortByCreationTime), string(comment.SortAscending), -1, 0)
+		expectNoErr(t, "GetReplies", err)
+		expectOrder(t, "replies of a comment without any", keys(comments, id))
+	}},
+	{"comment/update", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		added := addComment(t, repos, "alice", p.ID, -1, "frist")
+		updated, err := repos.Comment.UpdateComment(ctx, &comment.Comment{ID: added.ID, Content: "first"})
+		expectNoErr(t, "UpdateComment", err)
+		if updated.Content != "first" || updated.Commenter != "alice" {
+			t.Errorf("UpdateComment returned %+v", updated)
+		}
+		c, err := repos.Comment.GetComment(ctx, added.ID)
+		expectNoErr(t, "GetComment", err)
+		if c.Content != "first" {
+			t.Errorf("expected content first, got %q", c.Content)
+		}
+		_, err = repos.Comment.UpdateComment(ctx, &comment.Comment{ID: missingID, Content: "lost"})
+		expectErr(t, "UpdateComment of a missing comment", err, comment.ErrCommentNotFound)
+	}},
+	{"comment/delete", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		c := addComment(t, repos, "alice", p.ID, -1, "first")
+		expectNoErr(t, "DeleteComment", repos.Comment.DeleteComment(ctx, c.ID))
+		_, err := repos.Comment.GetComment(ctx, c.ID)
+		expectErr(t, "GetComment of a deleted comment", err, comment.ErrCommentNotFound)
+		expectNoErr(t, "DeleteComment of a missing comment", repos.Comment.DeleteComment(ctx, c.ID))
+		got, err := repos.Post.GetPost(ctx, p.ID)
+		expectNoErr(t, "GetPost", err)
+		expectOrder(t, "comments of the post", got.CommentsID)
+	}},
+}
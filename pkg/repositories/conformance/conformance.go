@@ -0,0 +1,158 @@
+/*
+Package conformance holds the behaviour every implementation of the Repository
+interfaces of the domain, search and auth services is expected to share. The
+services only rely on what's checked here, so a storage backend that passes all
+the checks can stand in for any other.
+
+Run runs them all as subtests against the repositories of a backend:
+
+	func TestConformance(t *testing.T) {
+		conformance.Run(t, newRepositories)
+	}
+
+The checks themselves only report through T, which *testing.T satisfies, so
+that they can be run outside of tests too, see Check.Exec.
+
+Each backend runs them from a test of its own, cmd/conformance runs them too,
+against a configured postgres database for one.
+*/
+package conformance
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+)
+
+// T is what checks report their failures to.
+// Fatalf is expected to stop the check, like it stops a test.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+var _ T = (*testing.T)(nil)
+
+// Repositories holds the implementations under check. They're expected to
+// share their data, like those of a storage backend do.
+// UnitOfWork is expected to group calls to any of them.
+type Repositories struct {
+	User    user.Repository
+	Channel channel.Repository
+	Feed    feed.Repository
+	Release release.Repository
+	Post    post.Repository
+	Comment comment.Repository
+	Search  search.Repository
+	Auth    auth.Repository
+
+	UnitOfWork unitofwork.UnitOfWork
+}
+
+// Register registers the repositories under the names the database
+// repositories of a backend look each other up by.
+func (repos *Repositories) Register(dbRepos map[string]interface{}) {
+	dbRepos["User"] = &repos.User
+	dbRepos["Channel"] = &repos.Channel
+	dbRepos["Feed"] = &repos.Feed
+	dbRepos["Release"] = &repos.Release
+	dbRepos["Post"] = &repos.Post
+	dbRepos["Comment"] = &repos.Comment
+	dbRepos["Search"] = &repos.Search
+	dbRepos["Auth"] = &repos.Auth
+}
+
+// Factory returns empty repositories for a check to run against along with a
+// function that releases them once it's done.
+type Factory func() (repos *Repositories, release func(), err error)
+
+// Check is a single check of the behaviour of the repositories.
+// Names are prefixed with the repository they check, "user/add" for example.
+type Check struct {
+	Name string
+	Run  func(t T, repos *Repositories)
+}
+
+// Checks returns all the checks, grouped by the repository they check.
+func Checks() []Check {
+	var checks []Check
+	checks = append(checks, userChecks...)
+	checks = append(checks, channelChecks...)
+	checks = append(checks, feedChecks...)
+	checks = append(checks, releaseChecks...)
+	checks = append(checks, postChecks...)
+	checks = append(checks, commentChecks...)
+	checks = append(checks, searchChecks...)
+	checks = append(checks, authChecks...)
+	checks = append(checks, unitOfWorkChecks...)
+	return checks
+}
+
+// Run runs all the checks as subtests of t, each against repositories of
+// its own returned by newRepos.
+func Run(t *testing.T, newRepos Factory) {
+	for _, check := range Checks() {
+		check := check
+		t.Run(check.Name, func(t *testing.T) {
+			repos, release, err := newRepos()
+			if err != nil {
+				t.Fatalf("setting up repositories failed because of: %v", err)
+			}
+			defer release()
+			check.Run(t, repos)
+		})
+	}
+}
+
+// Exec runs the check against repositories returned by newRepos and returns
+// the failures it reported. Panics are reported as failures too.
+func (c Check) Exec(newRepos Factory) []string {
+	repos, release, err := newRepos()
+	if err != nil {
+		return []string{fmt.Sprintf("setting up repositories failed because of: %v", err)}
+	}
+	defer release()
+
+	r := new(recorder)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if v := recover(); v != nil {
+				r.failures = append(r.failures, fmt.Sprintf("panicked: %v", v))
+			}
+		}()
+		c.Run(r, repos)
+	}()
+	<-done
+	return r.failures
+}
+
+// recorder is the T checks are executed with outside of tests.
+// It's only used by the goroutine running the check.
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// Fatalf stops the check by exiting the goroutine running it.
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
@@ -0,0 +1,121 @@
+package conformance
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+)
+
+// getFeed is a helper function that gets the feed of the user.
+func getFeed(t T, repos *Repositories, username string) *feed.Feed {
+	t.Helper()
+	f, err := repos.Feed.GetFeed(ctx, username)
+	if err != nil {
+		t.Fatalf("getting the feed of %s failed because of: %v", username, err)
+	}
+	return f
+}
+
+var feedChecks = []Check{
+	{"feed/user feed", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		f := getFeed(t, repos, "alice")
+		if f.OwnerUsername != "alice" || f.Sorting != feed.SortHot {
+			t.Errorf("users are expected to get a hot feed, got %+v", f)
+		}
+		_, err := repos.Feed.GetFeed(ctx, "nobody")
+		expectErr(t, "GetFeed of a missing user", err, feed.ErrFeedNotFound)
+		err = repos.Feed.AddFeed(ctx, &feed.Feed{OwnerUsername: "nobody", Sorting: feed.SortNew})
+		expectErr(t, "AddFeed of a missing user", err, feed.ErrFeedNotFound)
+	}},
+	{"feed/update", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		f := getFeed(t, repos, "alice")
+		expectNoErr(t, "UpdateFeed", repos.Feed.UpdateFeed(ctx, f.ID, &feed.Feed{Sorting: feed.SortNew}))
+		if f = getFeed(t, repos, "alice"); f.Sorting != feed.SortNew {
+			t.Errorf("expected sorting %q, got %q", feed.SortNew, f.Sorting)
+		}
+		expectNoErr(t, "UpdateFeed", repos.Feed.UpdateFeed(ctx, f.ID, &feed.Feed{Sorting: "sideways"}))
+		if f = getFeed(t, repos, "alice"); f.Sorting != feed.SortTop {
+			t.Errorf("unknown sortings are expected to default to %q, got %q", feed.SortTop, f.Sorting)
+		}
+	}},
+	{"feed/subscriptions", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		addChannel(t, repos, "news", "The News", "bob")
+		f := getFeed(t, repos, "alice")
+		expectErr(t, "Subscribe to a missing channel", repos.Feed.Subscribe(ctx, f, "nowhere"), feed.ErrChannelNotFound)
+		expectNoErr(t, "Subscribe", repos.Feed.Subscribe(ctx, f, "news"))
+		tick()
+		expectNoErr(t, "Subscribe", repos.Feed.Subscribe(ctx, f, "bob"))
+		expectNoErr(t, "Subscribe to a subscribed channel", repos.Feed.Subscribe(ctx, f, "news"))
+		channelname := func(c *feed.Channel) string { return c.Channelname }
+
+		channels, err := repos.Feed.GetChannels(ctx, f, string(feed.SortBySubscriptionTime), string(feed.SortAscending))
+		expectNoErr(t, "GetChannels", err)
+		expectOrder(t, "by subscription time", keys(channels, channelname), "news", "bob")
+		for _, c := range channels {
+			if c.SubscriptionTime.IsZero() {
+				t.Errorf("GetChannels returned no subscription time for %s", c.Channelname)
+			}
+			if c.Channelname == "news" && c.Name != "The News" {
+				t.Errorf("GetChannels returned %+v", c)
+			}
+		}
+
+		channels, err = repos.Feed.GetChannels(ctx, f, string(feed.SortByUsername), string(feed.SortAscending))
+		expectNoErr(t, "GetChannels", err)
+		expectOrder(t, "by username", keys(channels, channelname), "bob", "news")
+
+		channels, err = repos.Feed.GetChannels(ctx, f, string(feed.SortByName), string(feed.SortDescending))
+		expectNoErr(t, "GetChannels", err)
+		expectOrder(t, "by name descending", keys(channels, channelname), "news", "bob")
+
+		expectNoErr(t, "Unsubscribe", repos.Feed.Unsubscribe(ctx, f, "news"))
+		expectNoErr(t, "Unsubscribe of an unsubscribed channel", repos.Feed.Unsubscribe(ctx, f, "news"))
+		channels, err = repos.Feed.GetChannels(ctx, f, string(feed.SortByUsername), string(feed.SortAscending))
+		expectNoErr(t, "GetChannels", err)
+		expectOrder(t, "after unsubscribing", keys(channels, channelname), "bob")
+	}},
+	{"feed/posts", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		addUser(t, repos, "carol", "")
+		starred := addPost(t, repos, "bob", "bob", "starred", "")
+		tick()
+		commented := addPost(t, repos, "bob", "bob", "commented", "")
+		tick()
+		unsubscribed := addPost(t, repos, "carol", "carol", "unsubscribed", "")
+		tick()
+		latest := addPost(t, repos, "bob", "bob", "latest", "")
+		_, err := repos.Post.AddPostStar(ctx, starred.ID, &post.Star{Username: "alice", NumOfStars: 3})
+		expectNoErr(t, "AddPostStar", err)
+		addComment(t, repos, "alice", commented.ID, -1, "first")
+		addComment(t, repos, "carol", unsubscribed.ID, -1, "first")
+
+		f := getFeed(t, repos, "alice")
+		expectNoErr(t, "Subscribe", repos.Feed.Subscribe(ctx, f, "bob"))
+		id := func(p *feed.Post) int { return p.ID }
+
+		posts, err := repos.Feed.GetPosts(ctx, f, feed.SortNew, -1, 0)
+		expectNoErr(t, "GetPosts", err)
+		expectOrder(t, "new posts", keys(posts, id), int(latest.ID), int(commented.ID), int(starred.ID))
+
+		posts, err = repos.Feed.GetPosts(ctx, f, feed.SortTop, -1, 0)
+		expectNoErr(t, "GetPosts", err)
+		expectOrder(t, "top posts", keys(posts, id), int(starred.ID), int(latest.ID), int(commented.ID))
+
+		posts, err = repos.Feed.GetPosts(ctx, f, feed.SortHot, -1, 0)
+		expectNoErr(t, "GetPosts", err)
+		expectOrder(t, "hot posts", keys(posts, id), int(commented.ID), int(latest.ID), int(starred.ID))
+
+		posts, err = repos.Feed.GetPosts(ctx, f, feed.SortNew, 2, 1)
+		expectNoErr(t, "GetPosts", err)
+		expectOrder(t, "second page of two new posts", keys(posts, id), int(commented.ID), int(starred.ID))
+
+		expectNoErr(t, "Unsubscribe", repos.Feed.Unsubscribe(ctx, f, "bob"))
+		posts, err = repos.Feed.GetPosts(ctx, f, feed.SortNew, -1, 0)
+		expectNoErr(t, "GetPosts", err)
+		expectOrder(t, "posts without subscriptions", keys(posts, id))
+	}},
+}
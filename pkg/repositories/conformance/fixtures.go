@@ -0,0 +1,153 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+// ctx is the context the checks pass to the repositories.
+var ctx = context.Background()
+
+// password is the password of the users the checks add.
+const password = "password"
+
+// missingID is an id no record is ever stored under.
+const missingID = 1 << 30
+
+// tick waits long enough for the records added next to be created later than
+// the ones added before, storages only keep creation times to the millisecond.
+func tick() {
+	time.Sleep(5 * time.Millisecond)
+}
+
+// addUser is a helper function that adds a user with the given username, an
+// email made out of it and the given first name, which may be empty.
+// Users get a channel and a feed of their own, under their username.
+func addUser(t T, repos *Repositories, username, firstName string) *user.User {
+	t.Helper()
+	u, err := repos.User.AddUser(ctx, &user.User{
+		Username:  username,
+		Email:     username + "@example.com",
+		FirstName: firstName,
+		Password:  password,
+	})
+	if err != nil {
+		t.Fatalf("adding user %s failed because of: %v", username, err)
+	}
+	return u
+}
+
+// addRelease is a helper function that adds a text release to the channel.
+func addRelease(t T, repos *Repositories, channelUsername, title, content string) *release.Release {
+	t.Helper()
+	r, err := repos.Release.AddRelease(ctx, &release.Release{
+		OwnerChannel: channelUsername,
+		Type:         release.Text,
+		Content:      content,
+		Metadata:     release.Metadata{Title: title},
+	})
+	if err != nil {
+		t.Fatalf("adding release %q failed because of: %v", title, err)
+	}
+	return r
+}
+
+// addPost is a helper function that adds a post by the user to the channel.
+func addPost(t T, repos *Repositories, username, channelUsername, title, description string, contents ...uint) *post.Post {
+	t.Helper()
+	p, err := repos.Post.AddPost(ctx, &post.Post{
+		PostedByUsername: username,
+		OriginChannel:    channelUsername,
+		Title:            title,
+		Description:      description,
+		ContentsID:       contents,
+	})
+	if err != nil {
+		t.Fatalf("adding post %q failed because of: %v", title, err)
+	}
+	return p
+}
+
+// addComment is a helper function that adds a comment by the user on the post.
+// replyTo is -1 for comments that aren't replies.
+func addComment(t T, repos *Repositories, username string, postID uint, replyTo int, content string) *comment.Comment {
+	t.Helper()
+	c, err := repos.Comment.AddComment(ctx, &comment.Comment{
+		OriginPost: int(postID),
+		Commenter:  username,
+		Content:    content,
+		ReplyTo:    replyTo,
+	})
+	if err != nil {
+		t.Fatalf("adding comment %q failed because of: %v", content, err)
+	}
+	return c
+}
+
+// expectErr is a helper function that reports the error unless it is, or wraps, the expected one.
+func expectErr(t T, what string, err, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("%s: expected error %q, got %v", what, expected, err)
+	}
+}
+
+// expectNoErr is a helper function that stops the check if there's an error.
+func expectNoErr(t T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s failed because of: %v", what, err)
+	}
+}
+
+// expectSameTime is a helper function that reports the times unless they're
+// the same to the second.
+func expectSameTime(t T, what string, got, expected time.Time) {
+	t.Helper()
+	if !got.Truncate(time.Second).Equal(expected.Truncate(time.Second)) {
+		t.Errorf("%s: expected %v, got %v", what, expected, got)
+	}
+}
+
+// expectOrder is a helper function that reports the keys unless they're the
+// expected ones in the expected order.
+func expectOrder[K comparable](t T, what string, got []K, expected ...K) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", what, expected, got)
+		return
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("%s: expected %v, got %v", what, expected, got)
+			return
+		}
+	}
+}
+
+// expectSet is a helper function that reports the IDs unless they're the
+// expected ones, in any order. Searches that rank their matches are only
+// expected to agree on what matches.
+func expectSet[ID ~int | ~uint](t T, what string, got []ID, expected ...ID) {
+	t.Helper()
+	sorted := append([]ID(nil), got...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+	expectOrder(t, what, sorted, expected...)
+}
+
+// keys is a helper function that returns the key of each of the items.
+func keys[I any, K comparable](items []I, key func(I) K) []K {
+	ks := make([]K, 0, len(items))
+	for _, item := range items {
+		ks = append(ks, key(item))
+	}
+	return ks
+}
@@ -0,0 +1,161 @@
+package conformance
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+)
+
+var postChecks = []Check{
+	{"post/add and get", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		first := addRelease(t, repos, "alice", "first", "one")
+		second := addRelease(t, repos, "alice", "second", "two")
+		added := addPost(t, repos, "alice", "alice", "Releases", "the first two", uint(second.ID), uint(first.ID))
+		p, err := repos.Post.GetPost(ctx, added.ID)
+		expectNoErr(t, "GetPost", err)
+		if p.ID != added.ID || p.PostedByUsername != "alice" || p.OriginChannel != "alice" || p.Title != "Releases" || p.Description != "the first two" {
+			t.Errorf("GetPost returned %+v", p)
+		}
+		expectSet(t, "contents", p.ContentsID, uint(first.ID), uint(second.ID))
+		if len(p.Stars) != 0 || len(p.CommentsID) != 0 {
+			t.Errorf("a new post is expected to have no stars or comments, got %+v", p)
+		}
+		if p.CreationTime.IsZero() {
+			t.Errorf("GetPost returned no creation time")
+		}
+	}},
+	{"post/get missing", func(t T, repos *Repositories) {
+		_, err := repos.Post.GetPost(ctx, missingID)
+		expectErr(t, "GetPost", err, post.ErrPostNotFound)
+	}},
+	{"post/add with missing references", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		_, err := repos.Post.AddPost(ctx, &post.Post{PostedByUsername: "nobody", OriginChannel: "alice", Title: "lost"})
+		expectErr(t, "AddPost by a missing user", err, post.ErrSomePostDataNotPersisted)
+		_, err = repos.Post.AddPost(ctx, &post.Post{PostedByUsername: "alice", OriginChannel: "nowhere", Title: "lost"})
+		expectErr(t, "AddPost to a missing channel", err, post.ErrSomePostDataNotPersisted)
+	}},
+	{"post/update", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		first := addRelease(t, repos, "alice", "first", "one")
+		second := addRelease(t, repos, "alice", "second", "two")
+		added := addPost(t, repos, "alice", "alice", "Releases", "the first", uint(first.ID))
+		p, err := repos.Post.UpdatePost(ctx, &post.Post{Title: "More releases", ContentsID: []uint{uint(second.ID)}}, added.ID)
+		expectNoErr(t, "UpdatePost", err)
+		if p.Title != "More releases" || p.Description != "the first" {
+			t.Errorf("only the set fields are expected to be updated, got %+v", p)
+		}
+		expectSet(t, "replaced contents", p.ContentsID, uint(second.ID))
+
+		p, err = repos.Post.UpdatePost(ctx, &post.Post{ContentsID: []uint{uint(first.ID), missingID}}, added.ID)
+		expectErr(t, "UpdatePost with a missing release", err, post.ErrSomePostDataNotPersisted)
+		p, err = repos.Post.GetPost(ctx, added.ID)
+		expectNoErr(t, "GetPost", err)
+		expectSet(t, "contents left as they were", p.ContentsID, uint(second.ID))
+
+		_, err = repos.Post.UpdatePost(ctx, &post.Post{Title: "lost"}, missingID)
+		expectErr(t, "UpdatePost of a missing post", err, post.ErrPostNotFound)
+	}},
+	{"post/delete", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		expectNoErr(t, "DeletePost", repos.Post.DeletePost(ctx, p.ID))
+		_, err := repos.Post.GetPost(ctx, p.ID)
+		expectErr(t, "GetPost of a deleted post", err, post.ErrPostNotFound)
+		expectNoErr(t, "DeletePost of a missing post", repos.Post.DeletePost(ctx, p.ID))
+	}},
+	{"post/delete with catalog", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		r := addRelease(t, repos, "alice", "story", "once upon a time")
+		p := addPost(t, repos, "alice", "alice", "story", "", uint(r.ID))
+		expectNoErr(t, "AddReleaseToOfficialCatalog", repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", uint(r.ID), p.ID))
+		if err := repos.Post.DeletePost(ctx, p.ID); err == nil {
+			t.Errorf("DeletePost of a post in a catalog succeeded")
+		}
+		_, err := repos.Post.GetPost(ctx, p.ID)
+		expectNoErr(t, "GetPost of a post that failed to be deleted", err)
+	}},
+	{"post/search", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		banana := addPost(t, repos, "alice", "alice", "banana", "a yellow fruit")
+		tick()
+		apple := addPost(t, repos, "bob", "bob", "Apple", "a red fruit")
+		tick()
+		cherry := addPost(t, repos, "alice", "bob", "cherry", "a red berry")
+		id := func(p *post.Post) uint { return p.ID }
+
+		posts, err := repos.Post.SearchPost(ctx, "", post.SortByTitle, post.SortAscending, -1, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectOrder(t, "by title ascending", keys(posts, id), apple.ID, banana.ID, cherry.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "", post.SortByCreationTime, post.SortDescending, -1, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectOrder(t, "by creation time descending", keys(posts, id), cherry.ID, apple.ID, banana.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "", post.SortByPoster, post.SortAscending, -1, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectOrder(t, "by poster", keys(posts, id), banana.ID, cherry.ID, apple.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "", post.SortByChannel, post.SortDescending, 2, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectOrder(t, "first page of two by channel descending", keys(posts, id), apple.ID, cherry.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "", post.SortByTitle, post.SortAscending, 2, 2)
+		expectNoErr(t, "SearchPost", err)
+		expectOrder(t, "second page of two", keys(posts, id), cherry.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "fruit", post.SortByTitle, post.SortAscending, -1, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectSet(t, "matching the pattern", keys(posts, id), apple.ID, banana.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "red fruit", "", post.SortAscending, -1, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectSet(t, "matching all the words of the pattern", keys(posts, id), apple.ID)
+
+		posts, err = repos.Post.SearchPost(ctx, "unicorn", "", post.SortAscending, -1, 0)
+		expectNoErr(t, "SearchPost", err)
+		expectSet(t, "matching nothing", keys(posts, id))
+	}},
+	{"post/stars", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		star, err := repos.Post.AddPostStar(ctx, p.ID, &post.Star{Username: "bob", NumOfStars: 3})
+		expectNoErr(t, "AddPostStar", err)
+		if star.Username != "bob" || star.NumOfStars != 3 {
+			t.Errorf("AddPostStar returned %+v", star)
+		}
+		_, err = repos.Post.AddPostStar(ctx, p.ID, &post.Star{Username: "bob", NumOfStars: 1})
+		expectErr(t, "AddPostStar of a starred post", err, post.ErrStarNotFound)
+		_, err = repos.Post.AddPostStar(ctx, missingID, &post.Star{Username: "bob", NumOfStars: 1})
+		expectErr(t, "AddPostStar of a missing post", err, post.ErrStarNotFound)
+		_, err = repos.Post.AddPostStar(ctx, p.ID, &post.Star{Username: "nobody", NumOfStars: 1})
+		expectErr(t, "AddPostStar by a missing user", err, post.ErrStarNotFound)
+
+		star, err = repos.Post.GetPostStar(ctx, p.ID, "bob")
+		expectNoErr(t, "GetPostStar", err)
+		if star.NumOfStars != 3 {
+			t.Errorf("GetPostStar returned %+v", star)
+		}
+		_, err = repos.Post.GetPostStar(ctx, p.ID, "alice")
+		expectErr(t, "GetPostStar of a user that didn't star", err, post.ErrStarNotFound)
+
+		star, err = repos.Post.UpdatePostStar(ctx, p.ID, &post.Star{Username: "bob", NumOfStars: 5})
+		expectNoErr(t, "UpdatePostStar", err)
+		if star.NumOfStars != 5 {
+			t.Errorf("UpdatePostStar returned %+v", star)
+		}
+		_, err = repos.Post.UpdatePostStar(ctx, p.ID, &post.Star{Username: "alice", NumOfStars: 5})
+		expectErr(t, "UpdatePostStar of a user that didn't star", err, post.ErrStarNotFound)
+		got, err := repos.Post.GetPost(ctx, p.ID)
+		expectNoErr(t, "GetPost", err)
+		if len(got.Stars) != 1 || got.Stars["bob"] != 5 {
+			t.Errorf("expected the stars of bob, got %v", got.Stars)
+		}
+
+		expectNoErr(t, "DeletePostStar", repos.Post.DeletePostStar(ctx, p.ID, "bob"))
+		expectNoErr(t, "DeletePostStar of a deleted star", repos.Post.DeletePostStar(ctx, p.ID, "bob"))
+		_, err = repos.Post.GetPostStar(ctx, p.ID, "bob")
+		expectErr(t, "GetPostStar of a deleted star", err, post.ErrStarNotFound)
+	}},
+}
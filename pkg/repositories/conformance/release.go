@@ -0,0 +1,143 @@
+package conformance
+
+import (
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+)
+
+var releaseChecks = []Check{
+	{"release/add and get", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		releaseDate := time.Date(2019, time.March, 14, 15, 9, 26, 0, time.UTC)
+		added, err := repos.Release.AddRelease(ctx, &release.Release{
+			OwnerChannel: "alice",
+			Type:         release.Image,
+			Content:      "cover.png",
+			Metadata: release.Metadata{
+				Title:         "Cover",
+				ReleaseDate:   releaseDate,
+				GenreDefining: "art",
+				Description:   "the cover",
+				Other:         release.Other{Authors: []string{"alice", "bob"}, Genres: []string{"art"}},
+			},
+		})
+		expectNoErr(t, "AddRelease", err)
+		r, err := repos.Release.GetRelease(ctx, added.ID)
+		expectNoErr(t, "GetRelease", err)
+		if r.ID != added.ID || r.OwnerChannel != "alice" || r.Type != release.Image || r.Content != "cover.png" {
+			t.Errorf("GetRelease returned %+v", r)
+		}
+		if r.Title != "Cover" || r.GenreDefining != "art" || r.Description != "the cover" {
+			t.Errorf("GetRelease returned metadata %+v", r.Metadata)
+		}
+		expectSameTime(t, "release date", r.ReleaseDate, releaseDate)
+		expectOrder(t, "authors", r.Authors, "alice", "bob")
+		expectOrder(t, "genres", r.Genres, "art")
+		if r.CreationTime.IsZero() {
+			t.Errorf("GetRelease returned no creation time")
+		}
+	}},
+	{"release/get missing", func(t T, repos *Repositories) {
+		_, err := repos.Release.GetRelease(ctx, missingID)
+		expectErr(t, "GetRelease", err, release.ErrReleaseNotFound)
+	}},
+	{"release/add to missing channel", func(t T, repos *Repositories) {
+		_, err := repos.Release.AddRelease(ctx, &release.Release{OwnerChannel: "nowhere", Type: release.Text, Content: "lost"})
+		expectErr(t, "AddRelease", err, release.ErrInvalidReleaseData)
+	}},
+	{"release/update", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		added, err := repos.Release.AddRelease(ctx, &release.Release{
+			OwnerChannel: "alice",
+			Type:         release.Text,
+			Content:      "once upon a time",
+			Metadata: release.Metadata{
+				Title:       "Story",
+				Description: "a story",
+				Other:       release.Other{Authors: []string{"alice"}},
+			},
+		})
+		expectNoErr(t, "AddRelease", err)
+		updated, err := repos.Release.UpdateRelease(ctx, &release.Release{
+			ID:       added.ID,
+			Type:     release.Text,
+			Content:  "happily ever after",
+			Metadata: release.Metadata{Title: "Ending", Other: release.Other{Genres: []string{"fairy tale"}}},
+		})
+		expectNoErr(t, "UpdateRelease", err)
+		if updated.ID != added.ID || updated.Content != "happily ever after" || updated.Title != "Ending" {
+			t.Errorf("UpdateRelease returned %+v", updated)
+		}
+		r, err := repos.Release.GetRelease(ctx, added.ID)
+		expectNoErr(t, "GetRelease", err)
+		if r.OwnerChannel != "alice" || r.Content != "happily ever after" || r.Title != "Ending" || r.Description != "a story" {
+			t.Errorf("only the set fields are expected to be updated, got %+v", r)
+		}
+		expectOrder(t, "replaced authors", r.Authors)
+		expectOrder(t, "replaced genres", r.Genres, "fairy tale")
+
+		_, err = repos.Release.UpdateRelease(ctx, &release.Release{ID: missingID, Metadata: release.Metadata{Title: "Lost"}})
+		expectErr(t, "UpdateRelease of a missing release", err, release.ErrReleaseNotFound)
+	}},
+	{"release/delete", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		r := addRelease(t, repos, "alice", "story", "once upon a time")
+		expectNoErr(t, "DeleteRelease", repos.Release.DeleteRelease(ctx, r.ID))
+		_, err := repos.Release.GetRelease(ctx, r.ID)
+		expectErr(t, "GetRelease of a deleted release", err, release.ErrReleaseNotFound)
+		expectNoErr(t, "DeleteRelease of a missing release", repos.Release.DeleteRelease(ctx, r.ID))
+	}},
+	{"release/search", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		first := addRelease(t, repos, "alice", "first", "the quick fox")
+		tick()
+		second, err := repos.Release.AddRelease(ctx, &release.Release{
+			OwnerChannel: "alice",
+			Type:         release.Image,
+			Content:      "second.png",
+			Metadata:     release.Metadata{Title: "second"},
+		})
+		expectNoErr(t, "AddRelease", err)
+		tick()
+		third := addRelease(t, repos, "alice", "third", "the lazy dog")
+		tick()
+		draft := addRelease(t, repos, "alice", "draft", "the quick draft")
+		p := addPost(t, repos, "alice", "alice", "releases", "", uint(first.ID), uint(second.ID), uint(third.ID), uint(draft.ID))
+		for _, r := range []*release.Release{third, first, second} {
+			expectNoErr(t, "AddReleaseToOfficialCatalog", repos.Channel.AddReleaseToOfficialCatalog(ctx, "alice", uint(r.ID), p.ID))
+		}
+		id := func(r *release.Release) int { return r.ID }
+
+		releases, err := repos.Release.SearchRelease(ctx, "", release.SortCreationTime, release.SortAscending, -1, 0)
+		expectNoErr(t, "SearchRelease", err)
+		expectOrder(t, "catalogued releases by creation time", keys(releases, id), first.ID, second.ID, third.ID)
+
+		releases, err = repos.Release.SearchRelease(ctx, "", release.SortByType, release.SortAscending, -1, 0)
+		expectNoErr(t, "SearchRelease", err)
+		expectOrder(t, "by type ascending", keys(releases, id), second.ID, first.ID, third.ID)
+
+		releases, err = repos.Release.SearchRelease(ctx, "", release.SortByType, release.SortDescending, -1, 0)
+		expectNoErr(t, "SearchRelease", err)
+		expectOrder(t, "by type descending", keys(releases, id), first.ID, third.ID, second.ID)
+
+		releases, err = repos.Release.SearchRelease(ctx, "", release.SortCreationTime, release.SortDescending, 1, 1)
+		expectNoErr(t, "SearchRelease", err)
+		expectOrder(t, "second page of one", keys(releases, id), second.ID)
+		if len(releases) == 1 && (releases[0].Content != "second.png" || releases[0].Title != "second") {
+			t.Errorf("SearchRelease returned %+v", releases[0])
+		}
+
+		releases, err = repos.Release.SearchRelease(ctx, "quick", release.SortCreationTime, release.SortAscending, -1, 0)
+		expectNoErr(t, "SearchRelease", err)
+		expectSet(t, "catalogued releases matching the pattern", keys(releases, id), first.ID)
+
+		releases, err = repos.Release.SearchRelease(ctx, "the", "", release.SortAscending, -1, 0)
+		expectNoErr(t, "SearchRelease", err)
+		expectSet(t, "catalogued releases matching a common word", keys(releases, id), first.ID, third.ID)
+
+		releases, err = repos.Release.SearchRelease(ctx, "unicorn", "", release.SortAscending, -1, 0)
+		expectNoErr(t, "SearchRelease", err)
+		expectSet(t, "releases matching nothing", keys(releases, id))
+	}},
+}
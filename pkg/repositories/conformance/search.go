@@ -0,0 +1,40 @@
+package conformance
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+)
+
+var searchChecks = []Check{
+	{"search/comments", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		question := addComment(t, repos, "alice", p.ID, -1, "where is the red fox")
+		answer := addComment(t, repos, "bob", p.ID, question.ID, "the fox went home")
+		addComment(t, repos, "bob", p.ID, -1, "nice post")
+		id := func(c *search.Comment) int { return c.ID }
+
+		comments, err := repos.Search.SearchComments(ctx, "fox", "", "", -1, 0)
+		expectNoErr(t, "SearchComments", err)
+		expectSet(t, "matching the word", keys(comments, id), question.ID, answer.ID)
+		for _, c := range comments {
+			if c.ID == answer.ID && (c.OriginPost != int(p.ID) || c.ReplyTo != question.ID || c.Commenter != "bob" || c.Content != "the fox went home") {
+				t.Errorf("SearchComments returned %+v", c)
+			}
+		}
+
+		comments, err = repos.Search.SearchComments(ctx, "red fox", "", "", -1, 0)
+		expectNoErr(t, "SearchComments", err)
+		expectSet(t, "matching all the words", keys(comments, id), question.ID)
+
+		comments, err = repos.Search.SearchComments(ctx, "fox", "", "", 1, 0)
+		expectNoErr(t, "SearchComments", err)
+		if len(comments) != 1 {
+			t.Errorf("expected a page of one, got %v", keys(comments, id))
+		}
+
+		comments, err = repos.Search.SearchComments(ctx, "unicorn", "", "", -1, 0)
+		expectNoErr(t, "SearchComments", err)
+		expectSet(t, "matching nothing", keys(comments, id))
+	}},
+}
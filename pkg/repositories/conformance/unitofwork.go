@@ -0,0 +1,91 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+)
+
+// errAbort is returned by the units of work the checks want rolled back.
+var errAbort = errors.New("aborted")
+
+var unitOfWorkChecks = []Check{
+	{"unitofwork/commit", func(t T, repos *Repositories) {
+		var r *release.Release
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			_, err := repos.User.AddUser(ctx, &user.User{Username: "alice", Email: "alice@example.com", Password: password})
+			if err != nil {
+				return err
+			}
+			r, err = repos.Release.AddRelease(ctx, &release.Release{OwnerChannel: "alice", Type: release.Text, Content: "kept"})
+			return err
+		})
+		expectNoErr(t, "Do", err)
+		_, err = repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		_, err = repos.Release.GetRelease(ctx, r.ID)
+		expectNoErr(t, "GetRelease", err)
+	}},
+	{"unitofwork/rollback", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "Alice")
+		var r *release.Release
+		compensated := 0
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			_, err := repos.User.UpdateUser(ctx, "alice", &user.User{FirstName: "Liddell"})
+			if err != nil {
+				return err
+			}
+			r, err = repos.Release.AddRelease(ctx, &release.Release{OwnerChannel: "alice", Type: release.Text, Content: "lost"})
+			if err != nil {
+				return err
+			}
+			unitofwork.OnRollback(ctx, func() { compensated++ })
+			return errAbort
+		})
+		expectErr(t, "Do", err, errAbort)
+		if compensated != 1 {
+			t.Errorf("functions registered with OnRollback are expected to be called once, got %d calls", compensated)
+		}
+		u, err := repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if u.FirstName != "Alice" {
+			t.Errorf("updates made in a rolled back unit of work are expected to be undone, got %+v", u)
+		}
+		_, err = repos.Release.GetRelease(ctx, r.ID)
+		expectErr(t, "GetRelease of a rolled back release", err, release.ErrReleaseNotFound)
+	}},
+	{"unitofwork/join", func(t T, repos *Repositories) {
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+				_, err := repos.User.AddUser(ctx, &user.User{Username: "alice", Email: "alice@example.com", Password: password})
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			return errAbort
+		})
+		expectErr(t, "Do", err, errAbort)
+		_, err = repos.User.GetUser(ctx, "alice")
+		expectErr(t, "GetUser of a user added in a joined unit of work", err, user.ErrUserNotFound)
+	}},
+	{"unitofwork/failed call", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		err := repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			_, err := repos.User.UpdateUser(ctx, "bob", &user.User{Email: "alice@example.com", FirstName: "Lost"})
+			expectErr(t, "UpdateUser to a taken email", err, user.ErrSomeUserDataNotPersisted)
+			_, err = repos.User.UpdateUser(ctx, "bob", &user.User{LastName: "Kept"})
+			return err
+		})
+		expectNoErr(t, "Do", err)
+		u, err := repos.User.GetUser(ctx, "bob")
+		expectNoErr(t, "GetUser", err)
+		if u.Email != "bob@example.com" || u.FirstName != "" || u.LastName != "Kept" {
+			t.Errorf("a failed call is expected to be undone without the rest of the unit of work, got %+v", u)
+		}
+	}},
+}
@@ -0,0 +1,216 @@
+package conformance
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+var userChecks = []Check{
+	{"user/add and get", func(t T, repos *Repositories) {
+		added := addUser(t, repos, "alice", "Alice")
+		if added.Username != "alice" || added.Email != "alice@example.com" || added.FirstName != "Alice" {
+			t.Errorf("AddUser returned %+v", added)
+		}
+		u, err := repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if u.Username != "alice" || u.Email != "alice@example.com" || u.FirstName != "Alice" || u.LastName != "" {
+			t.Errorf("GetUser returned %+v", u)
+		}
+		if u.CreationTime.IsZero() {
+			t.Errorf("GetUser returned no creation time")
+		}
+		if u.Verified {
+			t.Errorf("new users aren't expected to be verified")
+		}
+		if u.Password != "" {
+			t.Errorf("GetUser returned the password")
+		}
+	}},
+	{"user/get missing", func(t T, repos *Repositories) {
+		_, err := repos.User.GetUser(ctx, "nobody")
+		expectErr(t, "GetUser", err, user.ErrUserNotFound)
+	}},
+	{"user/duplicate", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		_, err := repos.User.AddUser(ctx, &user.User{Username: "alice", Email: "other@example.com", Password: password})
+		if err == nil {
+			t.Errorf("AddUser of a taken username succeeded")
+		}
+		_, err = repos.User.AddUser(ctx, &user.User{Username: "other", Email: "alice@example.com", Password: password})
+		if err == nil {
+			t.Errorf("AddUser of a taken email succeeded")
+		}
+		occupied, err := repos.User.UsernameOccupied(ctx, "alice")
+		expectNoErr(t, "UsernameOccupied", err)
+		if !occupied {
+			t.Errorf("UsernameOccupied of a user is false")
+		}
+		occupied, err = repos.User.UsernameOccupied(ctx, "nobody")
+		expectNoErr(t, "UsernameOccupied", err)
+		if occupied {
+			t.Errorf("UsernameOccupied of a free username is true")
+		}
+		occupied, err = repos.User.EmailOccupied(ctx, "alice@example.com")
+		expectNoErr(t, "EmailOccupied", err)
+		if !occupied {
+			t.Errorf("EmailOccupied of a user's email is false")
+		}
+	}},
+	{"user/update", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "Alice")
+		expectNoErr(t, "MarkVerified", repos.User.MarkVerified(ctx, "alice"))
+		u, err := repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if !u.Verified {
+			t.Errorf("MarkVerified didn't verify the user")
+		}
+
+		u, err = repos.User.UpdateUser(ctx, "alice", &user.User{LastName: "Liddell", Bio: "down the hole"})
+		expectNoErr(t, "UpdateUser", err)
+		if u.FirstName != "Alice" || u.LastName != "Liddell" || u.Bio != "down the hole" || !u.Verified {
+			t.Errorf("UpdateUser of the last name and bio returned %+v", u)
+		}
+
+		_, err = repos.User.UpdateUser(ctx, "alice", &user.User{Email: "liddell@example.com"})
+		expectNoErr(t, "UpdateUser", err)
+		u, err = repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if u.Email != "liddell@example.com" || u.Verified {
+			t.Errorf("changed emails are expected to be unverified, got %+v", u)
+		}
+
+		_, err = repos.User.UpdateUser(ctx, "nobody", &user.User{FirstName: "No"})
+		expectErr(t, "UpdateUser of a missing user", err, user.ErrUserNotFound)
+	}},
+	{"user/update to taken email", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "bob", "")
+		_, err := repos.User.UpdateUser(ctx, "bob", &user.User{Email: "alice@example.com", FirstName: "Bob"})
+		expectErr(t, "UpdateUser", err, user.ErrSomeUserDataNotPersisted)
+		u, err := repos.User.GetUser(ctx, "bob")
+		expectNoErr(t, "GetUser", err)
+		if u.Email != "bob@example.com" || u.FirstName != "" {
+			t.Errorf("updates are expected to be all or nothing, got %+v", u)
+		}
+	}},
+	{"user/rename", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "Alice")
+		u, err := repos.User.UpdateUser(ctx, "alice", &user.User{Username: "liddell"})
+		expectNoErr(t, "UpdateUser", err)
+		if u.Username != "liddell" {
+			t.Errorf("UpdateUser returned %+v", u)
+		}
+		_, err = repos.User.GetUser(ctx, "alice")
+		expectErr(t, "GetUser of the old username", err, user.ErrUserNotFound)
+		u, err = repos.User.GetUser(ctx, "liddell")
+		expectNoErr(t, "GetUser of the new username", err)
+		if u.FirstName != "Alice" {
+			t.Errorf("GetUser returned %+v", u)
+		}
+	}},
+	{"user/delete", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		expectNoErr(t, "DeleteUser", repos.User.DeleteUser(ctx, "alice"))
+		_, err := repos.User.GetUser(ctx, "alice")
+		expectErr(t, "GetUser of a deleted user", err, user.ErrUserNotFound)
+		expectNoErr(t, "DeleteUser of a missing user", repos.User.DeleteUser(ctx, "alice"))
+	}},
+	{"user/search", func(t T, repos *Repositories) {
+		addUser(t, repos, "carol", "Carol")
+		addUser(t, repos, "alice", "")
+		addUser(t, repos, "Bob", "bob")
+		addUser(t, repos, "dave", "Dave")
+		username := func(u *user.User) string { return u.Username }
+
+		users, err := repos.User.SearchUser(ctx, "", string(user.SortByUsername), string(user.SortAscending), -1, 0)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "by username ascending", keys(users, username), "alice", "Bob", "carol", "dave")
+
+		users, err = repos.User.SearchUser(ctx, "", string(user.SortByUsername), string(user.SortDescending), -1, 0)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "by username descending", keys(users, username), "dave", "carol", "Bob", "alice")
+
+		users, err = repos.User.SearchUser(ctx, "", string(user.SortByFirstName), string(user.SortDescending), -1, 0)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "by first name descending, missing last", keys(users, username), "dave", "carol", "Bob", "alice")
+
+		users, err = repos.User.SearchUser(ctx, "", string(user.SortByCreationTime), string(user.SortAscending), -1, 0)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "by creation time", keys(users, username), "carol", "alice", "Bob", "dave")
+
+		users, err = repos.User.SearchUser(ctx, "", string(user.SortByUsername), string(user.SortAscending), 2, 1)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "second page of two", keys(users, username), "Bob", "carol")
+
+		users, err = repos.User.SearchUser(ctx, "", string(user.SortByUsername), string(user.SortAscending), 2, 4)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "page past the end", keys(users, username))
+
+		users, err = repos.User.SearchUser(ctx, "AR", string(user.SortByUsername), string(user.SortAscending), -1, 0)
+		expectNoErr(t, "SearchUser", err)
+		expectOrder(t, "matching the pattern", keys(users, username), "carol")
+	}},
+	{"user/authenticate", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		ok, err := repos.User.Authenticate(ctx, &user.User{Username: "alice", Password: password})
+		expectNoErr(t, "Authenticate", err)
+		if !ok {
+			t.Errorf("Authenticate with the right password failed")
+		}
+		ok, err = repos.User.Authenticate(ctx, &user.User{Email: "alice@example.com", Password: password})
+		expectNoErr(t, "Authenticate", err)
+		if !ok {
+			t.Errorf("Authenticate by email with the right password failed")
+		}
+		ok, err = repos.User.Authenticate(ctx, &user.User{Username: "alice", Password: "wrong"})
+		expectNoErr(t, "Authenticate", err)
+		if ok {
+			t.Errorf("Authenticate with the wrong password succeeded")
+		}
+		ok, _ = repos.User.Authenticate(ctx, &user.User{Username: "nobody", Password: password})
+		if ok {
+			t.Errorf("Authenticate of a missing user succeeded")
+		}
+	}},
+	{"user/bookmarks", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		p := addPost(t, repos, "alice", "alice", "first", "")
+		expectNoErr(t, "BookmarkPost", repos.User.BookmarkPost(ctx, "alice", int(p.ID)))
+		expectNoErr(t, "BookmarkPost of a bookmarked post", repos.User.BookmarkPost(ctx, "alice", int(p.ID)))
+		u, err := repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if len(u.BookmarkedPosts) != 1 {
+			t.Errorf("expected one bookmark, got %v", u.BookmarkedPosts)
+		}
+		for _, postID := range u.BookmarkedPosts {
+			if postID != int(p.ID) {
+				t.Errorf("expected post %d bookmarked, got %d", p.ID, postID)
+			}
+		}
+		expectErr(t, "BookmarkPost of a missing post", repos.User.BookmarkPost(ctx, "alice", missingID), user.ErrPostNotFound)
+		expectErr(t, "BookmarkPost of a missing user", repos.User.BookmarkPost(ctx, "nobody", int(p.ID)), user.ErrUserNotFound)
+
+		expectNoErr(t, "DeleteBookmark", repos.User.DeleteBookmark(ctx, "alice", int(p.ID)))
+		u, err = repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if len(u.BookmarkedPosts) != 0 {
+			t.Errorf("expected no bookmarks, got %v", u.BookmarkedPosts)
+		}
+	}},
+	{"user/picture", func(t T, repos *Repositories) {
+		addUser(t, repos, "alice", "")
+		expectNoErr(t, "AddPicture", repos.User.AddPicture(ctx, "alice", "alice.png"))
+		expectNoErr(t, "AddPicture", repos.User.AddPicture(ctx, "alice", "alice.jpg"))
+		u, err := repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if u.PictureURL != "alice.jpg" {
+			t.Errorf("expected picture alice.jpg, got %q", u.PictureURL)
+		}
+		expectNoErr(t, "RemovePicture", repos.User.RemovePicture(ctx, "alice"))
+		u, err = repos.User.GetUser(ctx, "alice")
+		expectNoErr(t, "GetUser", err)
+		if u.PictureURL != "" {
+			t.Errorf("expected no picture, got %q", u.PictureURL)
+		}
+		expectErr(t, "AddPicture of a missing user", repos.User.AddPicture(ctx, "nobody", "x.png"), user.ErrUserNotFound)
+	}},
+}
@@ -0,0 +1,552 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+)
+
+type authRepository repository
+
+// NewAuthRepository returns a struct that implements the auth.Repository
+// over the given Store.
+func NewAuthRepository(store *Store) auth.Repository {
+	return &authRepository{store}
+}
+
+// Authenticate checks the given password against the pass hash found in the store for the user.
+// The user is looked up by username or, if that's not given, by email.
+// On success, the Username of the passed user is set to that found in the store.
+func (repo *authRepository) Authenticate(ctx context.Context, u *auth.User) (bool, error) {
+	s := repo.store
+	unlock := s.readLock()
+	var found *userRecord
+	for _, record := range s.users {
+		if (u.Username != "" && record.username == u.Username) || (u.Username == "" && record.email == u.Email) {
+			found = record
+			break
+		}
+	}
+	if found == nil {
+		unlock()
+		return false, auth.ErrUserNotFound
+	}
+	username, passHash := found.username, found.passHash
+	unlock()
+
+	err := bcrypt.CompareHashAndPassword([]byte(passHash), []byte(u.Password))
+	switch err {
+	case nil:
+		u.Username = username
+		return true, nil
+	case bcrypt.ErrHashTooShort:
+		return false, err
+	case bcrypt.ErrMismatchedHashAndPassword:
+		return false, nil
+	default:
+		return false, fmt.Errorf("bcrypt hash compare failed because: %w", err)
+	}
+}
+
+// AddToBlacklist blacklists the given token id until expiresAt.
+// Entries that have already expired are pruned on the way.
+func (repo *authRepository) AddToBlacklist(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s := repo.store
+	defer s.writeLock(ctx, blacklistTable)()
+
+	s.blacklist[tokenID] = expiresAt
+	now := time.Now()
+	for id, t := range s.blacklist {
+		if !t.After(now) {
+			delete(s.blacklist, id)
+		}
+	}
+	return nil
+}
+
+// IsInBlacklist checks whether a given token id is blacklisted and hasn't expired.
+func (repo *authRepository) IsInBlacklist(ctx context.Context, tokenID string) (bool, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	expiresAt, ok := s.blacklist[tokenID]
+	return ok && expiresAt.After(time.Now()), nil
+}
+
+// AddRefreshToken persists the given refresh token record.
+// Refresh tokens that have expired are pruned on the way.
+func (repo *authRepository) AddRefreshToken(ctx context.Context, rt *auth.RefreshToken) error {
+	s := repo.store
+	defer s.writeLock(ctx, refreshTokensTable)()
+
+	if _, ok := s.refreshTokens[rt.TokenHash]; ok {
+		return fmt.Errorf("insertion into refresh_tokens failed because of: token already exists")
+	}
+	if _, ok := s.users[rt.Username]; !ok {
+		return fmt.Errorf("insertion into refresh_tokens failed because of: %w", auth.ErrUserNotFound)
+	}
+	stored := *rt
+	s.refreshTokens[rt.TokenHash] = &stored
+	now := time.Now()
+	for hash, token := range s.refreshTokens {
+		if !token.ExpiresAt.After(now) {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	return nil
+}
+
+// GetRefreshToken retrieves the refresh token record stored under the given hash.
+func (repo *authRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return nil, auth.ErrRefreshTokenNotFound
+	}
+	found := *rt
+	return &found, nil
+}
+
+// MarkRefreshTokenUsed marks the refresh token under the given hash as used.
+// It returns false if the token was already marked as used before the call.
+func (repo *authRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	s := repo.store
+	defer s.writeLock(ctx, refreshTokensTable)()
+
+	rt, ok := s.refreshTokens[tokenHash]
+	if !ok || rt.Used {
+		return false, nil
+	}
+	rt.Used = true
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily revokes all the refresh tokens belonging to the given family.
+func (repo *authRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	s := repo.store
+	defer s.writeLock(ctx, refreshTokensTable)()
+
+	for _, rt := range s.refreshTokens {
+		if rt.FamilyID == familyID {
+			rt.Revoked = true
+		}
+	}
+	return nil
+}
+
+// GetUser retrieves the username and email of the user identified by the given username or email.
+func (repo *authRepository) GetUser(ctx context.Context, identifier string) (*auth.User, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	for _, u := range s.users {
+		if u.username == identifier || u.email == identifier {
+			return &auth.User{Username: u.username, Email: u.email}, nil
+		}
+	}
+	return nil, auth.ErrUserNotFound
+}
+
+// AddPasswordResetToken persists the given password reset token record.
+// Any other token the user has been issued is invalidated and expired ones are pruned on the way.
+func (repo *authRepository) AddPasswordResetToken(ctx context.Context, prt *auth.PasswordResetToken) error {
+	s := repo.store
+	defer s.writeLock(ctx, passwordResetTokensTable)()
+
+	now := time.Now()
+	for hash, token := range s.passwordResetTokens {
+		if token.Username == prt.Username || !token.ExpiresAt.After(now) {
+			delete(s.passwordResetTokens, hash)
+		}
+	}
+	if _, ok := s.users[prt.Username]; !ok {
+		return fmt.Errorf("insertion into password_reset_tokens failed because of: %w", auth.ErrUserNotFound)
+	}
+	stored := *prt
+	s.passwordResetTokens[prt.TokenHash] = &stored
+	return nil
+}
+
+// ConsumePasswordResetToken marks the password reset token under the given hash as used
+// and returns it. Tokens that are already used or have expired can't be consumed.
+func (repo *authRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*auth.PasswordResetToken, error) {
+	s := repo.store
+	defer s.writeLock(ctx, passwordResetTokensTable)()
+
+	prt, ok := s.passwordResetTokens[tokenHash]
+	if !ok || prt.Used || !prt.ExpiresAt.After(time.Now()) {
+		return nil, auth.ErrInvalidPasswordResetToken
+	}
+	prt.Used = true
+	consumed := *prt
+	return &consumed, nil
+}
+
+// SetPassword stores the bcrypt hash of the given password for the user.
+func (repo *authRepository) SetPassword(ctx context.Context, username, password string) error {
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("unable to generate bcrypt has because: %w", err)
+	}
+	s := repo.store
+	defer s.writeLock(ctx, usersTable)()
+
+	u, ok := s.users[username]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+	u.passHash = string(passHash)
+	return nil
+}
+
+// AddEmailVerificationToken persists the given email verification token record.
+// Any other token the user has been issued is invalidated and expired ones are pruned on the way
+// but the creation time of the latest one is kept around for throttling.
+func (repo *authRepository) AddEmailVerificationToken(ctx context.Context, evt *auth.EmailVerificationToken) error {
+	s := repo.store
+	defer s.writeLock(ctx, emailVerificationTokensTable)()
+
+	now := time.Now()
+	for hash, token := range s.emailVerificationTokens {
+		switch {
+		case token.Username == evt.Username:
+			token.Used = true
+		case !token.ExpiresAt.After(now):
+			delete(s.emailVerificationTokens, hash)
+		}
+	}
+	if _, ok := s.users[evt.Username]; !ok {
+		return fmt.Errorf("insertion into email_verification_tokens failed because of: %w", auth.ErrUserNotFound)
+	}
+	stored := *evt
+	s.emailVerificationTokens[evt.TokenHash] = &stored
+	return nil
+}
+
+// GetLastEmailVerificationTime returns the time the latest email verification token was issued
+// for the user or the zero time if none has been.
+func (repo *authRepository) GetLastEmailVerificationTime(ctx context.Context, username string) (time.Time, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	var last time.Time
+	for _, token := range s.emailVerificationTokens {
+		if token.Username == username && token.CreationTime.After(last) {
+			last = token.CreationTime
+		}
+	}
+	return last, nil
+}
+
+// ConsumeEmailVerificationToken marks the email verification token under the given hash as used
+// and returns it. Tokens that are already used, have expired or were issued for an email
+// the user no longer has can't be consumed.
+func (repo *authRepository) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (*auth.EmailVerificationToken, error) {
+	s := repo.store
+	defer s.writeLock(ctx, emailVerificationTokensTable)()
+
+	evt, ok := s.emailVerificationTokens[tokenHash]
+	if !ok || evt.Used || !evt.ExpiresAt.After(time.Now()) {
+		return nil, auth.ErrInvalidEmailVerificationToken
+	}
+	if u, ok := s.users[evt.Username]; !ok || u.email != evt.Email {
+		return nil, auth.ErrInvalidEmailVerificationToken
+	}
+	evt.Used = true
+	consumed := *evt
+	return &consumed, nil
+}
+
+// SetTOTPSecret persists the given TOTP enrolment along with its recovery codes,
+// replacing any previous enrolment of the user.
+func (repo *authRepository) SetTOTPSecret(ctx context.Context, ts *auth.TOTPSecret) error {
+	s := repo.store
+	defer s.writeLock(ctx, totpSecretsTable)()
+
+	if _, ok := s.users[ts.Username]; !ok {
+		return fmt.Errorf("upsertion into totp_secrets failed because of: %w", auth.ErrUserNotFound)
+	}
+	stored := *ts
+	stored.RecoveryCodeHashes = append([]string(nil), ts.RecoveryCodeHashes...)
+	s.totpSecrets[ts.Username] = &stored
+	return nil
+}
+
+// GetTOTPSecret retrieves the TOTP enrolment of the user.
+// The hashes of the recovery codes that are yet to be used are included.
+func (repo *authRepository) GetTOTPSecret(ctx context.Context, username string) (*auth.TOTPSecret, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	ts, ok := s.totpSecrets[username]
+	if !ok {
+		return nil, auth.ErrTOTPNotEnrolled
+	}
+	found := *ts
+	found.RecoveryCodeHashes = append([]string(nil), ts.RecoveryCodeHashes...)
+	return &found, nil
+}
+
+// ConfirmTOTPSecret marks the TOTP enrolment of the user as confirmed.
+func (repo *authRepository) ConfirmTOTPSecret(ctx context.Context, username string) error {
+	s := repo.store
+	defer s.writeLock(ctx, totpSecretsTable)()
+
+	ts, ok := s.totpSecrets[username]
+	if !ok {
+		return auth.ErrTOTPNotEnrolled
+	}
+	ts.Confirmed = true
+	return nil
+}
+
+// DeleteTOTPSecret removes the TOTP enrolment of the user along with its recovery codes.
+func (repo *authRepository) DeleteTOTPSecret(ctx context.Context, username string) error {
+	s := repo.store
+	defer s.writeLock(ctx, totpSecretsTable)()
+
+	delete(s.totpSecrets, username)
+	return nil
+}
+
+// UpdateTOTPLastUsedStep records the time step of the last accepted TOTP code of the user.
+// It returns false if a code of the same or a later step has already been accepted.
+func (repo *authRepository) UpdateTOTPLastUsedStep(ctx context.Context, username string, step int64) (bool, error) {
+	s := repo.store
+	defer s.writeLock(ctx, totpSecretsTable)()
+
+	ts, ok := s.totpSecrets[username]
+	if !ok || ts.LastUsedStep >= step {
+		return false, nil
+	}
+	ts.LastUsedStep = step
+	return true, nil
+}
+
+// ConsumeTOTPRecoveryCode marks the recovery code of the user under the given hash as used.
+// It returns false if there's no such code or it has already been used.
+func (repo *authRepository) ConsumeTOTPRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	s := repo.store
+	defer s.writeLock(ctx, totpSecretsTable)()
+
+	ts, ok := s.totpSecrets[username]
+	if !ok {
+		return false, nil
+	}
+	for i, hash := range ts.RecoveryCodeHashes {
+		if hash == codeHash {
+			ts.RecoveryCodeHashes = append(ts.RecoveryCodeHashes[:i:i], ts.RecoveryCodeHashes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddPersonalAccessToken persists the given personal access token record.
+func (repo *authRepository) AddPersonalAccessToken(ctx context.Context, pat *auth.PersonalAccessToken) error {
+	s := repo.store
+	defer s.writeLock(ctx, personalAccessTokensTable)()
+
+	if _, ok := s.users[pat.Username]; !ok {
+		return fmt.Errorf("insertion into personal_access_tokens failed because of: %w", auth.ErrUserNotFound)
+	}
+	for _, stored := range s.personalAccessTokens {
+		if stored.ID == pat.ID || stored.TokenHash == pat.TokenHash {
+			return fmt.Errorf("insertion into personal_access_tokens failed because of: token already exists")
+		}
+	}
+	stored := copyPersonalAccessToken(pat)
+	stored.Token = ""
+	s.personalAccessTokens[pat.TokenHash] = stored
+	return nil
+}
+
+// copyPersonalAccessToken is a helper function that deep copies the given record.
+func copyPersonalAccessToken(pat *auth.PersonalAccessToken) *auth.PersonalAccessToken {
+	c := *pat
+	c.Scopes = append([]string(nil), pat.Scopes...)
+	if pat.ExpiresAt != nil {
+		expiresAt := *pat.ExpiresAt
+		c.ExpiresAt = &expiresAt
+	}
+	if pat.LastUsedTime != nil {
+		lastUsedTime := *pat.LastUsedTime
+		c.LastUsedTime = &lastUsedTime
+	}
+	return &c
+}
+
+// GetPersonalAccessToken retrieves the personal access token record stored under the given hash.
+func (repo *authRepository) GetPersonalAccessToken(ctx context.Context, tokenHash string) (*auth.PersonalAccessToken, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	pat, ok := s.personalAccessTokens[tokenHash]
+	if !ok {
+		return nil, auth.ErrPersonalAccessTokenNotFound
+	}
+	return copyPersonalAccessToken(pat), nil
+}
+
+// GetPersonalAccessTokens retrieves the records of the personal access tokens of the
+// user that haven't been revoked, newest first.
+func (repo *authRepository) GetPersonalAccessTokens(ctx context.Context, username string) ([]*auth.PersonalAccessToken, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	pats := make([]*auth.PersonalAccessToken, 0)
+	for _, pat := range s.personalAccessTokens {
+		if pat.Username == username && !pat.Revoked {
+			pats = append(pats, copyPersonalAccessToken(pat))
+		}
+	}
+	orderBy(pats, "DESC", func(pat *auth.PersonalAccessToken) interface{} { return pat.CreationTime })
+	return pats, nil
+}
+
+// RevokePersonalAccessToken marks the personal access token of the user with the given id as revoked.
+func (repo *authRepository) RevokePersonalAccessToken(ctx context.Context, username, id string) error {
+	s := repo.store
+	defer s.writeLock(ctx, personalAccessTokensTable)()
+
+	for _, pat := range s.personalAccessTokens {
+		if pat.Username == username && pat.ID == id && !pat.Revoked {
+			pat.Revoked = true
+			return nil
+		}
+	}
+	return auth.ErrPersonalAccessTokenNotFound
+}
+
+// UpdatePersonalAccessTokenLastUsed records the time the personal access token was last used.
+func (repo *authRepository) UpdatePersonalAccessTokenLastUsed(ctx context.Context, id string, lastUsedTime time.Time) error {
+	s := repo.store
+	defer s.writeLock(ctx, personalAccessTokensTable)()
+
+	for _, pat := range s.personalAccessTokens {
+		if pat.ID == id {
+			pat.LastUsedTime = &lastUsedTime
+		}
+	}
+	return nil
+}
+
+// AddSession persists the given session record.
+// Sessions that have expired are pruned on the way.
+func (repo *authRepository) AddSession(ctx context.Context, session *auth.Session) error {
+	s := repo.store
+	defer s.writeLock(ctx, sessionsTable)()
+
+	if _, ok := s.sessions[session.ID]; ok {
+		return fmt.Errorf("insertion into sessions failed because of: session already exists")
+	}
+	if _, ok := s.users[session.Username]; !ok {
+		return fmt.Errorf("insertion into sessions failed because of: %w", auth.ErrUserNotFound)
+	}
+	s.sessions[session.ID] = copySession(session)
+	now := time.Now()
+	for id, stored := range s.sessions {
+		if !stored.ExpiresAt.After(now) {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+// copySession is a helper function that deep copies the given record.
+func copySession(session *auth.Session) *auth.Session {
+	c := *session
+	c.Scopes = append([]string(nil), session.Scopes...)
+	c.Current = false
+	return &c
+}
+
+// GetSession retrieves the session record with the given id.
+func (repo *authRepository) GetSession(ctx context.Context, id string) (*auth.Session, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, auth.ErrSessionNotFound
+	}
+	return copySession(session), nil
+}
+
+// GetSessions retrieves the records of the sessions of the user that are yet
+// to expire or be revoked, most recently used first.
+func (repo *authRepository) GetSessions(ctx context.Context, username string) ([]*auth.Session, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	now := time.Now()
+	sessions := make([]*auth.Session, 0)
+	for _, session := range s.sessions {
+		if session.Username == username && !session.Revoked && session.ExpiresAt.After(now) {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+	orderBy(sessions, "DESC", func(session *auth.Session) interface{} { return session.LastUsedTime })
+	return sessions, nil
+}
+
+// UpdateSession records the time the session was last refreshed and when it now expires.
+func (repo *authRepository) UpdateSession(ctx context.Context, id string, lastUsedTime, expiresAt time.Time) error {
+	s := repo.store
+	defer s.writeLock(ctx, sessionsTable)()
+
+	if session, ok := s.sessions[id]; ok {
+		session.LastUsedTime = lastUsedTime
+		session.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// RevokeSession marks the session of the user with the given id as revoked.
+func (repo *authRepository) RevokeSession(ctx context.Context, username, id string) error {
+	s := repo.store
+	defer s.writeLock(ctx, sessionsTable)()
+
+	session, ok := s.sessions[id]
+	if !ok || session.Username != username {
+		return auth.ErrSessionNotFound
+	}
+	session.Revoked = true
+	return nil
+}
+
+// RevokeSessions marks all the sessions of the user, except the one with the given id,
+// as revoked along with the refresh tokens issued in them.
+func (repo *authRepository) RevokeSessions(ctx context.Context, username, exceptID string) error {
+	s := repo.store
+	defer s.writeLock(ctx, sessionsTable, refreshTokensTable)()
+
+	for _, session := range s.sessions {
+		if session.Username == username && session.ID != exceptID {
+			session.Revoked = true
+		}
+	}
+	for _, rt := range s.refreshTokens {
+		if rt.Username == username && rt.FamilyID != exceptID {
+			rt.Revoked = true
+		}
+	}
+	return nil
+}
+
+// IsSessionRevoked checks whether the session with the given id has been revoked.
+// Unknown sessions, like those of tokens issued before sessions were recorded,
+// aren't considered revoked.
+func (repo *authRepository) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	session, ok := s.sessions[id]
+	return ok && session.Revoked, nil
+}
@@ -0,0 +1,325 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+)
+
+// maxStickiedPosts is how many posts a channel can have stickied at once.
+const maxStickiedPosts = 2
+
+// channelRepository...
+type channelRepository repository
+
+// NewChannelRepository returns a new in memory implementation of channel.Repository.
+func NewChannelRepository(store *Store) channel.Repository {
+	return &channelRepository{store}
+}
+
+// AddChannel takes in a channel.Channel struct and persists it in the store.
+func (repo *channelRepository) AddChannel(ctx context.Context, c *channel.Channel) (*channel.Channel, error) {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	if _, ok := s.channels[c.ChannelUsername]; ok {
+		return nil, fmt.Errorf("insertion of channel failed because of: channel %s already exists", c.ChannelUsername)
+	}
+	if _, ok := s.users[c.OwnerUsername]; !ok {
+		return nil, fmt.Errorf("insertion of admin user failed because of: %w", channel.ErrAdminNotFound)
+	}
+	s.channels[c.ChannelUsername] = &channelRecord{
+		username:     c.ChannelUsername,
+		name:         c.Name,
+		description:  c.Description,
+		creationTime: time.Now(),
+		admins:       []string{c.OwnerUsername},
+		owner:        c.OwnerUsername,
+	}
+	return c, nil
+}
+
+// GetChannel retrieves a channel.Channel based on the username passed.
+func (repo *channelRepository) GetChannel(ctx context.Context, channelUsername string) (*channel.Channel, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	record, ok := s.channels[channelUsername]
+	if !ok {
+		return nil, channel.ErrChannelNotFound
+	}
+	return s.toChannel(record), nil
+}
+
+// toChannel is just a helper function that copies the record into a channel.Channel
+// along with the IDs of its posts and releases. Callers must hold the lock.
+func (s *Store) toChannel(record *channelRecord) *channel.Channel {
+	c := &channel.Channel{
+		ChannelUsername: record.username,
+		Name:            record.name,
+		Description:     record.description,
+		PictureURL:      record.pictureURL,
+		OwnerUsername:   record.owner,
+		CreationTime:    record.creationTime,
+	}
+	c.AdminUsernames = append(c.AdminUsernames, record.admins...)
+	for id, p := range s.posts {
+		if p.channelFrom == record.username {
+			c.PostIDs = append(c.PostIDs, id)
+			if s.stickies[id] {
+				c.StickiedPostIDs = append(c.StickiedPostIDs, id)
+			}
+		}
+	}
+	sortIDs(c.PostIDs)
+	sortIDs(c.StickiedPostIDs)
+	for id, r := range s.releases {
+		if r.OwnerChannel == record.username {
+			c.ReleaseIDs = append(c.ReleaseIDs, uint(id))
+		}
+	}
+	sortIDs(c.ReleaseIDs)
+	for _, entry := range record.catalog {
+		c.OfficialReleaseIDs = append(c.OfficialReleaseIDs, entry.releaseID)
+	}
+	return c
+}
+
+// sortIDs is a helper function that sorts the IDs in insertion order.
+func sortIDs(ids []uint) {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+}
+
+// UpdateChannel updates a channel based on the passed in channel.Channel struct into channelUsername.
+func (repo *channelRepository) UpdateChannel(ctx context.Context, channelUsername string, c *channel.Channel) (*channel.Channel, error) {
+	tables := []table{channelsTable}
+	if c.ChannelUsername != "" && c.ChannelUsername != channelUsername {
+		tables = renameChannelTables
+	}
+	s := repo.store
+	defer s.writeLock(ctx, tables...)()
+
+	record, ok := s.channels[channelUsername]
+	if !ok {
+		return nil, channel.ErrChannelNotFound
+	}
+	if c.ChannelUsername != "" && c.ChannelUsername != channelUsername {
+		if _, ok := s.channels[c.ChannelUsername]; ok {
+			return nil, fmt.Errorf("updating failed of username column with %s because of: channel already exists", c.ChannelUsername)
+		}
+	}
+	if c.Name != "" {
+		record.name = c.Name
+	}
+	if c.Description != "" {
+		record.description = c.Description
+	}
+	if c.ChannelUsername != "" && c.ChannelUsername != channelUsername {
+		s.renameChannel(channelUsername, c.ChannelUsername)
+	}
+	return c, nil
+}
+
+// DeleteChannel deletes a channel based on the passed in channelUsername.
+// Its posts and releases are deleted along with it.
+func (repo *channelRepository) DeleteChannel(ctx context.Context, channelUsername string) error {
+	s := repo.store
+	defer s.writeLock(ctx, deleteChannelTables...)()
+
+	for id, p := range s.posts {
+		if p.channelFrom == channelUsername && s.postInCatalog(id) {
+			return fmt.Errorf("deletion of tuple from channels because of: post %d is in an official catalog", id)
+		}
+	}
+	s.deleteChannel(channelUsername)
+	return nil
+}
+
+// SearchChannels searches for channels according to the pattern.
+// If no pattern is provided, it returns all channels.
+// It makes use of pagination.
+func (repo *channelRepository) SearchChannels(ctx context.Context, pattern string, sortBy channel.SortBy, sortOrder channel.SortOrder, limit, offset int) ([]*channel.Channel, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	var channels = make([]*channel.Channel, 0)
+	for _, record := range s.channels {
+		if pattern == "" || contains(pattern, record.username, record.name) {
+			channels = append(channels, s.toChannel(record))
+		}
+	}
+	orderBy(channels, "ASC", func(c *channel.Channel) interface{} { return c.ChannelUsername })
+	orderBy(channels, string(sortOrder), func(c *channel.Channel) interface{} {
+		switch sortBy {
+		case channel.SortByUsername:
+			return c.ChannelUsername
+		case channel.SortByName:
+			return c.Name
+		default:
+			return c.CreationTime
+		}
+	})
+	return paginate(channels, limit, offset), nil
+}
+
+// AddAdmin adds the user under the given adminUsername to the admins of the channel.
+func (repo *channelRepository) AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	record, ok := s.channels[channelUsername]
+	if !ok {
+		return channel.ErrAdminNotFound
+	}
+	if _, ok := s.users[adminUsername]; !ok {
+		return channel.ErrAdminNotFound
+	}
+	for _, admin := range record.admins {
+		if admin == adminUsername {
+			return channel.ErrAdminAlreadyExists
+		}
+	}
+	record.admins = append(record.admins, adminUsername)
+	return nil
+}
+
+// DeleteAdmin removes the user under the given adminUsername from the admins of the channel.
+func (repo *channelRepository) DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	if record, ok := s.channels[channelUsername]; ok {
+		record.removeAdmin(adminUsername)
+	}
+	return nil
+}
+
+// ChangeOwner makes the admin under the given ownerUsername the owner of the channel.
+// The channel is left without an owner if they're not one of its admins.
+func (repo *channelRepository) ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	record, ok := s.channels[channelUsername]
+	if !ok {
+		return nil
+	}
+	record.owner = ""
+	for _, admin := range record.admins {
+		if admin == ownerUsername {
+			record.owner = ownerUsername
+		}
+	}
+	return nil
+}
+
+// AddReleaseToOfficialCatalog adds the release, taken from the given post, to the
+// official catalog of the channel.
+func (repo *channelRepository) AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	record, ok := s.channels[channelUsername]
+	switch {
+	case !ok:
+		return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %w", channel.ErrChannelNotFound)
+	case s.releases[int(releaseID)] == nil:
+		return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %w", channel.ErrReleaseNotFound)
+	case s.posts[postID] == nil:
+		return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %w", channel.ErrPostNotFound)
+	}
+	for _, entry := range record.catalog {
+		if entry.releaseID == releaseID {
+			return channel.ErrReleaseAlreadyExists
+		}
+	}
+	record.catalog = append(record.catalog, catalogEntry{releaseID, postID})
+	return nil
+}
+
+// DeleteReleaseFromCatalog deletes the release owned by the channel.
+func (repo *channelRepository) DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, releaseID uint) error {
+	s := repo.store
+	defer s.writeLock(ctx, deleteReleaseTables...)()
+
+	if r, ok := s.releases[int(releaseID)]; ok && r.OwnerChannel == channelUsername {
+		s.deleteRelease(int(releaseID))
+	}
+	return nil
+}
+
+// DeleteReleaseFromOfficialCatalog removes the release from the official catalog of the channel.
+func (repo *channelRepository) DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint) error {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	record, ok := s.channels[channelUsername]
+	if !ok {
+		return nil
+	}
+	for i, entry := range record.catalog {
+		if entry.releaseID == releaseID {
+			record.catalog = append(record.catalog[:i:i], record.catalog[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// StickyPost stickies the post on the channel, a channel can only have two
+// posts stickied at once.
+func (repo *channelRepository) StickyPost(ctx context.Context, channelUsername string, postID uint) error {
+	s := repo.store
+	defer s.writeLock(ctx, stickiesTable)()
+
+	stickied := 0
+	for id := range s.stickies {
+		if p, ok := s.posts[id]; ok && p.channelFrom == channelUsername {
+			stickied++
+		}
+	}
+	if stickied >= maxStickiedPosts {
+		return channel.ErrStickiedPostFull
+	}
+	if _, ok := s.posts[postID]; !ok {
+		return channel.ErrPostNotFound
+	}
+	s.stickies[postID] = true
+	return nil
+}
+
+// DeleteStickiedPost unstickies the post.
+func (repo *channelRepository) DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error {
+	s := repo.store
+	defer s.writeLock(ctx, stickiesTable)()
+
+	delete(s.stickies, stickiedPostID)
+	return nil
+}
+
+// AddPicture persists the given name as the picture of the channel.
+func (repo *channelRepository) AddPicture(ctx context.Context, channelUsername string, name string) (string, error) {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	record, ok := s.channels[channelUsername]
+	if !ok {
+		return "", channel.ErrChannelNotFound
+	}
+	record.pictureURL = name
+	return name, nil
+}
+
+// RemovePicture removes the picture of the channel.
+func (repo *channelRepository) RemovePicture(ctx context.Context, channelUsername string) error {
+	s := repo.store
+	defer s.writeLock(ctx, channelsTable)()
+
+	if record, ok := s.channels[channelUsername]; ok {
+		record.pictureURL = ""
+	}
+	return nil
+}
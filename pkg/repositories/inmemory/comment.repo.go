@@ -0,0 +1,127 @@
+package inmemory
+
+import (
+	"context"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+)
+
+// commentRepository ...
+type commentRepository repository
+
+// NewCommentRepository returns a new in memory implementation of comment.Repository.
+func NewCommentRepository(store *Store) comment.Repository {
+	return &commentRepository{store}
+}
+
+// AddComment persists the given struct into the store.
+func (repo *commentRepository) AddComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
+	s := repo.store
+	defer s.writeLock(ctx, commentsTable)()
+
+	if _, ok := s.posts[uint(c.OriginPost)]; !ok || c.OriginPost < 0 {
+		return nil, comment.ErrPostNotFound
+	}
+	if _, ok := s.users[c.Commenter]; !ok {
+		return nil, comment.ErrUserNotFound
+	}
+	s.lastCommentID++
+	c.ID = s.lastCommentID
+	c.CreationTime = time.Now()
+	stored := *c
+	s.comments[c.ID] = &stored
+	return c, nil
+}
+
+// GetComment returns a comment.Comment under the given id from the store.
+func (repo *commentRepository) GetComment(ctx context.Context, id int) (*comment.Comment, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	c, ok := s.comments[id]
+	if !ok {
+		return nil, comment.ErrCommentNotFound
+	}
+	found := *c
+	return &found, nil
+}
+
+// GetComments returns all comments in the store that match the given post
+// id.
+func (repo *commentRepository) GetComments(ctx context.Context, postID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	if _, ok := s.posts[uint(postID)]; !ok || postID < 0 {
+		return nil, comment.ErrPostNotFound
+	}
+	return s.commentsWhere(func(c *comment.Comment) bool { return c.OriginPost == postID }, by, order, limit, offset), nil
+}
+
+// GetReplies returns all comments in the store that are replies to the
+// comment of the given id.
+func (repo *commentRepository) GetReplies(ctx context.Context, commentID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return nil, comment.ErrCommentNotFound
+	}
+	return s.commentsWhere(func(c *comment.Comment) bool { return c.ReplyTo == commentID }, by, order, limit, offset), nil
+}
+
+// commentsWhere is just a helper function that returns sorted copies of the
+// comments the given function accepts. Callers must hold the lock.
+func (s *Store) commentsWhere(accept func(c *comment.Comment) bool, by string, order string, limit, offset int) []*comment.Comment {
+	var comments = make([]*comment.Comment, 0)
+	for _, c := range s.comments {
+		if accept(c) {
+			found := *c
+			comments = append(comments, &found)
+		}
+	}
+	orderBy(comments, "ASC", func(c *comment.Comment) interface{} { return c.ID })
+	orderBy(comments, order, func(c *comment.Comment) interface{} { return commentColumn(c, by) })
+	return paginate(comments, limit, offset)
+}
+
+// commentColumn is just a helper function that returns the value of the column
+// of the given name.
+func commentColumn(c *comment.Comment, column string) interface{} {
+	switch column {
+	case "commented_by":
+		return c.Commenter
+	case "content":
+		return c.Content
+	case "id":
+		return c.ID
+	default:
+		return c.CreationTime
+	}
+}
+
+// UpdateComment updates a comment in the store according to the given struct.
+func (repo *commentRepository) UpdateComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
+	s := repo.store
+	defer s.writeLock(ctx, commentsTable)()
+
+	stored, ok := s.comments[c.ID]
+	if !ok {
+		return nil, comment.ErrCommentNotFound
+	}
+	if c.Content != "" {
+		stored.Content = c.Content
+	}
+	updated := *stored
+	return &updated, nil
+}
+
+// DeleteComment removes the comment under the given id from the store.
+func (repo *commentRepository) DeleteComment(ctx context.Context, id int) error {
+	s := repo.store
+	defer s.writeLock(ctx, commentsTable)()
+
+	delete(s.comments, id)
+	return nil
+}
@@ -0,0 +1,26 @@
+package inmemory_test
+
+import (
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/conformance"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/inmemory"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func() (*conformance.Repositories, func(), error) {
+		store := inmemory.NewStore()
+		return &conformance.Repositories{
+			User:    inmemory.NewUserRepository(store),
+			Channel: inmemory.NewChannelRepository(store),
+			Feed:    inmemory.NewFeedRepository(store),
+			Release: inmemory.NewReleaseRepository(store),
+			Post:    inmemory.NewPostRepository(store),
+			Comment: inmemory.NewCommentRepository(store),
+			Search:  inmemory.NewSearchRepository(store),
+			Auth:    inmemory.NewAuthRepository(store),
+
+			UnitOfWork: inmemory.NewUnitOfWork(store),
+		}, func() {}, nil
+	})
+}
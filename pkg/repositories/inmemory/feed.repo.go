@@ -0,0 +1,182 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+)
+
+// feedRepository ...
+type feedRepository repository
+
+// NewFeedRepository returns a new in memory implementation of feed.Repository.
+func NewFeedRepository(store *Store) feed.Repository {
+	return &feedRepository{store}
+}
+
+// AddFeed persists a feed entity to the store according to the feed.Feed struct passed in.
+func (repo *feedRepository) AddFeed(ctx context.Context, f *feed.Feed) error {
+	s := repo.store
+	defer s.writeLock(ctx, feedsTable)()
+
+	if _, ok := s.users[f.OwnerUsername]; !ok {
+		return fmt.Errorf("insertion of user failed because of: %w", feed.ErrFeedNotFound)
+	}
+	s.lastFeedID++
+	s.feeds[s.lastFeedID] = &feedRecord{
+		id:            s.lastFeedID,
+		ownerUsername: f.OwnerUsername,
+		sorting:       sortingOf(f.Sorting),
+		subscriptions: make(map[string]time.Time),
+	}
+	return nil
+}
+
+// sortingOf is just a helper function that defaults to feed.SortTop like the database does.
+func sortingOf(sorting feed.Sorting) feed.Sorting {
+	switch sorting {
+	case feed.SortHot, feed.SortNew:
+		return sorting
+	default:
+		return feed.SortTop
+	}
+}
+
+// GetFeed retrieve the feed entity in the store belonging to the user of the passed
+// in username.
+func (repo *feedRepository) GetFeed(ctx context.Context, username string) (*feed.Feed, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	record := s.feedOf(username)
+	if record == nil {
+		return nil, feed.ErrFeedNotFound
+	}
+	return &feed.Feed{ID: record.id, OwnerUsername: record.ownerUsername, Sorting: record.sorting}, nil
+}
+
+// GetChannels retrieves the all the channels the given feed has subscribed to.
+func (repo *feedRepository) GetChannels(ctx context.Context, f *feed.Feed, sortBy string, sortOrder string) ([]*feed.Channel, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	channelSubscriptions := make([]*feed.Channel, 0)
+	record, ok := s.feeds[f.ID]
+	if !ok {
+		return channelSubscriptions, nil
+	}
+	for channelname, subscriptionTime := range record.subscriptions {
+		c := s.channels[channelname]
+		channelSubscriptions = append(channelSubscriptions, &feed.Channel{
+			Channelname:      c.username,
+			Name:             c.name,
+			SubscriptionTime: subscriptionTime,
+		})
+	}
+	orderBy(channelSubscriptions, "ASC", func(c *feed.Channel) interface{} { return c.Channelname })
+	orderBy(channelSubscriptions, sortOrder, func(c *feed.Channel) interface{} {
+		switch feed.SortBy(sortBy) {
+		case feed.SortByUsername:
+			return c.Channelname
+		case feed.SortByName:
+			return c.Name
+		default:
+			return c.SubscriptionTime
+		}
+	})
+	return channelSubscriptions, nil
+}
+
+// GetPosts gets posts from the channels the given feed is subscribed to sorted
+// according to the given sorting.
+func (repo *feedRepository) GetPosts(ctx context.Context, f *feed.Feed, sort feed.Sorting, limit, offset int) ([]*feed.Post, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	record, ok := s.feeds[f.ID]
+	if !ok {
+		return make([]*feed.Post, 0), nil
+	}
+	var posts []*postRecord
+	for _, p := range s.posts {
+		if _, subscribed := record.subscriptions[p.channelFrom]; subscribed {
+			posts = append(posts, p)
+		}
+	}
+	commentCounts := make(map[uint]int)
+	for _, c := range s.comments {
+		commentCounts[uint(c.OriginPost)]++
+	}
+
+	orderBy(posts, "DESC", func(p *postRecord) interface{} { return p.id })
+	orderBy(posts, "DESC", func(p *postRecord) interface{} { return p.creationTime })
+	switch sort {
+	case feed.SortNew:
+	case feed.SortHot:
+		orderBy(posts, "DESC", func(p *postRecord) interface{} {
+			if commentCounts[p.id] == 0 {
+				return nil
+			}
+			return commentCounts[p.id]
+		})
+	default:
+		orderBy(posts, "DESC", func(p *postRecord) interface{} {
+			if len(p.stars) == 0 {
+				return nil
+			}
+			var total uint
+			for _, count := range p.stars {
+				total += count
+			}
+			return total
+		})
+	}
+
+	feedPosts := make([]*feed.Post, 0)
+	for _, p := range paginate(posts, limit, offset) {
+		feedPosts = append(feedPosts, &feed.Post{ID: int(p.id)})
+	}
+	return feedPosts, nil
+}
+
+// UpdateFeed updates the feed under the given id according to the feed.Feed struct passed in.
+func (repo *feedRepository) UpdateFeed(ctx context.Context, id uint, f *feed.Feed) error {
+	s := repo.store
+	defer s.writeLock(ctx, feedsTable)()
+
+	if record, ok := s.feeds[id]; ok {
+		record.sorting = sortingOf(f.Sorting)
+	}
+	return nil
+}
+
+// Subscribe subscribes the given feed to the channel of the given channelname.
+func (repo *feedRepository) Subscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	s := repo.store
+	defer s.writeLock(ctx, feedsTable)()
+
+	record, ok := s.feeds[f.ID]
+	if !ok {
+		return feed.ErrChannelNotFound
+	}
+	if _, ok := s.channels[channelname]; !ok {
+		return feed.ErrChannelNotFound
+	}
+	if _, ok := record.subscriptions[channelname]; !ok {
+		record.subscriptions[channelname] = time.Now()
+	}
+	return nil
+}
+
+// Unsubscribe unsubscribes the given feed from the channel of the given channelname.
+func (repo *feedRepository) Unsubscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	s := repo.store
+	defer s.writeLock(ctx, feedsTable)()
+
+	if record, ok := s.feeds[f.ID]; ok {
+		delete(record.subscriptions, channelname)
+	}
+	return nil
+}
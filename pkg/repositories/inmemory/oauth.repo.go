@@ -0,0 +1,165 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+)
+
+type oAuthRepository repository
+
+// NewOAuthRepository returns a struct that implements the oauth.Repository
+// over the given Store.
+func NewOAuthRepository(store *Store) oauth.Repository {
+	return &oAuthRepository{store}
+}
+
+// AddClient persists the given client record.
+func (repo *oAuthRepository) AddClient(ctx context.Context, c *oauth.Client) error {
+	s := repo.store
+	defer s.writeLock(ctx, clientsTable)()
+
+	if _, ok := s.clients[c.ID]; ok {
+		return fmt.Errorf("insertion into oauth_clients failed because of: client already exists")
+	}
+	if _, ok := s.users[c.OwnerUsername]; !ok {
+		return fmt.Errorf("insertion into oauth_clients failed because of: owner %s doesn't exist", c.OwnerUsername)
+	}
+	stored := copyClient(c)
+	stored.Secret = ""
+	s.clients[c.ID] = stored
+	return nil
+}
+
+// copyClient is a helper function that deep copies the given record.
+func copyClient(c *oauth.Client) *oauth.Client {
+	copied := *c
+	copied.RedirectURIs = append([]string(nil), c.RedirectURIs...)
+	return &copied
+}
+
+// GetClient retrieves the client record with the given id.
+func (repo *oAuthRepository) GetClient(ctx context.Context, id string) (*oauth.Client, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	c, ok := s.clients[id]
+	if !ok {
+		return nil, oauth.ErrClientNotFound
+	}
+	return copyClient(c), nil
+}
+
+// GetClients retrieves the records of the clients registered by the user, newest first.
+func (repo *oAuthRepository) GetClients(ctx context.Context, ownerUsername string) ([]*oauth.Client, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	clients := make([]*oauth.Client, 0)
+	for _, c := range s.clients {
+		if c.OwnerUsername == ownerUsername {
+			clients = append(clients, copyClient(c))
+		}
+	}
+	orderBy(clients, "DESC", func(c *oauth.Client) interface{} { return c.CreationTime })
+	return clients, nil
+}
+
+// DeleteClient removes the client of the user with the given id.
+// Consents and codes issued to the client go with it.
+func (repo *oAuthRepository) DeleteClient(ctx context.Context, ownerUsername, id string) error {
+	s := repo.store
+	defer s.writeLock(ctx, deleteClientTables...)()
+
+	c, ok := s.clients[id]
+	if !ok || c.OwnerUsername != ownerUsername {
+		return oauth.ErrClientNotFound
+	}
+	s.deleteClient(id)
+	return nil
+}
+
+// GetConsent retrieves the record of the scopes the user has allowed the client.
+func (repo *oAuthRepository) GetConsent(ctx context.Context, username, clientID string) (*oauth.Consent, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	c, ok := s.consents[consentKey{username, clientID}]
+	if !ok {
+		return nil, oauth.ErrConsentNotFound
+	}
+	found := *c
+	found.Scopes = append([]string(nil), c.Scopes...)
+	return &found, nil
+}
+
+// SetConsent persists the given consent record, replacing the scopes of any
+// previous consent of the user to the client.
+func (repo *oAuthRepository) SetConsent(ctx context.Context, c *oauth.Consent) error {
+	s := repo.store
+	defer s.writeLock(ctx, consentsTable)()
+
+	if _, ok := s.clients[c.ClientID]; !ok {
+		return fmt.Errorf("upsertion into oauth_consents failed because of: %w", oauth.ErrClientNotFound)
+	}
+	if _, ok := s.users[c.Username]; !ok {
+		return fmt.Errorf("upsertion into oauth_consents failed because of: user %s doesn't exist", c.Username)
+	}
+	key := consentKey{c.Username, c.ClientID}
+	if stored, ok := s.consents[key]; ok {
+		stored.Scopes = append([]string(nil), c.Scopes...)
+		return nil
+	}
+	stored := *c
+	stored.Scopes = append([]string(nil), c.Scopes...)
+	s.consents[key] = &stored
+	return nil
+}
+
+// AddAuthorizationCode persists the given authorization code record.
+// Codes that have expired are pruned on the way.
+func (repo *oAuthRepository) AddAuthorizationCode(ctx context.Context, code *oauth.AuthorizationCode) error {
+	s := repo.store
+	defer s.writeLock(ctx, codesTable)()
+
+	if _, ok := s.codes[code.CodeHash]; ok {
+		return fmt.Errorf("insertion into oauth_authorization_codes failed because of: code already exists")
+	}
+	if _, ok := s.clients[code.ClientID]; !ok {
+		return fmt.Errorf("insertion into oauth_authorization_codes failed because of: %w", oauth.ErrClientNotFound)
+	}
+	if _, ok := s.users[code.Username]; !ok {
+		return fmt.Errorf("insertion into oauth_authorization_codes failed because of: user %s doesn't exist", code.Username)
+	}
+	s.codes[code.CodeHash] = copyAuthorizationCode(code)
+	now := time.Now()
+	for hash, stored := range s.codes {
+		if !stored.ExpiresAt.After(now) {
+			delete(s.codes, hash)
+		}
+	}
+	return nil
+}
+
+// copyAuthorizationCode is a helper function that deep copies the given record.
+func copyAuthorizationCode(code *oauth.AuthorizationCode) *oauth.AuthorizationCode {
+	c := *code
+	c.Scopes = append([]string(nil), code.Scopes...)
+	return &c
+}
+
+// ConsumeAuthorizationCode marks the authorization code stored under the given hash
+// as used and returns its record. A code can only be consumed once.
+func (repo *oAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*oauth.AuthorizationCode, error) {
+	s := repo.store
+	defer s.writeLock(ctx, codesTable)()
+
+	code, ok := s.codes[codeHash]
+	if !ok || code.Used {
+		return nil, oauth.ErrInvalidGrant
+	}
+	code.Used = true
+	return copyAuthorizationCode(code), nil
+}
@@ -0,0 +1,249 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+)
+
+// postRepository ...
+type postRepository repository
+
+// NewPostRepository returns a new in memory implementation of post.Repository.
+func NewPostRepository(store *Store) post.Repository {
+	return &postRepository{store}
+}
+
+// GetPost returns the post stored under the given id.
+func (repo *postRepository) GetPost(ctx context.Context, id uint) (*post.Post, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, post.ErrPostNotFound
+	}
+	return s.toPost(p), nil
+}
+
+// toPost is just a helper function that copies the record into a post.Post
+// along with the IDs of its comments. Callers must hold the lock.
+func (s *Store) toPost(record *postRecord) *post.Post {
+	p := &post.Post{
+		ID:               record.id,
+		PostedByUsername: record.postedBy,
+		OriginChannel:    record.channelFrom,
+		Title:            record.title,
+		Description:      record.description,
+		Stars:            make(map[string]uint, len(record.stars)),
+		CreationTime:     record.creationTime,
+	}
+	p.ContentsID = append(p.ContentsID, record.contents...)
+	for username, count := range record.stars {
+		p.Stars[username] = count
+	}
+	for id, c := range s.comments {
+		if c.OriginPost == int(record.id) {
+			p.CommentsID = append(p.CommentsID, id)
+		}
+	}
+	sort.Ints(p.CommentsID)
+	return p
+}
+
+// DeletePost Deletes the Post stored under the given id.
+// Posts releases in official catalogs were taken from can't be deleted.
+func (repo *postRepository) DeletePost(ctx context.Context, id uint) error {
+	s := repo.store
+	defer s.writeLock(ctx, deletePostTables...)()
+
+	if s.postInCatalog(id) {
+		return fmt.Errorf("deletion of post failed because of: post %d is in an official catalog", id)
+	}
+	s.deletePost(id)
+	return nil
+}
+
+// AddPost Adds the Post stored under its id from given post struct, all or nothing.
+func (repo *postRepository) AddPost(ctx context.Context, p *post.Post) (*post.Post, error) {
+	s := repo.store
+	defer s.writeLock(ctx, postsTable)()
+
+	if _, ok := s.users[p.PostedByUsername]; !ok {
+		return nil, post.ErrSomePostDataNotPersisted
+	}
+	if _, ok := s.channels[p.OriginChannel]; !ok {
+		return nil, post.ErrSomePostDataNotPersisted
+	}
+	s.lastPostID++
+	p.ID = s.lastPostID
+	s.posts[p.ID] = &postRecord{
+		id:           p.ID,
+		postedBy:     p.PostedByUsername,
+		channelFrom:  p.OriginChannel,
+		title:        p.Title,
+		description:  p.Description,
+		creationTime: time.Now(),
+		stars:        make(map[string]uint),
+	}
+	p.PostedByUsername = ""
+	p.OriginChannel = ""
+	p.Title = ""
+	p.Description = ""
+	added, err := s.updatePost(p, p.ID)
+	if err != nil {
+		delete(s.posts, p.ID)
+		return nil, err
+	}
+	return added, nil
+}
+
+// UpdatePost updates the post with given id and post struct, all or nothing.
+func (repo *postRepository) UpdatePost(ctx context.Context, pos *post.Post, id uint) (*post.Post, error) {
+	s := repo.store
+	defer s.writeLock(ctx, postsTable)()
+
+	return s.updatePost(pos, id)
+}
+
+// updatePost is just a helper function. Callers must hold the lock.
+func (s *Store) updatePost(pos *post.Post, id uint) (*post.Post, error) {
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, post.ErrPostNotFound
+	}
+	// changes are checked before any is made so that all of them are made or none is
+	if pos.PostedByUsername != "" {
+		if _, ok := s.users[pos.PostedByUsername]; !ok {
+			return nil, post.ErrSomePostDataNotPersisted
+		}
+	}
+	if pos.OriginChannel != "" {
+		if _, ok := s.channels[pos.OriginChannel]; !ok {
+			return nil, post.ErrSomePostDataNotPersisted
+		}
+	}
+	for _, releaseID := range pos.ContentsID {
+		if _, ok := s.releases[int(releaseID)]; !ok {
+			return nil, post.ErrSomePostDataNotPersisted
+		}
+	}
+	if pos.PostedByUsername != "" {
+		p.postedBy = pos.PostedByUsername
+	}
+	if pos.OriginChannel != "" {
+		p.channelFrom = pos.OriginChannel
+	}
+	if pos.Title != "" {
+		p.title = pos.Title
+	}
+	if pos.Description != "" {
+		p.description = pos.Description
+	}
+	if len(pos.ContentsID) != 0 {
+		p.contents = append([]uint(nil), pos.ContentsID...)
+	}
+	return s.toPost(p), nil
+}
+
+// SearchPost gets all Posts under specfications
+func (repo *postRepository) SearchPost(ctx context.Context, pattern string, by post.SortBy, order post.SortOrder, limit int, offset int) ([]*post.Post, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	var posts = make([]*post.Post, 0)
+	ranks := make(map[uint]int)
+	for id, p := range s.posts {
+		if pattern != "" {
+			ranks[id] = rank(pattern, p.title, p.description, p.postedBy, p.channelFrom)
+			if ranks[id] == 0 {
+				continue
+			}
+		}
+		posts = append(posts, s.toPost(p))
+	}
+	orderBy(posts, "ASC", func(p *post.Post) interface{} { return p.ID })
+	if pattern == "" || by != "" {
+		orderBy(posts, string(order), func(p *post.Post) interface{} {
+			switch by {
+			case post.SortByChannel:
+				return p.OriginChannel
+			case post.SortByPoster:
+				return p.PostedByUsername
+			case post.SortByTitle:
+				return p.Title
+			default:
+				return p.CreationTime
+			}
+		})
+	}
+	if pattern != "" {
+		orderBy(posts, "DESC", func(p *post.Post) interface{} { return ranks[p.ID] })
+	}
+	return paginate(posts, limit, offset), nil
+}
+
+// GetPostStar gets the star stored under the given postid and username.
+func (repo *postRepository) GetPostStar(ctx context.Context, id uint, username string) (*post.Star, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, post.ErrStarNotFound
+	}
+	count, ok := p.stars[username]
+	if !ok {
+		return nil, post.ErrStarNotFound
+	}
+	return &post.Star{Username: username, NumOfStars: count}, nil
+}
+
+// DeletePostStar deletes the star stored under given postid and username
+func (repo *postRepository) DeletePostStar(ctx context.Context, id uint, username string) error {
+	s := repo.store
+	defer s.writeLock(ctx, postsTable)()
+
+	if p, ok := s.posts[id]; ok {
+		delete(p.stars, username)
+	}
+	return nil
+}
+
+// AddPostStar adds a star given postid, number of stars and username
+func (repo *postRepository) AddPostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
+	s := repo.store
+	defer s.writeLock(ctx, postsTable)()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, post.ErrStarNotFound
+	}
+	if _, ok := s.users[star.Username]; !ok {
+		return nil, post.ErrStarNotFound
+	}
+	if _, ok := p.stars[star.Username]; ok {
+		return nil, post.ErrStarNotFound
+	}
+	p.stars[star.Username] = star.NumOfStars
+	return &post.Star{Username: star.Username, NumOfStars: star.NumOfStars}, nil
+}
+
+// UpdatePostStar updates a star stored given postid, number of stars and username
+func (repo *postRepository) UpdatePostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
+	s := repo.store
+	defer s.writeLock(ctx, postsTable)()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, post.ErrStarNotFound
+	}
+	if _, ok := p.stars[star.Username]; !ok {
+		return nil, post.ErrStarNotFound
+	}
+	p.stars[star.Username] = star.NumOfStars
+	return &post.Star{Username: star.Username, NumOfStars: star.NumOfStars}, nil
+}
@@ -0,0 +1,166 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+)
+
+// releaseRepository ...
+type releaseRepository repository
+
+// NewReleaseRepository returns a new in memory implementation of release.Repository.
+func NewReleaseRepository(store *Store) release.Repository {
+	return &releaseRepository{store}
+}
+
+// GetRelease returns the release under the given id.
+func (repo *releaseRepository) GetRelease(ctx context.Context, id int) (*release.Release, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	r, ok := s.releases[id]
+	if !ok {
+		return nil, release.ErrReleaseNotFound
+	}
+	return copyRelease(r), nil
+}
+
+// copyRelease is just a helper function that copies the release along with its metadata.
+// Unset release dates are the epoch like the database returns them.
+func copyRelease(r *release.Release) *release.Release {
+	c := *r
+	c.Authors = append([]string(nil), r.Authors...)
+	c.Genres = append([]string(nil), r.Genres...)
+	if c.ReleaseDate.IsZero() {
+		c.ReleaseDate = time.Unix(0, 0)
+	}
+	return &c
+}
+
+// SearchRelease searches for the releases in official catalogs according to the pattern.
+// If no pattern is provided, it returns all such releases.
+// It makes use of pagination.
+func (repo *releaseRepository) SearchRelease(ctx context.Context, pattern string, by release.SortBy, order release.SortOrder, limit int, offset int) ([]*release.Release, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	official := make(map[int]bool)
+	for _, c := range s.channels {
+		for _, entry := range c.catalog {
+			official[int(entry.releaseID)] = true
+		}
+	}
+	var releases = make([]*release.Release, 0)
+	ranks := make(map[int]int)
+	for id := range official {
+		r := s.releases[id]
+		if pattern != "" {
+			fields := []string{r.OwnerChannel, string(r.Type), r.Title, r.GenreDefining, r.Description}
+			fields = append(fields, r.Authors...)
+			fields = append(fields, r.Genres...)
+			if r.Type == release.Text {
+				fields = append(fields, r.Content)
+			}
+			ranks[id] = rank(pattern, fields...)
+			if ranks[id] == 0 {
+				continue
+			}
+		}
+		releases = append(releases, copyRelease(r))
+	}
+	orderBy(releases, "ASC", func(r *release.Release) interface{} { return r.ID })
+	if pattern == "" || by != "" {
+		orderBy(releases, string(order), func(r *release.Release) interface{} {
+			switch by {
+			case release.SortByChannel:
+				return r.OwnerChannel
+			case release.SortByType:
+				return string(r.Type)
+			default:
+				return r.CreationTime
+			}
+		})
+	}
+	if pattern != "" {
+		orderBy(releases, "DESC", func(r *release.Release) interface{} { return ranks[r.ID] })
+	}
+	return paginate(releases, limit, offset), nil
+}
+
+// DeleteRelease removes the release under the given id from the store.
+func (repo *releaseRepository) DeleteRelease(ctx context.Context, id int) error {
+	s := repo.store
+	defer s.writeLock(ctx, deleteReleaseTables...)()
+
+	s.deleteRelease(id)
+	return nil
+}
+
+// AddRelease persists the given struct into the store, all or nothing.
+func (repo *releaseRepository) AddRelease(ctx context.Context, r *release.Release) (*release.Release, error) {
+	s := repo.store
+	defer s.writeLock(ctx, releasesTable)()
+
+	if _, ok := s.channels[r.OwnerChannel]; !ok {
+		return nil, fmt.Errorf("insertion of release failed because of: %w", release.ErrInvalidReleaseData)
+	}
+	s.lastReleaseID++
+	r.ID = s.lastReleaseID
+	s.releases[r.ID] = &release.Release{
+		ID:           r.ID,
+		OwnerChannel: r.OwnerChannel,
+		Type:         r.Type,
+		CreationTime: time.Now(),
+	}
+	r.OwnerChannel = ""
+	added, err := s.updateRelease(r)
+	if err != nil {
+		delete(s.releases, r.ID)
+		return nil, err
+	}
+	return added, nil
+}
+
+// UpdateRelease updates a release in the store according to the given struct, all or nothing.
+// Only the non empty fields of the struct are updated, save for Other which is always replaced.
+func (repo *releaseRepository) UpdateRelease(ctx context.Context, rel *release.Release) (*release.Release, error) {
+	s := repo.store
+	defer s.writeLock(ctx, releasesTable)()
+
+	return s.updateRelease(rel)
+}
+
+// updateRelease is just a helper function. Callers must hold the lock.
+func (s *Store) updateRelease(rel *release.Release) (*release.Release, error) {
+	r, ok := s.releases[rel.ID]
+	if !ok {
+		return nil, release.ErrReleaseNotFound
+	}
+	if rel.OwnerChannel != "" {
+		if _, ok := s.channels[rel.OwnerChannel]; !ok {
+			return nil, release.ErrSomeReleaseDataNotPersisted
+		}
+		r.OwnerChannel = rel.OwnerChannel
+	}
+	if rel.Content != "" && rel.Type != "" {
+		r.Content = rel.Content
+	}
+	if !rel.ReleaseDate.IsZero() {
+		r.ReleaseDate = rel.ReleaseDate
+	}
+	if rel.Title != "" {
+		r.Title = rel.Title
+	}
+	if rel.GenreDefining != "" {
+		r.GenreDefining = rel.GenreDefining
+	}
+	if rel.Description != "" {
+		r.Description = rel.Description
+	}
+	r.Authors = append([]string(nil), rel.Authors...)
+	r.Genres = append([]string(nil), rel.Genres...)
+	return copyRelease(r), nil
+}
@@ -0,0 +1,582 @@
+/*
+Package inmemory contains implementations of the different Repository interfaces
+that keep everything in memory instead of a database. They stand in for the
+postgres package, behaviour the database gets from its constraints and triggers
+included, so that the server can be run and tested without PostgreSQL.
+Nothing is persisted, everything's lost once the process exits.
+*/
+package inmemory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+)
+
+// Store holds the data of all the repos of this package, it plays the part
+// of the database. The repos sharing a Store see each other's changes.
+type Store struct {
+	lock sync.RWMutex
+	// work is held by the unit of work in progress, tx, and txEnded is
+	// signalled as it ends.
+	work    sync.Mutex
+	tx      *storeTx
+	txEnded *sync.Cond
+
+	users    map[string]*userRecord
+	channels map[string]*channelRecord
+	feeds    map[uint]*feedRecord
+	releases map[int]*release.Release
+	posts    map[uint]*postRecord
+	comments map[int]*comment.Comment
+	stickies map[uint]bool
+
+	blacklist               map[string]time.Time
+	refreshTokens           map[string]*auth.RefreshToken
+	passwordResetTokens     map[string]*auth.PasswordResetToken
+	emailVerificationTokens map[string]*auth.EmailVerificationToken
+	totpSecrets             map[string]*auth.TOTPSecret
+	personalAccessTokens    map[string]*auth.PersonalAccessToken
+	sessions                map[string]*auth.Session
+
+	clients  map[string]*oauth.Client
+	consents map[consentKey]*oauth.Consent
+	codes    map[string]*oauth.AuthorizationCode
+
+	lastFeedID    uint
+	lastReleaseID int
+	lastPostID    uint
+	lastCommentID int
+}
+
+// userRecord is a row of the users table along with the rows of the tables
+// that only hang off of it.
+type userRecord struct {
+	username     string
+	email        string
+	passHash     string
+	firstName    string
+	middleName   string
+	lastName     string
+	bio          string
+	pictureURL   string
+	verified     bool
+	creationTime time.Time
+	bookmarks    map[int]time.Time // post ID to the time it was bookmarked
+}
+
+// channelRecord is a row of the channels table along with its admins, picture
+// and official catalog.
+type channelRecord struct {
+	username     string
+	name         string
+	description  string
+	pictureURL   string
+	creationTime time.Time
+	admins       []string
+	owner        string
+	catalog      []catalogEntry
+}
+
+// catalogEntry is a release in the official catalog of a channel along with
+// the post it was taken from.
+type catalogEntry struct {
+	releaseID uint
+	postID    uint
+}
+
+// feedRecord is a row of the feeds table along with its subscriptions.
+type feedRecord struct {
+	id            uint
+	ownerUsername string
+	sorting       feed.Sorting
+	subscriptions map[string]time.Time
+}
+
+// postRecord is a row of the posts table along with its contents and stars.
+type postRecord struct {
+	id           uint
+	postedBy     string
+	channelFrom  string
+	title        string
+	description  string
+	creationTime time.Time
+	contents     []uint
+	stars        map[string]uint
+}
+
+type consentKey struct {
+	username string
+	clientID string
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	s := &Store{
+		users:                   make(map[string]*userRecord),
+		channels:                make(map[string]*channelRecord),
+		feeds:                   make(map[uint]*feedRecord),
+		releases:                make(map[int]*release.Release),
+		posts:                   make(map[uint]*postRecord),
+		comments:                make(map[int]*comment.Comment),
+		stickies:                make(map[uint]bool),
+		blacklist:               make(map[string]time.Time),
+		refreshTokens:           make(map[string]*auth.RefreshToken),
+		passwordResetTokens:     make(map[string]*auth.PasswordResetToken),
+		emailVerificationTokens: make(map[string]*auth.EmailVerificationToken),
+		totpSecrets:             make(map[string]*auth.TOTPSecret),
+		personalAccessTokens:    make(map[string]*auth.PersonalAccessToken),
+		sessions:                make(map[string]*auth.Session),
+		clients:                 make(map[string]*oauth.Client),
+		consents:                make(map[consentKey]*oauth.Consent),
+		codes:                   make(map[string]*oauth.AuthorizationCode),
+	}
+	s.txEnded = sync.NewCond(&s.lock)
+	return s
+}
+
+type repository struct {
+	store *Store
+}
+
+// setupUser creates the channel and the feed every user gets, like the
+// setup_user trigger does. Callers must hold the lock.
+func (s *Store) setupUser(username string, now time.Time) {
+	s.channels[username] = &channelRecord{
+		username:     username,
+		name:         username + "'s channel",
+		creationTime: now,
+		admins:       []string{username},
+		owner:        username,
+	}
+	s.lastFeedID++
+	s.feeds[s.lastFeedID] = &feedRecord{
+		id:            s.lastFeedID,
+		ownerUsername: username,
+		sorting:       feed.SortHot,
+		subscriptions: make(map[string]time.Time),
+	}
+}
+
+// feedOf returns the feed of the given user, the oldest if there are more than one.
+// Callers must hold the lock.
+func (s *Store) feedOf(username string) *feedRecord {
+	var found *feedRecord
+	for _, f := range s.feeds {
+		if f.ownerUsername == username && (found == nil || f.id < found.id) {
+			found = f
+		}
+	}
+	return found
+}
+
+// renameUserTables are the tables renameUser writes to.
+var renameUserTables = []table{usersTable, channelsTable, feedsTable, postsTable, commentsTable,
+	refreshTokensTable, passwordResetTokensTable, emailVerificationTokensTable, totpSecretsTable,
+	personalAccessTokensTable, sessionsTable, clientsTable, consentsTable, codesTable}
+
+// renameUser updates all the references to the user, like the ON UPDATE CASCADE
+// foreign keys do. Callers must hold the lock.
+func (s *Store) renameUser(oldUsername, newUsername string) {
+	u := s.users[oldUsername]
+	delete(s.users, oldUsername)
+	u.username = newUsername
+	s.users[newUsername] = u
+
+	for _, c := range s.channels {
+		for i, admin := range c.admins {
+			if admin == oldUsername {
+				c.admins[i] = newUsername
+			}
+		}
+		if c.owner == oldUsername {
+			c.owner = newUsername
+		}
+	}
+	for _, f := range s.feeds {
+		if f.ownerUsername == oldUsername {
+			f.ownerUsername = newUsername
+		}
+	}
+	for _, p := range s.posts {
+		if p.postedBy == oldUsername {
+			p.postedBy = newUsername
+		}
+		if stars, ok := p.stars[oldUsername]; ok {
+			delete(p.stars, oldUsername)
+			p.stars[newUsername] = stars
+		}
+	}
+	for _, c := range s.comments {
+		if c.Commenter == oldUsername {
+			c.Commenter = newUsername
+		}
+	}
+
+	for _, rt := range s.refreshTokens {
+		if rt.Username == oldUsername {
+			rt.Username = newUsername
+		}
+	}
+	for _, prt := range s.passwordResetTokens {
+		if prt.Username == oldUsername {
+			prt.Username = newUsername
+		}
+	}
+	for _, evt := range s.emailVerificationTokens {
+		if evt.Username == oldUsername {
+			evt.Username = newUsername
+		}
+	}
+	if ts, ok := s.totpSecrets[oldUsername]; ok {
+		delete(s.totpSecrets, oldUsername)
+		ts.Username = newUsername
+		s.totpSecrets[newUsername] = ts
+	}
+	for _, pat := range s.personalAccessTokens {
+		if pat.Username == oldUsername {
+			pat.Username = newUsername
+		}
+	}
+	for _, session := range s.sessions {
+		if session.Username == oldUsername {
+			session.Username = newUsername
+		}
+	}
+	for _, c := range s.clients {
+		if c.OwnerUsername == oldUsername {
+			c.OwnerUsername = newUsername
+		}
+	}
+	for key, consent := range s.consents {
+		if key.username == oldUsername {
+			delete(s.consents, key)
+			consent.Username = newUsername
+			s.consents[consentKey{newUsername, key.clientID}] = consent
+		}
+	}
+	for _, code := range s.codes {
+		if code.Username == oldUsername {
+			code.Username = newUsername
+		}
+	}
+}
+
+// userReferenced tells whether the user has posts or comments, which keep it
+// from being deleted like the foreign keys without ON DELETE CASCADE do.
+// Callers must hold the lock.
+func (s *Store) userReferenced(username string) bool {
+	for _, p := range s.posts {
+		if p.postedBy == username {
+			return true
+		}
+	}
+	for _, c := range s.comments {
+		if c.Commenter == username {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUserTables are the tables deleteUser writes to.
+var deleteUserTables = []table{usersTable, channelsTable, feedsTable, postsTable,
+	refreshTokensTable, passwordResetTokensTable, emailVerificationTokensTable, totpSecretsTable,
+	personalAccessTokensTable, sessionsTable, clientsTable, consentsTable, codesTable}
+
+// deleteUser removes the user along with everything that references it, like
+// the ON DELETE CASCADE foreign keys do. The channel of the user stays around
+// as it does in the database. Callers must hold the lock.
+func (s *Store) deleteUser(username string) {
+	delete(s.users, username)
+	for _, c := range s.channels {
+		c.removeAdmin(username)
+	}
+	for id, f := range s.feeds {
+		if f.ownerUsername == username {
+			delete(s.feeds, id)
+		}
+	}
+	for _, p := range s.posts {
+		delete(p.stars, username)
+	}
+
+	for hash, rt := range s.refreshTokens {
+		if rt.Username == username {
+			delete(s.refreshTokens, hash)
+		}
+	}
+	for hash, prt := range s.passwordResetTokens {
+		if prt.Username == username {
+			delete(s.passwordResetTokens, hash)
+		}
+	}
+	for hash, evt := range s.emailVerificationTokens {
+		if evt.Username == username {
+			delete(s.emailVerificationTokens, hash)
+		}
+	}
+	delete(s.totpSecrets, username)
+	for hash, pat := range s.personalAccessTokens {
+		if pat.Username == username {
+			delete(s.personalAccessTokens, hash)
+		}
+	}
+	for id, session := range s.sessions {
+		if session.Username == username {
+			delete(s.sessions, id)
+		}
+	}
+	for id, c := range s.clients {
+		if c.OwnerUsername == username {
+			s.deleteClient(id)
+		}
+	}
+	for key := range s.consents {
+		if key.username == username {
+			delete(s.consents, key)
+		}
+	}
+	for hash, code := range s.codes {
+		if code.Username == username {
+			delete(s.codes, hash)
+		}
+	}
+}
+
+// removeAdmin is a helper function that removes the user from the admins of the channel.
+func (c *channelRecord) removeAdmin(username string) {
+	for i, admin := range c.admins {
+		if admin == username {
+			c.admins = append(c.admins[:i:i], c.admins[i+1:]...)
+			break
+		}
+	}
+	if c.owner == username {
+		c.owner = ""
+	}
+}
+
+// renameChannelTables are the tables renameChannel writes to.
+var renameChannelTables = []table{channelsTable, feedsTable, postsTable, releasesTable}
+
+// renameChannel updates all the references to the channel, like the ON UPDATE
+// CASCADE foreign keys do. Callers must hold the lock.
+func (s *Store) renameChannel(oldUsername, newUsername string) {
+	c := s.channels[oldUsername]
+	delete(s.channels, oldUsername)
+	c.username = newUsername
+	s.channels[newUsername] = c
+
+	for _, f := range s.feeds {
+		if t, ok := f.subscriptions[oldUsername]; ok {
+			delete(f.subscriptions, oldUsername)
+			f.subscriptions[newUsername] = t
+		}
+	}
+	for _, p := range s.posts {
+		if p.channelFrom == oldUsername {
+			p.channelFrom = newUsername
+		}
+	}
+	for _, r := range s.releases {
+		if r.OwnerChannel == oldUsername {
+			r.OwnerChannel = newUsername
+		}
+	}
+}
+
+// postInCatalog tells whether a release in an official catalog was taken from
+// the post, which keeps the post from being deleted. Callers must hold the lock.
+func (s *Store) postInCatalog(id uint) bool {
+	for _, c := range s.channels {
+		for _, entry := range c.catalog {
+			if entry.postID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteChannelTables are the tables deleteChannel writes to.
+var deleteChannelTables = []table{channelsTable, feedsTable, postsTable, stickiesTable, commentsTable,
+	usersTable, releasesTable}
+
+// deleteChannel removes the channel along with its posts, releases and
+// subscriptions, like the ON DELETE CASCADE foreign keys do. Callers must hold the lock.
+func (s *Store) deleteChannel(username string) {
+	delete(s.channels, username)
+	for _, f := range s.feeds {
+		delete(f.subscriptions, username)
+	}
+	for id, p := range s.posts {
+		if p.channelFrom == username {
+			s.deletePost(id)
+		}
+	}
+	for id, r := range s.releases {
+		if r.OwnerChannel == username {
+			s.deleteRelease(id)
+		}
+	}
+}
+
+// deletePostTables are the tables deletePost writes to.
+var deletePostTables = []table{postsTable, stickiesTable, commentsTable, usersTable}
+
+// deletePost removes the post along with its comments, stars, contents, stickies
+// and bookmarks, like the ON DELETE CASCADE foreign keys do. Callers must hold the lock.
+func (s *Store) deletePost(id uint) {
+	delete(s.posts, id)
+	delete(s.stickies, id)
+	for commentID, c := range s.comments {
+		if c.OriginPost == int(id) {
+			delete(s.comments, commentID)
+		}
+	}
+	for _, u := range s.users {
+		delete(u.bookmarks, int(id))
+	}
+}
+
+// deleteReleaseTables are the tables deleteRelease writes to.
+var deleteReleaseTables = []table{releasesTable, channelsTable, postsTable}
+
+// deleteRelease removes the release along with its catalog entries and the
+// post contents referencing it, like the ON DELETE CASCADE foreign keys do.
+// Callers must hold the lock.
+func (s *Store) deleteRelease(id int) {
+	delete(s.releases, id)
+	for _, c := range s.channels {
+		catalog := c.catalog[:0]
+		for _, entry := range c.catalog {
+			if entry.releaseID != uint(id) {
+				catalog = append(catalog, entry)
+			}
+		}
+		c.catalog = catalog
+	}
+	for _, p := range s.posts {
+		contents := p.contents[:0]
+		for _, releaseID := range p.contents {
+			if releaseID != uint(id) {
+				contents = append(contents, releaseID)
+			}
+		}
+		p.contents = contents
+	}
+}
+
+// deleteClientTables are the tables deleteClient writes to.
+var deleteClientTables = []table{clientsTable, consentsTable, codesTable}
+
+// deleteClient removes the oauth client along with its consents and codes.
+// Callers must hold the lock.
+func (s *Store) deleteClient(id string) {
+	delete(s.clients, id)
+	for key := range s.consents {
+		if key.clientID == id {
+			delete(s.consents, key)
+		}
+	}
+	for hash, code := range s.codes {
+		if code.ClientID == id {
+			delete(s.codes, hash)
+		}
+	}
+}
+
+// compare is a helper function that compares two values of a column the way
+// ORDER BY would, strings case insensitively.
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case time.Time:
+		switch t := b.(time.Time); {
+		case a.Before(t):
+			return -1
+		case a.After(t):
+			return 1
+		}
+	case int:
+		return a - b.(int)
+	case uint:
+		switch u := b.(uint); {
+		case a < u:
+			return -1
+		case a > u:
+			return 1
+		}
+	}
+	return 0
+}
+
+// orderBy is a helper function that stably sorts the items by the values the
+// given function returns for them, ascending unless the order is DESC.
+// Items the function returns nil for go last, like NULLS LAST.
+func orderBy[T any](items []T, order string, column func(T) interface{}) {
+	descending := strings.EqualFold(order, "DESC")
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := column(items[i]), column(items[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if descending {
+			return compare(a, b) > 0
+		}
+		return compare(a, b) < 0
+	})
+}
+
+// paginate is a helper function that applies LIMIT and OFFSET to the items.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// rank is a helper function that stands in for the full text search of the
+// database. It returns how many times the words of the pattern occur in the
+// given fields or zero if any of the words doesn't occur at all.
+func rank(pattern string, fields ...string) int {
+	text := strings.ToLower(strings.Join(fields, " "))
+	total := 0
+	for _, word := range strings.Fields(strings.ToLower(pattern)) {
+		word = strings.Trim(word, `"'.,;:!?()`)
+		if word == "" {
+			continue
+		}
+		n := strings.Count(text, word)
+		if n == 0 {
+			return 0
+		}
+		total += n
+	}
+	return total
+}
+
+// contains is a helper function that checks whether the pattern occurs in
+// any of the fields, case insensitively like ILIKE '%pattern%' does.
+func contains(pattern string, fields ...string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package inmemory
+
+import (
+	"context"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+)
+
+type searchRepository repository
+
+// NewSearchRepository returns a struct that implements the search.Repository
+// over the given Store.
+func NewSearchRepository(store *Store) search.Repository {
+	return &searchRepository{store}
+}
+
+// SearchComments searches for the comments whose content or commenter match the
+// pattern, the best matches first.
+func (repo *searchRepository) SearchComments(ctx context.Context, pattern string, by string, order string, limit, offset int) ([]*search.Comment, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	var comments = make([]*search.Comment, 0)
+	ranks := make(map[int]int)
+	for id, c := range s.comments {
+		ranks[id] = rank(pattern, c.Content, c.Commenter)
+		if ranks[id] == 0 {
+			continue
+		}
+		comments = append(comments, &search.Comment{
+			ID:           c.ID,
+			OriginPost:   c.OriginPost,
+			Commenter:    c.Commenter,
+			Content:      c.Content,
+			ReplyTo:      c.ReplyTo,
+			CreationTime: c.CreationTime,
+		})
+	}
+	orderBy(comments, "ASC", func(c *search.Comment) interface{} { return c.ID })
+	if by != "" {
+		orderBy(comments, order, func(c *search.Comment) interface{} {
+			switch by {
+			case "commented_by":
+				return c.Commenter
+			case "content":
+				return c.Content
+			case "id":
+				return c.ID
+			default:
+				return c.CreationTime
+			}
+		})
+	}
+	orderBy(comments, "DESC", func(c *search.Comment) interface{} { return ranks[c.ID] })
+	return paginate(comments, limit, offset), nil
+}
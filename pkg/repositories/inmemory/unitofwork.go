@@ -0,0 +1,282 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+)
+
+// table names one of the maps of the Store, the unit units of work snapshot
+// and hold on to.
+type table int
+
+const (
+	usersTable table = iota
+	channelsTable
+	feedsTable
+	releasesTable
+	postsTable
+	commentsTable
+	stickiesTable
+	blacklistTable
+	refreshTokensTable
+	passwordResetTokensTable
+	emailVerificationTokensTable
+	totpSecretsTable
+	personalAccessTokensTable
+	sessionsTable
+	clientsTable
+	consentsTable
+	codesTable
+)
+
+// storeTx is the transaction of a unit of work over a Store. The tables it
+// writes to are snapshotted the first time they are and it rolls back by
+// restoring them.
+type storeTx struct {
+	store    *Store
+	restores map[table]func()
+	done     bool
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork returns a struct that implements the unitofwork.UnitOfWork
+// interface over the given Store. Units of work over the Store run one at a
+// time, but the Store is only locked for the span of each call made in them.
+// Reads made outside of the unit of work see what it has written before it's
+// committed, writes made outside of it to the tables it has written to wait
+// for it to end like they would on the locks of the rows in the database, so
+// they mustn't be made with a context not derived from the one of the unit of
+// work from within it.
+func NewUnitOfWork(store *Store) unitofwork.UnitOfWork {
+	return &unitOfWork{store}
+}
+
+// Do runs fn in a transaction over the Store, see unitofwork.UnitOfWork.
+func (uow *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	s := uow.store
+	s.lock.RLock()
+	joined := s.txOf(ctx) != nil
+	s.lock.RUnlock()
+	if joined {
+		return fn(ctx)
+	}
+	s.work.Lock()
+	tx := &storeTx{store: s, restores: make(map[table]func())}
+	s.lock.Lock()
+	s.tx = tx
+	s.lock.Unlock()
+	return unitofwork.Run(ctx, tx, fn)
+}
+
+// Commit keeps the changes made in the unit of work.
+func (tx *storeTx) Commit() error {
+	return tx.end(false)
+}
+
+// Rollback undoes the changes made in the unit of work. The counters the ids
+// are taken from aren't set back, like sequences aren't in the database.
+func (tx *storeTx) Rollback() error {
+	return tx.end(true)
+}
+
+// end is a helper function that ends the unit of work, restoring the tables it
+// wrote to if it's rolled back, and lets the calls waiting on it go on.
+func (tx *storeTx) end(rollback bool) error {
+	s := tx.store
+	s.lock.Lock()
+	if tx.done {
+		s.lock.Unlock()
+		return fmt.Errorf("unit of work already committed or rolled back")
+	}
+	tx.done = true
+	if rollback {
+		for _, restore := range tx.restores {
+			restore()
+		}
+	}
+	s.tx = nil
+	s.txEnded.Broadcast()
+	s.lock.Unlock()
+	s.work.Unlock()
+	return nil
+}
+
+// snapshot snapshots the given tables the unit of work hasn't written to yet.
+// Callers must hold the lock.
+func (tx *storeTx) snapshot(tables []table) {
+	for _, t := range tables {
+		if _, ok := tx.restores[t]; !ok {
+			tx.restores[t] = tx.store.snapshot(t)
+		}
+	}
+}
+
+// touched tells whether the unit of work has written to any of the given
+// tables. Callers must hold the lock.
+func (tx *storeTx) touched(tables []table) bool {
+	for _, t := range tables {
+		if _, ok := tx.restores[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// txOf returns the unit of work over the Store held by ctx, nil if it holds
+// none or it has already ended. Callers must hold the lock.
+func (s *Store) txOf(ctx context.Context) *storeTx {
+	tx, ok := unitofwork.TxFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	t, ok := tx.(*storeTx)
+	if !ok || t.store != s || t.done {
+		return nil
+	}
+	return t
+}
+
+// writeLock takes the write lock for a call writing to the given tables. Within
+// a unit of work the tables are snapshotted first, outside of it the call waits
+// for the unit of work in progress to end if it has written to any of them. It
+// returns the function that releases the lock.
+func (s *Store) writeLock(ctx context.Context, tables ...table) func() {
+	s.lock.Lock()
+	if tx := s.txOf(ctx); tx != nil {
+		tx.snapshot(tables)
+	} else {
+		for s.tx != nil && s.tx.touched(tables) {
+			s.txEnded.Wait()
+		}
+	}
+	return s.lock.Unlock
+}
+
+// readLock takes the read lock. It returns the function that releases it.
+func (s *Store) readLock() func() {
+	s.lock.RLock()
+	return s.lock.RUnlock
+}
+
+// snapshot deep copies the table and returns the function that puts the copy
+// back. Callers must hold the lock.
+func (s *Store) snapshot(t table) func() {
+	switch t {
+	case usersTable:
+		users := copyMap(s.users, func(u *userRecord) *userRecord {
+			c := *u
+			c.bookmarks = copyMap(u.bookmarks, nil)
+			return &c
+		})
+		return func() { s.users = users }
+	case channelsTable:
+		channels := copyMap(s.channels, func(ch *channelRecord) *channelRecord {
+			c := *ch
+			c.admins = append([]string(nil), ch.admins...)
+			c.catalog = append([]catalogEntry(nil), ch.catalog...)
+			return &c
+		})
+		return func() { s.channels = channels }
+	case feedsTable:
+		feeds := copyMap(s.feeds, func(f *feedRecord) *feedRecord {
+			c := *f
+			c.subscriptions = copyMap(f.subscriptions, nil)
+			return &c
+		})
+		return func() { s.feeds = feeds }
+	case releasesTable:
+		releases := copyMap(s.releases, copyRecord(func(r *release.Release) {
+			r.Authors = append([]string(nil), r.Authors...)
+			r.Genres = append([]string(nil), r.Genres...)
+		}))
+		return func() { s.releases = releases }
+	case postsTable:
+		posts := copyMap(s.posts, func(p *postRecord) *postRecord {
+			c := *p
+			c.contents = append([]uint(nil), p.contents...)
+			c.stars = copyMap(p.stars, nil)
+			return &c
+		})
+		return func() { s.posts = posts }
+	case commentsTable:
+		comments := copyMap(s.comments, copyRecord[comment.Comment](nil))
+		return func() { s.comments = comments }
+	case stickiesTable:
+		stickies := copyMap(s.stickies, nil)
+		return func() { s.stickies = stickies }
+
+	case blacklistTable:
+		blacklist := copyMap(s.blacklist, nil)
+		return func() { s.blacklist = blacklist }
+	case refreshTokensTable:
+		refreshTokens := copyMap(s.refreshTokens, copyRecord[auth.RefreshToken](nil))
+		return func() { s.refreshTokens = refreshTokens }
+	case passwordResetTokensTable:
+		passwordResetTokens := copyMap(s.passwordResetTokens, copyRecord[auth.PasswordResetToken](nil))
+		return func() { s.passwordResetTokens = passwordResetTokens }
+	case emailVerificationTokensTable:
+		emailVerificationTokens := copyMap(s.emailVerificationTokens, copyRecord[auth.EmailVerificationToken](nil))
+		return func() { s.emailVerificationTokens = emailVerificationTokens }
+	case totpSecretsTable:
+		totpSecrets := copyMap(s.totpSecrets, copyRecord(func(ts *auth.TOTPSecret) {
+			ts.RecoveryCodeHashes = append([]string(nil), ts.RecoveryCodeHashes...)
+		}))
+		return func() { s.totpSecrets = totpSecrets }
+	case personalAccessTokensTable:
+		personalAccessTokens := copyMap(s.personalAccessTokens, copyPersonalAccessToken)
+		return func() { s.personalAccessTokens = personalAccessTokens }
+	case sessionsTable:
+		sessions := copyMap(s.sessions, copyRecord(func(session *auth.Session) {
+			session.Scopes = append([]string(nil), session.Scopes...)
+		}))
+		return func() { s.sessions = sessions }
+
+	case clientsTable:
+		clients := copyMap(s.clients, copyClient)
+		return func() { s.clients = clients }
+	case consentsTable:
+		consents := copyMap(s.consents, copyRecord(func(consent *oauth.Consent) {
+			consent.Scopes = append([]string(nil), consent.Scopes...)
+		}))
+		return func() { s.consents = consents }
+	case codesTable:
+		codes := copyMap(s.codes, copyAuthorizationCode)
+		return func() { s.codes = codes }
+	}
+	panic(fmt.Sprintf("inmemory: unknown table %d", t))
+}
+
+// copyMap is a helper function that copies the map, its values with the given
+// function if it isn't nil.
+func copyMap[K comparable, V any](m map[K]V, copyValue func(V) V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		if copyValue != nil {
+			v = copyValue(v)
+		}
+		c[k] = v
+	}
+	return c
+}
+
+// copyRecord is a helper function that returns a function copying records of
+// the given type, the slices of which are copied by the given function if it
+// isn't nil.
+func copyRecord[T any](copySlices func(*T)) func(*T) *T {
+	return func(record *T) *T {
+		c := *record
+		if copySlices != nil {
+			copySlices(&c)
+		}
+		return &c
+	}
+}
@@ -0,0 +1,89 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+)
+
+var errAbort = errors.New("aborted")
+
+// within fails the test if fn doesn't return within a second.
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s is expected to return, it's still waiting after a second", what)
+	}
+}
+
+func TestUnitOfWorkLocksTouchedTablesOnly(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	users := NewUserRepository(store)
+	auths := NewAuthRepository(store)
+	oauths := NewOAuthRepository(store)
+	for _, username := range []string{"alice", "bobby"} {
+		if _, err := users.AddUser(ctx, &user.User{Username: username, Email: username + "@example.com", Password: "password"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waiting := make(chan error, 1)
+	within(t, "a unit of work writing outside of its context to tables it hasn't touched", func() {
+		err := NewUnitOfWork(store).Do(ctx, func(txCtx context.Context) error {
+			if _, err := users.UpdateUser(txCtx, "alice", &user.User{FirstName: "Rolled back"}); err != nil {
+				return err
+			}
+			// neither of these is made in the unit of work, nor has it touched their tables
+			if err := auths.AddToBlacklist(ctx, "token", time.Now().Add(time.Hour)); err != nil {
+				return err
+			}
+			if err := oauths.AddClient(ctx, &oauth.Client{ID: "client", OwnerUsername: "bobby"}); err != nil {
+				return err
+			}
+			// the users table is held until the unit of work ends
+			go func() {
+				_, err := users.UpdateUser(ctx, "bobby", &user.User{FirstName: "Kept"})
+				waiting <- err
+			}()
+			select {
+			case err := <-waiting:
+				t.Errorf("a write to a table the unit of work has touched is expected to wait for it, returned %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+			return errAbort
+		})
+		if err != errAbort {
+			t.Errorf("expected the error of the unit of work, got %v", err)
+		}
+	})
+	within(t, "the write waiting on the unit of work", func() {
+		if err := <-waiting; err != nil {
+			t.Errorf("the write waiting on the unit of work failed because of: %v", err)
+		}
+	})
+
+	if u, err := users.GetUser(ctx, "alice"); err != nil || u.FirstName != "" {
+		t.Errorf("expected the tables the unit of work touched to be restored, got %+v, %v", u, err)
+	}
+	if u, err := users.GetUser(ctx, "bobby"); err != nil || u.FirstName != "Kept" {
+		t.Errorf("expected the write made after the rollback to be kept, got %+v, %v", u, err)
+	}
+	if blacklisted, err := auths.IsInBlacklist(ctx, "token"); err != nil || !blacklisted {
+		t.Errorf("expected the writes made outside of the unit of work to be kept, got %v, %v", blacklisted, err)
+	}
+	if _, err := oauths.GetClient(ctx, "client"); err != nil {
+		t.Errorf("expected the writes made outside of the unit of work to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+// userRepository ...
+type userRepository repository
+
+// NewUserRepository returns a new in memory implementation of user.Repository.
+// Creating a user also creates its channel and feed, like the database does.
+func NewUserRepository(store *Store) user.Repository {
+	return &userRepository{store}
+}
+
+// AddUser takes in a user.User struct and persists it in the store.
+func (repo *userRepository) AddUser(ctx context.Context, u *user.User) (*user.User, error) {
+	passHash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate bcrypt has because: %w", err)
+	}
+	s := repo.store
+	defer s.writeLock(ctx, usersTable, channelsTable, feedsTable)()
+
+	if _, ok := s.users[u.Username]; ok {
+		return nil, fmt.Errorf("insertion of user failed because of: username %s already exists", u.Username)
+	}
+	if _, ok := s.channels[u.Username]; ok {
+		return nil, fmt.Errorf("insertion of user failed because of: channel %s already exists", u.Username)
+	}
+	if s.emailOccupied(u.Email) {
+		return nil, fmt.Errorf("insertion of user failed because of: email %s already exists", u.Email)
+	}
+	now := time.Now()
+	s.users[u.Username] = &userRecord{
+		username:     u.Username,
+		email:        u.Email,
+		passHash:     string(passHash),
+		firstName:    u.FirstName,
+		middleName:   u.MiddleName,
+		lastName:     u.LastName,
+		bio:          u.Bio,
+		creationTime: now,
+		bookmarks:    make(map[int]time.Time),
+	}
+	s.setupUser(u.Username, now)
+	return s.users[u.Username].toUser(), nil
+}
+
+// GetUser retrieves a user.User based on the username passed.
+func (repo *userRepository) GetUser(ctx context.Context, username string) (*user.User, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	u, ok := s.users[username]
+	if !ok {
+		return nil, user.ErrUserNotFound
+	}
+	return u.toUser(), nil
+}
+
+// toUser is just a helper function that copies the record into a user.User.
+func (u *userRecord) toUser() *user.User {
+	bookmarkedPosts := make(map[time.Time]int, len(u.bookmarks))
+	for postID, t := range u.bookmarks {
+		bookmarkedPosts[t] = postID
+	}
+	return &user.User{
+		Username:        u.username,
+		Email:           u.email,
+		FirstName:       u.firstName,
+		MiddleName:      u.middleName,
+		LastName:        u.lastName,
+		CreationTime:    u.creationTime,
+		Bio:             u.bio,
+		BookmarkedPosts: bookmarkedPosts,
+		PictureURL:      u.pictureURL,
+		Verified:        u.verified,
+	}
+}
+
+// emailOccupied is just a helper function. Callers must hold the lock.
+func (s *Store) emailOccupied(email string) bool {
+	for _, u := range s.users {
+		if u.email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateUser updates a user based on the passed user.User struct.
+// Only the non empty fields of the struct are updated, all or nothing.
+func (repo *userRepository) UpdateUser(ctx context.Context, username string, u *user.User) (*user.User, error) {
+	var passHash []byte
+	if u.Password != "" {
+		var err error
+		passHash, err = bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate bcrypt has because: %w", err)
+		}
+	}
+	tables := []table{usersTable}
+	if u.Username != "" && u.Username != username {
+		tables = renameUserTables
+	}
+	s := repo.store
+	defer s.writeLock(ctx, tables...)()
+
+	record, ok := s.users[username]
+	if !ok {
+		return nil, user.ErrUserNotFound
+	}
+	// changes are checked before any is made so that all of them are made or none is
+	if u.Email != "" && u.Email != record.email && s.emailOccupied(u.Email) {
+		return nil, user.ErrSomeUserDataNotPersisted
+	}
+	if u.Username != "" && u.Username != username {
+		if _, ok := s.users[u.Username]; ok {
+			return nil, user.ErrSomeUserDataNotPersisted
+		}
+	}
+	if passHash != nil {
+		record.passHash = string(passHash)
+	}
+	if u.Email != "" && u.Email != record.email {
+		// a changed email has to be verified anew
+		record.email = u.Email
+		record.verified = false
+	}
+	if u.FirstName != "" {
+		record.firstName = u.FirstName
+	}
+	if u.MiddleName != "" {
+		record.middleName = u.MiddleName
+	}
+	if u.LastName != "" {
+		record.lastName = u.LastName
+	}
+	if u.Bio != "" {
+		record.bio = u.Bio
+	}
+	if u.Username != "" && u.Username != username {
+		s.renameUser(username, u.Username)
+	}
+	return record.toUser(), nil
+}
+
+// DeleteUser deletes a user based on the passed in username.
+// Users that have posted or commented can't be deleted.
+func (repo *userRepository) DeleteUser(ctx context.Context, username string) error {
+	s := repo.store
+	defer s.writeLock(ctx, deleteUserTables...)()
+
+	if s.userReferenced(username) {
+		return fmt.Errorf("deletion of user failed because of: user %s is referenced by posts or comments", username)
+	}
+	s.deleteUser(username)
+	return nil
+}
+
+// SearchUser searches for users according to the pattern.
+// If no pattern is provided, it returns all users.
+// It makes use of pagination.
+func (repo *userRepository) SearchUser(ctx context.Context, pattern, sortBy, sortOrder string, limit, offset int) ([]*user.User, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	var users = make([]*user.User, 0)
+	for _, u := range s.users {
+		if pattern == "" || contains(pattern, u.username, u.firstName, u.lastName) {
+			users = append(users, u.toUser())
+		}
+	}
+	orderBy(users, "ASC", func(u *user.User) interface{} { return u.Username })
+	orderBy(users, sortOrder, func(u *user.User) interface{} {
+		switch user.SortBy(sortBy) {
+		case user.SortByUsername:
+			return u.Username
+		case user.SortByFirstName:
+			return nullable(u.FirstName)
+		case user.SortByLastName:
+			return nullable(u.LastName)
+		default:
+			return u.CreationTime
+		}
+	})
+	return paginate(users, limit, offset), nil
+}
+
+// nullable is a helper function that returns nil for empty strings, which the
+// database stores as NULL, so that they're sorted last.
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Authenticate checks the given password against the pass hash of the user
+// of the given username or email.
+func (repo *userRepository) Authenticate(ctx context.Context, u *user.User) (bool, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	for _, record := range s.users {
+		if (u.Username != "" && record.username == u.Username) || (u.Email != "" && record.email == u.Email) {
+			err := bcrypt.CompareHashAndPassword([]byte(record.passHash), []byte(u.Password))
+			return err == nil, nil
+		}
+	}
+	return false, nil
+}
+
+// BookmarkPost bookmarks the given postID for the user of the given username.
+func (repo *userRepository) BookmarkPost(ctx context.Context, username string, postID int) error {
+	s := repo.store
+	defer s.writeLock(ctx, usersTable)()
+
+	u, ok := s.users[username]
+	if !ok {
+		return user.ErrUserNotFound
+	}
+	if _, ok := s.posts[uint(postID)]; !ok || postID < 0 {
+		return user.ErrPostNotFound
+	}
+	if _, ok := u.bookmarks[postID]; !ok {
+		u.bookmarks[postID] = time.Now()
+	}
+	return nil
+}
+
+// DeleteBookmark removes the given ID from the given user's bookmarks
+func (repo *userRepository) DeleteBookmark(ctx context.Context, username string, postID int) error {
+	s := repo.store
+	defer s.writeLock(ctx, usersTable)()
+
+	if u, ok := s.users[username]; ok {
+		delete(u.bookmarks, postID)
+	}
+	return nil
+}
+
+// UsernameOccupied checks if the given username is occupied by another user or a channel
+func (repo *userRepository) UsernameOccupied(ctx context.Context, username string) (bool, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	_, isUser := s.users[username]
+	_, isChannel := s.channels[username]
+	return isUser || isChannel, nil
+}
+
+// EmailOccupied checks if the given email is occupied by another user
+func (repo *userRepository) EmailOccupied(ctx context.Context, email string) (bool, error) {
+	s := repo.store
+	defer s.readLock()()
+
+	return s.emailOccupied(email), nil
+}
+
+// AddPicture persists the given name as the image_name for the user under the given username
+func (repo *userRepository) AddPicture(ctx context.Context, username, name string) error {
+	s := repo.store
+	defer s.writeLock(ctx, usersTable)()
+
+	u, ok := s.users[username]
+	if !ok {
+		return user.ErrUserNotFound
+	}
+	u.pictureURL = name
+	return nil
+}
+
+// RemovePicture removes the picture of the user under the given username.
+func (repo *userRepository) RemovePicture(ctx context.Context, username string) error {
+	s := repo.store
+	defer s.writeLock(ctx, usersTable)()
+
+	if u, ok := s.users[username]; ok {
+		u.pictureURL = ""
+	}
+	return nil
+}
+
+// MarkVerified sets the verified flag of the user of the given username.
+func (repo *userRepository) MarkVerified(ctx context.Context, username string) error {
+	s := repo.store
+	defer s.writeLock(ctx, usersTable)()
+
+	u, ok := s.users[username]
+	if !ok {
+		return user.ErrUserNotFound
+	}
+	u.verified = true
+	return nil
+}
@@ -1,39 +1,230 @@
 package memory
 
 import (
+	"context"
+	"time"
+
 	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
 )
 
+// revocationCacheLifetime is how long a positive blacklist or revoked session
+// lookup is cached for when the real expiry isn't known.
+const revocationCacheLifetime = time.Hour
+
 type jWtAuthRepository struct {
-	blacklist     map[string]struct{}
-	secondaryRepo *auth.Repository
+	blacklist       *Cache[string, struct{}]
+	revokedSessions *Cache[string, struct{}]
+	secondaryRepo   *auth.Repository
 }
 
 // NewAuthRepository returns a new in memory cache implementation of auth.Repository.
 // The database implementation of auth.Repository must be passed as the first argument
 // since to simplify logic, cache repos wrap the database repos.
-func NewAuthRepository(dbRepo *auth.Repository) auth.Repository {
+// The capacity of the given options limits each of the caches while entries
+// expire along with what they revoke instead of after the TTL.
+func NewAuthRepository(dbRepo *auth.Repository, options CacheOptions) auth.Repository {
 	return &jWtAuthRepository{
-		blacklist:     make(map[string]struct{}),
-		secondaryRepo: dbRepo,
+		blacklist:       NewCache[string, struct{}](options),
+		revokedSessions: NewCache[string, struct{}](options),
+		secondaryRepo:   dbRepo,
 	}
 }
 
+// CacheStats returns the counters of the caches of the repo summed up.
+func (repo *jWtAuthRepository) CacheStats() CacheStats {
+	return repo.blacklist.Stats().add(repo.revokedSessions.Stats())
+}
+
 // Authenticate checks whether the given User struct holds appropriate credentials
-func (repo *jWtAuthRepository) Authenticate(user *auth.User) (bool, error) {
-	return (*repo.secondaryRepo).Authenticate(user)
+func (repo *jWtAuthRepository) Authenticate(ctx context.Context, user *auth.User) (bool, error) {
+	return (*repo.secondaryRepo).Authenticate(ctx, user)
+}
+
+// AddToBlacklist adds a given token id to the list of tokens that can not be used no more.
+// It's persisted in the wrapped repo first so that other instances get to know of it.
+func (repo *jWtAuthRepository) AddToBlacklist(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	err := (*repo.secondaryRepo).AddToBlacklist(ctx, tokenID, expiresAt)
+	if err == nil {
+		repo.blacklist.SetWithExpiryIn(ctx, tokenID, struct{}{}, expiresAt)
+	}
+	return err
+}
+
+// IsInBlacklist checks whether a given token id is invalidated previously.
+// Only positive results are cached since the token might've been blacklisted
+// by another instance in the mean time.
+func (repo *jWtAuthRepository) IsInBlacklist(ctx context.Context, tokenID string) (bool, error) {
+	if _, ok := repo.blacklist.Get(tokenID); ok {
+		return true, nil
+	}
+	blacklisted, err := (*repo.secondaryRepo).IsInBlacklist(ctx, tokenID)
+	if err == nil && blacklisted {
+		repo.blacklist.SetWithExpiryIn(ctx, tokenID, struct{}{}, time.Now().Add(revocationCacheLifetime))
+	}
+	return blacklisted, err
+}
+
+// AddRefreshToken directly calls the same method on the wrapped repo.
+// Refresh tokens aren't cached since their single use state has to be
+// consistent across instances.
+func (repo *jWtAuthRepository) AddRefreshToken(ctx context.Context, rt *auth.RefreshToken) error {
+	return (*repo.secondaryRepo).AddRefreshToken(ctx, rt)
+}
+
+// GetRefreshToken directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	return (*repo.secondaryRepo).GetRefreshToken(ctx, tokenHash)
+}
+
+// MarkRefreshTokenUsed directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	return (*repo.secondaryRepo).MarkRefreshTokenUsed(ctx, tokenHash)
 }
 
-// AddToBlacklist adds a given token to the list of tokens that can not be used no more.
-func (repo *jWtAuthRepository) AddToBlacklist(tokenString string) error {
-	(*repo).blacklist[tokenString] = struct{}{}
-	return nil
+// RevokeRefreshTokenFamily directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return (*repo.secondaryRepo).RevokeRefreshTokenFamily(ctx, familyID)
 }
 
-// IsInBlacklist checks whether a given token is invalidated previously.
-func (repo *jWtAuthRepository) IsInBlacklist(token string) (bool, error) {
-	_, ok := (*repo).blacklist[token]
+// GetUser directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetUser(ctx context.Context, identifier string) (*auth.User, error) {
+	return (*repo.secondaryRepo).GetUser(ctx, identifier)
+}
+
+// AddPasswordResetToken directly calls the same method on the wrapped repo.
+// Like refresh tokens, password reset tokens aren't cached.
+func (repo *jWtAuthRepository) AddPasswordResetToken(ctx context.Context, prt *auth.PasswordResetToken) error {
+	return (*repo.secondaryRepo).AddPasswordResetToken(ctx, prt)
+}
+
+// ConsumePasswordResetToken directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*auth.PasswordResetToken, error) {
+	return (*repo.secondaryRepo).ConsumePasswordResetToken(ctx, tokenHash)
+}
+
+// SetPassword directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) SetPassword(ctx context.Context, username, password string) error {
+	return (*repo.secondaryRepo).SetPassword(ctx, username, password)
+}
+
+// AddEmailVerificationToken directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) AddEmailVerificationToken(ctx context.Context, evt *auth.EmailVerificationToken) error {
+	return (*repo.secondaryRepo).AddEmailVerificationToken(ctx, evt)
+}
+
+// GetLastEmailVerificationTime directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetLastEmailVerificationTime(ctx context.Context, username string) (time.Time, error) {
+	return (*repo.secondaryRepo).GetLastEmailVerificationTime(ctx, username)
+}
+
+// ConsumeEmailVerificationToken directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (*auth.EmailVerificationToken, error) {
+	return (*repo.secondaryRepo).ConsumeEmailVerificationToken(ctx, tokenHash)
+}
+
+// SetTOTPSecret directly calls the same method on the wrapped repo.
+// TOTP enrolments aren't cached since the replay protection they carry has
+// to be consistent across instances.
+func (repo *jWtAuthRepository) SetTOTPSecret(ctx context.Context, ts *auth.TOTPSecret) error {
+	return (*repo.secondaryRepo).SetTOTPSecret(ctx, ts)
+}
+
+// GetTOTPSecret directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetTOTPSecret(ctx context.Context, username string) (*auth.TOTPSecret, error) {
+	return (*repo.secondaryRepo).GetTOTPSecret(ctx, username)
+}
 
-	// ok will be true if it's in the blacklist
-	return ok, nil
+// ConfirmTOTPSecret directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) ConfirmTOTPSecret(ctx context.Context, username string) error {
+	return (*repo.secondaryRepo).ConfirmTOTPSecret(ctx, username)
+}
+
+// DeleteTOTPSecret directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) DeleteTOTPSecret(ctx context.Context, username string) error {
+	return (*repo.secondaryRepo).DeleteTOTPSecret(ctx, username)
+}
+
+// UpdateTOTPLastUsedStep directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) UpdateTOTPLastUsedStep(ctx context.Context, username string, step int64) (bool, error) {
+	return (*repo.secondaryRepo).UpdateTOTPLastUsedStep(ctx, username, step)
+}
+
+// ConsumeTOTPRecoveryCode directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) ConsumeTOTPRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	return (*repo.secondaryRepo).ConsumeTOTPRecoveryCode(ctx, username, codeHash)
+}
+
+// AddPersonalAccessToken directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) AddPersonalAccessToken(ctx context.Context, pat *auth.PersonalAccessToken) error {
+	return (*repo.secondaryRepo).AddPersonalAccessToken(ctx, pat)
+}
+
+// GetPersonalAccessToken directly calls the same method on the wrapped repo.
+// Personal access tokens aren't cached so that revocations take effect immediately.
+func (repo *jWtAuthRepository) GetPersonalAccessToken(ctx context.Context, tokenHash string) (*auth.PersonalAccessToken, error) {
+	return (*repo.secondaryRepo).GetPersonalAccessToken(ctx, tokenHash)
+}
+
+// GetPersonalAccessTokens directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetPersonalAccessTokens(ctx context.Context, username string) ([]*auth.PersonalAccessToken, error) {
+	return (*repo.secondaryRepo).GetPersonalAccessTokens(ctx, username)
+}
+
+// RevokePersonalAccessToken directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) RevokePersonalAccessToken(ctx context.Context, username, id string) error {
+	return (*repo.secondaryRepo).RevokePersonalAccessToken(ctx, username, id)
+}
+
+// UpdatePersonalAccessTokenLastUsed directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) UpdatePersonalAccessTokenLastUsed(ctx context.Context, id string, lastUsedTime time.Time) error {
+	return (*repo.secondaryRepo).UpdatePersonalAccessTokenLastUsed(ctx, id, lastUsedTime)
+}
+
+// AddSession directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) AddSession(ctx context.Context, session *auth.Session) error {
+	return (*repo.secondaryRepo).AddSession(ctx, session)
+}
+
+// GetSession directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetSession(ctx context.Context, id string) (*auth.Session, error) {
+	return (*repo.secondaryRepo).GetSession(ctx, id)
+}
+
+// GetSessions directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) GetSessions(ctx context.Context, username string) ([]*auth.Session, error) {
+	return (*repo.secondaryRepo).GetSessions(ctx, username)
+}
+
+// UpdateSession directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) UpdateSession(ctx context.Context, id string, lastUsedTime, expiresAt time.Time) error {
+	return (*repo.secondaryRepo).UpdateSession(ctx, id, lastUsedTime, expiresAt)
+}
+
+// RevokeSession revokes the session in the wrapped repo first so that other
+// instances get to know of it.
+func (repo *jWtAuthRepository) RevokeSession(ctx context.Context, username, id string) error {
+	err := (*repo.secondaryRepo).RevokeSession(ctx, username, id)
+	if err == nil {
+		repo.revokedSessions.SetWithExpiryIn(ctx, id, struct{}{}, time.Now().Add(revocationCacheLifetime))
+	}
+	return err
+}
+
+// RevokeSessions directly calls the same method on the wrapped repo.
+func (repo *jWtAuthRepository) RevokeSessions(ctx context.Context, username, exceptID string) error {
+	return (*repo.secondaryRepo).RevokeSessions(ctx, username, exceptID)
+}
+
+// IsSessionRevoked checks whether the session with the given id has been revoked.
+// Only positive results are cached since the session might've been revoked
+// by another instance in the mean time.
+func (repo *jWtAuthRepository) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	if _, ok := repo.revokedSessions.Get(id); ok {
+		return true, nil
+	}
+	revoked, err := (*repo.secondaryRepo).IsSessionRevoked(ctx, id)
+	if err == nil && revoked {
+		repo.revokedSessions.SetWithExpiryIn(ctx, id, struct{}{}, time.Now().Add(revocationCacheLifetime))
+	}
+	return revoked, err
 }
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheOptions holds the limits of the caches used by the repos of this package.
+// Capacity is the number of entries a cache holds before it starts evicting the
+// least recently used ones and TTL is how long an entry is kept. Zero values
+// mean no limit.
+type CacheOptions struct {
+	Capacity int
+	TTL      time.Duration
+}
+
+// CacheStats holds the counters of a cache.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Expired   uint64 `json:"expired"`
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+}
+
+// StatsReporter is implemented by the repos of this package that cache entries.
+type StatsReporter interface {
+	CacheStats() CacheStats
+}
+
+// add is a helper function that sums up the stats of two caches.
+func (s CacheStats) add(other CacheStats) CacheStats {
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.Evictions += other.Evictions
+	s.Expired += other.Expired
+	s.Size += other.Size
+	s.Capacity += other.Capacity
+	return s
+}
+
+// Cache is a bounded map safe for concurrent use. Once it's full, the least
+// recently used entry is evicted to make room for a new one and entries that
+// have outlived their expiry are dropped when they're next looked up.
+type Cache[K comparable, V any] struct {
+	lock    sync.Mutex
+	options CacheOptions
+	entries map[K]*list.Element
+	order   *list.List // front is the most recently used
+	stats   CacheStats
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewCache returns an empty Cache limited by the given options.
+func NewCache[K comparable, V any](options CacheOptions) *Cache[K, V] {
+	return &Cache[K, V]{
+		options: options,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value stored under the given key and whether it was found.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry[K, V])
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			c.order.MoveToFront(element)
+			c.stats.Hits++
+			return entry.value, true
+		}
+		c.remove(element)
+		c.stats.Expired++
+	}
+	c.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+// Set stores the value under the given key, replacing any value already there.
+// It expires after the TTL of the cache.
+func (c *Cache[K, V]) Set(key K, value V) {
+	var expiresAt time.Time
+	if c.options.TTL > 0 {
+		expiresAt = time.Now().Add(c.options.TTL)
+	}
+	c.SetWithExpiry(key, value, expiresAt)
+}
+
+// SetWithExpiry stores the value under the given key until the given time.
+// A zero time means the entry doesn't expire.
+func (c *Cache[K, V]) SetWithExpiry(key K, value V, expiresAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.options.Capacity > 0 && c.order.Len() > c.options.Capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// Delete removes the value stored under the given key if there's any.
+func (c *Cache[K, V]) Delete(key K) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// SetIn is like Set but made in the unit of work ctx may hold, see SetWithExpiryIn.
+func (c *Cache[K, V]) SetIn(ctx context.Context, key K, value V) {
+	var expiresAt time.Time
+	if c.options.TTL > 0 {
+		expiresAt = time.Now().Add(c.options.TTL)
+	}
+	c.SetWithExpiryIn(ctx, key, value, expiresAt)
+}
+
+// SetWithExpiryIn is like SetWithExpiry but made in the unit of work ctx may
+// hold. The value is then only stored once the unit of work is committed, see
+// NewUnitOfWork, and the key is deleted in the mean time for the value from
+// before not to outlive it.
+func (c *Cache[K, V]) SetWithExpiryIn(ctx context.Context, key K, value V, expiresAt time.Time) {
+	if !afterCommit(ctx, func() { c.SetWithExpiry(key, value, expiresAt) }) {
+		c.SetWithExpiry(key, value, expiresAt)
+		return
+	}
+	c.Delete(key)
+}
+
+// DeleteIn is like Delete but made in the unit of work ctx may hold. The key is
+// deleted again once the unit of work is committed in case the value from
+// before was cached in the mean time.
+func (c *Cache[K, V]) DeleteIn(ctx context.Context, key K) {
+	c.Delete(key)
+	afterCommit(ctx, func() { c.Delete(key) })
+}
+
+// Purge removes all the values of the cache.
+func (c *Cache[K, V]) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Len returns the number of values in the cache, including expired ones
+// that haven't been looked up since.
+func (c *Cache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns a snapshot of the counters of the cache.
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.options.Capacity
+	return stats
+}
+
+// remove is a helper function that removes the given element from the cache.
+// Callers must hold the lock.
+func (c *Cache[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry[K, V]).key)
+}
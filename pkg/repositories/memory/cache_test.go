@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/inmemory"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+func TestCacheGetSet(t *testing.T) {
+	c := NewCache[string, int](CacheOptions{})
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get of a missing key is expected to miss")
+	}
+	c.Set("a", 1)
+	c.Set("a", 2)
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Errorf("Get is expected to return the last value set, got %d, %v", v, ok)
+	}
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get of a deleted key is expected to miss")
+	}
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Purge()
+	if c.Len() != 0 {
+		t.Errorf("Purge is expected to empty the cache, %d entries left", c.Len())
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache[string, int](CacheOptions{Capacity: 3})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Get("a")    // b is now the least recently used
+	c.Set("c", 4) // and setting counts as a use too
+	c.Set("d", 5)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("the least recently used entry is expected to be evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%q is expected to be kept", key)
+		}
+	}
+	c.Set("e", 6) // a is now the least recently used
+	if _, ok := c.Get("a"); ok {
+		t.Error("entries are expected to be evicted in order of their last use")
+	}
+	if c.Len() != 3 {
+		t.Errorf("the cache is expected to hold its capacity, holds %d", c.Len())
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := NewCache[string, int](CacheOptions{TTL: 20 * time.Millisecond})
+	c.Set("ttl", 1)
+	c.SetWithExpiry("later", 2, time.Now().Add(time.Hour))
+	c.SetWithExpiry("never", 3, time.Time{})
+	c.SetWithExpiry("past", 4, time.Now().Add(-time.Second))
+
+	if _, ok := c.Get("ttl"); !ok {
+		t.Error("an entry is expected to be kept within its TTL")
+	}
+	if _, ok := c.Get("past"); ok {
+		t.Error("an entry past its expiry is expected to miss")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("ttl"); ok {
+		t.Error("an entry is expected to expire after the TTL")
+	}
+	for _, key := range []string{"later", "never"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("%q is expected to outlive the TTL of the cache", key)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("expired entries are expected to be dropped when looked up, %d entries left", c.Len())
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	c := NewCache[string, int](CacheOptions{Capacity: 2})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a")
+	c.Get("a")
+	c.Get("missing")
+	c.Set("c", 3) // evicts b
+	c.Set("d", 4) // evicts a
+	c.SetWithExpiry("d", 4, time.Now().Add(-time.Second))
+	c.Get("d")
+
+	want := CacheStats{Hits: 2, Misses: 2, Evictions: 2, Expired: 1, Size: 1, Capacity: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats is expected to return %+v, got %+v", want, got)
+	}
+	if got := want.add(want); got.Hits != 4 || got.Size != 2 || got.Capacity != 4 {
+		t.Errorf("adding stats is expected to sum them, got %+v", got)
+	}
+}
+
+func TestCacheConcurrentUse(t *testing.T) {
+	c := NewCache[int, string](CacheOptions{Capacity: 64, TTL: time.Millisecond})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (g*31 + i) % 100
+				switch i % 7 {
+				case 0:
+					c.Delete(key)
+				case 1:
+					c.SetWithExpiry(key, "forever", time.Time{})
+				case 2:
+					if i%100 == 2 {
+						c.Purge()
+					}
+				case 3:
+					c.Stats()
+					c.Len()
+				default:
+					if v, ok := c.Get(key); ok && v != "forever" && v != fmt.Sprint(key) {
+						t.Errorf("Get of %d returned %q, a value never set under it", key, v)
+					}
+					c.Set(key, fmt.Sprint(key))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if stats := c.Stats(); stats.Size > 64 {
+		t.Errorf("the cache is expected to stay within its capacity, holds %d", stats.Size)
+	}
+}
+
+func TestCachedRepositoryConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewStore()
+	users := inmemory.NewUserRepository(store)
+	if _, err := users.AddUser(ctx, &user.User{Username: "alice", Email: "alice@example.com", Password: "password"}); err != nil {
+		t.Fatal(err)
+	}
+	dbRepo := inmemory.NewReleaseRepository(store)
+	repo := NewReleaseRepository(&dbRepo, CacheOptions{Capacity: 4})
+	var ids []int
+	for i := 0; i < 8; i++ {
+		r, err := repo.AddRelease(ctx, &release.Release{OwnerChannel: "alice", Type: release.Text, Content: "first"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, r.ID)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				id := ids[(g+i)%len(ids)]
+				switch i % 5 {
+				case 0:
+					_, err := repo.UpdateRelease(ctx, &release.Release{ID: id, Type: release.Text, Content: fmt.Sprint("by ", g)})
+					if err != nil {
+						t.Errorf("updating release %d failed because of: %v", id, err)
+					}
+				case 1:
+					repo.(Invalidator).Invalidate("release", fmt.Sprint(id))
+				case 2:
+					if i%50 == 2 {
+						repo.(Invalidator).Purge()
+					}
+				default:
+					if _, err := repo.GetRelease(ctx, id); err != nil {
+						t.Errorf("getting release %d failed because of: %v", id, err)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// writes go through the cache to the store
+	for _, id := range ids {
+		if _, err := repo.UpdateRelease(ctx, &release.Release{ID: id, Type: release.Text, Content: "last"}); err != nil {
+			t.Fatal(err)
+		}
+		cached, err := repo.GetRelease(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, err := dbRepo.GetRelease(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached.Content != "last" || stored.Content != "last" {
+			t.Errorf("release %d is cached with %q but stored with %q", id, cached.Content, stored.Content)
+		}
+	}
+	if stats := repo.(StatsReporter).CacheStats(); stats.Size > 4 {
+		t.Errorf("the cache of the repo is expected to stay within its capacity, holds %d", stats.Size)
+	}
+}
+
+func TestCachedRepositoryInUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	store := inmemory.NewStore()
+	users := inmemory.NewUserRepository(store)
+	if _, err := users.AddUser(ctx, &user.User{Username: "alice", Email: "alice@example.com", Password: "password"}); err != nil {
+		t.Fatal(err)
+	}
+	dbRepo := inmemory.NewReleaseRepository(store)
+	repo := NewReleaseRepository(&dbRepo, CacheOptions{})
+	cache := repo.(*releaseRepository).cache
+	uow := NewUnitOfWork(inmemory.NewUnitOfWork(store))
+	other, err := repo.AddRelease(ctx, &release.Release{OwnerChannel: "alice", Type: release.Text, Content: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := repo.AddRelease(ctx, &release.Release{OwnerChannel: "alice", Type: release.Text, Content: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := func(ctx context.Context, content string) error {
+		_, err := repo.UpdateRelease(ctx, &release.Release{ID: rel.ID, Type: release.Text, Content: content})
+		return err
+	}
+	errRollback := fmt.Errorf("rolled back")
+
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		if err := update(ctx, "rolled back"); err != nil {
+			return err
+		}
+		if r, ok := cache.Get(rel.ID); ok {
+			t.Errorf("release is cached with %q before the unit of work is committed", r.Content)
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Fatalf("expected the error of the unit of work, got %v", err)
+	}
+	if r, err := repo.GetRelease(ctx, rel.ID); err != nil || r.Content != "first" {
+		t.Errorf("expected release to be left as it was after the rollback, got %v, %v", r, err)
+	}
+	if _, ok := cache.Get(other.ID); !ok {
+		t.Error("releases the unit of work didn't touch are expected to stay cached after the rollback")
+	}
+
+	// joined units of work are only cached once the outermost one is committed
+	err = uow.Do(ctx, func(ctx context.Context) error {
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			return update(ctx, "committed")
+		})
+		if err != nil {
+			return err
+		}
+		if _, ok := cache.Get(rel.ID); ok {
+			t.Error("release is cached before the outermost unit of work is committed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := cache.Get(rel.ID); !ok || r.Content != "committed" {
+		t.Errorf("expected release to be cached once the unit of work is committed, got %q, %v", r.Content, ok)
+	}
+}
@@ -1,12 +1,14 @@
 package memory
 
 import (
+	"context"
+
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
 )
 
 //ChannelRepository...
 type ChannelRepository struct {
-	cache         map[string]channel.Channel
+	cache         *Cache[string, channel.Channel]
 	secondaryRepo *channel.Repository
 	allRepos      *map[string]interface{}
 }
@@ -17,54 +19,76 @@ type ChannelRepository struct {
 // A map of all the other cache based implementations of the Repository interfaces
 // found in the different services of the project must be passed as a second argument as
 // the Repository might make use of them to fetch objects instead of implementing redundant logic.
-func NewChannelRepository(dbRepo *channel.Repository, allRepos *map[string]interface{}) channel.Repository {
-	return &ChannelRepository{make(map[string]channel.Channel, 100), dbRepo, allRepos}
+// The cache is limited by the given options.
+func NewChannelRepository(dbRepo *channel.Repository, allRepos *map[string]interface{}, options CacheOptions) channel.Repository {
+	return &ChannelRepository{NewCache[string, channel.Channel](options), dbRepo, allRepos}
+}
+
+// CacheStats returns the counters of the cache of the repo.
+func (repo *ChannelRepository) CacheStats() CacheStats {
+	return repo.cache.Stats()
+}
+
+// Invalidate evicts the channel stored under the given key, see Invalidator.
+func (repo *ChannelRepository) Invalidate(entity, key string) {
+	if entity != "channel" {
+		return
+	}
+	if key == "" {
+		repo.cache.Purge()
+		return
+	}
+	repo.cache.Delete(key)
+}
+
+// Purge evicts all the cached channels.
+func (repo *ChannelRepository) Purge() {
+	repo.cache.Purge()
 }
 
 // cacheChannel is just a helper function
-func (repo *ChannelRepository) cacheChannel(channelUsername string) error {
-	c, err := (*repo.secondaryRepo).GetChannel(channelUsername)
+func (repo *ChannelRepository) cacheChannel(ctx context.Context, channelUsername string) error {
+	c, err := (*repo.secondaryRepo).GetChannel(ctx, channelUsername)
 	if err != nil {
 		return err
 	}
 
-	repo.cache[channelUsername] = *c
+	repo.cache.SetIn(ctx, channelUsername, *c)
 
 	return err
 }
 
 // AddChannel takes in a channel.Channel struct and persists it.
 // Returns an error if the DB repository implementation returns an error.
-func (repo *ChannelRepository) AddChannel(channel *channel.Channel) (*channel.Channel, error) {
-	return (*repo.secondaryRepo).AddChannel(channel)
+func (repo *ChannelRepository) AddChannel(ctx context.Context, channel *channel.Channel) (*channel.Channel, error) {
+	return (*repo.secondaryRepo).AddChannel(ctx, channel)
 }
 
 // GetChannel retrieves a channel.Channel based on the channelUsername passed.
-func (repo *ChannelRepository) GetChannel(channelUsername string) (*channel.Channel, error) {
-	if _, ok := repo.cache[channelUsername]; ok == false {
-
-		err := repo.cacheChannel(channelUsername)
-		if err != nil {
-			return nil, err
-		}
+func (repo *ChannelRepository) GetChannel(ctx context.Context, channelUsername string) (*channel.Channel, error) {
+	if c, ok := repo.cache.Get(channelUsername); ok {
+		return &c, nil
 	}
-
-	c := repo.cache[channelUsername]
-	return &c, nil
+	c, err := (*repo.secondaryRepo).GetChannel(ctx, channelUsername)
+	if err != nil {
+		return nil, err
+	}
+	repo.cache.SetIn(ctx, channelUsername, *c)
+	return c, nil
 }
 
 // UpdateChannel updates a channel based on the passed channel.Channel struct.
-func (repo *ChannelRepository) UpdateChannel(channelUsername string, c *channel.Channel) (*channel.Channel, error) {
-	c, err := (*repo.secondaryRepo).UpdateChannel(channelUsername, c)
+func (repo *ChannelRepository) UpdateChannel(ctx context.Context, channelUsername string, c *channel.Channel) (*channel.Channel, error) {
+	c, err := (*repo.secondaryRepo).UpdateChannel(ctx, channelUsername, c)
 	if err == nil {
 		if c.ChannelUsername != "" {
-			err = repo.cacheChannel(c.ChannelUsername)
+			repo.cache.DeleteIn(ctx, channelUsername)
+			err = repo.cacheChannel(ctx, c.ChannelUsername)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			delete(repo.cache, c.ChannelUsername)
-			err = repo.cacheChannel(channelUsername)
+			err = repo.cacheChannel(ctx, channelUsername)
 			if err != nil {
 				return nil, err
 			}
@@ -74,32 +98,31 @@ func (repo *ChannelRepository) UpdateChannel(channelUsername string, c *channel.
 }
 
 // DeleteChannel deletes a channel based on the passed channelUsername.
-func (repo *ChannelRepository) DeleteChannel(channelUsername string) error {
-	err := (*repo.secondaryRepo).DeleteChannel(channelUsername)
+func (repo *ChannelRepository) DeleteChannel(ctx context.Context, channelUsername string) error {
+	err := (*repo.secondaryRepo).DeleteChannel(ctx, channelUsername)
 	if err == nil {
-		delete(repo.cache, channelUsername)
+		repo.cache.DeleteIn(ctx, channelUsername)
 	}
 	return err
 }
 
 // SearchChannel calls the DB repo SearchChannel function.
 // It also caches all the channels returned by the result.
-func (repo *ChannelRepository) SearchChannels(pattern string, sortBy channel.SortBy, sortOrder channel.SortOrder, limit, offset int) ([]*channel.Channel, error) {
-	result, err := (*repo.secondaryRepo).SearchChannels(pattern, sortBy, sortOrder, limit, offset)
+func (repo *ChannelRepository) SearchChannels(ctx context.Context, pattern string, sortBy channel.SortBy, sortOrder channel.SortOrder, limit, offset int) ([]*channel.Channel, error) {
+	result, err := (*repo.secondaryRepo).SearchChannels(ctx, pattern, sortBy, sortOrder, limit, offset)
 	if err == nil {
 		for _, c := range result {
-			cTemp := *c
-			repo.cache[c.ChannelUsername] = cTemp
+			repo.cache.SetIn(ctx, c.ChannelUsername, *c)
 		}
 	}
 	return result, err
 }
 
 // AddAdmin calls the DB repo AddAdmin function.
-func (repo *ChannelRepository) AddAdmin(channelUsername string, adminUsername string) error {
-	err := (*repo.secondaryRepo).AddAdmin(channelUsername, adminUsername)
+func (repo *ChannelRepository) AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	err := (*repo.secondaryRepo).AddAdmin(ctx, channelUsername, adminUsername)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -108,10 +131,10 @@ func (repo *ChannelRepository) AddAdmin(channelUsername string, adminUsername st
 }
 
 // DeleteAdmin calls the DB repo DeleteAdmin function.
-func (repo *ChannelRepository) DeleteAdmin(channelUsername string, adminUsername string) error {
-	err := (*repo.secondaryRepo).DeleteAdmin(channelUsername, adminUsername)
+func (repo *ChannelRepository) DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	err := (*repo.secondaryRepo).DeleteAdmin(ctx, channelUsername, adminUsername)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -120,10 +143,10 @@ func (repo *ChannelRepository) DeleteAdmin(channelUsername string, adminUsername
 }
 
 // ChangeOwner calls the DB repo ChangeOwner function.
-func (repo *ChannelRepository) ChangeOwner(channelUsername string, ownerUsername string) error {
-	err := (*repo.secondaryRepo).ChangeOwner(channelUsername, ownerUsername)
+func (repo *ChannelRepository) ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error {
+	err := (*repo.secondaryRepo).ChangeOwner(ctx, channelUsername, ownerUsername)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -132,10 +155,10 @@ func (repo *ChannelRepository) ChangeOwner(channelUsername string, ownerUsername
 }
 
 // AddReleaseToOfficialCatalog calls the DB repo AddReleaseToOfficialCatalog function.
-func (repo *ChannelRepository) AddReleaseToOfficialCatalog(channelUsername string, releaseID uint, postID uint) error {
-	err := (*repo.secondaryRepo).AddReleaseToOfficialCatalog(channelUsername, releaseID, postID)
+func (repo *ChannelRepository) AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error {
+	err := (*repo.secondaryRepo).AddReleaseToOfficialCatalog(ctx, channelUsername, releaseID, postID)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -144,10 +167,10 @@ func (repo *ChannelRepository) AddReleaseToOfficialCatalog(channelUsername strin
 }
 
 // DeleteReleaseFromCatalog calls the DB repo DeleteReleaseFromCatalog function.
-func (repo *ChannelRepository) DeleteReleaseFromCatalog(channelUsername string, ReleaseID uint) error {
-	err := (*repo.secondaryRepo).DeleteReleaseFromCatalog(channelUsername, ReleaseID)
+func (repo *ChannelRepository) DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error {
+	err := (*repo.secondaryRepo).DeleteReleaseFromCatalog(ctx, channelUsername, ReleaseID)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -156,10 +179,10 @@ func (repo *ChannelRepository) DeleteReleaseFromCatalog(channelUsername string,
 }
 
 // DeleteReleaseFromOfficialCatalog calls the DB repo DeleteReleaseFromOfficialCatalog function.
-func (repo *ChannelRepository) DeleteReleaseFromOfficialCatalog(channelUsername string, ReleaseID uint) error {
-	err := (*repo.secondaryRepo).DeleteReleaseFromOfficialCatalog(channelUsername, ReleaseID)
+func (repo *ChannelRepository) DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error {
+	err := (*repo.secondaryRepo).DeleteReleaseFromOfficialCatalog(ctx, channelUsername, ReleaseID)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -168,10 +191,10 @@ func (repo *ChannelRepository) DeleteReleaseFromOfficialCatalog(channelUsername
 }
 
 // StickyPost calls the DB repo StickyPost function.
-func (repo *ChannelRepository) StickyPost(channelUsername string, postID uint) error {
-	err := (*repo.secondaryRepo).StickyPost(channelUsername, postID)
+func (repo *ChannelRepository) StickyPost(ctx context.Context, channelUsername string, postID uint) error {
+	err := (*repo.secondaryRepo).StickyPost(ctx, channelUsername, postID)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -180,10 +203,10 @@ func (repo *ChannelRepository) StickyPost(channelUsername string, postID uint) e
 }
 
 // DeleteStickiedPost calls the DB repo DeleteStickiedPost function.
-func (repo *ChannelRepository) DeleteStickiedPost(channelUsername string, stickiedPostID uint) error {
-	err := (*repo.secondaryRepo).DeleteStickiedPost(channelUsername, stickiedPostID)
+func (repo *ChannelRepository) DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error {
+	err := (*repo.secondaryRepo).DeleteStickiedPost(ctx, channelUsername, stickiedPostID)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -192,10 +215,10 @@ func (repo *ChannelRepository) DeleteStickiedPost(channelUsername string, sticki
 }
 
 // AddPicture calls the same method on the wrapped repo with a lil caching in between.
-func (repo *ChannelRepository) AddPicture(channelUsername, name string) (string, error) {
-	a, err := (*repo.secondaryRepo).AddPicture(channelUsername, name)
+func (repo *ChannelRepository) AddPicture(ctx context.Context, channelUsername, name string) (string, error) {
+	a, err := (*repo.secondaryRepo).AddPicture(ctx, channelUsername, name)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return "", err
 		}
@@ -204,10 +227,10 @@ func (repo *ChannelRepository) AddPicture(channelUsername, name string) (string,
 }
 
 // RemovePicture calls the same method on the wrapped repo with a lil caching in between.
-func (repo *ChannelRepository) RemovePicture(channelUsername string) error {
-	err := (*repo.secondaryRepo).RemovePicture(channelUsername)
+func (repo *ChannelRepository) RemovePicture(ctx context.Context, channelUsername string) error {
+	err := (*repo.secondaryRepo).RemovePicture(ctx, channelUsername)
 	if err == nil {
-		err = repo.cacheChannel(channelUsername)
+		err = repo.cacheChannel(ctx, channelUsername)
 		if err != nil {
 			return err
 		}
@@ -1,84 +1,109 @@
 package memory
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
 )
 
 type commentRepository struct {
-	cache         map[int]comment.Comment
+	cache         *Cache[int, comment.Comment]
 	secondaryRepo *comment.Repository
 }
 
 // NewCommentRepository returns a struct that implements the comment.Repository using
 // a cached based implementation.
 // A database implementation of the same interface needs to be passed so that it can be
-// consulted when the caches aren't enough.
-func NewCommentRepository(secondaryRepo *comment.Repository) comment.Repository {
-	return &commentRepository{make(map[int]comment.Comment), secondaryRepo}
+// consulted when the caches aren't enough. The cache is limited by the given options.
+func NewCommentRepository(secondaryRepo *comment.Repository, options CacheOptions) comment.Repository {
+	return &commentRepository{NewCache[int, comment.Comment](options), secondaryRepo}
+}
+
+// CacheStats returns the counters of the cache of the repo.
+func (repo *commentRepository) CacheStats() CacheStats {
+	return repo.cache.Stats()
+}
+
+// Invalidate evicts the comment stored under the given key, see Invalidator.
+func (repo *commentRepository) Invalidate(entity, key string) {
+	if entity != "comment" {
+		return
+	}
+	if key == "" {
+		repo.cache.Purge()
+		return
+	}
+	if id, err := strconv.Atoi(key); err == nil {
+		repo.cache.Delete(id)
+	}
+}
+
+// Purge evicts all the cached comments.
+func (repo *commentRepository) Purge() {
+	repo.cache.Purge()
 }
 
 // AddComment calls the same method on the wrapped repo with a little caching in between.
-func (repo *commentRepository) AddComment(c *comment.Comment) (*comment.Comment, error) {
-	c, err := (*repo.secondaryRepo).AddComment(c)
+func (repo *commentRepository) AddComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
+	c, err := (*repo.secondaryRepo).AddComment(ctx, c)
 	if err == nil {
-		repo.cache[c.ID] = *c
+		repo.cache.SetIn(ctx, c.ID, *c)
 	}
 	return c, err
 }
 
 // GetComment returns the comment under the given id from the cache ,if found,
 // or from the the wrapped repository,
-func (repo *commentRepository) GetComment(id int) (*comment.Comment, error) {
-	if c, ok := repo.cache[id]; ok == false {
-		var err error
-		c, err := (*repo.secondaryRepo).GetComment(id)
-		if err != nil {
-			return nil, err
-		}
-		repo.cache[id] = *c
-		return c, nil
-	} else {
+func (repo *commentRepository) GetComment(ctx context.Context, id int) (*comment.Comment, error) {
+	if c, ok := repo.cache.Get(id); ok {
 		return &c, nil
 	}
+	c, err := (*repo.secondaryRepo).GetComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	repo.cache.SetIn(ctx, id, *c)
+	return c, nil
 }
 
 // GetComments calls the same method on the wrapped repo with a little caching in between.
-func (repo *commentRepository) GetComments(postID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
-	result, err := (*repo.secondaryRepo).GetComments(postID, by, order, limit, offset)
+func (repo *commentRepository) GetComments(ctx context.Context, postID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+	result, err := (*repo.secondaryRepo).GetComments(ctx, postID, by, order, limit, offset)
 	if err == nil {
 		for _, c := range result {
-			repo.cache[c.ID] = *c
+			repo.cache.SetIn(ctx, c.ID, *c)
 		}
 	}
 	return result, err
 }
 
 // GetReplies calls the same method on the wrapped repo with a little caching in between.
-func (repo *commentRepository) GetReplies(commentID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
-	result, err := (*repo.secondaryRepo).GetReplies(commentID, by, order, limit, offset)
+func (repo *commentRepository) GetReplies(ctx context.Context, commentID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+	result, err := (*repo.secondaryRepo).GetReplies(ctx, commentID, by, order, limit, offset)
 	if err == nil {
 		for _, c := range result {
-			repo.cache[c.ID] = *c
+			repo.cache.SetIn(ctx, c.ID, *c)
 		}
 	}
 	return result, err
 }
 
 // UpdateComment calls the same method on the wrapped repo with a little caching in between.
-func (repo *commentRepository) UpdateComment(c *comment.Comment) (*comment.Comment, error) {
-	c, err := (*repo.secondaryRepo).UpdateComment(c)
+func (repo *commentRepository) UpdateComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
+	c, err := (*repo.secondaryRepo).UpdateComment(ctx, c)
 	if err == nil {
-		repo.cache[c.ID] = *c
+		repo.cache.SetIn(ctx, c.ID, *c)
 	}
 	return c, err
 }
 
 // DeleteRelease calls the same method on the wrapped repo while also cleaning the cache
 // when appropriate.
-func (repo *commentRepository) DeleteComment(id int) error {
-	err := (*repo.secondaryRepo).DeleteComment(id)
+func (repo *commentRepository) DeleteComment(ctx context.Context, id int) error {
+	err := (*repo.secondaryRepo).DeleteComment(ctx, id)
 	if err == nil {
-		delete(repo.cache, id)
+		repo.cache.DeleteIn(ctx, id)
 	}
 	return err
 }
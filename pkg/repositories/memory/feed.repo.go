@@ -1,10 +1,14 @@
 package memory
 
-import "github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+import (
+	"context"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+)
 
 //feedRepository ...
 type feedRepository struct {
-	cache         map[string]feed.Feed
+	cache         *Cache[string, feed.Feed]
 	secondaryRepo *feed.Repository
 	allRepos      *map[string]interface{}
 }
@@ -15,71 +19,95 @@ type feedRepository struct {
 // A map of all the other cache based implementations of the Repository interfaces
 // found in the different services of the project must be passed as a second argument as
 // the Repository might make use of them to fetch objects instead of implementing redundant logic.
-func NewFeedRepository(secondaryRepo *feed.Repository, allRepos *map[string]interface{}) feed.Repository {
-	return &feedRepository{cache: make(map[string]feed.Feed), secondaryRepo: secondaryRepo, allRepos: allRepos}
+// The cache is limited by the given options.
+func NewFeedRepository(secondaryRepo *feed.Repository, allRepos *map[string]interface{}, options CacheOptions) feed.Repository {
+	return &feedRepository{cache: NewCache[string, feed.Feed](options), secondaryRepo: secondaryRepo, allRepos: allRepos}
+}
+
+// CacheStats returns the counters of the cache of the repo.
+func (repo *feedRepository) CacheStats() CacheStats {
+	return repo.cache.Stats()
+}
+
+// Invalidate evicts the feed stored under the given key, see Invalidator.
+func (repo *feedRepository) Invalidate(entity, key string) {
+	if entity != "feed" {
+		return
+	}
+	if key == "" {
+		repo.cache.Purge()
+		return
+	}
+	repo.cache.Delete(key)
+}
+
+// Purge evicts all the cached feeds.
+func (repo *feedRepository) Purge() {
+	repo.cache.Purge()
 }
 
 // GetFeed returns the feed belonging to the given username from either the
 // cache if found there or the secondary repos it wraps.
-func (repo *feedRepository) GetFeed(username string) (*feed.Feed, error) {
-	if _, ok := repo.cache[username]; ok == false {
-		err := repo.cacheFeed(username)
-		if err != nil {
-			return nil, err
-		}
+func (repo *feedRepository) GetFeed(ctx context.Context, username string) (*feed.Feed, error) {
+	if f, ok := repo.cache.Get(username); ok {
+		return &f, nil
+	}
+	f, err := (*repo.secondaryRepo).GetFeed(ctx, username)
+	if err != nil {
+		return nil, err
 	}
-	f := repo.cache[username]
-	return &f, nil
+	repo.cache.SetIn(ctx, username, *f)
+	return f, nil
 }
 
 //GetChannels directly calls the same method on the secondary repos it wraps to
 // get all the channels the given feed has subscribed to.
-func (repo *feedRepository) GetChannels(f *feed.Feed, sortBy string, sortOrder string) ([]*feed.Channel, error) {
-	return (*repo.secondaryRepo).GetChannels(f, sortBy, sortOrder)
+func (repo *feedRepository) GetChannels(ctx context.Context, f *feed.Feed, sortBy string, sortOrder string) ([]*feed.Channel, error) {
+	return (*repo.secondaryRepo).GetChannels(ctx, f, sortBy, sortOrder)
 }
 
 //AddFeed directly calls the same method on the secondary repos it wraps to
 // persist a feed to the username contained in the feed struct.
-func (repo *feedRepository) AddFeed(f *feed.Feed) error {
-	return (*repo.secondaryRepo).AddFeed(f)
+func (repo *feedRepository) AddFeed(ctx context.Context, f *feed.Feed) error {
+	return (*repo.secondaryRepo).AddFeed(ctx, f)
 }
 
 // cacheFeed is a helper function used to refresh items to the cache
-func (repo *feedRepository) cacheFeed(username string) error {
-	u, err := (*repo.secondaryRepo).GetFeed(username)
+func (repo *feedRepository) cacheFeed(ctx context.Context, username string) error {
+	u, err := (*repo.secondaryRepo).GetFeed(ctx, username)
 	if err != nil {
 		return err
 	}
-	repo.cache[username] = *u
+	repo.cache.SetIn(ctx, username, *u)
 	return nil
 }
 
 //GetPosts directly calls the same method on the secondary repos it wraps to
 // retrieve a list of posts collected from the channels the given feed has
 // subscribed to sorted according to the given method.
-func (repo *feedRepository) GetPosts(f *feed.Feed, sort feed.Sorting, limit, offset int) ([]*feed.Post, error) {
-	return (*repo.secondaryRepo).GetPosts(f, sort, limit, offset)
+func (repo *feedRepository) GetPosts(ctx context.Context, f *feed.Feed, sort feed.Sorting, limit, offset int) ([]*feed.Post, error) {
+	return (*repo.secondaryRepo).GetPosts(ctx, f, sort, limit, offset)
 }
 
 // UpdateFeed directly calls the same method on the secondary repos it wraps to
 // update the feed at the given id according to the given struct and if successful,
 // refreshes the feed entry of the cache.
-func (repo *feedRepository) UpdateFeed(id uint, f *feed.Feed) error {
-	err := (*repo.secondaryRepo).UpdateFeed(id, f)
+func (repo *feedRepository) UpdateFeed(ctx context.Context, id uint, f *feed.Feed) error {
+	err := (*repo.secondaryRepo).UpdateFeed(ctx, id, f)
 	if err == nil {
-		err = repo.cacheFeed(f.OwnerUsername)
+		err = repo.cacheFeed(ctx, f.OwnerUsername)
 	}
 	return err
 }
 
 // Subscribe directly calls the same method on the secondary repos it wraps to
 // add the channel to the list of channels that the feed collects posts from.
-func (repo *feedRepository) Subscribe(f *feed.Feed, channelname string) error {
-	return (*repo.secondaryRepo).Subscribe(f, channelname)
+func (repo *feedRepository) Subscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	return (*repo.secondaryRepo).Subscribe(ctx, f, channelname)
 }
 
 //Unsubscribe directly calls the same method on the secondary repos it wraps to
 // remove the channel to the list of channels that the feed collects posts from.
-func (repo *feedRepository) Unsubscribe(f *feed.Feed, channelname string) error {
-	return (*repo.secondaryRepo).Unsubscribe(f, channelname)
+func (repo *feedRepository) Unsubscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	return (*repo.secondaryRepo).Unsubscribe(ctx, f, channelname)
 }
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// InvalidationChannel is the channel the triggers of the database notify on
+// when rows the caches are built from change.
+const InvalidationChannel = "cache_invalidation"
+
+// Invalidator is implemented by the repos of this package whose entries can be
+// changed by other instances.
+type Invalidator interface {
+	// Invalidate evicts the entry of the given entity stored under the given key.
+	// An empty key evicts all the entries of the entity.
+	Invalidate(entity, key string)
+	// Purge evicts all the entries.
+	Purge()
+}
+
+// invalidation is the payload of the notifications sent on the InvalidationChannel.
+type invalidation struct {
+	Entity string  `json:"entity"`
+	Key    *string `json:"key"`
+}
+
+// Listener evicts entries of the caches of this instance when notified of
+// changes made to the database, by any instance, so that they don't serve
+// stale entries. Writes made by this instance are notified back to it as well,
+// the extra eviction only costs a read.
+type Listener struct {
+	listener     *pq.Listener
+	logger       *log.Logger
+	invalidators []Invalidator
+	done         chan struct{}
+	once         sync.Once
+}
+
+// NewListener returns a Listener that connects to the database with the given
+// connection string and evicts the entries of the given Invalidators.
+func NewListener(dataSourceName string, logger *log.Logger, invalidators ...Invalidator) *Listener {
+	l := &Listener{
+		logger:       logger,
+		invalidators: invalidators,
+		done:         make(chan struct{}),
+	}
+	l.listener = pq.NewListener(dataSourceName, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			l.logger.Printf("cache invalidation listener: %v", err)
+		}
+	})
+	return l
+}
+
+// Listen starts listening on the InvalidationChannel in the background.
+func (l *Listener) Listen() error {
+	if err := l.listener.Listen(InvalidationChannel); err != nil {
+		return err
+	}
+	go l.run()
+	return nil
+}
+
+// Close stops listening and closes the connection.
+func (l *Listener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.listener.Close()
+	})
+	return err
+}
+
+// run is a helper function that handles notifications until the Listener is closed.
+func (l *Listener) run() {
+	for {
+		select {
+		case <-l.done:
+			return
+		case n, ok := <-l.listener.Notify:
+			if !ok {
+				return
+			}
+			// a nil notification is sent after the connection is re-established,
+			// whatever was notified in the mean time is lost
+			if n == nil {
+				l.logger.Printf("cache invalidation listener reconnected, purging caches")
+				for _, invalidator := range l.invalidators {
+					invalidator.Purge()
+				}
+				continue
+			}
+			l.handle(n.Extra)
+		case <-time.After(90 * time.Second):
+			// makes sure a dropped connection is noticed even when nothing's notified
+			go l.listener.Ping()
+		}
+	}
+}
+
+// handle is a helper function that evicts the entries the given payload refers to.
+func (l *Listener) handle(payload string) {
+	var inv invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil || inv.Entity == "" {
+		l.logger.Printf("cache invalidation listener: ignoring payload %q", payload)
+		return
+	}
+	key := ""
+	if inv.Key != nil {
+		key = *inv.Key
+	}
+	for _, invalidator := range l.invalidators {
+		invalidator.Invalidate(inv.Entity, key)
+	}
+}
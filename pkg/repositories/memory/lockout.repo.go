@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+)
+
+type lockoutRepository struct {
+	attempts map[string]*lockout.Attempts
+	lock     sync.Mutex
+	logger   *log.Logger
+}
+
+// NewLockoutRepository returns a new in memory implementation of lockout.Repository.
+// Unlike the other repos of this package, it doesn't wrap a database repo and the
+// attempts it tracks are only known to this instance. Audit events are written to
+// the given logger.
+func NewLockoutRepository(logger *log.Logger) lockout.Repository {
+	return &lockoutRepository{
+		attempts: make(map[string]*lockout.Attempts),
+		logger:   logger,
+	}
+}
+
+// GetAttempts returns a copy of the attempts recorded under the given key.
+func (repo *lockoutRepository) GetAttempts(ctx context.Context, key string) (*lockout.Attempts, error) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+	if a, ok := repo.attempts[key]; ok {
+		copied := *a
+		return &copied, nil
+	}
+	return &lockout.Attempts{Key: key}, nil
+}
+
+// AddFailure counts a failure under the given key. Records that are past their
+// window and lockout are pruned on the way.
+func (repo *lockoutRepository) AddFailure(ctx context.Context, key string, at, resetBefore time.Time) (*lockout.Attempts, error) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+	a, ok := repo.attempts[key]
+	if !ok {
+		a = &lockout.Attempts{Key: key}
+		repo.attempts[key] = a
+	}
+	if a.LastFailure.Before(resetBefore) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailure = at
+	copied := *a
+	repo.pruneAttempts(resetBefore)
+	return &copied, nil
+}
+
+// SetLockedUntil locks the given key until the given time.
+func (repo *lockoutRepository) SetLockedUntil(ctx context.Context, key string, lockedUntil time.Time) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+	if a, ok := repo.attempts[key]; ok {
+		a.LockedUntil = lockedUntil
+	}
+	return nil
+}
+
+// ResetAttempts removes the attempts recorded under the given key.
+func (repo *lockoutRepository) ResetAttempts(ctx context.Context, key string) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+	delete(repo.attempts, key)
+	return nil
+}
+
+// AddEvent writes the given audit event to the logger.
+func (repo *lockoutRepository) AddEvent(ctx context.Context, e *lockout.Event) error {
+	repo.logger.Printf("audit: %s of %s after %d failures until %s",
+		e.Kind, e.Key, e.Failures, e.LockedUntil.Format(time.RFC3339))
+	return nil
+}
+
+// pruneAttempts is a helper function that removes records that are past their
+// window and lockout. Callers must hold the lock.
+func (repo *lockoutRepository) pruneAttempts(resetBefore time.Time) {
+	now := time.Now()
+	for key, a := range repo.attempts {
+		if a.LastFailure.Before(resetBefore) && now.After(a.LockedUntil) {
+			delete(repo.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+)
+
+type oAuthRepository struct {
+	secondaryRepo *oauth.Repository
+}
+
+// NewOAuthRepository returns a new in memory cache implementation of oauth.Repository.
+// The database implementation of oauth.Repository must be passed as the first argument
+// since to simplify logic, cache repos wrap the database repos.
+func NewOAuthRepository(dbRepo *oauth.Repository) oauth.Repository {
+	return &oAuthRepository{secondaryRepo: dbRepo}
+}
+
+// AddClient directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) AddClient(ctx context.Context, c *oauth.Client) error {
+	return (*repo.secondaryRepo).AddClient(ctx, c)
+}
+
+// GetClient directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) GetClient(ctx context.Context, id string) (*oauth.Client, error) {
+	return (*repo.secondaryRepo).GetClient(ctx, id)
+}
+
+// GetClients directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) GetClients(ctx context.Context, ownerUsername string) ([]*oauth.Client, error) {
+	return (*repo.secondaryRepo).GetClients(ctx, ownerUsername)
+}
+
+// DeleteClient directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) DeleteClient(ctx context.Context, ownerUsername, id string) error {
+	return (*repo.secondaryRepo).DeleteClient(ctx, ownerUsername, id)
+}
+
+// GetConsent directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) GetConsent(ctx context.Context, username, clientID string) (*oauth.Consent, error) {
+	return (*repo.secondaryRepo).GetConsent(ctx, username, clientID)
+}
+
+// SetConsent directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) SetConsent(ctx context.Context, c *oauth.Consent) error {
+	return (*repo.secondaryRepo).SetConsent(ctx, c)
+}
+
+// AddAuthorizationCode directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) AddAuthorizationCode(ctx context.Context, code *oauth.AuthorizationCode) error {
+	return (*repo.secondaryRepo).AddAuthorizationCode(ctx, code)
+}
+
+// ConsumeAuthorizationCode directly calls the same method on the wrapped repo.
+func (repo *oAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*oauth.AuthorizationCode, error) {
+	return (*repo.secondaryRepo).ConsumeAuthorizationCode(ctx, codeHash)
+}
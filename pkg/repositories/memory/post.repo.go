@@ -1,86 +1,112 @@
 package memory
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
 )
 
 //postRepository ...
 type postRepository struct {
-	cache         map[uint]post.Post
+	cache         *Cache[uint, post.Post]
 	secondaryRepo *post.Repository
 }
 
 // NewPostRepository returns a struct that implements the post.Repository using
-func NewPostRepository(secondaryRepo *post.Repository) post.Repository {
-	return &postRepository{cache: make(map[uint]post.Post), secondaryRepo: secondaryRepo}
+// a cache limited by the given options.
+func NewPostRepository(secondaryRepo *post.Repository, options CacheOptions) post.Repository {
+	return &postRepository{cache: NewCache[uint, post.Post](options), secondaryRepo: secondaryRepo}
+}
+
+// CacheStats returns the counters of the cache of the repo.
+func (repo *postRepository) CacheStats() CacheStats {
+	return repo.cache.Stats()
+}
+
+// Invalidate evicts the post stored under the given key, see Invalidator.
+func (repo *postRepository) Invalidate(entity, key string) {
+	if entity != "post" {
+		return
+	}
+	if key == "" {
+		repo.cache.Purge()
+		return
+	}
+	if id, err := strconv.ParseUint(key, 10, 0); err == nil {
+		repo.cache.Delete(uint(id))
+	}
+}
+
+// Purge evicts all the cached posts.
+func (repo *postRepository) Purge() {
+	repo.cache.Purge()
 }
 
 // cachePost is just a helper function to update the cache with new states of the struct
-func (repo *postRepository) cachePost(id uint) error {
-	u, err := (*repo.secondaryRepo).GetPost(id)
+func (repo *postRepository) cachePost(ctx context.Context, id uint) error {
+	u, err := (*repo.secondaryRepo).GetPost(ctx, id)
 	if err != nil {
 		return err
 	}
-	repo.cache[id] = *u
+	repo.cache.SetIn(ctx, id, *u)
 	return nil
 }
 
 // GetPost gets the Post stored under the given id.
-func (repo *postRepository) GetPost(id uint) (*post.Post, error) {
+func (repo *postRepository) GetPost(ctx context.Context, id uint) (*post.Post, error) {
 
-	if _, ok := repo.cache[id]; ok == false {
-		r, err := (*repo.secondaryRepo).GetPost(id)
-		if err != nil {
-			return nil, err
-		}
-		repo.cache[id] = *r
+	if p, ok := repo.cache.Get(id); ok {
+		return &p, nil
 	}
-	r := repo.cache[id]
-	return &r, nil
+	p, err := (*repo.secondaryRepo).GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	repo.cache.SetIn(ctx, id, *p)
+	return p, nil
 
 }
 
 // DeletePost Deletes the Post stored under the given id.
-func (repo *postRepository) DeletePost(id uint) error {
-	_, found := repo.cache[id]
-	if !found {
-		return post.ErrPostNotFound
+func (repo *postRepository) DeletePost(ctx context.Context, id uint) error {
+	if _, err := repo.GetPost(ctx, id); err != nil {
+		return err
 	}
-	err := (*repo.secondaryRepo).DeletePost(id)
+	err := (*repo.secondaryRepo).DeletePost(ctx, id)
 	if err == nil {
-		delete(repo.cache, id)
+		repo.cache.DeleteIn(ctx, id)
 	}
 	return err
 }
 
 // AddPost Adds the Post stored under the given id.
-func (repo *postRepository) AddPost(p *post.Post) (*post.Post, error) {
-	p, err := (*repo.secondaryRepo).AddPost(p)
+func (repo *postRepository) AddPost(ctx context.Context, p *post.Post) (*post.Post, error) {
+	p, err := (*repo.secondaryRepo).AddPost(ctx, p)
 	if err == nil {
-		repo.cache[p.ID] = *p
+		repo.cache.SetIn(ctx, p.ID, *p)
 	}
 	return p, err
 }
 
 //UpdatePost updates the post with given id and post struct
-func (repo *postRepository) UpdatePost(pos *post.Post, id uint) (*post.Post, error) {
-	_, found := repo.cache[id]
-	if !found {
-		return nil, post.ErrPostNotFound
+func (repo *postRepository) UpdatePost(ctx context.Context, pos *post.Post, id uint) (*post.Post, error) {
+	if _, err := repo.GetPost(ctx, id); err != nil {
+		return nil, err
 	}
-	p, err := (*repo.secondaryRepo).UpdatePost(pos, id)
+	p, err := (*repo.secondaryRepo).UpdatePost(ctx, pos, id)
 	if err == nil {
-		repo.cache[p.ID] = *p
+		repo.cache.SetIn(ctx, p.ID, *p)
 	}
 	return p, err
 }
 
 // SearchPost gets all Posts under specfications
-func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order post.SortOrder, limit int, offset int) ([]*post.Post, error) {
-	pos, err := (*repo.secondaryRepo).SearchPost(pattern, by, order, limit, offset)
+func (repo *postRepository) SearchPost(ctx context.Context, pattern string, by post.SortBy, order post.SortOrder, limit int, offset int) ([]*post.Post, error) {
+	pos, err := (*repo.secondaryRepo).SearchPost(ctx, pattern, by, order, limit, offset)
 	if err == nil {
 		for _, p := range pos {
-			repo.cache[p.ID] = *p
+			repo.cache.SetIn(ctx, p.ID, *p)
 
 		}
 	}
@@ -88,12 +114,11 @@ func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order pos
 }
 
 // GetPostStar gets the star stored under the given postid and username.
-func (repo *postRepository) GetPostStar(id uint, username string) (*post.Star, error) {
-	_, found := repo.cache[id]
-	if !found {
-		return nil, post.ErrPostNotFound
+func (repo *postRepository) GetPostStar(ctx context.Context, id uint, username string) (*post.Star, error) {
+	if _, err := repo.GetPost(ctx, id); err != nil {
+		return nil, err
 	}
-	s, err := (*repo.secondaryRepo).GetPostStar(id, username)
+	s, err := (*repo.secondaryRepo).GetPostStar(ctx, id, username)
 	if err != nil {
 		return nil, err
 	}
@@ -101,30 +126,28 @@ func (repo *postRepository) GetPostStar(id uint, username string) (*post.Star, e
 }
 
 //DeletePostStar deletes the star stored under given postid and username
-func (repo *postRepository) DeletePostStar(id uint, username string) error {
-	_, found := repo.cache[id]
-	if !found {
-		return post.ErrPostNotFound
+func (repo *postRepository) DeletePostStar(ctx context.Context, id uint, username string) error {
+	if _, err := repo.GetPost(ctx, id); err != nil {
+		return err
 	}
-	err := (*repo.secondaryRepo).DeletePostStar(id, username)
+	err := (*repo.secondaryRepo).DeletePostStar(ctx, id, username)
 	if err != nil {
 		return err
 	}
-	err = repo.cachePost(id)
+	err = repo.cachePost(ctx, id)
 	return err
 }
 
 //AddPostStar adds a star given postid, number of stars and username
-func (repo *postRepository) AddPostStar(id uint, star *post.Star) (*post.Star, error) {
-	_, found := repo.cache[id]
-	if !found {
-		return nil, post.ErrPostNotFound
+func (repo *postRepository) AddPostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
+	if _, err := repo.GetPost(ctx, id); err != nil {
+		return nil, err
 	}
-	s, err := (*repo.secondaryRepo).AddPostStar(id, star)
+	s, err := (*repo.secondaryRepo).AddPostStar(ctx, id, star)
 	if err != nil {
 		return nil, err
 	}
-	errs := repo.cachePost(id)
+	errs := repo.cachePost(ctx, id)
 	if errs != nil {
 		return nil, err
 	}
@@ -132,16 +155,15 @@ func (repo *postRepository) AddPostStar(id uint, star *post.Star) (*post.Star, e
 }
 
 //UpdatePostStar updates a star stored given postid, number of stars and username
-func (repo *postRepository) UpdatePostStar(id uint, star *post.Star) (*post.Star, error) {
-	_, found := repo.cache[id]
-	if !found {
-		return nil, post.ErrPostNotFound
+func (repo *postRepository) UpdatePostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
+	if _, err := repo.GetPost(ctx, id); err != nil {
+		return nil, err
 	}
-	s, err := (*repo.secondaryRepo).UpdatePostStar(id, star)
+	s, err := (*repo.secondaryRepo).UpdatePostStar(ctx, id, star)
 	if err != nil {
 		return nil, err
 	}
-	errs := repo.cachePost(id)
+	errs := repo.cachePost(ctx, id)
 	if errs != nil {
 		return nil, err
 	}
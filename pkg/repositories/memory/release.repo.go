@@ -1,44 +1,70 @@
 package memory
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
 )
 
 //releaseRepository ...
 type releaseRepository struct {
-	cache         map[int]release.Release
+	cache         *Cache[int, release.Release]
 	secondaryRepo *release.Repository
 }
 
 // NewReleaseRepository returns a struct that implements the release.Repository using
 // a cached based implementation.
 // A database implementation of the same interface needs to be passed so that it can be
-// consulted when the caches aren't enough.
-func NewReleaseRepository(secondaryRepo *release.Repository) release.Repository {
-	return &releaseRepository{cache: make(map[int]release.Release), secondaryRepo: secondaryRepo}
+// consulted when the caches aren't enough. The cache is limited by the given options.
+func NewReleaseRepository(secondaryRepo *release.Repository, options CacheOptions) release.Repository {
+	return &releaseRepository{cache: NewCache[int, release.Release](options), secondaryRepo: secondaryRepo}
+}
+
+// CacheStats returns the counters of the cache of the repo.
+func (repo *releaseRepository) CacheStats() CacheStats {
+	return repo.cache.Stats()
+}
+
+// Invalidate evicts the release stored under the given key, see Invalidator.
+func (repo *releaseRepository) Invalidate(entity, key string) {
+	if entity != "release" {
+		return
+	}
+	if key == "" {
+		repo.cache.Purge()
+		return
+	}
+	if id, err := strconv.Atoi(key); err == nil {
+		repo.cache.Delete(id)
+	}
+}
+
+// Purge evicts all the cached releases.
+func (repo *releaseRepository) Purge() {
+	repo.cache.Purge()
 }
 
 // GetRelease returns the release under the given id from the cache ,if found,
 // or from the the wrapped repository,
-func (repo *releaseRepository) GetRelease(id int) (*release.Release, error) {
-	if _, ok := repo.cache[id]; ok == false {
-		r, err := (*repo.secondaryRepo).GetRelease(id)
-		if err != nil {
-			return nil, err
-		}
-		repo.cache[id] = *r
+func (repo *releaseRepository) GetRelease(ctx context.Context, id int) (*release.Release, error) {
+	if r, ok := repo.cache.Get(id); ok {
+		return &r, nil
+	}
+	r, err := (*repo.secondaryRepo).GetRelease(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	r := repo.cache[id]
-	return &r, nil
+	repo.cache.SetIn(ctx, id, *r)
+	return r, nil
 }
 
 // SearchRelease calls the same method on the wrapped repo with a little caching in between.
-func (repo *releaseRepository) SearchRelease(pattern string, by release.SortBy, order release.SortOrder, limit int, offset int) ([]*release.Release, error) {
-	result, err := (*repo.secondaryRepo).SearchRelease(pattern, by, order, limit, offset)
+func (repo *releaseRepository) SearchRelease(ctx context.Context, pattern string, by release.SortBy, order release.SortOrder, limit int, offset int) ([]*release.Release, error) {
+	result, err := (*repo.secondaryRepo).SearchRelease(ctx, pattern, by, order, limit, offset)
 	if err == nil {
 		for _, r := range result {
-			rTemp := *r
-			repo.cache[r.ID] = rTemp
+			repo.cache.SetIn(ctx, r.ID, *r)
 		}
 	}
 	return result, err
@@ -46,29 +72,29 @@ func (repo *releaseRepository) SearchRelease(pattern string, by release.SortBy,
 
 // DeleteRelease calls the same method on the wrapped repo while also cleaning the cache
 // when appropriate.
-func (repo *releaseRepository) DeleteRelease(id int) error {
-	err := (*repo.secondaryRepo).DeleteRelease(id)
+func (repo *releaseRepository) DeleteRelease(ctx context.Context, id int) error {
+	err := (*repo.secondaryRepo).DeleteRelease(ctx, id)
 	if err == nil {
 		// If deletion is successful, it also tries to delete the user from its cache.
-		delete(repo.cache, id)
+		repo.cache.DeleteIn(ctx, id)
 	}
 	return err
 }
 
 // AddRelease calls the same method on the wrapped repo with a little caching in between.
-func (repo *releaseRepository) AddRelease(r *release.Release) (*release.Release, error) {
-	r, err := (*repo.secondaryRepo).AddRelease(r)
+func (repo *releaseRepository) AddRelease(ctx context.Context, r *release.Release) (*release.Release, error) {
+	r, err := (*repo.secondaryRepo).AddRelease(ctx, r)
 	if err == nil {
-		repo.cache[r.ID] = *r
+		repo.cache.SetIn(ctx, r.ID, *r)
 	}
 	return r, err
 }
 
 // UpdateRelease calls the same method on the wrapped repo with a little caching in between.
-func (repo *releaseRepository) UpdateRelease(rel *release.Release) (*release.Release, error) {
-	r, err := (*repo.secondaryRepo).UpdateRelease(rel)
+func (repo *releaseRepository) UpdateRelease(ctx context.Context, rel *release.Release) (*release.Release, error) {
+	r, err := (*repo.secondaryRepo).UpdateRelease(ctx, rel)
 	if err == nil {
-		repo.cache[r.ID] = *r
+		repo.cache.SetIn(ctx, r.ID, *r)
 	}
 	return r, err
 }
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+)
+
+type unitOfWork struct {
+	dbUnitOfWork unitofwork.UnitOfWork
+}
+
+// pendingWrites holds the writes made to the caches in a unit of work until
+// it's committed.
+type pendingWrites struct {
+	lock   sync.Mutex
+	writes []func()
+}
+
+type pendingWritesKey struct{}
+
+// NewUnitOfWork returns a struct that implements the unitofwork.UnitOfWork
+// interface by running units of work on the given one, that of the repos the
+// caches of this package are in front of. What the repos cache in a unit of
+// work is only stored once it's committed, see Cache.SetWithExpiryIn, so that
+// neither other requests nor a rolled back unit of work get to see what never was.
+func NewUnitOfWork(dbUnitOfWork unitofwork.UnitOfWork) unitofwork.UnitOfWork {
+	return &unitOfWork{dbUnitOfWork}
+}
+
+// Do runs fn in a unit of work of the database, see unitofwork.UnitOfWork.
+func (uow *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(pendingWritesKey{}).(*pendingWrites); ok {
+		// joined units of work are committed along with the outermost one
+		return uow.dbUnitOfWork.Do(ctx, fn)
+	}
+	pending := new(pendingWrites)
+	err := uow.dbUnitOfWork.Do(context.WithValue(ctx, pendingWritesKey{}, pending), fn)
+	if err != nil {
+		return err
+	}
+	pending.lock.Lock()
+	defer pending.lock.Unlock()
+	for _, write := range pending.writes {
+		write()
+	}
+	return nil
+}
+
+// afterCommit is a helper function that holds the write back until the unit
+// of work ctx holds is committed. It returns false, leaving the write to the
+// caller, if ctx holds none.
+func afterCommit(ctx context.Context, write func()) bool {
+	pending, ok := ctx.Value(pendingWritesKey{}).(*pendingWrites)
+	if !ok {
+		return false
+	}
+	pending.lock.Lock()
+	defer pending.lock.Unlock()
+	pending.writes = append(pending.writes, write)
+	return true
+}
@@ -1,12 +1,14 @@
 package memory
 
 import (
+	"context"
+
 	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
 )
 
 // userRepository ...
 type userRepository struct {
-	cache         map[string]user.User
+	cache         *Cache[string, user.User]
 	secondaryRepo *user.Repository
 	allRepos      *map[string]interface{}
 }
@@ -17,88 +19,111 @@ type userRepository struct {
 // A map of all the other cache based implementations of the Repository interfaces
 // found in the different services of the project must be passed as a second argument as
 // the Repository might make user of them to fetch objects instead of implementing redundant logic.
-func NewUserRepository(dbRepo *user.Repository, allRepos *map[string]interface{}) user.Repository {
-	return &userRepository{make(map[string]user.User, 100), dbRepo, allRepos}
+// The cache is limited by the given options.
+func NewUserRepository(dbRepo *user.Repository, allRepos *map[string]interface{}, options CacheOptions) user.Repository {
+	return &userRepository{NewCache[string, user.User](options), dbRepo, allRepos}
+}
+
+// CacheStats returns the counters of the cache of the repo.
+func (repo *userRepository) CacheStats() CacheStats {
+	return repo.cache.Stats()
+}
+
+// Invalidate evicts the user stored under the given key, see Invalidator.
+func (repo *userRepository) Invalidate(entity, key string) {
+	if entity != "user" {
+		return
+	}
+	if key == "" {
+		repo.cache.Purge()
+		return
+	}
+	repo.cache.Delete(key)
+}
+
+// Purge evicts all the cached users.
+func (repo *userRepository) Purge() {
+	repo.cache.Purge()
 }
 
 // AddUser takes in a user.User struct and persists it.
 // Returns an error if the DB repository implementation returns an error.
-func (repo *userRepository) AddUser(u *user.User) (*user.User, error) {
-	u, err := (*repo.secondaryRepo).AddUser(u)
+func (repo *userRepository) AddUser(ctx context.Context, u *user.User) (*user.User, error) {
+	u, err := (*repo.secondaryRepo).AddUser(ctx, u)
 	if err == nil {
-		repo.cache[u.Username] = *u
+		repo.cache.SetIn(ctx, u.Username, *u)
 	}
 	return u, err
 }
 
 // GetUser retrieves a user.User based on the username passed.
-func (repo *userRepository) GetUser(username string) (*user.User, error) {
-	if _, ok := repo.cache[username]; ok == false {
-		err := repo.cacheUser(username)
-		if err != nil {
-			return nil, err
-		}
+func (repo *userRepository) GetUser(ctx context.Context, username string) (*user.User, error) {
+	if u, ok := repo.cache.Get(username); ok {
+		return &u, nil
 	}
-	u := repo.cache[username]
-	return &u, nil
+	u, err := (*repo.secondaryRepo).GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	repo.cache.SetIn(ctx, username, *u)
+	return u, nil
 
 }
 
 // cacheUser is just a helper function
-func (repo *userRepository) cacheUser(username string) error {
-	u, err := (*repo.secondaryRepo).GetUser(username)
+func (repo *userRepository) cacheUser(ctx context.Context, username string) error {
+	u, err := (*repo.secondaryRepo).GetUser(ctx, username)
 	if err != nil {
 		return err
 	}
-	repo.cache[username] = *u
+	repo.cache.SetIn(ctx, username, *u)
 	return nil
 }
 
 // UpdateUser updates a user based on the passed user.User struct.
-func (repo *userRepository) UpdateUser(username string, u *user.User) (*user.User, error) {
-	u, err := (*repo.secondaryRepo).UpdateUser(username, u)
+func (repo *userRepository) UpdateUser(ctx context.Context, username string, u *user.User) (*user.User, error) {
+	u, err := (*repo.secondaryRepo).UpdateUser(ctx, username, u)
 	if err == nil {
 		// If updating in the DB repo is successful, it updates its cache by getting
 		// the new user.User and converting it into a cache able format.
 		if u.Username != "" {
 			// if the username is changed, use the new username from the struct to update the cache
-			repo.cache[u.Username] = *u
+			repo.cache.DeleteIn(ctx, username)
+			repo.cache.SetIn(ctx, u.Username, *u)
 		} else {
-			delete(repo.cache, u.Username)
-			repo.cache[username] = *u
+			repo.cache.SetIn(ctx, username, *u)
 		}
 	}
 	return u, err
 }
 
 // DeleteUser deletes a user based on the passed in username.
-func (repo *userRepository) DeleteUser(username string) error {
-	err := (*repo.secondaryRepo).DeleteUser(username)
+func (repo *userRepository) DeleteUser(ctx context.Context, username string) error {
+	err := (*repo.secondaryRepo).DeleteUser(ctx, username)
 	if err == nil {
 		// If deletion is successful, it also tries to delete the user from its cache.
-		delete(repo.cache, username)
+		repo.cache.DeleteIn(ctx, username)
 	}
 	return err
 }
 
 // SearchUser calls the DB repo SearchUser function.
 // It also caches all the users returned by the result.
-func (repo *userRepository) SearchUser(pattern, sortBy, sortOrder string, limit, offset int) ([]*user.User, error) {
-	result, err := (*repo.secondaryRepo).SearchUser(pattern, sortBy, sortOrder, limit, offset)
+func (repo *userRepository) SearchUser(ctx context.Context, pattern, sortBy, sortOrder string, limit, offset int) ([]*user.User, error) {
+	result, err := (*repo.secondaryRepo).SearchUser(ctx, pattern, sortBy, sortOrder, limit, offset)
 	if err == nil {
 		for _, u := range result {
-			uTemp := *u
-			repo.cache[u.Username] = uTemp
+			repo.cache.SetIn(ctx, u.Username, *u)
 		}
 	}
 	return result, err
 }
 
 // BookmarkPost calls the DB repo BookmarkPost function.
-func (repo *userRepository) BookmarkPost(username string, postID int) error {
-	err := (*repo.secondaryRepo).BookmarkPost(username, postID)
+func (repo *userRepository) BookmarkPost(ctx context.Context, username string, postID int) error {
+	err := (*repo.secondaryRepo).BookmarkPost(ctx, username, postID)
 	if err == nil {
-		err = repo.cacheUser(username)
+		err = repo.cacheUser(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -107,10 +132,10 @@ func (repo *userRepository) BookmarkPost(username string, postID int) error {
 }
 
 // DeleteBookmark calls the DB repo BookmarkPost function.
-func (repo *userRepository) DeleteBookmark(username string, postID int) error {
-	err := (*repo.secondaryRepo).DeleteBookmark(username, postID)
+func (repo *userRepository) DeleteBookmark(ctx context.Context, username string, postID int) error {
+	err := (*repo.secondaryRepo).DeleteBookmark(ctx, username, postID)
 	if err == nil {
-		err = repo.cacheUser(username)
+		err = repo.cacheUser(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -119,25 +144,25 @@ func (repo *userRepository) DeleteBookmark(username string, postID int) error {
 }
 
 // UsernameOccupied calls the DB repo UsernameOccupied function.
-func (repo *userRepository) UsernameOccupied(username string) (bool, error) {
-	return (*repo.secondaryRepo).UsernameOccupied(username)
+func (repo *userRepository) UsernameOccupied(ctx context.Context, username string) (bool, error) {
+	return (*repo.secondaryRepo).UsernameOccupied(ctx, username)
 }
 
 // EmailOccupied calls the DB repo EmailOccupied function.
-func (repo *userRepository) EmailOccupied(email string) (bool, error) {
-	return (*repo.secondaryRepo).EmailOccupied(email)
+func (repo *userRepository) EmailOccupied(ctx context.Context, email string) (bool, error) {
+	return (*repo.secondaryRepo).EmailOccupied(ctx, email)
 }
 
 // Authenticate calls the DB repo Authenticate function.
-func (repo *userRepository) Authenticate(u *user.User) (bool, error) {
-	return (*repo.secondaryRepo).Authenticate(u)
+func (repo *userRepository) Authenticate(ctx context.Context, u *user.User) (bool, error) {
+	return (*repo.secondaryRepo).Authenticate(ctx, u)
 }
 
 // AddPicture calls the same method on the wrapped repo with a lil caching in between.
-func (repo *userRepository) AddPicture(username, name string) error {
-	err := (*repo.secondaryRepo).AddPicture(username, name)
+func (repo *userRepository) AddPicture(ctx context.Context, username, name string) error {
+	err := (*repo.secondaryRepo).AddPicture(ctx, username, name)
 	if err == nil {
-		err = repo.cacheUser(username)
+		err = repo.cacheUser(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -146,10 +171,22 @@ func (repo *userRepository) AddPicture(username, name string) error {
 }
 
 // RemovePicture calls the same method on the wrapped repo with a lil caching in between.
-func (repo *userRepository) RemovePicture(username string) error {
-	err := (*repo.secondaryRepo).RemovePicture(username)
+func (repo *userRepository) RemovePicture(ctx context.Context, username string) error {
+	err := (*repo.secondaryRepo).RemovePicture(ctx, username)
+	if err == nil {
+		err = repo.cacheUser(ctx, username)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// MarkVerified calls the same method on the wrapped repo with a lil caching in between.
+func (repo *userRepository) MarkVerified(ctx context.Context, username string) error {
+	err := (*repo.secondaryRepo).MarkVerified(ctx, username)
 	if err == nil {
-		err = repo.cacheUser(username)
+		err = repo.cacheUser(ctx, username)
 		if err != nil {
 			return err
 		}
@@ -1,9 +1,12 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
+	"time"
 
 	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
 )
@@ -14,14 +17,21 @@ type jWtAuthRepository repository
 // a PostgresSQL database.
 // A database connection needs to be passed so that it can function.
 func NewAuthRepository(DB *sql.DB, allRepos *map[string]interface{}) auth.Repository {
-	return &jWtAuthRepository{DB, allRepos}
+	return &jWtAuthRepository{&database{DB}, allRepos}
 }
 
-// Authenticate checks the given pass hash against the pass hash found in the database for the username.
-func (repo *jWtAuthRepository) Authenticate(u *auth.User) (bool, error) {
-	query := `SELECT pass_hash from users where username = $1 or email = $2`
-	var passHash string
-	err := repo.db.QueryRow(query, u.Username, u.Email).Scan(&passHash)
+// Authenticate checks the given pass hash against the pass hash found in the database for the user.
+// The user is looked up by username or, if that's not given, by email.
+// On success, the Username of the passed user is set to that found in the database.
+func (repo *jWtAuthRepository) Authenticate(ctx context.Context, u *auth.User) (bool, error) {
+	query := `SELECT username, pass_hash from users where username = $1`
+	identifier := u.Username
+	if identifier == "" {
+		query = `SELECT username, pass_hash from users where email = $1`
+		identifier = u.Email
+	}
+	var username, passHash string
+	err := repo.db.QueryRowContext(ctx, query, identifier).Scan(&username, &passHash)
 	if err == sql.ErrNoRows {
 		return false, auth.ErrUserNotFound
 	} else if err != nil {
@@ -30,6 +40,7 @@ func (repo *jWtAuthRepository) Authenticate(u *auth.User) (bool, error) {
 	err = bcrypt.CompareHashAndPassword([]byte(passHash), []byte(u.Password))
 	switch err {
 	case nil:
+		u.Username = username
 		return true, nil
 	case bcrypt.ErrHashTooShort:
 		return false, err
@@ -40,12 +51,578 @@ func (repo *jWtAuthRepository) Authenticate(u *auth.User) (bool, error) {
 	}
 }
 
-// AddToBlacklist - this method is only implemented in memory cache as of now.
-func (repo *jWtAuthRepository) AddToBlacklist(tokenString string) error {
-	panic("not implemented")
+// AddToBlacklist persists the given token id in the token_blacklist table until expiresAt.
+// Entries that have already expired are pruned on the way.
+func (repo *jWtAuthRepository) AddToBlacklist(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO token_blacklist (token_id, expires_at)
+							VALUES ($1, $2)
+							ON CONFLICT(token_id) DO UPDATE
+							SET expires_at = $2`, tokenID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into token_blacklist failed because of: %w", err)
+	}
+	return repo.pruneBlacklist(ctx)
+}
+
+// IsInBlacklist checks whether a given token id is in the token_blacklist table and hasn't expired.
+func (repo *jWtAuthRepository) IsInBlacklist(ctx context.Context, tokenID string) (bool, error) {
+	var blacklisted bool
+	err := repo.db.QueryRowContext(ctx, `SELECT EXISTS(
+									SELECT token_id FROM token_blacklist
+									WHERE token_id = $1 AND expires_at > CURRENT_TIMESTAMP)`, tokenID).Scan(&blacklisted)
+	if err != nil {
+		return false, fmt.Errorf("couldn't check token_blacklist because of: %w", err)
+	}
+	return blacklisted, nil
+}
+
+// pruneBlacklist is just a helper function that removes expired entries.
+func (repo *jWtAuthRepository) pruneBlacklist(ctx context.Context) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM token_blacklist
+							WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("pruning of token_blacklist failed because of: %w", err)
+	}
+	return nil
+}
+
+// AddRefreshToken persists the given refresh token record.
+// Refresh tokens that have expired are pruned on the way.
+func (repo *jWtAuthRepository) AddRefreshToken(ctx context.Context, rt *auth.RefreshToken) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO refresh_tokens (token_hash, family_id, username, access_token_id, creation_time, expires_at)
+							VALUES ($1, $2, $3, $4, $5, $6)`,
+		rt.TokenHash, rt.FamilyID, rt.Username, rt.AccessTokenID, rt.CreationTime, rt.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into refresh_tokens failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM refresh_tokens
+							WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("pruning of refresh_tokens failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshToken retrieves the refresh token record stored under the given hash.
+func (repo *jWtAuthRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	rt := new(auth.RefreshToken)
+	err := repo.db.QueryRowContext(ctx, `SELECT token_hash, family_id, username, COALESCE(access_token_id, ''), used, revoked, creation_time, expires_at
+							FROM refresh_tokens
+							WHERE token_hash = $1`, tokenHash).Scan(
+		&rt.TokenHash, &rt.FamilyID, &rt.Username, &rt.AccessTokenID, &rt.Used, &rt.Revoked, &rt.CreationTime, &rt.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("unable to get refresh token because of: %w", err)
+	}
+	return rt, nil
+}
+
+// MarkRefreshTokenUsed marks the refresh token under the given hash as used.
+// It returns false if the token was already marked as used before the call.
+func (repo *jWtAuthRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, `UPDATE refresh_tokens
+							SET used = true
+							WHERE token_hash = $1 AND used = false`, tokenHash)
+	if err != nil {
+		return false, fmt.Errorf("updating of refresh_tokens failed because of: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("updating of refresh_tokens failed because of: %w", err)
+	}
+	return n == 1, nil
+}
+
+// RevokeRefreshTokenFamily revokes all the refresh tokens belonging to the given family.
+func (repo *jWtAuthRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE refresh_tokens
+							SET revoked = true
+							WHERE family_id = $1`, familyID)
+	if err != nil {
+		return fmt.Errorf("revoking of refresh token family failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetUser retrieves the username and email of the user identified by the given username or email.
+func (repo *jWtAuthRepository) GetUser(ctx context.Context, identifier string) (*auth.User, error) {
+	u := new(auth.User)
+	err := repo.db.QueryRowContext(ctx, `SELECT username, email
+							FROM users
+							WHERE username = $1 OR email = $1`, identifier).Scan(&u.Username, &u.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("unable to get user because of: %w", err)
+	}
+	return u, nil
+}
+
+// AddPasswordResetToken persists the given password reset token record.
+// Any other token the user has been issued is invalidated and expired ones are pruned on the way.
+func (repo *jWtAuthRepository) AddPasswordResetToken(ctx context.Context, prt *auth.PasswordResetToken) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM password_reset_tokens
+							WHERE username = $1 OR expires_at <= CURRENT_TIMESTAMP`, prt.Username)
+	if err != nil {
+		return fmt.Errorf("pruning of password_reset_tokens failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `INSERT INTO password_reset_tokens (token_hash, username, creation_time, expires_at)
+							VALUES ($1, $2, $3, $4)`,
+		prt.TokenHash, prt.Username, prt.CreationTime, prt.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into password_reset_tokens failed because of: %w", err)
+	}
+	return nil
+}
+
+// ConsumePasswordResetToken marks the password reset token under the given hash as used
+// and returns it. Tokens that are already used or have expired can't be consumed.
+func (repo *jWtAuthRepository) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*auth.PasswordResetToken, error) {
+	prt := new(auth.PasswordResetToken)
+	err := repo.db.QueryRowContext(ctx, `UPDATE password_reset_tokens
+							SET used = true
+							WHERE token_hash = $1 AND used = false AND expires_at > CURRENT_TIMESTAMP
+							RETURNING token_hash, username, used, creation_time, expires_at`, tokenHash).Scan(
+		&prt.TokenHash, &prt.Username, &prt.Used, &prt.CreationTime, &prt.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrInvalidPasswordResetToken
+		}
+		return nil, fmt.Errorf("consuming of password reset token failed because of: %w", err)
+	}
+	return prt, nil
+}
+
+// SetPassword stores the bcrypt hash of the given password for the user.
+func (repo *jWtAuthRepository) SetPassword(ctx context.Context, username, password string) error {
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("unable to generate bcrypt has because: %w", err)
+	}
+	result, err := repo.db.ExecContext(ctx, `UPDATE users
+							SET pass_hash = $1
+							WHERE username = $2`, string(passHash), username)
+	if err != nil {
+		return fmt.Errorf("updating of pass_hash failed because of: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return auth.ErrUserNotFound
+	}
+	return nil
+}
+
+// AddEmailVerificationToken persists the given email verification token record.
+// Any other token the user has been issued is invalidated and expired ones are pruned on the way
+// but the creation time of the latest one is kept around for throttling.
+func (repo *jWtAuthRepository) AddEmailVerificationToken(ctx context.Context, evt *auth.EmailVerificationToken) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE email_verification_tokens
+							SET used = true
+							WHERE username = $1`, evt.Username)
+	if err != nil {
+		return fmt.Errorf("invalidation of email_verification_tokens failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM email_verification_tokens
+							WHERE expires_at <= CURRENT_TIMESTAMP AND username <> $1`, evt.Username)
+	if err != nil {
+		return fmt.Errorf("pruning of email_verification_tokens failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `INSERT INTO email_verification_tokens (token_hash, username, email, creation_time, expires_at)
+							VALUES ($1, $2, $3, $4, $5)`,
+		evt.TokenHash, evt.Username, evt.Email, evt.CreationTime, evt.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into email_verification_tokens failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetLastEmailVerificationTime returns the time the latest email verification token was issued
+// for the user or the zero time if none has been.
+func (repo *jWtAuthRepository) GetLastEmailVerificationTime(ctx context.Context, username string) (time.Time, error) {
+	var last sql.NullTime
+	err := repo.db.QueryRowContext(ctx, `SELECT MAX(creation_time)
+							FROM email_verification_tokens
+							WHERE username = $1`, username).Scan(&last)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get last email verification time because of: %w", err)
+	}
+	return last.Time, nil
+}
+
+// ConsumeEmailVerificationToken marks the email verification token under the given hash as used
+// and returns it. Tokens that are already used, have expired or were issued for an email
+// the user no longer has can't be consumed.
+func (repo *jWtAuthRepository) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (*auth.EmailVerificationToken, error) {
+	evt := new(auth.EmailVerificationToken)
+	err := repo.db.QueryRowContext(ctx, `UPDATE email_verification_tokens
+							SET used = true
+							FROM users
+							WHERE token_hash = $1 AND used = false AND expires_at > CURRENT_TIMESTAMP
+							AND users.username = email_verification_tokens.username
+							AND users.email = email_verification_tokens.email
+							RETURNING token_hash, email_verification_tokens.username, email_verification_tokens.email, used, email_verification_tokens.creation_time, expires_at`, tokenHash).Scan(
+		&evt.TokenHash, &evt.Username, &evt.Email, &evt.Used, &evt.CreationTime, &evt.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrInvalidEmailVerificationToken
+		}
+		return nil, fmt.Errorf("consuming of email verification token failed because of: %w", err)
+	}
+	return evt, nil
+}
+
+// SetTOTPSecret persists the given TOTP enrolment along with its recovery codes,
+// replacing any previous enrolment of the user.
+func (repo *jWtAuthRepository) SetTOTPSecret(ctx context.Context, ts *auth.TOTPSecret) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction failed because of: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `INSERT INTO totp_secrets (username, secret, confirmed, last_used_step, creation_time)
+							VALUES ($1, $2, $3, $4, $5)
+							ON CONFLICT(username) DO UPDATE
+							SET secret = $2, confirmed = $3, last_used_step = $4, creation_time = $5`,
+		ts.Username, ts.Secret, ts.Confirmed, ts.LastUsedStep, ts.CreationTime)
+	if err != nil {
+		return fmt.Errorf("upsertion into totp_secrets failed because of: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM totp_recovery_codes
+							WHERE username = $1`, ts.Username)
+	if err != nil {
+		return fmt.Errorf("deletion from totp_recovery_codes failed because of: %w", err)
+	}
+	for _, codeHash := range ts.RecoveryCodeHashes {
+		_, err = tx.ExecContext(ctx, `INSERT INTO totp_recovery_codes (username, code_hash)
+							VALUES ($1, $2)`, ts.Username, codeHash)
+		if err != nil {
+			return fmt.Errorf("insertion into totp_recovery_codes failed because of: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetTOTPSecret retrieves the TOTP enrolment of the user.
+// The hashes of the recovery codes that are yet to be used are included.
+func (repo *jWtAuthRepository) GetTOTPSecret(ctx context.Context, username string) (*auth.TOTPSecret, error) {
+	ts := new(auth.TOTPSecret)
+	err := repo.db.QueryRowContext(ctx, `SELECT username, secret, confirmed, last_used_step, creation_time
+							FROM totp_secrets
+							WHERE username = $1`, username).Scan(
+		&ts.Username, &ts.Secret, &ts.Confirmed, &ts.LastUsedStep, &ts.CreationTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrTOTPNotEnrolled
+		}
+		return nil, fmt.Errorf("unable to get totp secret because of: %w", err)
+	}
+	rows, err := repo.db.QueryContext(ctx, `SELECT code_hash
+							FROM totp_recovery_codes
+							WHERE username = $1 AND used = false`, username)
+	if err != nil {
+		return nil, fmt.Errorf("querying for totp_recovery_codes failed because of: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var codeHash string
+		if err := rows.Scan(&codeHash); err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %w", err)
+		}
+		ts.RecoveryCodeHashes = append(ts.RecoveryCodeHashes, codeHash)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %w", err)
+	}
+	return ts, nil
 }
 
-// IsInBlacklist - this method is only implemented in memory cache as of now.
-func (repo *jWtAuthRepository) IsInBlacklist(token string) (bool, error) {
-	panic("not implemented")
+// ConfirmTOTPSecret marks the TOTP enrolment of the user as confirmed.
+func (repo *jWtAuthRepository) ConfirmTOTPSecret(ctx context.Context, username string) error {
+	result, err := repo.db.ExecContext(ctx, `UPDATE totp_secrets
+							SET confirmed = true
+							WHERE username = $1`, username)
+	if err != nil {
+		return fmt.Errorf("confirming of totp secret failed because of: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return auth.ErrTOTPNotEnrolled
+	}
+	return nil
+}
+
+// DeleteTOTPSecret removes the TOTP enrolment of the user along with its recovery codes.
+func (repo *jWtAuthRepository) DeleteTOTPSecret(ctx context.Context, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM totp_recovery_codes
+							WHERE username = $1`, username)
+	if err != nil {
+		return fmt.Errorf("deletion from totp_recovery_codes failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM totp_secrets
+							WHERE username = $1`, username)
+	if err != nil {
+		return fmt.Errorf("deletion from totp_secrets failed because of: %w", err)
+	}
+	return nil
+}
+
+// UpdateTOTPLastUsedStep records the time step of the last accepted TOTP code of the user.
+// It returns false if a code of the same or a later step has already been accepted.
+func (repo *jWtAuthRepository) UpdateTOTPLastUsedStep(ctx context.Context, username string, step int64) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, `UPDATE totp_secrets
+							SET last_used_step = $2
+							WHERE username = $1 AND last_used_step < $2`, username, step)
+	if err != nil {
+		return false, fmt.Errorf("updating of totp_secrets failed because of: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("updating of totp_secrets failed because of: %w", err)
+	}
+	return n == 1, nil
+}
+
+// ConsumeTOTPRecoveryCode marks the recovery code of the user under the given hash as used.
+// It returns false if there's no such code or it has already been used.
+func (repo *jWtAuthRepository) ConsumeTOTPRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, `UPDATE totp_recovery_codes
+							SET used = true
+							WHERE username = $1 AND code_hash = $2 AND used = false`, username, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("updating of totp_recovery_codes failed because of: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("updating of totp_recovery_codes failed because of: %w", err)
+	}
+	return n == 1, nil
+}
+
+// AddPersonalAccessToken persists the given personal access token record.
+func (repo *jWtAuthRepository) AddPersonalAccessToken(ctx context.Context, pat *auth.PersonalAccessToken) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO personal_access_tokens (id, token_hash, username, name, scopes, creation_time, expires_at)
+							VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		pat.ID, pat.TokenHash, pat.Username, pat.Name, pq.Array(pat.Scopes), pat.CreationTime, pat.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into personal_access_tokens failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetPersonalAccessToken retrieves the personal access token record stored under the given hash.
+func (repo *jWtAuthRepository) GetPersonalAccessToken(ctx context.Context, tokenHash string) (*auth.PersonalAccessToken, error) {
+	row := repo.db.QueryRowContext(ctx, `SELECT id, token_hash, username, name, scopes, creation_time, expires_at, last_used_time, revoked
+							FROM personal_access_tokens
+							WHERE token_hash = $1`, tokenHash)
+	pat, err := scanPersonalAccessToken(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrPersonalAccessTokenNotFound
+		}
+		return nil, fmt.Errorf("unable to get personal access token because of: %w", err)
+	}
+	return pat, nil
+}
+
+// GetPersonalAccessTokens retrieves the records of the personal access tokens of the
+// user that haven't been revoked, newest first.
+func (repo *jWtAuthRepository) GetPersonalAccessTokens(ctx context.Context, username string) ([]*auth.PersonalAccessToken, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT id, token_hash, username, name, scopes, creation_time, expires_at, last_used_time, revoked
+							FROM personal_access_tokens
+							WHERE username = $1 AND revoked = false
+							ORDER BY creation_time DESC`, username)
+	if err != nil {
+		return nil, fmt.Errorf("querying for personal_access_tokens failed because of: %w", err)
+	}
+	defer rows.Close()
+	pats := make([]*auth.PersonalAccessToken, 0)
+	for rows.Next() {
+		pat, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %w", err)
+		}
+		pats = append(pats, pat)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %w", err)
+	}
+	return pats, nil
+}
+
+// RevokePersonalAccessToken marks the personal access token of the user with the given id as revoked.
+func (repo *jWtAuthRepository) RevokePersonalAccessToken(ctx context.Context, username, id string) error {
+	result, err := repo.db.ExecContext(ctx, `UPDATE personal_access_tokens
+							SET revoked = true
+							WHERE username = $1 AND id = $2 AND revoked = false`, username, id)
+	if err != nil {
+		return fmt.Errorf("revoking of personal access token failed because of: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return auth.ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// UpdatePersonalAccessTokenLastUsed records the time the personal access token was last used.
+func (repo *jWtAuthRepository) UpdatePersonalAccessTokenLastUsed(ctx context.Context, id string, lastUsedTime time.Time) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE personal_access_tokens
+							SET last_used_time = $2
+							WHERE id = $1`, id, lastUsedTime)
+	if err != nil {
+		return fmt.Errorf("updating of personal_access_tokens failed because of: %w", err)
+	}
+	return nil
+}
+
+// scanPersonalAccessToken is a helper function that scans a personal access token
+// record out of a row holding the columns selected by GetPersonalAccessToken.
+func scanPersonalAccessToken(row interface{ Scan(...interface{}) error }) (*auth.PersonalAccessToken, error) {
+	pat := new(auth.PersonalAccessToken)
+	var expiresAt, lastUsedTime sql.NullTime
+	err := row.Scan(&pat.ID, &pat.TokenHash, &pat.Username, &pat.Name, pq.Array(&pat.Scopes),
+		&pat.CreationTime, &expiresAt, &lastUsedTime, &pat.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		pat.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedTime.Valid {
+		pat.LastUsedTime = &lastUsedTime.Time
+	}
+	return pat, nil
+}
+
+// AddSession persists the given session record.
+// Sessions that have expired are pruned on the way.
+func (repo *jWtAuthRepository) AddSession(ctx context.Context, session *auth.Session) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO sessions (id, username, client_id, scopes, user_agent, ip_address, creation_time, last_used_time, expires_at)
+							VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9)`,
+		session.ID, session.Username, session.ClientID, pq.Array(session.Scopes), session.UserAgent, session.IPAddress,
+		session.CreationTime, session.LastUsedTime, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into sessions failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM sessions
+							WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("pruning of sessions failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetSession retrieves the session record with the given id.
+func (repo *jWtAuthRepository) GetSession(ctx context.Context, id string) (*auth.Session, error) {
+	row := repo.db.QueryRowContext(ctx, `SELECT id, username, COALESCE(client_id, ''), scopes, COALESCE(user_agent, ''), COALESCE(ip_address, ''), creation_time, last_used_time, expires_at, revoked
+							FROM sessions
+							WHERE id = $1`, id)
+	session, err := scanSession(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, auth.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("unable to get session because of: %w", err)
+	}
+	return session, nil
+}
+
+// GetSessions retrieves the records of the sessions of the user that are yet
+// to expire or be revoked, most recently used first.
+func (repo *jWtAuthRepository) GetSessions(ctx context.Context, username string) ([]*auth.Session, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT id, username, COALESCE(client_id, ''), scopes, COALESCE(user_agent, ''), COALESCE(ip_address, ''), creation_time, last_used_time, expires_at, revoked
+							FROM sessions
+							WHERE username = $1 AND revoked = false AND expires_at > CURRENT_TIMESTAMP
+							ORDER BY last_used_time DESC`, username)
+	if err != nil {
+		return nil, fmt.Errorf("querying for sessions failed because of: %w", err)
+	}
+	defer rows.Close()
+	sessions := make([]*auth.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %w", err)
+	}
+	return sessions, nil
+}
+
+// scanSession is a helper function that scans a session record out of a row
+// holding the columns selected by GetSession.
+func scanSession(row interface{ Scan(...interface{}) error }) (*auth.Session, error) {
+	session := new(auth.Session)
+	err := row.Scan(&session.ID, &session.Username, &session.ClientID, pq.Array(&session.Scopes),
+		&session.UserAgent, &session.IPAddress, &session.CreationTime, &session.LastUsedTime, &session.ExpiresAt, &session.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// UpdateSession records the time the session was last refreshed and when it now expires.
+func (repo *jWtAuthRepository) UpdateSession(ctx context.Context, id string, lastUsedTime, expiresAt time.Time) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE sessions
+							SET last_used_time = $2, expires_at = $3
+							WHERE id = $1`, id, lastUsedTime, expiresAt)
+	if err != nil {
+		return fmt.Errorf("updating of sessions failed because of: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession marks the session of the user with the given id as revoked.
+func (repo *jWtAuthRepository) RevokeSession(ctx context.Context, username, id string) error {
+	result, err := repo.db.ExecContext(ctx, `UPDATE sessions
+							SET revoked = true
+							WHERE username = $1 AND id = $2`, username, id)
+	if err != nil {
+		return fmt.Errorf("revoking of session failed because of: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return auth.ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeSessions marks all the sessions of the user, except the one with the given id,
+// as revoked along with the refresh tokens issued in them.
+func (repo *jWtAuthRepository) RevokeSessions(ctx context.Context, username, exceptID string) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE sessions
+							SET revoked = true
+							WHERE username = $1 AND id <> $2`, username, exceptID)
+	if err != nil {
+		return fmt.Errorf("revoking of sessions failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `UPDATE refresh_tokens
+							SET revoked = true
+							WHERE username = $1 AND family_id <> $2`, username, exceptID)
+	if err != nil {
+		return fmt.Errorf("revoking of refresh tokens failed because of: %w", err)
+	}
+	return nil
+}
+
+// IsSessionRevoked checks whether the session with the given id has been revoked.
+// Unknown sessions, like those of tokens issued before sessions were recorded,
+// aren't considered revoked.
+func (repo *jWtAuthRepository) IsSessionRevoked(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+	err := repo.db.QueryRowContext(ctx, `SELECT revoked
+							FROM sessions
+							WHERE id = $1`, id).Scan(&revoked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to get session because of: %w", err)
+	}
+	return revoked, nil
 }
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/lib/pq"
@@ -19,38 +20,41 @@ type channelRepository repository
 // the Repository might make use of them to fetch objects instead of implementing redundant logic.
 //Each none helper function if successful will try to cache.
 func NewChannelRepository(DB *sql.DB, allRepos *map[string]interface{}) channel.Repository {
-	return &channelRepository{DB, allRepos}
+	return &channelRepository{&database{DB}, allRepos}
 }
 
-// AddChannel takes in a channel.Channel struct and persists it in the database.
-func (repo *channelRepository) AddChannel(c *channel.Channel) (*channel.Channel, error) {
-	var err error
-
-	_, err = repo.db.Exec(`INSERT INTO "issue#1".channels (username, name, description)
+// AddChannel takes in a channel.Channel struct and persists it in the database, all or nothing.
+func (repo *channelRepository) AddChannel(ctx context.Context, c *channel.Channel) (*channel.Channel, error) {
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		_, err := repo.db.ExecContext(ctx, `INSERT INTO "issue#1".channels (username, name, description)
 							VALUES ($1, $2, $3)`, c.ChannelUsername, c.Name, c.Description)
 
+		if err != nil {
+			return fmt.Errorf("insertion of channel failed because of: %s", err.Error())
+		}
+		err = repo.AddAdmin(ctx, c.ChannelUsername, c.OwnerUsername)
+		if err != nil {
+			return fmt.Errorf("insertion of admin user failed because of: %s", err.Error())
+		}
+		err = repo.ChangeOwner(ctx, c.ChannelUsername, c.OwnerUsername)
+		if err != nil {
+			return fmt.Errorf("insertion of admin user failed because of: %s", err.Error())
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("insertion of channel failed because of: %s", err.Error())
-	}
-	err = repo.AddAdmin(c.ChannelUsername, c.OwnerUsername)
-	if err != nil {
-		return nil, fmt.Errorf("insertion of admin user failed because of: %s", err.Error())
-	}
-	err = repo.ChangeOwner(c.ChannelUsername, c.OwnerUsername)
-	if err != nil {
-		return nil, fmt.Errorf("insertion of admin user failed because of: %s", err.Error())
+		return nil, err
 	}
-
 	return c, nil
 }
 
 // GetChannel retrieves a channel.Channel user.User based on the username passed.
-func (repo *channelRepository) GetChannel(channelUsername string) (*channel.Channel, error) {
+func (repo *channelRepository) GetChannel(ctx context.Context, channelUsername string) (*channel.Channel, error) {
 	var err error
 	var c = new(channel.Channel)
 
 	var creationTimeString string
-	err = repo.db.QueryRow(`SELECT name, COALESCE(description, ''), creation_time
+	err = repo.db.QueryRowContext(ctx, `SELECT name, COALESCE(description, ''), creation_time
 							FROM "issue#1".channels
 							WHERE username = $1`, channelUsername).Scan(&c.Name, &c.Description, &creationTimeString)
 
@@ -67,31 +71,31 @@ func (repo *channelRepository) GetChannel(channelUsername string) (*channel.Chan
 	}
 	c.CreationTime = creationTime
 
-	admins, err := repo.GetAdmins(channelUsername)
+	admins, err := repo.GetAdmins(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get admins because of: %s", err.Error())
 	}
-	owner, err := repo.GetOwner(channelUsername)
+	owner, err := repo.GetOwner(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get owner because of: %s", err.Error())
 	}
-	stickiedPosts, err := repo.GetStickiedPost(channelUsername)
+	stickiedPosts, err := repo.GetStickiedPost(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get bookmarked posts because of: %s", err.Error())
 	}
-	posts, err := repo.GetPosts(channelUsername)
+	posts, err := repo.GetPosts(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get posts because of: %s", err.Error())
 	}
-	unOfficialReleases, err := repo.GetUnOfficialRelease(channelUsername)
+	unOfficialReleases, err := repo.GetUnOfficialRelease(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get UnOfficialRelease because of: %s", err.Error())
 	}
-	officialReleases, err := repo.GetOfficialRelease(channelUsername)
+	officialReleases, err := repo.GetOfficialRelease(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get UnOfficialRelease because of: %s", err.Error())
 	}
-	pictureURL, err := repo.GetPicture(channelUsername)
+	pictureURL, err := repo.GetPicture(ctx, channelUsername)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get Picture because of: %s", err.Error())
 	}
@@ -106,35 +110,39 @@ func (repo *channelRepository) GetChannel(channelUsername string) (*channel.Chan
 	return c, nil
 }
 
-// UpdateChannel updates a channel based on the passed in channel.Channel struct into channelUsername.
-func (repo *channelRepository) UpdateChannel(channelUsername string, c *channel.Channel) (*channel.Channel, error) {
-	var err error
-
-	if c.Name != "" {
-		err = repo.execUpdateStatementOnColumn("name", c.Name, channelUsername)
-		if err != nil {
-			return nil, err
+// UpdateChannel updates a channel based on the passed in channel.Channel struct into channelUsername,
+// all of them are made or none is.
+func (repo *channelRepository) UpdateChannel(ctx context.Context, channelUsername string, c *channel.Channel) (*channel.Channel, error) {
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		if c.Name != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "name", c.Name, channelUsername)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if c.ChannelUsername != "" {
-		err = repo.execUpdateStatementOnColumn("username", c.ChannelUsername, channelUsername)
-		if err != nil {
-			return nil, err
+		if c.ChannelUsername != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "username", c.ChannelUsername, channelUsername)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if c.Description != "" {
-		err = repo.execUpdateStatementOnColumn("description", c.Description, channelUsername)
-		if err != nil {
-			return nil, err
+		if c.Description != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "description", c.Description, channelUsername)
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return c, nil
 }
 
 // execUpdateStatementOnColumn is just a helper function that updates a certain column
-func (repo *channelRepository) execUpdateStatementOnColumn(column, value, username string) error {
-	_, err := repo.db.Exec(fmt.Sprintf(`UPDATE "issue#1".channels 
+func (repo *channelRepository) execUpdateStatementOnColumn(ctx context.Context, column, value, username string) error {
+	_, err := repo.db.ExecContext(ctx, fmt.Sprintf(`UPDATE "issue#1".channels 
 									SET %s = $1 
 									WHERE username = $2`, column), value, username)
 	if err != nil {
@@ -144,8 +152,8 @@ func (repo *channelRepository) execUpdateStatementOnColumn(column, value, userna
 }
 
 // DeleteChannel deletes a channel based on the passed in channelUsername.
-func (repo *channelRepository) DeleteChannel(channelUsername string) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".channels
+func (repo *channelRepository) DeleteChannel(ctx context.Context, channelUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".channels
 							WHERE username = $1`, channelUsername)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from channels because of: %s", err.Error())
@@ -154,11 +162,11 @@ func (repo *channelRepository) DeleteChannel(channelUsername string) error {
 }
 
 // GetUnOfficialRelease is just a helper function that gets the UnOfficial Release List
-func (repo *channelRepository) GetUnOfficialRelease(username string) ([]uint, error) {
+func (repo *channelRepository) GetUnOfficialRelease(ctx context.Context, username string) ([]uint, error) {
 	var UnOfficialList []uint
 	var Release uint
 
-	rows, err := repo.db.Query(`SELECT id
+	rows, err := repo.db.QueryContext(ctx, `SELECT id
                 FROM "issue#1".releases
                 WHERE owner_channel = $1`, username)
 	if err != nil {
@@ -184,11 +192,11 @@ func (repo *channelRepository) GetUnOfficialRelease(username string) ([]uint, er
 }
 
 // GetOfficialRelease is just a helper function that gets the Official Release List
-func (repo *channelRepository) GetOfficialRelease(username string) ([]uint, error) {
+func (repo *channelRepository) GetOfficialRelease(ctx context.Context, username string) ([]uint, error) {
 	var OfficialList []uint
 	var Release uint
 
-	rows, err := repo.db.Query(`SELECT release_id
+	rows, err := repo.db.QueryContext(ctx, `SELECT release_id
                 FROM "issue#1".channel_official_catalog
                 WHERE channel_username = $1`, username)
 	if err != nil {
@@ -213,11 +221,11 @@ func (repo *channelRepository) GetOfficialRelease(username string) ([]uint, erro
 }
 
 // GetPosts is just a helper function that gets the Post List
-func (repo *channelRepository) GetPosts(username string) ([]uint, error) {
+func (repo *channelRepository) GetPosts(ctx context.Context, username string) ([]uint, error) {
 	var PostList []uint
 	var Post uint
 
-	rows, err := repo.db.Query(`SELECT id
+	rows, err := repo.db.QueryContext(ctx, `SELECT id
                 FROM "issue#1".posts
                 WHERE channel_from = $1`, username)
 	if err != nil {
@@ -241,12 +249,12 @@ func (repo *channelRepository) GetPosts(username string) ([]uint, error) {
 }
 
 // GetUnOfficialRelease is just a helper function that gets the Stickied Post List
-func (repo *channelRepository) GetStickiedPost(username string) ([]uint, error) {
+func (repo *channelRepository) GetStickiedPost(ctx context.Context, username string) ([]uint, error) {
 	var stickied []uint
 
 	var Post uint
 
-	rows, err := repo.db.Query(`SELECT
+	rows, err := repo.db.QueryContext(ctx, `SELECT
   post_id
 FROM
   "issue#1".channel_stickies
@@ -275,11 +283,11 @@ INNER JOIN "issue#1".posts ON channel_stickies.post_id =posts.id WHERE posts.cha
 }
 
 // GetOwner is just a helper function that gets the Owner
-func (repo *channelRepository) GetOwner(username string) (string, error) {
+func (repo *channelRepository) GetOwner(ctx context.Context, username string) (string, error) {
 	var owner string
 	var Admin string
 	var isOwner bool
-	rows, err := repo.db.Query(`SELECT username,is_owner
+	rows, err := repo.db.QueryContext(ctx, `SELECT username,is_owner
                 FROM "issue#1".channel_admins
                 WHERE channel_username = $1`, username)
 	if err != nil {
@@ -308,7 +316,7 @@ func (repo *channelRepository) GetOwner(username string) (string, error) {
 // SearchChannel searches for channels according to the pattern.
 // If no pattern is provided, it returns all channels.
 // It makes use of pagination.
-func (repo *channelRepository) SearchChannels(pattern string, sortBy channel.SortBy, sortOrder channel.SortOrder, limit, offset int) ([]*channel.Channel, error) {
+func (repo *channelRepository) SearchChannels(ctx context.Context, pattern string, sortBy channel.SortBy, sortOrder channel.SortOrder, limit, offset int) ([]*channel.Channel, error) {
 
 	var channels = make([]*channel.Channel, 0)
 	var err error
@@ -319,13 +327,13 @@ func (repo *channelRepository) SearchChannels(pattern string, sortBy channel.Sor
 												FROM "issue#1".channels) 
 												ORDER BY %s %s NULLS LAST
 												LIMIT $1 OFFSET $2`, sortBy, sortOrder)
-		rows, err = repo.db.Query(query, limit, offset)
+		rows, err = repo.db.QueryContext(ctx, query, limit, offset)
 	} else {
 		query = `SELECT username,name, COALESCE(description, ''),creation_time 
 			from channels
 			where username ilike '%' || $1 || '%'  OR name  ilike '%' || $1|| '%'
 			LIMIT $2 OFFSET $3`
-		rows, err = repo.db.Query(query, pattern, limit, offset)
+		rows, err = repo.db.QueryContext(ctx, query, pattern, limit, offset)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("querying for channels failed because of: %v", err)
@@ -348,31 +356,31 @@ func (repo *channelRepository) SearchChannels(pattern string, sortBy channel.Sor
 		}
 		c.CreationTime = creationTime
 
-		admins, err := repo.GetAdmins(c.ChannelUsername)
+		admins, err := repo.GetAdmins(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get admins because of: %s", err.Error())
 		}
-		owner, err := repo.GetOwner(c.ChannelUsername)
+		owner, err := repo.GetOwner(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get owner because of: %s", err.Error())
 		}
-		stickiedPosts, err := repo.GetStickiedPost(c.ChannelUsername)
+		stickiedPosts, err := repo.GetStickiedPost(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get stickied posts because of: %s", err.Error())
 		}
-		posts, err := repo.GetPosts(c.ChannelUsername)
+		posts, err := repo.GetPosts(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get posts because of: %s", err.Error())
 		}
-		unOfficialReleases, err := repo.GetUnOfficialRelease(c.ChannelUsername)
+		unOfficialReleases, err := repo.GetUnOfficialRelease(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get official realeases because of: %s", err.Error())
 		}
-		officialReleases, err := repo.GetOfficialRelease(c.ChannelUsername)
+		officialReleases, err := repo.GetOfficialRelease(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get official realeases because of: %s", err.Error())
 		}
-		pictureURL, err := repo.GetPicture(c.ChannelUsername)
+		pictureURL, err := repo.GetPicture(ctx, c.ChannelUsername)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get picture because of: %s", err.Error())
 		}
@@ -393,10 +401,10 @@ func (repo *channelRepository) SearchChannels(pattern string, sortBy channel.Sor
 }
 
 // AddAdmin adds a User adminUsername to the channel channelUsername as an admin
-func (repo *channelRepository) AddAdmin(channelUsername string, adminUsername string) error {
+func (repo *channelRepository) AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
 	var err error
 	owner := false
-	_, err = repo.db.Exec(`INSERT INTO "issue#1".channel_admins (channel_username,username,is_owner)
+	_, err = repo.db.ExecContext(ctx, `INSERT INTO "issue#1".channel_admins (channel_username,username,is_owner)
 							VALUES ($1, $2,$3)`, channelUsername, adminUsername, owner)
 	if err != nil {
 		const foreignKeyViolationErrorCode = pq.ErrorCode("23503")
@@ -418,8 +426,8 @@ func (repo *channelRepository) AddAdmin(channelUsername string, adminUsername st
 }
 
 // DeleteAdmin deletes role of a User adminUsername of the channel channelUsername as an admin
-func (repo *channelRepository) DeleteAdmin(channelUsername string, adminUsername string) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".channel_admins
+func (repo *channelRepository) DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".channel_admins
 							WHERE channel_username = $1 AND username= $2`, channelUsername, adminUsername)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from channel_admins because of: %s", err.Error())
@@ -428,10 +436,10 @@ func (repo *channelRepository) DeleteAdmin(channelUsername string, adminUsername
 }
 
 // GetAdmins gets a list of Admins of channel channelUsername
-func (repo *channelRepository) GetAdmins(channelUsername string) ([]string, error) {
+func (repo *channelRepository) GetAdmins(ctx context.Context, channelUsername string) ([]string, error) {
 	var AdminList []string
 	var Admin string
-	rows, err := repo.db.Query(`SELECT username
+	rows, err := repo.db.QueryContext(ctx, `SELECT username
                 FROM "issue#1".channel_admins
                 WHERE channel_username = $1`, channelUsername)
 	if err != nil {
@@ -455,32 +463,33 @@ func (repo *channelRepository) GetAdmins(channelUsername string) ([]string, erro
 }
 
 // ChangeOwner gets the owner of channel channelUsername
-func (repo *channelRepository) ChangeOwner(channelUsername string, ownerUsername string) error {
-	var err error
+func (repo *channelRepository) ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error {
 	var owner bool = true
 
-	_, err = repo.db.Exec(`UPDATE "issue#1".channel_admins
+	return repo.db.transact(ctx, func(ctx context.Context) error {
+		_, err := repo.db.ExecContext(ctx, `UPDATE "issue#1".channel_admins
 								  SET is_owner = $3 WHERE channel_username =$1 AND username=$2`, channelUsername, ownerUsername, owner)
-	if err != nil {
-		const foreignKeyViolationErrorCode = pq.ErrorCode("23503")
-		pgErr := err.(*pq.Error)
+		if err != nil {
+			const foreignKeyViolationErrorCode = pq.ErrorCode("23503")
+			pgErr := err.(*pq.Error)
 
-		if pgErr.Code == foreignKeyViolationErrorCode {
-			return channel.ErrOwnerNotFound
+			if pgErr.Code == foreignKeyViolationErrorCode {
+				return channel.ErrOwnerNotFound
+			}
+			return fmt.Errorf("changing of owner failed because of: %s", err.Error())
 		}
-		return fmt.Errorf("changing of owner failed because of: %s", err.Error())
-	}
-	_, err = repo.db.Exec(`UPDATE "issue#1".channel_admins SET is_owner = $3 where channel_username=$1 AND  username<>$2`, channelUsername, ownerUsername, !owner)
-	if err != nil {
-		return fmt.Errorf("changing of owner failed because of: %s", err.Error())
-	}
-	return nil
+		_, err = repo.db.ExecContext(ctx, `UPDATE "issue#1".channel_admins SET is_owner = $3 where channel_username=$1 AND  username<>$2`, channelUsername, ownerUsername, !owner)
+		if err != nil {
+			return fmt.Errorf("changing of owner failed because of: %s", err.Error())
+		}
+		return nil
+	})
 }
 
 // AddReleaseToOfficialCatalog adds a release releaseID into the Official Catalog channel channelUsername
-func (repo *channelRepository) AddReleaseToOfficialCatalog(channelUsername string, releaseID uint, postID uint) error {
+func (repo *channelRepository) AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error {
 
-	_, err := repo.db.Exec(`INSERT INTO channel_official_catalog (channel_username,release_id,post_from_id)
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO channel_official_catalog (channel_username,release_id,post_from_id)
 							VALUES ($1, $2,$3)`, channelUsername, releaseID, postID)
 	if err != nil {
 		const uniqueKeyViolationErrorCode = pq.ErrorCode("23505")
@@ -498,8 +507,8 @@ func (repo *channelRepository) AddReleaseToOfficialCatalog(channelUsername strin
 }
 
 // DeleteReleaseFromCatalog deletes a release releaseID from Catalog of channel channelUsername
-func (repo *channelRepository) DeleteReleaseFromCatalog(channelUsername string, ReleaseID uint) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".releases
+func (repo *channelRepository) DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".releases
 							WHERE owner_channel = $1 AND id = $2`, channelUsername, ReleaseID)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from channel_catalogs because of: %s", err.Error())
@@ -508,9 +517,9 @@ func (repo *channelRepository) DeleteReleaseFromCatalog(channelUsername string,
 }
 
 // DeleteReleaseFromOfficialCatalog deletes a release releaseID from Official Catalog of channel channelUsername
-func (repo *channelRepository) DeleteReleaseFromOfficialCatalog(channelUsername string, ReleaseID uint) error {
+func (repo *channelRepository) DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error {
 
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".channel_official_catalog
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".channel_official_catalog
 							WHERE channel_username = $1 AND release_id = $2`, channelUsername, ReleaseID)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from channel_catalogs because of: %s", err.Error())
@@ -519,15 +528,15 @@ func (repo *channelRepository) DeleteReleaseFromOfficialCatalog(channelUsername
 }
 
 // StickyPost stickies a post on channel channelUsername
-func (repo *channelRepository) StickyPost(channelUsername string, postID uint) error {
-	a, err := repo.GetStickiedPost(channelUsername)
+func (repo *channelRepository) StickyPost(ctx context.Context, channelUsername string, postID uint) error {
+	a, err := repo.GetStickiedPost(ctx, channelUsername)
 	if err != nil {
 		return fmt.Errorf("getting stickied posts failed because of: %s", err.Error())
 	}
 	if len(a) == 2 {
 		return channel.ErrStickiedPostFull
 	} else {
-		_, err := repo.db.Exec(`INSERT INTO channel_stickies (post_id)
+		_, err := repo.db.ExecContext(ctx, `INSERT INTO channel_stickies (post_id)
 							VALUES ($1)
 							ON CONFLICT DO NOTHING`, postID)
 		const foreignKeyViolationErrorCode = pq.ErrorCode("23503")
@@ -544,9 +553,9 @@ func (repo *channelRepository) StickyPost(channelUsername string, postID uint) e
 }
 
 // DeleteStickiedPost deletes a stickied post from channel channelUsername
-func (repo *channelRepository) DeleteStickiedPost(channelUsername string, stickiedPostID uint) error {
+func (repo *channelRepository) DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error {
 	//TODO
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".channel_stickies
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".channel_stickies
 							WHERE post_id = $1`, stickiedPostID)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from channel_stickie because of: %s", err.Error())
@@ -555,8 +564,8 @@ func (repo *channelRepository) DeleteStickiedPost(channelUsername string, sticki
 }
 
 // AddPicture persists the given name as the image_name for the user under the given username
-func (repo *channelRepository) AddPicture(channelUsername string, name string) (string, error) {
-	_, err := repo.db.Exec(`INSERT INTO "issue#1".channel_pictures (channelname, image_name) 
+func (repo *channelRepository) AddPicture(ctx context.Context, channelUsername string, name string) (string, error) {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO "issue#1".channel_pictures (channelname, image_name) 
 								VALUES ($1, $2)
 								ON CONFLICT(channelname) DO UPDATE
 								SET image_name = $1`, channelUsername, name)
@@ -573,8 +582,8 @@ func (repo *channelRepository) AddPicture(channelUsername string, name string) (
 }
 
 // RemovePicture removes the username's tuple entry from the user_avatars table.
-func (repo *channelRepository) RemovePicture(channelUsername string) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".channel_pictures
+func (repo *channelRepository) RemovePicture(ctx context.Context, channelUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".channel_pictures
 							WHERE channelname = $1`, channelUsername)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from channel_pictures failed because of: %v", err)
@@ -583,10 +592,10 @@ func (repo *channelRepository) RemovePicture(channelUsername string) error {
 }
 
 // GetPicture gets the username's tuple entry from the user_avatars table.
-func (repo *channelRepository) GetPicture(ChannelUsername string) (string, error) {
+func (repo *channelRepository) GetPicture(ctx context.Context, ChannelUsername string) (string, error) {
 	var pictureURL string
 
-	rows, err := repo.db.Query(`SELECT image_name
+	rows, err := repo.db.QueryContext(ctx, `SELECT image_name
                 FROM "issue#1".channel_pictures
                 WHERE channelname = $1`, ChannelUsername)
 	if err != nil {
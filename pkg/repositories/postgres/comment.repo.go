@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/lib/pq"
@@ -13,15 +14,15 @@ type commentRepository repository
 // a PostgresSQL database.
 // A database connection needs to be passed so that it can function.
 func NewCommentRepository(DB *sql.DB, allRepos *map[string]interface{}) comment.Repository {
-	return &commentRepository{DB, allRepos}
+	return &commentRepository{&database{DB}, allRepos}
 }
 
 // AddComment persists the given struct into the database.
-func (repo commentRepository) AddComment(c *comment.Comment) (*comment.Comment, error) {
+func (repo commentRepository) AddComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
 	query := `INSERT INTO comments (post_from, reply_to, content, commented_by)
 				VALUES ($1, $2, $3, $4)
 				RETURNING id`
-	err := repo.db.QueryRow(query, c.OriginPost, c.ReplyTo, c.Content, c.Commenter).Scan(&c.ID)
+	err := repo.db.QueryRowContext(ctx, query, c.OriginPost, c.ReplyTo, c.Content, c.Commenter).Scan(&c.ID)
 	if err != nil {
 		if pgErr, isPGErr := err.(pq.Error); !isPGErr {
 			switch pgErr.Column {
@@ -39,14 +40,14 @@ func (repo commentRepository) AddComment(c *comment.Comment) (*comment.Comment,
 }
 
 // GetComment returns a comment.Comment under the given id from the database.
-func (repo commentRepository) GetComment(id int) (*comment.Comment, error) {
+func (repo commentRepository) GetComment(ctx context.Context, id int) (*comment.Comment, error) {
 	var err error
 	var c = new(comment.Comment)
 
 	query := `SELECT post_from,commented_by,content,reply_to,creation_time
 				FROM comments
 				WHERE id = $1`
-	err = repo.db.QueryRow(query, id).Scan(&c.OriginPost, &c.Commenter, &c.Content, &c.ReplyTo, &c.CreationTime)
+	err = repo.db.QueryRowContext(ctx, query, id).Scan(&c.OriginPost, &c.Commenter, &c.Content, &c.ReplyTo, &c.CreationTime)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, comment.ErrCommentNotFound
@@ -59,10 +60,10 @@ func (repo commentRepository) GetComment(id int) (*comment.Comment, error) {
 
 // GetComments returns all comments in the database that match the given post
 // id.
-func (repo commentRepository) GetComments(postID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+func (repo commentRepository) GetComments(ctx context.Context, postID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
 	{ // block checks if post exits
 		var found bool
-		err := repo.db.QueryRow(`
+		err := repo.db.QueryRowContext(ctx, `
 				SELECT EXISTS(
                SELECT *
                FROM posts
@@ -82,7 +83,7 @@ func (repo commentRepository) GetComments(postID int, by string, order string, l
 			WHERE post_from = $1
 			ORDER BY %s %s
 			LIMIT $2 OFFSET $3`, by, order)
-	rows, err = repo.db.Query(query, postID, limit, offset)
+	rows, err = repo.db.QueryContext(ctx, query, postID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("querying for comments failed because of: %v", err)
 	}
@@ -107,10 +108,10 @@ func (repo commentRepository) GetComments(postID int, by string, order string, l
 
 // GetComments returns all comments in the database that match the given reply_to
 // id.
-func (repo commentRepository) GetReplies(commentID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+func (repo commentRepository) GetReplies(ctx context.Context, commentID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
 	{ // block checks if root comment exits
 		var found bool
-		err := repo.db.QueryRow(`
+		err := repo.db.QueryRowContext(ctx, `
 				SELECT EXISTS(
                SELECT *
                FROM comments
@@ -130,7 +131,7 @@ func (repo commentRepository) GetReplies(commentID int, by string, order string,
 			WHERE reply_to = $1
 			ORDER BY %s %s
 			LIMIT $2 OFFSET $3`, by, order)
-	rows, err = repo.db.Query(query, commentID, limit, offset)
+	rows, err = repo.db.QueryContext(ctx, query, commentID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("querying for comments failed because of: %v", err)
 	}
@@ -154,14 +155,14 @@ func (repo commentRepository) GetReplies(commentID int, by string, order string,
 }
 
 // UpdateComment updates a comment in the database according to the given struct.
-func (repo commentRepository) UpdateComment(c *comment.Comment) (*comment.Comment, error) {
+func (repo commentRepository) UpdateComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
 	var errs []error
 
 	if c.Content != "" {
 		query := `UPDATE comments
 				SET content = $1 
 				WHERE id = $2`
-		_, err := repo.db.Exec(query, c.Content, c.ID)
+		_, err := repo.db.ExecContext(ctx, query, c.Content, c.ID)
 		if err != nil {
 			errs = append(errs, err)
 		}
@@ -170,7 +171,7 @@ func (repo commentRepository) UpdateComment(c *comment.Comment) (*comment.Commen
 	if len(errs) == maxNoOfPossibleErr {
 		return nil, fmt.Errorf("was unable to update any data because of %v", errs)
 	}
-	c, err := repo.GetComment(c.ID)
+	c, err := repo.GetComment(ctx, c.ID)
 	if err == nil {
 		if len(errs) > 0 {
 			err = comment.ErrSomeCommentDataNotPersisted
@@ -180,8 +181,8 @@ func (repo commentRepository) UpdateComment(c *comment.Comment) (*comment.Commen
 }
 
 // DeleteComment removes the comment under the given id from the database.
-func (repo commentRepository) DeleteComment(id int) error {
-	_, err := repo.db.Exec(`DELETE FROM comments
+func (repo commentRepository) DeleteComment(ctx context.Context, id int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM comments
 							WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("deletion of comment failed because of: %v", err)
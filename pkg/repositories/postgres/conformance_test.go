@@ -0,0 +1,66 @@
+package postgres_test
+
+import (
+	"database/sql"
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/conformance"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/postgres/migrations"
+
+	_ "github.com/lib/pq"
+)
+
+// dsnVariable names the variable holding the data source name of the database
+// the conformance checks are run against. EVERYTHING IN IT IS DROPPED.
+const dsnVariable = "ISSUE1_TEST_POSTGRES_DSN"
+
+// TestConformance runs the conformance checks against the database named by
+// ISSUE1_TEST_POSTGRES_DSN, rolling its schema back and applying it anew
+// before each check. It's skipped if the variable isn't set.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(dsnVariable)
+	if dsn == "" {
+		t.Skipf("%s isn't set", dsnVariable)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err = db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.NewMigrator(db, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conformance.Run(t, func() (*conformance.Repositories, func(), error) {
+		if _, err := migrator.Down(math.MaxInt32); err != nil {
+			return nil, nil, err
+		}
+		if _, err := migrator.Up(); err != nil {
+			return nil, nil, err
+		}
+		dbRepos := make(map[string]interface{})
+		repos := &conformance.Repositories{
+			User:    postgres.NewUserRepository(db, &dbRepos),
+			Channel: postgres.NewChannelRepository(db, &dbRepos),
+			Feed:    postgres.NewFeedRepository(db, &dbRepos),
+			Release: postgres.NewReleaseRepository(db, &dbRepos),
+			Post:    postgres.NewPostRepository(db, &dbRepos),
+			Comment: postgres.NewCommentRepository(db, &dbRepos),
+			Search:  postgres.NewSearchRepository(db, &dbRepos),
+			Auth:    postgres.NewAuthRepository(db, &dbRepos),
+
+			UnitOfWork: postgres.NewUnitOfWork(db),
+		}
+		repos.Register(dbRepos)
+		return repos, func() {}, nil
+	})
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/lib/pq"
@@ -14,12 +15,11 @@ type feedRepository repository
 // the database connection must be passed as the first argument
 // since for the repo to work.
 func NewFeedRepository(db *sql.DB, allRepos *map[string]interface{}) feed.Repository {
-	return &feedRepository{db: db, allRepos: allRepos}
+	return &feedRepository{db: &database{db}, allRepos: allRepos}
 }
 
-// AddFeed persists a feed entity to the DB according to the feed.Feed struct passed in.
-func (repo *feedRepository) AddFeed(f *feed.Feed) error {
-	var err error
+// AddFeed persists a feed entity to the DB according to the feed.Feed struct passed in, all or nothing.
+func (repo *feedRepository) AddFeed(ctx context.Context, f *feed.Feed) error {
 	var sorting string
 	switch f.Sorting {
 	case feed.SortHot:
@@ -29,33 +29,31 @@ func (repo *feedRepository) AddFeed(f *feed.Feed) error {
 	default:
 		sorting = "top"
 	}
-	result, err := repo.db.Exec(`INSERT INTO feeds (owner_username,sorting)
-										VALUES ($1, $2)`, f.OwnerUsername, sorting)
-	if err != nil {
-		return fmt.Errorf("insertion of user failed because of: %s", err.Error())
-	}
-
-	id64, err := result.LastInsertId()
-	if err == nil {
-		return fmt.Errorf("unable to get LastInsertId")
-	}
-	username := f.OwnerUsername
-	f.OwnerUsername = ""
-	id := uint(id64)
-	err = repo.UpdateFeed(id, f)
-	if err != nil {
-		return fmt.Errorf("the feed was created for user %s but some data wasn't persisted because of: %s", username, err.Error())
-	}
-	return nil
+	return repo.db.transact(ctx, func(ctx context.Context) error {
+		var id uint
+		err := repo.db.QueryRowContext(ctx, `INSERT INTO feeds (owner_username,sorting)
+										VALUES ($1, $2)
+										RETURNING id`, f.OwnerUsername, sorting).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("insertion of user failed because of: %s", err.Error())
+		}
+		username := f.OwnerUsername
+		f.OwnerUsername = ""
+		err = repo.UpdateFeed(ctx, id, f)
+		if err != nil {
+			return fmt.Errorf("the feed couldn't be created for user %s because of: %s", username, err.Error())
+		}
+		return nil
+	})
 }
 
 // GetFeed retrieve the feed entity in the database belonging to the user of the passed
 // in username.
-func (repo *feedRepository) GetFeed(username string) (*feed.Feed, error) {
+func (repo *feedRepository) GetFeed(ctx context.Context, username string) (*feed.Feed, error) {
 	var err error
 	f := feed.Feed{OwnerUsername: username}
 	var sorting string
-	err = repo.db.QueryRow(`SELECT id, sorting
+	err = repo.db.QueryRowContext(ctx, `SELECT id, sorting
 	 								FROM feeds
 	 								WHERE owner_username = $1`, username).Scan(&f.ID, &sorting)
 	if err != nil {
@@ -76,9 +74,9 @@ func (repo *feedRepository) GetFeed(username string) (*feed.Feed, error) {
 }
 
 // GetChannels retrieves the all the channels the given feed has subscribed to.
-func (repo *feedRepository) GetChannels(f *feed.Feed, sortBy string, sortOrder string) ([]*feed.Channel, error) {
+func (repo *feedRepository) GetChannels(ctx context.Context, f *feed.Feed, sortBy string, sortOrder string) ([]*feed.Channel, error) {
 	channelSubscriptions := make([]*feed.Channel, 0)
-	rows, err := repo.db.Query(fmt.Sprintf(`
+	rows, err := repo.db.QueryContext(ctx, fmt.Sprintf(`
 		SELECT username, name, subscription_time
 		FROM (
 			(
@@ -113,14 +111,14 @@ func (repo *feedRepository) GetChannels(f *feed.Feed, sortBy string, sortOrder s
 // GetPosts returns a list of posts collected from the channels
 // the given feed has subscribed to sorted according to the given
 // method.
-func (repo *feedRepository) GetPosts(f *feed.Feed, sort feed.Sorting, limit, offset int) ([]*feed.Post, error) {
+func (repo *feedRepository) GetPosts(ctx context.Context, f *feed.Feed, sort feed.Sorting, limit, offset int) ([]*feed.Post, error) {
 	var err error
 
 	var rows *sql.Rows
 	switch sort {
 	// TODO test queries with actual posts
 	case feed.SortNew:
-		rows, err = repo.db.Query(`
+		rows, err = repo.db.QueryContext(ctx, `
 		SELECT id
 		FROM	(SELECT id, creation_time
 				      FROM (
@@ -133,7 +131,7 @@ func (repo *feedRepository) GetPosts(f *feed.Feed, sort feed.Sorting, limit, off
 				     ) AS P
 		ORDER BY creation_time DESC NULLS LAST LIMIT $2 OFFSET $3`, f.ID, limit, offset)
 	case feed.SortHot:
-		rows, err = repo.db.Query(`
+		rows, err = repo.db.QueryContext(ctx, `
 		SELECT post_id
 		FROM(SELECT LP.post_id, comment_count
 			FROM(
@@ -162,7 +160,7 @@ func (repo *feedRepository) GetPosts(f *feed.Feed, sort feed.Sorting, limit, off
 	case feed.SortTop:
 		fallthrough
 	default:
-		rows, err = repo.db.Query(`
+		rows, err = repo.db.QueryContext(ctx, `
 		SELECT post_id
 		FROM(SELECT Lp.post_id, total_star_count
 			FROM(
@@ -211,7 +209,7 @@ func (repo *feedRepository) GetPosts(f *feed.Feed, sort feed.Sorting, limit, off
 }
 
 // UpdateFeed updates the feed entity under the given id based on the passed in feed.Feed struct.
-func (repo *feedRepository) UpdateFeed(id uint, f *feed.Feed) error {
+func (repo *feedRepository) UpdateFeed(ctx context.Context, id uint, f *feed.Feed) error {
 	var sorting string
 	switch f.Sorting {
 	case feed.SortHot:
@@ -223,14 +221,14 @@ func (repo *feedRepository) UpdateFeed(id uint, f *feed.Feed) error {
 	}
 	/*
 		if f.OwnerUsername != "" {
-			err = repo.execUpdateStatementOnColumn("owner_username", f.OwnerUsername, id)
+			err = repo.execUpdateStatementOnColumn(ctx, "owner_username", f.OwnerUsername, id)
 			if err != nil {
 				return err
 			}
 			// change username for subsequent calls if username changed
 		}
 	*/
-	err := repo.execUpdateStatementOnColumn("sorting", sorting, id)
+	err := repo.execUpdateStatementOnColumn(ctx, "sorting", sorting, id)
 	if err != nil {
 		return err
 	}
@@ -238,8 +236,8 @@ func (repo *feedRepository) UpdateFeed(id uint, f *feed.Feed) error {
 }
 
 // execUpdateStatementOnColumn is just a helper function
-func (repo *feedRepository) execUpdateStatementOnColumn(column, value string, id uint) error {
-	_, err := repo.db.Exec(fmt.Sprintf(`
+func (repo *feedRepository) execUpdateStatementOnColumn(ctx context.Context, column, value string, id uint) error {
+	_, err := repo.db.ExecContext(ctx, fmt.Sprintf(`
 			UPDATE feeds 
 			SET %s = $1 
 			WHERE id = $2`, column), value, id)
@@ -250,8 +248,8 @@ func (repo *feedRepository) execUpdateStatementOnColumn(column, value string, id
 }
 
 // Subscribe adds the given channel to the list of channels that the feed collects posts from.
-func (repo *feedRepository) Subscribe(f *feed.Feed, channelname string) error {
-	_, err := repo.db.Exec(`
+func (repo *feedRepository) Subscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	_, err := repo.db.ExecContext(ctx, `
 		INSERT INTO feed_subscriptions (feed_id,channel_username)
 		VALUES ($1, $2)
 		ON CONFLICT DO NOTHING
@@ -269,8 +267,8 @@ func (repo *feedRepository) Subscribe(f *feed.Feed, channelname string) error {
 }
 
 // Unsubscribe removes the channel to the list of channels that the feed collects posts from.
-func (repo *feedRepository) Unsubscribe(f *feed.Feed, channelname string) error {
-	_, err := repo.db.Exec(`
+func (repo *feedRepository) Unsubscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	_, err := repo.db.ExecContext(ctx, `
 		DELETE FROM feed_subscriptions
 		WHERE feed_id = $1 AND channel_username = $2`, f.ID, channelname)
 	if err != nil {
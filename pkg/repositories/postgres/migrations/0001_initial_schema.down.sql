@@ -0,0 +1,42 @@
+--
+-- Drops the domain tables and the functions their triggers use.
+-- The citext extension is left in place since it's created by setup-schema.sql.
+--
+
+DROP TABLE IF EXISTS
+    "issue#1".tsvs_comment,
+    "issue#1".tsvs_posts,
+    "issue#1".tsvs_release,
+    "issue#1".user_avatars,
+    "issue#1".user_bookmarks,
+    "issue#1".users_bio,
+    "issue#1".post_stars,
+    "issue#1".post_contents,
+    "issue#1".comments,
+    "issue#1".channel_stickies,
+    "issue#1".channel_official_catalog,
+    "issue#1".channel_pictures,
+    "issue#1".channel_admins,
+    "issue#1".feed_subscriptions,
+    "issue#1".feeds,
+    "issue#1".posts,
+    "issue#1".release_metadata,
+    "issue#1".releases_image_based,
+    "issue#1".releases_text_based,
+    "issue#1".releases,
+    "issue#1".channels,
+    "issue#1".users
+    CASCADE;
+
+DROP SEQUENCE IF EXISTS
+    "issue#1".comments_id_seq,
+    "issue#1".feeds_id_seq,
+    "issue#1".post_id_seq,
+    "issue#1".title_id_seq;
+
+DROP FUNCTION IF EXISTS
+    "issue#1".setup_user(),
+    "issue#1".tsv_comment_trigger(),
+    "issue#1".tsv_on_metadata_update_trigger(),
+    "issue#1".tsv_post_trigger(),
+    "issue#1".tsv_text_based_update_trigger();
@@ -0,0 +1,1221 @@
+--
+-- Initial schema of the domain tables, taken from the pg_dump previously kept in scripts/setup-db/setup-tables.sql.
+-- The extension is normally created by scripts/setup-db/setup-schema.sql since only superusers can create it
+-- on older servers, creating it here is a no-op then.
+--
+
+CREATE EXTENSION IF NOT EXISTS citext WITH SCHEMA "issue#1";
+
+
+--
+-- Name: setup_user(); Type: FUNCTION; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE FUNCTION "issue#1".setup_user() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+BEGIN
+    insert into channels(username, name)
+    values (new.username, new.username || '''s channel');
+    insert into channel_admins (channel_username, username, is_owner)
+    values (new.username, new.username, true);
+    insert into feeds(owner_username, sorting)
+    values (new.username, 'hot');
+    return new;
+END;
+$$;
+
+
+ALTER FUNCTION "issue#1".setup_user() OWNER TO "issue#1_dev";
+
+--
+-- Name: tsv_comment_trigger(); Type: FUNCTION; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE FUNCTION "issue#1".tsv_comment_trigger() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+DECLARE
+    tsv tsvector := ''::tsvector;
+BEGIN
+    tsv := (SELECT setweight(to_tsvector('english', COALESCE(new.content, '')), 'D')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(new.commented_by, '')), 'B')
+    );
+    insert into tsvs_comment (comment_id, vector)
+    values (new.id, tsv)
+    ON CONFLICT (comment_id)
+        do update
+        set vector= tsv;
+    return new;
+END;
+$$;
+
+
+ALTER FUNCTION "issue#1".tsv_comment_trigger() OWNER TO "issue#1_dev";
+
+--
+-- Name: tsv_on_metadata_update_trigger(); Type: FUNCTION; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE FUNCTION "issue#1".tsv_on_metadata_update_trigger() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+DECLARE
+    tsv tsvector := ''::tsvector;
+BEGIN
+    case (select r.type from releases as r where id = new.release_id)
+        when 'image' then tsv := (SELECT setweight(to_tsvector('english', COALESCE(owner_channel, '')), 'B')
+                                             ||
+                                         setweight(to_tsvector('english', 'image'), 'A')
+                                             ||
+                                         setweight(to_tsvector('english', COALESCE(new.description, '')), 'D')
+                                             ||
+                                         setweight(to_tsvector('english', new.other), 'C')
+                                             ||
+                                         setweight(to_tsvector('english', COALESCE(new.genre_defining, '')), 'B')
+                                             ||
+                                         setweight(to_tsvector('english', COALESCE(new.title, '')), 'A')
+                                  FROM (SELECT owner_channel
+                                        FROM releases
+                                        WHERE id = new.release_id) as r2
+        );
+                          insert into tsvs_release (release_id, vector)
+                          values (new.release_id, tsv)
+                          ON CONFLICT (release_id) do update
+                              set vector= tsv;
+        when 'text' then tsv := (SELECT setweight(to_tsvector('english', COALESCE(owner_channel, '')), 'B')
+                                            ||
+                                        setweight(to_tsvector('english', 'text'), 'A')
+                                            ||
+                                        setweight(to_tsvector('english', COALESCE(new.description, '')), 'D')
+                                            ||
+                                        setweight(to_tsvector('english', new.other), 'C')
+                                            ||
+                                        setweight(to_tsvector('english', COALESCE(new.genre_defining, '')), 'B')
+                                            ||
+                                        setweight(to_tsvector('english', COALESCE(new.title, '')), 'A')
+                                            ||
+                                        setweight(to_tsvector('english', COALESCE(content, '')), 'D')
+                                 FROM (SELECT id as release_id, owner_channel
+                                       FROM releases
+                                       WHERE id = new.release_id
+                                      ) as r2
+                                          left join releases_text_based on releases_text_based.release_id = r2.release_id
+        );
+                         insert into tsvs_release (release_id, vector)
+                         values (new.release_id, tsv)
+                         ON CONFLICT (release_id) do update
+                             set vector= tsv;
+        else
+        end case;
+    return new;
+END;
+$$;
+
+
+ALTER FUNCTION "issue#1".tsv_on_metadata_update_trigger() OWNER TO "issue#1_dev";
+
+--
+-- Name: tsv_post_trigger(); Type: FUNCTION; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE FUNCTION "issue#1".tsv_post_trigger() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+DECLARE
+    tsv tsvector := ''::tsvector;
+BEGIN
+    tsv := (SELECT setweight(to_tsvector('english', COALESCE(new.description, '')), 'D')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(new.title, '')), 'A')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(new.posted_by, '')), 'B')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(new.channel_from, '')), 'B')
+    );
+    insert into tsvs_posts (post_id, vector)
+    values (new.id, tsv)
+    ON CONFLICT (post_id)
+        do update
+        set vector= tsv;
+    return new;
+END;
+$$;
+
+
+ALTER FUNCTION "issue#1".tsv_post_trigger() OWNER TO "issue#1_dev";
+
+--
+-- Name: tsv_text_based_update_trigger(); Type: FUNCTION; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE FUNCTION "issue#1".tsv_text_based_update_trigger() RETURNS trigger
+    LANGUAGE plpgsql
+AS $$
+DECLARE
+    tsv tsvector := ''::tsvector;
+BEGIN
+    tsv := (SELECT setweight(to_tsvector('english', COALESCE(owner_channel, '')), 'B')
+                       ||
+                   setweight(to_tsvector('english', 'text'), 'A')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(description, '')), 'D')
+                       ||
+                   setweight(to_tsvector('english', other), 'C')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(genre_defining, '')), 'B')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(title, '')), 'A')
+                       ||
+                   setweight(to_tsvector('english', COALESCE(new.content, '')), 'D')
+            FROM (SELECT id as release_id, owner_channel
+                  FROM releases
+                  WHERE id = new.release_id
+                 ) as r2
+                     left join release_metadata on release_metadata.release_id = r2.release_id
+    );
+    insert into tsvs_release (release_id, vector)
+    values (new.release_id, tsv)
+    ON CONFLICT (release_id) do update
+        set vector= tsv;
+    return new;
+END;
+$$;
+
+
+ALTER FUNCTION "issue#1".tsv_text_based_update_trigger() OWNER TO "issue#1_dev";
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+--
+-- Name: channel_admins; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".channel_admins (
+                                          channel_username character varying(24) NOT NULL,
+                                          username character varying(24) NOT NULL,
+                                          is_owner boolean NOT NULL
+);
+
+
+ALTER TABLE "issue#1".channel_admins OWNER TO "issue#1_dev";
+
+--
+-- Name: channel_official_catalog; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".channel_official_catalog (
+                                                    channel_username character varying(24) NOT NULL,
+                                                    release_id integer NOT NULL,
+                                                    post_from_id integer NOT NULL
+);
+
+
+ALTER TABLE "issue#1".channel_official_catalog OWNER TO "issue#1_dev";
+
+--
+-- Name: channel_pictures; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".channel_pictures (
+                                            channelname character varying(24) NOT NULL,
+                                            image_name text NOT NULL
+);
+
+
+ALTER TABLE "issue#1".channel_pictures OWNER TO "issue#1_dev";
+
+--
+-- Name: channel_stickies; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".channel_stickies (
+    post_id integer
+);
+
+
+ALTER TABLE "issue#1".channel_stickies OWNER TO "issue#1_dev";
+
+--
+-- Name: channels; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".channels (
+                                    username character varying(24) NOT NULL,
+                                    creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                    name character varying(80) NOT NULL,
+                                    description text
+);
+
+
+ALTER TABLE "issue#1".channels OWNER TO "issue#1_dev";
+
+--
+-- Name: comments; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".comments (
+                                    post_from integer NOT NULL,
+                                    id integer NOT NULL,
+                                    reply_to integer NOT NULL,
+                                    content text NOT NULL,
+                                    commented_by character varying(24) NOT NULL,
+                                    creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+
+ALTER TABLE "issue#1".comments OWNER TO "issue#1_dev";
+
+--
+-- Name: comments_id_seq; Type: SEQUENCE; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE "issue#1".comments ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME "issue#1".comments_id_seq
+        START WITH 1
+        INCREMENT BY 1
+        NO MINVALUE
+        NO MAXVALUE
+        CACHE 1
+    );
+
+
+--
+-- Name: feed_subscriptions; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".feed_subscriptions (
+                                              feed_id integer NOT NULL,
+                                              channel_username character varying(24) NOT NULL,
+                                              subscription_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+
+ALTER TABLE "issue#1".feed_subscriptions OWNER TO "issue#1_dev";
+
+--
+-- Name: feeds; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".feeds (
+                                 owner_username character varying(24) NOT NULL,
+                                 sorting text NOT NULL,
+                                 id integer NOT NULL
+);
+
+
+ALTER TABLE "issue#1".feeds OWNER TO "issue#1_dev";
+
+--
+-- Name: feeds_id_seq; Type: SEQUENCE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE SEQUENCE "issue#1".feeds_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "issue#1".feeds_id_seq OWNER TO "issue#1_dev";
+
+--
+-- Name: feeds_id_seq; Type: SEQUENCE OWNED BY; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER SEQUENCE "issue#1".feeds_id_seq OWNED BY "issue#1".feeds.id;
+
+
+--
+-- Name: post_contents; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".post_contents (
+                                         post_id integer NOT NULL,
+                                         release_id integer NOT NULL
+);
+
+
+ALTER TABLE "issue#1".post_contents OWNER TO "issue#1_dev";
+
+--
+-- Name: posts; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".posts (
+                                 id integer NOT NULL,
+                                 description text,
+                                 title character varying(256) NOT NULL,
+                                 posted_by character varying(22) NOT NULL,
+                                 channel_from character varying(22) NOT NULL,
+                                 creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+
+ALTER TABLE "issue#1".posts OWNER TO "issue#1_dev";
+
+--
+-- Name: post_id_seq; Type: SEQUENCE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE SEQUENCE "issue#1".post_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "issue#1".post_id_seq OWNER TO "issue#1_dev";
+
+--
+-- Name: post_id_seq; Type: SEQUENCE OWNED BY; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER SEQUENCE "issue#1".post_id_seq OWNED BY "issue#1".posts.id;
+
+
+--
+-- Name: post_stars; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".post_stars (
+                                      star_count integer NOT NULL,
+                                      post_id integer NOT NULL,
+                                      username character varying(22) NOT NULL
+);
+
+
+ALTER TABLE "issue#1".post_stars OWNER TO "issue#1_dev";
+
+--
+-- Name: release_metadata; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".release_metadata (
+                                            release_id integer NOT NULL,
+                                            description text,
+                                            other jsonb,
+                                            genre_defining text,
+                                            release_date timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                            title text
+);
+
+
+ALTER TABLE "issue#1".release_metadata OWNER TO "issue#1_dev";
+
+--
+-- Name: releases; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".releases (
+                                    id integer NOT NULL,
+                                    owner_channel character varying(24) NOT NULL,
+                                    type text NOT NULL,
+                                    creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+
+ALTER TABLE "issue#1".releases OWNER TO "issue#1_dev";
+
+--
+-- Name: releases_image_based; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".releases_image_based (
+                                                release_id integer NOT NULL,
+                                                image_name text NOT NULL
+);
+
+
+ALTER TABLE "issue#1".releases_image_based OWNER TO "issue#1_dev";
+
+--
+-- Name: releases_text_based; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".releases_text_based (
+                                               release_id integer NOT NULL,
+                                               content text NOT NULL
+);
+
+
+ALTER TABLE "issue#1".releases_text_based OWNER TO "issue#1_dev";
+
+--
+-- Name: title_id_seq; Type: SEQUENCE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE SEQUENCE "issue#1".title_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE "issue#1".title_id_seq OWNER TO "issue#1_dev";
+
+--
+-- Name: title_id_seq; Type: SEQUENCE OWNED BY; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER SEQUENCE "issue#1".title_id_seq OWNED BY "issue#1".releases.id;
+
+
+--
+-- Name: tsvs_comment; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".tsvs_comment (
+                                        comment_id integer NOT NULL,
+                                        vector tsvector
+);
+
+
+ALTER TABLE "issue#1".tsvs_comment OWNER TO "issue#1_dev";
+
+--
+-- Name: tsvs_posts; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".tsvs_posts (
+                                      post_id integer NOT NULL,
+                                      vector tsvector
+);
+
+
+ALTER TABLE "issue#1".tsvs_posts OWNER TO "issue#1_dev";
+
+--
+-- Name: tsvs_release; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".tsvs_release (
+                                        release_id integer NOT NULL,
+                                        vector tsvector
+);
+
+
+ALTER TABLE "issue#1".tsvs_release OWNER TO "issue#1_dev";
+
+--
+-- Name: user_avatars; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".user_avatars (
+                                        username character varying(24) NOT NULL,
+                                        image_name text NOT NULL
+);
+
+
+ALTER TABLE "issue#1".user_avatars OWNER TO "issue#1_dev";
+
+--
+-- Name: user_bookmarks; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".user_bookmarks (
+                                          username character varying(24) NOT NULL,
+                                          post_id integer NOT NULL,
+                                          creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+
+ALTER TABLE "issue#1".user_bookmarks OWNER TO "issue#1_dev";
+
+--
+-- Name: users; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".users (
+                                 email "issue#1".citext NOT NULL,
+                                 username character varying(24) NOT NULL,
+                                 creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                 pass_hash text NOT NULL,
+                                 first_name character varying(30),
+                                 middle_name character varying(30),
+                                 last_name character varying(30)
+);
+
+
+ALTER TABLE "issue#1".users OWNER TO "issue#1_dev";
+
+--
+-- Name: users_bio; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE "issue#1".users_bio (
+                                     username character varying(24) NOT NULL,
+                                     bio text NOT NULL
+);
+
+
+ALTER TABLE "issue#1".users_bio OWNER TO "issue#1_dev";
+
+--
+-- Name: feeds id; Type: DEFAULT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".feeds ALTER COLUMN id SET DEFAULT nextval('"issue#1".feeds_id_seq'::regclass);
+
+
+--
+-- Name: posts id; Type: DEFAULT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".posts ALTER COLUMN id SET DEFAULT nextval('"issue#1".post_id_seq'::regclass);
+
+
+--
+-- Name: releases id; Type: DEFAULT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases ALTER COLUMN id SET DEFAULT nextval('"issue#1".title_id_seq'::regclass);
+
+
+--
+-- Name: channel_admins channel_admins_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_admins
+    ADD CONSTRAINT channel_admins_pkey PRIMARY KEY (channel_username, username);
+
+
+--
+-- Name: channel_official_catalog channel_catalog_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_official_catalog
+    ADD CONSTRAINT channel_catalog_pkey PRIMARY KEY (channel_username, release_id);
+
+
+--
+-- Name: channel_pictures channel_pictures_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_pictures
+    ADD CONSTRAINT channel_pictures_pk PRIMARY KEY (channelname);
+
+
+--
+-- Name: channels channels_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channels
+    ADD CONSTRAINT channels_pkey PRIMARY KEY (username);
+
+
+--
+-- Name: tsvs_comment comment_tsvs_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".tsvs_comment
+    ADD CONSTRAINT comment_tsvs_pk PRIMARY KEY (comment_id);
+
+
+--
+-- Name: comments comments_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".comments
+    ADD CONSTRAINT comments_pk PRIMARY KEY (id);
+
+
+--
+-- Name: feed_subscriptions feed_channels_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".feed_subscriptions
+    ADD CONSTRAINT feed_channels_pkey PRIMARY KEY (feed_id, channel_username);
+
+
+--
+-- Name: feeds feeds_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".feeds
+    ADD CONSTRAINT feeds_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: releases_image_based image based_image_name_key; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases_image_based
+    ADD CONSTRAINT "image based_image_name_key" UNIQUE (image_name);
+
+
+--
+-- Name: releases_image_based image based_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases_image_based
+    ADD CONSTRAINT "image based_pkey" PRIMARY KEY (release_id);
+
+
+--
+-- Name: release_metadata metadata_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".release_metadata
+    ADD CONSTRAINT metadata_pkey PRIMARY KEY (release_id);
+
+
+--
+-- Name: post_contents post_contents_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".post_contents
+    ADD CONSTRAINT post_contents_pkey PRIMARY KEY (post_id, release_id);
+
+
+--
+-- Name: posts post_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".posts
+    ADD CONSTRAINT post_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: post_stars post_stars_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".post_stars
+    ADD CONSTRAINT post_stars_pkey PRIMARY KEY (username, post_id);
+
+
+--
+-- Name: tsvs_posts posts_tsvs_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".tsvs_posts
+    ADD CONSTRAINT posts_tsvs_pk PRIMARY KEY (post_id);
+
+
+--
+-- Name: releases release_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases
+    ADD CONSTRAINT release_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: tsvs_release release_tsvs_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".tsvs_release
+    ADD CONSTRAINT release_tsvs_pk PRIMARY KEY (release_id);
+
+
+--
+-- Name: releases_text_based text based_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases_text_based
+    ADD CONSTRAINT "text based_pkey" PRIMARY KEY (release_id);
+
+
+--
+-- Name: user_avatars user_avatars_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".user_avatars
+    ADD CONSTRAINT user_avatars_pk PRIMARY KEY (username);
+
+
+--
+-- Name: user_bookmarks user_bookmarks_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".user_bookmarks
+    ADD CONSTRAINT user_bookmarks_pkey PRIMARY KEY (username, post_id);
+
+
+--
+-- Name: users_bio users_bio_pk; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".users_bio
+    ADD CONSTRAINT users_bio_pk PRIMARY KEY (username);
+
+
+--
+-- Name: users users_email_key; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".users
+    ADD CONSTRAINT users_email_key UNIQUE (email);
+
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (username);
+
+
+--
+-- Name: comment_ts_index; Type: INDEX; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE INDEX comment_ts_index ON "issue#1".tsvs_comment USING gin (vector);
+
+
+--
+-- Name: post_ts_index; Type: INDEX; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE INDEX post_ts_index ON "issue#1".tsvs_posts USING gin (vector);
+
+
+--
+-- Name: release_ts_index; Type: INDEX; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE INDEX release_ts_index ON "issue#1".tsvs_release USING gin (vector);
+
+
+--
+-- Name: release_tsvs_release_id_uindex; Type: INDEX; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE UNIQUE INDEX release_tsvs_release_id_uindex ON "issue#1".tsvs_release USING btree (release_id);
+
+
+--
+-- Name: comments comment_insert_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER comment_insert_trigger AFTER INSERT ON "issue#1".comments FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_comment_trigger();
+
+
+--
+-- Name: comments comment_update_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER comment_update_trigger AFTER UPDATE ON "issue#1".comments FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_comment_trigger();
+
+
+--
+-- Name: release_metadata metadata_insert_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER metadata_insert_trigger AFTER INSERT ON "issue#1".release_metadata FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_on_metadata_update_trigger();
+
+
+--
+-- Name: release_metadata metadata_update_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER metadata_update_trigger AFTER UPDATE ON "issue#1".release_metadata FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_on_metadata_update_trigger();
+
+
+--
+-- Name: posts post_insert_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER post_insert_trigger AFTER INSERT ON "issue#1".posts FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_post_trigger();
+
+
+--
+-- Name: posts post_update_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER post_update_trigger AFTER UPDATE ON "issue#1".posts FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_post_trigger();
+
+
+--
+-- Name: users setup_user; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER setup_user AFTER INSERT ON "issue#1".users FOR EACH ROW EXECUTE FUNCTION "issue#1".setup_user();
+
+
+--
+-- Name: releases_text_based text_based_update_trigger; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TRIGGER text_based_update_trigger AFTER UPDATE ON "issue#1".releases_text_based FOR EACH ROW EXECUTE FUNCTION "issue#1".tsv_text_based_update_trigger();
+
+
+--
+-- Name: channel_admins channel_admins_channel_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_admins
+    ADD CONSTRAINT channel_admins_channel_username_fkey FOREIGN KEY (channel_username) REFERENCES "issue#1".channels(username) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: channel_admins channel_admins_user_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_admins
+    ADD CONSTRAINT channel_admins_user_username_fkey FOREIGN KEY (username) REFERENCES "issue#1".users(username) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: channel_official_catalog channel_catalog_channel_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_official_catalog
+    ADD CONSTRAINT channel_catalog_channel_username_fkey FOREIGN KEY (channel_username) REFERENCES "issue#1".channels(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: channel_official_catalog channel_catalog_post_from_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_official_catalog
+    ADD CONSTRAINT channel_catalog_post_from_id_fkey FOREIGN KEY (post_from_id) REFERENCES "issue#1".posts(id);
+
+
+--
+-- Name: channel_official_catalog channel_catalog_release_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_official_catalog
+    ADD CONSTRAINT channel_catalog_release_id_fkey FOREIGN KEY (release_id) REFERENCES "issue#1".releases(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: channel_pictures channel_pictures_channels_username_fk; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_pictures
+    ADD CONSTRAINT channel_pictures_channels_username_fk FOREIGN KEY (channelname) REFERENCES "issue#1".channels(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: tsvs_comment comment_tsvs_comments_id_fk; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".tsvs_comment
+    ADD CONSTRAINT comment_tsvs_comments_id_fk FOREIGN KEY (comment_id) REFERENCES "issue#1".comments(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: comments comments_commented_by_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".comments
+    ADD CONSTRAINT comments_commented_by_fkey FOREIGN KEY (commented_by) REFERENCES "issue#1".users(username) ON UPDATE CASCADE NOT VALID;
+
+
+--
+-- Name: comments comments_post_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".comments
+    ADD CONSTRAINT comments_post_id_fkey FOREIGN KEY (post_from) REFERENCES "issue#1".posts(id) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: feed_subscriptions feed_channels_channel_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".feed_subscriptions
+    ADD CONSTRAINT feed_channels_channel_username_fkey FOREIGN KEY (channel_username) REFERENCES "issue#1".channels(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: feed_subscriptions feed_channels_feed_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".feed_subscriptions
+    ADD CONSTRAINT feed_channels_feed_id_fkey FOREIGN KEY (feed_id) REFERENCES "issue#1".feeds(id) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: feeds feeds_owner_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".feeds
+    ADD CONSTRAINT feeds_owner_username_fkey FOREIGN KEY (owner_username) REFERENCES "issue#1".users(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: release_metadata metadata_release_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".release_metadata
+    ADD CONSTRAINT metadata_release_id_fkey FOREIGN KEY (release_id) REFERENCES "issue#1".releases(id) ON DELETE CASCADE;
+
+
+--
+-- Name: post_contents post_contents_post_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".post_contents
+    ADD CONSTRAINT post_contents_post_id_fkey FOREIGN KEY (post_id) REFERENCES "issue#1".posts(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: post_contents post_contents_release_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".post_contents
+    ADD CONSTRAINT post_contents_release_id_fkey FOREIGN KEY (release_id) REFERENCES "issue#1".releases(id) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: post_stars post_stars_post_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".post_stars
+    ADD CONSTRAINT post_stars_post_id_fkey FOREIGN KEY (post_id) REFERENCES "issue#1".posts(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: post_stars post_stars_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".post_stars
+    ADD CONSTRAINT post_stars_username_fkey FOREIGN KEY (username) REFERENCES "issue#1".users(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: posts posts_channel_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".posts
+    ADD CONSTRAINT posts_channel_username_fkey FOREIGN KEY (channel_from) REFERENCES "issue#1".channels(username) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: posts posts_poster_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".posts
+    ADD CONSTRAINT posts_poster_username_fkey FOREIGN KEY (posted_by) REFERENCES "issue#1".users(username) ON UPDATE CASCADE NOT VALID;
+
+
+--
+-- Name: tsvs_posts posts_tsvs_posts_id_fk; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".tsvs_posts
+    ADD CONSTRAINT posts_tsvs_posts_id_fk FOREIGN KEY (post_id) REFERENCES "issue#1".posts(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: tsvs_release release_tsvs_releases_id_fk; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".tsvs_release
+    ADD CONSTRAINT release_tsvs_releases_id_fk FOREIGN KEY (release_id) REFERENCES "issue#1".releases(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: releases_image_based releases_image_based_release_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases_image_based
+    ADD CONSTRAINT releases_image_based_release_id_fkey FOREIGN KEY (release_id) REFERENCES "issue#1".releases(id) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: releases releases_owner_channel_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases
+    ADD CONSTRAINT releases_owner_channel_fkey FOREIGN KEY (owner_channel) REFERENCES "issue#1".channels(username) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: channel_stickies stickied_posts_post_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".channel_stickies
+    ADD CONSTRAINT stickied_posts_post_id_fkey FOREIGN KEY (post_id) REFERENCES "issue#1".posts(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: releases_text_based text based_title_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".releases_text_based
+    ADD CONSTRAINT "text based_title_id_fkey" FOREIGN KEY (release_id) REFERENCES "issue#1".releases(id) ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: user_avatars user_avatars_users_username_fk; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".user_avatars
+    ADD CONSTRAINT user_avatars_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: user_bookmarks user_bookmarks_post_id_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".user_bookmarks
+    ADD CONSTRAINT user_bookmarks_post_id_fkey FOREIGN KEY (post_id) REFERENCES "issue#1".posts(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: user_bookmarks user_bookmarks_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".user_bookmarks
+    ADD CONSTRAINT user_bookmarks_username_fkey FOREIGN KEY (username) REFERENCES "issue#1".users(username) ON UPDATE CASCADE ON DELETE CASCADE;
+
+
+--
+-- Name: users_bio users_bio_username_fkey; Type: FK CONSTRAINT; Schema: issue#1; Owner: issue#1_dev
+--
+
+ALTER TABLE ONLY "issue#1".users_bio
+    ADD CONSTRAINT users_bio_username_fkey FOREIGN KEY (username) REFERENCES "issue#1".users(username) ON UPDATE CASCADE ON DELETE CASCADE NOT VALID;
+
+
+--
+-- Name: TABLE channel_admins; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".channel_admins TO "issue#1_REST";
+
+
+--
+-- Name: TABLE channel_official_catalog; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".channel_official_catalog TO "issue#1_REST";
+
+
+--
+-- Name: TABLE channel_stickies; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".channel_stickies TO "issue#1_REST";
+
+
+--
+-- Name: TABLE channels; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".channels TO "issue#1_REST";
+
+
+--
+-- Name: TABLE comments; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".comments TO "issue#1_REST";
+
+
+--
+-- Name: TABLE feed_subscriptions; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".feed_subscriptions TO "issue#1_REST";
+
+
+--
+-- Name: TABLE feeds; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".feeds TO "issue#1_REST";
+
+
+--
+-- Name: SEQUENCE feeds_id_seq; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON SEQUENCE "issue#1".feeds_id_seq TO "issue#1_REST";
+
+
+--
+-- Name: TABLE post_contents; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".post_contents TO "issue#1_REST";
+
+
+--
+-- Name: TABLE posts; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".posts TO "issue#1_REST";
+
+
+--
+-- Name: SEQUENCE post_id_seq; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON SEQUENCE "issue#1".post_id_seq TO "issue#1_REST";
+
+
+--
+-- Name: TABLE post_stars; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".post_stars TO "issue#1_REST";
+
+
+--
+-- Name: TABLE release_metadata; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".release_metadata TO "issue#1_REST";
+
+
+--
+-- Name: TABLE releases; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".releases TO "issue#1_REST";
+
+
+--
+-- Name: TABLE releases_image_based; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".releases_image_based TO "issue#1_REST";
+
+
+--
+-- Name: TABLE releases_text_based; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".releases_text_based TO "issue#1_REST";
+
+
+--
+-- Name: SEQUENCE title_id_seq; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON SEQUENCE "issue#1".title_id_seq TO "issue#1_REST";
+
+
+--
+-- Name: TABLE user_bookmarks; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".user_bookmarks TO "issue#1_REST";
+
+
+--
+-- Name: TABLE users; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".users TO "issue#1_REST";
+
+
+--
+-- Name: TABLE users_bio; Type: ACL; Schema: issue#1; Owner: issue#1_dev
+--
+
+GRANT ALL ON TABLE "issue#1".users_bio TO "issue#1_REST";
@@ -0,0 +1,18 @@
+--
+-- Drops the tables used by the auth service.
+--
+
+DROP TABLE IF EXISTS
+    "issue#1".login_lockout_events,
+    "issue#1".login_attempts,
+    "issue#1".sessions,
+    "issue#1".personal_access_tokens,
+    "issue#1".totp_recovery_codes,
+    "issue#1".totp_secrets,
+    "issue#1".email_verification_tokens,
+    "issue#1".password_reset_tokens,
+    "issue#1".refresh_tokens,
+    "issue#1".token_blacklist;
+
+ALTER TABLE "issue#1".users
+    DROP COLUMN IF EXISTS verified;
@@ -0,0 +1,226 @@
+--
+-- Tables used by the auth service.
+-- Statements are idempotent since they used to be applied by hand to existing databases.
+--
+
+--
+-- Name: token_blacklist; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".token_blacklist (
+                                                         token_id   text                     NOT NULL,
+                                                         expires_at timestamp with time zone NOT NULL,
+                                                         CONSTRAINT token_blacklist_pk PRIMARY KEY (token_id)
+);
+
+CREATE INDEX IF NOT EXISTS token_blacklist_expires_at_index ON "issue#1".token_blacklist USING btree (expires_at);
+
+ALTER TABLE "issue#1".token_blacklist OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".token_blacklist TO "issue#1_REST";
+
+--
+-- Name: refresh_tokens; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".refresh_tokens (
+                                                        token_hash      text                     NOT NULL,
+                                                        family_id       text                     NOT NULL,
+                                                        username        character varying(24)    NOT NULL,
+                                                        access_token_id text,
+                                                        used            boolean                  DEFAULT false NOT NULL,
+                                                        revoked         boolean                  DEFAULT false NOT NULL,
+                                                        creation_time   timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                        expires_at      timestamp with time zone NOT NULL,
+                                                        CONSTRAINT refresh_tokens_pk PRIMARY KEY (token_hash),
+                                                        CONSTRAINT refresh_tokens_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_index ON "issue#1".refresh_tokens USING btree (family_id);
+
+ALTER TABLE "issue#1".refresh_tokens OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".refresh_tokens TO "issue#1_REST";
+
+--
+-- Name: password_reset_tokens; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".password_reset_tokens (
+                                                               token_hash    text                     NOT NULL,
+                                                               username      character varying(24)    NOT NULL,
+                                                               used          boolean                  DEFAULT false NOT NULL,
+                                                               creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                               expires_at    timestamp with time zone NOT NULL,
+                                                               CONSTRAINT password_reset_tokens_pk PRIMARY KEY (token_hash),
+                                                               CONSTRAINT password_reset_tokens_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_username_index ON "issue#1".password_reset_tokens USING btree (username);
+
+ALTER TABLE "issue#1".password_reset_tokens OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".password_reset_tokens TO "issue#1_REST";
+
+--
+-- Name: email_verification_tokens; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".email_verification_tokens (
+                                                                   token_hash    text                     NOT NULL,
+                                                                   username      character varying(24)    NOT NULL,
+                                                                   email         "issue#1".citext         NOT NULL,
+                                                                   used          boolean                  DEFAULT false NOT NULL,
+                                                                   creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                                   expires_at    timestamp with time zone NOT NULL,
+                                                                   CONSTRAINT email_verification_tokens_pk PRIMARY KEY (token_hash),
+                                                                   CONSTRAINT email_verification_tokens_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS email_verification_tokens_username_index ON "issue#1".email_verification_tokens USING btree (username);
+
+ALTER TABLE "issue#1".email_verification_tokens OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".email_verification_tokens TO "issue#1_REST";
+
+--
+-- Name: users verified; Type: COLUMN; Schema: issue#1; Owner: issue#1_dev
+--
+-- Accounts that predate email verification are considered verified, new ones start out unverified.
+--
+
+DO
+$$
+    BEGIN
+        IF NOT EXISTS(SELECT 1
+                      FROM information_schema.columns
+                      WHERE table_schema = 'issue#1'
+                        AND table_name = 'users'
+                        AND column_name = 'verified') THEN
+            ALTER TABLE "issue#1".users
+                ADD COLUMN verified boolean DEFAULT true NOT NULL;
+            ALTER TABLE "issue#1".users
+                ALTER COLUMN verified SET DEFAULT false;
+        END IF;
+    END
+$$;
+
+--
+-- Name: totp_secrets; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".totp_secrets (
+                                                      username       character varying(24)    NOT NULL,
+                                                      secret         text                     NOT NULL,
+                                                      confirmed      boolean                  DEFAULT false NOT NULL,
+                                                      last_used_step bigint                   DEFAULT 0 NOT NULL,
+                                                      creation_time  timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                      CONSTRAINT totp_secrets_pk PRIMARY KEY (username),
+                                                      CONSTRAINT totp_secrets_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE "issue#1".totp_secrets OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".totp_secrets TO "issue#1_REST";
+
+--
+-- Name: totp_recovery_codes; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".totp_recovery_codes (
+                                                             username  character varying(24) NOT NULL,
+                                                             code_hash text                  NOT NULL,
+                                                             used      boolean               DEFAULT false NOT NULL,
+                                                             CONSTRAINT totp_recovery_codes_pk PRIMARY KEY (username, code_hash),
+                                                             CONSTRAINT totp_recovery_codes_totp_secrets_username_fk FOREIGN KEY (username) REFERENCES "issue#1".totp_secrets (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE "issue#1".totp_recovery_codes OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".totp_recovery_codes TO "issue#1_REST";
+
+--
+-- Name: personal_access_tokens; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".personal_access_tokens (
+                                                             id             character varying(32)    NOT NULL,
+                                                             token_hash     text                     NOT NULL,
+                                                             username       character varying(24)    NOT NULL,
+                                                             name           character varying(64)    NOT NULL,
+                                                             scopes         text[]                   NOT NULL,
+                                                             creation_time  timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                             expires_at     timestamp with time zone,
+                                                             last_used_time timestamp with time zone,
+                                                             revoked        boolean                  DEFAULT false NOT NULL,
+                                                             CONSTRAINT personal_access_tokens_pk PRIMARY KEY (id),
+                                                             CONSTRAINT personal_access_tokens_token_hash_uindex UNIQUE (token_hash),
+                                                             CONSTRAINT personal_access_tokens_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE "issue#1".personal_access_tokens OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".personal_access_tokens TO "issue#1_REST";
+
+--
+-- Name: sessions; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".sessions (
+                                               id             text                     NOT NULL,
+                                               username       character varying(24)    NOT NULL,
+                                               user_agent     text,
+                                               ip_address     character varying(45),
+                                               creation_time  timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                               last_used_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                               expires_at     timestamp with time zone NOT NULL,
+                                               revoked        boolean                  DEFAULT false NOT NULL,
+                                               CONSTRAINT sessions_pk PRIMARY KEY (id),
+                                               CONSTRAINT sessions_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE "issue#1".sessions OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".sessions TO "issue#1_REST";
+
+ALTER TABLE "issue#1".sessions
+    ADD COLUMN IF NOT EXISTS client_id text;
+
+ALTER TABLE "issue#1".sessions
+    ADD COLUMN IF NOT EXISTS scopes text[] DEFAULT '{*}' NOT NULL;
+
+--
+-- Name: login_attempts; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".login_attempts (
+                                                     key          text                     NOT NULL,
+                                                     failures     integer                  DEFAULT 0 NOT NULL,
+                                                     last_failure timestamp with time zone NOT NULL,
+                                                     locked_until timestamp with time zone,
+                                                     CONSTRAINT login_attempts_pk PRIMARY KEY (key)
+);
+
+ALTER TABLE "issue#1".login_attempts OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".login_attempts TO "issue#1_REST";
+
+--
+-- Name: login_lockout_events; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".login_lockout_events (
+                                                           id            serial                   NOT NULL,
+                                                           kind          character varying(16)    NOT NULL,
+                                                           key           text                     NOT NULL,
+                                                           failures      integer                  NOT NULL,
+                                                           locked_until  timestamp with time zone NOT NULL,
+                                                           creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                           CONSTRAINT login_lockout_events_pk PRIMARY KEY (id)
+);
+
+ALTER TABLE "issue#1".login_lockout_events OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".login_lockout_events TO "issue#1_REST";
+
+GRANT ALL ON SEQUENCE "issue#1".login_lockout_events_id_seq TO "issue#1_REST";
@@ -0,0 +1,8 @@
+--
+-- Drops the tables used by the oauth service.
+--
+
+DROP TABLE IF EXISTS
+    "issue#1".oauth_authorization_codes,
+    "issue#1".oauth_consents,
+    "issue#1".oauth_clients;
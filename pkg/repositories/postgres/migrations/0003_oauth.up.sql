@@ -0,0 +1,69 @@
+--
+-- Tables used by the oauth service.
+-- Statements are idempotent since they used to be applied by hand to existing databases.
+--
+
+--
+-- Name: oauth_clients; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".oauth_clients (
+                                                    id             character varying(32)    NOT NULL,
+                                                    secret_hash    text,
+                                                    name           character varying(64)    NOT NULL,
+                                                    redirect_uris  text[]                   NOT NULL,
+                                                    confidential   boolean                  DEFAULT false NOT NULL,
+                                                    owner_username character varying(24)    NOT NULL,
+                                                    creation_time  timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                    CONSTRAINT oauth_clients_pk PRIMARY KEY (id),
+                                                    CONSTRAINT oauth_clients_users_username_fk FOREIGN KEY (owner_username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS oauth_clients_owner_username_index ON "issue#1".oauth_clients USING btree (owner_username);
+
+ALTER TABLE "issue#1".oauth_clients OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".oauth_clients TO "issue#1_REST";
+
+--
+-- Name: oauth_consents; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".oauth_consents (
+                                                     username      character varying(24)    NOT NULL,
+                                                     client_id     character varying(32)    NOT NULL,
+                                                     scopes        text[]                   NOT NULL,
+                                                     creation_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                     CONSTRAINT oauth_consents_pk PRIMARY KEY (username, client_id),
+                                                     CONSTRAINT oauth_consents_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+                                                     CONSTRAINT oauth_consents_oauth_clients_id_fk FOREIGN KEY (client_id) REFERENCES "issue#1".oauth_clients (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE "issue#1".oauth_consents OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".oauth_consents TO "issue#1_REST";
+
+--
+-- Name: oauth_authorization_codes; Type: TABLE; Schema: issue#1; Owner: issue#1_dev
+--
+
+CREATE TABLE IF NOT EXISTS "issue#1".oauth_authorization_codes (
+                                                                code_hash             text                     NOT NULL,
+                                                                client_id             character varying(32)    NOT NULL,
+                                                                username              character varying(24)    NOT NULL,
+                                                                redirect_uri          text                     NOT NULL,
+                                                                scopes                text[]                   NOT NULL,
+                                                                code_challenge        text                     NOT NULL,
+                                                                code_challenge_method character varying(8)     NOT NULL,
+                                                                nonce                 text,
+                                                                used                  boolean                  DEFAULT false NOT NULL,
+                                                                creation_time         timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+                                                                expires_at            timestamp with time zone NOT NULL,
+                                                                CONSTRAINT oauth_authorization_codes_pk PRIMARY KEY (code_hash),
+                                                                CONSTRAINT oauth_authorization_codes_users_username_fk FOREIGN KEY (username) REFERENCES "issue#1".users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+                                                                CONSTRAINT oauth_authorization_codes_oauth_clients_id_fk FOREIGN KEY (client_id) REFERENCES "issue#1".oauth_clients (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+ALTER TABLE "issue#1".oauth_authorization_codes OWNER TO "issue#1_dev";
+
+GRANT ALL ON TABLE "issue#1".oauth_authorization_codes TO "issue#1_REST";
@@ -0,0 +1,49 @@
+--
+-- Drops the cache invalidation triggers.
+--
+
+DROP TRIGGER IF EXISTS users_user_cache_invalidation ON "issue#1".users;
+DROP TRIGGER IF EXISTS users_user_cache_invalidation_truncate ON "issue#1".users;
+DROP TRIGGER IF EXISTS users_bio_user_cache_invalidation ON "issue#1".users_bio;
+DROP TRIGGER IF EXISTS users_bio_user_cache_invalidation_truncate ON "issue#1".users_bio;
+DROP TRIGGER IF EXISTS user_avatars_user_cache_invalidation ON "issue#1".user_avatars;
+DROP TRIGGER IF EXISTS user_avatars_user_cache_invalidation_truncate ON "issue#1".user_avatars;
+DROP TRIGGER IF EXISTS user_bookmarks_user_cache_invalidation ON "issue#1".user_bookmarks;
+DROP TRIGGER IF EXISTS user_bookmarks_user_cache_invalidation_truncate ON "issue#1".user_bookmarks;
+DROP TRIGGER IF EXISTS channels_channel_cache_invalidation ON "issue#1".channels;
+DROP TRIGGER IF EXISTS channels_channel_cache_invalidation_truncate ON "issue#1".channels;
+DROP TRIGGER IF EXISTS channel_admins_channel_cache_invalidation ON "issue#1".channel_admins;
+DROP TRIGGER IF EXISTS channel_admins_channel_cache_invalidation_truncate ON "issue#1".channel_admins;
+DROP TRIGGER IF EXISTS channel_official_catalog_channel_cache_invalidation ON "issue#1".channel_official_catalog;
+DROP TRIGGER IF EXISTS channel_official_catalog_channel_cache_invalidation_truncate ON "issue#1".channel_official_catalog;
+DROP TRIGGER IF EXISTS channel_pictures_channel_cache_invalidation ON "issue#1".channel_pictures;
+DROP TRIGGER IF EXISTS channel_pictures_channel_cache_invalidation_truncate ON "issue#1".channel_pictures;
+DROP TRIGGER IF EXISTS releases_channel_cache_invalidation ON "issue#1".releases;
+DROP TRIGGER IF EXISTS releases_channel_cache_invalidation_truncate ON "issue#1".releases;
+DROP TRIGGER IF EXISTS posts_channel_cache_invalidation ON "issue#1".posts;
+DROP TRIGGER IF EXISTS posts_channel_cache_invalidation_truncate ON "issue#1".posts;
+DROP TRIGGER IF EXISTS feeds_feed_cache_invalidation ON "issue#1".feeds;
+DROP TRIGGER IF EXISTS feeds_feed_cache_invalidation_truncate ON "issue#1".feeds;
+DROP TRIGGER IF EXISTS releases_release_cache_invalidation ON "issue#1".releases;
+DROP TRIGGER IF EXISTS releases_release_cache_invalidation_truncate ON "issue#1".releases;
+DROP TRIGGER IF EXISTS releases_image_based_release_cache_invalidation ON "issue#1".releases_image_based;
+DROP TRIGGER IF EXISTS releases_image_based_release_cache_invalidation_truncate ON "issue#1".releases_image_based;
+DROP TRIGGER IF EXISTS releases_text_based_release_cache_invalidation ON "issue#1".releases_text_based;
+DROP TRIGGER IF EXISTS releases_text_based_release_cache_invalidation_truncate ON "issue#1".releases_text_based;
+DROP TRIGGER IF EXISTS release_metadata_release_cache_invalidation ON "issue#1".release_metadata;
+DROP TRIGGER IF EXISTS release_metadata_release_cache_invalidation_truncate ON "issue#1".release_metadata;
+DROP TRIGGER IF EXISTS posts_post_cache_invalidation ON "issue#1".posts;
+DROP TRIGGER IF EXISTS posts_post_cache_invalidation_truncate ON "issue#1".posts;
+DROP TRIGGER IF EXISTS post_contents_post_cache_invalidation ON "issue#1".post_contents;
+DROP TRIGGER IF EXISTS post_contents_post_cache_invalidation_truncate ON "issue#1".post_contents;
+DROP TRIGGER IF EXISTS post_stars_post_cache_invalidation ON "issue#1".post_stars;
+DROP TRIGGER IF EXISTS post_stars_post_cache_invalidation_truncate ON "issue#1".post_stars;
+DROP TRIGGER IF EXISTS comments_post_cache_invalidation ON "issue#1".comments;
+DROP TRIGGER IF EXISTS comments_post_cache_invalidation_truncate ON "issue#1".comments;
+DROP TRIGGER IF EXISTS comments_comment_cache_invalidation ON "issue#1".comments;
+DROP TRIGGER IF EXISTS comments_comment_cache_invalidation_truncate ON "issue#1".comments;
+DROP TRIGGER IF EXISTS channel_stickies_channel_cache_invalidation ON "issue#1".channel_stickies;
+DROP TRIGGER IF EXISTS channel_stickies_channel_cache_invalidation_truncate ON "issue#1".channel_stickies;
+
+DROP FUNCTION IF EXISTS "issue#1".notify_channel_stickies_invalidation();
+DROP FUNCTION IF EXISTS "issue#1".notify_cache_invalidation();
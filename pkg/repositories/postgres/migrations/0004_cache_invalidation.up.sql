@@ -0,0 +1,444 @@
+--
+-- Triggers that notify the instances of the server when rows their caches are
+-- built from change so that they can evict the stale entries.
+-- Payloads are JSON objects of the form {"entity": "user", "key": "abebe"} sent
+-- on the cache_invalidation channel. The key is null when a table is truncated,
+-- every entry of the entity is stale then.
+--
+
+CREATE OR REPLACE FUNCTION "issue#1".notify_cache_invalidation() RETURNS trigger
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    entity     text := TG_ARGV[0];
+    key_column text := TG_ARGV[1];
+BEGIN
+    IF TG_OP = 'TRUNCATE' THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object('entity', entity, 'key', NULL)::text);
+        RETURN NULL;
+    END IF;
+    -- both keys are sent on update since the key itself might've changed
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object('entity', entity, 'key', to_jsonb(OLD) ->> key_column)::text);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object('entity', entity, 'key', to_jsonb(NEW) ->> key_column)::text);
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+ALTER FUNCTION "issue#1".notify_cache_invalidation() OWNER TO "issue#1_dev";
+
+--
+-- Stickied posts only reference the post, the channel is found through it.
+-- Nothing is sent if the post is already gone, deleting it notified the channel.
+--
+
+CREATE OR REPLACE FUNCTION "issue#1".notify_channel_stickies_invalidation() RETURNS trigger
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    channel text;
+BEGIN
+    IF TG_OP = 'TRUNCATE' THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object('entity', 'channel', 'key', NULL)::text);
+        RETURN NULL;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        SELECT channel_from INTO channel FROM "issue#1".posts WHERE id = OLD.post_id;
+        IF channel IS NOT NULL THEN
+            PERFORM pg_notify('cache_invalidation', json_build_object('entity', 'channel', 'key', channel)::text);
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        SELECT channel_from INTO channel FROM "issue#1".posts WHERE id = NEW.post_id;
+        IF channel IS NOT NULL THEN
+            PERFORM pg_notify('cache_invalidation', json_build_object('entity', 'channel', 'key', channel)::text);
+        END IF;
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+ALTER FUNCTION "issue#1".notify_channel_stickies_invalidation() OWNER TO "issue#1_dev";
+
+--
+-- Name: users users_user_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS users_user_cache_invalidation ON "issue#1".users;
+CREATE TRIGGER users_user_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".users
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+DROP TRIGGER IF EXISTS users_user_cache_invalidation_truncate ON "issue#1".users;
+CREATE TRIGGER users_user_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".users
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+--
+-- Name: users_bio users_bio_user_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS users_bio_user_cache_invalidation ON "issue#1".users_bio;
+CREATE TRIGGER users_bio_user_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".users_bio
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+DROP TRIGGER IF EXISTS users_bio_user_cache_invalidation_truncate ON "issue#1".users_bio;
+CREATE TRIGGER users_bio_user_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".users_bio
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+--
+-- Name: user_avatars user_avatars_user_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS user_avatars_user_cache_invalidation ON "issue#1".user_avatars;
+CREATE TRIGGER user_avatars_user_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".user_avatars
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+DROP TRIGGER IF EXISTS user_avatars_user_cache_invalidation_truncate ON "issue#1".user_avatars;
+CREATE TRIGGER user_avatars_user_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".user_avatars
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+--
+-- Name: user_bookmarks user_bookmarks_user_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS user_bookmarks_user_cache_invalidation ON "issue#1".user_bookmarks;
+CREATE TRIGGER user_bookmarks_user_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".user_bookmarks
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+DROP TRIGGER IF EXISTS user_bookmarks_user_cache_invalidation_truncate ON "issue#1".user_bookmarks;
+CREATE TRIGGER user_bookmarks_user_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".user_bookmarks
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('user', 'username');
+
+--
+-- Name: channels channels_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS channels_channel_cache_invalidation ON "issue#1".channels;
+CREATE TRIGGER channels_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".channels
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'username');
+
+DROP TRIGGER IF EXISTS channels_channel_cache_invalidation_truncate ON "issue#1".channels;
+CREATE TRIGGER channels_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".channels
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'username');
+
+--
+-- Name: channel_admins channel_admins_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS channel_admins_channel_cache_invalidation ON "issue#1".channel_admins;
+CREATE TRIGGER channel_admins_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".channel_admins
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channel_username');
+
+DROP TRIGGER IF EXISTS channel_admins_channel_cache_invalidation_truncate ON "issue#1".channel_admins;
+CREATE TRIGGER channel_admins_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".channel_admins
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channel_username');
+
+--
+-- Name: channel_official_catalog channel_official_catalog_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS channel_official_catalog_channel_cache_invalidation ON "issue#1".channel_official_catalog;
+CREATE TRIGGER channel_official_catalog_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".channel_official_catalog
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channel_username');
+
+DROP TRIGGER IF EXISTS channel_official_catalog_channel_cache_invalidation_truncate ON "issue#1".channel_official_catalog;
+CREATE TRIGGER channel_official_catalog_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".channel_official_catalog
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channel_username');
+
+--
+-- Name: channel_pictures channel_pictures_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS channel_pictures_channel_cache_invalidation ON "issue#1".channel_pictures;
+CREATE TRIGGER channel_pictures_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".channel_pictures
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channelname');
+
+DROP TRIGGER IF EXISTS channel_pictures_channel_cache_invalidation_truncate ON "issue#1".channel_pictures;
+CREATE TRIGGER channel_pictures_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".channel_pictures
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channelname');
+
+--
+-- Name: releases releases_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS releases_channel_cache_invalidation ON "issue#1".releases;
+CREATE TRIGGER releases_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".releases
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'owner_channel');
+
+DROP TRIGGER IF EXISTS releases_channel_cache_invalidation_truncate ON "issue#1".releases;
+CREATE TRIGGER releases_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".releases
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'owner_channel');
+
+--
+-- Name: posts posts_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS posts_channel_cache_invalidation ON "issue#1".posts;
+CREATE TRIGGER posts_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".posts
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channel_from');
+
+DROP TRIGGER IF EXISTS posts_channel_cache_invalidation_truncate ON "issue#1".posts;
+CREATE TRIGGER posts_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".posts
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('channel', 'channel_from');
+
+--
+-- Name: feeds feeds_feed_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS feeds_feed_cache_invalidation ON "issue#1".feeds;
+CREATE TRIGGER feeds_feed_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".feeds
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('feed', 'owner_username');
+
+DROP TRIGGER IF EXISTS feeds_feed_cache_invalidation_truncate ON "issue#1".feeds;
+CREATE TRIGGER feeds_feed_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".feeds
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('feed', 'owner_username');
+
+--
+-- Name: releases releases_release_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS releases_release_cache_invalidation ON "issue#1".releases;
+CREATE TRIGGER releases_release_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".releases
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'id');
+
+DROP TRIGGER IF EXISTS releases_release_cache_invalidation_truncate ON "issue#1".releases;
+CREATE TRIGGER releases_release_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".releases
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'id');
+
+--
+-- Name: releases_image_based releases_image_based_release_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS releases_image_based_release_cache_invalidation ON "issue#1".releases_image_based;
+CREATE TRIGGER releases_image_based_release_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".releases_image_based
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'release_id');
+
+DROP TRIGGER IF EXISTS releases_image_based_release_cache_invalidation_truncate ON "issue#1".releases_image_based;
+CREATE TRIGGER releases_image_based_release_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".releases_image_based
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'release_id');
+
+--
+-- Name: releases_text_based releases_text_based_release_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS releases_text_based_release_cache_invalidation ON "issue#1".releases_text_based;
+CREATE TRIGGER releases_text_based_release_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".releases_text_based
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'release_id');
+
+DROP TRIGGER IF EXISTS releases_text_based_release_cache_invalidation_truncate ON "issue#1".releases_text_based;
+CREATE TRIGGER releases_text_based_release_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".releases_text_based
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'release_id');
+
+--
+-- Name: release_metadata release_metadata_release_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS release_metadata_release_cache_invalidation ON "issue#1".release_metadata;
+CREATE TRIGGER release_metadata_release_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".release_metadata
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'release_id');
+
+DROP TRIGGER IF EXISTS release_metadata_release_cache_invalidation_truncate ON "issue#1".release_metadata;
+CREATE TRIGGER release_metadata_release_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".release_metadata
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('release', 'release_id');
+
+--
+-- Name: posts posts_post_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS posts_post_cache_invalidation ON "issue#1".posts;
+CREATE TRIGGER posts_post_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".posts
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'id');
+
+DROP TRIGGER IF EXISTS posts_post_cache_invalidation_truncate ON "issue#1".posts;
+CREATE TRIGGER posts_post_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".posts
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'id');
+
+--
+-- Name: post_contents post_contents_post_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS post_contents_post_cache_invalidation ON "issue#1".post_contents;
+CREATE TRIGGER post_contents_post_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".post_contents
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'post_id');
+
+DROP TRIGGER IF EXISTS post_contents_post_cache_invalidation_truncate ON "issue#1".post_contents;
+CREATE TRIGGER post_contents_post_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".post_contents
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'post_id');
+
+--
+-- Name: post_stars post_stars_post_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS post_stars_post_cache_invalidation ON "issue#1".post_stars;
+CREATE TRIGGER post_stars_post_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".post_stars
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'post_id');
+
+DROP TRIGGER IF EXISTS post_stars_post_cache_invalidation_truncate ON "issue#1".post_stars;
+CREATE TRIGGER post_stars_post_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".post_stars
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'post_id');
+
+--
+-- Name: comments comments_post_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS comments_post_cache_invalidation ON "issue#1".comments;
+CREATE TRIGGER comments_post_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".comments
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'post_from');
+
+DROP TRIGGER IF EXISTS comments_post_cache_invalidation_truncate ON "issue#1".comments;
+CREATE TRIGGER comments_post_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".comments
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('post', 'post_from');
+
+--
+-- Name: comments comments_comment_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS comments_comment_cache_invalidation ON "issue#1".comments;
+CREATE TRIGGER comments_comment_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".comments
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('comment', 'id');
+
+DROP TRIGGER IF EXISTS comments_comment_cache_invalidation_truncate ON "issue#1".comments;
+CREATE TRIGGER comments_comment_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".comments
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_cache_invalidation('comment', 'id');
+
+--
+-- Name: channel_stickies channel_stickies_channel_cache_invalidation; Type: TRIGGER; Schema: issue#1; Owner: issue#1_dev
+--
+
+DROP TRIGGER IF EXISTS channel_stickies_channel_cache_invalidation ON "issue#1".channel_stickies;
+CREATE TRIGGER channel_stickies_channel_cache_invalidation
+    AFTER INSERT OR UPDATE OR DELETE
+    ON "issue#1".channel_stickies
+    FOR EACH ROW
+EXECUTE PROCEDURE "issue#1".notify_channel_stickies_invalidation();
+
+DROP TRIGGER IF EXISTS channel_stickies_channel_cache_invalidation_truncate ON "issue#1".channel_stickies;
+CREATE TRIGGER channel_stickies_channel_cache_invalidation_truncate
+    AFTER TRUNCATE
+    ON "issue#1".channel_stickies
+    FOR EACH STATEMENT
+EXECUTE PROCEDURE "issue#1".notify_channel_stickies_invalidation();
@@ -0,0 +1,286 @@
+/*
+Package migrations contains the versioned schema of the PostgreSQL database and
+the logic to apply it. Migrations are embedded in the binary as pairs of
+NNNN_name.up.sql and NNNN_name.down.sql files and the versions applied are
+recorded in the schema_migrations table.
+*/
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey is the key of the advisory lock held while migrating so that
+// instances started together don't apply the same migration twice.
+const lockKey = 7204
+
+// Migration is a single versioned change to the schema.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied and when.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt"`
+}
+
+// ErrNoDownMigration is returned when rolling back a migration that can't be rolled back.
+var ErrNoDownMigration = fmt.Errorf("migration has no down script")
+
+// Migrator applies the embedded migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	logger     *log.Logger
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for the given database.
+func NewMigrator(db *sql.DB, logger *log.Logger) (*Migrator, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, logger: logger, migrations: migrations}, nil
+}
+
+// Up applies all the migrations that haven't been applied yet, in order.
+// Each migration is applied in its own transaction along with its record so
+// that a failing migration leaves the schema as it was before it.
+// It returns the number of migrations applied.
+func (m *Migrator) Up() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, migration := range m.migrations {
+		done, err := m.inTransaction(func(tx *sql.Tx) (bool, error) {
+			if ok, err := isApplied(tx, migration.Version); err != nil || ok {
+				return false, err
+			}
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return false, err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+				migration.Version, migration.Name, time.Now())
+			return err == nil, err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("applying migration %04d_%s failed because of: %w", migration.Version, migration.Name, err)
+		}
+		if done {
+			applied++
+			m.logger.Printf("applied migration %04d_%s", migration.Version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+// Down rolls back the given number of the most recently applied migrations.
+// It returns the number of migrations rolled back.
+func (m *Migrator) Down(steps int) (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+	rolledBack := 0
+	for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+		migration := m.migrations[i]
+		done, err := m.inTransaction(func(tx *sql.Tx) (bool, error) {
+			if ok, err := isApplied(tx, migration.Version); err != nil || !ok {
+				return false, err
+			}
+			if migration.Down == "" {
+				return false, ErrNoDownMigration
+			}
+			if _, err := tx.Exec(migration.Down); err != nil {
+				return false, err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			return err == nil, err
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rolling back migration %04d_%s failed because of: %w", migration.Version, migration.Name, err)
+		}
+		if done {
+			rolledBack++
+			m.logger.Printf("rolled back migration %04d_%s", migration.Version, migration.Name)
+		}
+	}
+	return rolledBack, nil
+}
+
+// Status returns the state of each of the migrations, in order.
+func (m *Migrator) Status() ([]*Status, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("querying for schema_migrations failed because of: %w", err)
+	}
+	defer rows.Close()
+	appliedAt := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var t time.Time
+		if err := rows.Scan(&version, &t); err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %w", err)
+		}
+		appliedAt[version] = t
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %w", err)
+	}
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &Status{Version: migration.Version, Name: migration.Name}
+		if t, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ensureTable is a helper function that creates the schema_migrations table if
+// it doesn't exist. Databases set up by the scripts before migrations existed
+// already hold the initial schema, it's recorded as applied for them. The later
+// migrations are idempotent so they can be applied on top.
+func (m *Migrator) ensureTable() error {
+	_, err := m.inTransaction(func(tx *sql.Tx) (bool, error) {
+		var exists bool
+		err := tx.QueryRow(`SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+		if err != nil || exists {
+			return false, err
+		}
+		_, err = tx.Exec(`CREATE TABLE schema_migrations (
+							version    integer                  NOT NULL,
+							name       text                     NOT NULL,
+							applied_at timestamp with time zone NOT NULL,
+							CONSTRAINT schema_migrations_pk PRIMARY KEY (version)
+						)`)
+		if err != nil {
+			return false, err
+		}
+		var hasSchema bool
+		err = tx.QueryRow(`SELECT to_regclass('users') IS NOT NULL`).Scan(&hasSchema)
+		if err != nil || !hasSchema || len(m.migrations) == 0 {
+			return false, err
+		}
+		initial := m.migrations[0]
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+			initial.Version, initial.Name, time.Now())
+		if err == nil {
+			m.logger.Printf("existing schema found, recorded migration %04d_%s as applied", initial.Version, initial.Name)
+		}
+		return true, err
+	})
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations failed because of: %w", err)
+	}
+	return nil
+}
+
+// inTransaction is a helper function that runs fn in a transaction holding the
+// migration lock, committing it if fn succeeds.
+func (m *Migrator) inTransaction(fn func(tx *sql.Tx) (bool, error)) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, lockKey); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	done, err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return done, tx.Commit()
+}
+
+// isApplied is a helper function that checks whether the migration of the given version has been applied.
+func isApplied(tx *sql.Tx, version int) (bool, error) {
+	var applied bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied)
+	return applied, err
+}
+
+// load is a helper function that reads the embedded migrations, ordered by version.
+func load() ([]Migration, error) {
+	return Read(files)
+}
+
+// Read reads the migrations held by the NNNN_name.up.sql and NNNN_name.down.sql
+// files at the root of the given file system, ordered by version.
+func Read(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations failed because: %w", err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration file %s isn't named NNNN_name.up.sql or NNNN_name.down.sql", name)
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		separator := strings.Index(base, "_")
+		if separator < 1 {
+			return nil, fmt.Errorf("migration file %s has no version", name)
+		}
+		version, err := strconv.Atoi(base[:separator])
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s has an invalid version", name)
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration file %s failed because: %w", name, err)
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: base[separator+1:]}
+			byVersion[version] = migration
+		} else if migration.Name != base[separator+1:] {
+			return nil, fmt.Errorf("migration files of version %d have different names", version)
+		}
+		if direction == "up" {
+			migration.Up = string(b)
+		} else {
+			migration.Down = string(b)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/oauth"
+)
+
+type oAuthRepository repository
+
+// NewOAuthRepository returns a struct that implements the oauth.Repository using
+// a PostgresSQL database.
+// A database connection needs to be passed so that it can function.
+func NewOAuthRepository(DB *sql.DB, allRepos *map[string]interface{}) oauth.Repository {
+	return &oAuthRepository{&database{DB}, allRepos}
+}
+
+// AddClient persists the given client record.
+func (repo *oAuthRepository) AddClient(ctx context.Context, c *oauth.Client) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO oauth_clients (id, secret_hash, name, redirect_uris, confidential, owner_username, creation_time)
+							VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)`,
+		c.ID, c.SecretHash, c.Name, pq.Array(c.RedirectURIs), c.Confidential, c.OwnerUsername, c.CreationTime)
+	if err != nil {
+		return fmt.Errorf("insertion into oauth_clients failed because of: %w", err)
+	}
+	return nil
+}
+
+// GetClient retrieves the client record with the given id.
+func (repo *oAuthRepository) GetClient(ctx context.Context, id string) (*oauth.Client, error) {
+	row := repo.db.QueryRowContext(ctx, `SELECT id, COALESCE(secret_hash, ''), name, redirect_uris, confidential, owner_username, creation_time
+							FROM oauth_clients
+							WHERE id = $1`, id)
+	c, err := scanClient(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, oauth.ErrClientNotFound
+		}
+		return nil, fmt.Errorf("unable to get client because of: %w", err)
+	}
+	return c, nil
+}
+
+// GetClients retrieves the records of the clients registered by the user, newest first.
+func (repo *oAuthRepository) GetClients(ctx context.Context, ownerUsername string) ([]*oauth.Client, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT id, COALESCE(secret_hash, ''), name, redirect_uris, confidential, owner_username, creation_time
+							FROM oauth_clients
+							WHERE owner_username = $1
+							ORDER BY creation_time DESC`, ownerUsername)
+	if err != nil {
+		return nil, fmt.Errorf("querying for oauth_clients failed because of: %w", err)
+	}
+	defer rows.Close()
+	clients := make([]*oauth.Client, 0)
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %w", err)
+		}
+		clients = append(clients, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %w", err)
+	}
+	return clients, nil
+}
+
+// scanClient is a helper function that scans a client record out of a row
+// holding the columns selected by GetClient.
+func scanClient(row interface{ Scan(...interface{}) error }) (*oauth.Client, error) {
+	c := new(oauth.Client)
+	err := row.Scan(&c.ID, &c.SecretHash, &c.Name, pq.Array(&c.RedirectURIs), &c.Confidential, &c.OwnerUsername, &c.CreationTime)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteClient removes the client of the user with the given id.
+// Consents and codes issued to the client go with it.
+func (repo *oAuthRepository) DeleteClient(ctx context.Context, ownerUsername, id string) error {
+	result, err := repo.db.ExecContext(ctx, `DELETE FROM oauth_clients
+							WHERE owner_username = $1 AND id = $2`, ownerUsername, id)
+	if err != nil {
+		return fmt.Errorf("deletion of client failed because of: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return oauth.ErrClientNotFound
+	}
+	return nil
+}
+
+// GetConsent retrieves the record of the scopes the user has allowed the client.
+func (repo *oAuthRepository) GetConsent(ctx context.Context, username, clientID string) (*oauth.Consent, error) {
+	consent := new(oauth.Consent)
+	err := repo.db.QueryRowContext(ctx, `SELECT username, client_id, scopes, creation_time
+							FROM oauth_consents
+							WHERE username = $1 AND client_id = $2`, username, clientID).
+		Scan(&consent.Username, &consent.ClientID, pq.Array(&consent.Scopes), &consent.CreationTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, oauth.ErrConsentNotFound
+		}
+		return nil, fmt.Errorf("unable to get consent because of: %w", err)
+	}
+	return consent, nil
+}
+
+// SetConsent persists the given consent record, replacing the scopes of any
+// previous consent of the user to the client.
+func (repo *oAuthRepository) SetConsent(ctx context.Context, c *oauth.Consent) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO oauth_consents (username, client_id, scopes, creation_time)
+							VALUES ($1, $2, $3, $4)
+							ON CONFLICT (username, client_id) DO UPDATE
+							SET scopes = EXCLUDED.scopes`,
+		c.Username, c.ClientID, pq.Array(c.Scopes), c.CreationTime)
+	if err != nil {
+		return fmt.Errorf("upsertion into oauth_consents failed because of: %w", err)
+	}
+	return nil
+}
+
+// AddAuthorizationCode persists the given authorization code record.
+// Codes that have expired are pruned on the way.
+func (repo *oAuthRepository) AddAuthorizationCode(ctx context.Context, code *oauth.AuthorizationCode) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO oauth_authorization_codes (code_hash, client_id, username, redirect_uri, scopes, code_challenge, code_challenge_method, nonce, creation_time, expires_at)
+							VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10)`,
+		code.CodeHash, code.ClientID, code.Username, code.RedirectURI, pq.Array(code.Scopes),
+		code.CodeChallenge, code.CodeChallengeMethod, code.Nonce, code.CreationTime, code.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("insertion into oauth_authorization_codes failed because of: %w", err)
+	}
+	_, err = repo.db.ExecContext(ctx, `DELETE FROM oauth_authorization_codes
+							WHERE expires_at <= $1`, time.Now())
+	if err != nil {
+		return fmt.Errorf("pruning of oauth_authorization_codes failed because of: %w", err)
+	}
+	return nil
+}
+
+// ConsumeAuthorizationCode marks the authorization code stored under the given hash
+// as used and returns its record. The check and the update happen in one statement
+// so that a code can't be redeemed twice by concurrent requests.
+func (repo *oAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*oauth.AuthorizationCode, error) {
+	code := new(oauth.AuthorizationCode)
+	err := repo.db.QueryRowContext(ctx, `UPDATE oauth_authorization_codes
+							SET used = true
+							WHERE code_hash = $1 AND used = false
+							RETURNING code_hash, client_id, username, redirect_uri, scopes, code_challenge, code_challenge_method, COALESCE(nonce, ''), used, creation_time, expires_at`, codeHash).
+		Scan(&code.CodeHash, &code.ClientID, &code.Username, &code.RedirectURI, pq.Array(&code.Scopes),
+			&code.CodeChallenge, &code.CodeChallengeMethod, &code.Nonce, &code.Used, &code.CreationTime, &code.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, oauth.ErrInvalidGrant
+		}
+		return nil, fmt.Errorf("consuming of authorization code failed because of: %w", err)
+	}
+	return code, nil
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -12,15 +13,15 @@ type postRepository repository
 // NewPostRepository returns a struct that implements the release.Repository using
 //a postgres database
 func NewPostRepository(DB *sql.DB, allRepos *map[string]interface{}) post.Repository {
-	return &postRepository{DB, allRepos}
+	return &postRepository{&database{DB}, allRepos}
 }
 
 // GetPost gets the Post stored under the given id.
-func (repo *postRepository) GetPost(id uint) (*post.Post, error) {
+func (repo *postRepository) GetPost(ctx context.Context, id uint) (*post.Post, error) {
 	var err error
 	var p = new(post.Post)
 
-	err = repo.db.QueryRow(`
+	err = repo.db.QueryRowContext(ctx, `
 								SELECT COALESCE(posted_by, ''), COALESCE(channel_from, ''), COALESCE(title, ''), COALESCE(description, ''),creation_time
 								FROM "issue#1".posts
 								WHERE posts.id = $1`, id).Scan(&p.PostedByUsername, &p.OriginChannel, &p.Title, &p.Description, &p.CreationTime)
@@ -28,15 +29,15 @@ func (repo *postRepository) GetPost(id uint) (*post.Post, error) {
 		//checkErr(err)
 		return nil, post.ErrPostNotFound
 	}
-	ContentList, errr := repo.getContents(id)
+	ContentList, errr := repo.getContents(ctx, id)
 	if errr != nil {
 		return nil, errr
 	}
-	StarList, e := repo.getStars(id)
+	StarList, e := repo.getStars(ctx, id)
 	if e != nil {
 		return nil, e
 	}
-	CommentList, d := repo.getComments(id)
+	CommentList, d := repo.getComments(ctx, id)
 	if d != nil {
 		return nil, fmt.Errorf("Comments Not found because of: %v", d)
 	}
@@ -49,14 +50,14 @@ func (repo *postRepository) GetPost(id uint) (*post.Post, error) {
 	return p, nil
 
 }
-func (repo *postRepository) getContents(id uint) ([]uint, error) {
+func (repo *postRepository) getContents(ctx context.Context, id uint) ([]uint, error) {
 
 	var ContentList = []uint{}
 	var (
 		releaseID uint
 	)
 
-	rows, err := repo.db.Query(`SELECT release_id
+	rows, err := repo.db.QueryContext(ctx, `SELECT release_id
 								FROM "issue#1".post_contents
 								WHERE post_id = $1`, id)
 	if err != nil {
@@ -79,14 +80,14 @@ func (repo *postRepository) getContents(id uint) ([]uint, error) {
 	return ContentList, nil
 }
 
-func (repo *postRepository) getComments(id uint) ([]int, error) {
+func (repo *postRepository) getComments(ctx context.Context, id uint) ([]int, error) {
 
 	var CommentList = []int{}
 	var (
 		commentID int
 	)
 
-	rows, err := repo.db.Query(`SELECT id
+	rows, err := repo.db.QueryContext(ctx, `SELECT id
 								FROM "issue#1".comments
 								WHERE post_from = $1`, id)
 	if err != nil {
@@ -107,7 +108,7 @@ func (repo *postRepository) getComments(id uint) ([]int, error) {
 	return CommentList, nil
 }
 
-func (repo *postRepository) getStars(id uint) (map[string]uint, error) {
+func (repo *postRepository) getStars(ctx context.Context, id uint) (map[string]uint, error) {
 	// TODO test this method
 	var StarList = make(map[string]uint, 0)
 	var (
@@ -115,7 +116,7 @@ func (repo *postRepository) getStars(id uint) (map[string]uint, error) {
 		starCount uint
 	)
 
-	rows, err := repo.db.Query(`SELECT username, star_count
+	rows, err := repo.db.QueryContext(ctx, `SELECT username, star_count
 								FROM "issue#1".post_stars 
 								WHERE post_id = $1`, id)
 	if err != nil {
@@ -138,8 +139,8 @@ func (repo *postRepository) getStars(id uint) (map[string]uint, error) {
 }
 
 // DeletePost Deletes the Post stored under the given id.
-func (repo *postRepository) DeletePost(id uint) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".posts
+func (repo *postRepository) DeletePost(ctx context.Context, id uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".posts
 							WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("deletion of post failed because of: %v", err)
@@ -147,93 +148,90 @@ func (repo *postRepository) DeletePost(id uint) error {
 	return nil
 }
 
-// AddPost Adds the Post stored under its id from given post struct.
-func (repo *postRepository) AddPost(p *post.Post) (*post.Post, error) {
-
-	query := `INSERT INTO "issue#1".posts (posted_by,channel_from, title,description) 
+// AddPost Adds the Post stored under its id from given post struct, all or nothing.
+func (repo *postRepository) AddPost(ctx context.Context, p *post.Post) (*post.Post, error) {
+	var added *post.Post
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		query := `INSERT INTO "issue#1".posts (posted_by,channel_from, title,description) 
 				VALUES ($1,$2,$3,$4)
 				RETURNING id`
-	errs := repo.db.QueryRow(query, p.PostedByUsername, p.OriginChannel, p.Title, p.Description).Scan(&p.ID)
-	if errs != nil {
-		//checkErr(errs)
-		return nil, post.ErrSomePostDataNotPersisted
+		errs := repo.db.QueryRowContext(ctx, query, p.PostedByUsername, p.OriginChannel, p.Title, p.Description).Scan(&p.ID)
+		if errs != nil {
+			return post.ErrSomePostDataNotPersisted
+		}
+		p.PostedByUsername = ""
+		p.OriginChannel = ""
+		p.Title = ""
+		p.Description = ""
+		var err error
+		added, err = repo.UpdatePost(ctx, p, p.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	p.PostedByUsername = ""
-	p.OriginChannel = ""
-	p.Title = ""
-	p.Description = ""
-	return repo.UpdatePost(p, p.ID)
-
+	return added, nil
 }
 
-//UpdatePost updates the post with given id and post struct
-func (repo *postRepository) UpdatePost(pos *post.Post, id uint) (*post.Post, error) {
-	var errs []error
-
-	if pos.PostedByUsername != "" {
-		err := repo.execUpdateStatementOnColumnIntoPost("posted_by", pos.PostedByUsername, id)
-		if err != nil {
-			errs = append(errs, err)
+//UpdatePost updates the post with given id and post struct, all or nothing.
+func (repo *postRepository) UpdatePost(ctx context.Context, pos *post.Post, id uint) (*post.Post, error) {
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		if pos.PostedByUsername != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "posted_by", pos.PostedByUsername, id)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if pos.OriginChannel != "" {
-		err := repo.execUpdateStatementOnColumnIntoPost("channel_from", pos.OriginChannel, id)
-		if err != nil {
-			errs = append(errs, err)
+		if pos.OriginChannel != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "channel_from", pos.OriginChannel, id)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if pos.Title != "" {
-		err := repo.execUpdateStatementOnColumnIntoPost("title", pos.Title, id)
-		if err != nil {
-			errs = append(errs, err)
+		if pos.Title != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "title", pos.Title, id)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if pos.Description != "" {
-		err := repo.execUpdateStatementOnColumnIntoPost("description", pos.Description, id)
-		if err != nil {
-			errs = append(errs, err)
+		if pos.Description != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "description", pos.Description, id)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if len(pos.ContentsID) != 0 {
-		err := repo.execUpdateStatementOnColumnIntoContents("release_id", pos.ContentsID, id)
-		if err != nil {
-			errs = append(errs, err)
+		if len(pos.ContentsID) != 0 {
+			return repo.execUpdateStatementOnColumnIntoContents(ctx, "release_id", pos.ContentsID, id)
 		}
-	}
-	const maxNoOfPossibleErr = 5
-	if len(errs) == maxNoOfPossibleErr {
-		return nil, fmt.Errorf("was unable to update any data because of %v", errs)
-	}
-
-	p, d := repo.GetPost(id)
-	if d == nil {
-		if len(errs) > 0 {
+		return nil
+	})
 
-			d = post.ErrSomePostDataNotPersisted
-		}
-	} else {
-		d = post.ErrPostNotFound
+	p, d := repo.GetPost(ctx, id)
+	if d != nil {
+		return nil, post.ErrPostNotFound
 	}
-	fmt.Printf("%+v", p)
-	return p, d
+	if err != nil {
+		return nil, post.ErrSomePostDataNotPersisted
+	}
+	return p, nil
 }
 
-func (repo *postRepository) execUpdateStatementOnColumnIntoPost(column string, value string, id uint) error {
+func (repo *postRepository) execUpdateStatementOnColumnIntoPost(ctx context.Context, column string, value string, id uint) error {
 	query := fmt.Sprintf(`UPDATE posts
 								SET %s = $1 
 								WHERE id = $2`, column)
-	_, err := repo.db.Exec(query, value, id)
+	_, err := repo.db.ExecContext(ctx, query, value, id)
 	if err != nil {
 		return fmt.Errorf("updating failed of %s column with %s because of: %v", column, value, err)
 	}
 	return nil
 }
-func (repo *postRepository) execUpdateStatementOnColumnIntoContents(column string, value []uint, id uint) error {
+func (repo *postRepository) execUpdateStatementOnColumnIntoContents(ctx context.Context, column string, value []uint, id uint) error {
 	query := fmt.Sprintf(`UPDATE post_contents
 								SET %s = $1 
 								WHERE post_id = $2`, column)
 	for _, v := range value {
-		_, err := repo.db.Exec(query, v, id)
+		_, err := repo.db.ExecContext(ctx, query, v, id)
 		if err != nil {
 			return fmt.Errorf("updating failed of %s column with %d because of: %v", column, v, err)
 		}
@@ -241,12 +239,12 @@ func (repo *postRepository) execUpdateStatementOnColumnIntoContents(column strin
 
 	return nil
 }
-func (repo *postRepository) execUpdateStatementOnColumnIntoStars(value map[string]int, id uint) error {
+func (repo *postRepository) execUpdateStatementOnColumnIntoStars(ctx context.Context, value map[string]int, id uint) error {
 	query := `UPDATE post_stars
 				SET username = $1, star_count=$2
 				WHERE post_id = $3`
 	for k, v := range value {
-		_, err := repo.db.Exec(query, k, v, id)
+		_, err := repo.db.ExecContext(ctx, query, k, v, id)
 		if err != nil {
 			return fmt.Errorf("updating failed of username-%s starcount-%d column of postid %d because of: %v", k, v, id, err)
 		}
@@ -256,7 +254,7 @@ func (repo *postRepository) execUpdateStatementOnColumnIntoStars(value map[strin
 }
 
 // SearchPost gets all Posts under specfications
-func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order post.SortOrder, limit int, offset int) ([]*post.Post, error) {
+func (repo *postRepository) SearchPost(ctx context.Context, pattern string, by post.SortBy, order post.SortOrder, limit int, offset int) ([]*post.Post, error) {
 	var posts = make([]*post.Post, 0)
 	var err error
 	var rows *sql.Rows
@@ -267,7 +265,7 @@ func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order pos
 		FROM "issue#1".posts
 		ORDER BY %s %s NULLS LAST
 		LIMIT $1 OFFSET $2`, by, order)
-		rows, err = repo.db.Query(query, limit, offset)
+		rows, err = repo.db.QueryContext(ctx, query, limit, offset)
 	} else {
 		query = `
 			  SELECT id,
@@ -293,7 +291,7 @@ func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order pos
 		}
 		query = fmt.Sprintf(`%s 
 		  LIMIT $2 OFFSET $3`, query)
-		rows, err = repo.db.Query(query, pattern, limit, offset)
+		rows, err = repo.db.QueryContext(ctx, query, pattern, limit, offset)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("querying for posts failed because of: %v", err)
@@ -305,15 +303,15 @@ func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order pos
 		if err != nil {
 			return nil, post.ErrPostNotFound
 		}
-		ContentList, errr := repo.getContents(p.ID)
+		ContentList, errr := repo.getContents(ctx, p.ID)
 		if errr != nil {
 			return nil, err
 		}
-		StarList, e := repo.getStars(p.ID)
+		StarList, e := repo.getStars(ctx, p.ID)
 		if e != nil {
 			return nil, e
 		}
-		CommentList, d := repo.getComments(p.ID)
+		CommentList, d := repo.getComments(ctx, p.ID)
 		if d != nil {
 			return nil, fmt.Errorf("Comments Not found because of: %v", d)
 		}
@@ -333,10 +331,10 @@ func (repo *postRepository) SearchPost(pattern string, by post.SortBy, order pos
 }
 
 // GetPostStar gets the star stored under the given postid and username.
-func (repo *postRepository) GetPostStar(id uint, username string) (*post.Star, error) {
+func (repo *postRepository) GetPostStar(ctx context.Context, id uint, username string) (*post.Star, error) {
 	s := post.Star{}
 
-	err := repo.db.QueryRow(`SELECT username, star_count
+	err := repo.db.QueryRowContext(ctx, `SELECT username, star_count
 								FROM "issue#1".post_stars 
 								WHERE post_id = $1 AND username=$2`, id, username).Scan(&s.Username, &s.NumOfStars)
 	if err != nil {
@@ -348,8 +346,8 @@ func (repo *postRepository) GetPostStar(id uint, username string) (*post.Star, e
 }
 
 //DeletePostStar deletes the star stored under given postid and username
-func (repo *postRepository) DeletePostStar(id uint, username string) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".post_stars
+func (repo *postRepository) DeletePostStar(ctx context.Context, id uint, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".post_stars
 							WHERE post_id = $1 AND username=$2`, id, username)
 	if err != nil {
 		//checkErr(err)
@@ -359,28 +357,28 @@ func (repo *postRepository) DeletePostStar(id uint, username string) error {
 }
 
 //AddPostStar adds a star given postid, number of stars and username
-func (repo *postRepository) AddPostStar(id uint, star *post.Star) (*post.Star, error) {
+func (repo *postRepository) AddPostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
 	query := `INSERT INTO "issue#1".post_stars (post_id,username, star_count) 
 				VALUES ($1,$2,$3)`
-	_, errs := repo.db.Exec(query, id, star.Username, star.NumOfStars)
+	_, errs := repo.db.ExecContext(ctx, query, id, star.Username, star.NumOfStars)
 	if errs != nil {
 		//checkErr(errs)
 		return nil, post.ErrStarNotFound
 	}
-	return repo.GetPostStar(id, star.Username)
+	return repo.GetPostStar(ctx, id, star.Username)
 }
 
 //UpdatePostStar updates a star stored given postid, number of stars and username
-func (repo *postRepository) UpdatePostStar(id uint, star *post.Star) (*post.Star, error) {
+func (repo *postRepository) UpdatePostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
 	query := `UPDATE "issue#1".post_stars
 								SET star_count=$2 
 								WHERE post_id = $3 AND username = $1`
-	_, errs := repo.db.Exec(query, star.Username, star.NumOfStars, id)
+	_, errs := repo.db.ExecContext(ctx, query, star.Username, star.NumOfStars, id)
 	if errs != nil {
 
 		return nil, post.ErrStarNotFound
 	}
-	return repo.GetPostStar(id, star.Username)
+	return repo.GetPostStar(ctx, id, star.Username)
 }
 
 // func checkErr(errs error) {
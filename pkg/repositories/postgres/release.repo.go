@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -14,11 +15,11 @@ type releaseRepository repository
 // a PostgresSQL database.
 // A database connection needs to be passed so that it can function.
 func NewReleaseRepository(db *sql.DB, allRepos *map[string]interface{}) release.Repository {
-	return &releaseRepository{db: db, allRepos: allRepos}
+	return &releaseRepository{db: &database{db}, allRepos: allRepos}
 }
 
 // GetRelease returns a release.Release under the given id from the database.
-func (repo releaseRepository) GetRelease(id int) (*release.Release, error) {
+func (repo releaseRepository) GetRelease(ctx context.Context, id int) (*release.Release, error) {
 	var err error
 	var r = new(release.Release)
 
@@ -26,7 +27,7 @@ func (repo releaseRepository) GetRelease(id int) (*release.Release, error) {
 	query := `SELECT type, owner_channel, creation_time
 				FROM releases
 				WHERE id = $1`
-	err = repo.db.QueryRow(query, id).Scan(&typeString, &r.OwnerChannel, &r.CreationTime)
+	err = repo.db.QueryRowContext(ctx, query, id).Scan(&typeString, &r.OwnerChannel, &r.CreationTime)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, release.ErrReleaseNotFound
@@ -34,13 +35,13 @@ func (repo releaseRepository) GetRelease(id int) (*release.Release, error) {
 		return nil, fmt.Errorf("unable to get release from db becaues: %v", err)
 	}
 	r.Type = release.Type(typeString)
-	content, err := repo.getContent(id, r.Type)
+	content, err := repo.getContent(ctx, id, r.Type)
 	if err != nil {
 		return nil, err
 	}
 	r.Content = content
 
-	metadata, err := repo.getMetadata(id)
+	metadata, err := repo.getMetadata(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +52,7 @@ func (repo releaseRepository) GetRelease(id int) (*release.Release, error) {
 }
 
 // SearchRelease searches the database for releases that satisfy the given arguments.
-func (repo releaseRepository) SearchRelease(pattern string, by release.SortBy, order release.SortOrder, limit int, offset int) ([]*release.Release, error) {
+func (repo releaseRepository) SearchRelease(ctx context.Context, pattern string, by release.SortBy, order release.SortOrder, limit int, offset int) ([]*release.Release, error) {
 	var releases = make([]*release.Release, 0)
 	var err error
 	var rows *sql.Rows
@@ -83,7 +84,7 @@ func (repo releaseRepository) SearchRelease(pattern string, by release.SortBy, o
 				     ) AS "coc*"
 				ORDER BY %s %s NULLS LAST
 				LIMIT $1 OFFSET $2`, by, order)
-		rows, err = repo.db.Query(query, limit, offset)
+		rows, err = repo.db.QueryContext(ctx, query, limit, offset)
 	} else {
 		query = `
 				SELECT id, owner_channel, content, type, creation_time
@@ -124,7 +125,7 @@ func (repo releaseRepository) SearchRelease(pattern string, by release.SortBy, o
 		}
 		query = fmt.Sprintf(`%s 
 				LIMIT $2 OFFSET $3`, query)
-		rows, err = repo.db.Query(query, pattern, limit, offset)
+		rows, err = repo.db.QueryContext(ctx, query, pattern, limit, offset)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("querying for releases failed because of: %v", err)
@@ -137,7 +138,7 @@ func (repo releaseRepository) SearchRelease(pattern string, by release.SortBy, o
 			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
 		}
 
-		metadata, err := repo.getMetadata(r.ID)
+		metadata, err := repo.getMetadata(ctx, r.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -153,8 +154,8 @@ func (repo releaseRepository) SearchRelease(pattern string, by release.SortBy, o
 }
 
 // DeleteRelease removes the release under the given id from the database.
-func (repo releaseRepository) DeleteRelease(id int) error {
-	_, err := repo.db.Exec(`DELETE FROM releases
+func (repo releaseRepository) DeleteRelease(ctx context.Context, id int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM releases
 							WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("deletion of release failed because: %v", err)
@@ -162,108 +163,107 @@ func (repo releaseRepository) DeleteRelease(id int) error {
 	return nil
 }
 
-// AddRelease persists the given struct into the database.
-func (repo releaseRepository) AddRelease(r *release.Release) (*release.Release, error) {
-	query := `INSERT INTO releases (owner_channel, type) 
-				VALUES ($1, $2)
-				RETURNING id`
-	err := repo.db.QueryRow(query, r.OwnerChannel, r.Type).Scan(&r.ID)
+// AddRelease persists the given struct into the database, all or nothing.
+func (repo releaseRepository) AddRelease(ctx context.Context, r *release.Release) (*release.Release, error) {
+	var added *release.Release
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		query := `INSERT INTO releases (owner_channel, type) 
+					VALUES ($1, $2)
+					RETURNING id`
+		err := repo.db.QueryRowContext(ctx, query, r.OwnerChannel, r.Type).Scan(&r.ID)
+		if err != nil {
+			return fmt.Errorf("insertion of release failed because of: %v", err)
+		}
+		r.OwnerChannel = ""
+		added, err = repo.UpdateRelease(ctx, r)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("insertion of release failed because of: %v", err)
+		return nil, err
 	}
-	r.OwnerChannel = ""
-	return repo.UpdateRelease(r)
+	return added, nil
 }
 
-// UpdateRelease updates a release in the database according to the given struct.
-func (repo releaseRepository) UpdateRelease(rel *release.Release) (*release.Release, error) {
-	var errs []error
-	// Checks if value is to be updated before attempting.
-	// This way, there won't be columns with Go's zero string value of "" instead of null
-	if rel.OwnerChannel != "" {
-		err := repo.execUpdateStatementOnColumnIntoReleases("owner_channel", rel.OwnerChannel, rel.ID)
-		if err != nil {
-			errs = append(errs, err)
+// UpdateRelease updates a release in the database according to the given struct, all or nothing.
+func (repo releaseRepository) UpdateRelease(ctx context.Context, rel *release.Release) (*release.Release, error) {
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		// Checks if value is to be updated before attempting.
+		// This way, there won't be columns with Go's zero string value of "" instead of null
+		if rel.OwnerChannel != "" {
+			err := repo.execUpdateStatementOnColumnIntoReleases(ctx, "owner_channel", rel.OwnerChannel, rel.ID)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if rel.Content != "" && rel.Type != "" {
-		err := repo.execUpdateStatementForContent(rel.Type, rel.Content, rel.ID)
-		if err != nil {
-			errs = append(errs, err)
+		if rel.Content != "" && rel.Type != "" {
+			err := repo.execUpdateStatementForContent(ctx, rel.Type, rel.Content, rel.ID)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if !rel.ReleaseDate.IsZero() {
-		err := repo.execUpdateStatementOnColumnIntoMetadata("release_date", rel.ReleaseDate, rel.ID)
-		if err != nil {
-			errs = append(errs, err)
+		if !rel.ReleaseDate.IsZero() {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "release_date", rel.ReleaseDate, rel.ID)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if rel.Title != "" {
-		err := repo.execUpdateStatementOnColumnIntoMetadata("title", rel.Title, rel.ID)
-		if err != nil {
-			errs = append(errs, err)
+		if rel.Title != "" {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "title", rel.Title, rel.ID)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if rel.GenreDefining != "" {
-		err := repo.execUpdateStatementOnColumnIntoMetadata("genre_defining", rel.GenreDefining, rel.ID)
-		if err != nil {
-			errs = append(errs, err)
+		if rel.GenreDefining != "" {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "genre_defining", rel.GenreDefining, rel.ID)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if rel.Description != "" {
-		err := repo.execUpdateStatementOnColumnIntoMetadata("description", rel.Description, rel.ID)
-		if err != nil {
-			errs = append(errs, err)
+		if rel.Description != "" {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "description", rel.Description, rel.ID)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	otherJSONRaw, err := json.Marshal(rel.Other)
-	if err == nil {
-		//jsonbString := fmt.Sprintf("to_jsonb(%s::text)", string(otherJSONRaw))
-		err := repo.execUpdateStatementOnColumnIntoMetadata("other", string(otherJSONRaw), rel.ID)
+		otherJSONRaw, err := json.Marshal(rel.Other)
 		if err != nil {
-			errs = append(errs, err)
+			return err
 		}
-	} else {
-		errs = append(errs, err)
-	}
-	const maxNoOfPossibleErr = 6
-	if len(errs) == maxNoOfPossibleErr {
-		return nil, fmt.Errorf("was unable to update any data because of %v", errs)
-	}
-	r, err := repo.GetRelease(rel.ID)
-	if err == nil {
-		if len(errs) > 0 {
-			fmt.Printf("%+v", errs)
-			err = release.ErrSomeReleaseDataNotPersisted
+		return repo.execUpdateStatementOnColumnIntoMetadata(ctx, "other", string(otherJSONRaw), rel.ID)
+	})
+	if err != nil {
+		if _, getErr := repo.GetRelease(ctx, rel.ID); getErr == release.ErrReleaseNotFound {
+			return nil, release.ErrReleaseNotFound
 		}
+		return nil, release.ErrSomeReleaseDataNotPersisted
 	}
-	return r, err
+	return repo.GetRelease(ctx, rel.ID)
 }
 
-func (repo releaseRepository) execUpdateStatementOnColumnIntoReleases(column, value string, id int) error {
+func (repo releaseRepository) execUpdateStatementOnColumnIntoReleases(ctx context.Context, column, value string, id int) error {
 	query := fmt.Sprintf(`UPDATE releases
 								SET %s = $1 
 								WHERE id = $2`, column)
-	_, err := repo.db.Exec(query, value, id)
+	_, err := repo.db.ExecContext(ctx, query, value, id)
 	if err != nil {
 		return fmt.Errorf("updating failed of %s column with %s because of: %v", column, value, err)
 	}
 	return nil
 }
 
-func (repo releaseRepository) execUpdateStatementOnColumnIntoMetadata(column string, value interface{}, id int) error {
+func (repo releaseRepository) execUpdateStatementOnColumnIntoMetadata(ctx context.Context, column string, value interface{}, id int) error {
 	query := fmt.Sprintf(`INSERT INTO release_metadata (release_id, %s)
 								VALUES ($1, $2)
 								ON CONFLICT(release_id) DO UPDATE
 								SET %s = $2`, column, column)
-	_, err := repo.db.Exec(query, id, value)
+	_, err := repo.db.ExecContext(ctx, query, id, value)
 	if err != nil {
 		return fmt.Errorf("upsertion failed of %s column to metadat with %s because of: %v", column, value, err)
 	}
 	return nil
 }
 
-func (repo releaseRepository) execUpdateStatementForContent(t release.Type, value string, id int) error {
+func (repo releaseRepository) execUpdateStatementForContent(ctx context.Context, t release.Type, value string, id int) error {
 	var query string
 	if t == release.Image {
 		query = `INSERT INTO releases_image_based (release_id, image_name)
@@ -276,14 +276,14 @@ func (repo releaseRepository) execUpdateStatementForContent(t release.Type, valu
 				ON CONFLICT(release_id) DO UPDATE
 				SET content = $2`
 	}
-	_, err := repo.db.Exec(query, id, value)
+	_, err := repo.db.ExecContext(ctx, query, id, value)
 	if err != nil {
 		return fmt.Errorf("upserting failed of %s type with %s because of: %v", string(t), value, err)
 	}
 	return nil
 }
 
-func (repo releaseRepository) getContent(id int, t release.Type) (string, error) {
+func (repo releaseRepository) getContent(ctx context.Context, id int, t release.Type) (string, error) {
 	var content, query string
 	if t == release.Image {
 		query = `SELECT COALESCE(image_name, '') 
@@ -294,7 +294,7 @@ func (repo releaseRepository) getContent(id int, t release.Type) (string, error)
 				FROM releases_text_based 
 				WHERE release_id = $1`
 	}
-	err := repo.db.QueryRow(query, id).Scan(&content)
+	err := repo.db.QueryRowContext(ctx, query, id).Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", nil
@@ -304,7 +304,7 @@ func (repo releaseRepository) getContent(id int, t release.Type) (string, error)
 	return content, nil
 }
 
-func (repo releaseRepository) getMetadata(id int) (*release.Metadata, error) {
+func (repo releaseRepository) getMetadata(ctx context.Context, id int) (*release.Metadata, error) {
 	var err error
 	var meta = new(release.Metadata)
 
@@ -313,7 +313,7 @@ func (repo releaseRepository) getMetadata(id int) (*release.Metadata, error) {
 	query := `SELECT COALESCE(title, ''), COALESCE(description, ''), COALESCE(genre_defining, ''), COALESCE(release_date, to_timestamp(0)), COALESCE(other, jsonb_build_object())
 				FROM release_metadata
 				WHERE release_id = $1`
-	err = repo.db.QueryRow(query, id).Scan(&meta.Title, &meta.Description, &meta.GenreDefining, &meta.ReleaseDate, &otherJSON)
+	err = repo.db.QueryRowContext(ctx, query, id).Scan(&meta.Title, &meta.Description, &meta.GenreDefining, &meta.ReleaseDate, &otherJSON)
 	if err != nil {
 		return nil, fmt.Errorf("metadata for release not found because: %v", err)
 	}
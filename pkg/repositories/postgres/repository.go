@@ -3,10 +3,6 @@ Package postgres contains implementations of business level objects
 all contained in a single package to help with circular dependencies */
 package postgres
 
-import (
-	"database/sql"
-)
-
 /*
 //DBHandler ...
 type DBHandler interface {
@@ -22,6 +18,6 @@ type Row interface {
 */
 
 type repository struct {
-	db       *sql.DB
+	db       *database
 	allRepos *map[string]interface{}
 }
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/slim-crown/issue-1-REST/pkg/services/search"
@@ -12,10 +13,10 @@ type searchRepository repository
 // a PostgresSQL database.
 // A database connection needs to be passed so that it can function.
 func NewSearchRepository(DB *sql.DB, allRepos *map[string]interface{}) search.Repository {
-	return &searchRepository{DB, allRepos}
+	return &searchRepository{&database{DB}, allRepos}
 }
 
-func (repo searchRepository) SearchComments(pattern string, by string, order string, limit, offset int) ([]*search.Comment, error) {
+func (repo searchRepository) SearchComments(ctx context.Context, pattern string, by string, order string, limit, offset int) ([]*search.Comment, error) {
 
 	var comments = make([]*search.Comment, 0)
 	var err error
@@ -39,7 +40,7 @@ func (repo searchRepository) SearchComments(pattern string, by string, order str
 	}
 	query = fmt.Sprintf(`%s 
 				LIMIT $2 OFFSET $3`, query)
-	rows, err = repo.db.Query(query, pattern, limit, offset)
+	rows, err = repo.db.QueryContext(ctx, query, pattern, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("querying for comments failed because of: %v", err)
 	}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
@@ -21,42 +22,50 @@ type userRepository repository
 // found in the different services of the project must be passed as a second argument as
 // the Repository might make user of them to fetch objects instead of implementing redundant logic.
 func NewUserRepository(DB *sql.DB, allRepos *map[string]interface{}) user.Repository {
-	return &userRepository{DB, allRepos}
+	return &userRepository{&database{DB}, allRepos}
 }
 
-// AddUser takes in a user.User struct and persists it in the database.
-func (repo *userRepository) AddUser(u *user.User) (*user.User, error) {
+// AddUser takes in a user.User struct and persists it in the database, all or nothing.
+func (repo *userRepository) AddUser(ctx context.Context, u *user.User) (*user.User, error) {
 	var err error
 	passHash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("unable to generate bcrypt has because: %w", err)
 	}
-	_, err = repo.db.Exec(`INSERT INTO "issue#1".users (username, email, pass_hash)
+	var added *user.User
+	err = repo.db.transact(ctx, func(ctx context.Context) error {
+		_, err := repo.db.ExecContext(ctx, `INSERT INTO "issue#1".users (username, email, pass_hash)
 							VALUES ($1, $2, $3)`, u.Username, u.Email, string(passHash))
+		if err != nil {
+			return fmt.Errorf("insertion of user failed because of: %w", err)
+		}
+
+		// set the username to zero to avoid call to UpdateUser won't do redundant updating of username
+		username := u.Username
+		u.Username = ""
+		u.Email = ""
+		u.Password = ""
+
+		// using UpdateUser to set the rest of the values so that null values will be preserved
+		// (instead of columns with go's zero value of "")
+		added, err = repo.UpdateUser(ctx, username, u)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("insertion of user failed because of: %w", err)
+		return nil, err
 	}
-
-	// set the username to zero to avoid call to UpdateUser won't do redundant updating of username
-	username := u.Username
-	u.Username = ""
-	u.Email = ""
-	u.Password = ""
-
-	// using UpdateUser to set the rest of the values so that null values will be preserved
-	// (instead of columns with go's zero value of "")
-	return repo.UpdateUser(username, u)
+	return added, nil
 }
 
 // GetUser retrieves a user.User based on the username passed.
-func (repo *userRepository) GetUser(username string) (*user.User, error) {
+func (repo *userRepository) GetUser(ctx context.Context, username string) (*user.User, error) {
 	var err error
 	var u = new(user.User)
 
-	err = repo.db.QueryRow(`
-								SELECT email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, '')
+	err = repo.db.QueryRowContext(ctx, `
+								SELECT email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, ''), verified
 								FROM users LEFT JOIN users_bio ub on users.username = ub.username LEFT JOIN user_avatars ua on users.username = ua.username
-								WHERE users.username = $1`, username).Scan(&u.Email, &u.FirstName, &u.MiddleName, &u.LastName, &u.CreationTime, &u.Bio, &u.PictureURL)
+								WHERE users.username = $1`, username).Scan(&u.Email, &u.FirstName, &u.MiddleName, &u.LastName, &u.CreationTime, &u.Bio, &u.PictureURL, &u.Verified)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, user.ErrUserNotFound
@@ -64,7 +73,7 @@ func (repo *userRepository) GetUser(username string) (*user.User, error) {
 		return nil, fmt.Errorf("unable to get user from db becaues: %v", err)
 	}
 
-	bookmarkedPosts, err := repo.getBookmarkedPosts(username)
+	bookmarkedPosts, err := repo.getBookmarkedPosts(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get bookmarked posts because of: %v", err)
 	}
@@ -75,10 +84,10 @@ func (repo *userRepository) GetUser(username string) (*user.User, error) {
 }
 
 // getBookmarkedPosts is just a helper function
-func (repo *userRepository) getBookmarkedPosts(username string) (map[time.Time]int, error) {
+func (repo *userRepository) getBookmarkedPosts(ctx context.Context, username string) (map[time.Time]int, error) {
 	var bookmarkedPosts = make(map[time.Time]int, 0)
 
-	rows, err := repo.db.Query(`SELECT post_id, creation_time
+	rows, err := repo.db.QueryContext(ctx, `SELECT post_id, creation_time
 								FROM "issue#1".user_bookmarks 
 								WHERE username = $1`, username)
 	if err != nil {
@@ -105,93 +114,88 @@ func (repo *userRepository) getBookmarkedPosts(username string) (map[time.Time]i
 }
 
 // UpdateUser updates a user based on the passed user.User struct.
-// If updating in the DB repo is successful, it updates its cache by getting
-// the new user.User and converting it into a cache able format.
-func (repo *userRepository) UpdateUser(username string, u *user.User) (*user.User, error) {
-	var errs []error
-
-	// if err != nil {
-	// 	return fmt.Errorf("creation of update statement failed because of: %v", err)
-	// }
-	// Checks if value is to be updated before attempting.
-	// This way, there won't be columns with go's zero string value of "" instead of null
+// Only the non empty fields of the struct are updated, all or nothing.
+func (repo *userRepository) UpdateUser(ctx context.Context, username string, u *user.User) (*user.User, error) {
+	var passHash []byte
 	if u.Password != "" {
-
-		passHash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		var err error
+		passHash, err = bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
 		if err != nil {
 			return nil, fmt.Errorf("unable to generate bcrypt has because: %w", err)
 		}
-
-		err = repo.execUpdateStatementOnColumn("pass_hash", string(passHash), username)
-		if err != nil {
-			errs = append(errs, err)
-		}
 	}
-	if u.Email != "" {
-		err := repo.execUpdateStatementOnColumn("email", u.Email, username)
-		if err != nil {
-			errs = append(errs, err)
+	newUsername := username
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		// Checks if value is to be updated before attempting.
+		// This way, there won't be columns with go's zero string value of "" instead of null
+		if u.Password != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "pass_hash", string(passHash), username)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if u.FirstName != "" {
-		err := repo.execUpdateStatementOnColumn("first_name", u.FirstName, username)
-		if err != nil {
-			errs = append(errs, err)
+		if u.Email != "" {
+			// a changed email has to be verified anew
+			_, err := repo.db.ExecContext(ctx, `UPDATE "issue#1".users
+								SET verified = false
+								WHERE username = $1 AND email <> $2`, username, u.Email)
+			if err != nil {
+				return fmt.Errorf("resetting verified failed because of: %v", err)
+			}
+			err = repo.execUpdateStatementOnColumn(ctx, "email", u.Email, username)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if u.MiddleName != "" {
-		err := repo.execUpdateStatementOnColumn("middle_name", u.MiddleName, username)
-		if err != nil {
-			errs = append(errs, err)
+		if u.FirstName != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "first_name", u.FirstName, username)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if u.LastName != "" {
-		err := repo.execUpdateStatementOnColumn("last_name", u.LastName, username)
-		if err != nil {
-			errs = append(errs, err)
+		if u.MiddleName != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "middle_name", u.MiddleName, username)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if u.Bio != "" {
-		_, err := repo.db.Exec(`INSERT INTO "issue#1".users_bio(bio, username)
+		if u.LastName != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "last_name", u.LastName, username)
+			if err != nil {
+				return err
+			}
+		}
+		if u.Bio != "" {
+			_, err := repo.db.ExecContext(ctx, `INSERT INTO "issue#1".users_bio(bio, username)
 								VALUES ($1, $2)
 								ON CONFLICT(username) DO UPDATE
 								SET bio = $1`, u.Bio, username)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("upsertion of bio failed because of: %v", err))
-		}
-	}
-	if u.Username != "" {
-		err := repo.execUpdateStatementOnColumn("username", u.Username, username)
-		if err != nil {
-			errs = append(errs, err)
+			if err != nil {
+				return fmt.Errorf("upsertion of bio failed because of: %v", err)
+			}
 		}
-		// change username for subsequent calls if username changed
-		username = u.Username
-	}
-	/*
-		if u.PictureURL != "" {
-			err := repo.AddPicture(u.Username, u.PictureURL)
+		if u.Username != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "username", u.Username, username)
 			if err != nil {
-				errs = append(errs, err)
+				return err
 			}
-		}*/
-	const maxNoOfPossibleErr = 7
-	if len(errs) == maxNoOfPossibleErr {
-		return nil, fmt.Errorf("was unable to update any data because of %v", errs)
-	}
-	u, err := repo.GetUser(username)
-	if err == nil {
-		if len(errs) > 0 {
-			fmt.Printf("%+v", errs)
-			err = user.ErrSomeUserDataNotPersisted
+			// change username for subsequent calls if username changed
+			newUsername = u.Username
+		}
+		return nil
+	})
+	if err != nil {
+		if _, getErr := repo.GetUser(ctx, username); getErr == user.ErrUserNotFound {
+			return nil, user.ErrUserNotFound
 		}
+		return nil, user.ErrSomeUserDataNotPersisted
 	}
-	return u, err
+	return repo.GetUser(ctx, newUsername)
 }
 
 // execUpdateStatementOnColumn is just a helper function
-func (repo *userRepository) execUpdateStatementOnColumn(column, value, username string) error {
-	_, err := repo.db.Exec(fmt.Sprintf(`UPDATE "issue#1".users 
+func (repo *userRepository) execUpdateStatementOnColumn(ctx context.Context, column, value, username string) error {
+	_, err := repo.db.ExecContext(ctx, fmt.Sprintf(`UPDATE "issue#1".users 
 									SET %s = $1 
 									WHERE username = $2`, column), value, username)
 	if err != nil {
@@ -202,8 +206,8 @@ func (repo *userRepository) execUpdateStatementOnColumn(column, value, username
 
 // DeleteUser deletes a user based on the passed in username.
 // If deletion is successful, it also tries to delete the user from its cache.
-func (repo *userRepository) DeleteUser(username string) error {
-	_, err := repo.db.Exec(`DELETE FROM users
+func (repo *userRepository) DeleteUser(ctx context.Context, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM users
 							WHERE username = $1`, username)
 	if err != nil {
 		return fmt.Errorf("deletion of user failed because of: %v", err)
@@ -214,25 +218,25 @@ func (repo *userRepository) DeleteUser(username string) error {
 // SearchUser searches for users according to the pattern.
 // If no pattern is provided, it returns all users.
 // It makes use of pagination.
-func (repo *userRepository) SearchUser(pattern, sortBy, sortOrder string, limit, offset int) ([]*user.User, error) {
+func (repo *userRepository) SearchUser(ctx context.Context, pattern, sortBy, sortOrder string, limit, offset int) ([]*user.User, error) {
 	var users = make([]*user.User, 0)
 	var err error
 	var rows *sql.Rows
 
 	if pattern == "" {
-		rows, err = repo.db.Query(fmt.Sprintf(`
-		SELECT users.username, email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, '')
+		rows, err = repo.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT users.username, email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, ''), verified
 		FROM users LEFT JOIN users_bio ub on users.username = ub.username LEFT JOIN user_avatars ua on users.username = ua.username
 		ORDER BY %s %s NULLS LAST
 		LIMIT $1 OFFSET $2`, sortBy, sortOrder), limit, offset)
 	} else {
 		query := fmt.Sprintf(`
-		SELECT users.username, email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, '')
+		SELECT users.username, email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, ''), verified
 		FROM users LEFT JOIN users_bio ub on users.username = ub.username LEFT JOIN user_avatars ua on users.username = ua.username
 		WHERE users.username ILIKE '%%' || $3 || '%%' OR first_name ILIKE '%%' || $3 || '%%' OR last_name ILIKE '%%' || $3 || '%%'
 		ORDER BY %s %s NULLS LAST
 		LIMIT $1 OFFSET $2`, sortBy, sortOrder)
-		rows, err = repo.db.Query(query, limit, offset, pattern)
+		rows, err = repo.db.QueryContext(ctx, query, limit, offset, pattern)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("querying for users failed because of: %v", err)
@@ -241,11 +245,11 @@ func (repo *userRepository) SearchUser(pattern, sortBy, sortOrder string, limit,
 
 	for rows.Next() {
 		u := user.User{}
-		err := rows.Scan(&u.Username, &u.Email, &u.FirstName, &u.MiddleName, &u.LastName, &u.CreationTime, &u.Bio, &u.PictureURL)
+		err := rows.Scan(&u.Username, &u.Email, &u.FirstName, &u.MiddleName, &u.LastName, &u.CreationTime, &u.Bio, &u.PictureURL, &u.Verified)
 		if err != nil {
 			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
 		}
-		bookmarkedPosts, err := repo.getBookmarkedPosts(u.Username)
+		bookmarkedPosts, err := repo.getBookmarkedPosts(ctx, u.Username)
 		if err != nil {
 			return nil, fmt.Errorf("unable to get bookmarked posts because of: %s", err.Error())
 		}
@@ -261,7 +265,7 @@ func (repo *userRepository) SearchUser(pattern, sortBy, sortOrder string, limit,
 }
 
 // Authenticate checks the given pass hash against the pass hash found in the database for the username.
-func (repo *userRepository) Authenticate(u *user.User) (bool, error) {
+func (repo *userRepository) Authenticate(ctx context.Context, u *user.User) (bool, error) {
 	/*	var hashedPassword string
 		{
 			// this block hashes the password
@@ -296,7 +300,7 @@ SELECT EXISTS(
                    )::text
            )`
 	var accepted bool
-	err := repo.db.QueryRow(query, u.Password, u.Username, u.Email).Scan(&accepted)
+	err := repo.db.QueryRowContext(ctx, query, u.Password, u.Username, u.Email).Scan(&accepted)
 	if err != nil {
 		return false, fmt.Errorf("couldn't authenticate user beacause: %w", err)
 	}
@@ -304,8 +308,8 @@ SELECT EXISTS(
 }
 
 // BookmarkPost bookmarks the given postID for the user of the given username.
-func (repo *userRepository) BookmarkPost(username string, postID int) error {
-	_, err := repo.db.Exec(`INSERT INTO user_bookmarks (username, post_id)
+func (repo *userRepository) BookmarkPost(ctx context.Context, username string, postID int) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO user_bookmarks (username, post_id)
 							VALUES ($1, $2)
 							ON CONFLICT DO NOTHING`, username, postID)
 	const foreignKeyViolationErrorCode = pq.ErrorCode("23503")
@@ -321,8 +325,8 @@ func (repo *userRepository) BookmarkPost(username string, postID int) error {
 }
 
 // DeleteBookmark removes the given ID from the given user's bookmarks
-func (repo *userRepository) DeleteBookmark(username string, postID int) error {
-	_, err := repo.db.Exec(`DELETE FROM "issue#1".user_bookmarks
+func (repo *userRepository) DeleteBookmark(ctx context.Context, username string, postID int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM "issue#1".user_bookmarks
 							WHERE username = $1 AND post_id = $2`, username, postID)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from user_bookmarks because of: %v", err)
@@ -331,9 +335,9 @@ func (repo *userRepository) DeleteBookmark(username string, postID int) error {
 }
 
 // UsernameOccupied checks if the given username is occupied by another user or a channel
-func (repo *userRepository) UsernameOccupied(username string) (bool, error) {
+func (repo *userRepository) UsernameOccupied(ctx context.Context, username string) (bool, error) {
 	var occupied bool
-	err := repo.db.QueryRow(`
+	err := repo.db.QueryRowContext(ctx, `
 				SELECT EXISTS(
                SELECT username
                FROM ((select username from users)
@@ -348,9 +352,9 @@ func (repo *userRepository) UsernameOccupied(username string) (bool, error) {
 }
 
 // EmailOccupied checks if the given email is occupied by another user
-func (repo *userRepository) EmailOccupied(email string) (bool, error) {
+func (repo *userRepository) EmailOccupied(ctx context.Context, email string) (bool, error) {
 	var occupied bool
-	err := repo.db.QueryRow(`SELECT EXISTS(SELECT username FROM "issue#1".users
+	err := repo.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT username FROM "issue#1".users
 									WHERE email = $1)`, email).Scan(&occupied)
 	if err != nil {
 		return true, fmt.Errorf("unable to check if email occupied")
@@ -359,8 +363,8 @@ func (repo *userRepository) EmailOccupied(email string) (bool, error) {
 }
 
 // AddPicture persists the given name as the image_name for the user under the given username
-func (repo *userRepository) AddPicture(username, name string) error {
-	_, err := repo.db.Exec(`INSERT INTO user_avatars (username, image_name) 
+func (repo *userRepository) AddPicture(ctx context.Context, username, name string) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO user_avatars (username, image_name) 
 								VALUES ($1, $2)
 								ON CONFLICT(username) DO UPDATE
 								SET image_name = $1`, username, name)
@@ -377,11 +381,25 @@ func (repo *userRepository) AddPicture(username, name string) error {
 }
 
 // RemovePicture removes the username's tuple entry from the user_avatars table.
-func (repo *userRepository) RemovePicture(username string) error {
-	_, err := repo.db.Exec(`DELETE FROM user_avatars
+func (repo *userRepository) RemovePicture(ctx context.Context, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM user_avatars
 							WHERE username = $1`, username)
 	if err != nil {
 		return fmt.Errorf("deletion of tuple from user_avatars failed because of: %v", err)
 	}
 	return nil
 }
+
+// MarkVerified sets the verified column of the user of the given username.
+func (repo *userRepository) MarkVerified(ctx context.Context, username string) error {
+	result, err := repo.db.ExecContext(ctx, `UPDATE "issue#1".users
+								SET verified = true
+								WHERE username = $1`, username)
+	if err != nil {
+		return fmt.Errorf("marking user verified failed because of: %v", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+)
+
+// maxStickiedPosts is how many posts a channel can have stickied at once.
+const maxStickiedPosts = 2
+
+// channelRepository...
+type channelRepository repository
+
+// NewChannelRepository returns a new SQLite implementation of channel.Repository.
+// the database connection must be passed as the first argument
+// since for the repo to work.
+// A map of all the other SQLite based implementations of the Repository interfaces
+// found in the different services of the project must be passed as a second argument as
+// the Repository might make use of them to fetch objects instead of implementing redundant logic.
+func NewChannelRepository(DB *sql.DB, allRepos *map[string]interface{}) channel.Repository {
+	return &channelRepository{&database{DB}, allRepos}
+}
+
+// AddChannel takes in a channel.Channel struct and persists it in the database
+// along with its owner, all or nothing.
+func (repo *channelRepository) AddChannel(ctx context.Context, c *channel.Channel) (*channel.Channel, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("insertion of channel failed because of: %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO channels (username, name, description)
+							VALUES (?, ?, NULLIF(?, ''))`, c.ChannelUsername, c.Name, c.Description)
+	if err != nil {
+		return nil, fmt.Errorf("insertion of channel failed because of: %s", err.Error())
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO channel_admins (channel_username, username, is_owner)
+							VALUES (?, ?, 1)`, c.ChannelUsername, c.OwnerUsername)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			return nil, fmt.Errorf("insertion of admin user failed because of: %w", channel.ErrAdminNotFound)
+		}
+		return nil, fmt.Errorf("insertion of admin user failed because of: %s", err.Error())
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("insertion of channel failed because of: %s", err.Error())
+	}
+	return c, nil
+}
+
+// GetChannel retrieves a channel.Channel based on the username passed.
+func (repo *channelRepository) GetChannel(ctx context.Context, channelUsername string) (*channel.Channel, error) {
+	var c = new(channel.Channel)
+	err := repo.db.QueryRowContext(ctx, `SELECT username, name, COALESCE(description, ''), creation_time, COALESCE(image_name, '')
+							FROM channels LEFT JOIN channel_pictures cp ON channels.username = cp.channelname
+							WHERE username = ?`, channelUsername).Scan(&c.ChannelUsername, &c.Name, &c.Description, &c.CreationTime, &c.PictureURL)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, channel.ErrChannelNotFound
+		}
+		return nil, fmt.Errorf("unable to get channel from db because of: %v", err)
+	}
+	if err = repo.getRelations(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// getRelations is just a helper function that fills in the admins, owner, posts
+// and releases of the given channel.
+func (repo *channelRepository) getRelations(ctx context.Context, c *channel.Channel) error {
+	rows, err := repo.db.QueryContext(ctx, `SELECT username, is_owner
+								FROM channel_admins
+								WHERE channel_username = ?
+								ORDER BY rowid`, c.ChannelUsername)
+	if err != nil {
+		return fmt.Errorf("querying for admins failed because of: %v", err)
+	}
+	defer rows.Close()
+	c.AdminUsernames = nil
+	c.OwnerUsername = ""
+	for rows.Next() {
+		var admin string
+		var isOwner bool
+		if err := rows.Scan(&admin, &isOwner); err != nil {
+			return fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		c.AdminUsernames = append(c.AdminUsernames, admin)
+		if isOwner {
+			c.OwnerUsername = admin
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	rows.Close()
+
+	c.PostIDs, err = repo.getIDs(ctx, `SELECT id FROM posts WHERE channel_from = ? ORDER BY id`, c.ChannelUsername)
+	if err != nil {
+		return fmt.Errorf("unable to get posts because of: %s", err.Error())
+	}
+	c.StickiedPostIDs, err = repo.getIDs(ctx, `SELECT post_id
+										FROM channel_stickies INNER JOIN posts ON channel_stickies.post_id = posts.id
+										WHERE posts.channel_from = ?
+										ORDER BY post_id`, c.ChannelUsername)
+	if err != nil {
+		return fmt.Errorf("unable to get stickied posts because of: %s", err.Error())
+	}
+	c.ReleaseIDs, err = repo.getIDs(ctx, `SELECT id FROM releases WHERE owner_channel = ? ORDER BY id`, c.ChannelUsername)
+	if err != nil {
+		return fmt.Errorf("unable to get releases because of: %s", err.Error())
+	}
+	c.OfficialReleaseIDs, err = repo.getIDs(ctx, `SELECT release_id
+										FROM channel_official_catalog
+										WHERE channel_username = ?
+										ORDER BY rowid`, c.ChannelUsername)
+	if err != nil {
+		return fmt.Errorf("unable to get official releases because of: %s", err.Error())
+	}
+	return nil
+}
+
+// getIDs is just a helper function that returns the IDs the given query selects.
+func (repo *channelRepository) getIDs(ctx context.Context, query string, args ...interface{}) ([]uint, error) {
+	var ids []uint
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying for ids failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	return ids, nil
+}
+
+// UpdateChannel updates a channel based on the passed in channel.Channel struct into channelUsername.
+// The username is changed last so that the rest of the updates find the channel,
+// all of them are made or none is.
+func (repo *channelRepository) UpdateChannel(ctx context.Context, channelUsername string, c *channel.Channel) (*channel.Channel, error) {
+	if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM channels WHERE username = ?)`, channelUsername) {
+		return nil, channel.ErrChannelNotFound
+	}
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		if c.Name != "" {
+			if err := repo.execUpdateStatementOnColumn(ctx, "name", c.Name, channelUsername); err != nil {
+				return err
+			}
+		}
+		if c.Description != "" {
+			if err := repo.execUpdateStatementOnColumn(ctx, "description", c.Description, channelUsername); err != nil {
+				return err
+			}
+		}
+		if c.ChannelUsername != "" && c.ChannelUsername != channelUsername {
+			if err := repo.execUpdateStatementOnColumn(ctx, "username", c.ChannelUsername, channelUsername); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// execUpdateStatementOnColumn is just a helper function that updates a certain column
+func (repo *channelRepository) execUpdateStatementOnColumn(ctx context.Context, column, value, username string) error {
+	_, err := repo.db.ExecContext(ctx, fmt.Sprintf(`UPDATE channels
+									SET %s = ?
+									WHERE username = ?`, column), value, username)
+	if err != nil {
+		return fmt.Errorf("updating failed of %s column with %s because of: %s", column, value, err.Error())
+	}
+	return nil
+}
+
+// DeleteChannel deletes a channel based on the passed in channelUsername.
+// Its posts and releases are deleted along with it, it fails if any of its
+// posts is in an official catalog.
+func (repo *channelRepository) DeleteChannel(ctx context.Context, channelUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM channels
+							WHERE username = ?`, channelUsername)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from channels because of: %s", err.Error())
+	}
+	return nil
+}
+
+// SearchChannels searches for channels according to the pattern.
+// If no pattern is provided, it returns all channels.
+// It makes use of pagination.
+func (repo *channelRepository) SearchChannels(ctx context.Context, pattern string, sortBy channel.SortBy, sortOrder channel.SortOrder, limit, offset int) ([]*channel.Channel, error) {
+	var channels = make([]*channel.Channel, 0)
+	query := fmt.Sprintf(`SELECT username, name, COALESCE(description, ''), creation_time, COALESCE(image_name, '')
+							FROM channels LEFT JOIN channel_pictures cp ON channels.username = cp.channelname
+							WHERE ?3 = '' OR username LIKE '%%' || ?3 || '%%' OR name LIKE '%%' || ?3 || '%%'
+							ORDER BY channels.%s COLLATE NOCASE %s NULLS LAST, username COLLATE NOCASE
+							LIMIT ?1 OFFSET ?2`, sortBy, sortOrder)
+	rows, err := repo.db.QueryContext(ctx, query, limit, offset, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("querying for channels failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := channel.Channel{}
+		err := rows.Scan(&c.ChannelUsername, &c.Name, &c.Description, &c.CreationTime, &c.PictureURL)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %s", err.Error())
+		}
+		channels = append(channels, &c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %s", err.Error())
+	}
+	rows.Close()
+
+	for _, c := range channels {
+		if err := repo.getRelations(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// AddAdmin adds a User adminUsername to the channel channelUsername as an admin
+func (repo *channelRepository) AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO channel_admins (channel_username, username, is_owner)
+							VALUES (?, ?, 0)`, channelUsername, adminUsername)
+	if err != nil {
+		switch {
+		case isConstraintViolation(err, sqlite3.ErrConstraintForeignKey):
+			return channel.ErrAdminNotFound
+		case isConstraintViolation(err, sqlite3.ErrConstraintPrimaryKey):
+			return channel.ErrAdminAlreadyExists
+		default:
+			return fmt.Errorf("inserting into admins failed because of: %s ", err.Error())
+		}
+	}
+	return nil
+}
+
+// DeleteAdmin deletes role of a User adminUsername of the channel channelUsername as an admin
+func (repo *channelRepository) DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM channel_admins
+							WHERE channel_username = ? AND username = ?`, channelUsername, adminUsername)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from channel_admins because of: %s", err.Error())
+	}
+	return nil
+}
+
+// ChangeOwner makes the admin under the given ownerUsername the owner of the channel.
+// The channel is left without an owner if they're not one of its admins.
+func (repo *channelRepository) ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE channel_admins
+							SET is_owner = (username = ?2)
+							WHERE channel_username = ?1`, channelUsername, ownerUsername)
+	if err != nil {
+		return fmt.Errorf("changing of owner failed because of: %s", err.Error())
+	}
+	return nil
+}
+
+// AddReleaseToOfficialCatalog adds the release, taken from the given post, to the
+// official catalog of the channel.
+func (repo *channelRepository) AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO channel_official_catalog (channel_username, release_id, post_from_id)
+							VALUES (?, ?, ?)`, channelUsername, releaseID, postID)
+	if err != nil {
+		switch {
+		case isConstraintViolation(err, sqlite3.ErrConstraintPrimaryKey):
+			return channel.ErrReleaseAlreadyExists
+		case !isConstraintViolation(err, sqlite3.ErrConstraintForeignKey):
+			return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %s", err.Error())
+		// the violation doesn't tell which of the keys is missing
+		case !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM channels WHERE username = ?)`, channelUsername):
+			return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %w", channel.ErrChannelNotFound)
+		case !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM releases WHERE id = ?)`, releaseID):
+			return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %w", channel.ErrReleaseNotFound)
+		default:
+			return fmt.Errorf("addition of tuple of release channel_official_catalogs because of: %w", channel.ErrPostNotFound)
+		}
+	}
+	return nil
+}
+
+// DeleteReleaseFromCatalog deletes a release releaseID from Catalog of channel channelUsername
+func (repo *channelRepository) DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, releaseID uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM releases
+							WHERE owner_channel = ? AND id = ?`, channelUsername, releaseID)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from channel_catalogs because of: %s", err.Error())
+	}
+	return nil
+}
+
+// DeleteReleaseFromOfficialCatalog deletes a release releaseID from Official Catalog of channel channelUsername
+func (repo *channelRepository) DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM channel_official_catalog
+							WHERE channel_username = ? AND release_id = ?`, channelUsername, releaseID)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from channel_catalogs because of: %s", err.Error())
+	}
+	return nil
+}
+
+// StickyPost stickies the post on the channel, a channel can only have two
+// posts stickied at once.
+func (repo *channelRepository) StickyPost(ctx context.Context, channelUsername string, postID uint) error {
+	var stickied int
+	err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*)
+							FROM channel_stickies INNER JOIN posts ON channel_stickies.post_id = posts.id
+							WHERE posts.channel_from = ?`, channelUsername).Scan(&stickied)
+	if err != nil {
+		return fmt.Errorf("getting stickied posts failed because of: %s", err.Error())
+	}
+	if stickied >= maxStickiedPosts {
+		return channel.ErrStickiedPostFull
+	}
+	_, err = repo.db.ExecContext(ctx, `INSERT INTO channel_stickies (post_id)
+							VALUES (?)
+							ON CONFLICT DO NOTHING`, postID)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			return channel.ErrPostNotFound
+		}
+		return fmt.Errorf("inserting into channel_stickies failed because of: %s", err.Error())
+	}
+	return nil
+}
+
+// DeleteStickiedPost deletes a stickied post from channel channelUsername
+func (repo *channelRepository) DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM channel_stickies
+							WHERE post_id = ?`, stickiedPostID)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from channel_stickie because of: %s", err.Error())
+	}
+	return nil
+}
+
+// AddPicture persists the given name as the image_name for the channel under the given username
+func (repo *channelRepository) AddPicture(ctx context.Context, channelUsername string, name string) (string, error) {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO channel_pictures (channelname, image_name)
+								VALUES (?1, ?2)
+								ON CONFLICT(channelname) DO UPDATE
+								SET image_name = ?2`, channelUsername, name)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			return "", channel.ErrChannelNotFound
+		}
+		return "", fmt.Errorf("inserting into channel_pictures failed because of: %v", err)
+	}
+	return name, nil
+}
+
+// RemovePicture removes the channel's tuple entry from the channel_pictures table.
+func (repo *channelRepository) RemovePicture(ctx context.Context, channelUsername string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM channel_pictures
+							WHERE channelname = ?`, channelUsername)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from channel_pictures failed because of: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/comment"
+)
+
+type commentRepository repository
+
+// NewCommentRepository returns a struct that implements the comment.Repository using
+// a SQLite database.
+// A database connection needs to be passed so that it can function.
+func NewCommentRepository(DB *sql.DB, allRepos *map[string]interface{}) comment.Repository {
+	return &commentRepository{&database{DB}, allRepos}
+}
+
+// AddComment persists the given struct into the database.
+func (repo commentRepository) AddComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
+	now := time.Now().UTC()
+	result, err := repo.db.ExecContext(ctx, `INSERT INTO comments (post_from, reply_to, content, commented_by, creation_time)
+				VALUES (?, ?, ?, ?, ?)`, c.OriginPost, c.ReplyTo, c.Content, c.Commenter, now)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = ?)`, c.OriginPost) {
+				return nil, comment.ErrPostNotFound
+			}
+			return nil, comment.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("insertion of comment failed because of: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("insertion of comment failed because of: %v", err)
+	}
+	c.ID = int(id)
+	c.CreationTime = now
+	return c, nil
+}
+
+// GetComment returns a comment.Comment under the given id from the database.
+func (repo commentRepository) GetComment(ctx context.Context, id int) (*comment.Comment, error) {
+	var c = new(comment.Comment)
+	err := repo.db.QueryRowContext(ctx, `SELECT post_from, commented_by, content, reply_to, creation_time
+				FROM comments
+				WHERE id = ?`, id).Scan(&c.OriginPost, &c.Commenter, &c.Content, &c.ReplyTo, &c.CreationTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, comment.ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("unable to get comment from db becaues: %v", err)
+	}
+	c.ID = id
+	return c, nil
+}
+
+// GetComments returns all comments in the database that match the given post
+// id.
+func (repo commentRepository) GetComments(ctx context.Context, postID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+	if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = ?)`, postID) {
+		return nil, comment.ErrPostNotFound
+	}
+	rows, err := repo.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, commented_by, content, reply_to, creation_time
+			FROM comments
+			WHERE post_from = ?
+			ORDER BY %s COLLATE NOCASE %s NULLS LAST, id
+			LIMIT ? OFFSET ?`, by, order), postID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying for comments failed because of: %v", err)
+	}
+	defer rows.Close()
+	var comments = make([]*comment.Comment, 0)
+	for rows.Next() {
+		c := new(comment.Comment)
+		err := rows.Scan(&c.ID, &c.Commenter, &c.Content, &c.ReplyTo, &c.CreationTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from row failed because: %v", err)
+		}
+		c.OriginPost = postID
+		comments = append(comments, c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	return comments, nil
+}
+
+// GetReplies returns all comments in the database that match the given reply_to
+// id.
+func (repo commentRepository) GetReplies(ctx context.Context, commentID int, by string, order string, limit, offset int) ([]*comment.Comment, error) {
+	if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM comments WHERE id = ?)`, commentID) {
+		return nil, comment.ErrCommentNotFound
+	}
+	rows, err := repo.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, commented_by, content, post_from, creation_time
+			FROM comments
+			WHERE reply_to = ?
+			ORDER BY %s COLLATE NOCASE %s NULLS LAST, id
+			LIMIT ? OFFSET ?`, by, order), commentID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying for comments failed because of: %v", err)
+	}
+	defer rows.Close()
+	var comments = make([]*comment.Comment, 0)
+	for rows.Next() {
+		c := new(comment.Comment)
+		err := rows.Scan(&c.ID, &c.Commenter, &c.Content, &c.OriginPost, &c.CreationTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from row failed because: %v", err)
+		}
+		c.ReplyTo = commentID
+		comments = append(comments, c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	return comments, nil
+}
+
+// UpdateComment updates a comment in the database according to the given struct.
+func (repo commentRepository) UpdateComment(ctx context.Context, c *comment.Comment) (*comment.Comment, error) {
+	if c.Content != "" {
+		_, err := repo.db.ExecContext(ctx, `UPDATE comments
+				SET content = ?
+				WHERE id = ?`, c.Content, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("was unable to update any data because of %v", err)
+		}
+	}
+	return repo.GetComment(ctx, c.ID)
+}
+
+// DeleteComment removes the comment under the given id from the database.
+func (repo commentRepository) DeleteComment(ctx context.Context, id int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM comments
+							WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deletion of comment failed because of: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlite_test
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/conformance"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite"
+	"github.com/slim-crown/issue-1-REST/pkg/repositories/sqlite/migrations"
+)
+
+// TestConformance runs the conformance checks each against a new database
+// file. It's skipped unless the tests are built with the sqlite_fts5 tag:
+//
+//	go test -tags sqlite_fts5 ./pkg/repositories/sqlite
+func TestConformance(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "issue1.db"))
+	if err == sqlite.ErrNoFTS5 {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	conformance.Run(t, func() (*conformance.Repositories, func(), error) {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "issue1.db"))
+		if err != nil {
+			return nil, nil, err
+		}
+		migrator, err := migrations.NewMigrator(db, log.New(io.Discard, "", 0))
+		if err == nil {
+			_, err = migrator.Up()
+		}
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+
+		dbRepos := make(map[string]interface{})
+		repos := &conformance.Repositories{
+			User:    sqlite.NewUserRepository(db, &dbRepos),
+			Channel: sqlite.NewChannelRepository(db, &dbRepos),
+			Feed:    sqlite.NewFeedRepository(db, &dbRepos),
+			Release: sqlite.NewReleaseRepository(db, &dbRepos),
+			Post:    sqlite.NewPostRepository(db, &dbRepos),
+			Comment: sqlite.NewCommentRepository(db, &dbRepos),
+			Search:  sqlite.NewSearchRepository(db, &dbRepos),
+			Auth:    sqlite.NewAuthRepository(db, &dbRepos),
+
+			UnitOfWork: sqlite.NewUnitOfWork(db),
+		}
+		repos.Register(dbRepos)
+		return repos, func() { db.Close() }, nil
+	})
+}
@@ -0,0 +1,178 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/feed"
+)
+
+// feedRepository ...
+type feedRepository repository
+
+// NewFeedRepository returns a new SQLite implementation of feed.Repository.
+// the database connection must be passed as the first argument
+// since for the repo to work.
+func NewFeedRepository(db *sql.DB, allRepos *map[string]interface{}) feed.Repository {
+	return &feedRepository{db: &database{db}, allRepos: allRepos}
+}
+
+// sortingOf is just a helper function that defaults to feed.SortTop for
+// sortings the feeds table doesn't know.
+func sortingOf(sorting feed.Sorting) feed.Sorting {
+	switch sorting {
+	case feed.SortHot, feed.SortNew:
+		return sorting
+	default:
+		return feed.SortTop
+	}
+}
+
+// AddFeed persists a feed entity to the DB according to the feed.Feed struct passed in.
+func (repo *feedRepository) AddFeed(ctx context.Context, f *feed.Feed) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO feeds (owner_username, sorting)
+										VALUES (?, ?)`, f.OwnerUsername, string(sortingOf(f.Sorting)))
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			return fmt.Errorf("insertion of user failed because of: %w", feed.ErrFeedNotFound)
+		}
+		return fmt.Errorf("insertion of user failed because of: %s", err.Error())
+	}
+	return nil
+}
+
+// GetFeed retrieve the feed entity in the database belonging to the user of the passed
+// in username.
+func (repo *feedRepository) GetFeed(ctx context.Context, username string) (*feed.Feed, error) {
+	f := feed.Feed{OwnerUsername: username}
+	var sorting string
+	err := repo.db.QueryRowContext(ctx, `SELECT id, sorting
+	 								FROM feeds
+	 								WHERE owner_username = ?
+	 								ORDER BY id
+	 								LIMIT 1`, username).Scan(&f.ID, &sorting)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, feed.ErrFeedNotFound
+		}
+		return nil, fmt.Errorf("unable to get feed from db becaues: %v", err)
+	}
+	f.Sorting = sortingOf(feed.Sorting(sorting))
+	return &f, nil
+}
+
+// GetChannels retrieves the all the channels the given feed has subscribed to.
+func (repo *feedRepository) GetChannels(ctx context.Context, f *feed.Feed, sortBy string, sortOrder string) ([]*feed.Channel, error) {
+	channelSubscriptions := make([]*feed.Channel, 0)
+	rows, err := repo.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT username, name, subscription_time
+		FROM feed_subscriptions INNER JOIN channels ON feed_subscriptions.channel_username = channels.username
+		WHERE feed_id = ?
+		ORDER BY %s COLLATE NOCASE %s NULLS LAST, username COLLATE NOCASE`, sortBy, sortOrder), f.ID)
+	if err != nil {
+		return nil, fmt.Errorf("querying for feed_subscriptions failed because of: %s", err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := new(feed.Channel)
+		err := rows.Scan(&c.Channelname, &c.Name, &c.SubscriptionTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %s", err.Error())
+		}
+		channelSubscriptions = append(channelSubscriptions, c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %s", err.Error())
+	}
+	return channelSubscriptions, nil
+}
+
+// GetPosts returns a list of posts collected from the channels
+// the given feed has subscribed to sorted according to the given
+// method. Ties are broken by recency.
+func (repo *feedRepository) GetPosts(ctx context.Context, f *feed.Feed, sort feed.Sorting, limit, offset int) ([]*feed.Post, error) {
+	var orderBy string
+	switch sort {
+	case feed.SortNew:
+		orderBy = `creation_time DESC, id DESC`
+	case feed.SortHot:
+		orderBy = `(SELECT NULLIF(COUNT(*), 0) FROM comments WHERE post_from = posts.id) DESC NULLS LAST, creation_time DESC, id DESC`
+	case feed.NotSet:
+		fallthrough
+	case feed.SortTop:
+		fallthrough
+	default:
+		orderBy = `(SELECT SUM(star_count) FROM post_stars WHERE post_id = posts.id) DESC NULLS LAST, creation_time DESC, id DESC`
+	}
+	rows, err := repo.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id
+		FROM posts
+		WHERE channel_from IN (SELECT channel_username FROM feed_subscriptions WHERE feed_id = ?)
+		ORDER BY %s
+		LIMIT ? OFFSET ?`, orderBy), f.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying for feed_subscriptions failed because of: %s", err.Error())
+	}
+	defer rows.Close()
+
+	var id int
+	posts := make([]*feed.Post, 0)
+
+	for rows.Next() {
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %s", err.Error())
+		}
+		posts = append(posts, &feed.Post{ID: id})
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %s", err.Error())
+	}
+
+	return posts, nil
+}
+
+// UpdateFeed updates the feed entity under the given id based on the passed in feed.Feed struct.
+func (repo *feedRepository) UpdateFeed(ctx context.Context, id uint, f *feed.Feed) error {
+	_, err := repo.db.ExecContext(ctx, `
+			UPDATE feeds
+			SET sorting = ?
+			WHERE id = ?`, string(sortingOf(f.Sorting)), id)
+	if err != nil {
+		return fmt.Errorf("updating failed of sorting column with %s because of: %w", f.Sorting, err)
+	}
+	return nil
+}
+
+// Subscribe adds the given channel to the list of channels that the feed collects posts from.
+func (repo *feedRepository) Subscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	_, err := repo.db.ExecContext(ctx, `
+		INSERT INTO feed_subscriptions (feed_id, channel_username)
+		VALUES (?, ?)
+		ON CONFLICT DO NOTHING
+		`, f.ID, channelname)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			return feed.ErrChannelNotFound
+		}
+		return fmt.Errorf("insertion of subscription failed because of: %s", err.Error())
+	}
+	return nil
+}
+
+// Unsubscribe removes the channel to the list of channels that the feed collects posts from.
+func (repo *feedRepository) Unsubscribe(ctx context.Context, f *feed.Feed, channelname string) error {
+	_, err := repo.db.ExecContext(ctx, `
+		DELETE FROM feed_subscriptions
+		WHERE feed_id = ? AND channel_username = ?`, f.ID, channelname)
+	if err != nil {
+		return fmt.Errorf("deletion of subscription failed because of: %s", err.Error())
+	}
+	return nil
+}
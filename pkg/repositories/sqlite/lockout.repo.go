@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/lockout"
+)
+
+type lockoutRepository repository
+
+// NewLockoutRepository returns a struct that implements the lockout.Repository using
+// a SQLite database. Attempts tracked here are shared by all processes using the file.
+// A database connection needs to be passed so that it can function.
+func NewLockoutRepository(DB *sql.DB, allRepos *map[string]interface{}) lockout.Repository {
+	return &lockoutRepository{&database{DB}, allRepos}
+}
+
+// GetAttempts retrieves the attempts recorded under the given key.
+func (repo *lockoutRepository) GetAttempts(ctx context.Context, key string) (*lockout.Attempts, error) {
+	a := &lockout.Attempts{Key: key}
+	var lockedUntil sql.NullTime
+	err := repo.db.QueryRowContext(ctx, `SELECT failures, last_failure, locked_until
+							FROM login_attempts
+							WHERE key = ?`, key).Scan(&a.Failures, &a.LastFailure, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return nil, fmt.Errorf("unable to get login attempts because of: %w", err)
+	}
+	a.LockedUntil = lockedUntil.Time
+	return a, nil
+}
+
+// AddFailure counts a failure under the given key. The count is updated and read
+// back in one transaction, which holds the write lock from the start, so that
+// concurrent failures aren't lost. Records that are past their window and
+// lockout are pruned on the way.
+func (repo *lockoutRepository) AddFailure(ctx context.Context, key string, at, resetBefore time.Time) (*lockout.Attempts, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("starting transaction failed because of: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `INSERT INTO login_attempts (key, failures, last_failure)
+							VALUES (?1, 1, ?2)
+							ON CONFLICT (key) DO UPDATE
+							SET failures = CASE WHEN login_attempts.last_failure < ?3 THEN 1 ELSE login_attempts.failures + 1 END,
+								last_failure = ?2`, key, at.UTC(), resetBefore.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("insertion into login_attempts failed because of: %w", err)
+	}
+	a := &lockout.Attempts{Key: key}
+	var lockedUntil sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT failures, last_failure, locked_until
+							FROM login_attempts
+							WHERE key = ?`, key).Scan(&a.Failures, &a.LastFailure, &lockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("insertion into login_attempts failed because of: %w", err)
+	}
+	a.LockedUntil = lockedUntil.Time
+	_, err = tx.ExecContext(ctx, `DELETE FROM login_attempts
+							WHERE last_failure < ? AND (locked_until IS NULL OR locked_until <= ?)`, resetBefore.UTC(), time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("pruning of login_attempts failed because of: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing transaction failed because of: %w", err)
+	}
+	return a, nil
+}
+
+// SetLockedUntil locks the given key until the given time.
+func (repo *lockoutRepository) SetLockedUntil(ctx context.Context, key string, lockedUntil time.Time) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE login_attempts
+							SET locked_until = ?
+							WHERE key = ?`, lockedUntil.UTC(), key)
+	if err != nil {
+		return fmt.Errorf("updating of login_attempts failed because of: %w", err)
+	}
+	return nil
+}
+
+// ResetAttempts removes the attempts recorded under the given key.
+func (repo *lockoutRepository) ResetAttempts(ctx context.Context, key string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM login_attempts
+							WHERE key = ?`, key)
+	if err != nil {
+		return fmt.Errorf("deletion from login_attempts failed because of: %w", err)
+	}
+	return nil
+}
+
+// AddEvent persists the given audit event.
+func (repo *lockoutRepository) AddEvent(ctx context.Context, e *lockout.Event) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO login_lockout_events (kind, key, failures, locked_until, creation_time)
+							VALUES (?, ?, ?, ?, ?)`,
+		string(e.Kind), e.Key, e.Failures, e.LockedUntil.UTC(), e.CreationTime.UTC())
+	if err != nil {
+		return fmt.Errorf("insertion into login_lockout_events failed because of: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+--
+-- Drops the domain tables along with their search tables and triggers.
+--
+
+DROP TABLE IF EXISTS releases_fts;
+DROP TABLE IF EXISTS comments_fts;
+DROP TABLE IF EXISTS posts_fts;
+DROP VIEW IF EXISTS release_documents;
+DROP TABLE IF EXISTS user_bookmarks;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS channel_stickies;
+DROP TABLE IF EXISTS channel_official_catalog;
+DROP TABLE IF EXISTS post_stars;
+DROP TABLE IF EXISTS post_contents;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS releases_text_based;
+DROP TABLE IF EXISTS releases_image_based;
+DROP TABLE IF EXISTS release_metadata;
+DROP TABLE IF EXISTS releases;
+DROP TABLE IF EXISTS feed_subscriptions;
+DROP TABLE IF EXISTS feeds;
+DROP TABLE IF EXISTS channel_pictures;
+DROP TABLE IF EXISTS channel_admins;
+DROP TABLE IF EXISTS channels;
+DROP TABLE IF EXISTS user_avatars;
+DROP TABLE IF EXISTS users_bio;
+DROP TABLE IF EXISTS users;
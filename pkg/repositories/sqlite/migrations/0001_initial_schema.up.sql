@@ -0,0 +1,336 @@
+--
+-- Initial schema of the domain tables, ported from the PostgreSQL one.
+-- Timestamps are kept as text in UTC so that they sort and compare as they should,
+-- the driver parses the columns declared TIMESTAMP back into time.Time.
+-- The tsvs_* tables are replaced by FTS5 tables kept up to date by triggers,
+-- the rowid of each being the id of the row it indexes.
+--
+
+CREATE TABLE users
+(
+    email         TEXT      NOT NULL COLLATE NOCASE UNIQUE,
+    username      TEXT      NOT NULL PRIMARY KEY,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    pass_hash     TEXT      NOT NULL,
+    first_name    TEXT,
+    middle_name   TEXT,
+    last_name     TEXT
+);
+
+CREATE TABLE users_bio
+(
+    username TEXT NOT NULL PRIMARY KEY REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    bio      TEXT NOT NULL
+);
+
+CREATE TABLE user_avatars
+(
+    username   TEXT NOT NULL PRIMARY KEY REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    image_name TEXT NOT NULL
+);
+
+CREATE TABLE channels
+(
+    username      TEXT      NOT NULL PRIMARY KEY,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    name          TEXT      NOT NULL,
+    description   TEXT
+);
+
+CREATE TABLE channel_admins
+(
+    channel_username TEXT    NOT NULL REFERENCES channels (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    username         TEXT    NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    is_owner         BOOLEAN NOT NULL,
+    PRIMARY KEY (channel_username, username)
+);
+
+CREATE TABLE channel_pictures
+(
+    channelname TEXT NOT NULL PRIMARY KEY REFERENCES channels (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    image_name  TEXT NOT NULL
+);
+
+CREATE TABLE feeds
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_username TEXT NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    sorting        TEXT NOT NULL
+);
+
+CREATE TABLE feed_subscriptions
+(
+    feed_id           INTEGER   NOT NULL REFERENCES feeds (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    channel_username  TEXT      NOT NULL REFERENCES channels (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    subscription_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (feed_id, channel_username)
+);
+
+CREATE TABLE releases
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_channel TEXT      NOT NULL REFERENCES channels (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    type          TEXT      NOT NULL,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE release_metadata
+(
+    release_id     INTEGER NOT NULL PRIMARY KEY REFERENCES releases (id) ON DELETE CASCADE,
+    description    TEXT,
+    other          TEXT CHECK (other IS NULL OR json_valid(other)),
+    genre_defining TEXT,
+    release_date   TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    title          TEXT
+);
+
+CREATE TABLE releases_image_based
+(
+    release_id INTEGER NOT NULL PRIMARY KEY REFERENCES releases (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    image_name TEXT    NOT NULL UNIQUE
+);
+
+CREATE TABLE releases_text_based
+(
+    release_id INTEGER NOT NULL PRIMARY KEY REFERENCES releases (id) ON DELETE CASCADE,
+    content    TEXT    NOT NULL
+);
+
+CREATE TABLE posts
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    description   TEXT,
+    title         TEXT      NOT NULL,
+    posted_by     TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE,
+    channel_from  TEXT      NOT NULL REFERENCES channels (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE post_contents
+(
+    post_id    INTEGER NOT NULL REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    release_id INTEGER NOT NULL REFERENCES releases (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (post_id, release_id)
+);
+
+CREATE TABLE post_stars
+(
+    star_count INTEGER NOT NULL,
+    post_id    INTEGER NOT NULL REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    username   TEXT    NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (username, post_id)
+);
+
+CREATE TABLE channel_official_catalog
+(
+    channel_username TEXT    NOT NULL REFERENCES channels (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    release_id       INTEGER NOT NULL REFERENCES releases (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    post_from_id     INTEGER NOT NULL REFERENCES posts (id),
+    PRIMARY KEY (channel_username, release_id)
+);
+
+CREATE TABLE channel_stickies
+(
+    post_id INTEGER NOT NULL PRIMARY KEY REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE comments
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_from     INTEGER   NOT NULL REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    reply_to      INTEGER   NOT NULL,
+    content       TEXT      NOT NULL,
+    commented_by  TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE user_bookmarks
+(
+    username      TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    post_id       INTEGER   NOT NULL REFERENCES posts (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (username, post_id)
+);
+
+CREATE INDEX channel_admins_username_index ON channel_admins (username);
+CREATE INDEX feeds_owner_username_index ON feeds (owner_username);
+CREATE INDEX releases_owner_channel_index ON releases (owner_channel);
+CREATE INDEX posts_channel_from_index ON posts (channel_from);
+CREATE INDEX posts_posted_by_index ON posts (posted_by);
+CREATE INDEX post_stars_post_id_index ON post_stars (post_id);
+CREATE INDEX comments_post_from_index ON comments (post_from);
+CREATE INDEX comments_reply_to_index ON comments (reply_to);
+
+--
+-- Every user gets a channel of their own, administered by them, and a feed.
+--
+
+CREATE TRIGGER setup_user
+    AFTER INSERT
+    ON users
+BEGIN
+    INSERT INTO channels (username, name)
+    VALUES (new.username, new.username || '''s channel');
+    INSERT INTO channel_admins (channel_username, username, is_owner)
+    VALUES (new.username, new.username, 1);
+    INSERT INTO feeds (owner_username, sorting)
+    VALUES (new.username, 'hot');
+END;
+
+--
+-- Full text search. Columns are weighted at query time through bm25(), the weights
+-- mirror the ones the tsvectors were built with.
+--
+
+CREATE VIRTUAL TABLE posts_fts USING fts5
+(
+    title,
+    description,
+    posted_by,
+    channel_from,
+    tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER posts_fts_insert
+    AFTER INSERT
+    ON posts
+BEGIN
+    INSERT INTO posts_fts (rowid, title, description, posted_by, channel_from)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), new.posted_by, new.channel_from);
+END;
+
+CREATE TRIGGER posts_fts_update
+    AFTER UPDATE
+    ON posts
+BEGIN
+    DELETE FROM posts_fts WHERE rowid = old.id;
+    INSERT INTO posts_fts (rowid, title, description, posted_by, channel_from)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), new.posted_by, new.channel_from);
+END;
+
+CREATE TRIGGER posts_fts_delete
+    AFTER DELETE
+    ON posts
+BEGIN
+    DELETE FROM posts_fts WHERE rowid = old.id;
+END;
+
+CREATE VIRTUAL TABLE comments_fts USING fts5
+(
+    content,
+    commented_by,
+    tokenize = 'porter unicode61'
+);
+
+CREATE TRIGGER comments_fts_insert
+    AFTER INSERT
+    ON comments
+BEGIN
+    INSERT INTO comments_fts (rowid, content, commented_by)
+    VALUES (new.id, new.content, new.commented_by);
+END;
+
+CREATE TRIGGER comments_fts_update
+    AFTER UPDATE
+    ON comments
+BEGIN
+    DELETE FROM comments_fts WHERE rowid = old.id;
+    INSERT INTO comments_fts (rowid, content, commented_by)
+    VALUES (new.id, new.content, new.commented_by);
+END;
+
+CREATE TRIGGER comments_fts_delete
+    AFTER DELETE
+    ON comments
+BEGIN
+    DELETE FROM comments_fts WHERE rowid = old.id;
+END;
+
+CREATE VIRTUAL TABLE releases_fts USING fts5
+(
+    type,
+    owner_channel,
+    title,
+    genre_defining,
+    description,
+    other,
+    content,
+    tokenize = 'porter unicode61'
+);
+
+-- release_documents holds what's indexed for each release, spread over three
+-- tables. The text values found in the other metadata are indexed, not its keys.
+CREATE VIEW release_documents AS
+SELECT r.id                                   AS id,
+       r.type                                 AS type,
+       r.owner_channel                        AS owner_channel,
+       COALESCE(m.title, '')                  AS title,
+       COALESCE(m.genre_defining, '')         AS genre_defining,
+       COALESCE(m.description, '')            AS description,
+       COALESCE((SELECT group_concat(j.value, ' ')
+                 FROM json_tree(m.other) AS j
+                 WHERE j.type = 'text'), '') AS other,
+       COALESCE(t.content, '')                AS content
+FROM releases AS r
+         LEFT JOIN release_metadata AS m ON m.release_id = r.id
+         LEFT JOIN releases_text_based AS t ON t.release_id = r.id;
+
+CREATE TRIGGER releases_fts_insert
+    AFTER INSERT
+    ON releases
+BEGIN
+    INSERT INTO releases_fts (rowid, type, owner_channel, title, genre_defining, description, other, content)
+    SELECT * FROM release_documents WHERE id = new.id;
+END;
+
+CREATE TRIGGER releases_fts_update
+    AFTER UPDATE
+    ON releases
+BEGIN
+    DELETE FROM releases_fts WHERE rowid = old.id;
+    INSERT INTO releases_fts (rowid, type, owner_channel, title, genre_defining, description, other, content)
+    SELECT * FROM release_documents WHERE id = new.id;
+END;
+
+CREATE TRIGGER releases_fts_delete
+    AFTER DELETE
+    ON releases
+BEGIN
+    DELETE FROM releases_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER release_metadata_fts_insert
+    AFTER INSERT
+    ON release_metadata
+BEGIN
+    DELETE FROM releases_fts WHERE rowid = new.release_id;
+    INSERT INTO releases_fts (rowid, type, owner_channel, title, genre_defining, description, other, content)
+    SELECT * FROM release_documents WHERE id = new.release_id;
+END;
+
+CREATE TRIGGER release_metadata_fts_update
+    AFTER UPDATE
+    ON release_metadata
+BEGIN
+    DELETE FROM releases_fts WHERE rowid = new.release_id;
+    INSERT INTO releases_fts (rowid, type, owner_channel, title, genre_defining, description, other, content)
+    SELECT * FROM release_documents WHERE id = new.release_id;
+END;
+
+CREATE TRIGGER releases_text_based_fts_insert
+    AFTER INSERT
+    ON releases_text_based
+BEGIN
+    DELETE FROM releases_fts WHERE rowid = new.release_id;
+    INSERT INTO releases_fts (rowid, type, owner_channel, title, genre_defining, description, other, content)
+    SELECT * FROM release_documents WHERE id = new.release_id;
+END;
+
+CREATE TRIGGER releases_text_based_fts_update
+    AFTER UPDATE
+    ON releases_text_based
+BEGIN
+    DELETE FROM releases_fts WHERE rowid = new.release_id;
+    INSERT INTO releases_fts (rowid, type, owner_channel, title, genre_defining, description, other, content)
+    SELECT * FROM release_documents WHERE id = new.release_id;
+END;
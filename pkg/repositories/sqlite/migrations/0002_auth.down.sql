@@ -0,0 +1,17 @@
+--
+-- Drops the tables used by the auth service and the login lockout.
+--
+
+DROP TABLE IF EXISTS login_lockout_events;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS totp_recovery_codes;
+DROP TABLE IF EXISTS totp_secrets;
+DROP TABLE IF EXISTS email_verification_tokens;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS token_blacklist;
+
+ALTER TABLE users
+    DROP COLUMN verified;
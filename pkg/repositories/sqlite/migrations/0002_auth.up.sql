@@ -0,0 +1,119 @@
+--
+-- Tables used by the auth service and the login lockout.
+-- The text[] columns of the PostgreSQL schema are kept as JSON arrays.
+--
+
+ALTER TABLE users
+    ADD COLUMN verified BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE TABLE token_blacklist
+(
+    token_id   TEXT      NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX token_blacklist_expires_at_index ON token_blacklist (expires_at);
+
+CREATE TABLE refresh_tokens
+(
+    token_hash      TEXT      NOT NULL PRIMARY KEY,
+    family_id       TEXT      NOT NULL,
+    username        TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    access_token_id TEXT,
+    used            BOOLEAN   NOT NULL DEFAULT 0,
+    revoked         BOOLEAN   NOT NULL DEFAULT 0,
+    creation_time   TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX refresh_tokens_family_id_index ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_username_index ON refresh_tokens (username);
+
+CREATE TABLE password_reset_tokens
+(
+    token_hash    TEXT      NOT NULL PRIMARY KEY,
+    username      TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    used          BOOLEAN   NOT NULL DEFAULT 0,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX password_reset_tokens_username_index ON password_reset_tokens (username);
+
+CREATE TABLE email_verification_tokens
+(
+    token_hash    TEXT      NOT NULL PRIMARY KEY,
+    username      TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    email         TEXT      NOT NULL COLLATE NOCASE,
+    used          BOOLEAN   NOT NULL DEFAULT 0,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX email_verification_tokens_username_index ON email_verification_tokens (username);
+
+CREATE TABLE totp_secrets
+(
+    username       TEXT      NOT NULL PRIMARY KEY REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    secret         TEXT      NOT NULL,
+    confirmed      BOOLEAN   NOT NULL DEFAULT 0,
+    last_used_step INTEGER   NOT NULL DEFAULT 0,
+    creation_time  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE totp_recovery_codes
+(
+    username  TEXT    NOT NULL REFERENCES totp_secrets (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    code_hash TEXT    NOT NULL,
+    used      BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (username, code_hash)
+);
+
+CREATE TABLE personal_access_tokens
+(
+    id             TEXT      NOT NULL PRIMARY KEY,
+    token_hash     TEXT      NOT NULL UNIQUE,
+    username       TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    name           TEXT      NOT NULL,
+    scopes         TEXT      NOT NULL CHECK (json_valid(scopes)),
+    creation_time  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at     TIMESTAMP,
+    last_used_time TIMESTAMP,
+    revoked        BOOLEAN   NOT NULL DEFAULT 0
+);
+
+CREATE INDEX personal_access_tokens_username_index ON personal_access_tokens (username);
+
+CREATE TABLE sessions
+(
+    id             TEXT      NOT NULL PRIMARY KEY,
+    username       TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_agent     TEXT,
+    ip_address     TEXT,
+    creation_time  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_used_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at     TIMESTAMP NOT NULL,
+    revoked        BOOLEAN   NOT NULL DEFAULT 0,
+    client_id      TEXT,
+    scopes         TEXT      NOT NULL DEFAULT '["*"]' CHECK (json_valid(scopes))
+);
+
+CREATE INDEX sessions_username_index ON sessions (username);
+
+CREATE TABLE login_attempts
+(
+    key          TEXT      NOT NULL PRIMARY KEY,
+    failures     INTEGER   NOT NULL DEFAULT 0,
+    last_failure TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE login_lockout_events
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind          TEXT      NOT NULL,
+    key           TEXT      NOT NULL,
+    failures      INTEGER   NOT NULL,
+    locked_until  TIMESTAMP NOT NULL,
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
@@ -0,0 +1,7 @@
+--
+-- Drops the tables used by the oauth service.
+--
+
+DROP TABLE IF EXISTS oauth_authorization_codes;
+DROP TABLE IF EXISTS oauth_consents;
+DROP TABLE IF EXISTS oauth_clients;
@@ -0,0 +1,40 @@
+--
+-- Tables used by the oauth service.
+--
+
+CREATE TABLE oauth_clients
+(
+    id             TEXT      NOT NULL PRIMARY KEY,
+    secret_hash    TEXT,
+    name           TEXT      NOT NULL,
+    redirect_uris  TEXT      NOT NULL CHECK (json_valid(redirect_uris)),
+    confidential   BOOLEAN   NOT NULL DEFAULT 0,
+    owner_username TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    creation_time  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX oauth_clients_owner_username_index ON oauth_clients (owner_username);
+
+CREATE TABLE oauth_consents
+(
+    username      TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    client_id     TEXT      NOT NULL REFERENCES oauth_clients (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    scopes        TEXT      NOT NULL CHECK (json_valid(scopes)),
+    creation_time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (username, client_id)
+);
+
+CREATE TABLE oauth_authorization_codes
+(
+    code_hash             TEXT      NOT NULL PRIMARY KEY,
+    client_id             TEXT      NOT NULL REFERENCES oauth_clients (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    username              TEXT      NOT NULL REFERENCES users (username) ON UPDATE CASCADE ON DELETE CASCADE,
+    redirect_uri          TEXT      NOT NULL,
+    scopes                TEXT      NOT NULL CHECK (json_valid(scopes)),
+    code_challenge        TEXT      NOT NULL,
+    code_challenge_method TEXT      NOT NULL,
+    nonce                 TEXT,
+    used                  BOOLEAN   NOT NULL DEFAULT 0,
+    creation_time         TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at            TIMESTAMP NOT NULL
+);
@@ -0,0 +1,182 @@
+/*
+Package migrations contains the versioned schema of the SQLite database and
+the logic to apply it. Migrations are embedded in the binary as pairs of
+NNNN_name.up.sql and NNNN_name.down.sql files, named and read the same way as
+the PostgreSQL ones, and the versions applied are recorded in the
+schema_migrations table.
+*/
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"time"
+
+	pgmigrations "github.com/slim-crown/issue-1-REST/pkg/repositories/postgres/migrations"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is a single versioned change to the schema.
+type Migration = pgmigrations.Migration
+
+// Status tells whether a migration has been applied and when.
+type Status = pgmigrations.Status
+
+// ErrNoDownMigration is returned when rolling back a migration that can't be rolled back.
+var ErrNoDownMigration = pgmigrations.ErrNoDownMigration
+
+// Migrator applies the embedded migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	logger     *log.Logger
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for the given database.
+func NewMigrator(db *sql.DB, logger *log.Logger) (*Migrator, error) {
+	migrations, err := pgmigrations.Read(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, logger: logger, migrations: migrations}, nil
+}
+
+// Up applies all the migrations that haven't been applied yet, in order.
+// Each migration is applied in its own transaction along with its record so
+// that a failing migration leaves the schema as it was before it.
+// It returns the number of migrations applied.
+func (m *Migrator) Up() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, migration := range m.migrations {
+		done, err := m.inTransaction(func(tx *sql.Tx) (bool, error) {
+			if ok, err := isApplied(tx, migration.Version); err != nil || ok {
+				return false, err
+			}
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return false, err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				migration.Version, migration.Name, time.Now().UTC())
+			return err == nil, err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("applying migration %04d_%s failed because of: %w", migration.Version, migration.Name, err)
+		}
+		if done {
+			applied++
+			m.logger.Printf("applied migration %04d_%s", migration.Version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+// Down rolls back the given number of the most recently applied migrations.
+// It returns the number of migrations rolled back.
+func (m *Migrator) Down(steps int) (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+	rolledBack := 0
+	for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+		migration := m.migrations[i]
+		done, err := m.inTransaction(func(tx *sql.Tx) (bool, error) {
+			if ok, err := isApplied(tx, migration.Version); err != nil || !ok {
+				return false, err
+			}
+			if migration.Down == "" {
+				return false, ErrNoDownMigration
+			}
+			if _, err := tx.Exec(migration.Down); err != nil {
+				return false, err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+			return err == nil, err
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rolling back migration %04d_%s failed because of: %w", migration.Version, migration.Name, err)
+		}
+		if done {
+			rolledBack++
+			m.logger.Printf("rolled back migration %04d_%s", migration.Version, migration.Name)
+		}
+	}
+	return rolledBack, nil
+}
+
+// Status returns the state of each of the migrations, in order.
+func (m *Migrator) Status() ([]*Status, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("querying for schema_migrations failed because of: %w", err)
+	}
+	defer rows.Close()
+	appliedAt := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var t time.Time
+		if err := rows.Scan(&version, &t); err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %w", err)
+		}
+		appliedAt[version] = t
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %w", err)
+	}
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &Status{Version: migration.Version, Name: migration.Name}
+		if t, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ensureTable is a helper function that creates the schema_migrations table if
+// it doesn't exist.
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+							version    INTEGER   NOT NULL PRIMARY KEY,
+							name       TEXT      NOT NULL,
+							applied_at TIMESTAMP NOT NULL
+						)`)
+	if err != nil {
+		return fmt.Errorf("creating schema_migrations failed because of: %w", err)
+	}
+	return nil
+}
+
+// inTransaction is a helper function that runs fn in a transaction, committing
+// it if fn succeeds. Connections are opened with _txlock=immediate so the
+// transaction holds the write lock from the start, two instances migrating
+// the same file wait on each other instead of applying a migration twice.
+func (m *Migrator) inTransaction(fn func(tx *sql.Tx) (bool, error)) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	done, err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return done, tx.Commit()
+}
+
+// isApplied is a helper function that checks whether the migration of the given version has been applied.
+func isApplied(tx *sql.Tx, version int) (bool, error) {
+	var applied bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`, version).Scan(&applied)
+	return applied, err
+}
@@ -0,0 +1,323 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/post"
+)
+
+type postRepository repository
+
+// NewPostRepository returns a struct that implements the post.Repository using
+// a SQLite database.
+func NewPostRepository(DB *sql.DB, allRepos *map[string]interface{}) post.Repository {
+	return &postRepository{&database{DB}, allRepos}
+}
+
+// GetPost gets the Post stored under the given id.
+func (repo *postRepository) GetPost(ctx context.Context, id uint) (*post.Post, error) {
+	var p = new(post.Post)
+	err := repo.db.QueryRowContext(ctx, `
+								SELECT posted_by, channel_from, title, COALESCE(description, ''), creation_time
+								FROM posts
+								WHERE posts.id = ?`, id).Scan(&p.PostedByUsername, &p.OriginChannel, &p.Title, &p.Description, &p.CreationTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, post.ErrPostNotFound
+		}
+		return nil, fmt.Errorf("unable to get post from db because of: %v", err)
+	}
+	p.ID = id
+	if err = repo.getRelations(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// getRelations is just a helper function that fills in the contents, comments
+// and stars of the given post.
+func (repo *postRepository) getRelations(ctx context.Context, p *post.Post) error {
+	p.ContentsID = []uint{}
+	p.CommentsID = []int{}
+	p.Stars = make(map[string]uint)
+
+	rows, err := repo.db.QueryContext(ctx, `SELECT release_id
+								FROM post_contents
+								WHERE post_id = ?
+								ORDER BY rowid`, p.ID)
+	if err != nil {
+		return fmt.Errorf("querying for post contents failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var releaseID uint
+		if err := rows.Scan(&releaseID); err != nil {
+			return fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		p.ContentsID = append(p.ContentsID, releaseID)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	rows.Close()
+
+	rows, err = repo.db.QueryContext(ctx, `SELECT id
+								FROM comments
+								WHERE post_from = ?
+								ORDER BY id`, p.ID)
+	if err != nil {
+		return fmt.Errorf("querying for post comments failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var commentID int
+		if err := rows.Scan(&commentID); err != nil {
+			return fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		p.CommentsID = append(p.CommentsID, commentID)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	rows.Close()
+
+	rows, err = repo.db.QueryContext(ctx, `SELECT username, star_count
+								FROM post_stars
+								WHERE post_id = ?`, p.ID)
+	if err != nil {
+		return fmt.Errorf("querying for star list failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var username string
+		var starCount uint
+		if err := rows.Scan(&username, &starCount); err != nil {
+			return fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		p.Stars[username] = starCount
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	return nil
+}
+
+// DeletePost Deletes the Post stored under the given id.
+func (repo *postRepository) DeletePost(ctx context.Context, id uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM posts
+							WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deletion of post failed because of: %v", err)
+	}
+	return nil
+}
+
+// AddPost Adds the Post stored under its id from given post struct, all or nothing.
+func (repo *postRepository) AddPost(ctx context.Context, p *post.Post) (*post.Post, error) {
+	var added *post.Post
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		result, err := repo.db.ExecContext(ctx, `INSERT INTO posts (posted_by, channel_from, title, description)
+				VALUES (?, ?, ?, NULLIF(?, ''))`, p.PostedByUsername, p.OriginChannel, p.Title, p.Description)
+		if err != nil {
+			return post.ErrSomePostDataNotPersisted
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return post.ErrSomePostDataNotPersisted
+		}
+		p.ID = uint(id)
+		p.PostedByUsername = ""
+		p.OriginChannel = ""
+		p.Title = ""
+		p.Description = ""
+		added, err = repo.UpdatePost(ctx, p, p.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// UpdatePost updates the post with given id and post struct, all or nothing.
+// The contents given replace the ones the post has.
+func (repo *postRepository) UpdatePost(ctx context.Context, pos *post.Post, id uint) (*post.Post, error) {
+	if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM posts WHERE id = ?)`, id) {
+		return nil, post.ErrPostNotFound
+	}
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		if pos.PostedByUsername != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "posted_by", pos.PostedByUsername, id)
+			if err != nil {
+				return err
+			}
+		}
+		if pos.OriginChannel != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "channel_from", pos.OriginChannel, id)
+			if err != nil {
+				return err
+			}
+		}
+		if pos.Title != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "title", pos.Title, id)
+			if err != nil {
+				return err
+			}
+		}
+		if pos.Description != "" {
+			err := repo.execUpdateStatementOnColumnIntoPost(ctx, "description", pos.Description, id)
+			if err != nil {
+				return err
+			}
+		}
+		if len(pos.ContentsID) != 0 {
+			return repo.replaceContents(ctx, pos.ContentsID, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, post.ErrSomePostDataNotPersisted
+	}
+	return repo.GetPost(ctx, id)
+}
+
+func (repo *postRepository) execUpdateStatementOnColumnIntoPost(ctx context.Context, column string, value string, id uint) error {
+	query := fmt.Sprintf(`UPDATE posts
+								SET %s = ?
+								WHERE id = ?`, column)
+	_, err := repo.db.ExecContext(ctx, query, value, id)
+	if err != nil {
+		return fmt.Errorf("updating failed of %s column with %s because of: %v", column, value, err)
+	}
+	return nil
+}
+
+// replaceContents is just a helper function that replaces the contents of the
+// post with the given releases. It fails if one of the releases doesn't exist.
+func (repo *postRepository) replaceContents(ctx context.Context, releaseIDs []uint, id uint) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM post_contents
+							WHERE post_id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deletion of post contents failed because of: %v", err)
+	}
+	for _, releaseID := range releaseIDs {
+		_, err := repo.db.ExecContext(ctx, `INSERT INTO post_contents (post_id, release_id)
+								VALUES (?, ?)
+								ON CONFLICT DO NOTHING`, id, releaseID)
+		if err != nil {
+			if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+				err = fmt.Errorf("release doesn't exist")
+			}
+			return fmt.Errorf("updating failed of release_id column with %d because of: %v", releaseID, err)
+		}
+	}
+	return nil
+}
+
+// SearchPost gets all Posts under specfications. Matches are ordered by
+// relevance first, bm25 scores are lower the better the match. The weights of
+// the columns are in the order of the columns of posts_fts.
+func (repo *postRepository) SearchPost(ctx context.Context, pattern string, by post.SortBy, order post.SortOrder, limit int, offset int) ([]*post.Post, error) {
+	var posts = make([]*post.Post, 0)
+	var err error
+	var rows *sql.Rows
+	const columns = `posts.id, posts.posted_by, posts.channel_from, posts.title, COALESCE(posts.description, ''), posts.creation_time`
+	if pattern == "" {
+		if by == "" {
+			by = post.SortByCreationTime
+		}
+		query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts
+		ORDER BY %s COLLATE NOCASE %s NULLS LAST, id
+		LIMIT ? OFFSET ?`, columns, by, order)
+		rows, err = repo.db.QueryContext(ctx, query, limit, offset)
+	} else {
+		match := matchQuery(pattern)
+		if match == "" {
+			return posts, nil
+		}
+		query := fmt.Sprintf(`
+		SELECT %s
+		FROM posts_fts INNER JOIN posts ON posts.id = posts_fts.rowid
+		WHERE posts_fts MATCH ?1
+		ORDER BY bm25(posts_fts, 1.0, 0.1, 0.4, 0.4)`, columns)
+		if by != "" {
+			query = fmt.Sprintf(`%s, posts.%s COLLATE NOCASE %s NULLS LAST`, query, by, order)
+		}
+		query = fmt.Sprintf(`%s, posts.id
+		  LIMIT ?2 OFFSET ?3`, query)
+		rows, err = repo.db.QueryContext(ctx, query, match, limit, offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying for posts failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p := post.Post{}
+		err := rows.Scan(&p.ID, &p.PostedByUsername, &p.OriginChannel, &p.Title, &p.Description, &p.CreationTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		posts = append(posts, &p)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	rows.Close()
+
+	for _, p := range posts {
+		if err := repo.getRelations(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
+
+// GetPostStar gets the star stored under the given postid and username.
+func (repo *postRepository) GetPostStar(ctx context.Context, id uint, username string) (*post.Star, error) {
+	s := post.Star{}
+	err := repo.db.QueryRowContext(ctx, `SELECT username, star_count
+								FROM post_stars
+								WHERE post_id = ? AND username = ?`, id, username).Scan(&s.Username, &s.NumOfStars)
+	if err != nil {
+		return nil, post.ErrStarNotFound
+	}
+	return &s, nil
+}
+
+// DeletePostStar deletes the star stored under given postid and username
+func (repo *postRepository) DeletePostStar(ctx context.Context, id uint, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM post_stars
+							WHERE post_id = ? AND username = ?`, id, username)
+	if err != nil {
+		return post.ErrStarNotFound
+	}
+	return nil
+}
+
+// AddPostStar adds a star given postid, number of stars and username
+func (repo *postRepository) AddPostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO post_stars (post_id, username, star_count)
+				VALUES (?, ?, ?)`, id, star.Username, star.NumOfStars)
+	if err != nil {
+		return nil, post.ErrStarNotFound
+	}
+	return repo.GetPostStar(ctx, id, star.Username)
+}
+
+// UpdatePostStar updates a star stored given postid, number of stars and username
+func (repo *postRepository) UpdatePostStar(ctx context.Context, id uint, star *post.Star) (*post.Star, error) {
+	_, err := repo.db.ExecContext(ctx, `UPDATE post_stars
+								SET star_count = ?
+								WHERE post_id = ? AND username = ?`, star.NumOfStars, id, star.Username)
+	if err != nil {
+		return nil, post.ErrStarNotFound
+	}
+	return repo.GetPostStar(ctx, id, star.Username)
+}
@@ -0,0 +1,310 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/release"
+)
+
+type releaseRepository repository
+
+// NewReleaseRepository returns a struct that implements the release.Repository using
+// a SQLite database.
+// A database connection needs to be passed so that it can function.
+func NewReleaseRepository(db *sql.DB, allRepos *map[string]interface{}) release.Repository {
+	return &releaseRepository{db: &database{db}, allRepos: allRepos}
+}
+
+// GetRelease returns a release.Release under the given id from the database.
+func (repo releaseRepository) GetRelease(ctx context.Context, id int) (*release.Release, error) {
+	var err error
+	var r = new(release.Release)
+
+	var typeString string
+	query := `SELECT type, owner_channel, creation_time
+				FROM releases
+				WHERE id = ?`
+	err = repo.db.QueryRowContext(ctx, query, id).Scan(&typeString, &r.OwnerChannel, &r.CreationTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, release.ErrReleaseNotFound
+		}
+		return nil, fmt.Errorf("unable to get release from db becaues: %v", err)
+	}
+	r.Type = release.Type(typeString)
+	content, err := repo.getContent(ctx, id, r.Type)
+	if err != nil {
+		return nil, err
+	}
+	r.Content = content
+
+	metadata, err := repo.getMetadata(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.Metadata = *metadata
+
+	r.ID = id
+	return r, nil
+}
+
+// SearchRelease searches the database for the releases in official catalogs that
+// satisfy the given arguments. Matches are ordered by relevance first, bm25
+// scores are lower the better the match. The weights of the columns are in the
+// order of the columns of releases_fts.
+func (repo releaseRepository) SearchRelease(ctx context.Context, pattern string, by release.SortBy, order release.SortOrder, limit int, offset int) ([]*release.Release, error) {
+	var releases = make([]*release.Release, 0)
+	var err error
+	var rows *sql.Rows
+	const columns = `r.id, r.owner_channel, COALESCE(ri.image_name, rt.content, ''), r.type, r.creation_time`
+	const contents = `LEFT JOIN releases_image_based ri ON r.id = ri.release_id
+					LEFT JOIN releases_text_based rt ON r.id = rt.release_id`
+	if pattern == "" {
+		if by == "" {
+			by = release.SortCreationTime
+		}
+		query := fmt.Sprintf(`
+				SELECT %s
+				FROM releases r %s
+				WHERE r.id IN (SELECT release_id FROM channel_official_catalog)
+				ORDER BY r.%s COLLATE NOCASE %s NULLS LAST, r.id
+				LIMIT ? OFFSET ?`, columns, contents, by, order)
+		rows, err = repo.db.QueryContext(ctx, query, limit, offset)
+	} else {
+		match := matchQuery(pattern)
+		if match == "" {
+			return releases, nil
+		}
+		query := fmt.Sprintf(`
+				SELECT %s
+				FROM releases_fts INNER JOIN releases r ON r.id = releases_fts.rowid %s
+				WHERE releases_fts MATCH ?1 AND r.id IN (SELECT release_id FROM channel_official_catalog)
+				ORDER BY bm25(releases_fts, 1.0, 0.4, 1.0, 0.4, 0.1, 0.2, 0.1)`, columns, contents)
+		if by != "" {
+			query = fmt.Sprintf(`%s, r.%s COLLATE NOCASE %s NULLS LAST`, query, by, order)
+		}
+		query = fmt.Sprintf(`%s, r.id
+				LIMIT ?2 OFFSET ?3`, query)
+		rows, err = repo.db.QueryContext(ctx, query, match, limit, offset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying for releases failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := new(release.Release)
+		err := rows.Scan(&r.ID, &r.OwnerChannel, &r.Content, &r.Type, &r.CreationTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		releases = append(releases, r)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	rows.Close()
+
+	for _, r := range releases {
+		metadata, err := repo.getMetadata(ctx, r.ID)
+		if err != nil {
+			return nil, err
+		}
+		r.Metadata = *metadata
+	}
+	return releases, nil
+}
+
+// DeleteRelease removes the release under the given id from the database.
+func (repo releaseRepository) DeleteRelease(ctx context.Context, id int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM releases
+							WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deletion of release failed because: %v", err)
+	}
+	return nil
+}
+
+// AddRelease persists the given struct into the database, all or nothing.
+func (repo releaseRepository) AddRelease(ctx context.Context, r *release.Release) (*release.Release, error) {
+	var added *release.Release
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		result, err := repo.db.ExecContext(ctx, `INSERT INTO releases (owner_channel, type)
+				VALUES (?, ?)`, r.OwnerChannel, string(r.Type))
+		if err != nil {
+			if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+				return fmt.Errorf("insertion of release failed because of: %w", release.ErrInvalidReleaseData)
+			}
+			return fmt.Errorf("insertion of release failed because of: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("insertion of release failed because of: %v", err)
+		}
+		r.ID = int(id)
+		r.OwnerChannel = ""
+		added, err = repo.UpdateRelease(ctx, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// UpdateRelease updates a release in the database according to the given struct, all or nothing.
+// Only the non empty fields of the struct are updated, save for Other which is always replaced.
+func (repo releaseRepository) UpdateRelease(ctx context.Context, rel *release.Release) (*release.Release, error) {
+	if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM releases WHERE id = ?)`, rel.ID) {
+		return nil, release.ErrReleaseNotFound
+	}
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		// Checks if value is to be updated before attempting.
+		// This way, there won't be columns with Go's zero string value of "" instead of null
+		if rel.OwnerChannel != "" {
+			err := repo.execUpdateStatementOnColumnIntoReleases(ctx, "owner_channel", rel.OwnerChannel, rel.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if rel.Content != "" && rel.Type != "" {
+			err := repo.execUpdateStatementForContent(ctx, rel.Type, rel.Content, rel.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if !rel.ReleaseDate.IsZero() {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "release_date", rel.ReleaseDate.UTC(), rel.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if rel.Title != "" {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "title", rel.Title, rel.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if rel.GenreDefining != "" {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "genre_defining", rel.GenreDefining, rel.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if rel.Description != "" {
+			err := repo.execUpdateStatementOnColumnIntoMetadata(ctx, "description", rel.Description, rel.ID)
+			if err != nil {
+				return err
+			}
+		}
+		otherJSONRaw, err := json.Marshal(rel.Other)
+		if err != nil {
+			return err
+		}
+		return repo.execUpdateStatementOnColumnIntoMetadata(ctx, "other", string(otherJSONRaw), rel.ID)
+	})
+	if err != nil {
+		return nil, release.ErrSomeReleaseDataNotPersisted
+	}
+	return repo.GetRelease(ctx, rel.ID)
+}
+
+func (repo releaseRepository) execUpdateStatementOnColumnIntoReleases(ctx context.Context, column, value string, id int) error {
+	query := fmt.Sprintf(`UPDATE releases
+								SET %s = ?
+								WHERE id = ?`, column)
+	_, err := repo.db.ExecContext(ctx, query, value, id)
+	if err != nil {
+		return fmt.Errorf("updating failed of %s column with %s because of: %v", column, value, err)
+	}
+	return nil
+}
+
+func (repo releaseRepository) execUpdateStatementOnColumnIntoMetadata(ctx context.Context, column string, value interface{}, id int) error {
+	query := fmt.Sprintf(`INSERT INTO release_metadata (release_id, %s)
+								VALUES (?1, ?2)
+								ON CONFLICT(release_id) DO UPDATE
+								SET %s = ?2`, column, column)
+	_, err := repo.db.ExecContext(ctx, query, id, value)
+	if err != nil {
+		return fmt.Errorf("upsertion failed of %s column to metadat with %s because of: %v", column, value, err)
+	}
+	return nil
+}
+
+func (repo releaseRepository) execUpdateStatementForContent(ctx context.Context, t release.Type, value string, id int) error {
+	var query string
+	if t == release.Image {
+		query = `INSERT INTO releases_image_based (release_id, image_name)
+				VALUES (?1, ?2)
+				ON CONFLICT(release_id) DO UPDATE
+				SET image_name = ?2`
+	} else {
+		query = `INSERT INTO releases_text_based (release_id, content)
+				VALUES (?1, ?2)
+				ON CONFLICT(release_id) DO UPDATE
+				SET content = ?2`
+	}
+	_, err := repo.db.ExecContext(ctx, query, id, value)
+	if err != nil {
+		return fmt.Errorf("upserting failed of %s type with %s because of: %v", string(t), value, err)
+	}
+	return nil
+}
+
+func (repo releaseRepository) getContent(ctx context.Context, id int, t release.Type) (string, error) {
+	var content, query string
+	if t == release.Image {
+		query = `SELECT COALESCE(image_name, '')
+				FROM releases_image_based
+				WHERE release_id = ?`
+	} else {
+		query = `SELECT COALESCE(content, '')
+				FROM releases_text_based
+				WHERE release_id = ?`
+	}
+	err := repo.db.QueryRowContext(ctx, query, id).Scan(&content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to get release content because: %v", err)
+	}
+	return content, nil
+}
+
+// getMetadata is just a helper function. The release date is selected as is,
+// the driver only parses columns declared as timestamps, not expressions.
+// Unset release dates are the epoch like PostgreSQL returns them.
+func (repo releaseRepository) getMetadata(ctx context.Context, id int) (*release.Metadata, error) {
+	var err error
+	var meta = new(release.Metadata)
+
+	var releaseDate sql.NullTime
+	var otherJSON string
+
+	query := `SELECT COALESCE(title, ''), COALESCE(description, ''), COALESCE(genre_defining, ''), release_date, COALESCE(other, '{}')
+				FROM release_metadata
+				WHERE release_id = ?`
+	err = repo.db.QueryRowContext(ctx, query, id).Scan(&meta.Title, &meta.Description, &meta.GenreDefining, &releaseDate, &otherJSON)
+	if err != nil {
+		return nil, fmt.Errorf("metadata for release not found because: %v", err)
+	}
+	meta.ReleaseDate = time.Unix(0, 0)
+	if releaseDate.Valid {
+		meta.ReleaseDate = releaseDate.Time
+	}
+
+	err = json.Unmarshal([]byte(otherJSON), &meta.Other)
+	if err != nil {
+		return nil, fmt.Errorf("parsing of 'Other' json blob for metadata failed because: %v", err)
+	}
+
+	return meta, nil
+}
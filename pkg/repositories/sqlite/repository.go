@@ -0,0 +1,186 @@
+/*
+Package sqlite contains implementations of the different Repository interfaces
+backed by a single SQLite database file, for those who'd rather not run
+PostgreSQL. They behave like the ones of the postgres package, search is done
+through FTS5 tables in place of the tsvectors.
+
+The driver is github.com/mattn/go-sqlite3 which needs cgo, and FTS5 is only
+compiled in when building with the sqlite_fts5 tag:
+
+	go build -tags sqlite_fts5 ./cmd/server
+*/
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+type repository struct {
+	db       *database
+	allRepos *map[string]interface{}
+}
+
+// ErrNoFTS5 is returned by Open when the driver was built without the sqlite_fts5 tag.
+var ErrNoFTS5 = fmt.Errorf("sqlite was built without FTS5, build with the sqlite_fts5 tag")
+
+// Open opens the SQLite database file at the given path, creating it if it doesn't
+// exist. Foreign keys are enforced, the journal is kept in WAL mode so that
+// readers don't block the writer and transactions take the write lock as they
+// begin so that they don't fail midway when another connection is writing.
+func Open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=1&_busy_timeout=5000&_txlock=immediate&_journal_mode=WAL",
+		url.PathEscape(path))
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	var hasFTS5 bool
+	if err = db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&hasFTS5); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening %s failed because of: %w", path, err)
+	}
+	if !hasFTS5 {
+		db.Close()
+		return nil, ErrNoFTS5
+	}
+	return db, nil
+}
+
+// isConstraintViolation is just a helper function that tells whether the given
+// error was caused by the violation of a constraint of the given kind.
+func isConstraintViolation(err error, code sqlite3.ErrNoExtended) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == code
+}
+
+// exists is just a helper function that runs the given query, which is expected
+// to be a SELECT EXISTS, and returns its result. Errors count as not existing.
+func exists(ctx context.Context, db *database, query string, args ...interface{}) bool {
+	var found bool
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&found); err != nil {
+		return false
+	}
+	return found
+}
+
+// nullableUTC is just a helper function that returns the given time in UTC.
+// Timestamps are stored as text, they only sort and compare correctly when all
+// of them are in the same zone so every time bound needs to be in UTC.
+func nullableUTC(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// stringArray stores a slice of strings as a JSON array, it stands in for the
+// text[] columns of PostgreSQL.
+type stringArray []string
+
+// Value implements driver.Valuer.
+func (a stringArray) Value() (driver.Value, error) {
+	if a == nil {
+		a = stringArray{}
+	}
+	b, err := json.Marshal([]string(a))
+	return string(b), err
+}
+
+// Scan implements sql.Scanner.
+func (a *stringArray) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(src), (*[]string)(a))
+	case []byte:
+		return json.Unmarshal(src, (*[]string)(a))
+	default:
+		return fmt.Errorf("unable to scan %T into a string array", src)
+	}
+}
+
+// matchQuery is just a helper function that turns a search pattern in the
+// syntax websearch_to_tsquery accepts into an FTS5 query: words are all
+// required, "quoted text" is a phrase, or between two terms makes either do
+// and a leading - excludes a term. It returns an empty string if nothing in
+// the pattern can be matched.
+func matchQuery(pattern string) string {
+	type term struct {
+		text    string
+		negated bool
+	}
+	var terms []term
+	var orBefore []bool
+	pendingOr := false
+	for rest := strings.TrimSpace(pattern); rest != ""; rest = strings.TrimSpace(rest) {
+		negated := false
+		if rest[0] == '-' {
+			negated = true
+			rest = rest[1:]
+		}
+		var text string
+		if rest != "" && rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\n\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+			if !negated && strings.EqualFold(text, "or") {
+				pendingOr = len(terms) > 0
+				continue
+			}
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		terms = append(terms, term{text: text, negated: negated})
+		orBefore = append(orBefore, pendingOr && !negated)
+		pendingOr = false
+	}
+
+	var query strings.Builder
+	var deferred []string
+	for i, t := range terms {
+		quoted := `"` + strings.ReplaceAll(t.text, `"`, `""`) + `"`
+		switch {
+		case t.negated && query.Len() == 0:
+			// NOT is a binary operator in FTS5, exclusions need a term before them
+			deferred = append(deferred, quoted)
+			continue
+		case t.negated:
+			query.WriteString(" NOT ")
+		case query.Len() == 0:
+		case orBefore[i]:
+			query.WriteString(" OR ")
+		default:
+			query.WriteString(" AND ")
+		}
+		query.WriteString(quoted)
+		if !t.negated {
+			for _, d := range deferred {
+				query.WriteString(" NOT " + d)
+			}
+			deferred = nil
+		}
+	}
+	return query.String()
+}
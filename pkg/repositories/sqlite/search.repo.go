@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/search"
+)
+
+type searchRepository repository
+
+// NewSearchRepository returns a struct that implements the search.Repository using
+// a SQLite database.
+// A database connection needs to be passed so that it can function.
+func NewSearchRepository(DB *sql.DB, allRepos *map[string]interface{}) search.Repository {
+	return &searchRepository{&database{DB}, allRepos}
+}
+
+// SearchComments searches the comments using the comments_fts index. Matches
+// are ordered by relevance first, bm25 scores are lower the better the match.
+func (repo searchRepository) SearchComments(ctx context.Context, pattern string, by string, order string, limit, offset int) ([]*search.Comment, error) {
+	var comments = make([]*search.Comment, 0)
+	match := matchQuery(pattern)
+	if match == "" {
+		return comments, nil
+	}
+	query := `
+				SELECT comments.id, comments.post_from, comments.commented_by, comments.content, comments.reply_to, comments.creation_time
+				FROM comments_fts INNER JOIN comments ON comments.id = comments_fts.rowid
+				WHERE comments_fts MATCH ?1
+				ORDER BY bm25(comments_fts, 1.0, 0.4)`
+	if by != "" {
+		query = fmt.Sprintf(`%s, comments.%s COLLATE NOCASE %s NULLS LAST`, query, by, order)
+	}
+	query = fmt.Sprintf(`%s, comments.id
+				LIMIT ?2 OFFSET ?3`, query)
+	rows, err := repo.db.QueryContext(ctx, query, match, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("querying for comments failed because of: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := new(search.Comment)
+		err := rows.Scan(&c.ID, &c.OriginPost, &c.Commenter, &c.Content, &c.ReplyTo, &c.CreationTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from row failed because: %v", err)
+		}
+		comments = append(comments, c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	return comments, nil
+}
@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/unitofwork"
+)
+
+// database wraps the connection pool the repos of this package share. The
+// statements they make with a context holding a unit of work of this package
+// run in its transaction instead of on a connection of their own.
+type database struct {
+	*sql.DB
+}
+
+// transaction is a transaction begun by database.BeginTx. Those begun within
+// a unit of work are savepoints of its transaction, they're released on commit
+// and rolled back to on rollback.
+type transaction struct {
+	*sql.Tx
+	savepoint  string
+	savepoints int
+	done       bool
+}
+
+type unitOfWork struct {
+	db *database
+}
+
+// NewUnitOfWork returns a struct that implements the unitofwork.UnitOfWork
+// interface whose units of work are transactions of the given database.
+// The repos of this package must share the database for their calls to join them.
+func NewUnitOfWork(db *sql.DB) unitofwork.UnitOfWork {
+	return &unitOfWork{db: &database{db}}
+}
+
+// Do runs fn in a transaction, see unitofwork.UnitOfWork.
+func (uow *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := transactionOf(ctx); ok {
+		return fn(ctx)
+	}
+	return uow.db.transact(ctx, fn)
+}
+
+// transactionOf is a helper function that returns the transaction of the unit
+// of work held by ctx, if it's one of this package.
+func transactionOf(ctx context.Context) (*transaction, bool) {
+	tx, ok := unitofwork.TxFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	t, ok := tx.(*transaction)
+	return t, ok
+}
+
+// BeginTx begins a transaction, or a savepoint if ctx holds a unit of work.
+func (db *database) BeginTx(ctx context.Context, opts *sql.TxOptions) (*transaction, error) {
+	if outer, ok := transactionOf(ctx); ok {
+		outer.savepoints++
+		t := &transaction{Tx: outer.Tx, savepoint: fmt.Sprintf("sp_%d", outer.savepoints)}
+		if _, err := t.ExecContext(ctx, "SAVEPOINT "+t.savepoint); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{Tx: tx}, nil
+}
+
+// transact is a helper function that runs fn in a transaction, which is
+// committed if it succeeds and rolled back otherwise. The repo calls fn makes
+// with the context it's given run in the transaction.
+func (db *database) transact(ctx context.Context, fn func(ctx context.Context) error) error {
+	t, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("starting transaction failed because of: %w", err)
+	}
+	if t.savepoint == "" {
+		return unitofwork.Run(ctx, t, fn)
+	}
+	defer t.Rollback()
+	if err = fn(ctx); err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+// ExecContext executes the query in the transaction of the unit of work held
+// by ctx if there's one.
+func (db *database) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if t, ok := transactionOf(ctx); ok {
+		return t.ExecContext(ctx, query, args...)
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext executes the query in the transaction of the unit of work held
+// by ctx if there's one.
+func (db *database) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if t, ok := transactionOf(ctx); ok {
+		return t.QueryContext(ctx, query, args...)
+	}
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext executes the query in the transaction of the unit of work
+// held by ctx if there's one.
+func (db *database) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if t, ok := transactionOf(ctx); ok {
+		return t.QueryRowContext(ctx, query, args...)
+	}
+	return db.DB.QueryRowContext(ctx, query, args...)
+}
+
+// Commit commits the transaction or releases the savepoint.
+func (t *transaction) Commit() error {
+	if t.savepoint == "" {
+		return t.Tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// Rollback rolls the transaction back, or back to the savepoint. Like for
+// sql.Tx, rolling back once committed does nothing.
+func (t *transaction) Rollback() error {
+	if t.savepoint == "" {
+		return t.Tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if _, err := t.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint); err != nil {
+		return err
+	}
+	_, err := t.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
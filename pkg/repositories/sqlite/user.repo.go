@@ -0,0 +1,366 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+// userRepository ...
+type userRepository repository
+
+// NewUserRepository returns a new SQLite implementation of user.Repository.
+// the database connection must be passed as the first argument
+// since for the repo to work.
+// A map of all the other SQLite based implementations of the Repository interfaces
+// found in the different services of the project must be passed as a second argument as
+// the Repository might make user of them to fetch objects instead of implementing redundant logic.
+func NewUserRepository(DB *sql.DB, allRepos *map[string]interface{}) user.Repository {
+	return &userRepository{&database{DB}, allRepos}
+}
+
+// AddUser takes in a user.User struct and persists it in the database, all or nothing.
+// The setup_user trigger creates the user's channel and feed along with it.
+func (repo *userRepository) AddUser(ctx context.Context, u *user.User) (*user.User, error) {
+	var err error
+	passHash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate bcrypt has because: %w", err)
+	}
+	var added *user.User
+	err = repo.db.transact(ctx, func(ctx context.Context) error {
+		_, err := repo.db.ExecContext(ctx, `INSERT INTO users (username, email, pass_hash)
+							VALUES (?, ?, ?)`, u.Username, u.Email, string(passHash))
+		if err != nil {
+			return fmt.Errorf("insertion of user failed because of: %w", err)
+		}
+
+		// set the username to zero to avoid call to UpdateUser won't do redundant updating of username
+		username := u.Username
+		u.Username = ""
+		u.Email = ""
+		u.Password = ""
+
+		// using UpdateUser to set the rest of the values so that null values will be preserved
+		// (instead of columns with go's zero value of "")
+		added, err = repo.UpdateUser(ctx, username, u)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// GetUser retrieves a user.User based on the username passed.
+func (repo *userRepository) GetUser(ctx context.Context, username string) (*user.User, error) {
+	var err error
+	var u = new(user.User)
+
+	err = repo.db.QueryRowContext(ctx, `
+								SELECT email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, ''), verified
+								FROM users LEFT JOIN users_bio ub on users.username = ub.username LEFT JOIN user_avatars ua on users.username = ua.username
+								WHERE users.username = ?`, username).Scan(&u.Email, &u.FirstName, &u.MiddleName, &u.LastName, &u.CreationTime, &u.Bio, &u.PictureURL, &u.Verified)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, user.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("unable to get user from db becaues: %v", err)
+	}
+
+	bookmarkedPosts, err := repo.getBookmarkedPosts(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get bookmarked posts because of: %v", err)
+	}
+	u.BookmarkedPosts = bookmarkedPosts
+
+	u.Username = username
+	return u, nil
+}
+
+// getBookmarkedPosts is just a helper function
+func (repo *userRepository) getBookmarkedPosts(ctx context.Context, username string) (map[time.Time]int, error) {
+	var bookmarkedPosts = make(map[time.Time]int, 0)
+
+	rows, err := repo.db.QueryContext(ctx, `SELECT post_id, creation_time
+								FROM user_bookmarks
+								WHERE username = ?`, username)
+	if err != nil {
+		return nil, fmt.Errorf("querying for user_bookmarks failed because of: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			postID       int
+			creationTime time.Time
+		)
+		err := rows.Scan(&postID, &creationTime)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		bookmarkedPosts[creationTime] = postID
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	return bookmarkedPosts, nil
+}
+
+// UpdateUser updates a user based on the passed user.User struct.
+// Only the non empty fields of the struct are updated, all or nothing.
+func (repo *userRepository) UpdateUser(ctx context.Context, username string, u *user.User) (*user.User, error) {
+	if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM users WHERE username = ?)`, username) {
+		return nil, user.ErrUserNotFound
+	}
+	var passHash []byte
+	if u.Password != "" {
+		var err error
+		passHash, err = bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate bcrypt has because: %w", err)
+		}
+	}
+	newUsername := username
+	err := repo.db.transact(ctx, func(ctx context.Context) error {
+		// Checks if value is to be updated before attempting.
+		// This way, there won't be columns with go's zero string value of "" instead of null
+		if u.Password != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "pass_hash", string(passHash), username)
+			if err != nil {
+				return err
+			}
+		}
+		if u.Email != "" {
+			// a changed email has to be verified anew, email compares case insensitively
+			_, err := repo.db.ExecContext(ctx, `UPDATE users
+								SET email = ?1, verified = 0
+								WHERE username = ?2 AND email <> ?1`, u.Email, username)
+			if err != nil {
+				return fmt.Errorf("updating failed of email column with %s because of: %v", u.Email, err)
+			}
+		}
+		if u.FirstName != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "first_name", u.FirstName, username)
+			if err != nil {
+				return err
+			}
+		}
+		if u.MiddleName != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "middle_name", u.MiddleName, username)
+			if err != nil {
+				return err
+			}
+		}
+		if u.LastName != "" {
+			err := repo.execUpdateStatementOnColumn(ctx, "last_name", u.LastName, username)
+			if err != nil {
+				return err
+			}
+		}
+		if u.Bio != "" {
+			_, err := repo.db.ExecContext(ctx, `INSERT INTO users_bio(bio, username)
+								VALUES (?1, ?2)
+								ON CONFLICT(username) DO UPDATE
+								SET bio = ?1`, u.Bio, username)
+			if err != nil {
+				return fmt.Errorf("upsertion of bio failed because of: %v", err)
+			}
+		}
+		if u.Username != "" && u.Username != username {
+			err := repo.execUpdateStatementOnColumn(ctx, "username", u.Username, username)
+			if err != nil {
+				return err
+			}
+			// change username for the call below if username changed
+			newUsername = u.Username
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, user.ErrSomeUserDataNotPersisted
+	}
+	return repo.GetUser(ctx, newUsername)
+}
+
+// execUpdateStatementOnColumn is just a helper function
+func (repo *userRepository) execUpdateStatementOnColumn(ctx context.Context, column, value, username string) error {
+	_, err := repo.db.ExecContext(ctx, fmt.Sprintf(`UPDATE users
+									SET %s = ?
+									WHERE username = ?`, column), value, username)
+	if err != nil {
+		return fmt.Errorf("updating failed of %s column with %s because of: %s", column, value, err.Error())
+	}
+	return nil
+}
+
+// DeleteUser deletes a user based on the passed in username.
+// Users that have posted or commented can't be deleted.
+func (repo *userRepository) DeleteUser(ctx context.Context, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM users
+							WHERE username = ?`, username)
+	if err != nil {
+		return fmt.Errorf("deletion of user failed because of: %v", err)
+	}
+	return nil
+}
+
+// SearchUser searches for users according to the pattern.
+// If no pattern is provided, it returns all users.
+// It makes use of pagination.
+func (repo *userRepository) SearchUser(ctx context.Context, pattern, sortBy, sortOrder string, limit, offset int) ([]*user.User, error) {
+	var users = make([]*user.User, 0)
+	var err error
+	var rows *sql.Rows
+
+	// LIKE is case insensitive, no need for ILIKE
+	query := fmt.Sprintf(`
+		SELECT users.username, email, COALESCE(first_name, ''), COALESCE(middle_name, ''), COALESCE(last_name, ''), creation_time, COALESCE(bio, ''), COALESCE(image_name, ''), verified
+		FROM users LEFT JOIN users_bio ub on users.username = ub.username LEFT JOIN user_avatars ua on users.username = ua.username
+		WHERE ?3 = '' OR users.username LIKE '%%' || ?3 || '%%' OR first_name LIKE '%%' || ?3 || '%%' OR last_name LIKE '%%' || ?3 || '%%'
+		ORDER BY users.%s COLLATE NOCASE %s NULLS LAST, users.username COLLATE NOCASE
+		LIMIT ?1 OFFSET ?2`, sortBy, sortOrder)
+	rows, err = repo.db.QueryContext(ctx, query, limit, offset, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("querying for users failed because of: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u := user.User{}
+		err := rows.Scan(&u.Username, &u.Email, &u.FirstName, &u.MiddleName, &u.LastName, &u.CreationTime, &u.Bio, &u.PictureURL, &u.Verified)
+		if err != nil {
+			return nil, fmt.Errorf("scanning from rows failed because: %v", err)
+		}
+		users = append(users, &u)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("scanning from rows faulty because: %v", err)
+	}
+	rows.Close()
+
+	// bookmarks are fetched once done with the rows, they hold on to their connection till then
+	for _, u := range users {
+		bookmarkedPosts, err := repo.getBookmarkedPosts(ctx, u.Username)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get bookmarked posts because of: %s", err.Error())
+		}
+		u.BookmarkedPosts = bookmarkedPosts
+	}
+	return users, nil
+}
+
+// Authenticate checks the given password against the pass hash of the user
+// of the given username or email.
+func (repo *userRepository) Authenticate(ctx context.Context, u *user.User) (bool, error) {
+	var passHash string
+	err := repo.db.QueryRowContext(ctx, `SELECT pass_hash
+							FROM users
+							WHERE (?1 <> '' AND username = ?1) OR (?2 <> '' AND email = ?2)
+							LIMIT 1`, u.Username, u.Email).Scan(&passHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("couldn't authenticate user beacause: %w", err)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(passHash), []byte(u.Password)) == nil, nil
+}
+
+// BookmarkPost bookmarks the given postID for the user of the given username.
+func (repo *userRepository) BookmarkPost(ctx context.Context, username string, postID int) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO user_bookmarks (username, post_id)
+							VALUES (?, ?)
+							ON CONFLICT DO NOTHING`, username, postID)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			// the violation doesn't tell which of the keys is missing
+			if !exists(ctx, repo.db, `SELECT EXISTS (SELECT 1 FROM users WHERE username = ?)`, username) {
+				return user.ErrUserNotFound
+			}
+			return user.ErrPostNotFound
+		}
+		return fmt.Errorf("inserting into user_bookmarks failed because of: %v", err)
+	}
+	return nil
+}
+
+// DeleteBookmark removes the given ID from the given user's bookmarks
+func (repo *userRepository) DeleteBookmark(ctx context.Context, username string, postID int) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM user_bookmarks
+							WHERE username = ? AND post_id = ?`, username, postID)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from user_bookmarks because of: %v", err)
+	}
+	return nil
+}
+
+// UsernameOccupied checks if the given username is occupied by another user or a channel
+func (repo *userRepository) UsernameOccupied(ctx context.Context, username string) (bool, error) {
+	var occupied bool
+	err := repo.db.QueryRowContext(ctx, `
+				SELECT EXISTS(SELECT 1 FROM users WHERE username = ?1)
+					OR EXISTS(SELECT 1 FROM channels WHERE username = ?1)`, username).Scan(&occupied)
+	if err != nil {
+		return true, fmt.Errorf("unable to check if username occupied")
+	}
+	return occupied, nil
+}
+
+// EmailOccupied checks if the given email is occupied by another user
+func (repo *userRepository) EmailOccupied(ctx context.Context, email string) (bool, error) {
+	var occupied bool
+	err := repo.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT username FROM users
+									WHERE email = ?)`, email).Scan(&occupied)
+	if err != nil {
+		return true, fmt.Errorf("unable to check if email occupied")
+	}
+	return occupied, nil
+}
+
+// AddPicture persists the given name as the image_name for the user under the given username
+func (repo *userRepository) AddPicture(ctx context.Context, username, name string) error {
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO user_avatars (username, image_name)
+								VALUES (?1, ?2)
+								ON CONFLICT(username) DO UPDATE
+								SET image_name = ?2`, username, name)
+	if err != nil {
+		if isConstraintViolation(err, sqlite3.ErrConstraintForeignKey) {
+			return user.ErrUserNotFound
+		}
+		return fmt.Errorf("inserting into user_avatars failed because of: %v", err)
+	}
+	return nil
+}
+
+// RemovePicture removes the username's tuple entry from the user_avatars table.
+func (repo *userRepository) RemovePicture(ctx context.Context, username string) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM user_avatars
+							WHERE username = ?`, username)
+	if err != nil {
+		return fmt.Errorf("deletion of tuple from user_avatars failed because of: %v", err)
+	}
+	return nil
+}
+
+// MarkVerified sets the verified column of the user of the given username.
+func (repo *userRepository) MarkVerified(ctx context.Context, username string) error {
+	result, err := repo.db.ExecContext(ctx, `UPDATE users
+								SET verified = 1
+								WHERE username = ?`, username)
+	if err != nil {
+		return fmt.Errorf("marking user verified failed because of: %v", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
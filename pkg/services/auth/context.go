@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+// principalContextKey is the key under which the Principal is stored in a context.Context.
+// It's unexported so that only this package can set or read it.
+type principalContextKey struct{}
+
+// NewContextWithPrincipal returns a copy of the given context carrying the given Principal.
+func NewContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the Principal stored in the given context, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok && p != nil
+}
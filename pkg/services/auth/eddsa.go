@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// signingMethodEdDSA implements the EdDSA signing method of RFC 8037 over
+// Ed25519 keys which isn't provided by the jwt package.
+type signingMethodEdDSA struct{}
+
+// SigningMethodEdDSA is registered with the jwt package under the "EdDSA" alg.
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+var errEdDSAVerification = errors.New("eddsa: verification error")
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg returns the alg header value of tokens signed with the method.
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify checks the signature of the signing string using the ed25519.PublicKey.
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return errEdDSAVerification
+	}
+	return nil
+}
+
+// Sign signs the signing string using the ed25519.PrivateKey.
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
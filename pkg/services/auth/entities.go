@@ -1,8 +1,198 @@
 package auth
 
+import "time"
+
 // User represents standard user entity of issue#1.
 type User struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password,omitempty"`
 }
+
+// TokenTypeTOTPChallenge is the typ claim of challenge tokens issued to users
+// with TOTP enabled. Such tokens can only be traded for a TokenPair and
+// must not be accepted as access tokens. Access tokens carry no typ claim.
+const TokenTypeTOTPChallenge = "totp_challenge"
+
+// TokenTypeIDToken is the typ claim of OpenID Connect ID tokens issued to third
+// party clients. They identify the user to the client but aren't access tokens.
+const TokenTypeIDToken = "id_token"
+
+// TokenTypePersonalAccessToken is the TokenType of principals authenticated
+// with a personal access token.
+const TokenTypePersonalAccessToken = "pat"
+
+// PersonalAccessTokenPrefix is prepended to all personal access tokens to tell
+// them apart from JWTs in the Authorization header.
+const PersonalAccessTokenPrefix = "i1pat_"
+
+// ScopeAll is the scope granting access to everything the user can do.
+// Tokens issued on password login carry this scope.
+const ScopeAll = "*"
+
+// Scopes that can be granted to personal access tokens.
+const (
+	ScopeChannelsWrite = "channels:write"
+	ScopeReleasesWrite = "releases:write"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsWrite = "comments:write"
+	ScopeUsersWrite    = "users:write"
+	ScopePrivateRead   = "private:read"
+)
+
+// GrantableScopes lists the scopes personal access tokens can be given.
+// ScopeAll isn't among them, actions only it grants, like managing
+// personal access tokens themselves, require a password login.
+var GrantableScopes = []string{
+	ScopeChannelsWrite,
+	ScopeReleasesWrite,
+	ScopePostsWrite,
+	ScopeCommentsWrite,
+	ScopeUsersWrite,
+	ScopePrivateRead,
+}
+
+// Principal represents the authenticated party behind a request.
+// TokenID is the jti of the token used to authenticate while ExpiresAt is
+// when that token stops being accepted.
+// Verified tells whether the user has verified their email address.
+// TokenType is empty for access tokens and TokenTypePersonalAccessToken for
+// personal access tokens, whose ExpiresAt is zero if they never expire.
+// SessionID is the id of the login session access tokens were issued in and
+// ClientID that of the third party client they were issued to, if any.
+type Principal struct {
+	Username  string
+	TokenID   string
+	TokenType string
+	SessionID string
+	ClientID  string
+	Scopes    []string
+	ExpiresAt time.Time
+	Verified  bool
+}
+
+// HasScope checks whether the Principal has been granted the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenPair is what's handed out to a user on successful authentication.
+// Token is the short lived JWT access token while RefreshToken is an opaque,
+// single use token that can be traded for a new TokenPair.
+// ExpiresIn is the lifetime of the access token in seconds.
+type TokenPair struct {
+	Token        string   `json:"token"`
+	RefreshToken string   `json:"refreshToken"`
+	ExpiresIn    int      `json:"expiresIn"`
+	Scopes       []string `json:"-"`
+}
+
+// RefreshToken represents a server side record of an issued refresh token.
+// Only the hash of the token is stored.
+// All refresh tokens rotated from a single login share the same FamilyID.
+// AccessTokenID is the jti of the access token issued along side it.
+type RefreshToken struct {
+	TokenHash     string
+	FamilyID      string
+	Username      string
+	AccessTokenID string
+	Used          bool
+	Revoked       bool
+	CreationTime  time.Time
+	ExpiresAt     time.Time
+}
+
+// Session represents a login, it's started whenever a user is issued a new
+// TokenPair and lives on for as long as its refresh tokens keep getting rotated.
+// Its ID is the FamilyID shared by all the refresh tokens issued in it.
+// Sessions of third party clients carry the ClientID and the Scopes they were granted.
+// Current is only set when listing sessions, on the session the listing request
+// was authenticated with.
+type Session struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	ClientID     string    `json:"clientID,omitempty"`
+	Scopes       []string  `json:"scopes"`
+	UserAgent    string    `json:"userAgent"`
+	IPAddress    string    `json:"ipAddress"`
+	CreationTime time.Time `json:"creationTime"`
+	LastUsedTime time.Time `json:"lastUsedTime"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Revoked      bool      `json:"-"`
+	Current      bool      `json:"current"`
+}
+
+// PasswordResetToken represents a server side record of an issued password reset token.
+// Only the hash of the token is stored and it can only be used once.
+type PasswordResetToken struct {
+	TokenHash    string
+	Username     string
+	Used         bool
+	CreationTime time.Time
+	ExpiresAt    time.Time
+}
+
+// EmailVerificationToken represents a server side record of an issued email verification token.
+// Only the hash of the token is stored and it can only be used once.
+// Email is the address the token was mailed to, the token only verifies that address.
+type EmailVerificationToken struct {
+	TokenHash    string
+	Username     string
+	Email        string
+	Used         bool
+	CreationTime time.Time
+	ExpiresAt    time.Time
+}
+
+// TOTPSecret represents a server side record of a user's TOTP enrolment.
+// The secret has to be kept as is to compute codes while recovery codes are
+// only stored hashed. Enrolments only take effect once Confirmed with a valid code.
+// LastUsedStep is the time step of the last accepted code, codes of it and earlier
+// steps aren't accepted again.
+type TOTPSecret struct {
+	Username           string
+	Secret             string
+	Confirmed          bool
+	LastUsedStep       int64
+	RecoveryCodeHashes []string
+	CreationTime       time.Time
+}
+
+// TOTPEnrollment is what's handed out to a user on enrolling into TOTP.
+// The recovery codes are only ever shown here.
+type TOTPEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioningURI"`
+	RecoveryCodes   []string `json:"recoveryCodes"`
+}
+
+// TOTPChallenge is what's handed out to a user with TOTP enabled on successful
+// password authentication. The ChallengeToken and a TOTP or recovery code are
+// to be traded for a TokenPair before it expires.
+type TOTPChallenge struct {
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresIn      int    `json:"expiresIn"`
+}
+
+// PersonalAccessToken represents a long lived token users create for scripts
+// and integrations. Only the hash of the token is stored, the token itself is
+// only set on the struct returned on creation.
+// ExpiresAt is nil for tokens that never expire and LastUsedTime is nil for
+// tokens that haven't been used yet.
+type PersonalAccessToken struct {
+	ID           string     `json:"id"`
+	Token        string     `json:"token,omitempty"`
+	TokenHash    string     `json:"-"`
+	Username     string     `json:"username"`
+	Name         string     `json:"name"`
+	Scopes       []string   `json:"scopes"`
+	CreationTime time.Time  `json:"creationTime"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	LastUsedTime *time.Time `json:"lastUsedTime"`
+	Revoked      bool       `json:"-"`
+}
@@ -0,0 +1,325 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Algorithms tokens can be signed with.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a key tokens are signed and verified with.
+// Tokens name the key they were signed with by its ID in their kid header.
+// A key signs tokens from NotBefore until NotAfter, the next key by NotBefore
+// taking over then. Tokens it signed are accepted and its public half is
+// published until ExpiresAt so that it should be at least the lifetime of the
+// tokens after NotAfter. Zero NotAfter and ExpiresAt mean never.
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	NotBefore  time.Time
+	NotAfter   time.Time
+	ExpiresAt  time.Time
+}
+
+// JSONWebKey is the public half of a SigningKey in the RFC 7517 format.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the RFC 7517 document served to let others verify tokens.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// KeySet holds the keys tokens are signed and verified with.
+// Those loaded from a manifest can be reloaded while in use, see Reload.
+type KeySet struct {
+	lock         sync.RWMutex
+	keys         []*SigningKey
+	manifestPath string
+}
+
+// ErrNoSigningKey is returned when none of the keys of a KeySet is to sign tokens at the time
+var ErrNoSigningKey = fmt.Errorf("no signing key active")
+
+// NewKeySet returns a KeySet holding the given keys.
+func NewKeySet(keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make([]*SigningKey, 0, len(keys))}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if key.ID == "" || seen[key.ID] {
+			return nil, fmt.Errorf("key ids must be unique and not empty, found %q", key.ID)
+		}
+		seen[key.ID] = true
+		if key.Algorithm == "" {
+			key.Algorithm = algorithmOf(key.PrivateKey)
+		}
+		if err := checkKeyAlgorithm(key); err != nil {
+			return nil, err
+		}
+		ks.keys = append(ks.keys, key)
+	}
+	sort.Slice(ks.keys, func(i, j int) bool {
+		return ks.keys[i].NotBefore.Before(ks.keys[j].NotBefore)
+	})
+	return ks, nil
+}
+
+// NewEphemeralKeySet returns a KeySet holding a single Ed25519 key generated on the spot.
+// Tokens signed with it stop being accepted once the process exits, it's meant for
+// development and tests.
+func NewEphemeralKeySet() (*KeySet, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("key generation failed because %v", err)
+	}
+	id, err := generateTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("key id generation failed because %v", err)
+	}
+	return NewKeySet(&SigningKey{ID: id, Algorithm: AlgorithmEdDSA, PrivateKey: privateKey})
+}
+
+// LoadKeySet reads the keys listed in the JSON manifest at the given path.
+// The manifest looks like:
+//
+//	{"keys": [{"kid": "2020-06", "alg": "EdDSA", "file": "2020-06.pem",
+//	           "notBefore": "2020-06-01T00:00:00Z", "notAfter": "2020-07-01T00:00:00Z",
+//	           "expiresAt": "2020-07-08T00:00:00Z"}]}
+//
+// Files are PEM encoded PKCS #8 RSA or Ed25519 private keys, or PKCS #1 RSA private keys,
+// and relative paths are resolved from the directory of the manifest.
+// The alg can be left out to have it inferred from the key.
+func LoadKeySet(manifestPath string) (*KeySet, error) {
+	keys, err := loadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := NewKeySet(keys...)
+	if err != nil {
+		return nil, err
+	}
+	ks.manifestPath = manifestPath
+	return ks, nil
+}
+
+// Reload reads the manifest the KeySet was loaded from again and swaps its keys
+// for those listed now, which is how keys are rotated without a restart. The
+// keys are left as they were if the manifest can't be read or is invalid.
+// It does nothing to KeySets that weren't loaded from a manifest.
+func (ks *KeySet) Reload() error {
+	if ks.manifestPath == "" {
+		return nil
+	}
+	keys, err := loadManifest(ks.manifestPath)
+	if err != nil {
+		return err
+	}
+	loaded, err := NewKeySet(keys...)
+	if err != nil {
+		return err
+	}
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+	ks.keys = loaded.keys
+	return nil
+}
+
+// loadManifest is a helper function that reads the keys listed in the manifest at the given path.
+func loadManifest(manifestPath string) ([]*SigningKey, error) {
+	manifestBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading key manifest failed because %v", err)
+	}
+	var manifest struct {
+		Keys []struct {
+			ID        string    `json:"kid"`
+			Algorithm string    `json:"alg"`
+			File      string    `json:"file"`
+			NotBefore time.Time `json:"notBefore"`
+			NotAfter  time.Time `json:"notAfter"`
+			ExpiresAt time.Time `json:"expiresAt"`
+		} `json:"keys"`
+	}
+	if err = json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("parsing key manifest failed because %v", err)
+	}
+	keys := make([]*SigningKey, 0, len(manifest.Keys))
+	for _, entry := range manifest.Keys {
+		file := entry.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(manifestPath), file)
+		}
+		privateKey, err := loadPrivateKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("loading key %s failed because %v", entry.ID, err)
+		}
+		keys = append(keys, &SigningKey{
+			ID:         entry.ID,
+			Algorithm:  entry.Algorithm,
+			PrivateKey: privateKey,
+			NotBefore:  entry.NotBefore,
+			NotAfter:   entry.NotAfter,
+			ExpiresAt:  entry.ExpiresAt,
+		})
+	}
+	return keys, nil
+}
+
+// Sign signs the given claims with the key that's to sign tokens at the time,
+// naming it in the kid header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	ks.lock.RLock()
+	key := ks.signingKey(time.Now())
+	ks.lock.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc looks up the public key to verify the given token with by its kid header.
+// It's meant to be passed to the parsing functions of the jwt package.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	for _, key := range ks.keys {
+		if key.ID != kid {
+			continue
+		}
+		if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+			return nil, fmt.Errorf("key %s has expired", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.PrivateKey.Public(), nil
+	}
+	return nil, fmt.Errorf("unknown key: %q", kid)
+}
+
+// JSONWebKeySet returns the public halves of the keys that haven't expired.
+// Keys yet to sign tokens are included so that verifiers learn of them ahead of time.
+func (ks *KeySet) JSONWebKeySet() *JSONWebKeySet {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	set := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(ks.keys))}
+	now := time.Now()
+	for _, key := range ks.keys {
+		if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+			continue
+		}
+		jwk := JSONWebKey{Use: "sig", Algorithm: key.Algorithm, KeyID: key.ID}
+		switch publicKey := key.PrivateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// signingKey is a helper function that returns the key with the latest NotBefore
+// that's to sign tokens at the given time, nil if there's none. The caller holds
+// the lock.
+func (ks *KeySet) signingKey(at time.Time) *SigningKey {
+	for i := len(ks.keys) - 1; i >= 0; i-- {
+		key := ks.keys[i]
+		if at.Before(key.NotBefore) {
+			continue
+		}
+		if !key.NotAfter.IsZero() && !at.Before(key.NotAfter) {
+			continue
+		}
+		if !key.ExpiresAt.IsZero() && at.After(key.ExpiresAt) {
+			continue
+		}
+		return key
+	}
+	return nil
+}
+
+// loadPrivateKey is a helper function that reads a PEM encoded private key from the given file.
+func loadPrivateKey(file string) (crypto.Signer, error) {
+	pemBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", file)
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return key, nil
+		case ed25519.PrivateKey:
+			return key, nil
+		}
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+}
+
+// algorithmOf is a helper function that returns the algorithm used with the given key.
+func algorithmOf(key crypto.Signer) string {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return AlgorithmRS256
+	case ed25519.PrivateKey:
+		return AlgorithmEdDSA
+	}
+	return ""
+}
+
+// checkKeyAlgorithm is a helper function that checks whether the key can be used with its algorithm.
+func checkKeyAlgorithm(key *SigningKey) error {
+	if key.PrivateKey == nil || key.Algorithm == "" || key.Algorithm != algorithmOf(key.PrivateKey) {
+		return fmt.Errorf("key %s can't be used with algorithm %q", key.ID, key.Algorithm)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// writeKey writes a new Ed25519 key to the directory under the given id.
+func writeKey(t *testing.T, dir, id string) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err = ioutil.WriteFile(filepath.Join(dir, id+".pem"), pemBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeManifest writes the manifest to the directory and returns its path.
+func writeManifest(t *testing.T, dir, manifest string) string {
+	t.Helper()
+	path := filepath.Join(dir, "keys.json")
+	if err := ioutil.WriteFile(path, []byte(manifest), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// kidOf returns the id of the key the key set signs tokens with.
+func kidOf(t *testing.T, ks *KeySet) string {
+	t.Helper()
+	tokenString, err := ks.Sign(jwt.MapClaims{"sub": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Parse(tokenString, ks.Keyfunc)
+	if err != nil {
+		t.Fatalf("token signed by the key set isn't verified by it: %v", err)
+	}
+	return token.Header["kid"].(string)
+}
+
+func TestLoadKeySetNeedsTheManifest(t *testing.T) {
+	if _, err := LoadKeySet(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadKeySet is expected to fail without the manifest")
+	}
+}
+
+func TestKeySetReload(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "old")
+	writeKey(t, dir, "new")
+	path := writeManifest(t, dir, `{"keys": [{"kid": "old", "file": "old.pem"}]}`)
+	ks, err := LoadKeySet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := kidOf(t, ks); kid != "old" {
+		t.Fatalf("expected tokens to be signed with old, got %s", kid)
+	}
+	oldToken, err := ks.Sign(jwt.MapClaims{"sub": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeManifest(t, dir, `{"keys": [{"kid": "old", "file": "old.pem", "notAfter": "2000-01-01T00:00:00Z"},
+		{"kid": "new", "file": "new.pem", "notBefore": "2000-01-01T00:00:00Z"}]}`)
+	if err = ks.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if kid := kidOf(t, ks); kid != "new" {
+		t.Errorf("expected tokens to be signed with the key added to the manifest, got %s", kid)
+	}
+	if _, err = jwt.Parse(oldToken, ks.Keyfunc); err != nil {
+		t.Errorf("tokens signed before the rotation are expected to still be accepted, got %v", err)
+	}
+	if n := len(ks.JSONWebKeySet().Keys); n != 2 {
+		t.Errorf("expected both keys to be published, got %d", n)
+	}
+
+	// a broken manifest leaves the keys as they were
+	writeManifest(t, dir, `{"keys": [{"kid": "new", "file": "missing.pem"}]}`)
+	if err = ks.Reload(); err == nil {
+		t.Error("Reload is expected to fail when a key can't be loaded")
+	}
+	if kid := kidOf(t, ks); kid != "new" {
+		t.Errorf("expected the keys to be kept after a failed reload, got %s", kid)
+	}
+}
+
+func TestEphemeralKeySetReload(t *testing.T) {
+	ks, err := NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid := kidOf(t, ks)
+	if err = ks.Reload(); err != nil {
+		t.Errorf("Reload is expected to do nothing to ephemeral key sets, got %v", err)
+	}
+	if got := kidOf(t, ks); got != kid {
+		t.Errorf("expected the ephemeral key to be kept, got %s", got)
+	}
+}
@@ -1,76 +1,854 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/mail"
 )
 
 // Service defines an interface for authentication
 type Service interface {
-	Authenticate(user *User) (bool, error)
-	GenerateToken(username string) (string, error)
-	AddToBlacklist(tokenString string) error
-	IsInBlacklist(token string) (bool, error)
+	Authenticate(ctx context.Context, user *User) (bool, error)
+	GetUsername(ctx context.Context, identifier string) (string, error)
+	IssueTokens(ctx context.Context, username, userAgent, ipAddress string) (*TokenPair, error)
+	IssueClientTokens(ctx context.Context, username, clientID string, scopes []string, userAgent, ipAddress string) (*TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken, clientID string) (*TokenPair, error)
+	RevokeTokenFamily(ctx context.Context, tokenString string) error
+	AddToBlacklist(ctx context.Context, tokenString string) error
+	IsInBlacklist(ctx context.Context, token string) (bool, error)
+	RequestPasswordReset(ctx context.Context, identifier string) error
+	ResetPassword(ctx context.Context, resetToken, newPassword string) error
+	RequestEmailVerification(ctx context.Context, username string) error
+	VerifyEmail(ctx context.Context, verificationToken string) (string, error)
+	EnrollTOTP(ctx context.Context, username string) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, username, code string) error
+	DisableTOTP(ctx context.Context, username, code string) error
+	IsTOTPEnabled(ctx context.Context, username string) (bool, error)
+	IssueTOTPChallenge(ctx context.Context, username string) (*TOTPChallenge, error)
+	GetTOTPChallengeUsername(ctx context.Context, challengeToken string) (string, error)
+	CompleteTOTPChallenge(ctx context.Context, challengeToken, code, userAgent, ipAddress string) (*TokenPair, error)
+	CreatePersonalAccessToken(ctx context.Context, username, name string, scopes []string, lifetime time.Duration) (*PersonalAccessToken, error)
+	GetPersonalAccessTokens(ctx context.Context, username string) ([]*PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, username, id string) error
+	AuthenticatePersonalAccessToken(ctx context.Context, token string) (*Principal, error)
+	GetSessions(ctx context.Context, username string) ([]*Session, error)
+	RevokeSession(ctx context.Context, username, sessionID string) error
+	RevokeAllSessions(ctx context.Context, username, exceptSessionID string) error
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+	GetJSONWebKeySet() *JSONWebKeySet
 }
 
-// Repository defines an interface that provides persistence functionality for the search service.
+// Repository defines an interface that provides persistence functionality for the auth service.
+// Users can be looked up and authenticated by either their username or email.
+// Blacklisted tokens are identified by their jti claim, or a hash of the token if it has none,
+// and are kept until expiresAt, after which they're no longer usable anyways.
 type Repository interface {
-	Authenticate(user *User) (bool, error)
-	AddToBlacklist(tokenString string) error
-	IsInBlacklist(token string) (bool, error)
+	Authenticate(ctx context.Context, user *User) (bool, error)
+	AddToBlacklist(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsInBlacklist(ctx context.Context, tokenID string) (bool, error)
+	AddRefreshToken(ctx context.Context, rt *RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, tokenHash string) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	GetUser(ctx context.Context, identifier string) (*User, error)
+	AddPasswordResetToken(ctx context.Context, prt *PasswordResetToken) error
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	SetPassword(ctx context.Context, username, password string) error
+	AddEmailVerificationToken(ctx context.Context, evt *EmailVerificationToken) error
+	GetLastEmailVerificationTime(ctx context.Context, username string) (time.Time, error)
+	ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (*EmailVerificationToken, error)
+	SetTOTPSecret(ctx context.Context, ts *TOTPSecret) error
+	GetTOTPSecret(ctx context.Context, username string) (*TOTPSecret, error)
+	ConfirmTOTPSecret(ctx context.Context, username string) error
+	DeleteTOTPSecret(ctx context.Context, username string) error
+	UpdateTOTPLastUsedStep(ctx context.Context, username string, step int64) (bool, error)
+	ConsumeTOTPRecoveryCode(ctx context.Context, username, codeHash string) (bool, error)
+	AddPersonalAccessToken(ctx context.Context, pat *PersonalAccessToken) error
+	GetPersonalAccessToken(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	GetPersonalAccessTokens(ctx context.Context, username string) ([]*PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, username, id string) error
+	UpdatePersonalAccessTokenLastUsed(ctx context.Context, id string, lastUsedTime time.Time) error
+	AddSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, id string) (*Session, error)
+	GetSessions(ctx context.Context, username string) ([]*Session, error)
+	UpdateSession(ctx context.Context, id string, lastUsedTime, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, username, id string) error
+	RevokeSessions(ctx context.Context, username, exceptID string) error
+	IsSessionRevoked(ctx context.Context, id string) (bool, error)
 }
 
 // ErrUserNotFound is returned when the the username specified isn't recognized
 var ErrUserNotFound = fmt.Errorf("user not found")
 
+// ErrRefreshTokenNotFound is returned when the refresh token specified isn't recognized
+var ErrRefreshTokenNotFound = fmt.Errorf("refresh token not found")
+
+// ErrInvalidRefreshToken is returned when the refresh token specified is unknown, expired or revoked
+var ErrInvalidRefreshToken = fmt.Errorf("refresh token invalid")
+
+// ErrRefreshTokenReused is returned when an already used refresh token is presented again.
+// The whole family the token belongs to is revoked when this happens.
+var ErrRefreshTokenReused = fmt.Errorf("refresh token reused")
+
+// ErrInvalidPasswordResetToken is returned when the password reset token specified is unknown, expired or used
+var ErrInvalidPasswordResetToken = fmt.Errorf("password reset token invalid")
+
+// ErrInvalidPassword is returned when the new password specified doesn't meet requirements
+var ErrInvalidPassword = fmt.Errorf("password invalid")
+
+// ErrInvalidEmailVerificationToken is returned when the email verification token specified is unknown, expired or used
+var ErrInvalidEmailVerificationToken = fmt.Errorf("email verification token invalid")
+
+// ErrEmailVerificationThrottled is returned when an email verification is requested
+// before the resend interval since the last one has passed.
+var ErrEmailVerificationThrottled = fmt.Errorf("email verification requested too soon")
+
+// ErrTOTPNotEnrolled is returned when the user specified hasn't enrolled into TOTP
+var ErrTOTPNotEnrolled = fmt.Errorf("totp not enrolled")
+
+// ErrTOTPAlreadyEnabled is returned when enrolling a user that has a confirmed TOTP enrolment
+var ErrTOTPAlreadyEnabled = fmt.Errorf("totp already enabled")
+
+// ErrInvalidTOTPCode is returned when the TOTP or recovery code specified isn't valid
+var ErrInvalidTOTPCode = fmt.Errorf("totp code invalid")
+
+// ErrInvalidTOTPChallenge is returned when the challenge token specified is invalid or expired
+var ErrInvalidTOTPChallenge = fmt.Errorf("totp challenge invalid")
+
+// ErrPersonalAccessTokenNotFound is returned when the personal access token specified isn't recognized
+var ErrPersonalAccessTokenNotFound = fmt.Errorf("personal access token not found")
+
+// ErrInvalidPersonalAccessToken is returned when the personal access token specified is unknown, expired or revoked
+var ErrInvalidPersonalAccessToken = fmt.Errorf("personal access token invalid")
+
+// ErrInvalidScope is returned when a scope specified isn't one of the GrantableScopes
+var ErrInvalidScope = fmt.Errorf("scope invalid")
+
+// ErrSessionNotFound is returned when the session specified isn't recognized
+var ErrSessionNotFound = fmt.Errorf("session not found")
+
+// Config holds the settings used by the auth service.
+type Config struct {
+	TokenAccessLifetime, TokenRefreshLifetime time.Duration
+	TokenSigningKeys                          *KeySet
+	PasswordResetLifetime                     time.Duration
+	EmailVerificationLifetime                 time.Duration
+	EmailVerificationResendInterval           time.Duration
+	TOTPIssuer                                string
+	TOTPChallengeLifetime                     time.Duration
+}
+
 // jWTAuthenticationBackend provides methods for implementation of a JWT based authentication
 type jWTAuthenticationBackend struct {
-	TokenAccessLifetime, TokenRefreshLifetime time.Duration
-	TokenSigningSecret                        []byte
-	repo                                      *Repository
+	Config
+	repo   *Repository
+	mailer mail.Mailer
 }
 
-// NewAuthService returns a new JWTAuthenticationBackend that uses the passed arguments
-func NewAuthService(r *Repository, tokenAccessLifetime, tokenRefreshLifetime time.Duration, tokenSigningSecret []byte) Service {
+// NewAuthService returns a new JWTAuthenticationBackend that uses the passed arguments.
+// The mailer is used to deliver password reset and email verification tokens.
+func NewAuthService(r *Repository, mailer mail.Mailer, config Config) Service {
 	return &jWTAuthenticationBackend{
-		TokenAccessLifetime:  tokenAccessLifetime,
-		TokenRefreshLifetime: tokenRefreshLifetime,
-		TokenSigningSecret:   tokenSigningSecret,
-		repo:                 r,
+		Config: config,
+		repo:   r,
+		mailer: mailer,
+	}
+}
+
+// IssueTokens generates a new access token and a refresh token, starting
+// a new token family, for the given username. A Session is recorded for the
+// family along with the given details of the client that logged in.
+// The tokens grant ScopeAll, they're meant for first party clients that the
+// user entered their password into.
+func (s *jWTAuthenticationBackend) IssueTokens(ctx context.Context, username, userAgent, ipAddress string) (*TokenPair, error) {
+	return s.IssueClientTokens(ctx, username, "", []string{ScopeAll}, userAgent, ipAddress)
+}
+
+// IssueClientTokens is like IssueTokens but the tokens are issued to the third
+// party client of the given id and only grant the given scopes. Tokens rotated
+// from them keep the same client and scopes.
+func (s *jWTAuthenticationBackend) IssueClientTokens(ctx context.Context, username, clientID string, scopes []string, userAgent, ipAddress string) (*TokenPair, error) {
+	familyID, err := generateTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("token family id generation failed because %v", err)
+	}
+	now := time.Now()
+	session := &Session{
+		ID:           familyID,
+		Username:     username,
+		ClientID:     clientID,
+		Scopes:       scopes,
+		UserAgent:    userAgent,
+		IPAddress:    ipAddress,
+		CreationTime: now,
+		LastUsedTime: now,
+		ExpiresAt:    now.Add(s.TokenRefreshLifetime),
+	}
+	if err = (*s.repo).AddSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("session persistence failed because %v", err)
+	}
+	return s.issueTokensInSession(ctx, session)
+}
+
+// RefreshTokens trades the given refresh token for a new TokenPair of the same family.
+// Refresh tokens are single use, presenting one a second time revokes its whole family.
+// Only the client the refresh token was issued to can use it, an empty clientID
+// standing for first party clients.
+func (s *jWTAuthenticationBackend) RefreshTokens(ctx context.Context, refreshToken, clientID string) (*TokenPair, error) {
+	tokenHash := hashToken(refreshToken)
+	rt, err := (*s.repo).GetRefreshToken(ctx, tokenHash)
+	switch err {
+	case nil:
+	case ErrRefreshTokenNotFound:
+		return nil, ErrInvalidRefreshToken
+	default:
+		return nil, err
+	}
+	if rt.Revoked || time.Now().After(rt.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := (*s.repo).GetSession(ctx, rt.FamilyID)
+	switch err {
+	case nil:
+	case ErrSessionNotFound:
+		// families issued before sessions were recorded
+		session = &Session{ID: rt.FamilyID, Username: rt.Username, Scopes: []string{ScopeAll}}
+	default:
+		return nil, err
+	}
+	if session.Revoked || session.ClientID != clientID {
+		return nil, ErrInvalidRefreshToken
+	}
+	marked := false
+	if !rt.Used {
+		if marked, err = (*s.repo).MarkRefreshTokenUsed(ctx, tokenHash); err != nil {
+			return nil, err
+		}
+	}
+	if !marked {
+		if err = s.revokeFamily(ctx, rt.Username, rt.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	pair, err := s.issueTokensInSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err = (*s.repo).UpdateSession(ctx, rt.FamilyID, now, now.Add(s.TokenRefreshLifetime)); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// RevokeTokenFamily revokes all the refresh tokens of the family the given access token
+// was issued in, ending its Session.
+func (s *jWTAuthenticationBackend) RevokeTokenFamily(ctx context.Context, tokenString string) error {
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return fmt.Errorf("token parsing failed because %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	familyID, ok := claims["fam"].(string)
+	if !ok || familyID == "" {
+		return nil
+	}
+	username, _ := claims["sub"].(string)
+	return s.revokeFamily(ctx, username, familyID)
+}
+
+// GetSessions returns the sessions of the given user that are yet to expire or be revoked.
+func (s *jWTAuthenticationBackend) GetSessions(ctx context.Context, username string) ([]*Session, error) {
+	return (*s.repo).GetSessions(ctx, username)
+}
+
+// RevokeSession ends the session of the given user with the given id.
+// Access tokens issued in it stop being accepted right away.
+func (s *jWTAuthenticationBackend) RevokeSession(ctx context.Context, username, sessionID string) error {
+	if err := (*s.repo).RevokeSession(ctx, username, sessionID); err != nil {
+		return err
+	}
+	return (*s.repo).RevokeRefreshTokenFamily(ctx, sessionID)
+}
+
+// RevokeAllSessions ends all the sessions of the given user except the one with
+// the given id, which can be left empty to end all of them.
+func (s *jWTAuthenticationBackend) RevokeAllSessions(ctx context.Context, username, exceptSessionID string) error {
+	return (*s.repo).RevokeSessions(ctx, username, exceptSessionID)
+}
+
+// IsSessionRevoked checks whether the session with the given id has been revoked.
+func (s *jWTAuthenticationBackend) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	return (*s.repo).IsSessionRevoked(ctx, sessionID)
+}
+
+// revokeFamily is a helper function that revokes the refresh tokens of the given
+// family along with its session. Families issued before sessions were recorded
+// have none, which isn't treated as an error.
+func (s *jWTAuthenticationBackend) revokeFamily(ctx context.Context, username, familyID string) error {
+	if err := (*s.repo).RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		return err
+	}
+	if err := (*s.repo).RevokeSession(ctx, username, familyID); err != nil && err != ErrSessionNotFound {
+		return err
+	}
+	return nil
+}
+
+// issueTokensInSession is a helper function that generates and persists a new
+// TokenPair in the family of the given session.
+func (s *jWTAuthenticationBackend) issueTokensInSession(ctx context.Context, session *Session) (*TokenPair, error) {
+	accessToken, accessTokenID, err := s.generateAccessToken(session)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("refresh token generation failed because %v", err)
 	}
+	now := time.Now()
+	err = (*s.repo).AddRefreshToken(ctx, &RefreshToken{
+		TokenHash:     hashToken(refreshToken),
+		FamilyID:      session.ID,
+		Username:      session.Username,
+		AccessTokenID: accessTokenID,
+		CreationTime:  now,
+		ExpiresAt:     now.Add(s.TokenRefreshLifetime),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("refresh token persistence failed because %v", err)
+	}
+	return &TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.TokenAccessLifetime.Seconds()),
+		Scopes:       session.Scopes,
+	}, nil
 }
 
-// GenerateToken generates a new JWT token based on the given username.
-// It uses the HS-SHA512 encryption standard.
-func (s *jWTAuthenticationBackend) GenerateToken(username string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS512)
+// generateAccessToken generates a new JWT token for the user of the given session
+// carrying its scopes and, if any, its client.
+// It's signed with the key of the TokenSigningKeys that's active at the time.
+// The id of the generated token is also returned.
+func (s *jWTAuthenticationBackend) generateAccessToken(session *Session) (string, string, error) {
 	mapClaim := jwt.MapClaims{}
 	mapClaim["exp"] = time.Now().Add(s.TokenAccessLifetime).Unix()
 	mapClaim["iat"] = time.Now().Unix()
+	mapClaim["sub"] = session.Username
+	mapClaim["fam"] = session.ID
+	mapClaim["scope"] = strings.Join(session.Scopes, " ")
+	if session.ClientID != "" {
+		mapClaim["client_id"] = session.ClientID
+	}
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", "", fmt.Errorf("token id generation failed because %v", err)
+	}
+	mapClaim["jti"] = jti
+	tokenString, err := s.TokenSigningKeys.Sign(mapClaim)
+	if err != nil {
+		return "", "", fmt.Errorf("token signing failed because %v", err)
+	}
+	return tokenString, jti, nil
+}
+
+// Authenticate checks whether the given User struct holds appropriate credentials.
+// Either the Username or the Email of the user can be used to identify it, on
+// success, the Username field is set to that of the authenticated user.
+func (s *jWTAuthenticationBackend) Authenticate(ctx context.Context, user *User) (bool, error) {
+	if user.Email == "" && strings.Contains(user.Username, "@") {
+		user.Email, user.Username = user.Username, ""
+	}
+	return (*s.repo).Authenticate(ctx, user)
+}
+
+// GetUsername returns the username of the user identified by the given
+// username or email, ErrUserNotFound if there's none.
+func (s *jWTAuthenticationBackend) GetUsername(ctx context.Context, identifier string) (string, error) {
+	u, err := (*s.repo).GetUser(ctx, identifier)
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// RequestPasswordReset issues a single use password reset token for the user
+// identified by the given username or email and mails it to the user.
+func (s *jWTAuthenticationBackend) RequestPasswordReset(ctx context.Context, identifier string) error {
+	u, err := (*s.repo).GetUser(ctx, identifier)
+	if err != nil {
+		return err
+	}
+	resetToken, err := generateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("password reset token generation failed because %v", err)
+	}
+	now := time.Now()
+	err = (*s.repo).AddPasswordResetToken(ctx, &PasswordResetToken{
+		TokenHash:    hashToken(resetToken),
+		Username:     u.Username,
+		CreationTime: now,
+		ExpiresAt:    now.Add(s.PasswordResetLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("password reset token persistence failed because %v", err)
+	}
+	return s.mailer.Send(&mail.Message{
+		To:      []string{u.Email},
+		Subject: "issue#1 password reset",
+		Body: fmt.Sprintf(`Hello %s,
+
+A password reset was requested for your account. Use the following token to set
+a new password. It expires in %s and can only be used once.
+
+%s
+
+If you didn't request this, you can safely ignore this message.
+`, u.Username, s.PasswordResetLifetime, resetToken),
+	})
+}
+
+// ResetPassword sets the password of the user the given reset token was issued for.
+// The token is consumed in the process and all the sessions of the user are
+// revoked along with their refresh tokens, whoever held the old password is
+// logged out. Call it in a unit of work for all of it to take effect together.
+func (s *jWTAuthenticationBackend) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	if len(newPassword) < 8 {
+		return ErrInvalidPassword
+	}
+	prt, err := (*s.repo).ConsumePasswordResetToken(ctx, hashToken(resetToken))
+	if err != nil {
+		return err
+	}
+	if err = (*s.repo).SetPassword(ctx, prt.Username, newPassword); err != nil {
+		return err
+	}
+	return (*s.repo).RevokeSessions(ctx, prt.Username, "")
+}
+
+// RequestEmailVerification issues a single use email verification token for the
+// given user and mails it to the user's current email address.
+// Requests made before EmailVerificationResendInterval passes since the last one are refused.
+func (s *jWTAuthenticationBackend) RequestEmailVerification(ctx context.Context, username string) error {
+	u, err := (*s.repo).GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	last, err := (*s.repo).GetLastEmailVerificationTime(ctx, u.Username)
+	if err != nil {
+		return err
+	}
+	if time.Since(last) < s.EmailVerificationResendInterval {
+		return ErrEmailVerificationThrottled
+	}
+	verificationToken, err := generateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("email verification token generation failed because %v", err)
+	}
+	now := time.Now()
+	err = (*s.repo).AddEmailVerificationToken(ctx, &EmailVerificationToken{
+		TokenHash:    hashToken(verificationToken),
+		Username:     u.Username,
+		Email:        u.Email,
+		CreationTime: now,
+		ExpiresAt:    now.Add(s.EmailVerificationLifetime),
+	})
+	if err != nil {
+		return fmt.Errorf("email verification token persistence failed because %v", err)
+	}
+	return s.mailer.Send(&mail.Message{
+		To:      []string{u.Email},
+		Subject: "issue#1 email verification",
+		Body: fmt.Sprintf(`Hello %s,
+
+Use the following token to verify this email address for your account.
+It expires in %s and can only be used once.
+
+%s
+
+Until verified, you won't be able to post, comment or star posts.
+`, u.Username, s.EmailVerificationLifetime, verificationToken),
+	})
+}
+
+// VerifyEmail consumes the given email verification token and returns the
+// username of the user whose email it verifies.
+// Tokens issued for an email the user has since changed aren't accepted.
+func (s *jWTAuthenticationBackend) VerifyEmail(ctx context.Context, verificationToken string) (string, error) {
+	evt, err := (*s.repo).ConsumeEmailVerificationToken(ctx, hashToken(verificationToken))
+	if err != nil {
+		return "", err
+	}
+	return evt.Username, nil
+}
+
+// EnrollTOTP generates a new TOTP secret and recovery codes for the user.
+// The enrolment replaces any previous unconfirmed one and only takes
+// effect once confirmed through ConfirmTOTP.
+func (s *jWTAuthenticationBackend) EnrollTOTP(ctx context.Context, username string) (*TOTPEnrollment, error) {
+	switch ts, err := (*s.repo).GetTOTPSecret(ctx, username); err {
+	case nil:
+		if ts.Confirmed {
+			return nil, ErrTOTPAlreadyEnabled
+		}
+	case ErrTOTPNotEnrolled:
+	default:
+		return nil, err
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("totp secret generation failed because %v", err)
+	}
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("recovery code generation failed because %v", err)
+	}
+	ts := &TOTPSecret{
+		Username:     username,
+		Secret:       secret,
+		CreationTime: time.Now(),
+	}
+	for _, code := range recoveryCodes {
+		ts.RecoveryCodeHashes = append(ts.RecoveryCodeHashes, hashRecoveryCode(code))
+	}
+	if err = (*s.repo).SetTOTPSecret(ctx, ts); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(s.TOTPIssuer, username, secret),
+		RecoveryCodes:   recoveryCodes,
+	}, nil
+}
+
+// ConfirmTOTP enables the pending TOTP enrolment of the user if the given code is valid for it.
+func (s *jWTAuthenticationBackend) ConfirmTOTP(ctx context.Context, username, code string) error {
+	ts, err := (*s.repo).GetTOTPSecret(ctx, username)
+	if err != nil {
+		return err
+	}
+	if ts.Confirmed {
+		return ErrTOTPAlreadyEnabled
+	}
+	if err = s.checkTOTPCode(ctx, ts, code, false); err != nil {
+		return err
+	}
+	return (*s.repo).ConfirmTOTPSecret(ctx, username)
+}
+
+// DisableTOTP removes the TOTP enrolment of the user.
+// A valid TOTP or recovery code is required to do so.
+func (s *jWTAuthenticationBackend) DisableTOTP(ctx context.Context, username, code string) error {
+	ts, err := (*s.repo).GetTOTPSecret(ctx, username)
+	if err != nil {
+		return err
+	}
+	if ts.Confirmed {
+		if err = s.checkTOTPCode(ctx, ts, code, true); err != nil {
+			return err
+		}
+	}
+	return (*s.repo).DeleteTOTPSecret(ctx, username)
+}
+
+// IsTOTPEnabled checks whether the user has a confirmed TOTP enrolment.
+func (s *jWTAuthenticationBackend) IsTOTPEnabled(ctx context.Context, username string) (bool, error) {
+	ts, err := (*s.repo).GetTOTPSecret(ctx, username)
+	switch err {
+	case nil:
+		return ts.Confirmed, nil
+	case ErrTOTPNotEnrolled:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// IssueTOTPChallenge generates a short lived challenge token for a user that
+// has passed password authentication but still has to present a TOTP code.
+func (s *jWTAuthenticationBackend) IssueTOTPChallenge(ctx context.Context, username string) (*TOTPChallenge, error) {
+	jti, err := generateTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("token id generation failed because %v", err)
+	}
+	mapClaim := jwt.MapClaims{}
+	mapClaim["jti"] = jti
+	mapClaim["exp"] = time.Now().Add(s.TOTPChallengeLifetime).Unix()
+	mapClaim["iat"] = time.Now().Unix()
 	mapClaim["sub"] = username
-	token.Claims = mapClaim
-	tokenString, err := token.SignedString(s.TokenSigningSecret)
+	mapClaim["typ"] = TokenTypeTOTPChallenge
+	tokenString, err := s.TokenSigningKeys.Sign(mapClaim)
 	if err != nil {
-		return "", fmt.Errorf("token signing failed because %v", err)
+		return nil, fmt.Errorf("token signing failed because %v", err)
 	}
-	return tokenString, nil
+	return &TOTPChallenge{ChallengeToken: tokenString, ExpiresIn: int(s.TOTPChallengeLifetime.Seconds())}, nil
 }
 
-// Authenticate checks whether the given User struct holds appropriate credentials
-func (s *jWTAuthenticationBackend) Authenticate(user *User) (bool, error) {
-	return (*s.repo).Authenticate(user)
+// GetTOTPChallengeUsername returns the username of the user the challenge token
+// was issued to, ErrInvalidTOTPChallenge if it's invalid, expired or used.
+func (s *jWTAuthenticationBackend) GetTOTPChallengeUsername(ctx context.Context, challengeToken string) (string, error) {
+	username, _, _, err := s.parseTOTPChallenge(ctx, challengeToken)
+	return username, err
+}
+
+// CompleteTOTPChallenge trades a challenge token and a valid TOTP or recovery
+// code of its user for a new TokenPair, starting a new Session for the given client.
+// A challenge is good for a single attempt, it's used up whether the code is
+// valid or not so that guessing codes takes a password login per guess.
+func (s *jWTAuthenticationBackend) CompleteTOTPChallenge(ctx context.Context, challengeToken, code, userAgent, ipAddress string) (*TokenPair, error) {
+	username, jti, expiresAt, err := s.parseTOTPChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if err = (*s.repo).AddToBlacklist(ctx, jti, expiresAt); err != nil {
+		return nil, err
+	}
+	ts, err := (*s.repo).GetTOTPSecret(ctx, username)
+	switch err {
+	case nil:
+	case ErrTOTPNotEnrolled:
+		return nil, ErrInvalidTOTPChallenge
+	default:
+		return nil, err
+	}
+	if err = s.checkTOTPCode(ctx, ts, code, true); err != nil {
+		return nil, err
+	}
+	return s.IssueTokens(ctx, username, userAgent, ipAddress)
+}
+
+// parseTOTPChallenge is a helper function that validates the challenge token and
+// returns its subject, id and expiry. Used up challenges are invalid.
+func (s *jWTAuthenticationBackend) parseTOTPChallenge(ctx context.Context, challengeToken string) (string, string, time.Time, error) {
+	token, err := jwt.Parse(challengeToken, s.TokenSigningKeys.Keyfunc)
+	if err != nil || !token.Valid {
+		return "", "", time.Time{}, ErrInvalidTOTPChallenge
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	username, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	if typ, _ := claims["typ"].(string); typ != TokenTypeTOTPChallenge || username == "" || jti == "" {
+		return "", "", time.Time{}, ErrInvalidTOTPChallenge
+	}
+	used, err := (*s.repo).IsInBlacklist(ctx, jti)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	if used {
+		return "", "", time.Time{}, ErrInvalidTOTPChallenge
+	}
+	return username, jti, time.Unix(int64(exp), 0), nil
+}
+
+// checkTOTPCode is a helper function that validates the code against the secret,
+// or, if allowed, against the recovery codes of the user.
+// Accepted codes are consumed so that they can't be replayed.
+func (s *jWTAuthenticationBackend) checkTOTPCode(ctx context.Context, ts *TOTPSecret, code string, allowRecoveryCode bool) error {
+	if step, ok := validateTOTPCode(ts.Secret, code, time.Now()); ok {
+		if step <= ts.LastUsedStep {
+			return ErrInvalidTOTPCode
+		}
+		updated, err := (*s.repo).UpdateTOTPLastUsedStep(ctx, ts.Username, step)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrInvalidTOTPCode
+		}
+		return nil
+	}
+	if allowRecoveryCode {
+		consumed, err := (*s.repo).ConsumeTOTPRecoveryCode(ctx, ts.Username, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if consumed {
+			return nil
+		}
+	}
+	return ErrInvalidTOTPCode
+}
+
+// CreatePersonalAccessToken generates and persists a new personal access token
+// for the given user. A lifetime of zero creates a token that never expires.
+// The returned struct is the only place the token itself can be found.
+func (s *jWTAuthenticationBackend) CreatePersonalAccessToken(ctx context.Context, username, name string, scopes []string, lifetime time.Duration) (*PersonalAccessToken, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !isGrantableScope(scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	id, err := generateTokenID()
+	if err != nil {
+		return nil, fmt.Errorf("personal access token id generation failed because %v", err)
+	}
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("personal access token generation failed because %v", err)
+	}
+	token = PersonalAccessTokenPrefix + token
+	pat := &PersonalAccessToken{
+		ID:           id,
+		TokenHash:    hashToken(token),
+		Username:     username,
+		Name:         name,
+		Scopes:       scopes,
+		CreationTime: time.Now(),
+	}
+	if lifetime > 0 {
+		expiresAt := pat.CreationTime.Add(lifetime)
+		pat.ExpiresAt = &expiresAt
+	}
+	if err = (*s.repo).AddPersonalAccessToken(ctx, pat); err != nil {
+		return nil, err
+	}
+	pat.Token = token
+	return pat, nil
+}
+
+// GetPersonalAccessTokens returns the personal access tokens of the given user
+// that haven't been revoked.
+func (s *jWTAuthenticationBackend) GetPersonalAccessTokens(ctx context.Context, username string) ([]*PersonalAccessToken, error) {
+	return (*s.repo).GetPersonalAccessTokens(ctx, username)
+}
+
+// RevokePersonalAccessToken revokes the personal access token of the given user with the given id.
+func (s *jWTAuthenticationBackend) RevokePersonalAccessToken(ctx context.Context, username, id string) error {
+	return (*s.repo).RevokePersonalAccessToken(ctx, username, id)
+}
+
+// AuthenticatePersonalAccessToken returns a Principal holding the scopes of the given
+// personal access token if it's valid. It also records the time the token was last used.
+func (s *jWTAuthenticationBackend) AuthenticatePersonalAccessToken(ctx context.Context, token string) (*Principal, error) {
+	token = strings.TrimPrefix(token, "Bearer ")
+	if !strings.HasPrefix(token, PersonalAccessTokenPrefix) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+	pat, err := (*s.repo).GetPersonalAccessToken(ctx, hashToken(token))
+	switch {
+	case err == ErrPersonalAccessTokenNotFound:
+		return nil, ErrInvalidPersonalAccessToken
+	case err != nil:
+		return nil, err
+	}
+	now := time.Now()
+	if pat.Revoked || (pat.ExpiresAt != nil && now.After(*pat.ExpiresAt)) {
+		return nil, ErrInvalidPersonalAccessToken
+	}
+	if err = (*s.repo).UpdatePersonalAccessTokenLastUsed(ctx, pat.ID, now); err != nil {
+		return nil, err
+	}
+	principal := &Principal{
+		Username:  pat.Username,
+		TokenID:   pat.ID,
+		TokenType: TokenTypePersonalAccessToken,
+		Scopes:    pat.Scopes,
+	}
+	if pat.ExpiresAt != nil {
+		principal.ExpiresAt = *pat.ExpiresAt
+	}
+	return principal, nil
+}
+
+// isGrantableScope is a helper function that checks whether the scope is one of the GrantableScopes.
+func isGrantableScope(scope string) bool {
+	for _, grantable := range GrantableScopes {
+		if scope == grantable {
+			return true
+		}
+	}
+	return false
+}
+
+// GetJSONWebKeySet returns the public keys tokens issued by the service can be verified with.
+func (s *jWTAuthenticationBackend) GetJSONWebKeySet() *JSONWebKeySet {
+	return s.TokenSigningKeys.JSONWebKeySet()
 }
 
 // AddToBlacklist adds a given token to the list of tokens that can not be used no more.
-func (s *jWTAuthenticationBackend) AddToBlacklist(tokenString string) error {
-	return (*s.repo).AddToBlacklist(tokenString)
+// The token is only kept in the blacklist until it's past its refresh lifetime.
+func (s *jWTAuthenticationBackend) AddToBlacklist(ctx context.Context, tokenString string) error {
+	tokenID, expiresAt, err := s.blacklistEntry(tokenString)
+	if err != nil {
+		return err
+	}
+	return (*s.repo).AddToBlacklist(ctx, tokenID, expiresAt)
 }
 
 // IsInBlacklist checks whether a given token is invalidated previously.
-func (s *jWTAuthenticationBackend) IsInBlacklist(tokenString string) (bool, error) {
-	return (*s.repo).IsInBlacklist(tokenString)
+func (s *jWTAuthenticationBackend) IsInBlacklist(ctx context.Context, tokenString string) (bool, error) {
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return false, nil
+	}
+	return (*s.repo).IsInBlacklist(ctx, tokenIDFromClaims(tokenString, token.Claims.(jwt.MapClaims)))
+}
+
+// blacklistEntry is a helper function that extracts the id and the time after which the
+// given token can be dropped from the blacklist.
+func (s *jWTAuthenticationBackend) blacklistEntry(tokenString string) (string, time.Time, error) {
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenString, s.TokenSigningKeys.Keyfunc)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token parsing failed because %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	expiresAt := time.Now().Add(s.TokenAccessLifetime + s.TokenRefreshLifetime)
+	if exp, ok := claims["exp"].(float64); ok {
+		expiresAt = time.Unix(int64(exp), 0).Add(s.TokenRefreshLifetime)
+	}
+	return tokenIDFromClaims(tokenString, claims), expiresAt, nil
+}
+
+// tokenIDFromClaims returns the jti claim of the token or, for tokens
+// issued without one, the hex encoded SHA-256 hash of the token itself.
+func tokenIDFromClaims(tokenString string, claims jwt.MapClaims) string {
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		return jti
+	}
+	return hashToken(tokenString)
+}
+
+// hashToken returns the hex encoded SHA-256 hash of the given token.
+func hashToken(tokenString string) string {
+	hash := sha256.Sum256([]byte(tokenString))
+	return hex.EncodeToString(hash[:])
+}
+
+// generateTokenID returns a random URL-safe string used as the jti of tokens.
+func generateTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// generateOpaqueToken returns a random opaque string used as refresh, password reset,
+// email verification and personal access tokens.
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
 /*func (backend *JWTAuthenticationBackend) getTokenRemainingValidity(timestamp interface{}) int {
@@ -4,16 +4,19 @@ import "time"
 
 // Channel represents a singular stream of posts that a user can subscribe to
 // under adminstration by certain users.
+// PictureURLs holds the URL of each size the picture is served in, keyed by
+// the name of the size, it's filled in when the channel is served, never stored.
 type Channel struct {
-	ChannelUsername    string    `json:"channelUsername"`
-	Name               string    `json:"name,omitempty"`
-	Description        string    `json:"description,omitempty"`
-	PictureURL         string    `json:"pictureURL,omitempty"`
-	OwnerUsername      string    `json:"ownerUsername,omitempty"`
-	AdminUsernames     []string  `json:"adminUsernames,omitempty"`
-	PostIDs            []uint    `json:"postIDs,omitempty"`
-	StickiedPostIDs    []uint    `json:"stickiedPostIDs,omitempty "`
-	ReleaseIDs         []uint    `json:"releaseIDs,omitempty"`
-	OfficialReleaseIDs []uint    `json:"officialReleaseIDs,omitempty"`
-	CreationTime       time.Time `json:"creationTime,omitempty"`
+	ChannelUsername    string            `json:"channelUsername"`
+	Name               string            `json:"name,omitempty"`
+	Description        string            `json:"description,omitempty"`
+	PictureURL         string            `json:"pictureURL,omitempty"`
+	PictureURLs        map[string]string `json:"pictureURLs,omitempty"`
+	OwnerUsername      string            `json:"ownerUsername,omitempty"`
+	AdminUsernames     []string          `json:"adminUsernames,omitempty"`
+	PostIDs            []uint            `json:"postIDs,omitempty"`
+	StickiedPostIDs    []uint            `json:"stickiedPostIDs,omitempty "`
+	ReleaseIDs         []uint            `json:"releaseIDs,omitempty"`
+	OfficialReleaseIDs []uint            `json:"officialReleaseIDs,omitempty"`
+	CreationTime       time.Time         `json:"creationTime,omitempty"`
 }
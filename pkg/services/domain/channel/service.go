@@ -2,41 +2,46 @@
 Package channel contains definition and implemntation of a service that deals with User entities */
 package channel
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
+)
 
 type Service interface {
-	AddChannel(channel *Channel) (*Channel, error)
-	GetChannel(username string) (*Channel, error)
-	UpdateChannel(username string, channel *Channel) (*Channel, error)
-	SearchChannels(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Channel, error)
-	DeleteChannel(username string) error
-	AddAdmin(channelUsername string, adminUsername string) error
-	DeleteAdmin(channelUsername string, adminUsername string) error
-	ChangeOwner(channelUsername string, ownerUsername string) error
-	DeleteReleaseFromCatalog(channelUsername string, ReleaseID uint) error
-	DeleteReleaseFromOfficialCatalog(channelUsername string, ReleaseID uint) error
-	AddReleaseToOfficialCatalog(channelUsername string, releaseID uint, postID uint) error
-	DeleteStickiedPost(channelUsername string, stickiedPostID uint) error
-	StickyPost(channelUsername string, postID uint) error
-	AddPicture(channelUsername string, name string) (string, error)
-	RemovePicture(channelUsername string) error
+	AddChannel(ctx context.Context, channel *Channel) (*Channel, error)
+	GetChannel(ctx context.Context, username string) (*Channel, error)
+	UpdateChannel(ctx context.Context, username string, channel *Channel) (*Channel, error)
+	SearchChannels(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Channel, error)
+	DeleteChannel(ctx context.Context, username string) error
+	AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error
+	DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error
+	ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error
+	DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error
+	DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error
+	AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error
+	DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error
+	StickyPost(ctx context.Context, channelUsername string, postID uint) error
+	AddPicture(ctx context.Context, channelUsername string, name string) (string, error)
+	RemovePicture(ctx context.Context, channelUsername string) error
 }
 type Repository interface {
-	AddChannel(channel *Channel) (*Channel, error)
-	GetChannel(username string) (*Channel, error)
-	UpdateChannel(username string, channel *Channel) (*Channel, error)
-	SearchChannels(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Channel, error)
-	DeleteChannel(username string) error
-	AddAdmin(channelUsername string, adminUsername string) error
-	DeleteAdmin(channelUsername string, adminUsername string) error
-	ChangeOwner(channelUsername string, ownerUsername string) error
-	DeleteReleaseFromCatalog(channelUsername string, ReleaseID uint) error
-	DeleteReleaseFromOfficialCatalog(channelUsername string, ReleaseID uint) error
-	AddReleaseToOfficialCatalog(channelUsername string, releaseID uint, postID uint) error
-	DeleteStickiedPost(channelUsername string, stickiedPostID uint) error
-	StickyPost(channelUsername string, postID uint) error
-	AddPicture(channelUsername string, name string) (string, error)
-	RemovePicture(channelUsername string) error
+	AddChannel(ctx context.Context, channel *Channel) (*Channel, error)
+	GetChannel(ctx context.Context, username string) (*Channel, error)
+	UpdateChannel(ctx context.Context, username string, channel *Channel) (*Channel, error)
+	SearchChannels(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Channel, error)
+	DeleteChannel(ctx context.Context, username string) error
+	AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error
+	DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error
+	ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error
+	DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error
+	DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error
+	AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error
+	DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error
+	StickyPost(ctx context.Context, channelUsername string, postID uint) error
+	AddPicture(ctx context.Context, channelUsername string, name string) (string, error)
+	RemovePicture(ctx context.Context, channelUsername string) error
 }
 type SortOrder string
 type SortBy string
@@ -100,9 +105,25 @@ func NewService(repo *Repository, allServices *map[string]interface{}) Service {
 	return s
 }
 
+// authorize is a helper function that consults the policy service on whether
+// the principal behind ctx may perform the action on the given channel.
+func (service *service) authorize(ctx context.Context, action policy.Action, c *Channel) error {
+	return service.authorizeOn(ctx, action, &policy.Resource{ChannelOwner: c.OwnerUsername, ChannelAdmins: c.AdminUsernames})
+}
+
+// authorizeOn is a helper function that consults the policy service on whether
+// the principal behind ctx may perform the action on the given resource.
+func (service *service) authorizeOn(ctx context.Context, action policy.Action, resource *policy.Resource) error {
+	temp, ok := (*service.allServices)["Policy"]
+	if !ok {
+		return fmt.Errorf("policy service not available")
+	}
+	return policy.Authorize(ctx, *temp.(*policy.Service), action, resource)
+}
+
 //
-func (service *service) IsPostFromChannel(channelUsername string, postID uint) bool {
-	c, _ := service.GetChannel(channelUsername)
+func (service *service) IsPostFromChannel(ctx context.Context, channelUsername string, postID uint) bool {
+	c, _ := service.GetChannel(ctx, channelUsername)
 	i := 0
 	for ; i < len(c.PostIDs); i++ {
 		if c.PostIDs[i] == postID {
@@ -113,81 +134,99 @@ func (service *service) IsPostFromChannel(channelUsername string, postID uint) b
 }
 
 // AddChannel adds a channel
-func (service *service) AddChannel(channel *Channel) (*Channel, error) {
+func (service *service) AddChannel(ctx context.Context, channel *Channel) (*Channel, error) {
 	if channel.Name == "" && channel.ChannelUsername == "" {
 		return nil, ErrInvalidChannelData
 	}
-	a, _ := service.GetChannel(channel.ChannelUsername)
+	if err := service.authorizeOn(ctx, policy.ChannelCreate, &policy.Resource{Username: channel.OwnerUsername}); err != nil {
+		return nil, err
+	}
+	a, _ := service.GetChannel(ctx, channel.ChannelUsername)
 	if a != nil {
 		return nil, ErrUserNameOccupied
 	}
-	return (*service.repo).AddChannel(channel)
+	return (*service.repo).AddChannel(ctx, channel)
 }
 
 // GetChannel gets a channel according to the given username
-func (service *service) GetChannel(username string) (*Channel, error) {
-	return (*service.repo).GetChannel(username)
+func (service *service) GetChannel(ctx context.Context, username string) (*Channel, error) {
+	return (*service.repo).GetChannel(ctx, username)
 }
 
 // UpdateChannel updates a channel according to the given username and channel
-func (service *service) UpdateChannel(username string, channel *Channel) (*Channel, error) {
-	_, err := service.GetChannel(username)
+func (service *service) UpdateChannel(ctx context.Context, username string, channel *Channel) (*Channel, error) {
+	c, err := service.GetChannel(ctx, username)
 	if err != nil {
 		//fmt.Errorf("channel can't be updated because %s", err.Error())
 		return nil, err
 	}
-	a, _ := service.GetChannel(channel.ChannelUsername)
+	if err := service.authorize(ctx, policy.ChannelUpdate, c); err != nil {
+		return nil, err
+	}
+	a, _ := service.GetChannel(ctx, channel.ChannelUsername)
 	if a != nil {
 		return nil, ErrUserNameOccupied
 	}
-	return (*service.repo).UpdateChannel(username, channel)
+	return (*service.repo).UpdateChannel(ctx, username, channel)
 }
 
 // SearchChannels searches the channel of the given username
-func (service *service) SearchChannels(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Channel, error) {
+func (service *service) SearchChannels(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Channel, error) {
 	if limit < 0 || offset < 0 {
 		return nil, fmt.Errorf("invalid pagination")
 	}
-	return (*service.repo).SearchChannels(pattern, sortBy, sortOrder, limit, offset)
+	return (*service.repo).SearchChannels(ctx, pattern, sortBy, sortOrder, limit, offset)
 }
 
 // DeleteChannel removes the channel of the given username
-func (service *service) DeleteChannel(username string) error {
-	_, err := service.GetChannel(username)
+func (service *service) DeleteChannel(ctx context.Context, username string) error {
+	c, err := service.GetChannel(ctx, username)
 	if err != nil {
 		//fmt.Errorf("channel can't be deleted because %s", err.Error())
 		return err
 	}
-	return (*service.repo).DeleteChannel(username)
+	if err := service.authorize(ctx, policy.ChannelDelete, c); err != nil {
+		return err
+	}
+	return (*service.repo).DeleteChannel(ctx, username)
 }
 
 // AddAdmin adds the given admin adminUsername from the channel of given username,ChannelUsername
-func (service *service) AddAdmin(channelUsername string, adminUsername string) error {
-	_, err := service.GetChannel(channelUsername)
+func (service *service) AddAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can'add admin because %s", err.Error())
 		return err
 	}
-	return (*service.repo).AddAdmin(channelUsername, adminUsername)
+	if err := service.authorize(ctx, policy.ChannelAddAdmin, c); err != nil {
+		return err
+	}
+	return (*service.repo).AddAdmin(ctx, channelUsername, adminUsername)
 }
 
 // DeleteAdmin deletes the given admin adminUsername from the channel of given username,ChannelUsername
-func (service *service) DeleteAdmin(channelUsername string, adminUsername string) error {
-	_, err := service.GetChannel(channelUsername)
+func (service *service) DeleteAdmin(ctx context.Context, channelUsername string, adminUsername string) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can'delete admin because %s", err.Error())
 		return err
 	}
-	return (*service.repo).DeleteAdmin(channelUsername, adminUsername)
+	if err := service.authorize(ctx, policy.ChannelRemoveAdmin, c); err != nil {
+		return err
+	}
+	return (*service.repo).DeleteAdmin(ctx, channelUsername, adminUsername)
 }
 
 // DeleteReleaseFromOfficialCatalog deletes the given release ReleaseID from the catalog of channel of given username,ChannelUsername
-func (service *service) DeleteReleaseFromCatalog(channelUsername string, ReleaseID uint) error {
-	c, err := service.GetChannel(channelUsername)
+func (service *service) DeleteReleaseFromCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can'delete release because %s", err.Error())
 		return err
 	}
+	if err := service.authorize(ctx, policy.ChannelManageCatalog, c); err != nil {
+		return err
+	}
 	i := 0
 	oia := false
 
@@ -204,17 +243,20 @@ func (service *service) DeleteReleaseFromCatalog(channelUsername string, Release
 		fmt.Printf("%d", ReleaseID)
 		return ErrReleaseNotFound
 	}
-	return (*service.repo).DeleteReleaseFromCatalog(channelUsername, ReleaseID)
+	return (*service.repo).DeleteReleaseFromCatalog(ctx, channelUsername, ReleaseID)
 }
 
 // DeleteReleaseFromOfficialCatalog deletes the given release ReleaseID from the official catalog of channel of given username,ChannelUsername
-func (service *service) DeleteReleaseFromOfficialCatalog(channelUsername string, ReleaseID uint) error {
+func (service *service) DeleteReleaseFromOfficialCatalog(ctx context.Context, channelUsername string, ReleaseID uint) error {
 
-	c, err := service.GetChannel(channelUsername)
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can'delete release because %s", err.Error())
 		return err
 	}
+	if err := service.authorize(ctx, policy.ChannelManageCatalog, c); err != nil {
+		return err
+	}
 	i := 0
 	oia := false
 
@@ -229,29 +271,35 @@ func (service *service) DeleteReleaseFromOfficialCatalog(channelUsername string,
 	if oia == false {
 		return ErrReleaseNotFound
 	}
-	return (*service.repo).DeleteReleaseFromOfficialCatalog(channelUsername, ReleaseID)
+	return (*service.repo).DeleteReleaseFromOfficialCatalog(ctx, channelUsername, ReleaseID)
 }
 
 // AddReleaseToOfficialCatalog adds the given release ReleaseID from the official catalog of channel of given username,ChannelUsername
-func (service *service) AddReleaseToOfficialCatalog(channelUsername string, releaseID uint, postID uint) error {
-	_, err := service.GetChannel(channelUsername)
+func (service *service) AddReleaseToOfficialCatalog(ctx context.Context, channelUsername string, releaseID uint, postID uint) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel add  release because %s", err.Error())
 		return err
 	}
-	if !service.IsPostFromChannel(channelUsername, postID) {
+	if err := service.authorize(ctx, policy.ChannelManageCatalog, c); err != nil {
+		return err
+	}
+	if !service.IsPostFromChannel(ctx, channelUsername, postID) {
 		return ErrPostNotFound
 	}
-	return (*service.repo).AddReleaseToOfficialCatalog(channelUsername, releaseID, postID)
+	return (*service.repo).AddReleaseToOfficialCatalog(ctx, channelUsername, releaseID, postID)
 }
 
 // DeleteStickiedPost deletes the given post id from stickied post for the channel of given username,channelUsername.
-func (service *service) DeleteStickiedPost(channelUsername string, stickiedPostID uint) error {
-	c, err := service.GetChannel(channelUsername)
+func (service *service) DeleteStickiedPost(ctx context.Context, channelUsername string, stickiedPostID uint) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can'delete stickied post because %s", err.Error())
 		return err
 	}
+	if err := service.authorize(ctx, policy.ChannelManageStickies, c); err != nil {
+		return err
+	}
 	i := 0
 	oia := false
 
@@ -266,16 +314,19 @@ func (service *service) DeleteStickiedPost(channelUsername string, stickiedPostI
 	if oia == false {
 		return ErrStickiedPostNotFound
 	}
-	return (*service.repo).DeleteStickiedPost(channelUsername, stickiedPostID)
+	return (*service.repo).DeleteStickiedPost(ctx, channelUsername, stickiedPostID)
 }
 
 // ChangeOwner changes the given ownerUsername as the owner for the given channel of username channelUsername.
-func (service *service) ChangeOwner(channelUsername string, ownerUsername string) error {
-	c, err := service.GetChannel(channelUsername)
+func (service *service) ChangeOwner(ctx context.Context, channelUsername string, ownerUsername string) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can't change owner because %s", err.Error())
 		return err
 	}
+	if err := service.authorize(ctx, policy.ChannelChangeOwner, c); err != nil {
+		return err
+	}
 
 	i := 0
 	oia := false
@@ -291,16 +342,19 @@ func (service *service) ChangeOwner(channelUsername string, ownerUsername string
 	if oia == false {
 		return ErrOwnerToBeNotAdmin
 	}
-	return (*service.repo).ChangeOwner(channelUsername, ownerUsername)
+	return (*service.repo).ChangeOwner(ctx, channelUsername, ownerUsername)
 }
 
 // StickyPost sticks the given postID for the channel of the given username on top of the post view of channel.
-func (service *service) StickyPost(channelUsername string, postID uint) error {
-	c, err := service.GetChannel(channelUsername)
+func (service *service) StickyPost(ctx context.Context, channelUsername string, postID uint) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can't sticky post because %s", err.Error())
 		return err
 	}
+	if err := service.authorize(ctx, policy.ChannelManageStickies, c); err != nil {
+		return err
+	}
 	i := 0
 	oia := false
 
@@ -315,25 +369,31 @@ func (service *service) StickyPost(channelUsername string, postID uint) error {
 	if oia == true {
 		return ErrPostAlreadyStickied
 	}
-	return (*service.repo).StickyPost(channelUsername, postID)
+	return (*service.repo).StickyPost(ctx, channelUsername, postID)
 }
 
 // AddPicture adds the given image name as the picture for the given username.
-func (service *service) AddPicture(channelUsername string, name string) (string, error) {
-	_, err := service.GetChannel(channelUsername)
+func (service *service) AddPicture(ctx context.Context, channelUsername string, name string) (string, error) {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can't add picture because %s", err.Error())
 		return "", err
 	}
-	return (*service.repo).AddPicture(channelUsername, name)
+	if err := service.authorize(ctx, policy.ChannelManagePicture, c); err != nil {
+		return "", err
+	}
+	return (*service.repo).AddPicture(ctx, channelUsername, name)
 }
 
 // RemovePicture removes the picture for the given username.
-func (service *service) RemovePicture(channelUsername string) error {
-	_, err := service.GetChannel(channelUsername)
+func (service *service) RemovePicture(ctx context.Context, channelUsername string) error {
+	c, err := service.GetChannel(ctx, channelUsername)
 	if err != nil {
 		//fmt.Errorf("channel can't remove picture because %s", err.Error())
 		return err
 	}
-	return (*service.repo).RemovePicture(channelUsername)
+	if err := service.authorize(ctx, policy.ChannelManagePicture, c); err != nil {
+		return err
+	}
+	return (*service.repo).RemovePicture(ctx, channelUsername)
 }
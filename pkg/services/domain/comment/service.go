@@ -2,26 +2,31 @@
 Package comment contains definition and implementation of a service that deals with User entities */
 package comment
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
+)
 
 // Service specifies a method to service Comment entities.
 type Service interface {
-	AddComment(c *Comment) (*Comment, error)
-	GetComment(id int) (*Comment, error)
-	GetComments(postID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error)
-	GetReplies(commentID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error)
-	UpdateComment(c *Comment) (*Comment, error)
-	DeleteComment(id int) error
+	AddComment(ctx context.Context, c *Comment) (*Comment, error)
+	GetComment(ctx context.Context, id int) (*Comment, error)
+	GetComments(ctx context.Context, postID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error)
+	GetReplies(ctx context.Context, commentID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error)
+	UpdateComment(ctx context.Context, c *Comment) (*Comment, error)
+	DeleteComment(ctx context.Context, id int) error
 }
 
 // Repository specifies a repo interface to serve the Comment Service interface
 type Repository interface {
-	AddComment(c *Comment) (*Comment, error)
-	GetComment(id int) (*Comment, error)
-	GetComments(postID int, sortBy string, sortOrder string, limit, offset int) ([]*Comment, error)
-	GetReplies(commentID int, by string, order string, limit, offset int) ([]*Comment, error)
-	UpdateComment(c *Comment) (*Comment, error)
-	DeleteComment(id int) error
+	AddComment(ctx context.Context, c *Comment) (*Comment, error)
+	GetComment(ctx context.Context, id int) (*Comment, error)
+	GetComments(ctx context.Context, postID int, sortBy string, sortOrder string, limit, offset int) ([]*Comment, error)
+	GetReplies(ctx context.Context, commentID int, by string, order string, limit, offset int) ([]*Comment, error)
+	UpdateComment(ctx context.Context, c *Comment) (*Comment, error)
+	DeleteComment(ctx context.Context, id int) error
 }
 
 // SortOrder holds  that specify how comments are sorted
@@ -50,18 +55,32 @@ var ErrSomeCommentDataNotPersisted = fmt.Errorf("was not able to persist some us
 var ErrCommentNotFound = fmt.Errorf("comment not found")
 
 type service struct {
-	repo *Repository
+	allServices *map[string]interface{}
+	repo        *Repository
 }
 
 // NewService returns a struct that implements the comment.Service interface
-func NewService(repo *Repository) Service {
-	return &service{repo: repo}
+func NewService(repo *Repository, allServices *map[string]interface{}) Service {
+	return &service{allServices: allServices, repo: repo}
+}
+
+// authorize is a helper function that consults the policy service on whether
+// the principal behind ctx may perform the action on the resource.
+func (s service) authorize(ctx context.Context, action policy.Action, resource *policy.Resource) error {
+	temp, ok := (*s.allServices)["Policy"]
+	if !ok {
+		return fmt.Errorf("policy service not available")
+	}
+	return policy.Authorize(ctx, *temp.(*policy.Service), action, resource)
 }
 
 // AddComment adds an new comment based on the passed in struct
-func (s service) AddComment(c *Comment) (*Comment, error) {
+func (s service) AddComment(ctx context.Context, c *Comment) (*Comment, error) {
+	if err := s.authorize(ctx, policy.CommentCreate, &policy.Resource{Username: c.Commenter}); err != nil {
+		return nil, err
+	}
 	if c.ReplyTo != -1 {
-		if temp, err := s.GetComment(c.ReplyTo); err != nil {
+		if temp, err := s.GetComment(ctx, c.ReplyTo); err != nil {
 			return nil, err
 		} else {
 			if temp.OriginPost != c.OriginPost {
@@ -69,35 +88,46 @@ func (s service) AddComment(c *Comment) (*Comment, error) {
 			}
 		}
 	}
-	return (*s.repo).AddComment(c)
+	return (*s.repo).AddComment(ctx, c)
 }
 
 // GetComment gets the comment stored under the given id.
-func (s service) GetComment(id int) (*Comment, error) {
-	return (*s.repo).GetComment(id)
+func (s service) GetComment(ctx context.Context, id int) (*Comment, error) {
+	return (*s.repo).GetComment(ctx, id)
 }
 
 // GetComment get's all the comments found under a single post.
 // This includes replies to comments.
-func (s service) GetComments(postID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error) {
-	return (*s.repo).GetComments(postID, string(by), string(order), limit, offset)
+func (s service) GetComments(ctx context.Context, postID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error) {
+	return (*s.repo).GetComments(ctx, postID, string(by), string(order), limit, offset)
 }
 
 // GetReplies returns all the comments that are replies to the comment
 // under the given id.
-func (s service) GetReplies(commentID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error) {
-	return (*s.repo).GetReplies(commentID, string(by), string(order), limit, offset)
+func (s service) GetReplies(ctx context.Context, commentID int, by SortBy, order SortOrder, limit, offset int) ([]*Comment, error) {
+	return (*s.repo).GetReplies(ctx, commentID, string(by), string(order), limit, offset)
 }
 
 // UpdateComment updates a comment entity based on the given struct.
-func (s service) UpdateComment(c *Comment) (*Comment, error) {
-	if _, err := (*s.repo).GetComment(c.ID); err != nil {
+func (s service) UpdateComment(ctx context.Context, c *Comment) (*Comment, error) {
+	old, err := (*s.repo).GetComment(ctx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, policy.CommentUpdate, &policy.Resource{Commenter: old.Commenter}); err != nil {
 		return nil, err
 	}
-	return (*s.repo).UpdateComment(c)
+	return (*s.repo).UpdateComment(ctx, c)
 }
 
 // DeleteComment removes the comment under the given id.
-func (s service) DeleteComment(id int) error {
-	return (*s.repo).DeleteComment(id)
+func (s service) DeleteComment(ctx context.Context, id int) error {
+	c, err := (*s.repo).GetComment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, policy.CommentDelete, &policy.Resource{Commenter: c.Commenter}); err != nil {
+		return err
+	}
+	return (*s.repo).DeleteComment(ctx, id)
 }
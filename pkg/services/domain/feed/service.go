@@ -3,29 +3,32 @@ Package feed contains definition and implementation of a service that deals with
 package feed
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 // Service specifies a method to service Feeds .
 type Service interface {
-	AddFeed(f *Feed) error
-	GetFeed(username string) (*Feed, error)
-	GetPosts(f *Feed, sort Sorting, limit, offset int) ([]*Post, error)
-	GetChannels(f *Feed, sortBy SortBy, sortOrder SortOrder) ([]*Channel, error)
-	UpdateFeed(username string, f *Feed) error
-	Subscribe(f *Feed, channelname string) error
-	Unsubscribe(f *Feed, channelname string) error
+	AddFeed(ctx context.Context, f *Feed) error
+	GetFeed(ctx context.Context, username string) (*Feed, error)
+	GetPosts(ctx context.Context, f *Feed, sort Sorting, limit, offset int) ([]*Post, error)
+	GetChannels(ctx context.Context, f *Feed, sortBy SortBy, sortOrder SortOrder) ([]*Channel, error)
+	UpdateFeed(ctx context.Context, username string, f *Feed) error
+	Subscribe(ctx context.Context, f *Feed, channelname string) error
+	Unsubscribe(ctx context.Context, f *Feed, channelname string) error
 }
 
 // Repository specifies a repo interface to serve the Service interface
 type Repository interface {
-	AddFeed(f *Feed) error
-	GetFeed(username string) (*Feed, error)
-	GetPosts(f *Feed, sort Sorting, limit, offset int) ([]*Post, error)
-	GetChannels(f *Feed, sortBy string, sortOrder string) ([]*Channel, error)
-	UpdateFeed(id uint, f *Feed) error
-	Subscribe(f *Feed, channelname string) error
-	Unsubscribe(f *Feed, channelname string) error
+	AddFeed(ctx context.Context, f *Feed) error
+	GetFeed(ctx context.Context, username string) (*Feed, error)
+	GetPosts(ctx context.Context, f *Feed, sort Sorting, limit, offset int) ([]*Post, error)
+	GetChannels(ctx context.Context, f *Feed, sortBy string, sortOrder string) ([]*Channel, error)
+	UpdateFeed(ctx context.Context, id uint, f *Feed) error
+	Subscribe(ctx context.Context, f *Feed, channelname string) error
+	Unsubscribe(ctx context.Context, f *Feed, channelname string) error
 }
 
 // Sorting enums used by GetPosts methods signifying how retrieved posts ares sorted
@@ -84,8 +87,18 @@ func NewService(repo *Repository, allServices *map[string]interface{}) Service {
 	}
 }
 
+// authorize is a helper function that consults the policy service on whether
+// the principal behind ctx may manage the feed of the given username.
+func (s service) authorize(ctx context.Context, username string) error {
+	temp, ok := (*s.allServices)["Policy"]
+	if !ok {
+		return fmt.Errorf("policy service not available")
+	}
+	return policy.Authorize(ctx, *temp.(*policy.Service), policy.UserManageFeed, &policy.Resource{Username: username})
+}
+
 // GetUser returns the feed belonging to the given username
-func (s service) GetFeed(username string) (*Feed, error) {
+func (s service) GetFeed(ctx context.Context, username string) (*Feed, error) {
 	/*if s.userService == nil {
 		temp, ok := (*s.allServices)["User"]
 		if !ok {
@@ -97,72 +110,87 @@ func (s service) GetFeed(username string) (*Feed, error) {
 		return nil, UserDoesNotExist
 	}
 	*/
-	return (*s.repo).GetFeed(username)
+	return (*s.repo).GetFeed(ctx, username)
 }
 
 // GetPosts returns a list of posts collected from the channels
 // the given feed has subscribed to sorted according to the given
 // method.
 // Pagination can be specified.
-func (s service) GetPosts(f *Feed, sort Sorting, limit, offset int) ([]*Post, error) {
+func (s service) GetPosts(ctx context.Context, f *Feed, sort Sorting, limit, offset int) ([]*Post, error) {
+	if err := s.authorize(ctx, f.OwnerUsername); err != nil {
+		return nil, err
+	}
 	if limit < 0 || offset < 0 {
 		return nil, fmt.Errorf("invalid pagination")
 	}
-	if f, err := s.GetFeed(f.OwnerUsername); err != nil {
+	if f, err := s.GetFeed(ctx, f.OwnerUsername); err != nil {
 		return nil, err
 	} else {
 		if sort == NotSet {
 			sort = f.Sorting
 		}
-		return (*s.repo).GetPosts(f, sort, limit, offset)
+		return (*s.repo).GetPosts(ctx, f, sort, limit, offset)
 	}
 }
 
 // GetChannels returns all the channels the given feed has subscribed to
-func (s service) GetChannels(f *Feed, sortBy SortBy, sortOrder SortOrder) ([]*Channel, error) {
-	if f, err := s.GetFeed(f.OwnerUsername); err != nil {
+func (s service) GetChannels(ctx context.Context, f *Feed, sortBy SortBy, sortOrder SortOrder) ([]*Channel, error) {
+	if err := s.authorize(ctx, f.OwnerUsername); err != nil {
+		return nil, err
+	}
+	if f, err := s.GetFeed(ctx, f.OwnerUsername); err != nil {
 		return nil, err
 	} else {
-		return (*s.repo).GetChannels(f, string(sortBy), string(sortOrder))
+		return (*s.repo).GetChannels(ctx, f, string(sortBy), string(sortOrder))
 	}
 }
 
 // Subscribe adds the channel to the list of channels that the feed
 // collects posts from.
-func (s service) Subscribe(f *Feed, channelname string) error {
-	if f, err := s.GetFeed(f.OwnerUsername); err != nil {
+func (s service) Subscribe(ctx context.Context, f *Feed, channelname string) error {
+	if err := s.authorize(ctx, f.OwnerUsername); err != nil {
+		return err
+	}
+	if f, err := s.GetFeed(ctx, f.OwnerUsername); err != nil {
 		return err
 	} else {
-		return (*s.repo).Subscribe(f, channelname)
+		return (*s.repo).Subscribe(ctx, f, channelname)
 	}
 }
 
 // Unsubscribe removes the channel to the list of channels that the
 // feed collects posts from.
-func (s service) Unsubscribe(f *Feed, channelname string) error {
-	if f, err := s.GetFeed(f.OwnerUsername); err != nil {
+func (s service) Unsubscribe(ctx context.Context, f *Feed, channelname string) error {
+	if err := s.authorize(ctx, f.OwnerUsername); err != nil {
+		return err
+	}
+	if f, err := s.GetFeed(ctx, f.OwnerUsername); err != nil {
 		return err
 	} else {
-		return (*s.repo).Unsubscribe(f, channelname)
+		return (*s.repo).Unsubscribe(ctx, f, channelname)
 	}
 }
 
 // AddFeed adds a feed to the username contained in the feed struct.
-func (s service) AddFeed(f *Feed) error {
-	if _, err := s.GetFeed(f.OwnerUsername); err == nil {
+func (s service) AddFeed(ctx context.Context, f *Feed) error {
+	if _, err := s.GetFeed(ctx, f.OwnerUsername); err == nil {
 		return fmt.Errorf("feed already exists")
 	}
-	return (*s.repo).AddFeed(f)
+	return (*s.repo).AddFeed(ctx, f)
 
 }
 
 // UpdateFeed updates the feed at the given id according to the given struct.
-func (s service) UpdateFeed(username string, f *Feed) error {
-	if oldFeed, err := s.GetFeed(username); err != nil {
+func (s service) UpdateFeed(ctx context.Context, username string, f *Feed) error {
+	if err := s.authorize(ctx, username); err != nil {
+		return err
+	}
+	if oldFeed, err := s.GetFeed(ctx, username); err != nil {
 		return err
 	} else {
 		f.OwnerUsername = username
-		return (*s.repo).UpdateFeed(oldFeed.ID, f)
+		return (*s.repo).UpdateFeed(ctx, oldFeed.ID, f)
 	}
 
 }
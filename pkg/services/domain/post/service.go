@@ -3,33 +3,36 @@ Package post contains definiton and implemntation of a service that deals with U
 package post
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 // Service specifies a method to service Release entities.
 type Service interface {
-	GetPost(id uint) (*Post, error)
-	DeletePost(id uint) error
-	AddPost(p *Post) (*Post, error)
-	UpdatePost(pos *Post, id uint) (*Post, error)
-	SearchPost(pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Post, error)
-	GetPostStar(id uint, username string) (*Star, error)
-	DeletePostStar(id uint, username string) error
-	AddPostStar(id uint, star *Star) (*Star, error)
-	UpdatePostStar(id uint, star *Star) (*Star, error)
+	GetPost(ctx context.Context, id uint) (*Post, error)
+	DeletePost(ctx context.Context, id uint) error
+	AddPost(ctx context.Context, p *Post) (*Post, error)
+	UpdatePost(ctx context.Context, pos *Post, id uint) (*Post, error)
+	SearchPost(ctx context.Context, pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Post, error)
+	GetPostStar(ctx context.Context, id uint, username string) (*Star, error)
+	DeletePostStar(ctx context.Context, id uint, username string) error
+	AddPostStar(ctx context.Context, id uint, star *Star) (*Star, error)
+	UpdatePostStar(ctx context.Context, id uint, star *Star) (*Star, error)
 }
 
 // Repository specifies a repo interface to serve the Post Service interface
 type Repository interface {
-	GetPost(id uint) (*Post, error)
-	DeletePost(id uint) error
-	AddPost(p *Post) (*Post, error)
-	UpdatePost(pos *Post, id uint) (*Post, error)
-	SearchPost(pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Post, error)
-	GetPostStar(id uint, username string) (*Star, error)
-	DeletePostStar(id uint, username string) error
-	AddPostStar(id uint, star *Star) (*Star, error)
-	UpdatePostStar(id uint, star *Star) (*Star, error)
+	GetPost(ctx context.Context, id uint) (*Post, error)
+	DeletePost(ctx context.Context, id uint) error
+	AddPost(ctx context.Context, p *Post) (*Post, error)
+	UpdatePost(ctx context.Context, pos *Post, id uint) (*Post, error)
+	SearchPost(ctx context.Context, pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Post, error)
+	GetPostStar(ctx context.Context, id uint, username string) (*Star, error)
+	DeletePostStar(ctx context.Context, id uint, username string) error
+	AddPostStar(ctx context.Context, id uint, star *Star) (*Star, error)
+	UpdatePostStar(ctx context.Context, id uint, star *Star) (*Star, error)
 }
 
 // SortOrder holds enums used by SearchPost methods the order of Users are sorted with
@@ -61,21 +64,33 @@ var ErrReleaseNotFound = fmt.Errorf("Release not found")
 //ErrStarNotFound is returned when requested Star is not found
 var ErrStarNotFound = fmt.Errorf("Star not found")
 
-//ErrSomePostDataNotPersisted is returned when data aren't properly added to post database
+//ErrSomePostDataNotPersisted is returned when data aren't properly added to post database,
+//none of the changes are made then.
 var ErrSomePostDataNotPersisted = fmt.Errorf("Data not properly added")
 
 type service struct {
-	repo *Repository
+	allServices *map[string]interface{}
+	repo        *Repository
 }
 
 // NewService returns a struct that implements the Service interface
-func NewService(repo *Repository) Service {
-	return &service{repo: repo}
+func NewService(repo *Repository, allServices *map[string]interface{}) Service {
+	return &service{allServices: allServices, repo: repo}
+}
+
+// authorize is a helper function that consults the policy service on whether
+// the principal behind ctx may perform the action on the resource.
+func (s service) authorize(ctx context.Context, action policy.Action, resource *policy.Resource) error {
+	temp, ok := (*s.allServices)["Policy"]
+	if !ok {
+		return fmt.Errorf("policy service not available")
+	}
+	return policy.Authorize(ctx, *temp.(*policy.Service), action, resource)
 }
 
 // GetPost gets the Post stored under the given id.
-func (s service) GetPost(id uint) (*Post, error) {
-	p, err := (*s.repo).GetPost(id)
+func (s service) GetPost(ctx context.Context, id uint) (*Post, error) {
+	p, err := (*s.repo).GetPost(ctx, id)
 	if err != nil {
 		return nil, ErrPostNotFound
 	}
@@ -84,8 +99,15 @@ func (s service) GetPost(id uint) (*Post, error) {
 }
 
 // DeletePost Deletes the Post stored under the given id.
-func (s service) DeletePost(id uint) error {
-	err := (*s.repo).DeletePost(id)
+func (s service) DeletePost(ctx context.Context, id uint) error {
+	p, err := s.GetPost(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, policy.PostDelete, &policy.Resource{PostAuthor: p.PostedByUsername}); err != nil {
+		return err
+	}
+	err = (*s.repo).DeletePost(ctx, id)
 	if err != nil {
 		return ErrPostNotFound
 	}
@@ -94,33 +116,51 @@ func (s service) DeletePost(id uint) error {
 }
 
 // AddPost Adds the Post stored under the given id.
-func (s service) AddPost(p *Post) (*Post, error) {
-
-	return (*s.repo).AddPost(p)
+func (s service) AddPost(ctx context.Context, p *Post) (*Post, error) {
+	if err := s.authorize(ctx, policy.PostCreate, &policy.Resource{Username: p.PostedByUsername}); err != nil {
+		return nil, err
+	}
+	return (*s.repo).AddPost(ctx, p)
 }
 
 //UpdatePost updates the post with given id and post struct
-func (s service) UpdatePost(pos *Post, id uint) (*Post, error) {
-	return (*s.repo).UpdatePost(pos, id)
+func (s service) UpdatePost(ctx context.Context, pos *Post, id uint) (*Post, error) {
+	p, err := s.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, policy.PostUpdate, &policy.Resource{PostAuthor: p.PostedByUsername}); err != nil {
+		return nil, err
+	}
+	return (*s.repo).UpdatePost(ctx, pos, id)
 }
 
-func (s service) SearchPost(pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Post, error) {
+func (s service) SearchPost(ctx context.Context, pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Post, error) {
 	if limit < 0 || offset < 0 {
 		return nil, fmt.Errorf("invalid pagination")
 	}
-	return (*s.repo).SearchPost(pattern, by, order, limit, offset)
+	return (*s.repo).SearchPost(ctx, pattern, by, order, limit, offset)
 
 }
-func (s service) GetPostStar(id uint, username string) (*Star, error) {
-	return (*s.repo).GetPostStar(id, username)
+func (s service) GetPostStar(ctx context.Context, id uint, username string) (*Star, error) {
+	return (*s.repo).GetPostStar(ctx, id, username)
 }
-func (s service) DeletePostStar(id uint, username string) error {
+func (s service) DeletePostStar(ctx context.Context, id uint, username string) error {
+	if err := s.authorize(ctx, policy.PostStar, &policy.Resource{Username: username}); err != nil {
+		return err
+	}
 
-	return (*s.repo).DeletePostStar(id, username)
+	return (*s.repo).DeletePostStar(ctx, id, username)
 }
-func (s service) AddPostStar(id uint, star *Star) (*Star, error) {
-	return (*s.repo).AddPostStar(id, star)
+func (s service) AddPostStar(ctx context.Context, id uint, star *Star) (*Star, error) {
+	if err := s.authorize(ctx, policy.PostStar, &policy.Resource{Username: star.Username}); err != nil {
+		return nil, err
+	}
+	return (*s.repo).AddPostStar(ctx, id, star)
 }
-func (s service) UpdatePostStar(id uint, star *Star) (*Star, error) {
-	return (*s.repo).UpdatePostStar(id, star)
+func (s service) UpdatePostStar(ctx context.Context, id uint, star *Star) (*Star, error) {
+	if err := s.authorize(ctx, policy.PostStar, &policy.Resource{Username: star.Username}); err != nil {
+		return nil, err
+	}
+	return (*s.repo).UpdatePostStar(ctx, id, star)
 }
@@ -13,11 +13,15 @@ const (
 )
 
 // Release represents an atomic work of creativity.
+// Images holds the URL of each size the image of image releases is served in,
+// keyed by the name of the size, it's filled in when the release is served,
+// never stored.
 type Release struct {
-	ID           int    `json:"id"`
-	OwnerChannel string `json:"ownerChannel"`
-	Type         Type   `json:"type"`
-	Content      string `json:"content"`
+	ID           int               `json:"id"`
+	OwnerChannel string            `json:"ownerChannel"`
+	Type         Type              `json:"type"`
+	Content      string            `json:"content"`
+	Images       map[string]string `json:"images,omitempty"`
 	Metadata     `json:"metadata,omitempty"`
 	CreationTime time.Time `json:"creationTime,omitempty"`
 }
@@ -3,25 +3,29 @@ Package release contains definition and implementation of a service that deals w
 package release
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/channel"
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 // Service specifies a method to service Release entities.
 type Service interface {
-	GetRelease(id int) (*Release, error)
-	SearchRelease(pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Release, error)
-	DeleteRelease(id int) error
-	AddRelease(r *Release) (*Release, error)
-	UpdateRelease(rel *Release) (*Release, error)
+	GetRelease(ctx context.Context, id int) (*Release, error)
+	SearchRelease(ctx context.Context, pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Release, error)
+	DeleteRelease(ctx context.Context, id int) error
+	AddRelease(ctx context.Context, r *Release) (*Release, error)
+	UpdateRelease(ctx context.Context, rel *Release) (*Release, error)
 }
 
 // Repository specifies a repo interface to serve the release Service interface
 type Repository interface {
-	GetRelease(id int) (*Release, error)
-	SearchRelease(pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Release, error)
-	DeleteRelease(id int) error
-	AddRelease(r *Release) (*Release, error)
-	UpdateRelease(rel *Release) (*Release, error)
+	GetRelease(ctx context.Context, id int) (*Release, error)
+	SearchRelease(ctx context.Context, pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Release, error)
+	DeleteRelease(ctx context.Context, id int) error
+	AddRelease(ctx context.Context, r *Release) (*Release, error)
+	UpdateRelease(ctx context.Context, rel *Release) (*Release, error)
 }
 
 // SortOrder holds enums used by SearchRelease methods the order of Users are sorted with
@@ -46,59 +50,95 @@ var ErrReleaseNotFound = fmt.Errorf("release not found")
 // ErrInvalidReleaseData is returned when the requested passed release has invalid dat
 var ErrInvalidReleaseData = fmt.Errorf("release data invalid")
 
-// ErrSomeReleaseDataNotPersisted is returned when the requested passed release has invalid dat
+// ErrSomeReleaseDataNotPersisted is returned when some of the release data couldn't be persisted,
+// none of the changes are made then.
 var ErrSomeReleaseDataNotPersisted = fmt.Errorf("was unable to persist some release data")
 
 // ErrAttemptToChangeReleaseType is returned when the requested passed release has invalid dat
 var ErrAttemptToChangeReleaseType = fmt.Errorf("attempt to change release type")
 
 type service struct {
-	repo *Repository
+	allServices *map[string]interface{}
+	repo        *Repository
 }
 
 // NewService returns a struct that implements the release.Service interface
-func NewService(repo *Repository) Service {
-	return &service{repo: repo}
+func NewService(repo *Repository, allServices *map[string]interface{}) Service {
+	return &service{allServices: allServices, repo: repo}
+}
+
+// authorize is a helper function that consults the policy service on whether
+// the principal behind ctx may perform the action on the releases of the given channel.
+func (s service) authorize(ctx context.Context, action policy.Action, channelUsername string) error {
+	temp, ok := (*s.allServices)["Policy"]
+	if !ok {
+		return fmt.Errorf("policy service not available")
+	}
+	tempChannel, ok := (*s.allServices)["Channel"]
+	if !ok {
+		return fmt.Errorf("channel service not available")
+	}
+	c, err := (*tempChannel.(*channel.Service)).GetChannel(ctx, channelUsername)
+	if err != nil {
+		return err
+	}
+	return policy.Authorize(ctx, *temp.(*policy.Service), action, &policy.Resource{ChannelOwner: c.OwnerUsername, ChannelAdmins: c.AdminUsernames})
 }
 
 // AddRelease adds an new release based on the passed in struct
-func (s service) AddRelease(r *Release) (*Release, error) {
+func (s service) AddRelease(ctx context.Context, r *Release) (*Release, error) {
 	if r.Content == "" || r.OwnerChannel == "" {
 		return nil, ErrInvalidReleaseData
 	}
-	return (*s.repo).AddRelease(r)
+	if err := s.authorize(ctx, policy.ReleaseCreate, r.OwnerChannel); err != nil {
+		return nil, err
+	}
+	return (*s.repo).AddRelease(ctx, r)
 }
 
 // GetRelease gets the release stored under the given id.
-func (s service) GetRelease(id int) (*Release, error) {
-	return (*s.repo).GetRelease(id)
+func (s service) GetRelease(ctx context.Context, id int) (*Release, error) {
+	return (*s.repo).GetRelease(ctx, id)
 }
 
 // SearchRelease returns a list of official releases that match against the pattern.
 // Note: this won't return releases that aren't in a channel's official catalog.
 // If pattern is empty, it returns all releases.
 // Sorting and pagination can be specified.
-func (s service) SearchRelease(pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Release, error) {
+func (s service) SearchRelease(ctx context.Context, pattern string, by SortBy, order SortOrder, limit int, offset int) ([]*Release, error) {
 	if limit < 0 || offset < 0 {
 		return nil, fmt.Errorf("invalid pagination")
 	}
-	return (*s.repo).SearchRelease(pattern, by, order, limit, offset)
+	return (*s.repo).SearchRelease(ctx, pattern, by, order, limit, offset)
 }
 
 // DeleteRelease removes the release stored under the given id.
-func (s service) DeleteRelease(id int) error {
-	if _, err := s.GetRelease(id); err != nil {
+func (s service) DeleteRelease(ctx context.Context, id int) error {
+	rel, err := s.GetRelease(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, policy.ReleaseDelete, rel.OwnerChannel); err != nil {
 		return err
 	}
-	return (*s.repo).DeleteRelease(id)
+	return (*s.repo).DeleteRelease(ctx, id)
 }
 
 // UpdateRelease updates the release stored under the given id
 // based on the passed in struct.
-func (s service) UpdateRelease(r *Release) (*Release, error) {
-	if rel, err := s.GetRelease(r.ID); err != nil {
+func (s service) UpdateRelease(ctx context.Context, r *Release) (*Release, error) {
+	if rel, err := s.GetRelease(ctx, r.ID); err != nil {
 		return nil, err
 	} else {
+		if err := s.authorize(ctx, policy.ReleaseUpdate, rel.OwnerChannel); err != nil {
+			return nil, err
+		}
+		// releases are only moved to channels they could've been added to
+		if r.OwnerChannel != "" && r.OwnerChannel != rel.OwnerChannel {
+			if err := s.authorize(ctx, policy.ReleaseCreate, r.OwnerChannel); err != nil {
+				return nil, err
+			}
+		}
 		if r.Type != "" && r.Type != rel.Type {
 			return nil, ErrAttemptToChangeReleaseType
 		}
@@ -108,7 +148,7 @@ func (s service) UpdateRelease(r *Release) (*Release, error) {
 			r.OwnerChannel = ""
 		}
 	}
-	return (*s.repo).UpdateRelease(r)
+	return (*s.repo).UpdateRelease(ctx, r)
 }
 
 // removeDuplicates is a helper function
@@ -6,6 +6,9 @@ import (
 
 // User represents standard user entity of issue#1.
 // bookmarkedPosts map contains the postId mapped to the time it was bookmarked.
+// Verified tells whether the user has proven ownership of their email, it's reset whenever the email changes.
+// PictureURLs holds the URL of each size the picture is served in, keyed by
+// the name of the size, it's filled in when the user is served, never stored.
 type User struct {
 	Username        string            `json:"username"`
 	Email           string            `json:"email"`
@@ -17,4 +20,6 @@ type User struct {
 	BookmarkedPosts map[time.Time]int `json:"-"`
 	Password        string            `json:"password,omitempty"`
 	PictureURL      string            `json:"pictureURL"`
+	PictureURLs     map[string]string `json:"pictureURLs,omitempty"`
+	Verified        bool              `json:"verified"`
 }
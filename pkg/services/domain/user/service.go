@@ -3,37 +3,42 @@ Package user contains definition and implementation of a service that deals with
 package user
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/policy"
 )
 
 // Service specifies a method to service User entities.
 type Service interface {
-	AddUser(u *User) (*User, error)
-	GetUser(username string) (*User, error)
-	UpdateUser(u *User, username string) (*User, error)
-	DeleteUser(username string) error
-	SearchUser(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*User, error)
-	Authenticate(u *User) (bool, error)
-	BookmarkPost(username string, postID int) error
-	DeleteBookmark(username string, postID int) error
-	AddPicture(username, name string) error
-	RemovePicture(username string) error
+	AddUser(ctx context.Context, u *User) (*User, error)
+	GetUser(ctx context.Context, username string) (*User, error)
+	UpdateUser(ctx context.Context, u *User, username string) (*User, error)
+	DeleteUser(ctx context.Context, username string) error
+	SearchUser(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*User, error)
+	Authenticate(ctx context.Context, u *User) (bool, error)
+	BookmarkPost(ctx context.Context, username string, postID int) error
+	DeleteBookmark(ctx context.Context, username string, postID int) error
+	AddPicture(ctx context.Context, username, name string) error
+	RemovePicture(ctx context.Context, username string) error
+	MarkVerified(ctx context.Context, username string) error
 }
 
 // Repository specifies a repo interface to serve the Service interface
 type Repository interface {
-	AddUser(u *User) (*User, error)
-	GetUser(username string) (*User, error)
-	UpdateUser(username string, u *User) (*User, error)
-	DeleteUser(username string) error
-	SearchUser(pattern, sortBy, sortOrder string, limit, offset int) ([]*User, error)
-	Authenticate(u *User) (bool, error)
-	BookmarkPost(username string, postID int) error
-	DeleteBookmark(username string, postID int) error
-	UsernameOccupied(username string) (bool, error)
-	EmailOccupied(email string) (bool, error)
-	AddPicture(username, name string) error
-	RemovePicture(username string) error
+	AddUser(ctx context.Context, u *User) (*User, error)
+	GetUser(ctx context.Context, username string) (*User, error)
+	UpdateUser(ctx context.Context, username string, u *User) (*User, error)
+	DeleteUser(ctx context.Context, username string) error
+	SearchUser(ctx context.Context, pattern, sortBy, sortOrder string, limit, offset int) ([]*User, error)
+	Authenticate(ctx context.Context, u *User) (bool, error)
+	BookmarkPost(ctx context.Context, username string, postID int) error
+	DeleteBookmark(ctx context.Context, username string, postID int) error
+	UsernameOccupied(ctx context.Context, username string) (bool, error)
+	EmailOccupied(ctx context.Context, email string) (bool, error)
+	AddPicture(ctx context.Context, username, name string) error
+	RemovePicture(ctx context.Context, username string) error
+	MarkVerified(ctx context.Context, username string) error
 }
 
 // SortOrder holds enums used by SearchUser methods the order of Users are sorted with
@@ -68,7 +73,8 @@ var ErrPostNotFound = fmt.Errorf("post not found")
 // ErrInvalidUserData is returned when the the username specified isn't recognized
 var ErrInvalidUserData = fmt.Errorf("passed user data is invalid")
 
-// ErrSomeUserDataNotPersisted is returned when the the username specified isn't recognized
+// ErrSomeUserDataNotPersisted is returned when some of the user data couldn't be persisted,
+// none of the changes are made then.
 var ErrSomeUserDataNotPersisted = fmt.Errorf("was not able to persist some user data")
 
 type service struct {
@@ -82,20 +88,30 @@ func NewService(repo *Repository, allServices *map[string]interface{}) Service {
 	return s
 }
 
+// authorize is a helper function that consults the policy service on whether
+// the principal behind ctx may perform the action on the account of the given username.
+func (service *service) authorize(ctx context.Context, action policy.Action, username string) error {
+	temp, ok := (*service.allServices)["Policy"]
+	if !ok {
+		return fmt.Errorf("policy service not available")
+	}
+	return policy.Authorize(ctx, *temp.(*policy.Service), action, &policy.Resource{Username: username})
+}
+
 // AddUser adds a user according to the given username
-func (service *service) AddUser(u *User) (*User, error) {
+func (service *service) AddUser(ctx context.Context, u *User) (*User, error) {
 	if u.FirstName == "" || u.Email == "" || u.Password == "" {
 		return nil, ErrInvalidUserData
 	}
 	{
-		if occupied, err := (*service.repo).UsernameOccupied(u.Username); err == nil {
+		if occupied, err := (*service.repo).UsernameOccupied(ctx, u.Username); err == nil {
 			if occupied {
 				return nil, ErrUserNameOccupied
 			}
 		} else {
 			return nil, fmt.Errorf("couldn't check if username occupied because of: %s", err.Error())
 		}
-		if occupied, err := (*service.repo).EmailOccupied(u.Email); err == nil {
+		if occupied, err := (*service.repo).EmailOccupied(ctx, u.Email); err == nil {
 			if occupied {
 				return nil, ErrEmailIsOccupied
 			}
@@ -103,29 +119,32 @@ func (service *service) AddUser(u *User) (*User, error) {
 			return nil, fmt.Errorf("couldn't check if email occupied because of: %s", err.Error())
 		}
 	}
-	return (*service.repo).AddUser(u)
+	return (*service.repo).AddUser(ctx, u)
 }
 
 // GetUser returns the user according to the given username
-func (service *service) GetUser(username string) (*User, error) {
-	return (*service.repo).GetUser(username)
+func (service *service) GetUser(ctx context.Context, username string) (*User, error) {
+	return (*service.repo).GetUser(ctx, username)
 }
 
 // UpdateUser updates the user of the given username according to the User struct given
-func (service *service) UpdateUser(u *User, username string) (*User, error) {
-	if _, err := service.GetUser(username); err == ErrUserNotFound {
+func (service *service) UpdateUser(ctx context.Context, u *User, username string) (*User, error) {
+	if _, err := service.GetUser(ctx, username); err == ErrUserNotFound {
 		if u.Username == "" || u.Username == username {
 			u.Username = username
-			return service.AddUser(u)
+			return service.AddUser(ctx, u)
 		} else {
 			return nil, err
 		}
 	} else if err != nil {
 		return nil, err
 	}
+	if err := service.authorize(ctx, policy.UserUpdate, username); err != nil {
+		return nil, err
+	}
 	// Checks if username is trying to be changed, then if the new username is occupied
 	if u.Username != "" {
-		if occupied, err := (*service.repo).UsernameOccupied(u.Username); err == nil {
+		if occupied, err := (*service.repo).UsernameOccupied(ctx, u.Username); err == nil {
 			if occupied {
 				return nil, ErrUserNameOccupied
 			}
@@ -133,51 +152,71 @@ func (service *service) UpdateUser(u *User, username string) (*User, error) {
 			return nil, fmt.Errorf("couldn't check if username occupied because of: %s", err.Error())
 		}
 	}
-	return (*service.repo).UpdateUser(username, u)
+	return (*service.repo).UpdateUser(ctx, username, u)
 }
 
 // DeleteUser removes the user of the given username
-func (service *service) DeleteUser(username string) error {
-	return (*service.repo).DeleteUser(username)
+func (service *service) DeleteUser(ctx context.Context, username string) error {
+	if err := service.authorize(ctx, policy.UserDelete, username); err != nil {
+		return err
+	}
+	return (*service.repo).DeleteUser(ctx, username)
 }
 
 // SearchUser returns a list of users that match against the pattern.
 // If pattern is empty, it returns all users.
 // Sorting and pagination can be specified.
-func (service *service) SearchUser(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*User, error) {
+func (service *service) SearchUser(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*User, error) {
 	if limit < 0 || offset < 0 {
 		return nil, fmt.Errorf("invalid pagination")
 	}
-	return (*service.repo).SearchUser(pattern, string(sortBy), string(sortOrder), limit, offset)
+	return (*service.repo).SearchUser(ctx, pattern, string(sortBy), string(sortOrder), limit, offset)
 }
 
 // Authenticate checks if the given the credentials in the given struct is correct.
-func (service *service) Authenticate(u *User) (bool, error) {
-	return (*service.repo).Authenticate(u)
+func (service *service) Authenticate(ctx context.Context, u *User) (bool, error) {
+	return (*service.repo).Authenticate(ctx, u)
 }
 
 // BookmarkPost bookmarks the given postID for the user of the given username.
-func (service *service) BookmarkPost(username string, postID int) error {
-	if _, err := service.GetUser(username); err != nil {
+func (service *service) BookmarkPost(ctx context.Context, username string, postID int) error {
+	if _, err := service.GetUser(ctx, username); err != nil {
+		return err
+	}
+	if err := service.authorize(ctx, policy.UserManageBookmarks, username); err != nil {
 		return err
 	}
-	return (*service.repo).BookmarkPost(username, postID)
+	return (*service.repo).BookmarkPost(ctx, username, postID)
 }
 
 // DeleteBookmark removes the post of the given postID from the users bookmarks
-func (service *service) DeleteBookmark(username string, postID int) error {
-	if _, err := service.GetUser(username); err != nil {
+func (service *service) DeleteBookmark(ctx context.Context, username string, postID int) error {
+	if _, err := service.GetUser(ctx, username); err != nil {
+		return err
+	}
+	if err := service.authorize(ctx, policy.UserManageBookmarks, username); err != nil {
 		return err
 	}
-	return (*service.repo).DeleteBookmark(username, postID)
+	return (*service.repo).DeleteBookmark(ctx, username, postID)
 }
 
 // AddPicture adds the given image name as the picture for the given username.
-func (service *service) AddPicture(username, name string) error {
-	return (*service.repo).AddPicture(username, name)
+func (service *service) AddPicture(ctx context.Context, username, name string) error {
+	if err := service.authorize(ctx, policy.UserManagePicture, username); err != nil {
+		return err
+	}
+	return (*service.repo).AddPicture(ctx, username, name)
 }
 
 // RemovePicture removes the picture for the given username.
-func (service *service) RemovePicture(username string) error {
-	return (*service.repo).RemovePicture(username)
+func (service *service) RemovePicture(ctx context.Context, username string) error {
+	if err := service.authorize(ctx, policy.UserManagePicture, username); err != nil {
+		return err
+	}
+	return (*service.repo).RemovePicture(ctx, username)
+}
+
+// MarkVerified marks the email of the user of the given username as verified.
+func (service *service) MarkVerified(ctx context.Context, username string) error {
+	return (*service.repo).MarkVerified(ctx, username)
 }
@@ -0,0 +1,119 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// exifOrientationTag is the tag of the EXIF field telling how the pixels of
+// the image are to be turned and flipped for it to be upright.
+const exifOrientationTag = 0x0112
+
+// exifOrientation is a helper function that returns the EXIF orientation of
+// the JPEG image, 1 if it has none or it can't be read. Only as much of the
+// EXIF data as it takes to get to the orientation is parsed.
+func exifOrientation(jpeg []byte) int {
+	if len(jpeg) < 2 || jpeg[0] != 0xFF || jpeg[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(jpeg); {
+		if jpeg[i] != 0xFF {
+			return 1
+		}
+		marker := jpeg[i+1]
+		if marker == 0xFF { // fill byte
+			i++
+			continue
+		}
+		// the EXIF data comes before the scan, so does every APP segment
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(jpeg[i+2:]))
+		if length < 2 || i+2+length > len(jpeg) {
+			return 1
+		}
+		segment := jpeg[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation is a helper function that reads the orientation off the
+// first IFD of the TIFF structure EXIF data is kept in.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+	ifd := int64(order.Uint32(tiff[4:]))
+	if ifd+2 > int64(len(tiff)) {
+		return 1
+	}
+	entries := int64(order.Uint16(tiff[ifd:]))
+	for e := ifd + 2; e+12 <= int64(len(tiff)) && e < ifd+2+entries*12; e += 12 {
+		const short = 3
+		if order.Uint16(tiff[e:]) != exifOrientationTag || order.Uint16(tiff[e+2:]) != short {
+			continue
+		}
+		if o := int(order.Uint16(tiff[e+8:])); o >= 1 && o <= 8 {
+			return o
+		}
+		return 1
+	}
+	return 1
+}
+
+// orient is a helper function that turns and flips the image as the EXIF
+// orientation tells for it to be upright. Orientations 5 through 8 swap its
+// width and height.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // flipped horizontally
+				dx, dy = w-1-x, y
+			case 3: // turned half way
+				dx, dy = w-1-x, h-1-y
+			case 4: // flipped vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // to be turned clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // to be turned counterclockwise
+				dx, dy = y, w-1-x
+			}
+			s, d := img.PixOffset(x+img.Rect.Min.X, y+img.Rect.Min.Y), dst.PixOffset(dx, dy)
+			copy(dst.Pix[d:d+4], img.Pix[s:s+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,179 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// DefaultJPEGQuality is the quality images are encoded as JPEG at unless told otherwise.
+	DefaultJPEGQuality = 85
+	// DefaultMaxPixels is the most pixels images may have unless told otherwise,
+	// enough for the photos of most phones and cameras. Every pixel takes several
+	// bytes of memory while the image is processed.
+	DefaultMaxPixels = 24 * 1000 * 1000
+	// DefaultMaxBytes is the most bytes images may take unless told otherwise.
+	DefaultMaxBytes = 20 << 20
+	// DefaultMaxConcurrent is how many images are processed at once unless told otherwise.
+	DefaultMaxConcurrent = 2
+)
+
+// Config holds the settings used by the processor.
+// Images with more than MaxPixels pixels or that take more than MaxBytes bytes
+// are rejected before they're decoded, and no more than MaxConcurrent of them
+// are processed at once so that the memory they take stays bounded.
+type Config struct {
+	JPEGQuality   int
+	MaxPixels     int
+	MaxBytes      int64
+	MaxConcurrent int
+}
+
+type processor struct {
+	Config
+	// slots holds a token for every image being processed
+	slots chan struct{}
+}
+
+// NewProcessor returns a struct that implements the imaging.Processor
+// interface using the standard library's codecs. Images are turned upright as
+// their EXIF orientation tells and are re-encoded from their pixels alone,
+// which leaves behind their EXIF data, location and all, and any other
+// metadata. Images with transparent pixels are encoded as PNG, others in
+// whichever of JPEG, at the configured quality, and PNG their largest variant
+// takes less space in.
+func NewProcessor(config Config) Processor {
+	return &processor{Config: config, slots: make(chan struct{}, config.MaxConcurrent)}
+}
+
+// Process decodes the image and encodes it in each of the Variants.
+// Callers past MaxConcurrent wait for their turn until ctx is done.
+func (p *processor) Process(ctx context.Context, r io.Reader) ([]*Image, error) {
+	// one byte past the limit tells images that are too large from those that just fit
+	data, err := ioutil.ReadAll(io.LimitReader(r, p.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading image failed because of: %w", err)
+	}
+	if int64(len(data)) > p.MaxBytes {
+		return nil, ErrImageTooLarge
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	// checked before decoding since the pixels are all kept in memory
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > int64(p.MaxPixels) {
+		return nil, ErrImageTooLarge
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		defer func() { <-p.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	if format == "jpeg" {
+		src = orient(src, exifOrientation(data))
+	}
+
+	scaled := make([]*image.RGBA, len(Variants))
+	for i, v := range Variants {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		scaled[i] = scale(src, v)
+	}
+
+	// the format is picked by the largest variant, the one that matters the most
+	contentType, encode := "image/png", p.encodePNG
+	var largest []byte
+	if src.Opaque() {
+		asJPEG, err := p.encodeJPEG(scaled[len(scaled)-1])
+		if err != nil {
+			return nil, err
+		}
+		largest, err = p.encodePNG(scaled[len(scaled)-1])
+		if err != nil {
+			return nil, err
+		}
+		if len(asJPEG) < len(largest) {
+			contentType, encode, largest = "image/jpeg", p.encodeJPEG, asJPEG
+		}
+	}
+
+	images := make([]*Image, len(Variants))
+	for i, v := range Variants {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		content := largest
+		if i < len(Variants)-1 || content == nil {
+			if content, err = encode(scaled[i]); err != nil {
+				return nil, err
+			}
+		}
+		images[i] = &Image{
+			Variant:     v,
+			Content:     content,
+			ContentType: contentType,
+			Width:       scaled[i].Bounds().Dx(),
+			Height:      scaled[i].Bounds().Dy(),
+		}
+	}
+	return images, nil
+}
+
+func (p *processor) encodeJPEG(img *image.RGBA) ([]byte, error) {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: p.JPEGQuality}); err != nil {
+		return nil, fmt.Errorf("encoding image as jpeg failed because of: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func (p *processor) encodePNG(img *image.RGBA) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&b, img); err != nil {
+		return nil, fmt.Errorf("encoding image as png failed because of: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// scale is a helper function that scales the image down to fit the variant,
+// keeping its aspect ratio. Images that already fit are returned as they are.
+func scale(img *image.RGBA, v Variant) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= v.MaxWidth && h <= v.MaxHeight {
+		return img
+	}
+	ratio := float64(v.MaxWidth) / float64(w)
+	if r := float64(v.MaxHeight) / float64(h); r < ratio {
+		ratio = r
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, atLeastOne(float64(w)*ratio), atLeastOne(float64(h)*ratio)))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return scaled
+}
+
+func atLeastOne(f float64) int {
+	if n := int(f + 0.5); n > 1 {
+		return n
+	}
+	return 1
+}
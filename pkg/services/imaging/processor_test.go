@@ -0,0 +1,82 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// encodedPNG returns a w by h PNG image.
+func encodedPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// endless is a reader that never runs out of bytes.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func newTestProcessor(maxConcurrent int) Processor {
+	return NewProcessor(Config{JPEGQuality: DefaultJPEGQuality, MaxPixels: 100 * 100, MaxBytes: 1 << 20, MaxConcurrent: maxConcurrent})
+}
+
+func TestProcessLimits(t *testing.T) {
+	p := newTestProcessor(1)
+	ctx := context.Background()
+	if _, err := p.Process(ctx, endless{}); err != ErrImageTooLarge {
+		t.Errorf("an image past MaxBytes is expected to be rejected with ErrImageTooLarge, got %v", err)
+	}
+	if _, err := p.Process(ctx, bytes.NewReader(encodedPNG(t, 101, 100))); err != ErrImageTooLarge {
+		t.Errorf("an image past MaxPixels is expected to be rejected with ErrImageTooLarge, got %v", err)
+	}
+	images, err := p.Process(ctx, bytes.NewReader(encodedPNG(t, 100, 100)))
+	if err != nil {
+		t.Fatalf("an image within the limits is expected to be processed, got %v", err)
+	}
+	if len(images) != len(Variants) {
+		t.Errorf("expected an image for each of the %d variants, got %d", len(Variants), len(images))
+	}
+}
+
+func TestProcessMaxConcurrent(t *testing.T) {
+	p := newTestProcessor(1)
+	data := encodedPNG(t, 100, 100)
+
+	// the one slot is taken as if another image were being processed
+	p.(*processor).slots <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := p.Process(ctx, bytes.NewReader(data)); err != context.DeadlineExceeded {
+		t.Errorf("an image past MaxConcurrent is expected to wait until its context is done, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Process(context.Background(), bytes.NewReader(data))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("an image past MaxConcurrent is expected to wait for its turn, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	<-p.(*processor).slots
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("the waiting image is expected to be processed once it's its turn, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiting image is expected to be processed once a slot is free")
+	}
+}
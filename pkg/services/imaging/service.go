@@ -0,0 +1,57 @@
+/*
+Package imaging contains definition and implementation of a service that
+processes uploaded images into the sizes they're served in.
+*/
+package imaging
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Variant is a size images are served in. Images are scaled down to fit
+// within MaxWidth by MaxHeight, keeping their aspect ratio, and are never
+// scaled up.
+type Variant struct {
+	Name      string
+	MaxWidth  int
+	MaxHeight int
+}
+
+var (
+	// Thumb is the variant shown in lists and next to names.
+	Thumb = Variant{Name: "thumb", MaxWidth: 256, MaxHeight: 256}
+	// Medium is the variant shown on small screens, tall pages keep their height.
+	Medium = Variant{Name: "medium", MaxWidth: 1024, MaxHeight: 4096}
+	// Full is the largest variant images are served in.
+	Full = Variant{Name: "full", MaxWidth: 2048, MaxHeight: 8192}
+)
+
+// Variants are the variants images are processed into, smallest first.
+var Variants = []Variant{Thumb, Medium, Full}
+
+// Image is an image encoded in one of the variants.
+// It carries none of the metadata of the image it was processed from.
+type Image struct {
+	Variant     Variant
+	Content     []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Processor specifies a method to process images.
+type Processor interface {
+	// Process decodes the image read from r and returns it encoded in each of
+	// the Variants, in that order. All of them are encoded in the same format.
+	Process(ctx context.Context, r io.Reader) ([]*Image, error)
+}
+
+// ErrUnsupportedFormat is returned when the image passed to be processed
+// isn't a JPEG or a PNG image or can't be decoded.
+var ErrUnsupportedFormat = fmt.Errorf("image format not supported")
+
+// ErrImageTooLarge is returned when the image passed to be processed has
+// more pixels or takes more bytes than the processor is willing to decode.
+var ErrImageTooLarge = fmt.Errorf("image too large")
@@ -0,0 +1,31 @@
+package lockout
+
+import "time"
+
+// Attempts holds the failed authentication attempts made against a key.
+// Keys identify either a username or an IP address, see UsernameKey and IPKey.
+// Failures older than the failure window are forgotten on the next failure.
+// LockedUntil is zero if the key isn't locked.
+type Attempts struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// EventKind identifies what an Event records.
+type EventKind string
+
+// Kinds of events recorded for auditing.
+const (
+	EventLockout EventKind = "lockout"
+)
+
+// Event is an audit record of a key being locked.
+type Event struct {
+	Kind         EventKind `json:"kind"`
+	Key          string    `json:"key"`
+	Failures     int       `json:"failures"`
+	LockedUntil  time.Time `json:"lockedUntil"`
+	CreationTime time.Time `json:"creationTime"`
+}
@@ -0,0 +1,166 @@
+/*
+Package lockout contains definition and implementation of a service that
+tracks failed authentication attempts and temporarily locks the usernames
+and IP addresses they're made from.
+*/
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Service specifies the methods used to guard an authentication endpoint.
+// An empty username or ip is skipped so that endpoints that only know of
+// one can still be guarded.
+type Service interface {
+	Check(ctx context.Context, username, ip string) (time.Duration, error)
+	RecordFailure(ctx context.Context, username, ip string) (time.Duration, error)
+	RecordSuccess(ctx context.Context, username string) error
+}
+
+// Repository specifies a repo interface to serve the lockout.Service interface.
+// AddFailure has to atomically count the failure, starting over from one if
+// the last failure was before resetBefore, and return the updated Attempts.
+// GetAttempts returns zero valued Attempts for keys it has no record of.
+type Repository interface {
+	GetAttempts(ctx context.Context, key string) (*Attempts, error)
+	AddFailure(ctx context.Context, key string, at, resetBefore time.Time) (*Attempts, error)
+	SetLockedUntil(ctx context.Context, key string, lockedUntil time.Time) error
+	ResetAttempts(ctx context.Context, key string) error
+	AddEvent(ctx context.Context, e *Event) error
+}
+
+// Config holds the settings used by the lockout service.
+// A key gets locked for BaseLockout once its failures reach its max within the
+// FailureWindow, the lockout doubles with every further failure up to MaxLockout.
+type Config struct {
+	MaxUsernameFailures int
+	MaxIPFailures       int
+	FailureWindow       time.Duration
+	BaseLockout         time.Duration
+	MaxLockout          time.Duration
+}
+
+// UnknownUsername is the username failures against identifiers that match no
+// user are counted under, for the username lockout not to be dodged by making up
+// a new one for every attempt. It's no valid username so it matches no user.
+const UnknownUsername = "*unknown*"
+
+// UsernameKey returns the key failures against the given username are counted under.
+func UsernameKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+// IPKey returns the key failures from the given IP address are counted under.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+type service struct {
+	Config
+	repo *Repository
+}
+
+// NewService returns a struct that implements the lockout.Service interface
+func NewService(repo *Repository, config Config) Service {
+	return &service{Config: config, repo: repo}
+}
+
+// Check returns how long the longest of the locks on the username and the ip
+// still has to go. Zero is returned if neither is locked.
+func (s *service) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	var wait time.Duration
+	now := time.Now()
+	for _, key := range s.keys(username, ip) {
+		a, err := (*s.repo).GetAttempts(ctx, key)
+		if err != nil {
+			return 0, fmt.Errorf("unable to check for lockout because %v", err)
+		}
+		if remaining := a.LockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed attempt against the username and the ip, locking
+// those that reached their max. It returns the longest lockout it applied, zero if none.
+// Every lockout is recorded as an Event.
+func (s *service) RecordFailure(ctx context.Context, username, ip string) (time.Duration, error) {
+	var wait time.Duration
+	now := time.Now()
+	for _, key := range s.keys(username, ip) {
+		a, err := (*s.repo).AddFailure(ctx, key, now, now.Add(-s.FailureWindow))
+		if err != nil {
+			return 0, fmt.Errorf("unable to record failure because %v", err)
+		}
+		lockout := s.lockoutFor(key, a.Failures)
+		if lockout == 0 {
+			continue
+		}
+		lockedUntil := now.Add(lockout)
+		if err = (*s.repo).SetLockedUntil(ctx, key, lockedUntil); err != nil {
+			return 0, fmt.Errorf("unable to lock because %v", err)
+		}
+		err = (*s.repo).AddEvent(ctx, &Event{
+			Kind:         EventLockout,
+			Key:          key,
+			Failures:     a.Failures,
+			LockedUntil:  lockedUntil,
+			CreationTime: now,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("unable to audit lockout because %v", err)
+		}
+		if lockout > wait {
+			wait = lockout
+		}
+	}
+	return wait, nil
+}
+
+// RecordSuccess forgets the failures against the username.
+// Failures from the ip are kept since a single valid account shouldn't
+// let an address try its luck on others.
+func (s *service) RecordSuccess(ctx context.Context, username string) error {
+	if username == "" {
+		return nil
+	}
+	return (*s.repo).ResetAttempts(ctx, UsernameKey(username))
+}
+
+// lockoutFor is a helper function that returns how long a key with the given
+// number of failures should be locked for.
+func (s *service) lockoutFor(key string, failures int) time.Duration {
+	max := s.MaxIPFailures
+	if strings.HasPrefix(key, "username:") {
+		max = s.MaxUsernameFailures
+	}
+	if max <= 0 || failures < max {
+		return 0
+	}
+	lockout := s.BaseLockout
+	for i := max; i < failures && lockout < s.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > s.MaxLockout {
+		lockout = s.MaxLockout
+	}
+	return lockout
+}
+
+// keys is a helper function that returns the keys of the given username and ip
+// skipping those that are empty.
+func (s *service) keys(username, ip string) []string {
+	keys := make([]string, 0, 2)
+	if username != "" {
+		keys = append(keys, UsernameKey(username))
+	}
+	if ip != "" {
+		keys = append(keys, IPKey(ip))
+	}
+	return keys
+}
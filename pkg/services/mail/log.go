@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"log"
+	"strings"
+)
+
+type logMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer returns a struct that implements the mail.Mailer interface by
+// printing messages to the given logger instead of sending them.
+// Meant for local development and tests.
+func NewLogMailer(logger *log.Logger) Mailer {
+	return &logMailer{logger: logger}
+}
+
+// Send prints the message to the logger.
+func (m *logMailer) Send(msg *Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	m.logger.Printf("mail to %s\nSubject: %s\n\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+	return nil
+}
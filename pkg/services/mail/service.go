@@ -0,0 +1,22 @@
+/*
+Package mail contains definition and implementations of a service that
+delivers email messages to users.
+*/
+package mail
+
+import "fmt"
+
+// Message represents a plain text email message.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer specifies a method to deliver messages.
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// ErrNoRecipients is returned when a message with no recipients is passed to be sent.
+var ErrNoRecipients = fmt.Errorf("message has no recipients")
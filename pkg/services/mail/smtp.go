@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer returns a struct that implements the mail.Mailer interface by
+// relaying messages through the given SMTP server.
+// Authentication is skipped if username is empty.
+func NewSMTPMailer(host string, port int, username, password, from string) Mailer {
+	m := &smtpMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Send delivers the message through the SMTP server.
+func (m *smtpMailer) Send(msg *Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	err := smtp.SendMail(m.addr, m.auth, m.from, msg.To, formatMessage(m.from, msg))
+	if err != nil {
+		return fmt.Errorf("sending mail failed because of: %w", err)
+	}
+	return nil
+}
+
+// formatMessage is a helper function that renders the message in RFC 5322 format.
+// Line breaks are dropped from the header values so that they can't add headers
+// of their own and the lines of the body are ended with CRLF.
+func formatMessage(from string, msg *Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(strings.Join(msg.To, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// headerValue is a helper function that replaces the line breaks in the value with spaces.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(value)
+}
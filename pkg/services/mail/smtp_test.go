@@ -0,0 +1,151 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMessage(t *testing.T) {
+	msg := &Message{
+		To:      []string{"alice@example.com", "bobby@example.com"},
+		Subject: "password reset",
+		Body:    "use the token\nabcd\r\nto reset it",
+	}
+	header, body, ok := strings.Cut(string(formatMessage("issue1@example.com", msg)), "\r\n\r\n")
+	if !ok {
+		t.Fatalf("expected the header to be separated from the body by an empty line, got %q", header)
+	}
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(header + "\r\n\r\n")))
+	fields, err := r.ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("parsing the header failed because of: %v", err)
+	}
+	for name, want := range map[string]string{
+		"From":         "issue1@example.com",
+		"To":           "alice@example.com, bobby@example.com",
+		"Subject":      "password reset",
+		"Mime-Version": "1.0",
+		"Content-Type": `text/plain; charset="utf-8"`,
+	} {
+		if got := fields.Get(name); got != want {
+			t.Errorf("expected %s: %q, got %q", name, want, got)
+		}
+	}
+	if _, err := time.Parse(time.RFC1123Z, fields.Get("Date")); err != nil {
+		t.Errorf("expected an RFC 1123 Date, got %q", fields.Get("Date"))
+	}
+	if want := "use the token\r\nabcd\r\nto reset it"; body != want {
+		t.Errorf("expected the lines of the body to end with CRLF, got %q", body)
+	}
+}
+
+func TestFormatMessageHeaderInjection(t *testing.T) {
+	msg := &Message{
+		To:      []string{"alice@example.com\r\nBcc: eve@example.com"},
+		Subject: "hello\nBcc: eve@example.com",
+	}
+	header, _, _ := strings.Cut(string(formatMessage("issue1@example.com", msg)), "\r\n\r\n")
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("expected line breaks in header values to be dropped, got the header %q", header)
+		}
+	}
+}
+
+// fakeSMTPServer accepts a single mail on a local port and hands the envelope
+// and data of it over on the returned channel.
+func fakeSMTPServer(t *testing.T) (host string, port int, received <-chan []string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	c := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var lines []string
+		tp.PrintfLine("220 localhost")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL", "RCPT":
+				lines = append(lines, line)
+				tp.PrintfLine("250 ok")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				lines = append(lines, data...)
+				tp.PrintfLine("250 ok")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				c <- lines
+				return
+			default:
+				tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	addr := l.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, c
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	m := NewSMTPMailer(host, port, "", "", "issue1@example.com")
+
+	if err := m.Send(&Message{Subject: "nobody"}); err != ErrNoRecipients {
+		t.Errorf("expected a message with no recipients to be refused with ErrNoRecipients, got %v", err)
+	}
+	if err := m.Send(&Message{To: []string{"alice@example.com"}, Subject: "hello", Body: "hi alice"}); err != nil {
+		t.Fatalf("sending failed because of: %v", err)
+	}
+	select {
+	case lines := <-received:
+		got := strings.Join(lines, "\n")
+		for _, want := range []string{
+			"MAIL FROM:<issue1@example.com>",
+			"RCPT TO:<alice@example.com>",
+			"Subject: hello",
+			"hi alice",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected the server to receive %q, got:\n%s", want, got)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server didn't receive the mail within 5 seconds")
+	}
+}
+
+func TestNewSMTPMailerAddress(t *testing.T) {
+	m := NewSMTPMailer("::1", 587, "", "", "issue1@example.com").(*smtpMailer)
+	if want := "[::1]:" + strconv.Itoa(587); m.addr != want {
+		t.Errorf("expected the address %q, got %q", want, m.addr)
+	}
+	if m.auth != nil {
+		t.Errorf("expected no authentication without a username")
+	}
+	if m = NewSMTPMailer("localhost", 587, "alice", "password", "issue1@example.com").(*smtpMailer); m.auth == nil {
+		t.Errorf("expected authentication with a username")
+	}
+}
@@ -0,0 +1,134 @@
+package oauth
+
+import "time"
+
+// Scopes only meaningful to OpenID Connect. Clients can also request any of
+// auth.GrantableScopes.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// Grant types supported by the token endpoint.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// CodeChallengeMethodS256 is the only PKCE method accepted, plain challenges aren't.
+const CodeChallengeMethodS256 = "S256"
+
+// Client represents a third party application registered to act on behalf of users.
+// Confidential clients authenticate with their secret at the token endpoint while
+// public ones, like browser extensions, can't keep a secret and rely on PKCE alone.
+// Only the hash of the secret is stored, Secret is only set on the struct returned
+// on registration.
+type Client struct {
+	ID            string    `json:"id"`
+	Secret        string    `json:"secret,omitempty"`
+	SecretHash    string    `json:"-"`
+	Name          string    `json:"name"`
+	RedirectURIs  []string  `json:"redirectURIs"`
+	Confidential  bool      `json:"confidential"`
+	OwnerUsername string    `json:"ownerUsername"`
+	CreationTime  time.Time `json:"creationTime"`
+}
+
+// AuthorizationRequest holds the parameters of a request to the authorization endpoint.
+type AuthorizationRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scopes              []string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// ConsentPrompt describes an AuthorizationRequest to the user deciding on it.
+// AlreadyGranted is true if the user has consented to all of the scopes before,
+// consent screens can approve such requests without asking the user again.
+type ConsentPrompt struct {
+	ClientID       string   `json:"clientID"`
+	ClientName     string   `json:"clientName"`
+	Scopes         []string `json:"scopes"`
+	AlreadyGranted bool     `json:"alreadyGranted"`
+}
+
+// Consent records the scopes a user has allowed a client.
+type Consent struct {
+	Username     string
+	ClientID     string
+	Scopes       []string
+	CreationTime time.Time
+}
+
+// AuthorizationCode represents a server side record of an issued authorization code.
+// Only the hash of the code is stored and it can only be used once.
+type AuthorizationCode struct {
+	CodeHash            string
+	ClientID            string
+	Username            string
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	Used                bool
+	CreationTime        time.Time
+	ExpiresAt           time.Time
+}
+
+// TokenRequest holds the parameters of a request to the token endpoint along
+// with the details of the client that made it.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	ClientID     string
+	ClientSecret string
+	UserAgent    string
+	IPAddress    string
+}
+
+// TokenResponse is the RFC 6749 response of the token endpoint.
+// IDToken is only included for grants with the openid scope.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// UserInfo holds the OpenID Connect claims about a user.
+// The profile and email claims are only set if the matching scope was granted.
+type UserInfo struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Name              string `json:"name,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+}
+
+// DiscoveryDocument is the OpenID Connect provider metadata.
+type DiscoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
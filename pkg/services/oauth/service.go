@@ -0,0 +1,470 @@
+/*
+Package oauth contains definition and implementation of a service that lets
+third party clients act on behalf of users through the OAuth2 authorization
+code flow with PKCE, along with the OpenID Connect additions to it.
+Tokens are issued by the auth.Service.
+*/
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+	"github.com/slim-crown/issue-1-REST/pkg/services/domain/user"
+)
+
+// Service specifies the methods of an OAuth2 authorization server.
+type Service interface {
+	RegisterClient(ctx context.Context, c *Client) (*Client, error)
+	GetClient(ctx context.Context, id string) (*Client, error)
+	GetClients(ctx context.Context, ownerUsername string) ([]*Client, error)
+	DeleteClient(ctx context.Context, ownerUsername, id string) error
+	GetConsentPrompt(ctx context.Context, username string, req *AuthorizationRequest) (*ConsentPrompt, error)
+	Authorize(ctx context.Context, username string, req *AuthorizationRequest) (string, error)
+	Deny(ctx context.Context, req *AuthorizationRequest) (string, error)
+	Exchange(ctx context.Context, req *TokenRequest) (*TokenResponse, error)
+	GetUserInfo(ctx context.Context, principal *auth.Principal) (*UserInfo, error)
+	GetDiscoveryDocument() *DiscoveryDocument
+}
+
+// Repository specifies a repo interface to serve the oauth.Service interface
+type Repository interface {
+	AddClient(ctx context.Context, c *Client) error
+	GetClient(ctx context.Context, id string) (*Client, error)
+	GetClients(ctx context.Context, ownerUsername string) ([]*Client, error)
+	DeleteClient(ctx context.Context, ownerUsername, id string) error
+	GetConsent(ctx context.Context, username, clientID string) (*Consent, error)
+	SetConsent(ctx context.Context, c *Consent) error
+	AddAuthorizationCode(ctx context.Context, code *AuthorizationCode) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+}
+
+// ErrClientNotFound is returned when the client specified isn't recognized
+var ErrClientNotFound = fmt.Errorf("client not found")
+
+// ErrConsentNotFound is returned when the user specified hasn't consented to the client specified
+var ErrConsentNotFound = fmt.Errorf("consent not found")
+
+// ErrInvalidClient is returned when a client fails to authenticate
+var ErrInvalidClient = fmt.Errorf("client invalid")
+
+// ErrInvalidRedirectURI is returned when the redirect uri specified isn't one registered for the client.
+// Users mustn't be redirected when this happens.
+var ErrInvalidRedirectURI = fmt.Errorf("redirect uri invalid")
+
+// ErrInvalidScope is returned when a scope specified isn't supported
+var ErrInvalidScope = fmt.Errorf("scope invalid")
+
+// ErrInvalidRequest is returned when a parameter is missing or malformed, like a missing PKCE challenge
+var ErrInvalidRequest = fmt.Errorf("request invalid")
+
+// ErrInvalidGrant is returned when the authorization code or refresh token specified is unknown, expired,
+// used, issued to another client or doesn't match the PKCE verifier
+var ErrInvalidGrant = fmt.Errorf("grant invalid")
+
+// ErrUnsupportedGrantType is returned when the grant type specified isn't supported
+var ErrUnsupportedGrantType = fmt.Errorf("grant type unsupported")
+
+// Config holds the settings used by the oauth service.
+// Issuer is the base URL of the server, endpoints are advertised relative to it.
+type Config struct {
+	Issuer                    string
+	AuthorizationCodeLifetime time.Duration
+}
+
+type service struct {
+	Config
+	repo        *Repository
+	authService auth.Service
+	userService user.Service
+	keys        *auth.KeySet
+}
+
+// NewService returns a struct that implements the oauth.Service interface.
+// ID tokens are signed with the given keys, the same ones the auth.Service uses.
+func NewService(repo *Repository, authService auth.Service, userService user.Service, keys *auth.KeySet, config Config) Service {
+	return &service{
+		Config:      config,
+		repo:        repo,
+		authService: authService,
+		userService: userService,
+		keys:        keys,
+	}
+}
+
+// RegisterClient registers a new client, generating its id and, for confidential
+// clients, its secret. Redirect uris must be absolute.
+func (s *service) RegisterClient(ctx context.Context, c *Client) (*Client, error) {
+	if c.Name == "" || len(c.RedirectURIs) == 0 {
+		return nil, ErrInvalidRequest
+	}
+	for _, redirectURI := range c.RedirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			return nil, ErrInvalidRedirectURI
+		}
+	}
+	var err error
+	if c.ID, err = generateRandomString(16); err != nil {
+		return nil, fmt.Errorf("client id generation failed because %v", err)
+	}
+	c.Secret, c.SecretHash = "", ""
+	if c.Confidential {
+		if c.Secret, err = generateRandomString(32); err != nil {
+			return nil, fmt.Errorf("client secret generation failed because %v", err)
+		}
+		c.SecretHash = hashSecret(c.Secret)
+	}
+	c.CreationTime = time.Now()
+	if err = (*s.repo).AddClient(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetClient returns the client with the given id.
+func (s *service) GetClient(ctx context.Context, id string) (*Client, error) {
+	return (*s.repo).GetClient(ctx, id)
+}
+
+// GetClients returns the clients registered by the given user.
+func (s *service) GetClients(ctx context.Context, ownerUsername string) ([]*Client, error) {
+	return (*s.repo).GetClients(ctx, ownerUsername)
+}
+
+// DeleteClient removes the client of the given user with the given id.
+func (s *service) DeleteClient(ctx context.Context, ownerUsername, id string) error {
+	return (*s.repo).DeleteClient(ctx, ownerUsername, id)
+}
+
+// GetConsentPrompt validates the request and describes it to the user deciding on it.
+func (s *service) GetConsentPrompt(ctx context.Context, username string, req *AuthorizationRequest) (*ConsentPrompt, error) {
+	c, err := s.validateAuthorizationRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	prompt := &ConsentPrompt{ClientID: c.ID, ClientName: c.Name, Scopes: req.Scopes}
+	consent, err := (*s.repo).GetConsent(ctx, username, c.ID)
+	switch err {
+	case nil:
+		prompt.AlreadyGranted = containsAll(consent.Scopes, req.Scopes)
+	case ErrConsentNotFound:
+	default:
+		return nil, err
+	}
+	return prompt, nil
+}
+
+// Authorize records the user's consent to the request and issues an authorization code
+// for it. It returns the uri the user is to be redirected to, carrying the code.
+func (s *service) Authorize(ctx context.Context, username string, req *AuthorizationRequest) (string, error) {
+	c, err := s.validateAuthorizationRequest(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	consent, err := (*s.repo).GetConsent(ctx, username, c.ID)
+	switch err {
+	case nil:
+		consent.Scopes = union(consent.Scopes, req.Scopes)
+	case ErrConsentNotFound:
+		consent = &Consent{Username: username, ClientID: c.ID, Scopes: req.Scopes, CreationTime: now}
+	default:
+		return "", err
+	}
+	if err = (*s.repo).SetConsent(ctx, consent); err != nil {
+		return "", err
+	}
+	code, err := generateRandomString(32)
+	if err != nil {
+		return "", fmt.Errorf("authorization code generation failed because %v", err)
+	}
+	err = (*s.repo).AddAuthorizationCode(ctx, &AuthorizationCode{
+		CodeHash:            hashSecret(code),
+		ClientID:            c.ID,
+		Username:            username,
+		RedirectURI:         req.RedirectURI,
+		Scopes:              req.Scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+		CreationTime:        now,
+		ExpiresAt:           now.Add(s.AuthorizationCodeLifetime),
+	})
+	if err != nil {
+		return "", err
+	}
+	return redirectWith(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// Deny returns the uri the user is to be redirected to after refusing the request.
+func (s *service) Deny(ctx context.Context, req *AuthorizationRequest) (string, error) {
+	if _, err := s.validateAuthorizationRequest(ctx, req); err != nil {
+		return "", err
+	}
+	return redirectWith(req.RedirectURI, url.Values{"error": {"access_denied"}, "state": {req.State}}), nil
+}
+
+// Exchange trades an authorization code or a refresh token for tokens.
+func (s *service) Exchange(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	c, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(ctx, c, req)
+	case GrantTypeRefreshToken:
+		pair, err := s.authService.RefreshTokens(ctx, req.RefreshToken, c.ID)
+		switch err {
+		case nil:
+		case auth.ErrInvalidRefreshToken, auth.ErrRefreshTokenReused:
+			return nil, ErrInvalidGrant
+		default:
+			return nil, err
+		}
+		return tokenResponse(pair), nil
+	}
+	return nil, ErrUnsupportedGrantType
+}
+
+// GetUserInfo returns the claims about the principal's user its scopes allow.
+func (s *service) GetUserInfo(ctx context.Context, principal *auth.Principal) (*UserInfo, error) {
+	info := &UserInfo{Subject: principal.Username}
+	if !principal.HasScope(ScopeProfile) && !principal.HasScope(ScopeEmail) {
+		return info, nil
+	}
+	u, err := s.userService.GetUser(ctx, principal.Username)
+	if err != nil {
+		return nil, err
+	}
+	if principal.HasScope(ScopeProfile) {
+		info.PreferredUsername = u.Username
+		info.Name = strings.Join(strings.Fields(u.FirstName+" "+u.MiddleName+" "+u.LastName), " ")
+		info.Picture = u.PictureURL
+	}
+	if principal.HasScope(ScopeEmail) {
+		info.Email = u.Email
+		verified := u.Verified
+		info.EmailVerified = &verified
+	}
+	return info, nil
+}
+
+// GetDiscoveryDocument returns the OpenID Connect provider metadata of the server.
+func (s *service) GetDiscoveryDocument() *DiscoveryDocument {
+	return &DiscoveryDocument{
+		Issuer:                            s.Issuer,
+		AuthorizationEndpoint:             s.Issuer + "/oauth/authorize",
+		TokenEndpoint:                     s.Issuer + "/oauth/token",
+		UserInfoEndpoint:                  s.Issuer + "/oauth/userinfo",
+		JWKSURI:                           s.Issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   supportedScopes(),
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{auth.AlgorithmRS256, auth.AlgorithmEdDSA},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{CodeChallengeMethodS256},
+	}
+}
+
+// exchangeAuthorizationCode is a helper function that trades the code of the request
+// for tokens, adding an ID token if the openid scope was granted.
+func (s *service) exchangeAuthorizationCode(ctx context.Context, c *Client, req *TokenRequest) (*TokenResponse, error) {
+	code, err := (*s.repo).ConsumeAuthorizationCode(ctx, hashSecret(req.Code))
+	switch err {
+	case nil:
+	case ErrInvalidGrant:
+		return nil, ErrInvalidGrant
+	default:
+		return nil, err
+	}
+	if code.ClientID != c.ID || code.RedirectURI != req.RedirectURI || time.Now().After(code.ExpiresAt) {
+		return nil, ErrInvalidGrant
+	}
+	if !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier) {
+		return nil, ErrInvalidGrant
+	}
+	pair, err := s.authService.IssueClientTokens(ctx, code.Username, c.ID, code.Scopes, req.UserAgent, req.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	response := tokenResponse(pair)
+	if contains(code.Scopes, ScopeOpenID) {
+		if response.IDToken, err = s.generateIDToken(ctx, code, pair.ExpiresIn); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// generateIDToken is a helper function that generates an OpenID Connect ID token
+// for the grant of the given code.
+func (s *service) generateIDToken(ctx context.Context, code *AuthorizationCode, expiresIn int) (string, error) {
+	principal := &auth.Principal{Username: code.Username, Scopes: code.Scopes}
+	info, err := s.GetUserInfo(ctx, principal)
+	if err != nil {
+		return "", fmt.Errorf("fetching user info failed because %v", err)
+	}
+	now := time.Now()
+	mapClaim := jwt.MapClaims{}
+	mapClaim["iss"] = s.Issuer
+	mapClaim["sub"] = code.Username
+	mapClaim["aud"] = code.ClientID
+	mapClaim["exp"] = now.Add(time.Duration(expiresIn) * time.Second).Unix()
+	mapClaim["iat"] = now.Unix()
+	mapClaim["auth_time"] = code.CreationTime.Unix()
+	// ID tokens mustn't be accepted as access tokens
+	mapClaim["typ"] = auth.TokenTypeIDToken
+	if code.Nonce != "" {
+		mapClaim["nonce"] = code.Nonce
+	}
+	if info.PreferredUsername != "" {
+		mapClaim["preferred_username"] = info.PreferredUsername
+		mapClaim["name"] = info.Name
+	}
+	if info.EmailVerified != nil {
+		mapClaim["email"] = info.Email
+		mapClaim["email_verified"] = *info.EmailVerified
+	}
+	tokenString, err := s.keys.Sign(mapClaim)
+	if err != nil {
+		return "", fmt.Errorf("id token signing failed because %v", err)
+	}
+	return tokenString, nil
+}
+
+// validateAuthorizationRequest is a helper function that checks the request
+// against the client it names, returning the client.
+func (s *service) validateAuthorizationRequest(ctx context.Context, req *AuthorizationRequest) (*Client, error) {
+	c, err := (*s.repo).GetClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(c.RedirectURIs, req.RedirectURI) {
+		return nil, ErrInvalidRedirectURI
+	}
+	if req.ResponseType != "code" || req.CodeChallenge == "" || req.CodeChallengeMethod != CodeChallengeMethodS256 {
+		return nil, ErrInvalidRequest
+	}
+	if len(req.Scopes) == 0 || !containsAll(supportedScopes(), req.Scopes) {
+		return nil, ErrInvalidScope
+	}
+	return c, nil
+}
+
+// authenticateClient is a helper function that looks up the client and, if it's
+// confidential, checks its secret.
+func (s *service) authenticateClient(ctx context.Context, id, secret string) (*Client, error) {
+	c, err := (*s.repo).GetClient(ctx, id)
+	switch err {
+	case nil:
+	case ErrClientNotFound:
+		return nil, ErrInvalidClient
+	default:
+		return nil, err
+	}
+	if c.Confidential && subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return c, nil
+}
+
+// tokenResponse is a helper function that formats the pair as a TokenResponse.
+func tokenResponse(pair *auth.TokenPair) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  pair.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    pair.ExpiresIn,
+		RefreshToken: pair.RefreshToken,
+		Scope:        strings.Join(pair.Scopes, " "),
+	}
+}
+
+// verifyCodeChallenge is a helper function that checks the PKCE verifier against
+// the S256 challenge.
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	hash := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(hash[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// redirectWith is a helper function that adds the given values to the query of the uri.
+// Empty values are left out.
+func redirectWith(redirectURI string, values url.Values) string {
+	u, _ := url.Parse(redirectURI)
+	query := u.Query()
+	for key := range values {
+		if value := values.Get(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// supportedScopes returns the scopes clients can request.
+func supportedScopes() []string {
+	return append([]string{ScopeOpenID, ScopeProfile, ScopeEmail}, auth.GrantableScopes...)
+}
+
+// contains is a helper function that checks whether the slice holds the value.
+func contains(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll is a helper function that checks whether the slice holds all the values.
+func containsAll(slice, values []string) bool {
+	for _, v := range values {
+		if !contains(slice, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// union is a helper function that returns the values in either slice once.
+func union(a, b []string) []string {
+	result := append([]string{}, a...)
+	for _, v := range b {
+		if !contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// hashSecret returns the hex encoded SHA-256 hash of the given client secret or code.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// generateRandomString returns a random URL-safe string made out of n random bytes.
+func generateRandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,163 @@
+package policy
+
+import (
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+)
+
+// Action identifies something a principal wants to do to a resource.
+type Action string
+
+// Actions guarded by the policy.
+const (
+	ChannelCreate         Action = "channel:create"
+	ChannelUpdate         Action = "channel:update"
+	ChannelDelete         Action = "channel:delete"
+	ChannelViewPrivate    Action = "channel:view-private"
+	ChannelAddAdmin       Action = "channel:add-admin"
+	ChannelRemoveAdmin    Action = "channel:remove-admin"
+	ChannelChangeOwner    Action = "channel:change-owner"
+	ChannelManageCatalog  Action = "channel:manage-catalog"
+	ChannelManageStickies Action = "channel:manage-stickies"
+	ChannelManagePicture  Action = "channel:manage-picture"
+
+	ReleaseCreate         Action = "release:create"
+	ReleaseViewUnofficial Action = "release:view-unofficial"
+	ReleaseUpdate         Action = "release:update"
+	ReleaseDelete         Action = "release:delete"
+
+	PostCreate Action = "post:create"
+	PostUpdate Action = "post:update"
+	PostDelete Action = "post:delete"
+	PostStar   Action = "post:star"
+
+	CommentCreate Action = "comment:create"
+	CommentUpdate Action = "comment:update"
+	CommentDelete Action = "comment:delete"
+
+	UserUpdate          Action = "user:update"
+	UserDelete          Action = "user:delete"
+	UserViewPrivate     Action = "user:view-private"
+	UserManageBookmarks Action = "user:manage-bookmarks"
+	UserManagePicture   Action = "user:manage-picture"
+	UserManageFeed      Action = "user:manage-feed"
+	UserManageTOTP      Action = "user:manage-totp"
+	UserManageTokens    Action = "user:manage-tokens"
+	UserManageSessions  Action = "user:manage-sessions"
+	UserManageClients   Action = "user:manage-clients"
+	UserAuthorizeClient Action = "user:authorize-client"
+)
+
+// Role is a relationship a principal can have with a resource.
+type Role string
+
+// Roles recognized by the policy.
+const (
+	RoleChannelOwner Role = "channel-owner"
+	RoleChannelAdmin Role = "channel-admin"
+	RolePostAuthor   Role = "post-author"
+	RoleCommenter    Role = "commenter"
+	// RoleSelf is held when the resource's Username is the principal's.
+	RoleSelf Role = "self"
+	// RoleModerator is held by site moderators regardless of the resource.
+	RoleModerator Role = "moderator"
+)
+
+// Resource describes the ownership facts about the thing being acted upon
+// that the policy needs. Only the fields relevant to the action need be set.
+type Resource struct {
+	ChannelOwner  string
+	ChannelAdmins []string
+	PostAuthor    string
+	Commenter     string
+	Username      string
+}
+
+// Rules maps each Action to the roles that are allowed to perform it.
+// Actions missing from the table are denied to everyone.
+type Rules map[Action][]Role
+
+// DefaultRules is the rule table used by the server.
+var DefaultRules = Rules{
+	ChannelCreate:         {RoleSelf},
+	ChannelUpdate:         {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+	ChannelDelete:         {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+	ChannelViewPrivate:    {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+	ChannelAddAdmin:       {RoleChannelOwner, RoleChannelAdmin},
+	ChannelRemoveAdmin:    {RoleChannelOwner},
+	ChannelChangeOwner:    {RoleChannelOwner},
+	ChannelManageCatalog:  {RoleChannelOwner, RoleChannelAdmin},
+	ChannelManageStickies: {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+	ChannelManagePicture:  {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+
+	ReleaseCreate:         {RoleChannelOwner, RoleChannelAdmin},
+	ReleaseViewUnofficial: {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+	ReleaseUpdate:         {RoleChannelOwner, RoleChannelAdmin},
+	ReleaseDelete:         {RoleChannelOwner, RoleChannelAdmin, RoleModerator},
+
+	PostCreate: {RoleSelf},
+	PostUpdate: {RolePostAuthor},
+	PostDelete: {RolePostAuthor, RoleModerator},
+	PostStar:   {RoleSelf},
+
+	CommentCreate: {RoleSelf},
+	CommentUpdate: {RoleCommenter},
+	CommentDelete: {RoleCommenter, RoleModerator},
+
+	UserUpdate:          {RoleSelf},
+	UserDelete:          {RoleSelf, RoleModerator},
+	UserViewPrivate:     {RoleSelf},
+	UserManageBookmarks: {RoleSelf},
+	UserManagePicture:   {RoleSelf, RoleModerator},
+	UserManageFeed:      {RoleSelf},
+	UserManageTOTP:      {RoleSelf},
+	UserManageTokens:    {RoleSelf},
+	UserManageSessions:  {RoleSelf},
+	UserManageClients:   {RoleSelf},
+	UserAuthorizeClient: {RoleSelf},
+}
+
+// DefaultVerifiedOnly lists the actions principals who haven't verified their
+// email can't perform, whatever roles they hold.
+var DefaultVerifiedOnly = map[Action]bool{
+	ChannelCreate: true,
+	PostCreate:    true,
+	CommentCreate: true,
+	PostStar:      true,
+}
+
+// Scopes maps each Action to the scope a principal needs to perform it.
+// Actions missing from the table need auth.ScopeAll.
+type Scopes map[Action]string
+
+// DefaultScopes is the scope table used by the server. Deleting accounts or
+// channels, transferring channels and managing credentials are left out so
+// that they stay out of reach of personal access tokens.
+var DefaultScopes = Scopes{
+	ChannelCreate:         auth.ScopeChannelsWrite,
+	ChannelUpdate:         auth.ScopeChannelsWrite,
+	ChannelViewPrivate:    auth.ScopePrivateRead,
+	ChannelAddAdmin:       auth.ScopeChannelsWrite,
+	ChannelRemoveAdmin:    auth.ScopeChannelsWrite,
+	ChannelManageCatalog:  auth.ScopeChannelsWrite,
+	ChannelManageStickies: auth.ScopeChannelsWrite,
+	ChannelManagePicture:  auth.ScopeChannelsWrite,
+
+	ReleaseCreate:         auth.ScopeReleasesWrite,
+	ReleaseViewUnofficial: auth.ScopePrivateRead,
+	ReleaseUpdate:         auth.ScopeReleasesWrite,
+	ReleaseDelete:         auth.ScopeReleasesWrite,
+
+	PostCreate: auth.ScopePostsWrite,
+	PostUpdate: auth.ScopePostsWrite,
+	PostDelete: auth.ScopePostsWrite,
+	PostStar:   auth.ScopePostsWrite,
+
+	CommentCreate: auth.ScopeCommentsWrite,
+	CommentUpdate: auth.ScopeCommentsWrite,
+	CommentDelete: auth.ScopeCommentsWrite,
+
+	UserViewPrivate:     auth.ScopePrivateRead,
+	UserManageBookmarks: auth.ScopeUsersWrite,
+	UserManagePicture:   auth.ScopeUsersWrite,
+	UserManageFeed:      auth.ScopeUsersWrite,
+}
@@ -0,0 +1,114 @@
+/*
+Package policy contains definition and implementation of a service that decides
+whether an authenticated principal may perform an action on a resource.
+*/
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+)
+
+// Service answers "can principal X do action Y on resource Z".
+type Service interface {
+	Can(principal *auth.Principal, action Action, resource *Resource) bool
+	RolesOf(principal *auth.Principal, resource *Resource) []Role
+}
+
+// ErrForbidden is returned by the services that consult the policy when the
+// principal they're called on behalf of isn't allowed to do what's asked.
+var ErrForbidden = fmt.Errorf("principal is not allowed to perform the action")
+
+// Authorize is a helper function services call before acting on a resource.
+// It returns ErrForbidden unless the principal attached to ctx, the one
+// auth.PrincipalFromContext returns, may perform the action on the resource.
+func Authorize(ctx context.Context, s Service, action Action, resource *Resource) error {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if !s.Can(principal, action, resource) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+type service struct {
+	rules        Rules
+	verifiedOnly map[Action]bool
+	scopes       Scopes
+	moderators   map[string]bool
+}
+
+// NewService returns a struct that implements the policy.Service interface
+// using the given rule table. Actions in verifiedOnly are denied to principals
+// who haven't verified their email while the scope table limits what
+// principals with restricted scopes can do. The given usernames hold the moderator role.
+func NewService(rules Rules, verifiedOnly map[Action]bool, scopes Scopes, moderatorUsernames []string) Service {
+	s := &service{rules: rules, verifiedOnly: verifiedOnly, scopes: scopes, moderators: make(map[string]bool)}
+	for _, username := range moderatorUsernames {
+		s.moderators[username] = true
+	}
+	return s
+}
+
+// Can returns true if any of the roles the principal holds over the resource
+// is allowed to perform the action and the principal holds the scope the action needs.
+// A nil principal is never allowed.
+func (s *service) Can(principal *auth.Principal, action Action, resource *Resource) bool {
+	allowed, ok := s.rules[action]
+	if !ok || principal == nil {
+		return false
+	}
+	if s.verifiedOnly[action] && !principal.Verified {
+		return false
+	}
+	scope, ok := s.scopes[action]
+	if !ok {
+		scope = auth.ScopeAll
+	}
+	if !principal.HasScope(scope) {
+		return false
+	}
+	for _, held := range s.RolesOf(principal, resource) {
+		for _, role := range allowed {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RolesOf returns the roles the principal holds over the resource.
+func (s *service) RolesOf(principal *auth.Principal, resource *Resource) []Role {
+	if principal == nil || principal.Username == "" {
+		return nil
+	}
+	username := principal.Username
+	roles := make([]Role, 0)
+	if s.moderators[username] {
+		roles = append(roles, RoleModerator)
+	}
+	if resource == nil {
+		return roles
+	}
+	if resource.ChannelOwner == username {
+		roles = append(roles, RoleChannelOwner)
+	}
+	for _, admin := range resource.ChannelAdmins {
+		if admin == username {
+			roles = append(roles, RoleChannelAdmin)
+			break
+		}
+	}
+	if resource.PostAuthor == username {
+		roles = append(roles, RolePostAuthor)
+	}
+	if resource.Commenter == username {
+		roles = append(roles, RoleCommenter)
+	}
+	if resource.Username == username {
+		roles = append(roles, RoleSelf)
+	}
+	return roles
+}
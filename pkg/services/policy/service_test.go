@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/auth"
+)
+
+// resource is held by a different principal in each of the roles.
+var resource = &Resource{
+	ChannelOwner:  "olive",
+	ChannelAdmins: []string{"adam"},
+	PostAuthor:    "paula",
+	Commenter:     "carl",
+	Username:      "sam",
+}
+
+var principals = map[string]*auth.Principal{
+	"owner":     {Username: "olive", Scopes: []string{auth.ScopeAll}, Verified: true},
+	"admin":     {Username: "adam", Scopes: []string{auth.ScopeAll}, Verified: true},
+	"author":    {Username: "paula", Scopes: []string{auth.ScopeAll}, Verified: true},
+	"commenter": {Username: "carl", Scopes: []string{auth.ScopeAll}, Verified: true},
+	"self":      {Username: "sam", Scopes: []string{auth.ScopeAll}, Verified: true},
+	"moderator": {Username: "mona", Scopes: []string{auth.ScopeAll}, Verified: true},
+	// the account itself, before its email is verified
+	"unverified": {Username: "sam", Scopes: []string{auth.ScopeAll}},
+	// a token of the channel owner only granted the channels:write and private:read scopes
+	"scoped pat": {
+		Username:  "olive",
+		TokenType: auth.TokenTypePersonalAccessToken,
+		Scopes:    []string{auth.ScopeChannelsWrite, auth.ScopePrivateRead},
+		Verified:  true,
+	},
+}
+
+// allowed lists the principals each action is allowed to, all others are denied.
+var allowed = map[Action][]string{
+	ChannelCreate:         {"self"},
+	ChannelUpdate:         {"owner", "admin", "moderator", "scoped pat"},
+	ChannelDelete:         {"owner", "admin", "moderator"},
+	ChannelViewPrivate:    {"owner", "admin", "moderator", "scoped pat"},
+	ChannelAddAdmin:       {"owner", "admin", "scoped pat"},
+	ChannelRemoveAdmin:    {"owner", "scoped pat"},
+	ChannelChangeOwner:    {"owner"},
+	ChannelManageCatalog:  {"owner", "admin", "scoped pat"},
+	ChannelManageStickies: {"owner", "admin", "moderator", "scoped pat"},
+	ChannelManagePicture:  {"owner", "admin", "moderator", "scoped pat"},
+
+	ReleaseCreate:         {"owner", "admin"},
+	ReleaseViewUnofficial: {"owner", "admin", "moderator", "scoped pat"},
+	ReleaseUpdate:         {"owner", "admin"},
+	ReleaseDelete:         {"owner", "admin", "moderator"},
+
+	PostCreate: {"self"},
+	PostUpdate: {"author"},
+	PostDelete: {"author", "moderator"},
+	PostStar:   {"self"},
+
+	CommentCreate: {"self"},
+	CommentUpdate: {"commenter"},
+	CommentDelete: {"commenter", "moderator"},
+
+	UserUpdate:          {"self", "unverified"},
+	UserDelete:          {"self", "moderator", "unverified"},
+	UserViewPrivate:     {"self", "unverified"},
+	UserManageBookmarks: {"self", "unverified"},
+	UserManagePicture:   {"self", "moderator", "unverified"},
+	UserManageFeed:      {"self", "unverified"},
+	UserManageTOTP:      {"self", "unverified"},
+	UserManageTokens:    {"self", "unverified"},
+	UserManageSessions:  {"self", "unverified"},
+	UserManageClients:   {"self", "unverified"},
+	UserAuthorizeClient: {"self", "unverified"},
+}
+
+func newDefaultService() Service {
+	return NewService(DefaultRules, DefaultVerifiedOnly, DefaultScopes, []string{"mona"})
+}
+
+func TestCan(t *testing.T) {
+	s := newDefaultService()
+	for action, names := range allowed {
+		want := make(map[string]bool)
+		for _, name := range names {
+			want[name] = true
+		}
+		for name, principal := range principals {
+			if got := s.Can(principal, action, resource); got != want[name] {
+				t.Errorf("Can(%s, %s) = %v, expected %v", name, action, got, want[name])
+			}
+		}
+	}
+}
+
+func TestCanCoversEveryAction(t *testing.T) {
+	for action := range DefaultRules {
+		if _, ok := allowed[action]; !ok {
+			t.Errorf("%s has rules but isn't covered by the table of TestCan", action)
+		}
+	}
+}
+
+func TestCanDenies(t *testing.T) {
+	s := newDefaultService()
+	if s.Can(nil, UserUpdate, resource) {
+		t.Error("a nil principal is expected to be denied")
+	}
+	if s.Can(principals["owner"], Action("channel:unknown"), resource) {
+		t.Error("actions missing from the rules are expected to be denied")
+	}
+	if s.Can(&auth.Principal{Scopes: []string{auth.ScopeAll}, Verified: true}, UserUpdate, &Resource{}) {
+		t.Error("a principal without a username is expected to be denied")
+	}
+	if !s.Can(principals["moderator"], PostDelete, nil) {
+		t.Error("moderators are expected to hold their role without a resource")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	s := newDefaultService()
+	if err := Authorize(context.Background(), s, PostUpdate, resource); err != ErrForbidden {
+		t.Errorf("Authorize without a principal is expected to return ErrForbidden, got %v", err)
+	}
+	ctx := auth.NewContextWithPrincipal(context.Background(), principals["author"])
+	if err := Authorize(ctx, s, PostUpdate, resource); err != nil {
+		t.Errorf("Authorize is expected to allow the author to update the post, got %v", err)
+	}
+	if err := Authorize(ctx, s, PostCreate, resource); err != ErrForbidden {
+		t.Errorf("Authorize is expected to return ErrForbidden to whom the policy denies, got %v", err)
+	}
+}
@@ -2,16 +2,19 @@
 Package search contains definition and implementation of a service that deals searching.*/
 package search
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Service specifies a method to provide searching functionality.
 type Service interface {
-	SearchComments(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Comment, error)
+	SearchComments(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Comment, error)
 }
 
 // Repository specifies a repo interface to serve the search.Service interface
 type Repository interface {
-	SearchComments(pattern string, sortBy string, sortOrder string, limit, offset int) ([]*Comment, error)
+	SearchComments(ctx context.Context, pattern string, sortBy string, sortOrder string, limit, offset int) ([]*Comment, error)
 }
 
 // SortOrder holds  that specify how comments are sorted
@@ -38,9 +41,9 @@ func NewService(repo *Repository) Service {
 }
 
 // SearchComments returns a list of comments based on the given pattern and pagination parameters.
-func (s service) SearchComments(pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Comment, error) {
+func (s service) SearchComments(ctx context.Context, pattern string, sortBy SortBy, sortOrder SortOrder, limit, offset int) ([]*Comment, error) {
 	if limit < 0 || offset < 0 {
 		return nil, fmt.Errorf("invalid pagination")
 	}
-	return (*s.repo).SearchComments(pattern, string(sortBy), string(sortOrder), limit, offset)
+	return (*s.repo).SearchComments(ctx, pattern, string(sortBy), string(sortOrder), limit, offset)
 }
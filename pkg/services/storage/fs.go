@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileSystemStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewFileSystemStorage returns a struct that implements the storage.Storage
+// interface by keeping each blob as a file under the given directory, which is
+// created if missing. The files are expected to be served publicly under
+// baseURL, the URLs it hands out are that of the file and never expire.
+func NewFileSystemStorage(dir, baseURL string) (Storage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating storage directory failed because of: %w", err)
+	}
+	return &fileSystemStorage{dir: dir, baseURL: baseURL}, nil
+}
+
+// Put writes the blob to a temporary file that's then renamed to that of the key
+// so that a blob is never seen half written. The content type isn't kept, Get
+// works it out from the name or the content of the file.
+func (s *fileSystemStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating blob directory failed because of: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating blob file failed because of: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = io.Copy(tmpFile, r)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing blob file failed because of: %w", err)
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("writing blob file failed because of: %w", err)
+	}
+	return nil
+}
+
+// Get opens the file of the key.
+func (s *fileSystemStorage) Get(ctx context.Context, key string) (*Blob, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening blob file failed because of: %w", err)
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		file.Close()
+		return nil, ErrBlobNotFound
+	}
+	var contentType string
+	if err == nil {
+		contentType, err = detectContentType(file)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading blob file failed because of: %w", err)
+	}
+	return &Blob{
+		ReadCloser:   file,
+		ContentType:  contentType,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Delete removes the file of the key.
+func (s *fileSystemStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing blob file failed because of: %w", err)
+	}
+	return nil
+}
+
+// SignedURL returns the URL the file of the key is served at. Since the files
+// are public it isn't signed and expiresIn is ignored.
+func (s *fileSystemStorage) SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return s.baseURL + escapeKey(key, url.PathEscape), nil
+}
+
+// path is a helper function that returns the path of the file the blob of the
+// key is kept in.
+func (s *fileSystemStorage) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// detectContentType is a helper function that works out the content type of
+// the file from its extension, sniffing its first bytes if that's unknown.
+// The file is left at its start.
+func detectContentType(file *os.File) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(file.Name())); contentType != "" {
+		return contentType, nil
+	}
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxSignedURLLifetime is the longest S3 lets signed URLs live for.
+const MaxSignedURLLifetime = 7 * 24 * time.Hour
+
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3DateFormat    = "20060102T150405Z"
+	s3Service       = "s3"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+type s3Storage struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewS3Storage returns a struct that implements the storage.Storage interface
+// by keeping blobs as objects of the bucket of an S3 compatible service. The
+// endpoint is the base URL of the service, https://s3.<region>.amazonaws.com
+// for AWS, the bucket is addressed as the first segment of its path as MinIO
+// and the like expect. Requests are signed with AWS Signature Version 4 and the
+// URLs it hands out are presigned, the bucket need not be public.
+// http.DefaultClient is used if client is nil.
+func NewS3Storage(endpoint, region, bucket, accessKeyID, secretAccessKey string, client *http.Client) (Storage, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing s3 endpoint failed because of: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q isn't an http or https URL", endpoint)
+	}
+	if bucket == "" || strings.Contains(bucket, "/") {
+		return nil, fmt.Errorf("s3 bucket %q isn't valid", bucket)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath, u.RawQuery, u.Fragment = "", "", ""
+	return &s3Storage{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          client,
+		now:             time.Now,
+	}, nil
+}
+
+// Put uploads the blob as the object of the key. The blob is read into memory
+// first since its length and hash have to be known before it's sent.
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading blob failed because of: %w", err)
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading blob failed because of: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("uploading blob failed because of: %w", readS3Error(resp))
+	}
+	return nil
+}
+
+// Get downloads the object of the key, its body is streamed as it's read.
+func (s *s3Storage) Get(ctx context.Context, key string) (*Blob, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading blob failed because of: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		err := readS3Error(resp)
+		if err.noSuchKey() {
+			return nil, ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("downloading blob failed because of: %w", err)
+	}
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &Blob{
+		ReadCloser:   resp.Body,
+		ContentType:  resp.Header.Get("Content-Type"),
+		Size:         resp.ContentLength,
+		LastModified: lastModified,
+	}, nil
+}
+
+// Delete deletes the object of the key.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("deleting blob failed because of: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		if err := readS3Error(resp); !err.noSuchKey() {
+			return fmt.Errorf("deleting blob failed because of: %w", err)
+		}
+	}
+	return nil
+}
+
+// SignedURL returns a presigned URL to get the object of the key. The URL is
+// signed without contacting the service, it fails to fetch anything if the
+// object is missing. expiresIn is rounded up to the second and mustn't be
+// longer than MaxSignedURLLifetime.
+func (s *s3Storage) SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	if expiresIn <= 0 || expiresIn > MaxSignedURLLifetime {
+		return "", fmt.Errorf("signed url lifetime must be positive and at most %s, got %s", MaxSignedURLLifetime, expiresIn)
+	}
+	now := s.now().UTC()
+	u := s.objectURL(key)
+	query := map[string]string{
+		"X-Amz-Algorithm":     s3Algorithm,
+		"X-Amz-Credential":    s.accessKeyID + "/" + s.scope(now),
+		"X-Amz-Date":          now.Format(s3DateFormat),
+		"X-Amz-Expires":       strconv.FormatInt(int64((expiresIn+time.Second-1)/time.Second), 10),
+		"X-Amz-SignedHeaders": "host",
+	}
+	u.RawQuery = canonicalQuery(query)
+	signature := s.sign(now, canonicalRequest(http.MethodGet, u, map[string]string{"host": u.Host}, unsignedPayload))
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// newRequest is a helper function that returns a request for the object of
+// the key carrying the body, signed in its Authorization header.
+func (s *s3Storage) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	u := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+
+	now := s.now().UTC()
+	payloadHash := sha256Hex(body)
+	headers := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(s3DateFormat),
+	}
+	signature := s.sign(now, canonicalRequest(method, u, headers, payloadHash))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", headers["x-amz-date"])
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKeyID, s.scope(now), signedHeaders(headers), signature))
+	return req, nil
+}
+
+// objectURL is a helper function that returns the URL of the object of the key.
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path += "/" + s.bucket + "/" + key
+	u.RawPath = "/" + escapeKey(strings.TrimPrefix(u.Path, "/"), uriEncode)
+	return &u
+}
+
+// scope is a helper function that returns the scope credentials are valid in at the given time.
+func (s *s3Storage) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+// sign is a helper function that returns the signature of the canonical request made at the given time.
+func (s *s3Storage) sign(t time.Time, canonicalRequest string) string {
+	stringToSign := s3Algorithm + "\n" + t.Format(s3DateFormat) + "\n" + s.scope(t) + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalRequest is a helper function that returns the canonical form of a
+// request the signature is computed over. The header names must be in lower case.
+func canonicalRequest(method string, u *url.URL, headers map[string]string, payloadHash string) string {
+	var b strings.Builder
+	b.WriteString(method + "\n")
+	b.WriteString(u.EscapedPath() + "\n")
+	b.WriteString(u.RawQuery + "\n")
+	names := sortedKeys(headers)
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	b.WriteString("\n" + strings.Join(names, ";") + "\n")
+	b.WriteString(payloadHash)
+	return b.String()
+}
+
+// canonicalQuery is a helper function that encodes the query parameters sorted
+// by name, the way signatures expect them.
+func canonicalQuery(query map[string]string) string {
+	var params []string
+	for _, name := range sortedKeys(query) {
+		params = append(params, uriEncode(name)+"="+uriEncode(query[name]))
+	}
+	return strings.Join(params, "&")
+}
+
+// signedHeaders is a helper function that lists the names of the signed headers.
+func signedHeaders(headers map[string]string) string {
+	return strings.Join(sortedKeys(headers), ";")
+}
+
+// uriEncode is a helper function that percent encodes all but the unreserved
+// characters of RFC 3986, the way signatures expect them.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Error is the error S3 responds with.
+type s3Error struct {
+	StatusCode int    `xml:"-"`
+	Status     string `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	if e.Code == "" {
+		return "s3 responded with " + e.Status
+	}
+	return fmt.Sprintf("s3 responded with %s: %s: %s", e.Status, e.Code, e.Message)
+}
+
+// noSuchKey tells whether the error is that of a missing object. Responses to
+// HEAD requests and those of some S3 compatible services come without a code.
+func (e *s3Error) noSuchKey() bool {
+	return e.StatusCode == http.StatusNotFound && (e.Code == "NoSuchKey" || e.Code == "")
+}
+
+// readS3Error is a helper function that reads the error S3 responded with.
+func readS3Error(resp *http.Response) *s3Error {
+	e := &s3Error{StatusCode: resp.StatusCode, Status: resp.Status}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err == nil && len(b) > 0 {
+		_ = xml.Unmarshal(b, e)
+	}
+	return e
+}
@@ -0,0 +1,67 @@
+/*
+Package storage contains definition and implementations of a service that
+keeps blobs, the images uploaded by users for example, under string keys.
+*/
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Blob is the content of a stored blob along with what's known of it.
+// It must be closed once read.
+type Blob struct {
+	io.ReadCloser
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+// Storage specifies the methods used to keep blobs.
+// Keys are slash separated paths relative to the root of the storage, see ValidKey.
+type Storage interface {
+	// Put stores what's read from r under the key, replacing any blob stored under it.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get returns the blob stored under the key or ErrBlobNotFound.
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Delete removes the blob stored under the key. Deleting a missing blob isn't an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL the blob can be fetched from without credentials
+	// for at least as long as expiresIn.
+	SignedURL(ctx context.Context, key string, expiresIn time.Duration) (string, error)
+}
+
+// ErrBlobNotFound is returned when the requested blob isn't found.
+var ErrBlobNotFound = fmt.Errorf("blob not found")
+
+// ErrInvalidKey is returned when the key passed isn't valid, see ValidKey.
+var ErrInvalidKey = fmt.Errorf("invalid key")
+
+// ValidKey tells whether the key can be used to store a blob. Keys are made of
+// non empty path segments separated by single slashes, none of which are . or ..
+// so that keys can't refer outside the storage.
+func ValidKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, "\\\x00") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeKey is a helper function that escapes the segments of the key for use
+// as the path of a URL, keeping the slashes between them.
+func escapeKey(key string, escape func(string) string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
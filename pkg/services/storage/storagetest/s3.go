@@ -0,0 +1,260 @@
+package storagetest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxClockSkew is how far the time requests are signed at may be from that of the stand-in.
+const maxClockSkew = 15 * time.Minute
+
+// S3StandIn is an in memory stand-in for an S3 compatible service, serving
+// path style requests over an httptest.Server. It implements the little of
+// the API the storage package uses, putting, getting and deleting objects,
+// and checks the AWS Signature Version 4 of requests and presigned URLs the
+// way S3 does so that mistakes in signing are caught without an account.
+type S3StandIn struct {
+	*httptest.Server
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*s3Object
+}
+
+type s3Object struct {
+	content      []byte
+	contentType  string
+	lastModified time.Time
+}
+
+// NewS3StandIn starts a stand-in holding the given empty buckets that accepts
+// requests signed with the given credentials for the given region.
+// It must be closed once done with.
+func NewS3StandIn(region, accessKeyID, secretAccessKey string, buckets ...string) *S3StandIn {
+	s := &S3StandIn{
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		buckets:         make(map[string]map[string]*s3Object),
+	}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = make(map[string]*s3Object)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *S3StandIn) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeS3Error(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if code, message := s.authenticate(r, body); code != "" {
+		writeS3Error(w, http.StatusForbidden, code, message)
+		return
+	}
+
+	bucketName, key := strings.TrimPrefix(r.URL.Path, "/"), ""
+	if i := strings.Index(bucketName, "/"); i >= 0 {
+		bucketName, key = bucketName[:i], bucketName[i+1:]
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	if key == "" {
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented", "Only requests for objects are implemented")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = "binary/octet-stream"
+		}
+		bucket[key] = &s3Object{content: body, contentType: contentType, lastModified: time.Now().UTC().Truncate(time.Second)}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		object, ok := bucket[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.content)))
+		w.Header().Set("Last-Modified", object.lastModified.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(object.content)
+		}
+	case http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	}
+}
+
+// authenticate checks the signature of the request, be it in its Authorization
+// header or its query. It returns the code and message of the error to respond
+// with if it's not valid.
+func (s *S3StandIn) authenticate(r *http.Request, body []byte) (code, message string) {
+	query := r.URL.Query()
+	var (
+		credential, signedHeaders, signature, amzDate, payloadHash string
+		expires                                                    time.Duration
+	)
+	if query.Get("X-Amz-Algorithm") != "" {
+		if query.Get("X-Amz-Algorithm") != "AWS4-HMAC-SHA256" || r.Method != http.MethodGet {
+			return "AccessDenied", "Only presigned GET requests signed with AWS4-HMAC-SHA256 are supported"
+		}
+		credential, signedHeaders, signature = query.Get("X-Amz-Credential"), query.Get("X-Amz-SignedHeaders"), query.Get("X-Amz-Signature")
+		amzDate, payloadHash = query.Get("X-Amz-Date"), "UNSIGNED-PAYLOAD"
+		seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil || seconds <= 0 || seconds > 7*24*60*60 {
+			return "AuthorizationQueryParametersError", "X-Amz-Expires must be between 1 and 604800 seconds"
+		}
+		expires = time.Duration(seconds) * time.Second
+		query.Del("X-Amz-Signature")
+	} else {
+		const prefix = "AWS4-HMAC-SHA256 "
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, prefix) {
+			return "AccessDenied", "Requests must be signed with AWS4-HMAC-SHA256"
+		}
+		for _, field := range strings.Split(strings.TrimPrefix(authorization, prefix), ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = value
+			case "Signature":
+				signature = value
+			}
+		}
+		amzDate, payloadHash = r.Header.Get("X-Amz-Date"), r.Header.Get("X-Amz-Content-Sha256")
+		if payloadHash != "UNSIGNED-PAYLOAD" && payloadHash != hexSHA256(body) {
+			return "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed."
+		}
+	}
+
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return "AccessDenied", "X-Amz-Date is missing or malformed"
+	}
+	scope := signedAt.Format("20060102") + "/" + s.Region + "/s3/aws4_request"
+	if credential != s.AccessKeyID+"/"+scope {
+		return "InvalidAccessKeyId", fmt.Sprintf("Credential %q isn't valid", credential)
+	}
+	now := time.Now()
+	if expires > 0 {
+		if now.Before(signedAt.Add(-maxClockSkew)) || now.After(signedAt.Add(expires)) {
+			return "AccessDenied", "Request has expired"
+		}
+	} else if now.Before(signedAt.Add(-maxClockSkew)) || now.After(signedAt.Add(maxClockSkew)) {
+		return "RequestTimeTooSkewed", "The difference between the request time and the current time is too large."
+	}
+
+	var canonical strings.Builder
+	canonical.WriteString(r.Method + "\n")
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+	canonical.WriteString(strings.Join(segments, "/") + "\n")
+	var params []string
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, awsURIEncode(name)+"="+awsURIEncode(value))
+		}
+	}
+	sort.Strings(params)
+	canonical.WriteString(strings.Join(params, "&") + "\n")
+	names := strings.Split(signedHeaders, ";")
+	if !sort.StringsAreSorted(names) || !contains(names, "host") {
+		return "AccessDenied", "SignedHeaders must be sorted and include host"
+	}
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonical.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonical.WriteString("\n" + signedHeaders + "\n" + payloadHash)
+
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonical.String()))
+	key := []byte("AWS4" + s.SecretAccessKey)
+	for _, part := range []string{signedAt.Format("20060102"), s.Region, "s3", "aws4_request"} {
+		key = hmacOf(key, part)
+	}
+	if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(hmacOf(key, stringToSign)))) {
+		return "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."
+	}
+	return "", ""
+}
+
+// writeS3Error is a helper function that responds with an error the way S3 does.
+func writeS3Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: message})
+}
+
+// awsURIEncode is a helper function that percent encodes all but the
+// unreserved characters of RFC 3986.
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacOf(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,232 @@
+/*
+Package storagetest holds the behaviour every implementation of the
+storage.Storage interface is expected to share, along with S3StandIn, an in
+memory stand-in for an S3 compatible service the S3 implementation can be
+checked against without an account.
+
+Like the checks of the conformance package they don't depend on the testing
+package and report through T, which *testing.T satisfies:
+
+	for _, check := range storagetest.Checks() {
+		t.Run(check.Name, func(t *testing.T) {
+			s, release, err := newStorage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+			check.Run(t, s)
+		})
+	}
+
+cmd/storagetest runs them against the implementations of the storage package.
+*/
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/slim-crown/issue-1-REST/pkg/services/storage"
+)
+
+// T is what checks report their failures to.
+// Fatalf is expected to stop the check, like it stops a test.
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Factory returns an empty storage for a check to run against along with a
+// function that releases it once it's done. The URLs the storage signs are
+// expected to be served as long as it isn't released.
+type Factory func() (s storage.Storage, release func(), err error)
+
+// Check is a single check of the behaviour of a storage.
+type Check struct {
+	Name string
+	Run  func(t T, s storage.Storage)
+}
+
+// ctx is the context the checks pass to the storage.
+var ctx = context.Background()
+
+// png is the start of a PNG image, enough for its content type to be sniffed.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// Checks returns all the checks.
+func Checks() []Check {
+	return checks
+}
+
+var checks = []Check{
+	{"put then get", func(t T, s storage.Storage) {
+		before := time.Now().Add(-time.Second)
+		put(t, s, "release.png", png)
+		blob, content := get(t, s, "release.png")
+		if !bytes.Equal(content, png) {
+			t.Errorf("Get is expected to return what was put, got %q", content)
+		}
+		if blob.ContentType != "image/png" {
+			t.Errorf("Get is expected to return the content type of the blob, got %q", blob.ContentType)
+		}
+		if blob.Size != int64(len(png)) {
+			t.Errorf("Get is expected to return the size of the blob, got %d", blob.Size)
+		}
+		if blob.LastModified.Before(before) || blob.LastModified.After(time.Now().Add(time.Second)) {
+			t.Errorf("Get is expected to return when the blob was put, got %v", blob.LastModified)
+		}
+	}},
+	{"put replaces", func(t T, s storage.Storage) {
+		put(t, s, "user.png", png)
+		put(t, s, "user.png", append(png, "second"...))
+		_, content := get(t, s, "user.png")
+		if !bytes.Equal(content, append(png, "second"...)) {
+			t.Errorf("Put is expected to replace the blob under the key, got %q", content)
+		}
+	}},
+	{"nested and escaped keys", func(t T, s storage.Storage) {
+		keys := []string{"channels/a b+c=d.png", "channels/é/%41.png", "channels/a"}
+		for i, key := range keys {
+			put(t, s, key, append(png, byte(i)))
+		}
+		for i, key := range keys {
+			_, content := get(t, s, key)
+			if !bytes.Equal(content, append(png, byte(i))) {
+				t.Errorf("Get of %q is expected to return the blob put under it, got %q", key, content)
+			}
+		}
+	}},
+	{"get missing", func(t T, s storage.Storage) {
+		_, err := s.Get(ctx, "missing.png")
+		expectErr(t, "Get of a missing blob", err, storage.ErrBlobNotFound)
+		put(t, s, "dir/present.png", png)
+		_, err = s.Get(ctx, "dir")
+		expectErr(t, "Get of the prefix of a key", err, storage.ErrBlobNotFound)
+	}},
+	{"delete", func(t T, s storage.Storage) {
+		put(t, s, "release.png", png)
+		put(t, s, "kept.png", png)
+		if err := s.Delete(ctx, "release.png"); err != nil {
+			t.Fatalf("Delete failed because of: %v", err)
+		}
+		_, err := s.Get(ctx, "release.png")
+		expectErr(t, "Get of a deleted blob", err, storage.ErrBlobNotFound)
+		if err = s.Delete(ctx, "release.png"); err != nil {
+			t.Errorf("Delete of a missing blob is expected to succeed, got %v", err)
+		}
+		get(t, s, "kept.png")
+	}},
+	{"invalid keys", func(t T, s storage.Storage) {
+		for _, key := range []string{"", "/release.png", "release.png/", "a//b", "../release.png", "a/./b", `a\b`} {
+			err := s.Put(ctx, key, bytes.NewReader(png), "image/png")
+			expectErr(t, fmt.Sprintf("Put under %q", key), err, storage.ErrInvalidKey)
+			_, err = s.Get(ctx, key)
+			expectErr(t, fmt.Sprintf("Get of %q", key), err, storage.ErrInvalidKey)
+			err = s.Delete(ctx, key)
+			expectErr(t, fmt.Sprintf("Delete of %q", key), err, storage.ErrInvalidKey)
+			_, err = s.SignedURL(ctx, key, time.Minute)
+			expectErr(t, fmt.Sprintf("SignedURL of %q", key), err, storage.ErrInvalidKey)
+		}
+	}},
+	{"signed url", func(t T, s storage.Storage) {
+		for _, key := range []string{"release.png", "channels/a b+c=d.png"} {
+			put(t, s, key, png)
+			signedURL, err := s.SignedURL(ctx, key, time.Minute)
+			if err != nil {
+				t.Fatalf("SignedURL failed because of: %v", err)
+			}
+			resp, err := http.Get(signedURL)
+			if err != nil {
+				t.Fatalf("getting %s failed because of: %v", signedURL, err)
+			}
+			content, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("reading %s failed because of: %v", signedURL, err)
+			}
+			if resp.StatusCode != http.StatusOK || !bytes.Equal(content, png) {
+				t.Errorf("the signed url of %q is expected to serve the blob, got %s %q", key, resp.Status, content)
+			}
+		}
+	}},
+}
+
+// put is a helper function that puts the content under the key as a PNG image.
+func put(t T, s storage.Storage, key string, content []byte) {
+	t.Helper()
+	if err := s.Put(ctx, key, bytes.NewReader(content), "image/png"); err != nil {
+		t.Fatalf("putting %q failed because of: %v", key, err)
+	}
+}
+
+// get is a helper function that gets the blob of the key and reads it.
+func get(t T, s storage.Storage, key string) (*storage.Blob, []byte) {
+	t.Helper()
+	blob, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("getting %q failed because of: %v", key, err)
+	}
+	defer blob.Close()
+	content, err := ioutil.ReadAll(blob)
+	if err != nil {
+		t.Fatalf("reading %q failed because of: %v", key, err)
+	}
+	return blob, content
+}
+
+// expectErr is a helper function that reports unless err is target.
+func expectErr(t T, call string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s is expected to fail with %q, got %v", call, target, err)
+	}
+}
+
+// Exec runs the check against a storage returned by newStorage and returns
+// the failures it reported. Panics are reported as failures too.
+func (c Check) Exec(newStorage Factory) []string {
+	s, release, err := newStorage()
+	if err != nil {
+		return []string{fmt.Sprintf("setting up storage failed because of: %v", err)}
+	}
+	defer release()
+
+	r := new(recorder)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if v := recover(); v != nil {
+				r.failures = append(r.failures, fmt.Sprintf("panicked: %v", v))
+			}
+		}()
+		c.Run(r, s)
+	}()
+	<-done
+	return r.failures
+}
+
+// recorder is the T checks are executed with outside of tests.
+// It's only used by the goroutine running the check.
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+// Fatalf stops the check by exiting the goroutine running it.
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
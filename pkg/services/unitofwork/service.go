@@ -0,0 +1,111 @@
+/*
+Package unitofwork contains definition of a service that groups calls to the
+repositories of the other services into units of work that take effect all
+together or not at all.
+
+The unit of work travels in the context. Repository calls made with the context
+given to the function ran by Do join it, whichever repository they're made on:
+
+	err := uow.Do(r.Context(), func(ctx context.Context) error {
+		rel, err := releaseService.AddRelease(ctx, rel)
+		if err != nil {
+			return err
+		}
+		unitofwork.OnRollback(ctx, func() { os.Remove(path) })
+		return saveImage(path)
+	})
+
+Work done outside of the repositories, like writing files, can't be rolled
+back along with them. OnRollback registers functions that undo it instead.
+*/
+package unitofwork
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UnitOfWork specifies a method to run functions as units of work.
+// Do runs fn with a context holding a new unit of work which is committed if
+// fn returns nil and rolled back otherwise, fn's error is returned as it is.
+// If ctx already holds a unit of work, fn joins it instead of starting another,
+// it's then committed or rolled back along with the rest of it.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Tx is the handle storage backends keep in a unit of work, their transaction.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// work is the state of a unit of work in progress.
+type work struct {
+	tx            Tx
+	lock          sync.Mutex
+	compensations []func()
+}
+
+type contextKey struct{}
+
+// Run is a helper function implementations of UnitOfWork use in Do once they've
+// begun tx. It runs fn with a context holding a unit of work around tx, which is
+// committed if fn succeeds and rolled back otherwise. The functions registered
+// with OnRollback are called when tx is rolled back or fails to commit.
+// A panic in fn rolls tx back before it's resumed.
+func Run(ctx context.Context, tx Tx, fn func(ctx context.Context) error) (err error) {
+	w := &work{tx: tx}
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		_ = tx.Rollback()
+		w.compensate()
+		if p := recover(); p != nil {
+			panic(p)
+		}
+	}()
+	if err = fn(context.WithValue(ctx, contextKey{}, w)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing unit of work failed because of: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// TxFromContext returns the handle of the unit of work held by ctx, if any.
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	w, ok := ctx.Value(contextKey{}).(*work)
+	if !ok {
+		return nil, false
+	}
+	return w.tx, true
+}
+
+// OnRollback registers fn to be called if the unit of work held by ctx is rolled
+// back. Functions are called in the reverse order they were registered in.
+// It does nothing if ctx holds no unit of work.
+func OnRollback(ctx context.Context, fn func()) {
+	w, ok := ctx.Value(contextKey{}).(*work)
+	if !ok {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.compensations = append(w.compensations, fn)
+}
+
+// compensate is a helper function that calls the functions registered with
+// OnRollback, last first.
+func (w *work) compensate() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for i := len(w.compensations) - 1; i >= 0; i-- {
+		w.compensations[i]()
+	}
+}
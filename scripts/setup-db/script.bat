@@ -21,6 +21,11 @@ for /f "delims=" %%a in ('chcp ^|find /c "932"') do @ SET CLIENTENCODING_JP=%%a
 if "%CLIENTENCODING_JP%"=="1" SET PGCLIENTENCODING=SJIS
 if "%CLIENTENCODING_JP%"=="1" SET /P PGCLIENTENCODING="Client Encoding [%PGCLIENTENCODING%]: "
 
+REM the schema is brought up by the server's migrate command, run from subscript.bat
+SET ISSUE1_DB_HOST=%server%
+SET ISSUE1_DB_PORT=%port%
+SET ISSUE1_DB_PASSWORD=password1234!@#$
+
 REM Run psql
 "C:\Program Files\PostgreSQL\12\bin\psql.exe" -h %server% -U %username% -d %database% -p %port% -f subscript.bat
 
@@ -20,3 +20,331 @@ CREATE SCHEMA "issue#1";
 ALTER DATABASE "issue#1_db" SET search_path = "issue#1";
 
 ALTER SCHEMA "issue#1" OWNER TO "issue#1_dev";
+
+--
+-- Name: citext; Type: EXTENSION; Schema: -; Owner: -
+--
+
+CREATE EXTENSION IF NOT EXISTS citext WITH SCHEMA "issue#1";
+
+
+--
+-- Name: EXTENSION citext; Type: COMMENT; Schema: -; Owner: 
+--
+
+COMMENT ON EXTENSION citext IS 'data type for case-insensitive character strings';
+
+
+--
+-- Name: FUNCTION citextin(cstring); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citextin(cstring) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citextout("issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citextout("issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citextrecv(internal); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citextrecv(internal) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citextsend("issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citextsend("issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext(boolean); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext(boolean) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext(character); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext(character) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext(inet); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext(inet) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_cmp("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_cmp("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_eq("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_eq("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_ge("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_ge("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_gt("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_gt("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_hash("issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_hash("issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_hash_extended("issue#1".citext, bigint); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_hash_extended("issue#1".citext, bigint) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_larger("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_larger("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_le("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_le("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_lt("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_lt("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_ne("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_ne("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_pattern_cmp("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_pattern_cmp("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_pattern_ge("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_pattern_ge("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_pattern_gt("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_pattern_gt("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_pattern_le("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_pattern_le("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_pattern_lt("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_pattern_lt("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION citext_smaller("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".citext_smaller("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_match("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_match("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_match("issue#1".citext, "issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_match("issue#1".citext, "issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_matches("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_matches("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_matches("issue#1".citext, "issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_matches("issue#1".citext, "issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_replace("issue#1".citext, "issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_replace("issue#1".citext, "issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_replace("issue#1".citext, "issue#1".citext, text, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_replace("issue#1".citext, "issue#1".citext, text, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_split_to_array("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_split_to_array("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_split_to_array("issue#1".citext, "issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_split_to_array("issue#1".citext, "issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_split_to_table("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_split_to_table("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION regexp_split_to_table("issue#1".citext, "issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".regexp_split_to_table("issue#1".citext, "issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION replace("issue#1".citext, "issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".replace("issue#1".citext, "issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION split_part("issue#1".citext, "issue#1".citext, integer); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".split_part("issue#1".citext, "issue#1".citext, integer) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION strpos("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".strpos("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticlike("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticlike("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticlike("issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticlike("issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticnlike("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticnlike("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticnlike("issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticnlike("issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticregexeq("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticregexeq("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticregexeq("issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticregexeq("issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticregexne("issue#1".citext, "issue#1".citext); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticregexne("issue#1".citext, "issue#1".citext) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION texticregexne("issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".texticregexne("issue#1".citext, text) TO "issue#1_REST";
+
+
+--
+-- Name: FUNCTION translate("issue#1".citext, "issue#1".citext, text); Type: ACL; Schema: issue#1; Owner: postgres
+--
+
+GRANT ALL ON FUNCTION "issue#1".translate("issue#1".citext, "issue#1".citext, text) TO "issue#1_REST";
@@ -0,0 +1,23 @@
+#!/bin/sh
+# Sets up the database from scratch on Linux, like script.bat does on Windows.
+# The roles, the database and the schema are created by psql as a superuser,
+# the tables by the server's migrate command and then the sample data is loaded.
+# It DROPs the issue#1_db database if it exists.
+set -e
+cd "$(dirname "$0")"
+
+: "${PGHOST:=localhost}"
+: "${PGPORT:=5432}"
+: "${PGUSER:=postgres}"
+export PGHOST PGPORT PGUSER
+
+export ISSUE1_DB_HOST="$PGHOST"
+export ISSUE1_DB_PORT="$PGPORT"
+export ISSUE1_DB_PASSWORD="${ISSUE1_DB_PASSWORD:-password1234!@#\$}"
+
+psql -d postgres -f setup-db.sql
+psql -d 'issue#1_db' -f setup-schema.sql
+go run ../../cmd/server migrate up
+if [ "$1" != "--no-sample-data" ]; then
+	psql -d 'issue#1_db' -f sample-data.sql
+fi
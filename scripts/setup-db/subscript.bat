@@ -1,6 +1,7 @@
 \ir setup-db.sql;
 \connect issue#1_db postgres
 \ir setup-schema.sql;
-\ir setup-tables.sql;
+\! go run ../../cmd/server migrate up
+\ir sample-data.sql;
 \c issue#1_db issue#1_dev
 \dt